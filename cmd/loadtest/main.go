@@ -0,0 +1,472 @@
+// Command loadtest opens many concurrent SSH sessions against a terminalpub
+// server, drives a scripted key sequence through each TUI and reports
+// latency percentiles, optionally with the server's CPU and memory use.
+//
+//	go run ./cmd/loadtest -addr localhost:2222 -sessions 200 -duration 1m
+//
+// Each key's latency is the time until the server sends the next output,
+// which is how long the user would wait to see the screen change.
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// outputTimeout is how long a key waits for the screen to change before it
+// counts as timed out
+const outputTimeout = 5 * time.Second
+
+// keyNames maps the names usable in -script to the bytes a terminal sends
+var keyNames = map[string]string{
+	"enter":     "\r",
+	"esc":       "\x1b",
+	"tab":       "\t",
+	"space":     " ",
+	"backspace": "\x7f",
+	"up":        "\x1b[A",
+	"down":      "\x1b[B",
+	"right":     "\x1b[C",
+	"left":      "\x1b[D",
+}
+
+// step is one entry of the script: keys to send, or a pause
+type step struct {
+	name  string
+	keys  string
+	pause time.Duration
+}
+
+// results collects the measurements of all sessions
+type results struct {
+	mu          sync.Mutex
+	connect     []time.Duration // SSH handshake
+	firstRender []time.Duration // Shell start to first output
+	keys        []time.Duration // Key sent to next output
+	timeouts    int             // Keys with no output within outputTimeout
+	failures    map[string]int  // Sessions that failed, by error
+	completed   int             // Sessions that ran until the end
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:2222", "SSH address of the server")
+	user := flag.String("user", "loadtest", "SSH user name")
+	sessions := flag.Int("sessions", 10, "concurrent sessions")
+	ramp := flag.Duration("ramp", 5*time.Second, "time over which sessions are opened")
+	duration := flag.Duration("duration", 30*time.Second, "how long each session repeats the script")
+	script := flag.String("script", "down,down,down,up,enter,esc,+1s", "comma-separated keys: names (enter, esc, tab, up, ...), ctrl+x, literal text, or +duration for a pause")
+	think := flag.Duration("think", 200*time.Millisecond, "pause after every key")
+	keyFile := flag.String("key", "", "private key of a registered account; by default every session uses a new key and stays anonymous")
+	pid := flag.Int("pid", 0, "PID of the server, to sample its CPU and memory (Linux, same host only)")
+	healthURL := flag.String("health", "", "health endpoint to read the database pool usage from when done, e.g. http://localhost/health")
+	flag.Parse()
+
+	steps, err := parseScript(*script)
+	if err != nil {
+		log.Fatalf("Invalid -script: %v", err)
+	}
+	var signer ssh.Signer
+	if *keyFile != "" {
+		pem, err := os.ReadFile(*keyFile)
+		if err != nil {
+			log.Fatalf("Failed to read key: %v", err)
+		}
+		if signer, err = ssh.ParsePrivateKey(pem); err != nil {
+			log.Fatalf("Failed to parse key: %v", err)
+		}
+	}
+
+	var sampler *processSampler
+	if *pid != 0 {
+		sampler = newProcessSampler(*pid)
+		go sampler.run(time.Second)
+	}
+
+	log.Printf("Opening %d sessions to %s over %s, each running for %s", *sessions, *addr, *ramp, *duration)
+	res := &results{failures: map[string]int{}}
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range *sessions {
+		if *sessions > 1 {
+			time.Sleep(*ramp / time.Duration(*sessions))
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sessionSigner := signer
+			if sessionSigner == nil {
+				var err error
+				if sessionSigner, err = newSigner(); err != nil {
+					res.fail(err)
+					return
+				}
+			}
+			if err := runSession(*addr, *user, sessionSigner, steps, *think, *duration, res); err != nil {
+				res.fail(fmt.Errorf("session %d: %w", i, err))
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	res.report(*sessions, elapsed)
+	if sampler != nil {
+		sampler.report()
+	}
+	if *healthURL != "" {
+		reportPool(*healthURL)
+	}
+}
+
+// parseScript reads the -script flag
+func parseScript(script string) ([]step, error) {
+	var steps []step
+	for _, token := range strings.Split(script, ",") {
+		token = strings.TrimSpace(token)
+		switch {
+		case token == "":
+		case strings.HasPrefix(token, "+"):
+			pause, err := time.ParseDuration(token[1:])
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step{name: token, pause: pause})
+		case keyNames[token] != "":
+			steps = append(steps, step{name: token, keys: keyNames[token]})
+		case strings.HasPrefix(token, "ctrl+") && len(token) == 6:
+			c := token[5]
+			if c < 'a' || c > 'z' {
+				return nil, fmt.Errorf("unknown key %q", token)
+			}
+			steps = append(steps, step{name: token, keys: string(rune(c - 'a' + 1))})
+		default:
+			steps = append(steps, step{name: token, keys: token})
+		}
+	}
+	if len(steps) == 0 {
+		return nil, errors.New("no steps")
+	}
+	return steps, nil
+}
+
+// newSigner generates a throwaway Ed25519 key for one session
+func newSigner() (ssh.Signer, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return ssh.NewSignerFromKey(private)
+}
+
+// runSession opens one session and repeats the script in it until duration
+// has passed
+func runSession(addr, user string, signer ssh.Signer, steps []step, think, duration time.Duration, res *results) error {
+	config := &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         30 * time.Second,
+	}
+
+	begin := time.Now()
+	client, err := ssh.Dial("tcp", addr, config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	res.add(&res.connect, time.Since(begin))
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	if err := session.RequestPty("xterm-256color", 40, 120, ssh.TerminalModes{ssh.ECHO: 0}); err != nil {
+		return err
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	// Every read from the server is a screen update
+	output := make(chan time.Time, 64)
+	go func() {
+		defer close(output)
+		buf := make([]byte, 32*1024)
+		for {
+			if _, err := stdout.Read(buf); err != nil {
+				return
+			}
+			select {
+			case output <- time.Now():
+			default:
+			}
+		}
+	}()
+
+	begin = time.Now()
+	if err := session.Shell(); err != nil {
+		return err
+	}
+	if err := waitOutput(output); err != nil {
+		return fmt.Errorf("no first screen: %w", err)
+	}
+	res.add(&res.firstRender, time.Since(begin))
+
+	deadline := time.Now().Add(duration)
+	for time.Now().Before(deadline) {
+		for _, s := range steps {
+			if s.pause > 0 {
+				time.Sleep(s.pause)
+				continue
+			}
+			drain(output)
+			sent := time.Now()
+			if _, err := io.WriteString(stdin, s.keys); err != nil {
+				return fmt.Errorf("failed to send %s: %w", s.name, err)
+			}
+			switch err := waitOutput(output); {
+			case errors.Is(err, io.EOF):
+				return errors.New("server closed the session")
+			case err != nil:
+				res.timeout()
+			default:
+				res.add(&res.keys, time.Since(sent))
+			}
+			time.Sleep(think)
+		}
+	}
+
+	res.mu.Lock()
+	res.completed++
+	res.mu.Unlock()
+	return nil
+}
+
+// waitOutput waits for the next screen update
+func waitOutput(output <-chan time.Time) error {
+	select {
+	case _, ok := <-output:
+		if !ok {
+			return io.EOF
+		}
+		return nil
+	case <-time.After(outputTimeout):
+		return errors.New("timed out")
+	}
+}
+
+// drain discards updates that arrived before a key was sent
+func drain(output <-chan time.Time) {
+	for {
+		select {
+		case <-output:
+		default:
+			return
+		}
+	}
+}
+
+// add records a measurement
+func (r *results) add(into *[]time.Duration, d time.Duration) {
+	r.mu.Lock()
+	*into = append(*into, d)
+	r.mu.Unlock()
+}
+
+// timeout records a key the screen did not react to
+func (r *results) timeout() {
+	r.mu.Lock()
+	r.timeouts++
+	r.mu.Unlock()
+}
+
+// fail records a failed session
+func (r *results) fail(err error) {
+	r.mu.Lock()
+	// Errors are grouped without the session number
+	message := err.Error()
+	if _, rest, ok := strings.Cut(message, ": "); ok && strings.HasPrefix(message, "session ") {
+		message = rest
+	}
+	r.failures[message]++
+	r.mu.Unlock()
+}
+
+// report prints the results
+func (r *results) report(sessions int, elapsed time.Duration) {
+	fmt.Printf("\n%d/%d sessions completed in %s\n\n", r.completed, sessions, elapsed.Round(time.Millisecond))
+	fmt.Printf("%-14s %7s %9s %9s %9s %9s\n", "", "count", "p50", "p90", "p99", "max")
+	for _, row := range []struct {
+		name   string
+		values []time.Duration
+	}{
+		{"connect", r.connect},
+		{"first screen", r.firstRender},
+		{"key", r.keys},
+	} {
+		if len(row.values) == 0 {
+			fmt.Printf("%-14s %7d\n", row.name, 0)
+			continue
+		}
+		slices.Sort(row.values)
+		fmt.Printf("%-14s %7d %9s %9s %9s %9s\n", row.name, len(row.values),
+			percentile(row.values, 50), percentile(row.values, 90), percentile(row.values, 99), row.values[len(row.values)-1].Round(time.Microsecond))
+	}
+	if keys := len(r.keys) + r.timeouts; keys > 0 {
+		fmt.Printf("\nThroughput: %.1f keys/s, %d timed out (no update within %s)\n", float64(len(r.keys))/elapsed.Seconds(), r.timeouts, outputTimeout)
+	}
+	if len(r.failures) > 0 {
+		fmt.Println("\nFailed sessions:")
+		for message, count := range r.failures {
+			fmt.Printf("  %4d  %s\n", count, message)
+		}
+	}
+}
+
+// percentile returns the p-th percentile of sorted values
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p+99)/100 - 1
+	return sorted[max(i, 0)].Round(time.Microsecond)
+}
+
+// processSampler samples the CPU and memory use of a local process from /proc
+type processSampler struct {
+	pid     int
+	mu      sync.Mutex
+	cpu     []float64 // Percent of one core, per interval
+	maxRSS  int64     // Kilobytes
+	lastCPU float64   // Seconds of CPU time at the last sample
+	lastAt  time.Time
+	err     error
+}
+
+// newProcessSampler creates a sampler for pid
+func newProcessSampler(pid int) *processSampler {
+	return &processSampler{pid: pid}
+}
+
+// run samples the process every interval until it can no longer be read
+func (p *processSampler) run(interval time.Duration) {
+	for {
+		cpu, err := p.cpuSeconds()
+		if err == nil {
+			var rss int64
+			if rss, err = p.rss(); err == nil {
+				now := time.Now()
+				p.mu.Lock()
+				if !p.lastAt.IsZero() {
+					p.cpu = append(p.cpu, 100*(cpu-p.lastCPU)/now.Sub(p.lastAt).Seconds())
+				}
+				p.lastCPU, p.lastAt = cpu, now
+				p.maxRSS = max(p.maxRSS, rss)
+				p.mu.Unlock()
+			}
+		}
+		if err != nil {
+			p.mu.Lock()
+			p.err = err
+			p.mu.Unlock()
+			return
+		}
+		time.Sleep(interval)
+	}
+}
+
+// cpuSeconds returns the CPU time the process has used
+func (p *processSampler) cpuSeconds() (float64, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", p.pid))
+	if err != nil {
+		return 0, err
+	}
+	// Fields after the parenthesized command name; utime and stime are the
+	// 14th and 15th fields of the whole line
+	_, rest, ok := strings.Cut(string(stat), ") ")
+	fields := strings.Fields(rest)
+	if !ok || len(fields) < 13 {
+		return 0, errors.New("unexpected /proc stat format")
+	}
+	utime, _ := strconv.ParseFloat(fields[11], 64)
+	stime, _ := strconv.ParseFloat(fields[12], 64)
+	// Linux reports clock ticks, which are 1/100 s on every common platform
+	return (utime + stime) / 100, nil
+}
+
+// rss returns the resident memory of the process in kilobytes
+func (p *processSampler) rss() (int64, error) {
+	status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", p.pid))
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		if value, ok := strings.CutPrefix(line, "VmRSS:"); ok {
+			return strconv.ParseInt(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+		}
+	}
+	return 0, errors.New("no VmRSS in /proc status")
+}
+
+// report prints the sampled usage
+func (p *processSampler) report() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Printf("\nServer process %d:\n", p.pid)
+	if len(p.cpu) == 0 {
+		fmt.Printf("  no samples (%v)\n", p.err)
+		return
+	}
+	var sum float64
+	for _, c := range p.cpu {
+		sum += c
+	}
+	fmt.Printf("  CPU: %.0f%% average, %.0f%% peak (100%% = one core)\n", sum/float64(len(p.cpu)), slices.Max(p.cpu))
+	fmt.Printf("  Memory: %.1f MB peak RSS\n", float64(p.maxRSS)/1024)
+}
+
+// reportPool prints the database pool usage from the health endpoint
+func reportPool(healthURL string) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(healthURL)
+	if err != nil {
+		fmt.Printf("\nFailed to read %s: %v\n", healthURL, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	var health struct {
+		Pool map[string]any `json:"pool"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil || health.Pool == nil {
+		fmt.Printf("\nNo pool statistics at %s\n", healthURL)
+		return
+	}
+	fmt.Println("\nDatabase pool:")
+	keys := make([]string, 0, len(health.Pool))
+	for key := range health.Pool {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fmt.Printf("  %-22s %v\n", key, health.Pool[key])
+	}
+}
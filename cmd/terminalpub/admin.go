@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/db"
+	"github.com/fulgidus/terminalpub/internal/outbound"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// adminUsage lists the admin subcommands
+const adminUsage = `admin <command>
+
+Commands:
+  promote <username>              Make a user an instance admin
+  demote <username>               Make an admin a regular user again
+  user list [-json]               List local users
+  user suspend <username>         Lock a user out; unsuspend to undo
+  user delete <username>          Delete an account and tell its followers
+  token list [-json] <username>   List a user's API tokens
+  token revoke <username> <id>    Revoke one of a user's API tokens
+  domain block <domain> [reason]  Refuse every actor on a server; unblock to undo
+  domain list [-json]             List blocked domains
+  stats [-json]                   Count users, posts, follows and backlog`
+
+// adminTimeout bounds one admin command
+const adminTimeout = time.Minute
+
+// deliveryTimeout bounds telling followers about a deleted account
+const deliveryTimeout = 10 * time.Minute
+
+// adminCommand runs an admin action against the database directly, for
+// provisioning, cron jobs and instances with no admin yet
+func adminCommand(args []string) error {
+	if len(args) == 0 {
+		return errUsage(adminUsage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), adminTimeout)
+	defer cancel()
+	cfg, database, err := connect()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+	admin := services.NewAdminService(database.Postgres, database.Redis, cfg)
+
+	switch args[0] {
+	case "promote", "demote":
+		if len(args) != 2 {
+			return errUsage("admin " + args[0] + " <username>")
+		}
+		promote := args[0] == "promote"
+		if err := admin.SetAdmin(ctx, args[1], promote); err != nil {
+			return err
+		}
+		if promote {
+			fmt.Printf("%s is now an admin\n", args[1])
+		} else {
+			fmt.Printf("%s is no longer an admin\n", args[1])
+		}
+	case "user":
+		return adminUser(ctx, admin, database, cfg, args[1:])
+	case "token":
+		return adminToken(ctx, admin, args[1:])
+	case "domain":
+		return adminDomain(ctx, admin, args[1:])
+	case "stats":
+		asJSON, rest := listFlags("stats", args[1:])
+		if len(rest) != 0 {
+			return errUsage("admin stats [-json]")
+		}
+		stats, err := admin.Stats(ctx)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			return printJSON(stats)
+		}
+		w := newTable()
+		fmt.Fprintf(w, "Users\t%d\n", stats.Users)
+		fmt.Fprintf(w, "Admins\t%d\n", stats.Admins)
+		fmt.Fprintf(w, "Suspended users\t%d\n", stats.SuspendedUsers)
+		fmt.Fprintf(w, "Posts\t%d\n", stats.Posts)
+		fmt.Fprintf(w, "Followers\t%d\n", stats.Followers)
+		fmt.Fprintf(w, "Following\t%d\n", stats.Following)
+		fmt.Fprintf(w, "Remote actors\t%d\n", stats.RemoteActors)
+		fmt.Fprintf(w, "Pending delivery\t%d\n", stats.PendingDelivery)
+		fmt.Fprintf(w, "Quarantined\t%d\n", stats.Quarantined)
+		fmt.Fprintf(w, "Rejected actors\t%d\n", stats.RejectedActors)
+		fmt.Fprintf(w, "Blocked domains\t%d\n", stats.BlockedDomains)
+		return w.Flush()
+	default:
+		return errUsage(adminUsage)
+	}
+	return nil
+}
+
+// adminUser runs "admin user"
+func adminUser(ctx context.Context, admin *services.AdminService, database *db.DB, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errUsage(adminUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		asJSON, rest := listFlags("user list", args[1:])
+		if len(rest) != 0 {
+			return errUsage("admin user list [-json]")
+		}
+		users, err := admin.Users(ctx)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			return printJSON(users)
+		}
+		w := newTable()
+		fmt.Fprintln(w, "ID\tUSERNAME\tMASTODON\tCREATED\tFLAGS")
+		for _, user := range users {
+			var flags []string
+			if user.Admin {
+				flags = append(flags, "admin")
+			}
+			if user.Suspended {
+				flags = append(flags, "suspended")
+			}
+			if user.Silenced {
+				flags = append(flags, "silenced")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", user.ID, user.Username, user.MastodonAcct,
+				user.CreatedAt.Format("2006-01-02"), strings.Join(flags, ","))
+		}
+		return w.Flush()
+	case "suspend", "unsuspend":
+		if len(args) != 2 {
+			return errUsage("admin user " + args[0] + " <username>")
+		}
+		if args[0] == "suspend" {
+			if err := admin.Suspend(ctx, args[1]); err != nil {
+				return err
+			}
+			fmt.Printf("%s is suspended\n", args[1])
+		} else {
+			if err := admin.Unsuspend(ctx, args[1]); err != nil {
+				return err
+			}
+			fmt.Printf("%s is no longer suspended\n", args[1])
+		}
+	case "delete":
+		if len(args) != 2 {
+			return errUsage("admin user delete <username>")
+		}
+		userID, err := admin.DeleteUser(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s is deleted; telling their followers\n", args[1])
+
+		deliverCtx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		defer cancel()
+		if err := services.NewAccountService(database.Postgres, database.Redis, cfg).DeliverAccountDeletion(deliverCtx, userID); err != nil {
+			return fmt.Errorf("account deleted, but failed to tell its followers: %w", err)
+		}
+	default:
+		return errUsage(adminUsage)
+	}
+	return nil
+}
+
+// adminToken runs "admin token"
+func adminToken(ctx context.Context, admin *services.AdminService, args []string) error {
+	if len(args) == 0 {
+		return errUsage(adminUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		asJSON, rest := listFlags("token list", args[1:])
+		if len(rest) != 1 {
+			return errUsage("admin token list [-json] <username>")
+		}
+		tokens, err := admin.Tokens(ctx, rest[0])
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			return printJSON(tokens)
+		}
+		w := newTable()
+		fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tLAST USED")
+		for _, token := range tokens {
+			lastUsed := "never"
+			if token.LastUsedAt != nil {
+				lastUsed = token.LastUsedAt.Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s...\t%s\t%s\n", token.ID, token.Name, token.TokenPrefix, token.Scopes, lastUsed)
+		}
+		return w.Flush()
+	case "revoke":
+		if len(args) != 3 {
+			return errUsage("admin token revoke <username> <id>")
+		}
+		tokenID, err := strconv.Atoi(args[2])
+		if err != nil {
+			return errUsage("admin token revoke <username> <id>")
+		}
+		if err := admin.RevokeToken(ctx, args[1], tokenID); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked API token %d of %s\n", tokenID, args[1])
+	default:
+		return errUsage(adminUsage)
+	}
+	return nil
+}
+
+// adminDomain runs "admin domain"
+func adminDomain(ctx context.Context, admin *services.AdminService, args []string) error {
+	if len(args) == 0 {
+		return errUsage(adminUsage)
+	}
+
+	switch args[0] {
+	case "block":
+		if len(args) < 2 {
+			return errUsage("admin domain block <domain> [reason]")
+		}
+		domain, err := admin.BlockDomain(ctx, args[1], strings.Join(args[2:], " "))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Blocked %s and its subdomains\n", domain)
+	case "unblock":
+		if len(args) != 2 {
+			return errUsage("admin domain unblock <domain>")
+		}
+		if err := admin.UnblockDomain(ctx, args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Unblocked %s\n", args[1])
+	case "list":
+		asJSON, rest := listFlags("domain list", args[1:])
+		if len(rest) != 0 {
+			return errUsage("admin domain list [-json]")
+		}
+		domains, err := admin.BlockedDomains(ctx)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			return printJSON(domains)
+		}
+		w := newTable()
+		fmt.Fprintln(w, "DOMAIN\tBLOCKED\tREASON")
+		for _, domain := range domains {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", domain.Domain, domain.CreatedAt.Format("2006-01-02"), domain.Reason)
+		}
+		return w.Flush()
+	default:
+		return errUsage(adminUsage)
+	}
+	return nil
+}
+
+// listFlags parses the -json flag of a listing command and returns the
+// remaining arguments
+func listFlags(name string, args []string) (bool, []string) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Print JSON for scripts")
+	flags.Parse(args)
+	return *asJSON, flags.Args()
+}
+
+// printJSON writes v to standard output as indented JSON
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// newTable starts tab-separated output aligned into columns
+func newTable() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+}
+
+// connect loads the configuration and connects to the databases it names
+func connect() (*config.Config, *db.DB, error) {
+	cfg := config.LoadOrDefault(configPath)
+	if err := outbound.Configure(cfg); err != nil {
+		return nil, nil, err
+	}
+	database, err := db.Connect(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to databases: %w", err)
+	}
+	return cfg, database, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// backupCommand writes a backup archive of the database and host keys
+func backupCommand(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := flags.String("o", "", "Archive to write, - for standard output (default terminalpub-backup-<time>.tar.gz)")
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		return errUsage("backup [-o file]")
+	}
+	if *output == "" {
+		*output = "terminalpub-backup-" + time.Now().Format("20060102-150405") + ".tar.gz"
+	}
+
+	cfg, database, err := connect()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	var hostKeys []string
+	for _, keyPath := range []string{hostKeyPath, hostKeyPath + ".pub"} {
+		if _, err := os.Stat(keyPath); err == nil {
+			hostKeys = append(hostKeys, keyPath)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s not found; the backup has no host key\n", keyPath)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		// The archive holds password hashes and sealed keys
+		file, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to create archive: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	manifest, err := services.NewBackupService(database.Postgres, cfg).Backup(context.Background(), w, hostKeys)
+	if err != nil {
+		if *output != "-" {
+			os.Remove(*output)
+		}
+		return err
+	}
+
+	out := os.Stdout
+	if *output == "-" {
+		out = os.Stderr
+	}
+	fmt.Fprintf(out, "Backed up migration %d to %s:\n", manifest.Migration, *output)
+	for _, name := range slices.Sorted(maps.Keys(manifest.Tables)) {
+		fmt.Fprintf(out, "  %-16s %d rows\n", name, manifest.Tables[name])
+	}
+	for _, name := range manifest.HostKeys {
+		fmt.Fprintf(out, "  %-16s host key\n", name)
+	}
+	return nil
+}
+
+// restoreCommand puts back the contents of a backup archive
+func restoreCommand(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	only := flags.String("only", "", "Comma-separated sections to restore: "+strings.Join(services.BackupSections(), ", "))
+	dryRun := flags.Bool("dry-run", false, "Report what would be restored without changing anything")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errUsage("restore [-only sections] [-dry-run] <archive>")
+	}
+
+	opts := services.RestoreOptions{DryRun: *dryRun}
+	if *only != "" {
+		for _, section := range strings.Split(*only, ",") {
+			opts.Sections = append(opts.Sections, strings.TrimSpace(section))
+		}
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	cfg, database, err := connect()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	result, err := services.NewBackupService(database.Postgres, cfg).Restore(context.Background(), file, opts)
+	if err != nil {
+		return err
+	}
+
+	verb := "Restored"
+	if *dryRun {
+		verb = "Would restore"
+	}
+	fmt.Printf("%s from the backup of %s (terminalpub %s):\n", verb, result.Manifest.CreatedAt.Local().Format("2006-01-02 15:04"), result.Manifest.Version)
+	for _, table := range result.Tables {
+		fmt.Printf("  %-16s %d rows, %d already there\n", table.Name, table.Restored, table.Skipped)
+	}
+	for _, name := range slices.Sorted(maps.Keys(result.HostKeys)) {
+		status, err := restoreHostKey(name, result.HostKeys[name], *dryRun)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  %-16s %s\n", name, status)
+	}
+	return nil
+}
+
+// restoreHostKey writes a host key from a backup next to hostKeyPath,
+// moving a different key already there aside like keygen -force does
+func restoreHostKey(name string, data []byte, dryRun bool) (string, error) {
+	keyPath := filepath.Join(filepath.Dir(hostKeyPath), name)
+	existing, err := os.ReadFile(keyPath)
+	switch {
+	case err == nil && bytes.Equal(existing, data):
+		return "host key unchanged", nil
+	case dryRun && err == nil:
+		return "host key would replace the current one", nil
+	case dryRun:
+		return "host key", nil
+	case err == nil:
+		if err := os.Rename(keyPath, keyPath+".old"); err != nil {
+			return "", fmt.Errorf("failed to move the current host key aside: %w", err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return "", fmt.Errorf("failed to read host key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create host key directory: %w", err)
+	}
+	if err := os.WriteFile(keyPath, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write host key: %w", err)
+	}
+	if existing != nil {
+		return "host key, the previous one moved to " + name + ".old", nil
+	}
+	return "host key", nil
+}
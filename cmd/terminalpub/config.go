@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secrets in printed configuration
+const redacted = "REDACTED"
+
+// configCommand prints the configuration serve would use
+func configCommand(args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errUsage("config print")
+	}
+
+	cfg, err := config.Load(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "%s not found; these are the defaults\n", configPath)
+		cfg = config.DefaultConfig()
+	} else if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := doc.Encode(cfg); err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	redactSecrets(&doc)
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	defer enc.Close()
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to print configuration: %w", err)
+	}
+	return nil
+}
+
+// redactSecrets blanks the passwords, keys, DSNs and URL credentials under
+// node, so the output can be pasted into a bug report
+func redactSecrets(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind != yaml.ScalarNode || value.Value == "" {
+				continue
+			}
+			if isSecret(key.Value) {
+				value.Value, value.Tag, value.Style = redacted, "!!str", 0
+			} else if u, err := url.Parse(value.Value); err == nil && u.User != nil {
+				// e.g. a proxy with credentials
+				if _, ok := u.User.Password(); ok {
+					u.User = url.UserPassword(u.User.Username(), redacted)
+					value.Value = u.String()
+				}
+			}
+		}
+	}
+	for _, child := range node.Content {
+		redactSecrets(child)
+	}
+}
+
+// isSecret reports whether a configuration key holds a secret
+func isSecret(key string) bool {
+	return strings.Contains(key, "password") || strings.Contains(key, "secret") || key == "dsn"
+}
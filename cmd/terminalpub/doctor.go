@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/db"
+	"github.com/fulgidus/terminalpub/internal/outbound"
+	"github.com/golang-migrate/migrate/v4"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// checkResult is the outcome of one of doctor's checks
+type checkResult int
+
+const (
+	checkOK checkResult = iota
+	checkWarning
+	checkFailed
+)
+
+// checkMarks prefix each check in doctor's report
+var checkMarks = map[checkResult]string{checkOK: "ok  ", checkWarning: "warn", checkFailed: "FAIL"}
+
+// doctor checks that the server is set up to start: configuration,
+// databases, migrations and host key, with a hint for each problem
+func doctor(args []string) error {
+	if len(args) != 0 {
+		return errUsage("doctor")
+	}
+
+	failed := 0
+	report := func(result checkResult, name, detail string) {
+		fmt.Printf("[%s] %-14s %s\n", checkMarks[result], name, detail)
+		if result == checkFailed {
+			failed++
+		}
+	}
+
+	cfg, err := config.Load(configPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		report(checkWarning, "Configuration", configPath+" not found, using the defaults; copy config/config.example.yaml")
+		cfg = config.DefaultConfig()
+	case err != nil:
+		report(checkFailed, "Configuration", err.Error())
+		cfg = config.DefaultConfig()
+	default:
+		report(checkOK, "Configuration", configPath)
+	}
+
+	if err := outbound.Configure(cfg); err != nil {
+		report(checkFailed, "Outbound", err.Error())
+	} else {
+		report(checkOK, "Outbound", "proxy and denied networks are valid")
+	}
+
+	if cfg.Security.SecretKey == "" {
+		report(checkWarning, "Secret key", "security.secret_key is empty; users cannot link other services")
+	} else {
+		report(checkOK, "Secret key", "set")
+	}
+
+	if strings.HasPrefix(cfg.Server.BaseURL, "https://") {
+		report(checkOK, "Base URL", cfg.Server.BaseURL)
+	} else {
+		report(checkWarning, "Base URL", cfg.Server.BaseURL+" is not https; other servers may refuse to federate")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if database, err := db.Connect(cfg); err != nil {
+		report(checkFailed, "Databases", err.Error())
+	} else {
+		if err := database.Health(ctx); err != nil {
+			report(checkFailed, "Databases", err.Error())
+		} else {
+			report(checkOK, "Databases", "PostgreSQL and Redis answer")
+		}
+		database.Close()
+		checkMigrations(cfg, report)
+	}
+
+	if _, err := os.Stat(hostKeyPath); errors.Is(err, os.ErrNotExist) {
+		report(checkWarning, "Host key", hostKeyPath+" is missing; it is created on first start, or run terminalpub keygen")
+	} else if key, err := loadHostKey(); err != nil {
+		report(checkFailed, "Host key", err.Error())
+	} else {
+		report(checkOK, "Host key", hostKeyPath+" "+gossh.FingerprintSHA256(key))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// checkMigrations reports whether the database schema is up to date
+func checkMigrations(cfg *config.Config, report func(checkResult, string, string)) {
+	latest, err := latestMigration()
+	if err != nil {
+		report(checkFailed, "Migrations", err.Error())
+		return
+	}
+	m, err := newMigrate(cfg)
+	if err != nil {
+		report(checkFailed, "Migrations", err.Error())
+		return
+	}
+	defer m.Close()
+
+	current, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		report(checkFailed, "Migrations", "none applied; run terminalpub migrate up")
+	case err != nil:
+		report(checkFailed, "Migrations", err.Error())
+	case dirty:
+		report(checkFailed, "Migrations", fmt.Sprintf("migration %d failed halfway; fix it by hand, then force the version", current))
+	case current < latest:
+		report(checkFailed, "Migrations", fmt.Sprintf("at %d of %d; run terminalpub migrate up", current, latest))
+	default:
+		report(checkOK, "Migrations", fmt.Sprintf("at %d, the latest", current))
+	}
+}
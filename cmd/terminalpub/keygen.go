@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/keygen"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// keygenCommand creates the SSH host key serve uses. An existing key is kept
+// unless -force is given, since replacing it makes every client warn that
+// the server changed; the old key is then moved aside rather than deleted.
+func keygenCommand(args []string) error {
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	force := flags.Bool("force", false, "Replace an existing host key")
+	flags.Parse(args)
+
+	if _, err := os.Stat(hostKeyPath); err == nil {
+		if !*force {
+			key, err := loadHostKey()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Host key %s already exists: %s\n", hostKeyPath, gossh.FingerprintSHA256(key))
+			fmt.Println("Use -force to replace it; clients that connected before will warn that the key changed.")
+			return nil
+		}
+		for _, path := range []string{hostKeyPath, hostKeyPath + ".pub"} {
+			if err := os.Rename(path, path+".old"); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to move the old host key aside: %w", err)
+			}
+		}
+	}
+
+	pair, err := keygen.New(hostKeyPath, keygen.WithKeyType(keygen.Ed25519), keygen.WithWrite())
+	if err != nil {
+		return fmt.Errorf("failed to create host key: %w", err)
+	}
+	fmt.Printf("Created host key %s: %s\n", hostKeyPath, gossh.FingerprintSHA256(pair.PublicKey()))
+	return nil
+}
+
+// loadHostKey reads the public half of the host key at hostKeyPath
+func loadHostKey() (gossh.PublicKey, error) {
+	pair, err := keygen.New(hostKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read host key %s: %w", hostKeyPath, err)
+	}
+	return pair.PublicKey(), nil
+}
@@ -0,0 +1,108 @@
+// Command terminalpub runs the terminalpub server and the tools operators
+// use to set it up and look after it. Run "terminalpub help" for the list.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fulgidus/terminalpub/internal/version"
+)
+
+// configPath is the configuration file every subcommand reads
+var configPath string
+
+// command is a subcommand of the terminalpub binary
+type command struct {
+	name    string
+	args    string // Arguments, as the help lists them
+	summary string
+	run     func(args []string) error
+}
+
+// commands lists the subcommands in the order the help shows them
+var commands []command
+
+func init() {
+	// Assigned here because help refers back to the list
+	commands = []command{
+		{"serve", "[-demo]", "Run the SSH and HTTP servers (the default)", serve},
+		{"migrate", "up|down|version", "Apply or roll back database migrations", migrateCommand},
+		{"admin", "<command>", "Manage users and the instance without the TUI", adminCommand},
+		{"backup", "[-o file]", "Write a backup of users, keys, tokens, posts, follows and host keys", backupCommand},
+		{"restore", "[flags] <archive>", "Restore a backup, all of it or -only some sections", restoreCommand},
+		{"doctor", "", "Check the configuration, databases, migrations and host key", doctor},
+		{"keygen", "[-force]", "Create the SSH host key", keygenCommand},
+		{"config", "print", "Print the configuration in effect, secrets redacted", configCommand},
+		{"version", "", "Print the version", func([]string) error {
+			fmt.Println(version.String())
+			return nil
+		}},
+		{"help", "", "Show this help", func([]string) error {
+			usage()
+			return nil
+		}},
+	}
+}
+
+// errUsage reports a command used wrongly, holding how to use it
+type errUsage string
+
+// Error implements error
+func (e errUsage) Error() string {
+	return "usage: terminalpub " + string(e)
+}
+
+func main() {
+	flags := flag.NewFlagSet("terminalpub", flag.ExitOnError)
+	flags.StringVar(&configPath, "config", "config/config.yaml", "Configuration file")
+	showVersion := flags.Bool("version", false, "Print the version and exit")
+	flags.Usage = usage
+	flags.Parse(os.Args[1:])
+	if *showVersion {
+		fmt.Println(version.String())
+		return
+	}
+
+	args := flags.Args()
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if err := cmd.run(args); err != nil {
+			if errors.As(err, new(errUsage)) {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			fmt.Fprintf(os.Stderr, "terminalpub %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "terminalpub: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+// usage prints the subcommands and global flags
+func usage() {
+	out := os.Stderr
+	fmt.Fprintln(out, "Usage: terminalpub [-config file] <command> [arguments]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-26s %s\n", strings.TrimSpace(cmd.name+" "+cmd.args), cmd.summary)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Flags:")
+	fmt.Fprintln(out, "  -config file   Configuration file (default config/config.yaml)")
+	fmt.Fprintln(out, "  -version       Print the version and exit")
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// migrationsDir holds the SQL migrations, relative to the working directory
+const migrationsDir = "migrations"
+
+// migrateCommand applies or rolls back database migrations
+func migrateCommand(args []string) error {
+	if len(args) != 1 {
+		return errUsage("migrate up|down|version")
+	}
+	cfg := config.LoadOrDefault(configPath)
+
+	m, err := newMigrate(cfg)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		fmt.Println("Running migrations...")
+		if err := m.Up(); err != nil {
+			if errors.Is(err, migrate.ErrNoChange) {
+				fmt.Println("No migrations to run")
+				return nil
+			}
+			return fmt.Errorf("migration failed: %w", err)
+		}
+		fmt.Println("Migrations completed successfully!")
+
+	case "down":
+		fmt.Println("Rolling back last migration...")
+		if err := m.Steps(-1); err != nil {
+			if errors.Is(err, migrate.ErrNoChange) {
+				fmt.Println("No migrations to rollback")
+				return nil
+			}
+			return fmt.Errorf("rollback failed: %w", err)
+		}
+		fmt.Println("Rollback completed successfully!")
+
+	case "version":
+		schema, dirty, err := m.Version()
+		if err != nil {
+			if errors.Is(err, migrate.ErrNilVersion) {
+				fmt.Println("No migrations have been run yet")
+				return nil
+			}
+			return fmt.Errorf("failed to get version: %w", err)
+		}
+		fmt.Printf("Current version: %d", schema)
+		if dirty {
+			fmt.Println(" (dirty)")
+		} else {
+			fmt.Println()
+		}
+
+	default:
+		return errUsage("migrate up|down|version")
+	}
+	return nil
+}
+
+// newMigrate prepares the migrations in migrationsDir for the configured
+// database
+func newMigrate(cfg *config.Config) (*migrate.Migrate, error) {
+	pg := cfg.Database.Postgres
+	dbURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(pg.User, pg.Password),
+		Host:     fmt.Sprintf("%s:%d", pg.Host, pg.Port),
+		Path:     "/" + pg.Database,
+		RawQuery: "sslmode=" + url.QueryEscape(pg.SSLMode),
+	}
+	m, err := migrate.New("file://"+migrationsDir, dbURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	return m, nil
+}
+
+// latestMigration returns the number of the newest migration in
+// migrationsDir
+func latestMigration() (uint, error) {
+	entries, err := os.ReadDir(migrationsDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	var latest uint
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+		prefix, _, _ := strings.Cut(name, "_")
+		n, err := strconv.ParseUint(prefix, 10, 64)
+		if err == nil && uint(n) > latest {
+			latest = uint(n)
+		}
+	}
+	return latest, nil
+}
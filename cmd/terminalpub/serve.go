@@ -0,0 +1,380 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/bubbletea"
+	"github.com/charmbracelet/wish/logging"
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/db"
+	"github.com/fulgidus/terminalpub/internal/events"
+	"github.com/fulgidus/terminalpub/internal/handlers"
+	"github.com/fulgidus/terminalpub/internal/outbound"
+	"github.com/fulgidus/terminalpub/internal/reporting"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/fulgidus/terminalpub/internal/ui"
+	"github.com/fulgidus/terminalpub/internal/version"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// hostKeyPath is where the SSH server's host key is kept, created on first
+// start or with keygen
+const hostKeyPath = ".ssh/term_ed25519"
+
+// serve runs the SSH and HTTP servers until interrupted
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	demo := flags.Bool("demo", false, "Serve made-up timelines, notifications and profiles without PostgreSQL, Redis or Mastodon")
+	flags.Parse(args)
+	log.Println(version.String())
+
+	// Load configuration
+	cfg := config.LoadOrDefault(configPath)
+	log.Printf("Loaded configuration for domain: %s", cfg.Server.Domain)
+	if err := outbound.Configure(cfg); err != nil {
+		log.Fatalf("Invalid outbound configuration: %v", err)
+	}
+	if err := reporting.Configure(cfg); err != nil {
+		log.Fatalf("Invalid reporting configuration: %v", err)
+	}
+
+	// Connect to databases (optional for now, can fail gracefully)
+	var database *db.DB
+	var err error
+	if *demo {
+		appCtx, err = ui.NewDemoContext(cfg)
+		if err != nil {
+			log.Fatalf("Failed to load the demo: %v", err)
+		}
+		log.Println("Demo mode: sessions see canned data, no databases or Mastodon are used")
+	} else if database, err = db.Connect(cfg); err != nil {
+		log.Printf("Warning: Failed to connect to databases: %v", err)
+		log.Printf("SSH server will run without database support")
+	} else {
+		defer database.Close()
+		log.Println("Connected to PostgreSQL and Redis")
+		go database.MonitorPool(context.Background(), time.Minute)
+		go database.MonitorReplicas(context.Background(), 5*time.Second)
+		services.HandleEvents(events.Default, database.Postgres, cfg)
+
+		// Initialize app context for TUI
+		initAppContext(cfg, database)
+	}
+
+	// Setup HTTP server
+	httpServer := setupHTTPServer(cfg, database)
+	go func() {
+		addr := fmt.Sprintf(":%s", cfg.Server.HTTPPort)
+		log.Printf("Starting HTTP server on %s", addr)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("HTTP server error: %v", err)
+		}
+	}()
+
+	// Setup SSH server
+	// Note: Public key authentication is REQUIRED
+	// Users must have an SSH key pair to connect
+	sshServer, err := wish.NewServer(
+		wish.WithAddress(fmt.Sprintf("0.0.0.0:%s", cfg.Server.SSHPort)),
+		wish.WithHostKeyPath(hostKeyPath),
+		wish.WithPublicKeyAuth(func(ctx ssh.Context, key ssh.PublicKey) bool {
+			// Accept all public keys - we don't validate them here
+			// The public key is associated with the user account after Mastodon OAuth login
+			// On subsequent connections, if the key is found in the database, auto-login occurs
+			return true
+		}),
+		wish.WithMiddleware(sshMiddleware(cfg, database)...),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create SSH server: %w", err)
+	}
+
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+
+	log.Printf("Starting SSH server on 0.0.0.0:%s", cfg.Server.SSHPort)
+	go func() {
+		if err = sshServer.ListenAndServe(); err != nil {
+			log.Fatalln(err)
+		}
+	}()
+
+	<-done
+	log.Println("Shutting down servers...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Shutdown HTTP server
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Printf("HTTP server shutdown error: %v", err)
+	}
+
+	// Shutdown SSH server
+	if err := sshServer.Shutdown(ctx); err != nil {
+		log.Printf("SSH server shutdown error: %v", err)
+	}
+
+	reporting.Flush(5 * time.Second)
+	log.Println("Servers stopped")
+	return nil
+}
+
+func setupHTTPServer(cfg *config.Config, database *db.DB) *http.Server {
+	r := chi.NewRouter()
+
+	// Middleware
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(handlers.ReportPanics)
+	r.Use(middleware.Timeout(60 * time.Second))
+
+	// Routes
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<!DOCTYPE html>
+<html>
+<head>
+    <title>terminalpub</title>
+    <style>
+        body { 
+            font-family: monospace; 
+            max-width: 800px; 
+            margin: 50px auto; 
+            padding: 20px;
+            background: #1a1a1a;
+            color: #00ff00;
+        }
+        h1 { color: #00ff00; }
+        pre { background: #000; padding: 20px; border-radius: 5px; }
+        a { color: #00ffff; }
+    </style>
+</head>
+<body>
+    <h1>terminalpub</h1>
+    <p>ActivityPub for your terminal</p>
+    <h2>Connect via SSH:</h2>
+    <pre>ssh %s</pre>
+    <p><a href="/directory">Directory</a></p>
+    <p><a href="/guestbook">Guestbook</a></p>
+    <p><a href="/health">Health Check</a></p>
+    <p><a href="https://github.com/fulgidus/terminalpub">GitHub</a></p>
+</body>
+</html>`, cfg.Server.Domain)
+	})
+
+	// Health check endpoint
+	healthHandler := handlers.NewHealthHandler(database)
+	r.Handle("/health", healthHandler)
+
+	// OAuth Device Flow routes
+	if database != nil {
+		oauthHandler := handlers.NewOAuthHandler(database.Postgres, database.Redis, cfg)
+		r.With(handlers.MaxBodySize(handlers.MaxAPIBodySize)).Handle("/device", oauthHandler)
+		r.HandleFunc("/oauth/callback", oauthHandler.HandleCallback)
+	} else {
+		r.Get("/device", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("OAuth Device Flow - Database not available"))
+		})
+		r.Get("/oauth/callback", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("OAuth Callback - Database not available"))
+		})
+	}
+
+	// ActivityPub routes
+	if database != nil {
+		apHandler := handlers.NewActivityPubHandler(database.Postgres, cfg)
+		r.Get("/.well-known/webfinger", apHandler.WebFinger)
+		r.Get("/actor", apHandler.InstanceActor)
+		inboxLimit := handlers.MaxBodySize(int64(max(cfg.ActivityPub.MaxInboxSize, 1)) << 10)
+		r.With(inboxLimit).Post("/inbox", apHandler.SharedInbox)
+		r.Get("/users", apHandler.Users)
+		r.Get("/users/{username}", apHandler.Actor)
+		r.With(inboxLimit).Post("/users/{username}/inbox", apHandler.Inbox)
+		r.Get("/users/{username}/inbox", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Inbox is write-only", http.StatusMethodNotAllowed)
+		})
+		r.Get("/users/{username}/outbox", apHandler.SignedFetch(apHandler.Outbox))
+		r.Get("/users/{username}/statuses/{id}", apHandler.SignedFetch(apHandler.Status))
+		r.Get("/users/{username}/followers", apHandler.SignedFetch(apHandler.Followers))
+		r.Get("/users/{username}/following", apHandler.SignedFetch(apHandler.Following))
+		r.Get("/users/{username}/collections/featured", apHandler.SignedFetch(apHandler.Featured))
+
+		timelineHandler := handlers.NewTimelineHandler(database.Postgres, cfg)
+		r.Get("/api/v1/timelines/public", timelineHandler.Public)
+		r.Get("/api/v1/timelines/tag/{hashtag}", timelineHandler.Tag)
+
+		tagHandler := handlers.NewTagHandler(database.Postgres, cfg)
+		r.Get("/tags/{tag}", tagHandler.Page)
+
+		guestbookHandler := handlers.NewGuestbookHandler(database.Postgres, database.Redis, cfg)
+		r.Get("/guestbook", guestbookHandler.Page)
+
+		digestHandler := handlers.NewDigestHandler(database.Postgres, cfg)
+		r.Get("/digest/confirm/{token}", digestHandler.Confirm)
+		r.Get("/digest/unsubscribe/{token}", digestHandler.Unsubscribe)
+		r.Post("/digest/unsubscribe/{token}", digestHandler.Unsubscribe)
+
+		directoryHandler := handlers.NewDirectoryHandler(database.Postgres, cfg)
+		r.Get("/directory", directoryHandler.Page)
+
+		embedHandler := handlers.NewEmbedHandler(database.Postgres, cfg)
+		r.Get("/api/oembed", embedHandler.OEmbed)
+		r.Get("/embed/{postID}", embedHandler.Page)
+	} else {
+		r.Get("/.well-known/webfinger", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("WebFinger - Database not available"))
+		})
+		r.Get("/users/{username}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ActivityPub Actor - Database not available"))
+		})
+	}
+
+	// Personal API token routes
+	if database != nil {
+		apiTokenService := auth.NewAPITokenService(database.Postgres)
+		exportHandler := handlers.NewExportHandler(database.Postgres, database.Redis, cfg)
+		r.Get("/export/{token}", exportHandler.Download)
+		recordingHandler := handlers.NewRecordingHandler(database.Postgres, database.Redis, cfg)
+		r.Get("/recordings/{token}", recordingHandler.Download)
+		automationHandler := handlers.NewAutomationHandler(database.Postgres, cfg)
+		r.Route("/api/terminalpub/v1", func(r chi.Router) {
+			r.Use(handlers.MaxBodySize(handlers.MaxAPIBodySize))
+			r.Use(handlers.APITokenAuth(apiTokenService))
+			r.Handle("/status", handlers.NewStatusHandler(database.Postgres, cfg))
+			r.Handle("/export", exportHandler)
+			r.Post("/post", automationHandler.Post)
+			r.Get("/notifications", automationHandler.Notifications)
+			r.Get("/recordings", recordingHandler.List)
+			r.Get("/recordings/{id}", recordingHandler.Get)
+		})
+
+		// Mastodon-compatible client API
+		mastodonAPI := handlers.NewMastodonAPIHandler(database.Postgres, cfg)
+		apiLimit := handlers.MaxBodySize(handlers.MaxAPIBodySize)
+		r.With(apiLimit).Post("/api/v1/apps", mastodonAPI.CreateApp)
+		r.Get("/oauth/authorize", mastodonAPI.Authorize)
+		r.With(apiLimit).Post("/oauth/authorize", mastodonAPI.Authorize)
+		r.With(apiLimit).Post("/oauth/token", mastodonAPI.Token)
+		r.With(apiLimit).Post("/oauth/revoke", mastodonAPI.Revoke)
+		r.Group(func(r chi.Router) {
+			r.Use(apiLimit)
+			r.Use(handlers.APITokenAuth(apiTokenService))
+			r.Get("/api/v1/apps/verify_credentials", mastodonAPI.VerifyApp)
+			r.Get("/api/v1/accounts/verify_credentials", mastodonAPI.VerifyCredentials)
+			r.Get("/api/v1/accounts/{id}", mastodonAPI.Account)
+			r.Get("/api/v1/accounts/{id}/statuses", mastodonAPI.AccountStatuses)
+			r.Get("/api/v1/timelines/home", mastodonAPI.HomeTimeline)
+			r.Post("/api/v1/statuses", mastodonAPI.CreateStatus)
+			r.Get("/api/v1/statuses/{id}", mastodonAPI.Status)
+			r.Delete("/api/v1/statuses/{id}", mastodonAPI.DeleteStatus)
+			r.Post("/api/v1/statuses/{id}/pin", mastodonAPI.PinStatus)
+			r.Post("/api/v1/statuses/{id}/unpin", mastodonAPI.UnpinStatus)
+			r.Get("/api/v2/search", mastodonAPI.Search)
+			r.Post("/api/v1/push/subscription", mastodonAPI.CreatePushSubscription)
+			r.Get("/api/v1/push/subscription", mastodonAPI.PushSubscription)
+			r.Put("/api/v1/push/subscription", mastodonAPI.UpdatePushSubscription)
+			r.Delete("/api/v1/push/subscription", mastodonAPI.DeletePushSubscription)
+		})
+	}
+
+	addr := fmt.Sprintf(":%s", cfg.Server.HTTPPort)
+	return &http.Server{
+		Addr:         addr,
+		Handler:      r,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+}
+
+// sshMiddleware builds the SSH middleware chain; the last entry runs first
+func sshMiddleware(cfg *config.Config, database *db.DB) []wish.Middleware {
+	middlewares := []wish.Middleware{bubbletea.Middleware(teaHandler)}
+	if database != nil && cfg.Features.Recording.Enabled {
+		// Recordings capture what the TUI draws, once the user starts one
+		middlewares = append(middlewares, handlers.RecordingMiddleware(cfg.Features.Recording.MaxSize<<10))
+	}
+	if database != nil {
+		// Only sessions reaching the TUI count as connected
+		presence := services.NewPresenceService(database.Postgres, database.Redis)
+		middlewares = append(middlewares, handlers.PresenceMiddleware(presence, auth.NewSSHKeyService(database.Postgres)))
+		// Non-interactive commands (e.g. "ssh host status") bypass the TUI
+		middlewares = append(middlewares, handlers.NewSSHCommandHandler(database.Postgres, database.Redis, cfg).Middleware())
+	}
+	// A panic ends only the session it happened in
+	return append(middlewares, handlers.RecoverMiddleware(), logging.Middleware())
+}
+
+// Global app context for TUI
+var appCtx *ui.AppContext
+
+// initAppContext initializes the app context
+func initAppContext(cfg *config.Config, database *db.DB) {
+	if database == nil {
+		return
+	}
+
+	deviceFlowService := auth.NewDeviceFlowService(
+		database.Postgres,
+		fmt.Sprintf("http://%s/device", cfg.Server.Domain),
+	)
+	sshKeyService := auth.NewSSHKeyService(database.Postgres)
+	sessionManager := auth.NewSessionManager(database.Postgres, database.Redis)
+	apiTokenService := auth.NewAPITokenService(database.Postgres)
+	mastodonService := auth.NewMastodonService(database.Postgres, cfg.OAuth.CallbackURL, []string{"read", "write", "follow"})
+	tokenService := auth.NewTokenService(database.Postgres, mastodonService)
+	exportService := services.NewExportService(database.Postgres, database.Redis, cfg.Server.BaseURL)
+	accountService := services.NewAccountService(database.Postgres, database.Redis, cfg)
+	presenceService := services.NewPresenceService(database.Postgres, database.Redis)
+	chatService := services.NewChatService(database.Postgres, database.Redis)
+	guestbookService := services.NewGuestbookService(database.Postgres, database.Redis, cfg)
+	writeService := services.NewWriteService(database.Postgres, database.Redis)
+	recordingService := services.NewRecordingService(database.Postgres, database.Redis, cfg)
+	releaseService := services.NewReleaseService(database.Postgres, database.Redis, cfg)
+
+	appCtx = &ui.AppContext{
+		DB:                database.Postgres,
+		Redis:             database.Redis,
+		Config:            cfg,
+		DeviceFlowService: deviceFlowService,
+		SSHKeyService:     sshKeyService,
+		SessionManager:    sessionManager,
+		APITokenService:   apiTokenService,
+		TokenService:      tokenService,
+		ExportService:     exportService,
+		AccountService:    accountService,
+		PresenceService:   presenceService,
+		ChatService:       chatService,
+		GuestbookService:  guestbookService,
+		WriteService:      writeService,
+		RecordingService:  recordingService,
+		ReleaseService:    releaseService,
+		Events:            events.Default,
+	}
+}
+
+// teaHandler creates a new TUI model for each SSH session
+func teaHandler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
+	if appCtx == nil {
+		// Fallback if no database connection
+		return ui.Recover(ui.NewModel(nil, s)), []tea.ProgramOption{tea.WithAltScreen()}
+	}
+
+	return ui.Recover(ui.NewModel(appCtx, s)), []tea.ProgramOption{tea.WithAltScreen()}
+}
@@ -1,13 +1,258 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/db"
+	"github.com/fulgidus/terminalpub/internal/events"
+	"github.com/fulgidus/terminalpub/internal/outbound"
+	"github.com/fulgidus/terminalpub/internal/reporting"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/fulgidus/terminalpub/internal/version"
 )
 
+// purgeInterval is how often deleted accounts and recordings past retention
+// are removed
+const purgeInterval = time.Hour
+
+// inboxInterval is how often pending inbound activities are processed
+const inboxInterval = 30 * time.Second
+
+// inboxBatchSize bounds how many inbound activities one pass processes
+const inboxBatchSize = 100
+
+// pushInterval is how often queued push notifications are delivered
+const pushInterval = 10 * time.Second
+
+// pushBatchSize bounds how many push notifications one pass delivers
+const pushBatchSize = 100
+
+// webhookInterval is how often queued webhook payloads are delivered
+const webhookInterval = 10 * time.Second
+
+// webhookBatchSize bounds how many webhook payloads one pass delivers
+const webhookBatchSize = 100
+
+// matrixInterval is how often mentions are forwarded to Matrix rooms
+const matrixInterval = time.Minute
+
+// matrixBatchSize bounds how many Matrix messages one pass sends
+const matrixBatchSize = 100
+
+// digestInterval is how often due email digests are sent
+const digestInterval = 15 * time.Minute
+
+// digestBatchSize bounds how many digests one pass sends
+const digestBatchSize = 50
+
+// jobLease is how long a worker node keeps a job to itself; a node that
+// dies in the middle of one frees it when the lease runs out
+const jobLease = 10 * time.Minute
+
+// relayInterval is how often relay subscriptions are synced with the configuration
+const relayInterval = time.Hour
+
 func main() {
-	fmt.Println("Terminalpub Worker")
-	fmt.Println("TODO: Implement background workers")
-	fmt.Println("Workers will be implemented in Phase 6")
-	log.Println("Worker process would start here...")
+	showVersion := flag.Bool("version", false, "Print the version and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(version.String())
+		return
+	}
+	log.Printf("Terminalpub Worker, %s", version.String())
+
+	// Load configuration
+	cfg := config.LoadOrDefault("config/config.yaml")
+	if err := outbound.Configure(cfg); err != nil {
+		log.Fatalf("Invalid outbound configuration: %v", err)
+	}
+	if err := reporting.Configure(cfg); err != nil {
+		log.Fatalf("Invalid reporting configuration: %v", err)
+	}
+
+	database, err := db.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to databases: %v", err)
+	}
+	defer database.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go database.MonitorPool(ctx, time.Minute)
+	services.HandleEvents(events.Default, database.Postgres, cfg)
+
+	accountService := services.NewAccountService(database.Postgres, database.Redis, cfg)
+	inboxWorker := services.NewInboxWorker(database.Postgres, cfg)
+	relayService := services.NewRelayService(database.Postgres, cfg)
+	pushService := services.NewPushService(database.Postgres, cfg)
+	webhookService := services.NewWebhookService(database.Postgres, cfg)
+	matrixService := services.NewMatrixService(database.Postgres, database.Redis, cfg)
+	digestService := services.NewDigestService(database.Postgres, cfg)
+	recordingService := services.NewRecordingService(database.Postgres, database.Redis, cfg)
+	retention := time.Duration(cfg.Features.AccountDeletion.RetentionDays) * 24 * time.Hour
+
+	purgeTicker := time.NewTicker(purgeInterval)
+	defer purgeTicker.Stop()
+	inboxTicker := time.NewTicker(inboxInterval)
+	defer inboxTicker.Stop()
+	relayTicker := time.NewTicker(relayInterval)
+	defer relayTicker.Stop()
+	pushTicker := time.NewTicker(pushInterval)
+	defer pushTicker.Stop()
+	webhookTicker := time.NewTicker(webhookInterval)
+	defer webhookTicker.Stop()
+	matrixTicker := time.NewTicker(matrixInterval)
+	defer matrixTicker.Stop()
+	digestTicker := time.NewTicker(digestInterval)
+	defer digestTicker.Stop()
+
+	// Each job runs on one worker node at a time, so that several workers
+	// can share the databases without delivering anything twice
+	exclusive := func(name string, job func()) {
+		runExclusive(ctx, database, name, job)
+	}
+
+	purgeAll := func() {
+		purge(ctx, accountService, retention)
+		purgeRecordings(ctx, recordingService)
+		purgeLeases(ctx, database)
+	}
+
+	exclusive("purge", purgeAll)
+	exclusive("inbox", func() { processInbox(ctx, inboxWorker) })
+	exclusive("relays", func() { syncRelays(ctx, relayService) })
+	exclusive("push", func() { deliverPush(ctx, pushService) })
+	exclusive("webhooks", func() { deliverWebhooks(ctx, webhookService) })
+	exclusive("matrix", func() { forwardToMatrix(ctx, matrixService) })
+	exclusive("digests", func() { sendDigests(ctx, digestService) })
+
+	for {
+		select {
+		case <-ctx.Done():
+			reporting.Flush(5 * time.Second)
+			log.Println("Worker stopped")
+			return
+		case <-purgeTicker.C:
+			exclusive("purge", purgeAll)
+		case <-inboxTicker.C:
+			exclusive("inbox", func() { processInbox(ctx, inboxWorker) })
+		case <-relayTicker.C:
+			exclusive("relays", func() { syncRelays(ctx, relayService) })
+		case <-pushTicker.C:
+			exclusive("push", func() { deliverPush(ctx, pushService) })
+		case <-webhookTicker.C:
+			exclusive("webhooks", func() { deliverWebhooks(ctx, webhookService) })
+		case <-matrixTicker.C:
+			exclusive("matrix", func() { forwardToMatrix(ctx, matrixService) })
+		case <-digestTicker.C:
+			exclusive("digests", func() { sendDigests(ctx, digestService) })
+		}
+	}
+}
+
+// runExclusive runs job unless another worker node holds its lease
+func runExclusive(ctx context.Context, database *db.DB, name string, job func()) {
+	lease := "worker:" + name
+	ok, err := services.AcquireLease(ctx, database.Postgres, lease, jobLease)
+	if err != nil {
+		log.Printf("Skipping %s: %v", name, err)
+		return
+	}
+	if !ok {
+		return
+	}
+	defer services.ReleaseLease(context.WithoutCancel(ctx), database.Postgres, lease)
+	job()
+}
+
+// purgeLeases removes leases left behind by nodes that stopped mid-job
+func purgeLeases(ctx context.Context, database *db.DB) {
+	if _, err := services.PurgeExpiredLeases(ctx, database.Postgres); err != nil {
+		log.Printf("Lease purge failed: %v", err)
+	}
+}
+
+// purge removes deleted accounts past retention
+func purge(ctx context.Context, accountService *services.AccountService, retention time.Duration) {
+	purged, err := accountService.PurgeDeletedAccounts(ctx, retention)
+	if err != nil {
+		log.Printf("Account purge failed: %v", err)
+	} else if purged > 0 {
+		log.Printf("Purged %d deleted accounts", purged)
+	}
+}
+
+// purgeRecordings removes session recordings past retention
+func purgeRecordings(ctx context.Context, recordingService *services.RecordingService) {
+	purged, err := recordingService.PurgeExpired(ctx)
+	if err != nil {
+		log.Printf("Recording purge failed: %v", err)
+	} else if purged > 0 {
+		log.Printf("Purged %d expired recordings", purged)
+	}
+}
+
+// processInbox applies pending inbound activities
+func processInbox(ctx context.Context, inboxWorker *services.InboxWorker) {
+	processed, err := inboxWorker.ProcessPending(ctx, inboxBatchSize)
+	if err != nil {
+		log.Printf("Inbox processing failed: %v", err)
+	} else if processed > 0 {
+		log.Printf("Processed %d inbound activities", processed)
+	}
+}
+
+// syncRelays subscribes to and unsubscribes from relays to match the configuration
+func syncRelays(ctx context.Context, relayService *services.RelayService) {
+	if err := relayService.Sync(ctx); err != nil {
+		log.Printf("Relay sync failed: %v", err)
+	}
+}
+
+// deliverPush sends queued Web Push notifications
+func deliverPush(ctx context.Context, pushService *services.PushService) {
+	delivered, err := pushService.DeliverPending(ctx, pushBatchSize)
+	if err != nil {
+		log.Printf("Push delivery failed: %v", err)
+	} else if delivered > 0 {
+		log.Printf("Delivered %d push notifications", delivered)
+	}
+}
+
+// deliverWebhooks sends queued webhook payloads
+func deliverWebhooks(ctx context.Context, webhookService *services.WebhookService) {
+	delivered, err := webhookService.DeliverPending(ctx, webhookBatchSize)
+	if err != nil {
+		log.Printf("Webhook delivery failed: %v", err)
+	} else if delivered > 0 {
+		log.Printf("Delivered %d webhooks", delivered)
+	}
+}
+
+// forwardToMatrix sends the mentions users received while away to their Matrix rooms
+func forwardToMatrix(ctx context.Context, matrixService *services.MatrixService) {
+	forwarded, err := matrixService.ForwardPending(ctx, matrixBatchSize)
+	if err != nil {
+		log.Printf("Matrix forwarding failed: %v", err)
+	} else if forwarded > 0 {
+		log.Printf("Forwarded %d notifications to Matrix", forwarded)
+	}
+}
+
+// sendDigests emails the digests whose day or week has passed
+func sendDigests(ctx context.Context, digestService *services.DigestService) {
+	sent, err := digestService.SendDue(ctx, digestBatchSize)
+	if err != nil {
+		log.Printf("Sending digests failed: %v", err)
+	} else if sent > 0 {
+		log.Printf("Sent %d email digests", sent)
+	}
 }
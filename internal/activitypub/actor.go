@@ -0,0 +1,263 @@
+package activitypub
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+)
+
+// PublicCollection is the special collection addressing public posts
+const PublicCollection = "https://www.w3.org/ns/activitystreams#Public"
+
+// ActorURL returns the ActivityPub ID of a local user
+func ActorURL(baseURL, username string) string {
+	return fmt.Sprintf("%s/users/%s", baseURL, username)
+}
+
+// NewActor builds the Person object for a local user
+func NewActor(baseURL string, user *models.User) models.Actor {
+	actorID := ActorURL(baseURL, user.Username)
+
+	name := user.DisplayName
+	if name == "" {
+		name = user.Username
+	}
+
+	var icon *models.ActorImage
+	if user.AvatarURL != "" {
+		icon = &models.ActorImage{Type: "Image", URL: user.AvatarURL}
+	}
+
+	// The proof key is created on first delivery, so older actors may lack it
+	var assertionMethod []models.ActorMultikey
+	if user.ProofPublicKey != "" {
+		assertionMethod = []models.ActorMultikey{{
+			ID:                 ProofKeyID(actorID),
+			Type:               "Multikey",
+			Controller:         actorID,
+			PublicKeyMultibase: user.ProofPublicKey,
+		}}
+	}
+
+	return models.Actor{
+		Context: []any{
+			"https://www.w3.org/ns/activitystreams",
+			"https://w3id.org/security/v1",
+			MultikeyContext,
+			map[string]any{
+				"alsoKnownAs":  map[string]string{"@id": "as:alsoKnownAs", "@type": "@id"},
+				"movedTo":      map[string]string{"@id": "as:movedTo", "@type": "@id"},
+				"toot":         "http://joinmastodon.org/ns#",
+				"featured":     map[string]string{"@id": "toot:featured", "@type": "@id"},
+				"discoverable": "toot:discoverable",
+				"terminalpub":  "https://terminalpub.com/ns#",
+				"gpgKey":       "terminalpub:gpgKey",
+			},
+		},
+		ID:                        actorID,
+		Type:                      "Person",
+		PreferredUsername:         user.Username,
+		Name:                      name,
+		Summary:                   user.Bio,
+		Icon:                      icon,
+		Inbox:                     fmt.Sprintf("%s/inbox", actorID),
+		Outbox:                    fmt.Sprintf("%s/outbox", actorID),
+		Followers:                 fmt.Sprintf("%s/followers", actorID),
+		Following:                 fmt.Sprintf("%s/following", actorID),
+		Featured:                  FeaturedURL(actorID),
+		URL:                       fmt.Sprintf("%s/@%s", baseURL, user.Username),
+		ManuallyApprovesFollowers: user.ManuallyApprovesFollowers,
+		Discoverable:              user.Discoverable,
+		Published:                 user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		AlsoKnownAs:               user.AlsoKnownAs,
+		MovedTo:                   user.MovedTo,
+		AssertionMethod:           assertionMethod,
+		GPGKey:                    user.GPGPublicKey,
+		PublicKey: models.ActorPublicKey{
+			ID:           fmt.Sprintf("%s#main-key", actorID),
+			Owner:        actorID,
+			PublicKeyPem: user.PublicKey,
+		},
+		Endpoints: map[string]any{
+			"sharedInbox": fmt.Sprintf("%s/inbox", baseURL),
+		},
+	}
+}
+
+// FeaturedURL returns the collection of a local actor's pinned posts
+func FeaturedURL(actorID string) string {
+	return actorID + "/collections/featured"
+}
+
+// ProofKeyID returns the ID of a local actor's integrity proof key
+func ProofKeyID(actorID string) string {
+	return actorID + "#ed25519-key"
+}
+
+// MinimalActor strips an actor down to its identity, endpoints and public key
+func MinimalActor(actor models.Actor) models.Actor {
+	return models.Actor{
+		Context:           actor.Context,
+		ID:                actor.ID,
+		Type:              actor.Type,
+		PreferredUsername: actor.PreferredUsername,
+		Inbox:             actor.Inbox,
+		Outbox:            actor.Outbox,
+		Followers:         actor.Followers,
+		Following:         actor.Following,
+		PublicKey:         actor.PublicKey,
+		Endpoints:         actor.Endpoints,
+		AssertionMethod:   actor.AssertionMethod,
+	}
+}
+
+// NewCreateNote wraps a local post in a Create activity, as it appears in the outbox
+func NewCreateNote(baseURL, username string, post *models.Post) models.APActivity {
+	actorID := ActorURL(baseURL, username)
+
+	noteID := post.APID
+	if noteID == "" {
+		noteID = fmt.Sprintf("%s/statuses/%s", actorID, strconv.Itoa(post.ID))
+	}
+
+	var mentions []string
+	tags := hashtagObjects(baseURL, post.Content)
+	for _, mention := range post.Mentions {
+		mentions = append(mentions, mention.ActorID)
+		tags = append(tags, map[string]any{"type": "Mention", "href": mention.ActorID, "name": "@" + mention.Acct})
+	}
+	to, cc := Audience(actorID, post.Visibility, mentions)
+	published := post.PublishedAt.UTC().Format(time.RFC3339)
+
+	note := models.APNote{
+		ID:           noteID,
+		Type:         "Note",
+		AttributedTo: actorID,
+		Content:      post.Content,
+		Published:    published,
+		To:           to,
+		CC:           cc,
+		Tag:          tags,
+		GPGSignature: post.GPGSignature,
+	}
+
+	return models.APActivity{
+		ID:        noteID + "/activity",
+		Type:      "Create",
+		Actor:     actorID,
+		Object:    note,
+		To:        to,
+		CC:        cc,
+		Published: published,
+	}
+}
+
+// NewAddFeatured builds the Add activity announcing that a local user pinned a post
+func NewAddFeatured(baseURL, username, noteID string) models.APActivity {
+	return newFeaturedActivity(baseURL, username, noteID, "Add")
+}
+
+// NewRemoveFeatured builds the Remove activity announcing that a local user unpinned a post
+func NewRemoveFeatured(baseURL, username, noteID string) models.APActivity {
+	return newFeaturedActivity(baseURL, username, noteID, "Remove")
+}
+
+// newFeaturedActivity builds an activity of activityType changing a local
+// user's featured collection
+func newFeaturedActivity(baseURL, username, noteID, activityType string) models.APActivity {
+	actorID := ActorURL(baseURL, username)
+	now := time.Now().UTC()
+
+	return models.APActivity{
+		Context:   "https://www.w3.org/ns/activitystreams",
+		ID:        fmt.Sprintf("%s#%s/%d", noteID, strings.ToLower(activityType), now.UnixNano()),
+		Type:      activityType,
+		Actor:     actorID,
+		Object:    noteID,
+		Target:    FeaturedURL(actorID),
+		To:        []string{PublicCollection},
+		CC:        []string{actorID + "/followers"},
+		Published: now.Format(time.RFC3339),
+	}
+}
+
+// Audience returns the to and cc fields of a post with the given visibility
+// by actorID. Public posts are addressed to everyone, unlisted ones copy the
+// public collection so they stay off public timelines, followers-only posts
+// reach followers and direct posts only the mentioned actors, who are copied
+// on the other visibilities as well.
+func Audience(actorID, visibility string, mentions []string) ([]string, []string) {
+	followers := actorID + "/followers"
+	switch visibility {
+	case "unlisted":
+		return []string{followers}, append([]string{PublicCollection}, mentions...)
+	case "followers", "private":
+		return []string{followers}, mentions
+	case "direct":
+		return mentions, nil
+	default:
+		return []string{PublicCollection}, append([]string{followers}, mentions...)
+	}
+}
+
+// NewUpdateActor builds the Update activity announcing changes to a local actor's profile
+func NewUpdateActor(baseURL string, user *models.User) models.APActivity {
+	actor := NewActor(baseURL, user)
+	now := time.Now().UTC()
+
+	return models.APActivity{
+		Context:   "https://www.w3.org/ns/activitystreams",
+		ID:        fmt.Sprintf("%s#updates/%d", actor.ID, now.Unix()),
+		Type:      "Update",
+		Actor:     actor.ID,
+		Object:    actor,
+		To:        []string{PublicCollection},
+		Published: now.Format(time.RFC3339),
+	}
+}
+
+// NewDeleteActor builds the Delete activity announcing that a local actor is gone
+func NewDeleteActor(baseURL, username string) models.APActivity {
+	actorID := ActorURL(baseURL, username)
+
+	return models.APActivity{
+		Context:   "https://www.w3.org/ns/activitystreams",
+		ID:        fmt.Sprintf("%s#delete", actorID),
+		Type:      "Delete",
+		Actor:     actorID,
+		Object:    actorID,
+		To:        []string{PublicCollection},
+		Published: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// NewDeleteNote builds the Delete activity for a local user's post
+func NewDeleteNote(baseURL, username, noteID string) models.APActivity {
+	actorID := ActorURL(baseURL, username)
+	now := time.Now().UTC()
+
+	return models.APActivity{
+		Context:   "https://www.w3.org/ns/activitystreams",
+		ID:        noteID + "#delete",
+		Type:      "Delete",
+		Actor:     actorID,
+		Object:    NewTombstone(noteID, "Note", now),
+		To:        []string{PublicCollection},
+		CC:        []string{actorID + "/followers"},
+		Published: now.Format(time.RFC3339),
+	}
+}
+
+// NewTombstone builds the object served in place of a deleted actor or post
+func NewTombstone(id, formerType string, deletedAt time.Time) map[string]any {
+	return map[string]any{
+		"@context":   "https://www.w3.org/ns/activitystreams",
+		"id":         id,
+		"type":       "Tombstone",
+		"formerType": formerType,
+		"deleted":    deletedAt.UTC().Format(time.RFC3339),
+	}
+}
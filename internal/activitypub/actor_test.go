@@ -0,0 +1,66 @@
+package activitypub
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+)
+
+func TestAudience(t *testing.T) {
+	const actor = "https://terminalpub.example/users/alice"
+	const followers = actor + "/followers"
+	bob := []string{"https://example.social/users/bob"}
+
+	tests := []struct {
+		visibility string
+		mentions   []string
+		to, cc     []string
+	}{
+		{"public", nil, []string{PublicCollection}, []string{followers}},
+		{"public", bob, []string{PublicCollection}, []string{followers, bob[0]}},
+		{"", nil, []string{PublicCollection}, []string{followers}},
+		{"unlisted", nil, []string{followers}, []string{PublicCollection}},
+		{"unlisted", bob, []string{followers}, []string{PublicCollection, bob[0]}},
+		{"followers", nil, []string{followers}, nil},
+		{"private", bob, []string{followers}, bob},
+		{"direct", bob, bob, nil},
+		{"direct", nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.visibility, func(t *testing.T) {
+			to, cc := Audience(actor, tt.visibility, tt.mentions)
+			if !slices.Equal(to, tt.to) || !slices.Equal(cc, tt.cc) {
+				t.Errorf("Audience(%q, %v) = %v, %v, want %v, %v", tt.visibility, tt.mentions, to, cc, tt.to, tt.cc)
+			}
+		})
+	}
+}
+
+func TestNewCreateNoteMentions(t *testing.T) {
+	post := &models.Post{
+		ID:         7,
+		Content:    "hi @bob@example.social",
+		Visibility: "direct",
+		Mentions:   []models.PostMention{{ActorID: "https://example.social/users/bob", Acct: "bob@example.social"}},
+	}
+	activity := NewCreateNote("https://terminalpub.example", "alice", post)
+
+	if !slices.Equal(activity.To, []string{"https://example.social/users/bob"}) || len(activity.CC) != 0 {
+		t.Errorf("Create is addressed to %v, cc %v, want only the mentioned actor", activity.To, activity.CC)
+	}
+	note, ok := activity.Object.(models.APNote)
+	if !ok {
+		t.Fatalf("object is %T, want a Note", activity.Object)
+	}
+	if !slices.Equal(note.To, activity.To) || len(note.CC) != 0 {
+		t.Errorf("Note is addressed to %v, cc %v, want the activity's audience", note.To, note.CC)
+	}
+	if len(note.Tag) != 1 {
+		t.Fatalf("Note has %d tags, want the mention", len(note.Tag))
+	}
+	if tag, _ := note.Tag[0].(map[string]any); tag["type"] != "Mention" || tag["name"] != "@bob@example.social" {
+		t.Errorf("tag = %v, want a Mention of @bob@example.social", note.Tag[0])
+	}
+}
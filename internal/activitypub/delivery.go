@@ -0,0 +1,49 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/outbound"
+)
+
+// deliveryClient is shared by all outgoing inbox deliveries
+var deliveryClient = outbound.NewClient(15 * time.Second)
+
+// Deliver POSTs a signed activity to a remote inbox
+func Deliver(ctx context.Context, inboxURL string, activity any, privateKeyPEM, keyID, userAgent string) error {
+	body, err := json.Marshal(activity)
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", inboxURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/activity+json")
+	req.Header.Set("User-Agent", userAgent)
+
+	if err := SignRequest(req, privateKeyPEM, keyID); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := deliveryClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver activity: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("inbox returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
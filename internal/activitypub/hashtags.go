@@ -0,0 +1,55 @@
+package activitypub
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// hashtagPattern matches a hashtag not preceded by a word character or an
+	// entity ampersand, so "&#39;" is not taken for a tag. Combining marks
+	// belong to the tag, as in #हिन्दी.
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{M}\p{N}_&/])#([\p{L}\p{M}\p{N}_]*[\p{L}_][\p{L}\p{M}\p{N}_]*)`)
+	// hashtagExcluded matches the URLs and code spans hashtags are not taken from
+	hashtagExcluded = regexp.MustCompile("(?is)https?://[^\\s\"'<>]+|```.*?```|`[^`\n]*`|<code[^>]*>.*?</code>")
+)
+
+// maxHashtagLength matches the length of the post_tags.tag column
+const maxHashtagLength = 100
+
+// ExtractHashtags returns the distinct hashtags in post content, lowercased
+// and without the leading #
+func ExtractHashtags(content string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	content = hashtagExcluded.ReplaceAllString(content, " ")
+	for _, match := range hashtagPattern.FindAllStringSubmatch(content, -1) {
+		tag := strings.ToLower(match[1])
+		if utf8.RuneCountInString(tag) > maxHashtagLength || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// TagURL returns the URL of a local hashtag page
+func TagURL(baseURL, tag string) string {
+	return baseURL + "/tags/" + url.PathEscape(tag)
+}
+
+// hashtagObjects builds the Hashtag entries of a note's tag field
+func hashtagObjects(baseURL, content string) []any {
+	var objects []any
+	for _, tag := range ExtractHashtags(content) {
+		objects = append(objects, map[string]any{
+			"type": "Hashtag",
+			"href": TagURL(baseURL, tag),
+			"name": "#" + tag,
+		})
+	}
+	return objects
+}
@@ -0,0 +1,45 @@
+package activitypub
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{name: "plain", content: "#hello world #Go_lang", want: []string{"hello", "go_lang"}},
+		{name: "punctuation", content: "(#one), #two. \"#three\"!", want: []string{"one", "two", "three"}},
+		{name: "unicode", content: "#Café #日本語 #Ελληνικά #ÜBER", want: []string{"café", "日本語", "ελληνικά", "über"}},
+		{name: "combining marks", content: "#हिन्दी #café", want: []string{"हिन्दी", "café"}},
+		{name: "deduplicated in any case", content: "#Go #go #GO #Café #CAFÉ", want: []string{"go", "café"}},
+		{name: "trailing hash", content: "not a tag #", want: nil},
+		{name: "lone hashes", content: "# ## #!", want: nil},
+		{name: "digits only", content: "issue #123 #2024", want: nil},
+		{name: "digits and letters", content: "#2024goals #ab12", want: []string{"2024goals", "ab12"}},
+		{name: "inside a word", content: "C#sharp a#b", want: nil},
+		{name: "entity", content: "it&#39;s", want: nil},
+		{name: "URL fragment", content: "see https://example.social/about#rules and http://example.social/#top", want: nil},
+		{name: "URL after a separator", content: "https://example.social/search?q=#tag https://example.social/a-#b", want: nil},
+		{name: "path fragment", content: "docs/#intro", want: nil},
+		{name: "next to a URL", content: "#real https://example.social/#fake #also", want: []string{"real", "also"}},
+		{name: "HTML link", content: `<a href="https://example.social/#fake">#real</a>`, want: []string{"real"}},
+		{name: "inline code", content: "run `git log #skip` then #keep", want: []string{"keep"}},
+		{name: "code block", content: "```\n#include <stdio.h>\n```\n#c", want: []string{"c"}},
+		{name: "HTML code", content: "<code>#skip</code> <pre><code class=\"c\">\n#define X\n</code></pre> #keep", want: []string{"keep"}},
+		{name: "adjacent", content: "#one#two", want: []string{"one"}},
+		{name: "too long", content: "#" + strings.Repeat("a", maxHashtagLength+1) + " #ok", want: []string{"ok"}},
+		{name: "long in runes", content: "#" + strings.Repeat("é", maxHashtagLength), want: []string{strings.Repeat("é", maxHashtagLength)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractHashtags(tt.content); !slices.Equal(got, tt.want) {
+				t.Errorf("ExtractHashtags(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package activitypub
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+)
+
+// activityID returns a new unique ID for an activity by a local actor
+func activityID(actorID, kind string) string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return fmt.Sprintf("%s#%s/%s", actorID, kind, hex.EncodeToString(buf))
+}
+
+// NewFollow builds a Follow of a remote actor by a local user
+func NewFollow(baseURL, username, targetActorID string) models.APActivity {
+	actorID := ActorURL(baseURL, username)
+
+	return models.APActivity{
+		Context:   "https://www.w3.org/ns/activitystreams",
+		ID:        activityID(actorID, "follows"),
+		Type:      "Follow",
+		Actor:     actorID,
+		Object:    targetActorID,
+		To:        []string{targetActorID},
+		Published: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// NewLike builds a Like of a remote object by a local user
+func NewLike(baseURL, username, objectID, authorID string) models.APActivity {
+	actorID := ActorURL(baseURL, username)
+
+	return models.APActivity{
+		Context:   "https://www.w3.org/ns/activitystreams",
+		ID:        activityID(actorID, "likes"),
+		Type:      "Like",
+		Actor:     actorID,
+		Object:    objectID,
+		To:        []string{authorID},
+		Published: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// NewEmojiReact builds an emoji reaction to a remote object by a local
+// user, as understood by Pleroma, Akkoma and Misskey
+func NewEmojiReact(baseURL, username, objectID, authorID, emoji string) models.APActivity {
+	actorID := ActorURL(baseURL, username)
+
+	return models.APActivity{
+		Context:   "https://www.w3.org/ns/activitystreams",
+		ID:        activityID(actorID, "reactions"),
+		Type:      "EmojiReact",
+		Actor:     actorID,
+		Object:    objectID,
+		Content:   emoji,
+		To:        []string{authorID},
+		Published: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// NewAnnounce builds a public boost of a remote object by a local user
+func NewAnnounce(baseURL, username, objectID, authorID string) models.APActivity {
+	actorID := ActorURL(baseURL, username)
+
+	return models.APActivity{
+		Context:   "https://www.w3.org/ns/activitystreams",
+		ID:        activityID(actorID, "announces"),
+		Type:      "Announce",
+		Actor:     actorID,
+		Object:    objectID,
+		To:        []string{PublicCollection},
+		CC:        []string{authorID, actorID + "/followers"},
+		Published: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// NewUndo wraps a previous activity of a local actor in an Undo, addressed
+// like the original so it reaches the same inboxes
+func NewUndo(activity models.APActivity) models.APActivity {
+	original := activity
+	original.Context = nil
+
+	return models.APActivity{
+		Context:   "https://www.w3.org/ns/activitystreams",
+		ID:        activity.ID + "/undo",
+		Type:      "Undo",
+		Actor:     activity.Actor,
+		Object:    original,
+		To:        activity.To,
+		CC:        activity.CC,
+		Published: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// NewAccept accepts a remote actor's Follow of a local user
+func NewAccept(baseURL, username, followerID, followID string) models.APActivity {
+	return followResponse(baseURL, username, followerID, followID, "Accept")
+}
+
+// NewReject rejects a remote actor's Follow of a local user
+func NewReject(baseURL, username, followerID, followID string) models.APActivity {
+	return followResponse(baseURL, username, followerID, followID, "Reject")
+}
+
+// followResponse builds an Accept or Reject embedding the original Follow
+func followResponse(baseURL, username, followerID, followID, kind string) models.APActivity {
+	actorID := ActorURL(baseURL, username)
+
+	return models.APActivity{
+		Context: "https://www.w3.org/ns/activitystreams",
+		ID:      activityID(actorID, strings.ToLower(kind)+"s"),
+		Type:    kind,
+		Actor:   actorID,
+		Object: map[string]any{
+			"id":     followID,
+			"type":   "Follow",
+			"actor":  followerID,
+			"object": actorID,
+		},
+		To:        []string{followerID},
+		Published: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// NewMove announces to a local user's followers that the account moved to targetActorID
+func NewMove(baseURL, username, targetActorID string) models.APActivity {
+	actorID := ActorURL(baseURL, username)
+
+	return models.APActivity{
+		Context:   "https://www.w3.org/ns/activitystreams",
+		ID:        activityID(actorID, "moves"),
+		Type:      "Move",
+		Actor:     actorID,
+		Object:    actorID,
+		Target:    targetActorID,
+		To:        []string{actorID + "/followers"},
+		Published: time.Now().UTC().Format(time.RFC3339),
+	}
+}
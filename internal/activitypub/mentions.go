@@ -0,0 +1,60 @@
+package activitypub
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mentionPattern matches @user or @user@domain not preceded by a word
+// character, so email addresses are not taken for mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_/@.])@([A-Za-z0-9_][A-Za-z0-9_.-]*)(?:@([A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)+))?`)
+
+// ExtractMentions returns the distinct accounts mentioned in post content, as
+// user@domain or, for accounts written without a domain, just user
+func ExtractMentions(content string) []string {
+	var accts []string
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		acct := strings.TrimRight(match[1], ".-")
+		if match[2] != "" {
+			acct += "@" + match[2]
+		}
+		if seen[strings.ToLower(acct)] {
+			continue
+		}
+		seen[strings.ToLower(acct)] = true
+		accts = append(accts, acct)
+	}
+	return accts
+}
+
+// IsDirect reports whether an object is addressed privately: neither to the
+// public nor to a followers collection
+func IsDirect(object map[string]any) bool {
+	for _, field := range []string{"to", "cc"} {
+		for _, addr := range Addresses(object[field]) {
+			if IsPublicAddress(addr) || strings.HasSuffix(addr, "/followers") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Addresses returns the IDs in a to or cc field, which may be a single
+// string or a list
+func Addresses(field any) []string {
+	switch v := field.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var addrs []string
+		for _, entry := range v {
+			if addr, ok := entry.(string); ok {
+				addrs = append(addrs, addr)
+			}
+		}
+		return addrs
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package activitypub
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"remote", "hi @bob@example.social!", []string{"bob@example.social"}},
+		{"local", "@alice look", []string{"alice"}},
+		{"several", "@bob@example.social and @carol@other.example.", []string{"bob@example.social", "carol@other.example"}},
+		{"deduplicated in any case", "@Bob@example.social @bob@EXAMPLE.social", []string{"Bob@example.social"}},
+		{"trailing punctuation", "thanks @alice.", []string{"alice"}},
+		{"email address", "mail me at bob@example.social", nil},
+		{"URL path", "https://example.social/@bob", nil},
+		{"inside HTML", `<p><span>@bob@example.social</span></p>`, []string{"bob@example.social"}},
+		{"lone at", "@ @@", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractMentions(tt.content); !slices.Equal(got, tt.want) {
+				t.Errorf("ExtractMentions(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDirect(t *testing.T) {
+	const bob = "https://terminalpub.example/users/bob"
+
+	tests := []struct {
+		name   string
+		object map[string]any
+		want   bool
+	}{
+		{"to one actor", map[string]any{"to": bob}, true},
+		{"to a list of actors", map[string]any{"to": []any{bob, "https://example.social/users/carol"}}, true},
+		{"no audience", map[string]any{}, true},
+		{"public", map[string]any{"to": []any{PublicCollection}, "cc": []any{bob}}, false},
+		{"public in cc", map[string]any{"to": bob, "cc": "as:Public"}, false},
+		{"short public", map[string]any{"to": []any{"Public", bob}}, false},
+		{"followers", map[string]any{"to": "https://example.social/users/alice/followers", "cc": bob}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDirect(tt.object); got != tt.want {
+				t.Errorf("IsDirect(%v) = %v, want %v", tt.object, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddresses(t *testing.T) {
+	tests := []struct {
+		name  string
+		field any
+		want  []string
+	}{
+		{"string", "https://example.social/users/bob", []string{"https://example.social/users/bob"}},
+		{"list", []any{"a", 1, "b", nil}, []string{"a", "b"}},
+		{"missing", nil, nil},
+		{"object", map[string]any{"id": "a"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Addresses(tt.field); !slices.Equal(got, tt.want) {
+				t.Errorf("Addresses(%v) = %v, want %v", tt.field, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf16"
+)
+
+// DataIntegrityContext is the JSON-LD context documents carrying a proof must include
+const DataIntegrityContext = "https://w3id.org/security/data-integrity/v2"
+
+// MultikeyContext defines the Multikey verification methods on actors
+const MultikeyContext = "https://w3id.org/security/multikey/v1"
+
+// proofCryptosuite is the FEP-8b32 suite: Ed25519 over JCS-canonicalized JSON
+const proofCryptosuite = "eddsa-jcs-2022"
+
+// ed25519MulticodecPrefix marks an Ed25519 public key in a Multikey
+var ed25519MulticodecPrefix = []byte{0xed, 0x01}
+
+// base58Alphabet is the Bitcoin alphabet used by multibase base58btc ("z")
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// GenerateProofKey creates an Ed25519 key pair for object integrity proofs,
+// returning the private key seed in base58 and the public key as a Multikey
+func GenerateProofKey() (privateKey, publicKeyMultibase string, err error) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate proof key: %w", err)
+	}
+	return base58Encode(private.Seed()), EncodeMultikey(public), nil
+}
+
+// ParseProofKey decodes a private key produced by GenerateProofKey
+func ParseProofKey(privateKey string) (ed25519.PrivateKey, error) {
+	seed, err := base58Decode(privateKey)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid proof key")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// EncodeMultikey encodes an Ed25519 public key as a base58btc Multikey
+func EncodeMultikey(publicKey ed25519.PublicKey) string {
+	return "z" + base58Encode(append(append([]byte{}, ed25519MulticodecPrefix...), publicKey...))
+}
+
+// ParseMultikey decodes a base58btc Ed25519 Multikey
+func ParseMultikey(multibase string) (ed25519.PublicKey, error) {
+	if len(multibase) < 2 || multibase[0] != 'z' {
+		return nil, fmt.Errorf("unsupported multibase encoding")
+	}
+	raw, err := base58Decode(multibase[1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != len(ed25519MulticodecPrefix)+ed25519.PublicKeySize || !bytes.HasPrefix(raw, ed25519MulticodecPrefix) {
+		return nil, fmt.Errorf("not an Ed25519 Multikey")
+	}
+	return ed25519.PublicKey(raw[len(ed25519MulticodecPrefix):]), nil
+}
+
+// AddProof returns a copy of an activity with an eddsa-jcs-2022 integrity
+// proof made by verificationMethod, adding the data integrity context
+func AddProof(activity any, privateKey ed25519.PrivateKey, verificationMethod string) (map[string]any, error) {
+	doc, err := toDocument(activity)
+	if err != nil {
+		return nil, err
+	}
+	delete(doc, "proof")
+	doc["@context"] = withContext(doc["@context"], DataIntegrityContext)
+
+	proof := map[string]any{
+		"@context":           doc["@context"],
+		"type":               "DataIntegrityProof",
+		"cryptosuite":        proofCryptosuite,
+		"verificationMethod": verificationMethod,
+		"proofPurpose":       "assertionMethod",
+		"created":            time.Now().UTC().Format(time.RFC3339),
+	}
+	hash, err := proofHash(doc, proof)
+	if err != nil {
+		return nil, err
+	}
+	delete(proof, "@context")
+	proof["proofValue"] = "z" + base58Encode(ed25519.Sign(privateKey, hash))
+
+	doc["proof"] = proof
+	return doc, nil
+}
+
+// ProofVerificationMethod returns the verification method of a document's
+// proof, or "" if it has none
+func ProofVerificationMethod(doc map[string]any) string {
+	proof, _ := doc["proof"].(map[string]any)
+	method, _ := proof["verificationMethod"].(string)
+	return method
+}
+
+// VerifyProof checks a document's eddsa-jcs-2022 integrity proof against publicKey
+func VerifyProof(doc map[string]any, publicKey ed25519.PublicKey) error {
+	proof, ok := doc["proof"].(map[string]any)
+	if !ok {
+		return fmt.Errorf("document has no proof")
+	}
+	if proof["type"] != "DataIntegrityProof" || proof["cryptosuite"] != proofCryptosuite {
+		return fmt.Errorf("unsupported proof type %v/%v", proof["type"], proof["cryptosuite"])
+	}
+	if proof["proofPurpose"] != "assertionMethod" {
+		return fmt.Errorf("unexpected proof purpose %v", proof["proofPurpose"])
+	}
+
+	proofValue, _ := proof["proofValue"].(string)
+	if len(proofValue) < 2 || proofValue[0] != 'z' {
+		return fmt.Errorf("invalid proof value")
+	}
+	signature, err := base58Decode(proofValue[1:])
+	if err != nil {
+		return fmt.Errorf("invalid proof value: %w", err)
+	}
+
+	unsigned := make(map[string]any, len(doc))
+	for key, value := range doc {
+		if key != "proof" {
+			unsigned[key] = value
+		}
+	}
+	config := make(map[string]any, len(proof))
+	for key, value := range proof {
+		if key != "proofValue" {
+			config[key] = value
+		}
+	}
+	config["@context"] = doc["@context"]
+
+	hash, err := proofHash(unsigned, config)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, hash, signature) {
+		return fmt.Errorf("proof signature does not match")
+	}
+	return nil
+}
+
+// proofHash is the eddsa-jcs-2022 input: hash(proof config) || hash(document)
+func proofHash(doc, proofConfig map[string]any) ([]byte, error) {
+	canonicalConfig, err := Canonicalize(proofConfig)
+	if err != nil {
+		return nil, err
+	}
+	canonicalDoc, err := Canonicalize(doc)
+	if err != nil {
+		return nil, err
+	}
+	configHash := sha256.Sum256(canonicalConfig)
+	docHash := sha256.Sum256(canonicalDoc)
+	return append(configHash[:], docHash[:]...), nil
+}
+
+// toDocument converts an activity into a generic JSON document
+func toDocument(activity any) (map[string]any, error) {
+	raw, err := json.Marshal(activity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode activity: %w", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode activity: %w", err)
+	}
+	return doc, nil
+}
+
+// withContext adds a context URL to a JSON-LD @context value if missing
+func withContext(context any, url string) any {
+	switch context := context.(type) {
+	case nil:
+		return []any{"https://www.w3.org/ns/activitystreams", url}
+	case string:
+		if context == url {
+			return context
+		}
+		return []any{context, url}
+	case []any:
+		for _, entry := range context {
+			if entry == url {
+				return context
+			}
+		}
+		return append(context, url)
+	}
+	return context
+}
+
+// Canonicalize serializes JSON data following the JSON Canonicalization
+// Scheme (RFC 8785): sorted keys, no whitespace, ECMAScript number formatting
+func Canonicalize(value any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := canonicalize(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// canonicalize writes one JSON value in canonical form
+func canonicalize(buf *bytes.Buffer, value any) error {
+	switch value := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(value))
+	case float64:
+		number, err := canonicalNumber(value)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(number)
+	case string:
+		writeCanonicalString(buf, value)
+	case []any:
+		buf.WriteByte('[')
+		for i, entry := range value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := canonicalize(buf, entry); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		// Keys are ordered by UTF-16 code units, as in JavaScript
+		sort.Slice(keys, func(i, j int) bool {
+			return lessUTF16(keys[i], keys[j])
+		})
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, key)
+			buf.WriteByte(':')
+			if err := canonicalize(buf, value[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		// Typed values (structs, ints) go through their JSON form first
+		doc, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %T: %w", value, err)
+		}
+		var generic any
+		if err := json.Unmarshal(doc, &generic); err != nil {
+			return err
+		}
+		return canonicalize(buf, generic)
+	}
+	return nil
+}
+
+// canonicalNumber formats a number like ECMAScript's Number.prototype.toString
+func canonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("cannot canonicalize %v", f)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	abs := math.Abs(f)
+	if abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	// Go writes e+21 and e-07; ECMAScript writes e+21 and e-7
+	mantissa, exponent, _ := bytes.Cut([]byte(s), []byte("e"))
+	sign, digits := exponent[0], bytes.TrimLeft(exponent[1:], "0")
+	return string(mantissa) + "e" + string(sign) + string(digits), nil
+}
+
+// writeCanonicalString writes a JSON string with the minimal escaping RFC 8785 requires
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// lessUTF16 compares strings by their UTF-16 code units
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+// base58Encode encodes bytes in the Bitcoin base58 alphabet
+func base58Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	// Leading zero bytes are written as leading '1's
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// base58Decode decodes a Bitcoin base58 string
+func base58Decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range s {
+		digit := bytes.IndexRune([]byte(base58Alphabet), r)
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", r)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+
+	decoded := n.Bytes()
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), decoded...), nil
+}
@@ -0,0 +1,262 @@
+package activitypub
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// FEP-8b32 test vector: the key pair of the W3C eddsa-jcs-2022 examples and
+// the Create activity it signs
+const (
+	fepSecretKey = "z3u2en7t5LR2WtQH5PfFqMqwVHBeXouLzo6haApm8XHqvjxq"
+	fepPublicKey = "z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2"
+	fepActivity  = `{
+		"@context": [
+			"https://www.w3.org/ns/activitystreams",
+			"https://w3id.org/security/data-integrity/v1"
+		],
+		"id": "https://server.example/activities/1",
+		"type": "Create",
+		"actor": "https://server.example/users/alice",
+		"object": {
+			"id": "https://server.example/objects/1",
+			"type": "Note",
+			"attributedTo": "https://server.example/users/alice",
+			"content": "Hello world",
+			"location": {
+				"type": "Place",
+				"longitude": -71.184902,
+				"latitude": 25.273962
+			}
+		},
+		"proof": {
+			"type": "DataIntegrityProof",
+			"cryptosuite": "eddsa-jcs-2022",
+			"verificationMethod": "https://server.example/users/alice#ed25519-key",
+			"proofPurpose": "assertionMethod",
+			"proofValue": "zLaewdp4H9kqtwyrLatK4cjY5oRHwVcw4gibPSUDYDMhi4M49v8pcYk3ZB6D69dNpAPbUmY8ocuJ3m9KhKJEEg7z",
+			"created": "2023-02-24T23:36:38Z"
+		}
+	}`
+)
+
+// fepKeys decodes the test vector's key pair
+func fepKeys(t *testing.T) (ed25519.PrivateKey, ed25519.PublicKey) {
+	t.Helper()
+	raw, err := base58Decode(fepSecretKey[1:])
+	// Multicodec ed25519-priv, then the seed
+	if err != nil || len(raw) != 2+ed25519.SeedSize || raw[0] != 0x80 || raw[1] != 0x26 {
+		t.Fatalf("failed to decode secret key: %x, %v", raw, err)
+	}
+	publicKey, err := ParseMultikey(fepPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ed25519.NewKeyFromSeed(raw[2:]), publicKey
+}
+
+func TestProofVector(t *testing.T) {
+	privateKey, publicKey := fepKeys(t)
+	if got := EncodeMultikey(privateKey.Public().(ed25519.PublicKey)); got != fepPublicKey {
+		t.Fatalf("public key = %s, want %s", got, fepPublicKey)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(fepActivity), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if got := ProofVerificationMethod(doc); got != "https://server.example/users/alice#ed25519-key" {
+		t.Errorf("verification method = %q", got)
+	}
+	if err := VerifyProof(doc, publicKey); err != nil {
+		t.Fatalf("expected the test vector to verify: %v", err)
+	}
+
+	doc["object"].(map[string]any)["content"] = "Goodbye world"
+	if err := VerifyProof(doc, publicKey); err == nil {
+		t.Error("expected a changed object to fail")
+	}
+}
+
+func TestAddProof(t *testing.T) {
+	privateKey, publicKey := fepKeys(t)
+	activity := map[string]any{
+		"@context": "https://www.w3.org/ns/activitystreams",
+		"type":     "Like",
+		"actor":    "https://server.example/users/alice",
+		"object":   "https://remote.example/notes/1",
+	}
+	doc, err := AddProof(activity, privateKey, "https://server.example/users/alice#ed25519-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyProof(doc, publicKey); err != nil {
+		t.Fatalf("expected a fresh proof to verify: %v", err)
+	}
+	// Sent and received as JSON
+	raw, _ := json.Marshal(doc)
+	var received map[string]any
+	if err := json.Unmarshal(raw, &received); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyProof(received, publicKey); err != nil {
+		t.Errorf("expected the proof to verify after a JSON round trip: %v", err)
+	}
+
+	otherKey, _, _ := ed25519.GenerateKey(nil)
+	if err := VerifyProof(doc, otherKey); err == nil {
+		t.Error("expected another key to fail")
+	}
+	doc["actor"] = "https://server.example/users/mallory"
+	if err := VerifyProof(doc, publicKey); err == nil {
+		t.Error("expected a changed actor to fail")
+	}
+}
+
+func TestParseMultikey(t *testing.T) {
+	tests := []struct {
+		name      string
+		multibase string
+		wantErr   bool
+	}{
+		{name: "ed25519", multibase: fepPublicKey},
+		{name: "empty", multibase: "", wantErr: true},
+		{name: "base64 multibase", multibase: "m" + fepPublicKey[1:], wantErr: true},
+		{name: "invalid base58", multibase: "z0OIl", wantErr: true},
+		// The private key's multicodec, 0x8026
+		{name: "not a public key", multibase: fepSecretKey, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMultikey(tt.multibase)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseMultikey(%q) error = %v, wantErr %v", tt.multibase, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBase58(t *testing.T) {
+	// Vectors of the base58 encoding draft (draft-msporny-base58)
+	tests := []struct {
+		data    string
+		encoded string
+	}{
+		{"", ""},
+		{"Hello World!", "2NEpo7TZRRrLZSi2U"},
+		{"The quick brown fox jumps over the lazy dog.", "USm3fpXnKG5EUBx2ndxBDMPVciP5hGey2Jh4NDv6gmeo1LkMeiKrLJUUBk6Z"},
+		{"\x00\x00\x28\x7f\xb4\xcd", "11233QC4"},
+	}
+	for _, tt := range tests {
+		if got := base58Encode([]byte(tt.data)); got != tt.encoded {
+			t.Errorf("base58Encode(%q) = %q, want %q", tt.data, got, tt.encoded)
+		}
+		decoded, err := base58Decode(tt.encoded)
+		if err != nil || string(decoded) != tt.data {
+			t.Errorf("base58Decode(%q) = %q, %v, want %q", tt.encoded, decoded, err, tt.data)
+		}
+	}
+	if _, err := base58Decode("0OIl"); err == nil {
+		t.Error("expected characters outside the alphabet to fail")
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			// RFC 8785, section 3.2.2
+			name: "primitives",
+			input: `{
+				"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+				"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+				"literals": [null, true, false]
+			}`,
+			want: `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		{
+			// RFC 8785, section 3.2.3: keys are sorted by UTF-16 code units
+			name: "sorting",
+			input: `{
+				"\u20ac": "Euro Sign",
+				"\r": "Carriage Return",
+				"\ufb33": "Hebrew Letter Dalet With Dagesh",
+				"1": "One",
+				"\ud83d\ude00": "Emoji: Grinning Face",
+				"\u0080": "Control",
+				"\u00f6": "Latin Small Letter O With Diaeresis"
+			}`,
+			want: "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"ö\":\"Latin Small Letter O With Diaeresis\",\"€\":\"Euro Sign\",\"😀\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}",
+		},
+		{
+			name:  "nesting",
+			input: `{"b": [{"d": 1, "c": {}}, []], "a": "x"}`,
+			want:  `{"a":"x","b":[{"c":{},"d":1},[]]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value any
+			if err := json.Unmarshal([]byte(tt.input), &value); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Canonicalize(value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Canonicalize = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalNumber(t *testing.T) {
+	// RFC 8785, appendix B: IEEE 754 values and their ECMAScript form
+	tests := []struct {
+		bits uint64
+		want string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0xffefffffffffffff, "-1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+		{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+		{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+		{0x444b1ae4d6e2ef50, "1e+21"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x41b3de4355555554, "333333333.33333325"},
+		{0x41b3de4355555555, "333333333.3333333"},
+		{0x41b3de4355555556, "333333333.3333334"},
+		{0x41b3de4355555557, "333333333.33333343"},
+		{0xbecbf647612f3696, "-0.0000033333333333333333"},
+		{0x43143ff3c1cb0959, "1424953923781206.2"},
+	}
+	for _, tt := range tests {
+		got, err := canonicalNumber(math.Float64frombits(tt.bits))
+		if err != nil || got != tt.want {
+			t.Errorf("canonicalNumber(%#016x) = %q, %v, want %q", tt.bits, got, err, tt.want)
+		}
+	}
+
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if _, err := canonicalNumber(f); err == nil {
+			t.Errorf("expected %v to be refused", f)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package activitypub
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+)
+
+// InstanceActorURL returns the ID of the server's own actor, which follows relays
+func InstanceActorURL(baseURL string) string {
+	return baseURL + "/actor"
+}
+
+// NewInstanceActor builds the Application object representing the server
+func NewInstanceActor(baseURL, domain, publicKeyPEM string) models.Actor {
+	actorID := InstanceActorURL(baseURL)
+
+	return models.Actor{
+		Context: []any{
+			"https://www.w3.org/ns/activitystreams",
+			"https://w3id.org/security/v1",
+		},
+		ID:                        actorID,
+		Type:                      "Application",
+		PreferredUsername:         domain,
+		Name:                      "terminalpub",
+		Inbox:                     fmt.Sprintf("%s/inbox", baseURL),
+		Outbox:                    fmt.Sprintf("%s/outbox", actorID),
+		Followers:                 fmt.Sprintf("%s/followers", actorID),
+		Following:                 fmt.Sprintf("%s/following", actorID),
+		URL:                       baseURL,
+		ManuallyApprovesFollowers: true,
+		PublicKey: models.ActorPublicKey{
+			ID:           fmt.Sprintf("%s#main-key", actorID),
+			Owner:        actorID,
+			PublicKeyPem: publicKeyPEM,
+		},
+		Endpoints: map[string]any{
+			"sharedInbox": fmt.Sprintf("%s/inbox", baseURL),
+		},
+	}
+}
+
+// NewRelayFollow builds the instance actor's subscription to a relay. Relays
+// expect the public collection as the object, as Mastodon sends it.
+func NewRelayFollow(baseURL, relayActorID string) models.APActivity {
+	return relayFollow(baseURL, relayActorID, activityID(InstanceActorURL(baseURL), "follows"))
+}
+
+// NewRelayUnfollow cancels the subscription made by the Follow with followID
+func NewRelayUnfollow(baseURL, relayActorID, followID string) models.APActivity {
+	return NewUndo(relayFollow(baseURL, relayActorID, followID))
+}
+
+// relayFollow builds a relay subscription with a given activity ID
+func relayFollow(baseURL, relayActorID, followID string) models.APActivity {
+	return models.APActivity{
+		Context:   "https://www.w3.org/ns/activitystreams",
+		ID:        followID,
+		Type:      "Follow",
+		Actor:     InstanceActorURL(baseURL),
+		Object:    PublicCollection,
+		To:        []string{relayActorID},
+		Published: time.Now().UTC().Format(time.RFC3339),
+	}
+}
@@ -7,8 +7,11 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/fulgidus/terminalpub/internal/outbound"
 )
 
 // HTTPSignature represents an HTTP signature for ActivityPub requests
@@ -87,6 +90,11 @@ func SignRequest(r *http.Request, privateKeyPEM string, keyID string) error {
 	return nil
 }
 
+// requiredSignedHeaders must all be covered by an HTTP signature, so that it
+// cannot be replayed against another path or host, or outside the window its
+// Date header allows
+var requiredSignedHeaders = []string{"(request-target)", "host", "date"}
+
 // VerifyRequest verifies an HTTP signature on an incoming request
 func VerifyRequest(r *http.Request, publicKeyPEM string) error {
 	// Parse Signature header
@@ -99,6 +107,11 @@ func VerifyRequest(r *http.Request, publicKeyPEM string) error {
 	if err != nil {
 		return fmt.Errorf("failed to parse signature: %w", err)
 	}
+	for _, required := range requiredSignedHeaders {
+		if !slices.Contains(sig.Headers, required) {
+			return fmt.Errorf("signature does not cover %s", required)
+		}
+	}
 
 	// Build signing string from headers
 	var signingParts []string
@@ -106,6 +119,9 @@ func VerifyRequest(r *http.Request, publicKeyPEM string) error {
 		var value string
 		if header == "(request-target)" {
 			value = fmt.Sprintf("%s %s", strings.ToLower(r.Method), r.URL.Path)
+		} else if header == "host" {
+			// Go moves the Host header out of r.Header
+			value = r.Host
 		} else {
 			value = r.Header.Get(http.CanonicalHeaderKey(header))
 			if value == "" {
@@ -121,6 +137,45 @@ func VerifyRequest(r *http.Request, publicKeyPEM string) error {
 	return verifyString(signingString, sig.Signature, publicKeyPEM)
 }
 
+// VerifyDigest checks that the Digest header of a signed request matches its
+// body and is covered by the signature, so the body cannot be swapped for
+// another under a valid signature
+func VerifyDigest(r *http.Request, body []byte) error {
+	sig, err := parseSignatureHeader(r.Header.Get("Signature"))
+	if err != nil {
+		return fmt.Errorf("failed to parse signature: %w", err)
+	}
+	if !slices.Contains(sig.Headers, "digest") {
+		return fmt.Errorf("signature does not cover digest")
+	}
+
+	sum := sha256.Sum256(body)
+	want := base64.StdEncoding.EncodeToString(sum[:])
+	for _, digest := range strings.Split(r.Header.Get("Digest"), ",") {
+		algorithm, value, _ := strings.Cut(strings.TrimSpace(digest), "=")
+		if strings.EqualFold(algorithm, "SHA-256") {
+			if value != want {
+				return fmt.Errorf("digest does not match body")
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("missing SHA-256 digest")
+}
+
+// SignatureKeyID returns the keyId of the HTTP signature on a request
+func SignatureKeyID(r *http.Request) (string, error) {
+	sigHeader := r.Header.Get("Signature")
+	if sigHeader == "" {
+		return "", fmt.Errorf("missing Signature header")
+	}
+	sig, err := parseSignatureHeader(sigHeader)
+	if err != nil {
+		return "", err
+	}
+	return sig.KeyID, nil
+}
+
 // parseSignatureHeader parses the Signature header into components
 func parseSignatureHeader(header string) (*HTTPSignature, error) {
 	sig := &HTTPSignature{}
@@ -142,7 +197,7 @@ func parseSignatureHeader(header string) (*HTTPSignature, error) {
 		case "algorithm":
 			sig.Algorithm = value
 		case "headers":
-			sig.Headers = strings.Split(value, " ")
+			sig.Headers = strings.Fields(strings.ToLower(value))
 		case "signature":
 			sig.Signature = value
 		}
@@ -195,7 +250,16 @@ func verifyString(data string, signature string, publicKeyPEM string) error {
 
 // FetchActor fetches an ActivityPub actor from a remote server
 func FetchActor(actorURL string, privateKeyPEM string, keyID string) (map[string]any, error) {
-	req, err := http.NewRequest("GET", actorURL, nil)
+	actor, err := FetchObject(actorURL, privateKeyPEM, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch actor: %w", err)
+	}
+	return actor, nil
+}
+
+// FetchObject fetches any ActivityPub object with a signed GET
+func FetchObject(objectURL string, privateKeyPEM string, keyID string) (map[string]any, error) {
+	req, err := http.NewRequest("GET", objectURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -208,10 +272,10 @@ func FetchActor(actorURL string, privateKeyPEM string, keyID string) (map[string
 		return nil, fmt.Errorf("failed to sign request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := outbound.NewClient(10 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch actor: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -219,12 +283,12 @@ func FetchActor(actorURL string, privateKeyPEM string, keyID string) (map[string
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	var actor map[string]any
-	if err := parseJSON(resp.Body, &actor); err != nil {
-		return nil, fmt.Errorf("failed to parse actor: %w", err)
+	var object map[string]any
+	if err := parseJSON(resp.Body, &object); err != nil {
+		return nil, fmt.Errorf("failed to parse object: %w", err)
 	}
 
-	return actor, nil
+	return object, nil
 }
 
 // ResolveWebFinger resolves a WebFinger query for an actor
@@ -239,7 +303,7 @@ func ResolveWebFinger(username, domain string) (string, error) {
 
 	req.Header.Set("Accept", "application/jrd+json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := outbound.NewClient(10 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch webfinger: %w", err)
@@ -0,0 +1,79 @@
+package activitypub
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestVerifyRequest(t *testing.T) {
+	privateKey, publicKey, err := GenerateRSAKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := func() *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "https://example.social/users/alice/inbox", strings.NewReader(`{"type":"Follow"}`))
+		if err := SignRequest(req, privateKey, "https://remote.example/users/bob#main-key"); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	if err := VerifyRequest(signed(), publicKey); err != nil {
+		t.Fatalf("expected a signed request to verify: %v", err)
+	}
+
+	replayed := signed()
+	replayed.URL.Path = "/users/carol/inbox"
+	if err := VerifyRequest(replayed, publicKey); err == nil {
+		t.Error("expected a signature replayed on another path to fail")
+	}
+
+	// Signatures leaving out any of the required headers are refused, even
+	// when they are valid for the headers they do cover
+	for _, headers := range []string{"", "host", "host date", "(request-target) host", "(request-target) date digest"} {
+		req := signed()
+		var parts []string
+		for _, header := range strings.Fields(headers) {
+			value := req.Header.Get(header)
+			switch header {
+			case "(request-target)":
+				value = "post " + req.URL.Path
+			case "host":
+				value = req.Host
+			}
+			parts = append(parts, header+": "+value)
+		}
+		signature, err := signString(strings.Join(parts, "\n"), privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Signature", `keyId="https://remote.example/users/bob#main-key",algorithm="rsa-sha256",headers="`+headers+`",signature="`+signature+`"`)
+		if err := VerifyRequest(req, publicKey); err == nil {
+			t.Errorf("expected a signature over %q to be refused", headers)
+		}
+	}
+}
+
+func TestVerifyDigest(t *testing.T) {
+	privateKey, _, err := GenerateRSAKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `{"type":"Follow"}`
+	req, _ := http.NewRequest(http.MethodPost, "https://example.social/inbox", strings.NewReader(body))
+	if err := SignRequest(req, privateKey, "https://remote.example/users/bob#main-key"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyDigest(req, []byte(body)); err != nil {
+		t.Errorf("expected the digest to match the body: %v", err)
+	}
+	if err := VerifyDigest(req, []byte(`{"type":"Delete"}`)); err == nil {
+		t.Error("expected a swapped body to fail")
+	}
+	req.Header.Set("Signature", strings.Replace(req.Header.Get("Signature"), " digest", "", 1))
+	if err := VerifyDigest(req, []byte(body)); err == nil {
+		t.Error("expected a digest the signature does not cover to fail")
+	}
+}
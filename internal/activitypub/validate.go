@@ -0,0 +1,80 @@
+package activitypub
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+)
+
+// objectRequired lists the activity types that are meaningless without an object
+var objectRequired = map[string]bool{
+	"Create":     true,
+	"Update":     true,
+	"Delete":     true,
+	"Follow":     true,
+	"Accept":     true,
+	"Reject":     true,
+	"Announce":   true,
+	"Like":       true,
+	"EmojiReact": true,
+	"Undo":       true,
+	"Block":      true,
+	"Move":       true,
+	"Add":        true,
+	"Remove":     true,
+	"Flag":       true,
+}
+
+// IsActivityContentType reports whether a Content-Type header names one of
+// the media types ActivityPub servers post activities with
+func IsActivityContentType(header string) bool {
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "application/activity+json", "application/ld+json", "application/json":
+		return true
+	}
+	return false
+}
+
+// ValidateActivity checks that an inbound activity has the fields the inbox
+// relies on: a type, an actor given by an absolute http(s) URL, an id of the
+// same form when present, and an object for the types that act on one
+func ValidateActivity(activity map[string]any) error {
+	if activity == nil {
+		return fmt.Errorf("activity must be a JSON object")
+	}
+	activityType, _ := activity["type"].(string)
+	if activityType == "" {
+		return fmt.Errorf("missing type")
+	}
+	actorID, _ := activity["actor"].(string)
+	if !isHTTPURL(actorID) {
+		return fmt.Errorf("actor must be an http(s) URL")
+	}
+	if id, ok := activity["id"]; ok {
+		if s, _ := id.(string); !isHTTPURL(s) {
+			return fmt.Errorf("id must be an http(s) URL")
+		}
+	}
+	if objectRequired[activityType] {
+		switch object := activity["object"].(type) {
+		case string:
+			if object == "" {
+				return fmt.Errorf("missing object")
+			}
+		case map[string]any:
+		default:
+			return fmt.Errorf("%s requires an object", activityType)
+		}
+	}
+	return nil
+}
+
+// isHTTPURL reports whether s is an absolute http or https URL with a host
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
@@ -0,0 +1,66 @@
+package activitypub
+
+import "testing"
+
+func TestIsActivityContentType(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"application/activity+json", true},
+		{`application/ld+json; profile="https://www.w3.org/ns/activitystreams"`, true},
+		{"application/json; charset=utf-8", true},
+		{"Application/Activity+JSON", true},
+		{"text/html", false},
+		{"application/x-www-form-urlencoded", false},
+		{"", false},
+		{"application/json; =broken", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := IsActivityContentType(tt.header); got != tt.want {
+				t.Errorf("IsActivityContentType(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateActivity(t *testing.T) {
+	const actor = "https://example.social/users/alice"
+	note := map[string]any{"type": "Note", "content": "hi"}
+
+	tests := []struct {
+		name     string
+		activity map[string]any
+		valid    bool
+	}{
+		{"create with an embedded object", map[string]any{"type": "Create", "actor": actor, "id": actor + "/1", "object": note}, true},
+		{"follow with an object URL", map[string]any{"type": "Follow", "actor": actor, "object": "https://terminalpub.example/users/bob"}, true},
+		{"no id", map[string]any{"type": "Like", "actor": actor, "object": actor + "/statuses/1"}, true},
+		{"http actor", map[string]any{"type": "Delete", "actor": "http://example.social/users/alice", "object": "http://example.social/users/alice"}, true},
+		{"type without an object", map[string]any{"type": "Question", "actor": actor}, true},
+		{"nil", nil, false},
+		{"no type", map[string]any{"actor": actor, "object": note}, false},
+		{"type not a string", map[string]any{"type": 1, "actor": actor, "object": note}, false},
+		{"no actor", map[string]any{"type": "Create", "object": note}, false},
+		{"actor object", map[string]any{"type": "Create", "actor": map[string]any{"id": actor}, "object": note}, false},
+		{"relative actor", map[string]any{"type": "Create", "actor": "/users/alice", "object": note}, false},
+		{"non-http actor", map[string]any{"type": "Create", "actor": "javascript:alert(1)", "object": note}, false},
+		{"actor without host", map[string]any{"type": "Create", "actor": "https:///users/alice", "object": note}, false},
+		{"bad id", map[string]any{"type": "Create", "actor": actor, "id": "urn:uuid:1", "object": note}, false},
+		{"id not a string", map[string]any{"type": "Create", "actor": actor, "id": 7, "object": note}, false},
+		{"missing object", map[string]any{"type": "Create", "actor": actor}, false},
+		{"empty object", map[string]any{"type": "Undo", "actor": actor, "object": ""}, false},
+		{"object of the wrong kind", map[string]any{"type": "Announce", "actor": actor, "object": []any{"a"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateActivity(tt.activity)
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateActivity = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
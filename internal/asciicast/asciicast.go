@@ -0,0 +1,192 @@
+// Package asciicast records terminal output as asciinema v2 casts
+// (https://docs.asciinema.org/manual/asciicast/v2/)
+package asciicast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type casts are served with
+const ContentType = "application/x-asciicast"
+
+// ErrRecording is returned when starting a recorder that is already running
+var ErrRecording = errors.New("already recording")
+
+// Header is the first line of a cast
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recorder captures what is written to a terminal while it is started. It
+// sits in front of every session and costs nothing until then. It is safe
+// for concurrent use.
+type Recorder struct {
+	mu        sync.Mutex
+	term      string
+	width     int
+	height    int
+	maxSize   int // Bytes a cast may grow to; 0 for no limit
+	now       func() time.Time
+	recording bool
+	started   time.Time
+	header    Header // Of the cast being recorded
+	cast      bytes.Buffer
+	partial   []byte // Incomplete UTF-8 sequence held for the next write
+	truncated bool
+}
+
+// Recording is a finished cast
+type Recording struct {
+	Cast      []byte
+	Title     string
+	Width     int
+	Height    int
+	Duration  time.Duration
+	Truncated bool // Output stopped being recorded at the size limit
+}
+
+// NewRecorder returns a stopped recorder for a terminal of the given type
+// and size
+func NewRecorder(term string, width, height, maxSize int) *Recorder {
+	return &Recorder{term: term, width: width, height: height, maxSize: maxSize, now: time.Now}
+}
+
+// Start begins a new cast; the terminal should be redrawn right after so it
+// opens on a complete screen
+func (r *Recorder) Start(title string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.recording {
+		return ErrRecording
+	}
+
+	r.started = r.now()
+	r.cast.Reset()
+	r.partial = nil
+	r.truncated = false
+	r.header = Header{
+		Version:   2,
+		Width:     r.width,
+		Height:    r.height,
+		Timestamp: r.started.Unix(),
+		Title:     title,
+		Env:       map[string]string{"TERM": r.term},
+	}
+	header, err := json.Marshal(r.header)
+	if err != nil {
+		return fmt.Errorf("failed to encode cast header: %w", err)
+	}
+	r.cast.Write(header)
+	r.cast.WriteByte('\n')
+	r.recording = true
+	return nil
+}
+
+// Recording reports whether the recorder is running
+func (r *Recorder) Recording() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recording
+}
+
+// Write records output sent to the terminal. It never fails, so it can be
+// used alongside the real output without affecting it.
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.recording || len(p) == 0 {
+		return len(p), nil
+	}
+
+	// Events are JSON strings, so a character split across writes is
+	// recorded with the write that completes it
+	data := append(r.partial, p...)
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	r.partial = append([]byte(nil), data[cut:]...)
+	if cut > 0 {
+		r.event("o", string(data[:cut]))
+	}
+	return len(p), nil
+}
+
+// Resize records the terminal changing size
+func (r *Recorder) Resize(width, height int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.recording {
+		r.event("r", fmt.Sprintf("%dx%d", width, height))
+	}
+	r.width, r.height = width, height
+}
+
+// event appends an event line unless that would exceed the size limit
+func (r *Recorder) event(code, data string) {
+	if r.truncated {
+		return
+	}
+	elapsed := r.now().Sub(r.started).Seconds()
+	line, err := json.Marshal([]any{json.Number(fmt.Sprintf("%.6f", elapsed)), code, data})
+	if err != nil {
+		return
+	}
+	if r.maxSize > 0 && r.cast.Len()+len(line)+1 > r.maxSize {
+		r.truncated = true
+		return
+	}
+	r.cast.Write(line)
+	r.cast.WriteByte('\n')
+}
+
+// Stop ends the cast and returns it, or nil if the recorder was not running
+func (r *Recorder) Stop() *Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.recording {
+		return nil
+	}
+	r.recording = false
+
+	recording := &Recording{
+		Cast:      bytes.Clone(r.cast.Bytes()),
+		Title:     r.header.Title,
+		Width:     r.header.Width,
+		Height:    r.header.Height,
+		Duration:  r.now().Sub(r.started),
+		Truncated: r.truncated,
+	}
+	r.cast.Reset()
+	r.partial = nil
+	return recording
+}
+
+type contextKey struct{ name string }
+
+// ContextKey is the key an SSH session's Recorder is stored under in its
+// context
+var ContextKey = &contextKey{"asciicast-recorder"}
+
+// FromContext returns the Recorder stored in ctx, or nil
+func FromContext(ctx context.Context) *Recorder {
+	recorder, _ := ctx.Value(ContextKey).(*Recorder)
+	return recorder
+}
@@ -0,0 +1,106 @@
+package asciicast
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRecorder returns a recorder whose clock advances a second per reading
+func newTestRecorder(maxSize int) *Recorder {
+	r := NewRecorder("xterm-256color", 80, 24, maxSize)
+	clock := time.Unix(1700000000, 0)
+	r.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	return r
+}
+
+// parseCast splits a cast into its header and events
+func parseCast(t *testing.T, cast []byte) (Header, [][]any) {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(string(cast), "\n"), "\n")
+	var header Header
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatalf("invalid header %q: %v", lines[0], err)
+	}
+	var events [][]any
+	for _, line := range lines[1:] {
+		var event []any
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid event %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	return header, events
+}
+
+func TestRecorder(t *testing.T) {
+	r := newTestRecorder(0)
+	r.Write([]byte("before"))
+	if err := r.Start("demo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Start("again"); err != ErrRecording {
+		t.Errorf("expected ErrRecording, got %v", err)
+	}
+
+	r.Write([]byte("\x1b[2Jhello\r\n"))
+	// A character split across writes ends up in one event
+	r.Write([]byte("caf\xc3"))
+	r.Write([]byte("\xa9"))
+	r.Resize(100, 30)
+
+	recording := r.Stop()
+	if recording == nil {
+		t.Fatal("expected a recording")
+	}
+	r.Write([]byte("after"))
+	if r.Stop() != nil {
+		t.Error("expected nothing from a stopped recorder")
+	}
+
+	header, events := parseCast(t, recording.Cast)
+	if header.Version != 2 || header.Width != 80 || header.Height != 24 || header.Title != "demo" || header.Env["TERM"] != "xterm-256color" {
+		t.Errorf("unexpected header %+v", header)
+	}
+	want := [][]any{
+		{1.0, "o", "\x1b[2Jhello\r\n"},
+		{2.0, "o", "caf"},
+		{3.0, "o", "é"},
+		{4.0, "r", "100x30"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %v", len(want), events)
+	}
+	for i := range want {
+		for j := range want[i] {
+			if events[i][j] != want[i][j] {
+				t.Errorf("event %d: expected %v, got %v", i, want[i], events[i])
+				break
+			}
+		}
+	}
+	if recording.Duration != 5*time.Second || recording.Truncated {
+		t.Errorf("unexpected recording %+v", recording)
+	}
+}
+
+func TestRecorderSizeLimit(t *testing.T) {
+	r := newTestRecorder(200)
+	if err := r.Start(""); err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		r.Write([]byte(strings.Repeat("x", 40)))
+	}
+	recording := r.Stop()
+	if !recording.Truncated || len(recording.Cast) > 200 {
+		t.Fatalf("expected a cast cut at 200 bytes, got %d bytes", len(recording.Cast))
+	}
+	if _, events := parseCast(t, recording.Cast); len(events) == 0 {
+		t.Error("expected the events that fit")
+	}
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/db"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// apiTokenPrefix marks plaintext tokens issued by terminalpub
+const apiTokenPrefix = "tp_"
+
+// validateTokenStmt looks up a token by its hash; every API request runs it
+var validateTokenStmt = db.Prepare("api_token_by_hash", `
+	SELECT t.id, t.user_id, t.name, t.token_hash, t.token_prefix, t.scopes, t.last_used_at, t.expires_at, t.created_at,
+	       u.suspended_at IS NOT NULL
+	FROM api_tokens t
+	JOIN users u ON u.id = t.user_id
+	WHERE t.token_hash = $1
+`)
+
+// APITokenService manages personal API tokens
+type APITokenService struct {
+	db    *pgxpool.Pool
+	audit *AuditLog
+}
+
+// NewAPITokenService creates a new APITokenService instance
+func NewAPITokenService(db *pgxpool.Pool) *APITokenService {
+	return &APITokenService{db: db, audit: NewAuditLog(db)}
+}
+
+// hashAPIToken returns the hex-encoded SHA256 of a plaintext token
+func hashAPIToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// CreateToken issues a new token and returns it with its plaintext value.
+// The plaintext is only available here; only its hash is stored.
+func (s *APITokenService) CreateToken(ctx context.Context, userID int, name, scopes string) (*models.APIToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("token name is required")
+	}
+	if scopes == "" {
+		scopes = "read"
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	plaintext := apiTokenPrefix + hex.EncodeToString(raw)
+
+	token := &models.APIToken{
+		UserID:      userID,
+		Name:        name,
+		TokenHash:   hashAPIToken(plaintext),
+		TokenPrefix: plaintext[:len(apiTokenPrefix)+6],
+		Scopes:      scopes,
+	}
+
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scopes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, token.UserID, token.Name, token.TokenHash, token.TokenPrefix, token.Scopes).Scan(&token.ID, &token.CreatedAt)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	s.audit.Record(ctx, userID, models.AuditTokenGranted, fmt.Sprintf("%s (%s, %s...)", token.Name, token.Scopes, token.TokenPrefix))
+	return token, plaintext, nil
+}
+
+// ValidateToken looks up a plaintext token and returns it if it is valid
+func (s *APITokenService) ValidateToken(ctx context.Context, plaintext string) (*models.APIToken, error) {
+	if !strings.HasPrefix(plaintext, apiTokenPrefix) {
+		return nil, fmt.Errorf("invalid API token")
+	}
+
+	var token models.APIToken
+	var suspended bool
+	err := s.db.QueryRow(ctx, validateTokenStmt, hashAPIToken(plaintext)).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		&token.TokenPrefix,
+		&token.Scopes,
+		&token.LastUsedAt,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&suspended,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid API token")
+	}
+	if suspended {
+		return nil, ErrAccountSuspended
+	}
+
+	if token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt) {
+		return nil, fmt.Errorf("API token expired")
+	}
+
+	// Update last_used_at for this token
+	go func() {
+		updateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, _ = s.db.Exec(updateCtx,
+			"UPDATE api_tokens SET last_used_at = NOW() WHERE id = $1",
+			token.ID,
+		)
+	}()
+
+	return &token, nil
+}
+
+// ListUserTokens lists all API tokens for a user
+func (s *APITokenService) ListUserTokens(ctx context.Context, userID int) ([]models.APIToken, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, user_id, name, token_prefix, scopes, last_used_at, expires_at, created_at
+		FROM api_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []models.APIToken
+	for rows.Next() {
+		var token models.APIToken
+		err := rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			&token.TokenPrefix,
+			&token.Scopes,
+			&token.LastUsedAt,
+			&token.ExpiresAt,
+			&token.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+// RevokeToken deletes one of a user's API tokens
+func (s *APITokenService) RevokeToken(ctx context.Context, userID int, tokenID int) error {
+	var name, prefix string
+	err := s.db.QueryRow(ctx,
+		"DELETE FROM api_tokens WHERE id = $1 AND user_id = $2 RETURNING name, token_prefix",
+		tokenID, userID,
+	).Scan(&name, &prefix)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("API token not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	}
+
+	s.audit.Record(ctx, userID, models.AuditTokenRevoked, fmt.Sprintf("%s (%s...)", name, prefix))
+	return nil
+}
+
+// RevokeClientToken deletes the token with the given plaintext value if it
+// was issued to the OAuth client clientID
+func (s *APITokenService) RevokeClientToken(ctx context.Context, clientID, plaintext string) error {
+	var userID int
+	var name, prefix string
+	err := s.db.QueryRow(ctx,
+		"DELETE FROM api_tokens WHERE token_hash = $1 AND client_id = $2 RETURNING user_id, name, token_prefix",
+		hashAPIToken(plaintext), clientID,
+	).Scan(&userID, &name, &prefix)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	}
+	s.audit.Record(ctx, userID, models.AuditTokenRevoked, fmt.Sprintf("%s (%s...)", name, prefix))
+	return nil
+}
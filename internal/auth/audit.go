@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"log"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// clientIPKey is the context key of the address a request comes from
+type clientIPKey struct{}
+
+// WithClientIP returns a context recording the address of the client acting,
+// which audit log entries made with it carry
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP returns the client address stored by WithClientIP, if any
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// AuditLog records security-sensitive events: logins, SSH key and token
+// changes, admin actions and failed device-code attempts
+type AuditLog struct {
+	db *pgxpool.Pool
+}
+
+// NewAuditLog creates a new AuditLog instance
+func NewAuditLog(db *pgxpool.Pool) *AuditLog {
+	return &AuditLog{db: db}
+}
+
+// Record adds an event to the audit log. userID is 0 when no account is
+// known; the client address is taken from ctx. Failures are only logged, so
+// that they never block the action being recorded.
+func (a *AuditLog) Record(ctx context.Context, userID int, event models.AuditEventType, detail string) {
+	var user *int
+	if userID != 0 {
+		user = &userID
+	}
+	_, err := a.db.Exec(ctx, `
+		INSERT INTO audit_log (user_id, event, detail, ip_address)
+		VALUES ($1, $2, $3, $4)
+	`, user, string(event), detail, ClientIP(ctx))
+	if err != nil {
+		log.Printf("Failed to record %s audit event: %v", event, err)
+	}
+}
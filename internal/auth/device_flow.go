@@ -86,7 +86,8 @@ func generateDeviceCode() (string, error) {
 }
 
 // InitiateDeviceFlow starts a new device authorization flow
-func (d *DeviceFlowService) InitiateDeviceFlow(ctx context.Context, instanceURL, sshSessionID string) (*DeviceAuthResponse, error) {
+// inviteCode is only used if the login creates a new account.
+func (d *DeviceFlowService) InitiateDeviceFlow(ctx context.Context, instanceURL, sshSessionID, inviteCode string) (*DeviceAuthResponse, error) {
 	// Generate codes
 	userCodeFormatted, err := generateUserCode()
 	if err != nil {
@@ -105,8 +106,8 @@ func (d *DeviceFlowService) InitiateDeviceFlow(ctx context.Context, instanceURL,
 
 	// Store in database
 	query := `
-		INSERT INTO device_codes (user_code, device_code, instance_url, ssh_session_id, verification_uri, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO device_codes (user_code, device_code, instance_url, ssh_session_id, verification_uri, expires_at, invite_code)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
 		RETURNING id
 	`
 
@@ -118,6 +119,7 @@ func (d *DeviceFlowService) InitiateDeviceFlow(ctx context.Context, instanceURL,
 		sshSessionID,
 		d.verificationURI,
 		expiresAt,
+		inviteCode,
 	).Scan(&id)
 
 	if err != nil {
@@ -141,7 +143,7 @@ func (d *DeviceFlowService) GetDeviceCodeByUserCode(ctx context.Context, userCod
 
 	query := `
 		SELECT id, user_code, device_code, instance_url, ssh_session_id, 
-		       verification_uri, expires_at, authorized, user_id, created_at
+		       verification_uri, expires_at, authorized, user_id, COALESCE(invite_code, ''), created_at
 		FROM device_codes
 		WHERE user_code = $1
 	`
@@ -157,6 +159,7 @@ func (d *DeviceFlowService) GetDeviceCodeByUserCode(ctx context.Context, userCod
 		&dc.ExpiresAt,
 		&dc.Authorized,
 		&dc.UserID,
+		&dc.InviteCode,
 		&dc.CreatedAt,
 	)
 
@@ -176,7 +179,7 @@ func (d *DeviceFlowService) GetDeviceCodeByUserCode(ctx context.Context, userCod
 func (d *DeviceFlowService) GetDeviceCodeByDeviceCode(ctx context.Context, deviceCode string) (*models.DeviceCode, error) {
 	query := `
 		SELECT id, user_code, device_code, instance_url, ssh_session_id, 
-		       verification_uri, expires_at, authorized, user_id, created_at
+		       verification_uri, expires_at, authorized, user_id, COALESCE(invite_code, ''), created_at
 		FROM device_codes
 		WHERE device_code = $1
 	`
@@ -192,6 +195,7 @@ func (d *DeviceFlowService) GetDeviceCodeByDeviceCode(ctx context.Context, devic
 		&dc.ExpiresAt,
 		&dc.Authorized,
 		&dc.UserID,
+		&dc.InviteCode,
 		&dc.CreatedAt,
 	)
 
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/outbound"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -65,6 +66,13 @@ func NormalizeInstanceURL(instance string) string {
 	return "https://" + instance
 }
 
+// LocalUsername derives the terminalpub username for a Mastodon account
+// (e.g. "alice" on https://mastodon.social becomes "alice@mastodon_social")
+func LocalUsername(mastodonUsername, instanceURL string) string {
+	username := fmt.Sprintf("%s@%s", mastodonUsername, strings.TrimPrefix(NormalizeInstanceURL(instanceURL), "https://"))
+	return strings.ReplaceAll(username, ".", "_")
+}
+
 // GetOrCreateApp retrieves an existing app registration or creates a new one
 func (m *MastodonService) GetOrCreateApp(ctx context.Context, instanceURL string) (*models.MastodonApp, error) {
 	instanceURL = NormalizeInstanceURL(instanceURL)
@@ -130,7 +138,7 @@ func (m *MastodonService) registerApp(ctx context.Context, instanceURL string) (
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := outbound.NewClient(30 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to register app: %w", err)
@@ -190,7 +198,7 @@ func (m *MastodonService) GetAccount(ctx context.Context, instanceURL, accessTok
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := outbound.NewClient(30 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get account: %w", err)
@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// OutOfBandRedirectURI asks for the authorization code to be shown instead of redirected
+const OutOfBandRedirectURI = "urn:ietf:wg:oauth:2.0:oob"
+
+// loginCodeTTL is how long a login code from the SSH session stays valid
+const loginCodeTTL = 10 * time.Minute
+
+// authorizationCodeTTL is how long a client has to exchange an authorization code
+const authorizationCodeTTL = 10 * time.Minute
+
+// supportedScopes are the Mastodon scopes a client may request
+var supportedScopes = []string{"read", "write", "follow", "push", "profile"}
+
+// granularScopes are the Mastodon scopes narrower than read and write
+var granularScopes = []string{
+	"read:accounts", "read:blocks", "read:bookmarks", "read:favourites", "read:filters", "read:follows",
+	"read:lists", "read:mutes", "read:notifications", "read:search", "read:statuses",
+	"write:accounts", "write:blocks", "write:bookmarks", "write:conversations", "write:favourites",
+	"write:filters", "write:follows", "write:lists", "write:media", "write:mutes", "write:notifications",
+	"write:reports", "write:statuses",
+}
+
+// ErrInvalidGrant is returned when an authorization code or client credential does not check out
+var ErrInvalidGrant = errors.New("invalid grant")
+
+// OAuthServer lets client applications such as Mastodon apps log users into
+// terminalpub. Since users have no passwords, they confirm with a one-time
+// login code from their SSH session; access tokens are personal API tokens.
+type OAuthServer struct {
+	db     *pgxpool.Pool
+	tokens *APITokenService
+}
+
+// NewOAuthServer creates a new OAuthServer instance
+func NewOAuthServer(db *pgxpool.Pool) *OAuthServer {
+	return &OAuthServer{db: db, tokens: NewAPITokenService(db)}
+}
+
+// randomHex returns n random bytes, hex-encoded
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// RegisterApp registers a client application
+func (s *OAuthServer) RegisterApp(ctx context.Context, name, redirectURIs, scopes, website string) (*models.OAuthClient, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("client_name is required")
+	}
+	if len(name) > 255 {
+		return nil, fmt.Errorf("client_name is too long")
+	}
+
+	uris := strings.Fields(redirectURIs)
+	if len(uris) == 0 {
+		return nil, fmt.Errorf("redirect_uris is required")
+	}
+	for _, uri := range uris {
+		if uri == OutOfBandRedirectURI {
+			continue
+		}
+		if parsed, err := url.Parse(uri); err != nil || parsed.Scheme == "" {
+			return nil, fmt.Errorf("invalid redirect URI %q", uri)
+		}
+	}
+
+	scopes, err := normalizeScopes(scopes, "")
+	if err != nil {
+		return nil, err
+	}
+	if scopes == "" {
+		scopes = "read"
+	}
+
+	clientID, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	clientSecret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &models.OAuthClient{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Name:         name,
+		Website:      website,
+		RedirectURIs: uris,
+		Scopes:       scopes,
+	}
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO oauth_clients (client_id, client_secret, name, website, redirect_uris, scopes)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		RETURNING id, created_at
+	`, clientID, clientSecret, name, website, strings.Join(uris, "\n"), client.Scopes).Scan(&client.ID, &client.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register app: %w", err)
+	}
+	return client, nil
+}
+
+// GetApp returns a registered client application
+func (s *OAuthServer) GetApp(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	client := &models.OAuthClient{}
+	var redirectURIs string
+	err := s.db.QueryRow(ctx, `
+		SELECT id, client_id, client_secret, name, COALESCE(website, ''), redirect_uris, scopes, created_at
+		FROM oauth_clients WHERE client_id = $1
+	`, clientID).Scan(&client.ID, &client.ClientID, &client.ClientSecret, &client.Name, &client.Website, &redirectURIs, &client.Scopes, &client.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("unknown client")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load app: %w", err)
+	}
+	client.RedirectURIs = strings.Split(redirectURIs, "\n")
+	return client, nil
+}
+
+// AllowsRedirect reports whether a redirect URI was registered by the client
+func AllowsRedirect(client *models.OAuthClient, redirectURI string) bool {
+	return slices.Contains(client.RedirectURIs, redirectURI)
+}
+
+// normalizeScopes validates space-separated scopes, keeping them within
+// allowed if it is set, and returns them deduplicated
+func normalizeScopes(scopes, allowed string) (string, error) {
+	var result []string
+	for _, scope := range strings.Fields(scopes) {
+		base, _, granular := strings.Cut(scope, ":")
+		if !slices.Contains(supportedScopes, base) || granular && !slices.Contains(granularScopes, scope) {
+			return "", fmt.Errorf("unsupported scope %q", scope)
+		}
+		if allowed != "" && !slices.Contains(strings.Fields(allowed), scope) && !slices.Contains(strings.Fields(allowed), base) {
+			return "", fmt.Errorf("scope %q was not registered by the app", scope)
+		}
+		if !slices.Contains(result, scope) {
+			result = append(result, scope)
+		}
+	}
+	return strings.Join(result, " "), nil
+}
+
+// CreateLoginCode issues a one-time code the user enters on the authorization page
+func (s *OAuthServer) CreateLoginCode(ctx context.Context, userID int) (string, error) {
+	raw, err := randomHex(4)
+	if err != nil {
+		return "", err
+	}
+	code := strings.ToUpper(raw[:4] + "-" + raw[4:])
+
+	_, err = s.db.Exec(ctx,
+		"INSERT INTO oauth_login_codes (code_hash, user_id, expires_at) VALUES ($1, $2, $3)",
+		hashAPIToken(normalizeLoginCode(code)), userID, time.Now().Add(loginCodeTTL),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to save login code: %w", err)
+	}
+	return code, nil
+}
+
+// normalizeLoginCode makes login codes case- and dash-insensitive
+func normalizeLoginCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// Authorize consumes a login code and issues an authorization code for the
+// client, returning it with the user it belongs to
+func (s *OAuthServer) Authorize(ctx context.Context, client *models.OAuthClient, loginCode, redirectURI, scopes string) (string, int, error) {
+	if !AllowsRedirect(client, redirectURI) {
+		return "", 0, fmt.Errorf("redirect URI was not registered by the app")
+	}
+	if scopes == "" {
+		scopes = "read"
+	}
+	scopes, err := normalizeScopes(scopes, client.Scopes)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// Login codes are single use, so they are deleted whether or not they expired
+	var userID int
+	var expiresAt time.Time
+	err = s.db.QueryRow(ctx,
+		"DELETE FROM oauth_login_codes WHERE code_hash = $1 RETURNING user_id, expires_at",
+		hashAPIToken(normalizeLoginCode(loginCode)),
+	).Scan(&userID, &expiresAt)
+	if err != nil || time.Now().After(expiresAt) {
+		return "", 0, fmt.Errorf("invalid or expired login code")
+	}
+
+	code, err := randomHex(32)
+	if err != nil {
+		return "", 0, err
+	}
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, hashAPIToken(code), client.ClientID, userID, redirectURI, scopes, time.Now().Add(authorizationCodeTTL))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to save authorization code: %w", err)
+	}
+	return code, userID, nil
+}
+
+// Exchange trades an authorization code for an access token, returning the
+// token and its scopes
+func (s *OAuthServer) Exchange(ctx context.Context, clientID, clientSecret, code, redirectURI string) (string, string, error) {
+	client, err := s.GetApp(ctx, clientID)
+	if err != nil || subtle.ConstantTimeCompare([]byte(client.ClientSecret), []byte(clientSecret)) != 1 {
+		return "", "", ErrInvalidGrant
+	}
+
+	var userID int
+	var storedRedirect, scopes string
+	var expiresAt time.Time
+	err = s.db.QueryRow(ctx, `
+		DELETE FROM oauth_authorization_codes
+		WHERE code_hash = $1 AND client_id = $2
+		RETURNING user_id, redirect_uri, scopes, expires_at
+	`, hashAPIToken(code), clientID).Scan(&userID, &storedRedirect, &scopes, &expiresAt)
+	if err != nil || time.Now().After(expiresAt) || storedRedirect != redirectURI {
+		return "", "", ErrInvalidGrant
+	}
+
+	token, plaintext, err := s.tokens.CreateToken(ctx, userID, client.Name, scopes)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := s.db.Exec(ctx, "UPDATE api_tokens SET client_id = $2 WHERE id = $1", token.ID, clientID); err != nil {
+		return "", "", fmt.Errorf("failed to record token client: %w", err)
+	}
+	return plaintext, scopes, nil
+}
+
+// Revoke deletes an access token on behalf of the client it was issued to.
+// Tokens of other clients are left alone, as if they did not exist.
+func (s *OAuthServer) Revoke(ctx context.Context, clientID, clientSecret, token string) error {
+	client, err := s.GetApp(ctx, clientID)
+	if err != nil || subtle.ConstantTimeCompare([]byte(client.ClientSecret), []byte(clientSecret)) != 1 {
+		return ErrInvalidGrant
+	}
+	return s.tokens.RevokeClientToken(ctx, clientID, token)
+}
+
+// CleanupExpiredCodes removes login and authorization codes that were never used
+func (s *OAuthServer) CleanupExpiredCodes(ctx context.Context) error {
+	for _, table := range []string{"oauth_login_codes", "oauth_authorization_codes"} {
+		if _, err := s.db.Exec(ctx, "DELETE FROM "+table+" WHERE expires_at < NOW()"); err != nil {
+			return fmt.Errorf("failed to clean up %s: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package auth
+
+import "testing"
+
+func TestNormalizeScopes(t *testing.T) {
+	tests := []struct {
+		scopes  string
+		allowed string
+		want    string
+		wantErr bool
+	}{
+		{scopes: "read write", want: "read write"},
+		{scopes: "read  read push", want: "read push"},
+		{scopes: "read:statuses write:statuses", want: "read:statuses write:statuses"},
+		{scopes: "read:statuses", allowed: "read", want: "read:statuses"},
+		{scopes: "read:notifications", allowed: "read:notifications", want: "read:notifications"},
+		{scopes: "read", allowed: "read:notifications", wantErr: true},
+		{scopes: "write", allowed: "read", wantErr: true},
+		{scopes: "read:anything", wantErr: true},
+		{scopes: "read:", wantErr: true},
+		{scopes: "push:statuses", wantErr: true},
+		{scopes: "admin:read", wantErr: true},
+		{scopes: "admin", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeScopes(tt.scopes, tt.allowed)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeScopes(%q, %q) = %q, %v, want %q, error %v", tt.scopes, tt.allowed, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/fulgidus/terminalpub/internal/db"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
@@ -22,14 +23,23 @@ const (
 	RedisSessionPrefix = "session:"
 )
 
+// sessionByIDStmt loads an unexpired session when Redis does not have it
+var sessionByIDStmt = db.Prepare("session_by_id", `
+	SELECT s.id, s.user_id, s.public_key, s.ip_address, s.anonymous,
+	       s.created_at, s.last_seen_at, s.expires_at, u.username
+	FROM sessions s
+	LEFT JOIN users u ON s.user_id = u.id
+	WHERE s.id = $1 AND s.expires_at > NOW()
+`)
+
 // SessionManager manages SSH sessions using Redis for fast access and PostgreSQL for persistence
 type SessionManager struct {
 	db    *pgxpool.Pool
-	redis *redis.Client
+	redis redis.UniversalClient
 }
 
 // NewSessionManager creates a new SessionManager instance
-func NewSessionManager(db *pgxpool.Pool, redisClient *redis.Client) *SessionManager {
+func NewSessionManager(db *pgxpool.Pool, redisClient redis.UniversalClient) *SessionManager {
 	return &SessionManager{
 		db:    db,
 		redis: redisClient,
@@ -164,18 +174,10 @@ func (sm *SessionManager) getSessionFromRedis(ctx context.Context, sessionID str
 
 // getSessionFromDB retrieves session from PostgreSQL
 func (sm *SessionManager) getSessionFromDB(ctx context.Context, sessionID string) (*SessionData, error) {
-	query := `
-		SELECT s.id, s.user_id, s.public_key, s.ip_address, s.anonymous,
-		       s.created_at, s.last_seen_at, s.expires_at, u.username
-		FROM sessions s
-		LEFT JOIN users u ON s.user_id = u.id
-		WHERE s.id = $1 AND s.expires_at > NOW()
-	`
-
 	var sessionData SessionData
 	var username *string
 
-	err := sm.db.QueryRow(ctx, query, sessionID).Scan(
+	err := sm.db.QueryRow(ctx, sessionByIDStmt, sessionID).Scan(
 		&sessionData.SessionID,
 		&sessionData.UserID,
 		&sessionData.PublicKey,
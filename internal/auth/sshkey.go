@@ -4,23 +4,44 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/fulgidus/terminalpub/internal/db"
 	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/ssh"
 )
 
+// ErrAccountSuspended is returned when a suspended user tries to log in
+var ErrAccountSuspended = errors.New("account has been suspended")
+
+// userBySSHKeyStmt finds the owner of a key by fingerprint or public key;
+// every SSH connection runs it
+var userBySSHKeyStmt = db.Prepare("user_by_ssh_key", `
+	SELECT u.id, u.username, u.email, COALESCE(u.password_hash, ''), COALESCE(u.primary_mastodon_instance, ''),
+	       COALESCE(u.primary_mastodon_id, ''), COALESCE(u.primary_mastodon_acct, ''), u.private_key, u.public_key,
+	       u.actor_url, u.inbox_url, u.outbox_url, u.followers_url, u.following_url,
+	       u.created_at, u.updated_at, COALESCE(u.bio, ''), COALESCE(u.avatar_url, ''), u.username_confirmed, COALESCE(u.display_name, ''),
+	       u.suspended_at IS NOT NULL
+	FROM users u
+	INNER JOIN user_ssh_keys k ON k.user_id = u.id
+	WHERE k.fingerprint = $1 OR k.public_key = $2
+	LIMIT 1
+`)
+
 // SSHKeyService manages SSH public keys for users
 type SSHKeyService struct {
-	db *pgxpool.Pool
+	db    *pgxpool.Pool
+	audit *AuditLog
 }
 
 // NewSSHKeyService creates a new SSHKeyService instance
 func NewSSHKeyService(db *pgxpool.Pool) *SSHKeyService {
-	return &SSHKeyService{db: db}
+	return &SSHKeyService{db: db, audit: NewAuditLog(db)}
 }
 
 // ParseSSHPublicKey parses an SSH public key and extracts metadata
@@ -63,19 +84,9 @@ func (s *SSHKeyService) GetUserBySSHKey(ctx context.Context, publicKeyStr string
 	}
 
 	// Look up user by fingerprint (faster) or public key
-	query := `
-		SELECT u.id, u.username, u.email, COALESCE(u.password_hash, ''), COALESCE(u.primary_mastodon_instance, ''),
-		       COALESCE(u.primary_mastodon_id, ''), COALESCE(u.primary_mastodon_acct, ''), u.private_key, u.public_key,
-		       u.actor_url, u.inbox_url, u.outbox_url, u.followers_url, u.following_url,
-		       u.created_at, u.updated_at, COALESCE(u.bio, ''), COALESCE(u.avatar_url, '')
-		FROM users u
-		INNER JOIN user_ssh_keys k ON k.user_id = u.id
-		WHERE k.fingerprint = $1 OR k.public_key = $2
-		LIMIT 1
-	`
-
 	var user models.User
-	err = s.db.QueryRow(ctx, query, keyInfo.Fingerprint, publicKeyStr).Scan(
+	var suspended bool
+	err = s.db.QueryRow(ctx, userBySSHKeyStmt, keyInfo.Fingerprint, publicKeyStr).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
@@ -94,11 +105,17 @@ func (s *SSHKeyService) GetUserBySSHKey(ctx context.Context, publicKeyStr string
 		&user.UpdatedAt,
 		&user.Bio,
 		&user.AvatarURL,
+		&user.UsernameConfirmed,
+		&user.DisplayName,
+		&suspended,
 	)
 
 	if err != nil {
 		return nil, fmt.Errorf("user not found for SSH key: %w", err)
 	}
+	if suspended {
+		return nil, ErrAccountSuspended
+	}
 
 	// Update last_used_at for this key
 	go func() {
@@ -157,25 +174,27 @@ func (s *SSHKeyService) AddSSHKeyToUser(ctx context.Context, userID int, publicK
 	keyInfo.UserID = userID
 	now := time.Now()
 	keyInfo.LastUsedAt = &now
+	s.audit.Record(ctx, userID, models.AuditSSHKeyAdded, keyInfo.KeyType+" "+keyInfo.Fingerprint)
 
 	return keyInfo, nil
 }
 
 // RemoveSSHKey removes an SSH key from a user
 func (s *SSHKeyService) RemoveSSHKey(ctx context.Context, userID int, keyID int) error {
-	result, err := s.db.Exec(ctx,
-		"DELETE FROM user_ssh_keys WHERE id = $1 AND user_id = $2",
+	var keyType, fingerprint string
+	err := s.db.QueryRow(ctx,
+		"DELETE FROM user_ssh_keys WHERE id = $1 AND user_id = $2 RETURNING key_type, fingerprint",
 		keyID, userID,
-	)
+	).Scan(&keyType, &fingerprint)
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("SSH key not found")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to remove SSH key: %w", err)
 	}
 
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("SSH key not found")
-	}
-
+	s.audit.Record(ctx, userID, models.AuditSSHKeyRemoved, keyType+" "+fingerprint)
 	return nil
 }
 
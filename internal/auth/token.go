@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/outbound"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -67,7 +68,7 @@ func (t *TokenService) ExchangeCodeForToken(ctx context.Context, instanceURL, co
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := outbound.NewClient(30 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange token: %w", err)
@@ -114,6 +115,31 @@ func (t *TokenService) ExchangeCodeForToken(ctx context.Context, instanceURL, co
 	return token, nil
 }
 
+// VerifyPersonalToken checks a personal access token created in the instance's
+// settings and returns it with the account details, ready to be stored
+func (t *TokenService) VerifyPersonalToken(ctx context.Context, instanceURL, accessToken string) (*models.MastodonToken, error) {
+	instanceURL = NormalizeInstanceURL(instanceURL)
+	accessToken = strings.TrimSpace(accessToken)
+	if accessToken == "" {
+		return nil, fmt.Errorf("access token is required")
+	}
+
+	account, err := t.mastodonService.GetAccount(ctx, instanceURL, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify access token: %w", err)
+	}
+
+	return &models.MastodonToken{
+		InstanceURL: instanceURL,
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		MastodonID:  account.ID,
+		Username:    account.Username,
+		DisplayName: account.DisplayName,
+		AvatarURL:   account.Avatar,
+	}, nil
+}
+
 // StoreToken stores or updates a Mastodon token for a user
 func (t *TokenService) StoreToken(ctx context.Context, userID int, token *models.MastodonToken, isPrimary bool) error {
 	// If this is marked as primary, unset other primary tokens
@@ -239,7 +265,7 @@ func (t *TokenService) RefreshToken(ctx context.Context, token *models.MastodonT
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := outbound.NewClient(30 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to refresh token: %w", err)
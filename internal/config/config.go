@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -24,19 +25,43 @@ type Config struct {
 
 	Database struct {
 		Postgres struct {
-			Host           string `yaml:"host"`
-			Port           int    `yaml:"port"`
-			User           string `yaml:"user"`
-			Password       string `yaml:"password"`
-			Database       string `yaml:"database"`
-			SSLMode        string `yaml:"sslmode"`
-			MaxConnections int    `yaml:"max_connections"`
+			Host              string `yaml:"host"`
+			Port              int    `yaml:"port"`
+			User              string `yaml:"user"`
+			Password          string `yaml:"password"`
+			Database          string `yaml:"database"`
+			SSLMode           string `yaml:"sslmode"`
+			MaxConnections    int    `yaml:"max_connections"`
+			MinConnections    int    `yaml:"min_connections"`     // Connections kept open even when idle
+			MaxConnLifetime   int    `yaml:"max_conn_lifetime"`   // Seconds before a connection is replaced
+			MaxConnIdleTime   int    `yaml:"max_conn_idle_time"`  // Seconds an idle connection is kept
+			HealthCheckPeriod int    `yaml:"health_check_period"` // Seconds between checks of idle connections
+			StatementTimeout  int    `yaml:"statement_timeout"`   // Milliseconds any query may run; 0 for no limit
+			Replicas          []struct {
+				Host string `yaml:"host"`
+				Port int    `yaml:"port"`
+			} `yaml:"replicas"` // Streaming replicas for timeline, outbox and follower reads; same user and database
+			MaxReplicaLag int `yaml:"max_replica_lag"` // Seconds a replica may trail the primary before reads go back to it
 		} `yaml:"postgres"`
 		Redis struct {
-			Host     string `yaml:"host"`
-			Port     int    `yaml:"port"`
-			Password string `yaml:"password"`
-			DB       int    `yaml:"db"`
+			Host             string   `yaml:"host"`
+			Port             int      `yaml:"port"`
+			Username         string   `yaml:"username"` // ACL user; empty for the default user
+			Password         string   `yaml:"password"`
+			DB               int      `yaml:"db"`
+			Addrs            []string `yaml:"addrs"`             // host:port of the Sentinels or cluster nodes, replacing host and port
+			MasterName       string   `yaml:"master_name"`       // Sentinel: name of the monitored master
+			SentinelUsername string   `yaml:"sentinel_username"` // Sentinel: ACL user on the Sentinels, when they need one
+			SentinelPassword string   `yaml:"sentinel_password"`
+			Cluster          bool     `yaml:"cluster"` // Addrs are Redis Cluster nodes, or one configuration endpoint
+			TLS              struct {
+				Enabled            bool   `yaml:"enabled"`
+				CAFile             string `yaml:"ca_file"`   // PEM roots to trust instead of the system's
+				CertFile           string `yaml:"cert_file"` // Client certificate, when the server asks for one
+				KeyFile            string `yaml:"key_file"`
+				ServerName         string `yaml:"server_name"` // Name to verify, when it differs from the address
+				InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+			} `yaml:"tls"`
 		} `yaml:"redis"`
 	} `yaml:"database"`
 
@@ -47,13 +72,17 @@ type Config struct {
 	} `yaml:"oauth"`
 
 	ActivityPub struct {
-		Enabled          bool   `yaml:"enabled"`
-		UserAgent        string `yaml:"user_agent"`
-		MaxInboxSize     int    `yaml:"max_inbox_size"`
-		DeliveryWorkers  int    `yaml:"delivery_workers"`
-		InboxWorkers     int    `yaml:"inbox_workers"`
-		RetryMaxAttempts int    `yaml:"retry_max_attempts"`
-		RetryBaseDelay   int    `yaml:"retry_base_delay"`
+		Enabled          bool     `yaml:"enabled"`
+		UserAgent        string   `yaml:"user_agent"`
+		MaxInboxSize     int      `yaml:"max_inbox_size"` // KB accepted per inbound activity
+		DeliveryWorkers  int      `yaml:"delivery_workers"`
+		InboxWorkers     int      `yaml:"inbox_workers"`
+		RetryMaxAttempts int      `yaml:"retry_max_attempts"`
+		RetryBaseDelay   int      `yaml:"retry_base_delay"`
+		FollowMoves      bool     `yaml:"follow_moves"`     // Refollow accounts that move elsewhere
+		ActorCacheTTL    int      `yaml:"actor_cache_ttl"`  // Seconds before a cached remote actor is refetched
+		AuthorizedFetch  bool     `yaml:"authorized_fetch"` // Require signed GETs, like Mastodon's secure mode
+		Relays           []string `yaml:"relays"`           // Relay actor URLs the instance subscribes to
 	} `yaml:"activitypub"`
 
 	Features struct {
@@ -66,9 +95,31 @@ type Config struct {
 			RateLimit int  `yaml:"rate_limit"`
 		} `yaml:"anonymous_posting"`
 		Registration struct {
-			Enabled       bool `yaml:"enabled"`
-			RequireInvite bool `yaml:"require_invite"`
+			Enabled        bool `yaml:"enabled"`
+			RequireInvite  bool `yaml:"require_invite"`
+			InvitesPerUser int  `yaml:"invites_per_user"`
 		} `yaml:"registration"`
+		AccountDeletion struct {
+			RetentionDays int `yaml:"retention_days"`
+		} `yaml:"account_deletion"`
+		Bluesky struct {
+			Enabled bool `yaml:"enabled"` // Let users link a Bluesky account to cross-post to
+		} `yaml:"bluesky"`
+		Nostr struct {
+			Enabled bool     `yaml:"enabled"` // Let users sign notes with their Nostr key and publish them to relays
+			Relays  []string `yaml:"relays"`  // Relays notes go to unless the user chooses their own
+		} `yaml:"nostr"`
+		Matrix struct {
+			Enabled bool `yaml:"enabled"` // Let users forward mentions and DMs to a Matrix room while away
+		} `yaml:"matrix"`
+		Digest struct {
+			Enabled bool `yaml:"enabled"` // Let users opt into a daily or weekly email digest (needs smtp)
+		} `yaml:"digest"`
+		Recording struct {
+			Enabled       bool `yaml:"enabled"`        // Let users record their own session as an asciinema cast
+			MaxSize       int  `yaml:"max_size"`       // KB a recording may grow to before the rest is left out
+			RetentionDays int  `yaml:"retention_days"` // Days recordings are kept for download
+		} `yaml:"recording"`
 	} `yaml:"features"`
 
 	Security struct {
@@ -77,8 +128,64 @@ type Config struct {
 			RequestsPerMinute int  `yaml:"requests_per_minute"`
 		} `yaml:"rate_limiting"`
 		BlockedInstances []string `yaml:"blocked_instances"`
+		SecretKey        string   `yaml:"secret_key"` // Encrypts the credentials of linked services; required to link them
+		Spam             struct {
+			Enabled      bool `yaml:"enabled"`        // Hold suspicious inbound posts for admin review
+			NewActorDays int  `yaml:"new_actor_days"` // Actors unknown to the instance or created this recently are new
+			MinMentions  int  `yaml:"min_mentions"`   // Mentions a new actor's post needs to be suspicious
+			MinLinks     int  `yaml:"min_links"`      // Links, besides mentions and hashtags, it needs as well
+		} `yaml:"spam"`
 	} `yaml:"security"`
 
+	SMTP struct {
+		Host     string `yaml:"host"`
+		Port     int    `yaml:"port"` // 465 for implicit TLS; others upgrade with STARTTLS when offered
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+		From     string `yaml:"from"` // e.g. terminalpub <digest@example.com>
+	} `yaml:"smtp"`
+
+	Mastodon struct {
+		RetryMaxAttempts int `yaml:"retry_max_attempts"` // Attempts per API call, including the first; 1 disables retries
+		RetryBaseDelay   int `yaml:"retry_base_delay"`   // Milliseconds before the first retry, doubling for each further one
+		RetryMaxDelay    int `yaml:"retry_max_delay"`    // Milliseconds any single wait is capped at
+	} `yaml:"mastodon"`
+
+	Outbound struct {
+		Proxy          string   `yaml:"proxy"`           // http://, https:// or socks5:// proxy for requests to other servers
+		AllowPrivate   bool     `yaml:"allow_private"`   // Allow requests to loopback and private addresses, e.g. for local testing
+		DeniedNetworks []string `yaml:"denied_networks"` // Further CIDR ranges requests may never reach
+		Timeout        int      `yaml:"timeout"`         // Seconds to wait for a connection and for response headers
+		MaxRedirects   int      `yaml:"max_redirects"`
+		CAFile         string   `yaml:"ca_file"` // PEM file of extra CA certificates to trust, e.g. a private CA
+	} `yaml:"outbound"`
+
+	TUI struct {
+		Bell                 bool   `yaml:"bell"`
+		TitleUpdates         bool   `yaml:"title_updates"`
+		ActivityPollInterval int    `yaml:"activity_poll_interval"`
+		Accessible           bool   `yaml:"accessible"`      // Start every session in accessibility mode
+		ASCII                bool   `yaml:"ascii"`           // Draw every session with ASCII only, for legacy terminals
+		Banner               string `yaml:"banner"`          // ASCII art shown on the welcome screen instead of the title
+		MOTD                 string `yaml:"motd"`            // Message of the day shown to everyone until they dismiss it
+		IdleLock             int    `yaml:"idle_lock"`       // Minutes without a key press before the screen locks; 0 never locks
+		IdleDisconnect       int    `yaml:"idle_disconnect"` // Minutes without a key press before the session ends; 0 never ends it
+		FeedMaxPosts         int    `yaml:"feed_max_posts"`  // Posts a timeline keeps in memory while scrolling; the newest are dropped first
+		Clipboard            bool   `yaml:"clipboard"`       // Copy to the user's clipboard with OSC 52; off shows the text to select instead
+		ConfirmBoosts        bool   `yaml:"confirm_boosts"`  // S asks how to boost, or whether to undo a boost; off boosts publicly at once
+	} `yaml:"tui"`
+
+	Reporting struct {
+		DSN         string `yaml:"dsn"`          // Sentry-compatible DSN panics and errors are sent to; empty disables reporting
+		Environment string `yaml:"environment"`  // e.g. production or staging
+		APIFailures int    `yaml:"api_failures"` // Failed Mastodon API calls in a row before an instance is reported
+	} `yaml:"reporting"`
+
+	Updates struct {
+		Check      bool   `yaml:"check"`      // Tell admins in the TUI when a newer release is out
+		Repository string `yaml:"repository"` // GitHub repository whose releases are checked, as owner/name
+	} `yaml:"updates"`
+
 	Logging struct {
 		Level  string `yaml:"level"`
 		Format string `yaml:"format"`
@@ -135,6 +242,12 @@ func DefaultConfig() *Config {
 	cfg.Database.Postgres.Database = "terminalpub"
 	cfg.Database.Postgres.SSLMode = "disable"
 	cfg.Database.Postgres.MaxConnections = 25
+	cfg.Database.Postgres.MinConnections = 2
+	cfg.Database.Postgres.MaxConnLifetime = 3600
+	cfg.Database.Postgres.MaxConnIdleTime = 1800
+	cfg.Database.Postgres.HealthCheckPeriod = 60
+	cfg.Database.Postgres.StatementTimeout = 10000
+	cfg.Database.Postgres.MaxReplicaLag = 5
 
 	cfg.Database.Redis.Host = "localhost"
 	cfg.Database.Redis.Port = 6379
@@ -153,6 +266,9 @@ func DefaultConfig() *Config {
 	cfg.ActivityPub.InboxWorkers = 5
 	cfg.ActivityPub.RetryMaxAttempts = 5
 	cfg.ActivityPub.RetryBaseDelay = 30
+	cfg.ActivityPub.FollowMoves = true
+	cfg.ActivityPub.ActorCacheTTL = 86400
+	cfg.ActivityPub.AuthorizedFetch = false
 
 	// Features defaults
 	cfg.Features.ChatRoulette.Enabled = true
@@ -161,11 +277,46 @@ func DefaultConfig() *Config {
 	cfg.Features.AnonymousPosting.RateLimit = 10
 	cfg.Features.Registration.Enabled = true
 	cfg.Features.Registration.RequireInvite = false
+	cfg.Features.Registration.InvitesPerUser = 0
+	cfg.Features.AccountDeletion.RetentionDays = 30
+	cfg.Features.Recording.Enabled = true
+	cfg.Features.Recording.MaxSize = 5120
+	cfg.Features.Recording.RetentionDays = 7
 
 	// Security defaults
 	cfg.Security.RateLimiting.Enabled = true
 	cfg.Security.RateLimiting.RequestsPerMinute = 60
 	cfg.Security.BlockedInstances = []string{}
+	cfg.Security.Spam.Enabled = true
+	cfg.Security.Spam.NewActorDays = 7
+	cfg.Security.Spam.MinMentions = 3
+	cfg.Security.Spam.MinLinks = 1
+
+	// Mastodon API defaults
+	cfg.Mastodon.RetryMaxAttempts = 3
+	cfg.Mastodon.RetryBaseDelay = 250
+	cfg.Mastodon.RetryMaxDelay = 2000
+
+	// Outbound defaults
+	cfg.Outbound.Timeout = 30
+	cfg.Outbound.MaxRedirects = 5
+
+	// TUI defaults
+	cfg.TUI.Bell = true
+	cfg.TUI.TitleUpdates = true
+	cfg.TUI.ActivityPollInterval = 60
+	cfg.TUI.IdleLock = 15
+	cfg.TUI.IdleDisconnect = 60
+	cfg.TUI.FeedMaxPosts = 500
+	cfg.TUI.Clipboard = true
+	cfg.TUI.ConfirmBoosts = true
+
+	// Reporting defaults
+	cfg.Reporting.APIFailures = 5
+
+	// Update check defaults
+	cfg.Updates.Check = true
+	cfg.Updates.Repository = "fulgidus/terminalpub"
 
 	// Logging defaults
 	cfg.Logging.Level = "info"
@@ -174,3 +325,16 @@ func DefaultConfig() *Config {
 
 	return cfg
 }
+
+// InstanceBlocked reports whether a domain or one of its parents is listed
+// in Security.BlockedInstances
+func (c *Config) InstanceBlocked(domain string) bool {
+	domain = strings.ToLower(domain)
+	for _, blocked := range c.Security.BlockedInstances {
+		blocked = strings.ToLower(blocked)
+		if domain == blocked || strings.HasSuffix(domain, "."+blocked) {
+			return true
+		}
+	}
+	return false
+}
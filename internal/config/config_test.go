@@ -106,3 +106,24 @@ database:
 		t.Errorf("Expected password 'secret123', got '%s'", cfg.Database.Postgres.Password)
 	}
 }
+
+func TestInstanceBlocked(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Security.BlockedInstances = []string{"bad.example", "Spam.Social"}
+
+	tests := []struct {
+		domain string
+		want   bool
+	}{
+		{"bad.example", true},
+		{"sub.bad.example", true},
+		{"spam.social", true},
+		{"notbad.example", false},
+		{"good.example", false},
+	}
+	for _, tt := range tests {
+		if got := cfg.InstanceBlocked(tt.domain); got != tt.want {
+			t.Errorf("InstanceBlocked(%q) = %v, want %v", tt.domain, got, tt.want)
+		}
+	}
+}
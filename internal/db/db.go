@@ -2,7 +2,13 @@ package db
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/fulgidus/terminalpub/internal/config"
@@ -13,7 +19,7 @@ import (
 // DB holds database connections
 type DB struct {
 	Postgres *pgxpool.Pool
-	Redis    *redis.Client
+	Redis    redis.UniversalClient
 }
 
 // Connect establishes connections to PostgreSQL and Redis
@@ -22,21 +28,14 @@ func Connect(cfg *config.Config) (*DB, error) {
 
 	// Connect to PostgreSQL
 	pgConfig := cfg.Database.Postgres
-	connStr := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s pool_max_conns=%d",
-		pgConfig.Host,
-		pgConfig.Port,
-		pgConfig.User,
-		pgConfig.Password,
-		pgConfig.Database,
-		pgConfig.SSLMode,
-		pgConfig.MaxConnections,
-	)
-
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	pool, err := pgxpool.New(ctx, connStr)
+	poolConfig, err := newPoolConfig(cfg, pgConfig.Host, pgConfig.Port)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create postgres connection pool: %w", err)
 	}
@@ -49,26 +48,141 @@ func Connect(cfg *config.Config) (*DB, error) {
 
 	db.Postgres = pool
 
+	// Read replicas are optional; one that cannot be reached now is retried
+	// by MonitorReplicas, and reads stay on the primary meanwhile
+	if err := connectReplicas(ctx, cfg, pool); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	// Connect to Redis
-	redisConfig := cfg.Database.Redis
-	db.Redis = redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", redisConfig.Host, redisConfig.Port),
-		Password: redisConfig.Password,
-		DB:       redisConfig.DB,
-	})
+	redisOpts, err := redisOptions(cfg)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	db.Redis = redis.NewUniversalClient(redisOpts)
 
 	// Test Redis connection
 	if err := db.Redis.Ping(ctx).Err(); err != nil {
-		pool.Close()
+		db.Close()
 		return nil, fmt.Errorf("failed to ping redis: %w", err)
 	}
 
 	return db, nil
 }
 
+// newPoolConfig configures a pool for the PostgreSQL server at host and
+// port with the credentials and pool settings of database.postgres
+func newPoolConfig(cfg *config.Config, host string, port int) (*pgxpool.Config, error) {
+	pgConfig := cfg.Database.Postgres
+	connStr := fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		host,
+		port,
+		pgConfig.User,
+		pgConfig.Password,
+		pgConfig.Database,
+		pgConfig.SSLMode,
+	)
+	poolConfig, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid postgres configuration: %w", err)
+	}
+	applyPoolSettings(poolConfig, cfg)
+	poolConfig.AfterConnect = prepareStatements
+	return poolConfig, nil
+}
+
+// redisOptions turns database.redis into client options. Naming a master
+// selects Sentinel and setting cluster selects Redis Cluster; otherwise
+// the client talks to the one server at host and port, or in addrs.
+func redisOptions(cfg *config.Config) (*redis.UniversalOptions, error) {
+	rc := cfg.Database.Redis
+	opts := &redis.UniversalOptions{
+		Addrs:            rc.Addrs,
+		Username:         rc.Username,
+		Password:         rc.Password,
+		DB:               rc.DB,
+		MasterName:       rc.MasterName,
+		SentinelUsername: rc.SentinelUsername,
+		SentinelPassword: rc.SentinelPassword,
+		IsClusterMode:    rc.Cluster,
+	}
+
+	switch {
+	case rc.MasterName != "" && rc.Cluster:
+		return nil, fmt.Errorf("invalid redis configuration: master_name (Sentinel) and cluster exclude each other")
+	case (rc.MasterName != "" || rc.Cluster) && len(rc.Addrs) == 0:
+		return nil, fmt.Errorf("invalid redis configuration: Sentinel and Cluster need addrs")
+	case rc.MasterName == "" && !rc.Cluster && len(rc.Addrs) > 1:
+		// The client would pick Cluster by itself; say so instead
+		return nil, fmt.Errorf("invalid redis configuration: several addrs need master_name (Sentinel) or cluster: true")
+	case len(rc.Addrs) == 0:
+		opts.Addrs = []string{net.JoinHostPort(rc.Host, strconv.Itoa(rc.Port))}
+	}
+	if rc.Cluster && rc.DB != 0 {
+		return nil, fmt.Errorf("invalid redis configuration: Redis Cluster has only database 0")
+	}
+
+	if rc.TLS.Enabled {
+		tlsConfig := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			ServerName:         rc.TLS.ServerName,
+			InsecureSkipVerify: rc.TLS.InsecureSkipVerify,
+		}
+		if rc.TLS.CAFile != "" {
+			pem, err := os.ReadFile(rc.TLS.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read redis CA file: %w", err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("redis CA file %s holds no PEM certificates", rc.TLS.CAFile)
+			}
+		}
+		if rc.TLS.CertFile != "" || rc.TLS.KeyFile != "" {
+			cert, err := tls.LoadX509KeyPair(rc.TLS.CertFile, rc.TLS.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load redis client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		opts.TLSConfig = tlsConfig
+	}
+	return opts, nil
+}
+
+// applyPoolSettings sets the pool sizes, connection lifetimes and statement
+// timeout configured under database.postgres; unset values keep pgx's defaults
+func applyPoolSettings(poolConfig *pgxpool.Config, cfg *config.Config) {
+	pgConfig := cfg.Database.Postgres
+	if pgConfig.MaxConnections > 0 {
+		poolConfig.MaxConns = int32(pgConfig.MaxConnections)
+	}
+	if pgConfig.MinConnections > 0 {
+		poolConfig.MinConns = int32(min(pgConfig.MinConnections, int(poolConfig.MaxConns)))
+	}
+	if pgConfig.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = time.Duration(pgConfig.MaxConnLifetime) * time.Second
+	}
+	if pgConfig.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = time.Duration(pgConfig.MaxConnIdleTime) * time.Second
+	}
+	if pgConfig.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = time.Duration(pgConfig.HealthCheckPeriod) * time.Second
+	}
+	// The server cancels any statement running longer, whichever code path
+	// issued it and whatever context it was given
+	if pgConfig.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.Itoa(pgConfig.StatementTimeout)
+	}
+}
+
 // Close closes all database connections
 func (db *DB) Close() {
 	if db.Postgres != nil {
+		closeReplicas(db.Postgres)
 		db.Postgres.Close()
 	}
 	if db.Redis != nil {
@@ -90,3 +204,54 @@ func (db *DB) Health(ctx context.Context) error {
 
 	return nil
 }
+
+// PoolStats summarizes how busy the PostgreSQL connection pool is. Requests
+// that wait for a connection show up in EmptyAcquires and AcquireWait; a
+// pool whose connections are all acquired is saturated, and queries queue.
+type PoolStats struct {
+	MaxConns         int32   `json:"max_conns"`
+	TotalConns       int32   `json:"total_conns"`
+	AcquiredConns    int32   `json:"acquired_conns"`
+	IdleConns        int32   `json:"idle_conns"`
+	EmptyAcquires    int64   `json:"empty_acquires"`
+	CanceledAcquires int64   `json:"canceled_acquires"`
+	AcquireWaitSecs  float64 `json:"acquire_wait_seconds"`
+	Saturated        bool    `json:"saturated"`
+}
+
+// PoolStats returns the current statistics of the PostgreSQL pool
+func (db *DB) PoolStats() PoolStats {
+	stat := db.Postgres.Stat()
+	return PoolStats{
+		MaxConns:         stat.MaxConns(),
+		TotalConns:       stat.TotalConns(),
+		AcquiredConns:    stat.AcquiredConns(),
+		IdleConns:        stat.IdleConns(),
+		EmptyAcquires:    stat.EmptyAcquireCount(),
+		CanceledAcquires: stat.CanceledAcquireCount(),
+		AcquireWaitSecs:  stat.AcquireDuration().Seconds(),
+		Saturated:        stat.AcquiredConns() >= stat.MaxConns(),
+	}
+}
+
+// MonitorPool logs a warning for every interval in which queries had to
+// wait for a free connection, until ctx is done
+func (db *DB) MonitorPool(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := db.PoolStats()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		stats := db.PoolStats()
+		if waited := stats.EmptyAcquires - last.EmptyAcquires; waited > 0 {
+			log.Printf("Warning: %d queries waited for a database connection in the last %s (%d/%d in use, %.1fs waiting in total); consider raising database.postgres.max_connections",
+				waited, interval, stats.AcquiredConns, stats.MaxConns, stats.AcquireWaitSecs-last.AcquireWaitSecs)
+		}
+		last = stats
+	}
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestRedisOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(cfg *config.Config)
+		addrs     []string
+		wantErr   bool
+	}{
+		{"single server", func(cfg *config.Config) {}, []string{"localhost:6379"}, false},
+		{"sentinel", func(cfg *config.Config) {
+			cfg.Database.Redis.Addrs = []string{"s1:26379", "s2:26379"}
+			cfg.Database.Redis.MasterName = "mymaster"
+		}, []string{"s1:26379", "s2:26379"}, false},
+		{"cluster endpoint", func(cfg *config.Config) {
+			cfg.Database.Redis.Addrs = []string{"cluster:6379"}
+			cfg.Database.Redis.Cluster = true
+		}, []string{"cluster:6379"}, false},
+		{"sentinel without addrs", func(cfg *config.Config) {
+			cfg.Database.Redis.MasterName = "mymaster"
+		}, nil, true},
+		{"several addrs without a mode", func(cfg *config.Config) {
+			cfg.Database.Redis.Addrs = []string{"a:6379", "b:6379"}
+		}, nil, true},
+		{"cluster with a database", func(cfg *config.Config) {
+			cfg.Database.Redis.Addrs = []string{"cluster:6379"}
+			cfg.Database.Redis.Cluster = true
+			cfg.Database.Redis.DB = 2
+		}, nil, true},
+		{"missing CA file", func(cfg *config.Config) {
+			cfg.Database.Redis.TLS.Enabled = true
+			cfg.Database.Redis.TLS.CAFile = "testdata/missing.pem"
+		}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			tt.configure(cfg)
+			opts, err := redisOptions(cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("redisOptions succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("redisOptions: %v", err)
+			}
+			if !slices.Equal(opts.Addrs, tt.addrs) {
+				t.Errorf("Addrs = %v, want %v", opts.Addrs, tt.addrs)
+			}
+		})
+	}
+}
+
+func TestRedisOptionsTLS(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Database.Redis.TLS.Enabled = true
+	cfg.Database.Redis.TLS.ServerName = "redis.internal"
+
+	opts, err := redisOptions(cfg)
+	if err != nil {
+		t.Fatalf("redisOptions: %v", err)
+	}
+	if opts.TLSConfig == nil || opts.TLSConfig.ServerName != "redis.internal" {
+		t.Errorf("TLSConfig = %+v, want server name redis.internal", opts.TLSConfig)
+	}
+}
+
+func TestForReads(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Database.Postgres.Replicas = append(cfg.Database.Postgres.Replicas,
+		struct {
+			Host string `yaml:"host"`
+			Port int    `yaml:"port"`
+		}{Host: "127.0.0.1", Port: 1})
+	newPool := func() *pgxpool.Pool {
+		poolConfig, err := newPoolConfig(cfg, "127.0.0.1", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(pool.Close)
+		return pool
+	}
+
+	primary := newPool()
+	if got := ForReads(primary); got != primary {
+		t.Error("ForReads without replicas did not return the primary")
+	}
+
+	// Nothing listens on port 1, so the replica is down from the start
+	if err := connectReplicas(context.Background(), cfg, primary); err != nil {
+		t.Fatalf("connectReplicas: %v", err)
+	}
+	defer closeReplicas(primary)
+	if got := ForReads(primary); got != primary {
+		t.Error("ForReads used a replica that is down")
+	}
+
+	replicasMu.RLock()
+	r := replicaSets[primary].replicas[0]
+	replicasMu.RUnlock()
+	r.mu.Lock()
+	r.usable = true
+	r.mu.Unlock()
+	if got := ForReads(primary); got != r.pool {
+		t.Error("ForReads did not use the usable replica")
+	}
+}
@@ -0,0 +1,216 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// errNotReplica is reported for a configured replica that is not replaying
+// another server's changes, so its data would drift from the primary's
+var errNotReplica = errors.New("not a streaming replica (pg_is_in_recovery is false)")
+
+// replicaSet holds the read replicas of a primary pool
+type replicaSet struct {
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint32 // Round-robin position
+}
+
+// replica is a read replica and what its last check found
+type replica struct {
+	addr   string
+	pool   *pgxpool.Pool
+	mu     sync.Mutex
+	usable bool
+	lag    time.Duration
+	err    error
+}
+
+var (
+	replicasMu  sync.RWMutex
+	replicaSets = map[*pgxpool.Pool]*replicaSet{}
+)
+
+// ForReads returns the pool for a read that may be a moment out of date,
+// like a timeline page or a follower list: in turn, each read replica of
+// primary that trails it by no more than database.postgres.max_replica_lag,
+// or primary itself when there are none. Writes, and reads that must see
+// them, such as authentication, go to primary directly.
+func ForReads(primary *pgxpool.Pool) *pgxpool.Pool {
+	replicasMu.RLock()
+	set := replicaSets[primary]
+	replicasMu.RUnlock()
+	if set == nil {
+		return primary
+	}
+
+	start := set.next.Add(1)
+	for i := range set.replicas {
+		r := set.replicas[(int(start)+i)%len(set.replicas)]
+		r.mu.Lock()
+		usable := r.usable
+		r.mu.Unlock()
+		if usable {
+			return r.pool
+		}
+	}
+	return primary
+}
+
+// connectReplicas opens pools for the replicas of database.postgres and
+// checks their lag once. Pools connect lazily, so a replica that is down
+// only stays unused until a later check finds it up.
+func connectReplicas(ctx context.Context, cfg *config.Config, primary *pgxpool.Pool) error {
+	pgConfig := cfg.Database.Postgres
+	if len(pgConfig.Replicas) == 0 {
+		return nil
+	}
+
+	maxLag := time.Duration(pgConfig.MaxReplicaLag) * time.Second
+	if maxLag <= 0 {
+		maxLag = 5 * time.Second
+	}
+	set := &replicaSet{maxLag: maxLag}
+	for _, rc := range pgConfig.Replicas {
+		port := rc.Port
+		if port == 0 {
+			port = pgConfig.Port
+		}
+		poolConfig, err := newPoolConfig(cfg, rc.Host, port)
+		if err != nil {
+			closePools(set)
+			return fmt.Errorf("replica %s: %w", rc.Host, err)
+		}
+		pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+		if err != nil {
+			closePools(set)
+			return fmt.Errorf("failed to create pool for replica %s: %w", rc.Host, err)
+		}
+		r := &replica{addr: net.JoinHostPort(rc.Host, strconv.Itoa(port)), pool: pool}
+		r.check(ctx, maxLag)
+		set.replicas = append(set.replicas, r)
+	}
+
+	replicasMu.Lock()
+	replicaSets[primary] = set
+	replicasMu.Unlock()
+	return nil
+}
+
+// closeReplicas closes the replica pools of primary
+func closeReplicas(primary *pgxpool.Pool) {
+	replicasMu.Lock()
+	set := replicaSets[primary]
+	delete(replicaSets, primary)
+	replicasMu.Unlock()
+	if set != nil {
+		closePools(set)
+	}
+}
+
+// closePools closes the pools of a replica set
+func closePools(set *replicaSet) {
+	for _, r := range set.replicas {
+		r.pool.Close()
+	}
+}
+
+// check measures how far the replica trails the primary and decides
+// whether reads may use it
+func (r *replica) check(ctx context.Context, maxLag time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// A replica that has replayed everything it received is current, even
+	// when the primary has written nothing for a while
+	var inRecovery bool
+	var lagSeconds *float64
+	err := r.pool.QueryRow(ctx, `
+		SELECT pg_is_in_recovery(),
+			CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+				ELSE EXTRACT(EPOCH FROM NOW() - pg_last_xact_replay_timestamp())::float8
+			END
+	`).Scan(&inRecovery, &lagSeconds)
+	if err == nil && !inRecovery {
+		err = errNotReplica
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	wasUsable := r.usable
+	r.err = err
+	r.lag = 0
+	if lagSeconds != nil {
+		r.lag = time.Duration(*lagSeconds * float64(time.Second))
+	}
+	r.usable = err == nil && lagSeconds != nil && r.lag <= maxLag
+
+	switch {
+	case wasUsable && !r.usable && err != nil:
+		log.Printf("Warning: read replica %s is unavailable, reading from the primary: %v", r.addr, err)
+	case wasUsable && !r.usable:
+		log.Printf("Warning: read replica %s is %s behind, reading from the primary until it catches up", r.addr, r.lag.Round(100*time.Millisecond))
+	case !wasUsable && r.usable:
+		log.Printf("Read replica %s is in use", r.addr)
+	}
+}
+
+// MonitorReplicas checks the lag of the read replicas every interval until
+// ctx is done, so that reads move off a replica that falls behind or goes
+// down and back once it recovers
+func (db *DB) MonitorReplicas(ctx context.Context, interval time.Duration) {
+	replicasMu.RLock()
+	set := replicaSets[db.Postgres]
+	replicasMu.RUnlock()
+	if set == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, r := range set.replicas {
+			r.check(ctx, set.maxLag)
+		}
+	}
+}
+
+// ReplicaStatus describes each read replica as of its last check, by address
+func (db *DB) ReplicaStatus() map[string]string {
+	replicasMu.RLock()
+	set := replicaSets[db.Postgres]
+	replicasMu.RUnlock()
+	if set == nil {
+		return nil
+	}
+
+	status := make(map[string]string, len(set.replicas))
+	for _, r := range set.replicas {
+		r.mu.Lock()
+		switch {
+		case r.err != nil:
+			status[r.addr] = "down: " + r.err.Error()
+		case r.usable:
+			status[r.addr] = fmt.Sprintf("up, %s behind", r.lag.Round(100*time.Millisecond))
+		default:
+			status[r.addr] = fmt.Sprintf("lagging, %s behind", r.lag.Round(100*time.Millisecond))
+		}
+		r.mu.Unlock()
+	}
+	return status
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// statements are the queries prepared on every connection, by name
+var statements = map[string]string{}
+
+// Prepare registers a query that runs on nearly every interaction, such as
+// an authentication lookup, to be prepared on every connection of the pool.
+// It returns the statement's name, which Query and QueryRow accept in place
+// of the SQL. Queries are registered from package-level variables, so they
+// are known before Connect opens the first connection.
+func Prepare(name, sql string) string {
+	if _, exists := statements[name]; exists {
+		panic("db: statement " + name + " registered twice")
+	}
+	statements[name] = sql
+	return name
+}
+
+// prepareStatements prepares the registered queries on a new connection
+func prepareStatements(ctx context.Context, conn *pgx.Conn) error {
+	for name, sql := range statements {
+		if _, err := conn.Prepare(ctx, name, sql); err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+	}
+	return nil
+}
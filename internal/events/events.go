@@ -0,0 +1,158 @@
+// Package events lets the parts of terminalpub react to what happens
+// elsewhere without the code where it happens knowing about them: a post
+// being published, a follower arriving, an activity reaching an inbox or an
+// SSH session starting. Publishers raise a typed event on a Bus and every
+// handler subscribed to that type runs; federation delivery, webhooks, push
+// notifications, statistics and live TUI updates are all handlers.
+//
+// Events stay inside the process that raised them. Work other nodes must
+// see goes through the databases, as it did before.
+package events
+
+import (
+	"context"
+	"log"
+	"maps"
+	"reflect"
+	"sync"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/reporting"
+)
+
+// Event is something that happened, named for statistics and logs
+type Event interface {
+	EventName() string
+}
+
+// PostCreated is raised once a local user's post is saved, before it is
+// delivered anywhere
+type PostCreated struct {
+	UserID   int
+	Post     *models.Post
+	Activity models.APActivity // The Create activity announcing the post
+	Inboxes  []string          // Inboxes of the remote accounts the post mentions
+}
+
+// FollowerAdded is raised when a remote actor first follows a local user,
+// whether or not the user still has to approve the follow
+type FollowerAdded struct {
+	UserID   int
+	ActorID  string
+	Acct     string // user@domain of the follower
+	Accepted bool   // False while the follow awaits approval
+}
+
+// ActivityReceived is raised for an activity stored from an inbox; held
+// for review in quarantine or refused ones are not
+type ActivityReceived struct {
+	UserID   int // Zero for the shared inbox
+	Type     string
+	ActorID  string
+	ObjectID string
+	Activity map[string]any
+}
+
+// SessionStarted is raised when an SSH session opens, before the TUI or a
+// command runs
+type SessionStarted struct {
+	SessionID string
+	UserID    int // Zero until the session signs in, unless its key is registered
+}
+
+// EventName implements Event
+func (PostCreated) EventName() string { return "post.created" }
+
+// EventName implements Event
+func (FollowerAdded) EventName() string { return "follower.added" }
+
+// EventName implements Event
+func (ActivityReceived) EventName() string { return "activity.received" }
+
+// EventName implements Event
+func (SessionStarted) EventName() string { return "session.started" }
+
+// handler is a subscribed function, by pointer so it can be unsubscribed
+type handler struct {
+	fn func(ctx context.Context, event Event)
+}
+
+// Bus delivers events to the handlers subscribed to their type
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[reflect.Type][]*handler
+	counts   map[string]uint64
+}
+
+// NewBus creates a Bus without handlers
+func NewBus() *Bus {
+	return &Bus{handlers: map[reflect.Type][]*handler{}, counts: map[string]uint64{}}
+}
+
+// Default is the bus the server and worker publish on
+var Default = NewBus()
+
+// Subscribe calls fn for every event of type E published on b until the
+// returned function is called
+func Subscribe[E Event](b *Bus, fn func(ctx context.Context, event E)) (unsubscribe func()) {
+	eventType := reflect.TypeFor[E]()
+	h := &handler{fn: func(ctx context.Context, event Event) { fn(ctx, event.(E)) }}
+
+	b.mu.Lock()
+	b.handlers[eventType] = append(b.handlers[eventType], h)
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			handlers := b.handlers[eventType]
+			for i, candidate := range handlers {
+				if candidate == h {
+					// Copied so a Publish iterating the old slice is unaffected
+					b.handlers[eventType] = append(handlers[:i:i], handlers[i+1:]...)
+					break
+				}
+			}
+		})
+	}
+}
+
+// Publish runs the handlers of event's type one after another, in the order
+// they subscribed, and returns once all have. Handlers with slow work to do
+// start it in the background. A handler that panics is reported and does
+// not keep the others from running.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	b.mu.Lock()
+	b.counts[event.EventName()]++
+	handlers := b.handlers[reflect.TypeOf(event)]
+	b.mu.Unlock()
+
+	for _, h := range handlers {
+		b.run(ctx, h, event)
+	}
+}
+
+// run calls one handler, recovering from a panic
+func (b *Bus) run(ctx context.Context, h *handler, event Event) {
+	defer func() {
+		if value := recover(); value != nil {
+			log.Printf("Panic handling %s: %v", event.EventName(), value)
+			reporting.CapturePanic(value, reporting.Tags{"event": event.EventName()})
+		}
+	}()
+	h.fn(ctx, event)
+}
+
+// Counts returns how many events of each name were published since start
+func (b *Bus) Counts() map[string]uint64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return maps.Clone(b.counts)
+}
+
+// Publish publishes event on the Default bus
+func Publish(ctx context.Context, event Event) {
+	Default.Publish(ctx, event)
+}
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	bus := NewBus()
+	ctx := context.Background()
+
+	var got []string
+	unsubscribe := Subscribe(bus, func(_ context.Context, e FollowerAdded) {
+		got = append(got, "first "+e.Acct)
+	})
+	Subscribe(bus, func(_ context.Context, e FollowerAdded) {
+		got = append(got, "second "+e.Acct)
+	})
+	Subscribe(bus, func(_ context.Context, e SessionStarted) {
+		got = append(got, "session "+e.SessionID)
+	})
+
+	bus.Publish(ctx, FollowerAdded{Acct: "alice@example.com"})
+	want := []string{"first alice@example.com", "second alice@example.com"}
+	if !slices.Equal(got, want) {
+		t.Errorf("handlers ran as %q, want %q", got, want)
+	}
+
+	got = nil
+	unsubscribe()
+	unsubscribe()
+	bus.Publish(ctx, FollowerAdded{Acct: "bob@example.com"})
+	bus.Publish(ctx, SessionStarted{SessionID: "abc"})
+	want = []string{"second bob@example.com", "session abc"}
+	if !slices.Equal(got, want) {
+		t.Errorf("after unsubscribing, handlers ran as %q, want %q", got, want)
+	}
+
+	counts := bus.Counts()
+	if counts["follower.added"] != 2 || counts["session.started"] != 1 || counts["post.created"] != 0 {
+		t.Errorf("counts = %v", counts)
+	}
+}
+
+func TestPublishRecoversPanics(t *testing.T) {
+	bus := NewBus()
+	ran := false
+	Subscribe(bus, func(context.Context, PostCreated) { panic("broken handler") })
+	Subscribe(bus, func(context.Context, PostCreated) { ran = true })
+
+	bus.Publish(context.Background(), PostCreated{})
+	if !ran {
+		t.Error("a panicking handler kept the next one from running")
+	}
+}
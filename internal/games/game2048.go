@@ -0,0 +1,220 @@
+package games
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// size2048 is the number of rows and columns of the board
+const size2048 = 4
+
+func init() {
+	Register(Game{
+		Name:        "2048",
+		Title:       "2048",
+		Description: "Slide the tiles and merge them up to 2048",
+		New:         func() tea.Model { return new2048() },
+	})
+}
+
+// tileStyles colour tiles by value, warmer as they grow
+var tileStyles = map[int]lipgloss.Style{
+	2:    lipgloss.NewStyle().Foreground(lipgloss.Color("252")),
+	4:    lipgloss.NewStyle().Foreground(lipgloss.Color("229")),
+	8:    lipgloss.NewStyle().Foreground(lipgloss.Color("215")),
+	16:   lipgloss.NewStyle().Foreground(lipgloss.Color("209")),
+	32:   lipgloss.NewStyle().Foreground(lipgloss.Color("203")),
+	64:   lipgloss.NewStyle().Foreground(lipgloss.Color("196")),
+	128:  lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("228")),
+	256:  lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("226")),
+	512:  lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("220")),
+	1024: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("214")),
+	2048: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("208")),
+}
+
+// game2048 slides numbered tiles on a 4×4 board; equal tiles that meet merge
+type game2048 struct {
+	board [size2048][size2048]int
+	score int
+	won   bool // Reached 2048; playing on is allowed
+	over  bool
+}
+
+// new2048 starts a game with two tiles
+func new2048() game2048 {
+	var g game2048
+	g = g.spawn()
+	return g.spawn()
+}
+
+// Init does nothing: the game only moves on key presses
+func (g game2048) Init() tea.Cmd {
+	return nil
+}
+
+// Update slides the tiles in the direction of the key pressed
+func (g game2048) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return g, nil
+	}
+
+	var turns int // Quarter turns that make the move a slide to the left
+	switch key.String() {
+	case "left", "h", "a":
+		turns = 0
+	case "down", "j", "s":
+		turns = 1
+	case "right", "l", "d":
+		turns = 2
+	case "up", "k", "w":
+		turns = 3
+	case "r":
+		if g.over {
+			return new2048(), nil
+		}
+		return g, nil
+	default:
+		return g, nil
+	}
+	if g.over {
+		return g, nil
+	}
+
+	moved := g
+	for i := 0; i < turns; i++ {
+		moved.board = rotate(moved.board)
+	}
+	moved = moved.slideLeft()
+	for i := turns; i%4 != 0; i++ {
+		moved.board = rotate(moved.board)
+	}
+	if moved.board == g.board {
+		// Nothing moved, so no new tile either
+		return g, nil
+	}
+
+	moved = moved.spawn()
+	moved.over = !moved.canMove()
+	return moved, nil
+}
+
+// slideLeft slides every row to the left, merging each pair of equal tiles
+// once
+func (g game2048) slideLeft() game2048 {
+	for y := range g.board {
+		var row [size2048]int
+		n := 0
+		merged := false
+		for _, value := range g.board[y] {
+			if value == 0 {
+				continue
+			}
+			if n > 0 && row[n-1] == value && !merged {
+				row[n-1] *= 2
+				g.score += row[n-1]
+				if row[n-1] == 2048 {
+					g.won = true
+				}
+				merged = true
+				continue
+			}
+			row[n] = value
+			n++
+			merged = false
+		}
+		g.board[y] = row
+	}
+	return g
+}
+
+// rotate turns the board a quarter clockwise
+func rotate(board [size2048][size2048]int) [size2048][size2048]int {
+	var rotated [size2048][size2048]int
+	for y := range board {
+		for x := range board[y] {
+			rotated[x][size2048-1-y] = board[y][x]
+		}
+	}
+	return rotated
+}
+
+// spawn puts a 2, or now and then a 4, on a random empty cell
+func (g game2048) spawn() game2048 {
+	var empty []point
+	for y := range g.board {
+		for x := range g.board[y] {
+			if g.board[y][x] == 0 {
+				empty = append(empty, point{x, y})
+			}
+		}
+	}
+	if len(empty) == 0 {
+		return g
+	}
+	cell := empty[rand.IntN(len(empty))]
+	g.board[cell.y][cell.x] = 2
+	if rand.IntN(10) == 0 {
+		g.board[cell.y][cell.x] = 4
+	}
+	return g
+}
+
+// canMove reports whether any slide would change the board
+func (g game2048) canMove() bool {
+	for y := range g.board {
+		for x := range g.board[y] {
+			if g.board[y][x] == 0 {
+				return true
+			}
+			if x+1 < size2048 && g.board[y][x] == g.board[y][x+1] {
+				return true
+			}
+			if y+1 < size2048 && g.board[y][x] == g.board[y+1][x] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// View renders the board
+func (g game2048) View() string {
+	var board strings.Builder
+	for y := range g.board {
+		for x, value := range g.board[y] {
+			cell := "    ."
+			if value != 0 {
+				style, ok := tileStyles[value]
+				if !ok {
+					style = tileStyles[2048]
+				}
+				cell = style.Render(fmt.Sprintf("%5d", value))
+			}
+			board.WriteString(cell)
+			if x < size2048-1 {
+				board.WriteString(" ")
+			}
+		}
+		if y < size2048-1 {
+			board.WriteString("\n\n")
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("2048") + "  " + scoreStyle.Render(fmt.Sprintf("Score: %d", g.score)) + "\n")
+	b.WriteString(boardStyle.Padding(1, 2).Render(board.String()) + "\n")
+	switch {
+	case g.over:
+		b.WriteString("No moves left! " + subtleStyle.Render("r Play again"))
+	case g.won:
+		b.WriteString("You made 2048! " + subtleStyle.Render("Keep going for a higher score"))
+	default:
+		b.WriteString(subtleStyle.Render("Arrows/hjkl/wasd Slide the tiles"))
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+// Package games holds the mini games playable from the terminalpub menu.
+// Each game is a bubbletea model that registers itself from an init
+// function, so adding one takes a single file.
+package games
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Game describes a mini game players can pick from the games menu
+type Game struct {
+	Name        string // Identifies the game, e.g. on the command line
+	Title       string
+	Description string
+	New         func() tea.Model // Starts a new round
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Game{}
+)
+
+// Register adds a game to the games menu. It panics when the name is taken,
+// as registering twice is a programming error.
+func Register(game Game) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if game.Name == "" || game.New == nil {
+		panic("games: Register needs a name and a constructor")
+	}
+	if _, exists := registry[game.Name]; exists {
+		panic(fmt.Sprintf("games: %q registered twice", game.Name))
+	}
+	registry[game.Name] = game
+}
+
+// List returns the registered games in alphabetical order of title
+func List() []Game {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	list := make([]Game, 0, len(registry))
+	for _, game := range registry {
+		list = append(list, game)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Title < list[j].Title })
+	return list
+}
+
+// Lookup returns the game registered under name
+func Lookup(name string) (Game, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	game, ok := registry[name]
+	return game, ok
+}
+
+// Styles shared by the games, matching the rest of the TUI
+var (
+	titleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("99"))
+	scoreStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("208"))
+	subtleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	boardStyle  = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("241"))
+)
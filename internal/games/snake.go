@@ -0,0 +1,216 @@
+package games
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	snakeWidth  = 30
+	snakeHeight = 14
+
+	// snakeSpeed is how often the snake moves at the start; it speeds up as
+	// it grows, down to snakeMaxSpeed
+	snakeSpeed    = 150 * time.Millisecond
+	snakeMaxSpeed = 60 * time.Millisecond
+)
+
+func init() {
+	Register(Game{
+		Name:        "snake",
+		Title:       "Snake",
+		Description: "Eat, grow and don't bite your own tail",
+		New:         func() tea.Model { return newSnake() },
+	})
+}
+
+// snakeRounds numbers rounds so ticks of a round left behind are ignored
+var snakeRounds atomic.Int64
+
+// point is a cell of a board
+type point struct {
+	x, y int
+}
+
+// snakeTickMsg moves the snake of one round
+type snakeTickMsg struct {
+	round int64
+}
+
+// snake is the classic game: steer with the arrow keys or hjkl/wasd
+type snake struct {
+	round  int64
+	body   []point // Head first
+	dir    point
+	next   point // Direction of the next move, so two quick turns don't reverse
+	food   point
+	score  int
+	paused bool
+	over   bool
+}
+
+// newSnake starts a round with a short snake in the middle of the board
+func newSnake() snake {
+	s := snake{
+		round: snakeRounds.Add(1),
+		body:  []point{{snakeWidth / 2, snakeHeight / 2}, {snakeWidth/2 - 1, snakeHeight / 2}, {snakeWidth/2 - 2, snakeHeight / 2}},
+		dir:   point{1, 0},
+		next:  point{1, 0},
+	}
+	s.food = s.freeCell()
+	return s
+}
+
+// Init starts the snake moving
+func (s snake) Init() tea.Cmd {
+	return s.tick()
+}
+
+// tick schedules the next move, faster as the snake grows
+func (s snake) tick() tea.Cmd {
+	round := s.round
+	speed := max(snakeSpeed-time.Duration(s.score)*3*time.Millisecond, snakeMaxSpeed)
+	return tea.Tick(speed, func(time.Time) tea.Msg {
+		return snakeTickMsg{round: round}
+	})
+}
+
+// freeCell picks a random cell the snake is not on
+func (s snake) freeCell() point {
+	for {
+		cell := point{rand.IntN(snakeWidth), rand.IntN(snakeHeight)}
+		if !s.occupies(cell) {
+			return cell
+		}
+	}
+}
+
+// occupies reports whether the snake is on cell
+func (s snake) occupies(cell point) bool {
+	for _, part := range s.body {
+		if part == cell {
+			return true
+		}
+	}
+	return false
+}
+
+// Update moves the snake on each tick and turns it on key presses
+func (s snake) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case snakeTickMsg:
+		if msg.round != s.round || s.over {
+			return s, nil
+		}
+		if s.paused {
+			return s, s.tick()
+		}
+		s = s.move()
+		if s.over {
+			return s, nil
+		}
+		return s, s.tick()
+
+	case tea.KeyMsg:
+		turn := map[string]point{
+			"up": {0, -1}, "k": {0, -1}, "w": {0, -1},
+			"down": {0, 1}, "j": {0, 1}, "s": {0, 1},
+			"left": {-1, 0}, "h": {-1, 0}, "a": {-1, 0},
+			"right": {1, 0}, "l": {1, 0}, "d": {1, 0},
+		}
+		if dir, ok := turn[msg.String()]; ok {
+			// The snake can't turn back onto itself
+			if dir.x != -s.dir.x || dir.y != -s.dir.y {
+				s.next = dir
+			}
+			return s, nil
+		}
+		switch msg.String() {
+		case "p", " ":
+			if !s.over {
+				s.paused = !s.paused
+			}
+		case "r":
+			if s.over {
+				s = newSnake()
+				return s, s.tick()
+			}
+		}
+	}
+	return s, nil
+}
+
+// move advances the snake one cell, growing it when it eats
+func (s snake) move() snake {
+	s.dir = s.next
+	head := point{s.body[0].x + s.dir.x, s.body[0].y + s.dir.y}
+	if head.x < 0 || head.x >= snakeWidth || head.y < 0 || head.y >= snakeHeight {
+		s.over = true
+		return s
+	}
+
+	eats := head == s.food
+	body := s.body
+	if !eats {
+		// The tail moves out of the way of the head
+		body = body[:len(body)-1]
+	}
+	for _, part := range body {
+		if part == head {
+			s.over = true
+			return s
+		}
+	}
+	s.body = append([]point{head}, body...)
+
+	if eats {
+		s.score++
+		if len(s.body) == snakeWidth*snakeHeight {
+			s.over = true
+			return s
+		}
+		s.food = s.freeCell()
+	}
+	return s
+}
+
+// View renders the board
+func (s snake) View() string {
+	var board strings.Builder
+	for y := 0; y < snakeHeight; y++ {
+		for x := 0; x < snakeWidth; x++ {
+			cell := point{x, y}
+			switch {
+			case cell == s.body[0]:
+				board.WriteString("@")
+			case s.occupies(cell):
+				board.WriteString("o")
+			case cell == s.food:
+				board.WriteString("*")
+			default:
+				board.WriteString(" ")
+			}
+		}
+		if y < snakeHeight-1 {
+			board.WriteString("\n")
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Snake") + "  " + scoreStyle.Render(fmt.Sprintf("Score: %d", s.score)) + "\n")
+	b.WriteString(boardStyle.Render(board.String()) + "\n")
+	switch {
+	case s.over:
+		b.WriteString("Game over! " + subtleStyle.Render("r Play again"))
+	case s.paused:
+		b.WriteString("Paused " + subtleStyle.Render("p Resume"))
+	default:
+		b.WriteString(subtleStyle.Render("Arrows/hjkl/wasd Steer  p Pause"))
+	}
+	return b.String()
+}
@@ -1,27 +1,49 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/fulgidus/terminalpub/internal/activitypub"
 	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/events"
 	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // ActivityPubHandler handles ActivityPub-related HTTP requests
 type ActivityPubHandler struct {
-	db     *pgxpool.Pool
-	config *config.Config
+	db          *pgxpool.Pool
+	config      *config.Config
+	actors      *services.RemoteActorService
+	relays      *services.RelayService
+	posts       *services.PostService
+	directory   *services.DirectoryService
+	moderation  *services.ModerationService
+	quarantine  *services.QuarantineService
+	collections *services.CollectionService
 }
 
 // NewActivityPubHandler creates a new ActivityPub handler
 func NewActivityPubHandler(db *pgxpool.Pool, cfg *config.Config) *ActivityPubHandler {
 	return &ActivityPubHandler{
-		db:     db,
-		config: cfg,
+		db:          db,
+		config:      cfg,
+		actors:      services.NewRemoteActorService(db, cfg),
+		relays:      services.NewRelayService(db, cfg),
+		posts:       services.NewPostService(db, cfg),
+		directory:   services.NewDirectoryService(db, cfg),
+		moderation:  services.NewModerationService(db, nil, cfg),
+		quarantine:  services.NewQuarantineService(db, cfg),
+		collections: services.NewCollectionService(db, cfg),
 	}
 }
 
@@ -59,16 +81,27 @@ func (h *ActivityPubHandler) WebFinger(w http.ResponseWriter, r *http.Request) {
 	// Look up user in database
 	ctx := r.Context()
 	var user models.User
+	var deletedAt *time.Time
+	var suspended bool
 	err := h.db.QueryRow(ctx,
-		"SELECT id, username, bio, created_at FROM users WHERE username = $1",
+		"SELECT id, username, COALESCE(bio, ''), created_at, deleted_at, suspended_at IS NOT NULL FROM users WHERE username = $1",
 		username,
-	).Scan(&user.ID, &user.Username, &user.Bio, &user.CreatedAt)
+	).Scan(&user.ID, &user.Username, &user.Bio, &user.CreatedAt, &deletedAt, &suspended)
 
 	if err != nil {
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
 
+	if deletedAt != nil {
+		http.Error(w, "User deleted", http.StatusGone)
+		return
+	}
+	if suspended {
+		http.Error(w, "User suspended", http.StatusGone)
+		return
+	}
+
 	// Build WebFinger response
 	response := map[string]any{
 		"subject": resource,
@@ -108,44 +141,42 @@ func (h *ActivityPubHandler) Actor(w http.ResponseWriter, r *http.Request) {
 	// Look up user in database
 	ctx := r.Context()
 	var user models.User
+	var deletedAt *time.Time
+	var suspended bool
 	err := h.db.QueryRow(ctx,
-		"SELECT id, username, bio, private_key, public_key, created_at FROM users WHERE username = $1",
+		"SELECT id, username, COALESCE(bio, ''), COALESCE(display_name, ''), COALESCE(avatar_url, ''), COALESCE(public_key, ''), manually_approves_followers, also_known_as, COALESCE(moved_to, ''), COALESCE(proof_public_key, ''), discoverable, COALESCE(gpg_public_key, ''), created_at, deleted_at, suspended_at IS NOT NULL FROM users WHERE username = $1",
 		username,
-	).Scan(&user.ID, &user.Username, &user.Bio, &user.PrivateKey, &user.PublicKey, &user.CreatedAt)
+	).Scan(&user.ID, &user.Username, &user.Bio, &user.DisplayName, &user.AvatarURL, &user.PublicKey, &user.ManuallyApprovesFollowers, &user.AlsoKnownAs, &user.MovedTo, &user.ProofPublicKey, &user.Discoverable, &user.GPGPublicKey, &user.CreatedAt, &deletedAt, &suspended)
 
 	if err != nil {
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
 
-	// Build Actor object
-	actorID := fmt.Sprintf("%s/users/%s", h.config.Server.BaseURL, username)
+	// Deleted accounts are served as a Tombstone until they are purged
+	if deletedAt != nil {
+		w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(activitypub.NewTombstone(activitypub.ActorURL(h.config.Server.BaseURL, user.Username), "Person", *deletedAt))
+		return
+	}
+	if suspended {
+		http.Error(w, "User suspended", http.StatusGone)
+		return
+	}
 
-	actor := models.Actor{
-		Context: []string{
-			"https://www.w3.org/ns/activitystreams",
-			"https://w3id.org/security/v1",
-		},
-		ID:                        actorID,
-		Type:                      "Person",
-		PreferredUsername:         user.Username,
-		Name:                      user.Username,
-		Summary:                   user.Bio,
-		Inbox:                     fmt.Sprintf("%s/inbox", actorID),
-		Outbox:                    fmt.Sprintf("%s/outbox", actorID),
-		Followers:                 fmt.Sprintf("%s/followers", actorID),
-		Following:                 fmt.Sprintf("%s/following", actorID),
-		URL:                       fmt.Sprintf("%s/@%s", h.config.Server.BaseURL, username),
-		ManuallyApprovesFollowers: false,
-		Published:                 user.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		PublicKey: models.ActorPublicKey{
-			ID:           fmt.Sprintf("%s#main-key", actorID),
-			Owner:        actorID,
-			PublicKeyPem: user.PublicKey,
-		},
-		Endpoints: map[string]any{
-			"sharedInbox": fmt.Sprintf("%s/inbox", h.config.Server.BaseURL),
-		},
+	// Build Actor object
+	actor := activitypub.NewActor(h.config.Server.BaseURL, &user)
+
+	// With authorized fetch, unsigned requests only get what is needed to
+	// verify signatures and deliver, so two secure instances can still meet
+	if h.config.ActivityPub.AuthorizedFetch {
+		if r.Header.Get("Signature") == "" {
+			actor = activitypub.MinimalActor(actor)
+		} else if _, err := h.verifyFetch(r); err != nil {
+			writeFetchError(w, err)
+			return
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
@@ -172,26 +203,133 @@ func (h *ActivityPubHandler) Inbox(w http.ResponseWriter, r *http.Request) {
 	// Look up user
 	ctx := r.Context()
 	var userID int
-	err := h.db.QueryRow(ctx, "SELECT id FROM users WHERE username = $1", username).Scan(&userID)
+	var privateKey string
+	var suspended bool
+	err := h.db.QueryRow(ctx,
+		"SELECT id, COALESCE(private_key, ''), suspended_at IS NOT NULL FROM users WHERE username = $1", username,
+	).Scan(&userID, &privateKey, &suspended)
 	if err != nil {
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
+	if suspended {
+		http.Error(w, "User suspended", http.StatusGone)
+		return
+	}
+
+	keyID := activitypub.ActorURL(h.config.Server.BaseURL, username) + "#main-key"
+	if activity := h.receive(w, r, &userID, privateKey, keyID); activity == nil {
+		return
+	}
+
+	// Return 202 Accepted
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// SharedInbox handles activities delivered to the whole server (/inbox),
+// including relay traffic and relays' answers to the instance actor
+func (h *ActivityPubHandler) SharedInbox(w http.ResponseWriter, r *http.Request) {
+	privateKey, _, err := h.relays.InstanceKeys(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load instance key", http.StatusInternalServerError)
+		return
+	}
+
+	keyID := activitypub.InstanceActorURL(h.config.Server.BaseURL) + "#main-key"
+	activity := h.receive(w, r, nil, privateKey, keyID)
+	if activity == nil {
+		return
+	}
+
+	// Relayed announces are stored for the inbox worker; subscriptions are
+	// confirmed right away
+	if activity["type"] == "Accept" || activity["type"] == "Reject" {
+		if err := h.relays.HandleInbound(r.Context(), activity); err != nil {
+			log.Printf("Failed to process %v from %v: %v", activity["type"], activity["actor"], err)
+		}
+	}
 
-	// TODO: Verify HTTP signature
+	w.WriteHeader(http.StatusAccepted)
+}
 
-	// Parse activity
+// receive parses and validates an inbound activity, authenticates it, and
+// stores it for processing, or in quarantine if it looks like spam. Only
+// activities signed by their actor, or carrying a valid integrity proof by
+// it, are taken. userID is nil for the shared inbox. On failure, or when the
+// activity was quarantined, the response is written and nil is returned.
+// Stored activities are published as ActivityReceived.
+func (h *ActivityPubHandler) receive(w http.ResponseWriter, r *http.Request, userID *int, privateKey, keyID string) map[string]any {
+	ctx := r.Context()
+
+	// Parse activity; the body was bounded by MaxBodySize
+	if !activitypub.IsActivityContentType(r.Header.Get("Content-Type")) {
+		http.Error(w, "Unsupported content type", http.StatusUnsupportedMediaType)
+		return nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		if bodyTooLarge(err) {
+			http.Error(w, "Activity too large", http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, "Failed to read activity", http.StatusBadRequest)
+		}
+		return nil
+	}
 	var activity map[string]any
-	if err := json.NewDecoder(r.Body).Decode(&activity); err != nil {
+	if err := json.Unmarshal(body, &activity); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
-		return
+		return nil
+	}
+	if err := activitypub.ValidateActivity(activity); err != nil {
+		http.Error(w, "Invalid activity: "+err.Error(), http.StatusUnprocessableEntity)
+		return nil
+	}
+
+	proofVerified, err := h.authenticate(r, body, activity, privateKey, keyID)
+	if err != nil {
+		// Servers announce deleted accounts whose keys can no longer be
+		// fetched; there is nothing to verify them with, nor to act on
+		if activity["type"] == "Delete" && activity["object"] == activity["actor"] {
+			w.WriteHeader(http.StatusAccepted)
+			return nil
+		}
+		log.Printf("Refused %v from %v: %v", activity["type"], activity["actor"], err)
+		writeFetchError(w, err)
+		return nil
 	}
 
-	// Store activity in database for processing
-	activityJSON, _ := json.Marshal(activity)
 	activityType, _ := activity["type"].(string)
 	actorID, _ := activity["actor"].(string)
 
+	// Actors rejected by a moderator are turned away before anything is stored
+	rejected, err := h.moderation.ActorRejected(ctx, actorID)
+	if err != nil {
+		log.Printf("Failed to check whether %s is rejected: %v", actorID, err)
+		http.Error(w, "Failed to store activity", http.StatusInternalServerError)
+		return nil
+	}
+	if rejected {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return nil
+	}
+
+	// Suspected spam waits for an admin instead of reaching the inbox
+	reason, err := h.quarantine.Inspect(ctx, activity)
+	if err != nil {
+		log.Printf("Failed to inspect %s from %s: %v", activityType, actorID, err)
+	}
+	if reason != "" {
+		if err := h.quarantine.Hold(ctx, userID, activity, proofVerified, reason); err != nil {
+			http.Error(w, "Failed to store activity", http.StatusInternalServerError)
+			return nil
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}
+
+	// Store activity in database for processing
+	activityJSON, _ := json.Marshal(activity)
+
 	var objectID string
 	if obj, ok := activity["object"].(string); ok {
 		objectID = obj
@@ -202,115 +340,314 @@ func (h *ActivityPubHandler) Inbox(w http.ResponseWriter, r *http.Request) {
 	}
 
 	_, err = h.db.Exec(ctx, `
-		INSERT INTO activities (user_id, activity_type, actor_id, object_id, activity_json, direction, processed)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, userID, activityType, actorID, objectID, activityJSON, "inbound", false)
+		INSERT INTO activities (user_id, activity_type, actor_id, object_id, activity_json, direction, processed, proof_verified)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, userID, activityType, actorID, objectID, activityJSON, "inbound", false, proofVerified)
 
 	if err != nil {
 		http.Error(w, "Failed to store activity", http.StatusInternalServerError)
-		return
+		return nil
 	}
 
-	// Return 202 Accepted
-	w.WriteHeader(http.StatusAccepted)
+	received := events.ActivityReceived{Type: activityType, ActorID: actorID, ObjectID: objectID, Activity: activity}
+	if userID != nil {
+		received.UserID = *userID
+	}
+	events.Publish(ctx, received)
+	return activity
 }
 
-// Outbox handles outbox requests (/users/{username}/outbox)
-func (h *ActivityPubHandler) Outbox(w http.ResponseWriter, r *http.Request) {
-	// Extract username from URL path
-	path := strings.TrimPrefix(r.URL.Path, "/users/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "outbox" {
-		http.Error(w, "Invalid outbox path", http.StatusBadRequest)
+// authenticate checks that an inbound activity comes from its actor. An
+// integrity proof, if present, must verify; forwarded activities carry one so
+// they can be trusted without contacting the origin. Otherwise the request
+// must be signed by the actor, over a digest of body. It reports whether the
+// activity was authenticated by its proof.
+func (h *ActivityPubHandler) authenticate(r *http.Request, body []byte, activity map[string]any, privateKey, keyID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if activitypub.ProofVerificationMethod(activity) != "" {
+		if _, err := h.actors.VerifyProof(ctx, activity, privateKey, keyID); err != nil {
+			return false, fmt.Errorf("invalid integrity proof: %w", err)
+		}
+		return true, nil
+	}
+
+	signer, err := h.verifySignature(r.WithContext(ctx), privateKey, keyID)
+	if err != nil {
+		return false, err
+	}
+	if err := activitypub.VerifyDigest(r, body); err != nil {
+		return false, err
+	}
+	if actorID, _ := activity["actor"].(string); signer != actorID {
+		return false, fmt.Errorf("signed by %s, not by actor %s", signer, actorID)
+	}
+	return false, nil
+}
+
+// InstanceActor serves the server's own actor (/actor), which signs relay
+// subscriptions and fetches made on behalf of the instance
+func (h *ActivityPubHandler) InstanceActor(w http.ResponseWriter, r *http.Request) {
+	_, publicKey, err := h.relays.InstanceKeys(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load instance key", http.StatusInternalServerError)
 		return
 	}
-	username := parts[0]
 
-	// Look up user
+	w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(activitypub.NewInstanceActor(h.config.Server.BaseURL, h.config.Server.Domain, publicKey))
+}
+
+// Users handles requests for the collection of local actors who opted into
+// the profile directory (/users), so crawlers can discover them. Pages are
+// selected with ?page=N, most recently active users first.
+func (h *ActivityPubHandler) Users(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	var userID int
-	err := h.db.QueryRow(ctx, "SELECT id FROM users WHERE username = $1", username).Scan(&userID)
+	usersURL := h.config.Server.BaseURL + "/users"
+
+	total, err := h.directory.Count(ctx)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		log.Printf("Failed to count directory: %v", err)
+		http.Error(w, "Failed to load users", http.StatusInternalServerError)
 		return
 	}
 
-	// Get page parameter
 	page := r.URL.Query().Get("page")
-
-	actorID := fmt.Sprintf("%s/users/%s", h.config.Server.BaseURL, username)
-	outboxURL := fmt.Sprintf("%s/outbox", actorID)
-
 	if page == "" {
-		// Return OrderedCollection
-		var totalItems int
-		h.db.QueryRow(ctx, "SELECT COUNT(*) FROM posts WHERE user_id = $1", userID).Scan(&totalItems)
-
 		collection := models.OrderedCollection{
 			Context:    "https://www.w3.org/ns/activitystreams",
-			ID:         outboxURL,
+			ID:         usersURL,
 			Type:       "OrderedCollection",
-			TotalItems: totalItems,
-			First:      fmt.Sprintf("%s?page=1", outboxURL),
+			TotalItems: total,
+			First:      usersURL + "?page=1",
 		}
-
 		w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
 		json.NewEncoder(w).Encode(collection)
 		return
 	}
 
-	// Return OrderedCollectionPage
-	// TODO: Implement pagination and fetch actual posts
+	n, err := strconv.Atoi(page)
+	if err != nil || n < 1 {
+		http.Error(w, "Invalid page", http.StatusBadRequest)
+		return
+	}
+	entries, err := h.directory.List(ctx, services.MaxDirectoryPage, (n-1)*services.MaxDirectoryPage)
+	if err != nil {
+		log.Printf("Failed to load directory: %v", err)
+		http.Error(w, "Failed to load users", http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]any, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, entry.ActorURL)
+	}
 	collectionPage := models.OrderedCollectionPage{
 		Context:      "https://www.w3.org/ns/activitystreams",
-		ID:           fmt.Sprintf("%s?page=%s", outboxURL, page),
+		ID:           fmt.Sprintf("%s?page=%d", usersURL, n),
 		Type:         "OrderedCollectionPage",
-		PartOf:       outboxURL,
-		OrderedItems: []any{},
-		TotalItems:   0,
+		PartOf:       usersURL,
+		TotalItems:   total,
+		OrderedItems: items,
+	}
+	if n*services.MaxDirectoryPage < total {
+		collectionPage.Next = fmt.Sprintf("%s?page=%d", usersURL, n+1)
+	}
+	if n > 1 {
+		collectionPage.Prev = fmt.Sprintf("%s?page=%d", usersURL, n-1)
 	}
 
 	w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
 	json.NewEncoder(w).Encode(collectionPage)
 }
 
-// Followers handles followers collection requests (/users/{username}/followers)
-func (h *ActivityPubHandler) Followers(w http.ResponseWriter, r *http.Request) {
+// Outbox handles outbox requests (/users/{username}/outbox), listing the
+// public and unlisted posts of the user as Create activities
+func (h *ActivityPubHandler) Outbox(w http.ResponseWriter, r *http.Request) {
 	// Extract username from URL path
 	path := strings.TrimPrefix(r.URL.Path, "/users/")
 	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "followers" {
-		http.Error(w, "Invalid followers path", http.StatusBadRequest)
+	if len(parts) < 2 || parts[1] != "outbox" {
+		http.Error(w, "Invalid outbox path", http.StatusBadRequest)
 		return
 	}
 	username := parts[0]
 
 	// Look up user
 	ctx := r.Context()
-	var userID int
-	err := h.db.QueryRow(ctx, "SELECT id FROM users WHERE username = $1", username).Scan(&userID)
+	userID, ok := h.lookupUser(w, r, username)
+	if !ok {
+		return
+	}
+
+	actorID := activitypub.ActorURL(h.config.Server.BaseURL, username)
+	h.serveCollection(w, r, actorID+"/outbox",
+		func() (int, error) { return h.collections.OutboxCount(ctx, userID) },
+		func(maxID int) ([]any, int, error) {
+			posts, next, err := h.collections.OutboxPage(ctx, userID, maxID)
+			items := make([]any, 0, len(posts))
+			for i := range posts {
+				items = append(items, activitypub.NewCreateNote(h.config.Server.BaseURL, username, &posts[i]))
+			}
+			return items, next, err
+		})
+}
+
+// Status handles requests for a single local post (/users/{username}/statuses/{id})
+func (h *ActivityPubHandler) Status(w http.ResponseWriter, r *http.Request) {
+	// Extract username and post ID from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/users/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 3 || parts[1] != "statuses" {
+		http.Error(w, "Invalid status path", http.StatusBadRequest)
+		return
+	}
+	username := parts[0]
+	postID, err := strconv.Atoi(parts[2])
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		http.Error(w, "Post not found", http.StatusNotFound)
 		return
 	}
 
-	// Count followers
-	var totalItems int
-	h.db.QueryRow(ctx, "SELECT COUNT(*) FROM followers WHERE user_id = $1 AND accepted = true", userID).Scan(&totalItems)
+	ctx := r.Context()
+	var post models.Post
+	var apID *string
+	var deletedAt *time.Time
+	var suspended bool
+	err = h.db.QueryRow(ctx, `
+		SELECT p.id, p.user_id, p.content, COALESCE(p.visibility, 'public'), p.published_at, p.ap_id, COALESCE(p.gpg_signature, ''), p.deleted_at,
+			u.suspended_at IS NOT NULL
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.id = $1 AND u.username = $2
+	`, postID, username).Scan(&post.ID, &post.UserID, &post.Content, &post.Visibility, &post.PublishedAt, &apID, &post.GPGSignature, &deletedAt, &suspended)
+	if err != nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+	if suspended {
+		http.Error(w, "User suspended", http.StatusGone)
+		return
+	}
+	if apID != nil {
+		post.APID = *apID
+	}
+	if post.Mentions, err = h.posts.Mentions(ctx, post.ID); err != nil {
+		log.Printf("Failed to load mentions of post %d: %v", post.ID, err)
+		http.Error(w, "Failed to load post", http.StatusInternalServerError)
+		return
+	}
 
-	actorID := fmt.Sprintf("%s/users/%s", h.config.Server.BaseURL, username)
+	// Followers-only and direct posts are only served to a signed request
+	// from an actor in their audience; everyone else gets a 404 so their
+	// existence is not revealed
+	if post.Visibility != "public" && post.Visibility != "unlisted" && deletedAt == nil {
+		signer, err := h.verifyFetch(r)
+		if err != nil {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		}
+		allowed, err := h.posts.CanView(ctx, &post, signer)
+		if err != nil {
+			log.Printf("Failed to check audience of post %d: %v", post.ID, err)
+			http.Error(w, "Failed to load post", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		}
+	}
 
+	activity := activitypub.NewCreateNote(h.config.Server.BaseURL, username, &post)
+	note := activity.Object.(models.APNote)
+
+	w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
+
+	// Deleted posts are served as a Tombstone so remote servers drop their copies
+	if deletedAt != nil {
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(activitypub.NewTombstone(note.ID, "Note", *deletedAt))
+		return
+	}
+
+	note.Context = "https://www.w3.org/ns/activitystreams"
+	json.NewEncoder(w).Encode(note)
+}
+
+// Featured handles requests for a user's pinned posts (/users/{username}/collections/featured)
+func (h *ActivityPubHandler) Featured(w http.ResponseWriter, r *http.Request) {
+	// Extract username from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/users/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 3 || parts[1] != "collections" || parts[2] != "featured" {
+		http.Error(w, "Invalid featured collection path", http.StatusBadRequest)
+		return
+	}
+	username := parts[0]
+
+	// Look up user
+	ctx := r.Context()
+	userID, ok := h.lookupUser(w, r, username)
+	if !ok {
+		return
+	}
+
+	posts, err := h.posts.Pinned(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to load pinned posts of %s: %v", username, err)
+		http.Error(w, "Failed to load pinned posts", http.StatusInternalServerError)
+		return
+	}
+
+	// Pinned posts are listed inline, as Mastodon does, since there are few
+	items := make([]any, 0, len(posts))
+	for i := range posts {
+		items = append(items, activitypub.NewCreateNote(h.config.Server.BaseURL, username, &posts[i]).Object)
+	}
+
+	actorID := activitypub.ActorURL(h.config.Server.BaseURL, username)
 	collection := models.OrderedCollection{
-		Context:    "https://www.w3.org/ns/activitystreams",
-		ID:         fmt.Sprintf("%s/followers", actorID),
-		Type:       "OrderedCollection",
-		TotalItems: totalItems,
+		Context:      "https://www.w3.org/ns/activitystreams",
+		ID:           activitypub.FeaturedURL(actorID),
+		Type:         "OrderedCollection",
+		TotalItems:   len(items),
+		OrderedItems: items,
 	}
 
 	w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
 	json.NewEncoder(w).Encode(collection)
 }
 
+// Followers handles followers collection requests (/users/{username}/followers)
+func (h *ActivityPubHandler) Followers(w http.ResponseWriter, r *http.Request) {
+	// Extract username from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/users/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || parts[1] != "followers" {
+		http.Error(w, "Invalid followers path", http.StatusBadRequest)
+		return
+	}
+	username := parts[0]
+
+	// Look up user
+	ctx := r.Context()
+	userID, ok := h.lookupUser(w, r, username)
+	if !ok {
+		return
+	}
+
+	actorID := activitypub.ActorURL(h.config.Server.BaseURL, username)
+	h.serveCollection(w, r, actorID+"/followers",
+		func() (int, error) { return h.collections.FollowersCount(ctx, userID) },
+		func(maxID int) ([]any, int, error) {
+			actors, next, err := h.collections.FollowersPage(ctx, userID, maxID)
+			return actorItems(actors), next, err
+		})
+}
+
 // Following handles following collection requests (/users/{username}/following)
 func (h *ActivityPubHandler) Following(w http.ResponseWriter, r *http.Request) {
 	// Extract username from URL path
@@ -324,26 +661,102 @@ func (h *ActivityPubHandler) Following(w http.ResponseWriter, r *http.Request) {
 
 	// Look up user
 	ctx := r.Context()
-	var userID int
-	err := h.db.QueryRow(ctx, "SELECT id FROM users WHERE username = $1", username).Scan(&userID)
+	userID, ok := h.lookupUser(w, r, username)
+	if !ok {
+		return
+	}
+
+	actorID := activitypub.ActorURL(h.config.Server.BaseURL, username)
+	h.serveCollection(w, r, actorID+"/following",
+		func() (int, error) { return h.collections.FollowingCount(ctx, userID) },
+		func(maxID int) ([]any, int, error) {
+			actors, next, err := h.collections.FollowingPage(ctx, userID, maxID)
+			return actorItems(actors), next, err
+		})
+}
+
+// serveCollection answers a request for a paged collection: without
+// ?page, the OrderedCollection with its total and a link to the first page;
+// with it, one OrderedCollectionPage of items below ?max_id, linking on to
+// the next page by the id it ended at
+func (h *ActivityPubHandler) serveCollection(w http.ResponseWriter, r *http.Request, collectionURL string, count func() (int, error), page func(maxID int) ([]any, int, error)) {
+	total, err := count()
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		log.Printf("Failed to count %s: %v", collectionURL, err)
+		http.Error(w, "Failed to load collection", http.StatusInternalServerError)
 		return
 	}
 
-	// Count following
-	var totalItems int
-	h.db.QueryRow(ctx, "SELECT COUNT(*) FROM following WHERE user_id = $1 AND accepted = true", userID).Scan(&totalItems)
+	query := r.URL.Query()
+	if query.Get("page") == "" {
+		collection := models.OrderedCollection{
+			Context:    "https://www.w3.org/ns/activitystreams",
+			ID:         collectionURL,
+			Type:       "OrderedCollection",
+			TotalItems: total,
+			First:      collectionURL + "?page=true",
+		}
+		w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
+		json.NewEncoder(w).Encode(collection)
+		return
+	}
 
-	actorID := fmt.Sprintf("%s/users/%s", h.config.Server.BaseURL, username)
+	maxID := 0
+	pageURL := collectionURL + "?page=true"
+	if raw := query.Get("max_id"); raw != "" {
+		if maxID, err = strconv.Atoi(raw); err != nil || maxID < 1 {
+			http.Error(w, "Invalid max_id", http.StatusBadRequest)
+			return
+		}
+		pageURL += "&max_id=" + raw
+	}
 
-	collection := models.OrderedCollection{
-		Context:    "https://www.w3.org/ns/activitystreams",
-		ID:         fmt.Sprintf("%s/following", actorID),
-		Type:       "OrderedCollection",
-		TotalItems: totalItems,
+	items, next, err := page(maxID)
+	if err != nil {
+		log.Printf("Failed to load %s: %v", pageURL, err)
+		http.Error(w, "Failed to load collection", http.StatusInternalServerError)
+		return
+	}
+	collectionPage := models.OrderedCollectionPage{
+		Context:      "https://www.w3.org/ns/activitystreams",
+		ID:           pageURL,
+		Type:         "OrderedCollectionPage",
+		PartOf:       collectionURL,
+		TotalItems:   total,
+		OrderedItems: items,
+	}
+	if next != 0 {
+		collectionPage.Next = fmt.Sprintf("%s?page=true&max_id=%d", collectionURL, next)
 	}
 
 	w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
-	json.NewEncoder(w).Encode(collection)
+	json.NewEncoder(w).Encode(collectionPage)
+}
+
+// actorItems lists actor IDs as collection items
+func actorItems(actors []string) []any {
+	items := make([]any, 0, len(actors))
+	for _, actor := range actors {
+		items = append(items, actor)
+	}
+	return items
+}
+
+// lookupUser finds the local user a collection belongs to. Unknown users get
+// a 404 and suspended ones a 410, in which case false is returned.
+func (h *ActivityPubHandler) lookupUser(w http.ResponseWriter, r *http.Request, username string) (int, bool) {
+	var userID int
+	var suspended bool
+	err := h.db.QueryRow(r.Context(),
+		"SELECT id, suspended_at IS NOT NULL FROM users WHERE username = $1", username,
+	).Scan(&userID, &suspended)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return 0, false
+	}
+	if suspended {
+		http.Error(w, "User suspended", http.StatusGone)
+		return 0, false
+	}
+	return userID, true
 }
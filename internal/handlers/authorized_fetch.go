@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+)
+
+// maxSignatureAge bounds the clock skew accepted on a signed request's Date header
+const maxSignatureAge = 12 * time.Hour
+
+var (
+	// errBlockedInstance is returned when a signed request comes from a blocked domain
+	errBlockedInstance = errors.New("instance is blocked")
+	// errRejectedActor is returned when a signed request comes from an actor
+	// a moderator rejected
+	errRejectedActor = errors.New("actor is rejected")
+)
+
+// SignedFetch wraps a GET endpoint so that, with authorized fetch enabled, it
+// only answers requests signed by a remote actor on a domain that is not blocked
+func (h *ActivityPubHandler) SignedFetch(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.config.ActivityPub.AuthorizedFetch {
+			next(w, r)
+			return
+		}
+
+		if _, err := h.verifyFetch(r); err != nil {
+			writeFetchError(w, err)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// writeFetchError answers a request that failed signature checks
+func writeFetchError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errBlockedInstance) || errors.Is(err, errRejectedActor) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("WWW-Authenticate", `Signature realm="terminalpub",headers="(request-target) host date"`)
+	http.Error(w, "Request signature required", http.StatusUnauthorized)
+}
+
+// verifyFetch checks the HTTP signature of a GET and returns the signing actor
+func (h *ActivityPubHandler) verifyFetch(r *http.Request) (string, error) {
+	// The remote actor is fetched with the key of the local user being viewed,
+	// since the remote server may require signed fetches too
+	privateKey, keyID, err := h.fetchKey(r)
+	if err != nil {
+		return "", err
+	}
+	return h.verifySignature(r, privateKey, keyID)
+}
+
+// verifySignature checks the HTTP signature of a request against the cached
+// key of its signer, refetching the signer once if it fails, and returns the
+// signing actor. Remote actors are fetched signed with privateKey and keyID.
+func (h *ActivityPubHandler) verifySignature(r *http.Request, privateKey, keyID string) (string, error) {
+	signingKeyID, err := activitypub.SignatureKeyID(r)
+	if err != nil {
+		return "", err
+	}
+
+	domain, err := activitypub.ExtractDomain(signingKeyID)
+	if err != nil {
+		return "", err
+	}
+	if h.config.InstanceBlocked(domain) {
+		return "", errBlockedInstance
+	}
+
+	date, err := http.ParseTime(r.Header.Get("Date"))
+	if err != nil {
+		return "", fmt.Errorf("invalid Date header: %w", err)
+	}
+	if age := time.Since(date); age > maxSignatureAge || age < -maxSignatureAge {
+		return "", fmt.Errorf("signature date out of range")
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	publicKey, actorID, err := h.actors.PublicKey(ctx, signingKeyID, privateKey, keyID)
+	if err != nil {
+		return "", err
+	}
+	// Keys cached before they were checked against their owner may name
+	// another server than it
+	actorDomain, err := activitypub.ExtractDomain(actorID)
+	if err != nil {
+		return "", err
+	}
+	if h.config.InstanceBlocked(actorDomain) {
+		return "", errBlockedInstance
+	}
+	rejected, err := h.moderation.ActorRejected(ctx, actorID)
+	if err != nil {
+		return "", err
+	}
+	if rejected {
+		return "", errRejectedActor
+	}
+	if err := activitypub.VerifyRequest(r, publicKey); err == nil {
+		return actorID, nil
+	}
+
+	// The cached key may have been rotated
+	doc, err := h.actors.Refresh(ctx, actorID, privateKey, keyID)
+	if err != nil {
+		return "", err
+	}
+	key, _ := doc["publicKey"].(map[string]any)
+	pem, _ := key["publicKeyPem"].(string)
+	if err := activitypub.VerifyRequest(r, pem); err != nil {
+		return "", err
+	}
+	return actorID, nil
+}
+
+// fetchKey returns the signing key of the local user named in the request path
+func (h *ActivityPubHandler) fetchKey(r *http.Request) (string, string, error) {
+	username := strings.Split(strings.TrimPrefix(r.URL.Path, "/users/"), "/")[0]
+
+	var privateKey string
+	err := h.db.QueryRow(r.Context(),
+		"SELECT COALESCE(private_key, '') FROM users WHERE username = $1", username,
+	).Scan(&privateKey)
+	if err != nil || privateKey == "" {
+		return "", "", fmt.Errorf("no signing key for %s", username)
+	}
+
+	return privateKey, activitypub.ActorURL(h.config.Server.BaseURL, username) + "#main-key", nil
+}
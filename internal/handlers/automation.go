@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxAutomationPost bounds the plain-text body of a post sent by a script
+const maxAutomationPost = 64 * 1024
+
+// AutomationHandler serves the small API scripts use with a personal API
+// token: posting from cron jobs or IFTTT-style services and polling
+// notifications, without an SSH session
+type AutomationHandler struct {
+	posts           *services.PostService
+	mastodonService *services.MastodonService
+}
+
+// NewAutomationHandler creates a new automation API handler
+func NewAutomationHandler(db *pgxpool.Pool, cfg *config.Config) *AutomationHandler {
+	return &AutomationHandler{
+		posts:           services.NewPostService(db, cfg),
+		mastodonService: services.NewMastodonService(db, cfg),
+	}
+}
+
+// Post handles POST /api/terminalpub/v1/post. The post is the "status"
+// field of a form or JSON body, or a whole text/plain body, so that
+// `echo hello | curl --data-binary @-` works; "visibility" may be set in
+// the body or the query string.
+func (h *AutomationHandler) Post(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "write:statuses")
+	if !ok {
+		return
+	}
+
+	var text, visibility string
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/plain" {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAutomationPost))
+		if err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		text, visibility = string(body), r.URL.Query().Get("visibility")
+	} else {
+		params, err := requestParams(r)
+		if err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		text, visibility = params.Get("status"), params.Get("visibility")
+	}
+
+	post, err := h.posts.Create(r.Context(), token.UserID, text, visibility)
+	if err != nil {
+		writeAPIError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"id":         strconv.Itoa(post.ID),
+		"url":        post.APID,
+		"visibility": post.Visibility,
+		"created_at": post.CreatedAt,
+	})
+}
+
+// Notifications handles GET /api/terminalpub/v1/notifications, newest
+// first. ?limit (up to 40) and ?max_id page through them like the Mastodon
+// API; ?format=text returns one line per notification for shell scripts.
+func (h *AutomationHandler) Notifications(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "read:notifications")
+	if !ok {
+		return
+	}
+
+	limit := 20
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+		limit = min(n, 40)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	notifications, err := h.mastodonService.GetNotifications(ctx, token.UserID, limit, r.URL.Query().Get("max_id"))
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, "Failed to fetch notifications")
+		return
+	}
+	if notifications == nil {
+		notifications = []services.MastodonNotification{}
+	}
+
+	if r.URL.Query().Get("format") != "text" {
+		writeJSON(w, http.StatusOK, notifications)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, n := range notifications {
+		line := fmt.Sprintf("%s\t%s\t@%s", n.CreatedAt.UTC().Format(time.RFC3339), n.Type, n.Account.Acct)
+		if n.Status != nil {
+			line += "\t" + strings.Join(strings.Fields(plainText(n.Status.Content)), " ")
+		}
+		fmt.Fprintln(w, line)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DigestHandler serves the confirmation and unsubscribe links of email
+// digests
+type DigestHandler struct {
+	config    *config.Config
+	digest    *services.DigestService
+	templates *template.Template
+}
+
+// NewDigestHandler creates a new digest handler
+func NewDigestHandler(db *pgxpool.Pool, cfg *config.Config) *DigestHandler {
+	tmpl, err := template.ParseGlob("web/templates/*.html")
+	if err != nil {
+		log.Printf("Warning: Failed to load templates: %v", err)
+		tmpl = template.New("fallback")
+	}
+
+	return &DigestHandler{
+		config:    cfg,
+		digest:    services.NewDigestService(db, cfg),
+		templates: tmpl,
+	}
+}
+
+// Confirm handles GET /digest/confirm/{token}
+func (h *DigestHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	digest, err := h.digest.Confirm(ctx, chi.URLParam(r, "token"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		h.render(w, map[string]any{"Message": "This link is invalid or no longer works."})
+		return
+	}
+	h.render(w, map[string]any{
+		"Message": fmt.Sprintf("Confirmed: %s will get a %s digest.", digest.Email, digest.Frequency),
+	})
+}
+
+// Unsubscribe handles GET and POST /digest/unsubscribe/{token}. GET only
+// asks, so that link scanners opening it don't unsubscribe anyone; POST
+// unsubscribes, and is also what mail clients send for one-click
+// unsubscribe (RFC 8058).
+func (h *DigestHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	if r.Method != http.MethodPost {
+		h.render(w, map[string]any{
+			"Message":     "Stop receiving terminalpub digests at this address?",
+			"Unsubscribe": true,
+			"Token":       token,
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	if err := h.digest.Unsubscribe(ctx, token); err != nil {
+		http.Error(w, "Failed to unsubscribe", http.StatusInternalServerError)
+		return
+	}
+	h.render(w, map[string]any{"Message": "You are unsubscribed and will get no more digests."})
+}
+
+// render writes the digest page
+func (h *DigestHandler) render(w http.ResponseWriter, data map[string]any) {
+	data["Domain"] = h.config.Server.Domain
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := h.templates.ExecuteTemplate(w, "digest.html", data); err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
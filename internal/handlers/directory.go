@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// directoryPageSize is how many users a page of the directory shows
+const directoryPageSize = 40
+
+// DirectoryHandler serves the directory of local users who opted into it at
+// /directory, as HTML or as JSON depending on the Accept header
+type DirectoryHandler struct {
+	config    *config.Config
+	directory *services.DirectoryService
+	templates *template.Template
+}
+
+// NewDirectoryHandler creates a new directory handler
+func NewDirectoryHandler(db *pgxpool.Pool, cfg *config.Config) *DirectoryHandler {
+	tmpl, err := template.ParseGlob("web/templates/*.html")
+	if err != nil {
+		log.Printf("Warning: Failed to load templates: %v", err)
+		tmpl = template.New("fallback")
+	}
+
+	return &DirectoryHandler{
+		config:    cfg,
+		directory: services.NewDirectoryService(db, cfg),
+		templates: tmpl,
+	}
+}
+
+// Page handles GET /directory; ?page=N selects further pages
+func (h *DirectoryHandler) Page(w http.ResponseWriter, r *http.Request) {
+	page := 1
+	if value := r.URL.Query().Get("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		page = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	total, err := h.directory.Count(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load directory", http.StatusInternalServerError)
+		return
+	}
+	entries, err := h.directory.List(ctx, directoryPageSize, (page-1)*directoryPageSize)
+	if err != nil {
+		http.Error(w, "Failed to load directory", http.StatusInternalServerError)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		if entries == nil {
+			entries = []models.DirectoryEntry{}
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(entries)
+		return
+	}
+
+	data := map[string]any{
+		"Entries": entries,
+		"Total":   total,
+		"Domain":  h.config.Server.Domain,
+		"Prev":    page - 1,
+		"Next":    0,
+	}
+	if page*directoryPageSize < total {
+		data["Next"] = page + 1
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := h.templates.ExecuteTemplate(w, "directory.html", data); err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
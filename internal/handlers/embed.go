@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// embedWidth and embedHeight are the iframe size oEmbed responses
+	// suggest when the consumer sets no maximum
+	embedWidth  = 500
+	embedHeight = 280
+
+	// embedTextWidth is how many columns the terminal-style embed takes
+	embedTextWidth = 60
+)
+
+// embedPath matches the paths of a local post oEmbed accepts: its
+// ActivityPub ID and its embed page
+var embedPath = regexp.MustCompile(`^/(?:users/([a-z0-9_]+)/statuses|embed)/(\d+)$`)
+
+// EmbedHandler lets other sites embed public local posts: /api/oembed
+// describes a post to oEmbed consumers such as blog engines, and
+// /embed/{postID} is the page their iframe shows
+type EmbedHandler struct {
+	config    *config.Config
+	timelines *services.TimelineService
+	templates *template.Template
+}
+
+// NewEmbedHandler creates a new embed handler
+func NewEmbedHandler(db *pgxpool.Pool, cfg *config.Config) *EmbedHandler {
+	tmpl, err := template.ParseGlob("web/templates/*.html")
+	if err != nil {
+		log.Printf("Warning: Failed to load templates: %v", err)
+		tmpl = template.New("fallback")
+	}
+
+	return &EmbedHandler{
+		config:    cfg,
+		timelines: services.NewTimelineService(db, cfg),
+		templates: tmpl,
+	}
+}
+
+// OEmbed handles GET /api/oembed?url=<post URL>. maxwidth and maxheight
+// shrink the iframe; style=terminal embeds the terminal-style rendering.
+func (h *EmbedHandler) OEmbed(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if format := query.Get("format"); format != "" && format != "json" {
+		http.Error(w, "Only the json format is supported", http.StatusNotImplemented)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	status, postID, ok := h.lookup(ctx, w, query.Get("url"))
+	if !ok {
+		return
+	}
+
+	width, height := embedWidth, embedHeight
+	if n, err := strconv.Atoi(query.Get("maxwidth")); err == nil && n > 0 {
+		width = min(width, n)
+	}
+	if n, err := strconv.Atoi(query.Get("maxheight")); err == nil && n > 0 {
+		height = min(height, n)
+	}
+
+	src := fmt.Sprintf("%s/embed/%d", h.config.Server.BaseURL, postID)
+	if query.Get("style") == "terminal" {
+		src += "?style=terminal"
+	}
+	iframe := fmt.Sprintf(`<iframe src="%s" width="%d" height="%d" style="border: 0; max-width: 100%%" loading="lazy"></iframe>`,
+		template.HTMLEscapeString(src), width, height)
+
+	response := map[string]any{
+		"version":       "1.0",
+		"type":          "rich",
+		"provider_name": "terminalpub",
+		"provider_url":  h.config.Server.BaseURL,
+		"title":         "Post by @" + status.Account.Acct + "@" + h.config.Server.Domain,
+		"author_name":   embedAuthor(status),
+		"author_url":    activitypub.ActorURL(h.config.Server.BaseURL, status.Account.Username),
+		"html":          iframe,
+		"width":         width,
+		"height":        height,
+		"cache_age":     86400,
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(response)
+}
+
+// lookup finds the public post a URL on this server points to, replying
+// with an error when there is none
+func (h *EmbedHandler) lookup(ctx context.Context, w http.ResponseWriter, rawURL string) (*services.MastodonStatus, int, bool) {
+	target, err := url.Parse(rawURL)
+	base, baseErr := url.Parse(h.config.Server.BaseURL)
+	if rawURL == "" || err != nil || baseErr != nil || !strings.EqualFold(target.Host, base.Host) {
+		http.Error(w, "Not a post on this server", http.StatusNotFound)
+		return nil, 0, false
+	}
+	match := embedPath.FindStringSubmatch(target.Path)
+	if match == nil {
+		http.Error(w, "Not a post on this server", http.StatusNotFound)
+		return nil, 0, false
+	}
+	postID, err := strconv.Atoi(match[2])
+	if err != nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return nil, 0, false
+	}
+
+	status, ok := h.load(ctx, w, postID)
+	if ok && match[1] != "" && match[1] != status.Account.Username {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return nil, 0, false
+	}
+	return status, postID, ok
+}
+
+// load fetches a public post, replying with an error when there is none
+func (h *EmbedHandler) load(ctx context.Context, w http.ResponseWriter, postID int) (*services.MastodonStatus, bool) {
+	status, err := h.timelines.Post(ctx, postID)
+	if errors.Is(err, services.ErrPostNotFound) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Failed to load post %d for embedding: %v", postID, err)
+		http.Error(w, "Failed to load post", http.StatusInternalServerError)
+		return nil, false
+	}
+	return status, true
+}
+
+// Page handles GET /embed/{postID}, the page an embedded post's iframe
+// shows. style=terminal draws the post as the TUI does; format=text and
+// format=ansi serve that drawing as plain text, the latter in colour, for
+// curl and other terminals.
+func (h *EmbedHandler) Page(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(chi.URLParam(r, "postID"))
+	if err != nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	status, ok := h.load(ctx, w, postID)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	if format := query.Get("format"); format == "text" || format == "ansi" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, embedText(status, h.config.Server.Domain, format == "ansi"))
+		return
+	}
+
+	data := map[string]any{
+		"Name":      embedAuthor(status),
+		"Acct":      status.Account.Acct + "@" + h.config.Server.Domain,
+		"Avatar":    status.Account.Avatar,
+		"AuthorURL": activitypub.ActorURL(h.config.Server.BaseURL, status.Account.Username),
+		"Content":   plainText(status.Content),
+		"URL":       status.URL,
+		"Published": status.CreatedAt,
+		"Domain":    h.config.Server.Domain,
+		"Terminal":  query.Get("style") == "terminal",
+		"Box":       embedText(status, h.config.Server.Domain, false),
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := h.templates.ExecuteTemplate(w, "embed.html", data); err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// embedAuthor returns the name a post's author goes by
+func embedAuthor(status *services.MastodonStatus) string {
+	if status.Account.DisplayName != "" {
+		return status.Account.DisplayName
+	}
+	return status.Account.Username
+}
+
+// embedText draws a post in a rounded box, as the TUI shows it, coloured
+// with ANSI escapes when color is set
+func embedText(status *services.MastodonStatus, domain string, color bool) string {
+	paint := func(code, text string) string {
+		if !color || text == "" {
+			return text
+		}
+		return "\x1b[" + code + "m" + text + "\x1b[0m"
+	}
+	inner := embedTextWidth - 4
+
+	// Lines are laid out plain, then padded and painted
+	type line struct{ text, code string }
+	name := ansi.Truncate(stripControl(embedAuthor(status)), inner, "…")
+	acct := ansi.Truncate(stripControl("@"+status.Account.Acct+"@"+domain), inner, "…")
+	lines := []line{{name, "1;36"}, {acct, "2"}, {"", ""}}
+	for _, text := range strings.Split(ansi.Wrap(plainText(status.Content), inner, ""), "\n") {
+		lines = append(lines, line{text, ""})
+	}
+	lines = append(lines, line{"", ""}, line{status.CreatedAt.UTC().Format("2006-01-02 15:04") + " · " + domain, "2"})
+
+	var b strings.Builder
+	b.WriteString(paint("32", "╭"+strings.Repeat("─", inner+2)+"╮") + "\n")
+	for _, l := range lines {
+		padding := strings.Repeat(" ", max(inner-ansi.StringWidth(l.text), 0))
+		b.WriteString(paint("32", "│") + " " + paint(l.code, l.text) + padding + " " + paint("32", "│") + "\n")
+	}
+	b.WriteString(paint("32", "╰"+strings.Repeat("─", inner+2)+"╯") + "\n")
+	return b.String()
+}
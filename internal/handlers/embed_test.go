@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+func TestEmbedTextControlCharacters(t *testing.T) {
+	status := &services.MastodonStatus{
+		Content: "<p>hello\x1b]52;c;cHduZWQ=\x07 &#27;[2Jworld\u009b31m</p><p>second\tline</p>",
+		Account: services.MastodonAccount{Username: "alice", DisplayName: "Alice\x1b[5m", Acct: "alice\a"},
+	}
+	for _, color := range []bool{false, true} {
+		text := embedText(status, "example.social", color)
+		// Colour comes only from the embed's own SGR sequences
+		plain := strings.NewReplacer("\x1b[32m", "", "\x1b[1;36m", "", "\x1b[2m", "", "\x1b[m", "", "\x1b[0m", "").Replace(text)
+		for _, r := range plain {
+			if r != '\n' && (r < 0x20 || r >= 0x7f && r < 0xa0) {
+				t.Errorf("embedText(color %v) kept control character %U:\n%q", color, r, text)
+			}
+		}
+		if !strings.Contains(text, "hello]52;c;cHduZWQ= [2Jworld31m") || !strings.Contains(text, "second line") {
+			t.Errorf("embedText(color %v) lost the text:\n%s", color, text)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// ExportHandler serves user data archives
+type ExportHandler struct {
+	db            *pgxpool.Pool
+	exportService *services.ExportService
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(db *pgxpool.Pool, redisClient redis.UniversalClient, cfg *config.Config) *ExportHandler {
+	return &ExportHandler{
+		db:            db,
+		exportService: services.NewExportService(db, redisClient, cfg.Server.BaseURL),
+	}
+}
+
+// ServeHTTP serves the archive for the user authenticated by an API token
+// with the read scope
+func (h *ExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "read")
+	if !ok {
+		return
+	}
+
+	h.writeArchive(w, r, token.UserID)
+}
+
+// Download serves the archive for a one-time link (/export/{token})
+func (h *ExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.exportService.ConsumeDownloadToken(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		http.Error(w, "Invalid or expired download link", http.StatusNotFound)
+		return
+	}
+
+	h.writeArchive(w, r, userID)
+}
+
+// writeArchive streams the archive as a file download
+func (h *ExportHandler) writeArchive(w http.ResponseWriter, r *http.Request, userID int) {
+	var username string
+	if err := h.db.QueryRow(r.Context(), "SELECT username FROM users WHERE id = $1", userID).Scan(&username); err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, services.ArchiveFilename(username, time.Now())))
+
+	if err := h.exportService.WriteArchive(r.Context(), userID, w); err != nil {
+		// Headers are already sent; the truncated archive will fail to extract
+		log.Printf("Export failed for user %d: %v", userID, err)
+	}
+}
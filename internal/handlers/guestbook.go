@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// guestbookPageSize is how many entries the guestbook page shows
+const guestbookPageSize = 50
+
+// GuestbookHandler serves the guestbook anonymous visitors sign over SSH at
+// /guestbook, as HTML or as JSON depending on the Accept header
+type GuestbookHandler struct {
+	config    *config.Config
+	guestbook *services.GuestbookService
+	templates *template.Template
+}
+
+// NewGuestbookHandler creates a new guestbook handler
+func NewGuestbookHandler(db *pgxpool.Pool, redisClient redis.UniversalClient, cfg *config.Config) *GuestbookHandler {
+	tmpl, err := template.ParseGlob("web/templates/*.html")
+	if err != nil {
+		log.Printf("Warning: Failed to load templates: %v", err)
+		tmpl = template.New("fallback")
+	}
+
+	return &GuestbookHandler{
+		config:    cfg,
+		guestbook: services.NewGuestbookService(db, redisClient, cfg),
+		templates: tmpl,
+	}
+}
+
+// Page handles GET /guestbook
+func (h *GuestbookHandler) Page(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	entries, err := h.guestbook.Recent(ctx, guestbookPageSize)
+	if err != nil {
+		http.Error(w, "Failed to load guestbook", http.StatusInternalServerError)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		if entries == nil {
+			entries = []models.GuestbookEntry{}
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(entries)
+		return
+	}
+
+	data := map[string]any{
+		"Entries": entries,
+		"Open":    h.guestbook.Enabled(),
+		"Domain":  h.config.Server.Domain,
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := h.templates.ExecuteTemplate(w, "guestbook.html", data); err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
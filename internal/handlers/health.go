@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/fulgidus/terminalpub/internal/db"
+	"github.com/fulgidus/terminalpub/internal/events"
+	"github.com/fulgidus/terminalpub/internal/services"
 )
 
 // HealthHandler handles health check requests
@@ -23,6 +25,10 @@ func NewHealthHandler(database *db.DB) *HealthHandler {
 type HealthResponse struct {
 	Status   string            `json:"status"`
 	Services map[string]string `json:"services"`
+	Pool     *db.PoolStats     `json:"pool,omitempty"`     // PostgreSQL connection pool usage
+	Replicas map[string]string `json:"replicas,omitempty"` // Read replicas; one that is down or lagging only sends reads to the primary
+	Events   map[string]uint64 `json:"events,omitempty"`   // Domain events this node raised since it started
+	Node     string            `json:"node"`               // Which node answered, behind a load balancer
 	Time     string            `json:"time"`
 }
 
@@ -34,6 +40,8 @@ func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	response := HealthResponse{
 		Status:   "healthy",
 		Services: make(map[string]string),
+		Events:   events.Default.Counts(),
+		Node:     services.NodeID,
 		Time:     time.Now().UTC().Format(time.RFC3339),
 	}
 
@@ -46,6 +54,9 @@ func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		} else {
 			response.Services["database"] = "up"
 		}
+		stats := h.db.PoolStats()
+		response.Pool = &stats
+		response.Replicas = h.db.ReplicaStatus()
 	} else {
 		response.Services["database"] = "not configured"
 	}
@@ -0,0 +1,710 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/fulgidus/terminalpub/internal/webpush"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MastodonAPIHandler serves the subset of the Mastodon client API that lets
+// Mastodon apps log into terminalpub and use its native accounts and posts
+type MastodonAPIHandler struct {
+	db        *pgxpool.Pool
+	config    *config.Config
+	oauth     *auth.OAuthServer
+	users     *services.UserService
+	posts     *services.PostService
+	timelines *services.TimelineService
+	push      *services.PushService
+	search    *services.SearchService
+	templates *template.Template
+}
+
+// NewMastodonAPIHandler creates a new Mastodon API handler
+func NewMastodonAPIHandler(db *pgxpool.Pool, cfg *config.Config) *MastodonAPIHandler {
+	tmpl, err := template.ParseGlob("web/templates/*.html")
+	if err != nil {
+		log.Printf("Warning: Failed to load templates: %v", err)
+		tmpl = template.New("fallback")
+	}
+
+	return &MastodonAPIHandler{
+		db:        db,
+		config:    cfg,
+		oauth:     auth.NewOAuthServer(db),
+		users:     services.NewUserService(db),
+		posts:     services.NewPostService(db, cfg),
+		timelines: services.NewTimelineService(db, cfg),
+		push:      services.NewPushService(db, cfg),
+		search:    services.NewSearchService(db, cfg),
+		templates: tmpl,
+	}
+}
+
+// writeJSON writes a JSON API response
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeAPIError writes an error in the format Mastodon clients expect
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// requestParams returns the parameters of a form or JSON request body,
+// merged with the query string. Nested JSON objects are flattened to the
+// bracketed keys forms use, e.g. data[alerts][mention]; list values are
+// joined with spaces.
+func requestParams(r *http.Request) (url.Values, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		return r.Form, nil
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	params := r.URL.Query()
+	flattenParams(params, "", body)
+	return params, nil
+}
+
+// flattenParams adds the fields of a decoded JSON object to params
+func flattenParams(params url.Values, prefix string, body map[string]any) {
+	for key, value := range body {
+		if prefix != "" {
+			key = prefix + "[" + key + "]"
+		}
+		switch value := value.(type) {
+		case string:
+			params.Set(key, value)
+		case float64, bool:
+			params.Set(key, fmt.Sprint(value))
+		case []any:
+			var parts []string
+			for _, part := range value {
+				parts = append(parts, fmt.Sprint(part))
+			}
+			params.Set(key, strings.Join(parts, " "))
+		case map[string]any:
+			flattenParams(params, key, value)
+		}
+	}
+}
+
+// requireScope checks that the request's token grants scope, writing an error if not
+func requireScope(w http.ResponseWriter, r *http.Request, scope string) (*models.APIToken, bool) {
+	token, ok := APITokenFromContext(r.Context())
+	if !ok {
+		writeAPIError(w, http.StatusUnauthorized, "The access token is invalid")
+		return nil, false
+	}
+	if !token.HasScope(scope) {
+		writeAPIError(w, http.StatusForbidden, "This action is outside the authorized scopes")
+		return nil, false
+	}
+	return token, true
+}
+
+// CreateApp handles POST /api/v1/apps
+func (h *MastodonAPIHandler) CreateApp(w http.ResponseWriter, r *http.Request) {
+	params, err := requestParams(r)
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	client, err := h.oauth.RegisterApp(r.Context(), params.Get("client_name"), params.Get("redirect_uris"), params.Get("scopes"), params.Get("website"))
+	if err != nil {
+		writeAPIError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	_, vapidKey, err := h.push.VAPIDKeys(r.Context())
+	if err != nil {
+		log.Printf("Failed to load VAPID key: %v", err)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":            strconv.Itoa(client.ID),
+		"name":          client.Name,
+		"website":       nullable(client.Website),
+		"redirect_uri":  strings.Join(client.RedirectURIs, "\n"),
+		"redirect_uris": client.RedirectURIs,
+		"scopes":        strings.Fields(client.Scopes),
+		"client_id":     client.ClientID,
+		"client_secret": client.ClientSecret,
+		"vapid_key":     vapidKey,
+	})
+}
+
+// VerifyApp handles GET /api/v1/apps/verify_credentials
+func (h *MastodonAPIHandler) VerifyApp(w http.ResponseWriter, r *http.Request) {
+	token, ok := APITokenFromContext(r.Context())
+	if !ok {
+		writeAPIError(w, http.StatusUnauthorized, "The access token is invalid")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"name":    token.Name,
+		"website": nil,
+		"scopes":  strings.Fields(token.Scopes),
+	})
+}
+
+// nullable returns nil for an empty string, so it encodes as JSON null
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// Authorize handles GET and POST /oauth/authorize. The GET shows a form for
+// the login code from `ssh <host> login-code`; the POST checks it and
+// redirects back to the app with an authorization code.
+func (h *MastodonAPIHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	data := map[string]any{
+		"ClientID":    r.Form.Get("client_id"),
+		"RedirectURI": r.Form.Get("redirect_uri"),
+		"Scope":       r.Form.Get("scope"),
+		"State":       r.Form.Get("state"),
+		"Domain":      h.config.Server.Domain,
+	}
+
+	ctx := r.Context()
+	if r.Form.Get("response_type") != "code" {
+		h.renderAuthorize(w, data, "Only the authorization code flow is supported")
+		return
+	}
+	client, err := h.oauth.GetApp(ctx, r.Form.Get("client_id"))
+	if err != nil {
+		h.renderAuthorize(w, data, "Unknown application")
+		return
+	}
+	redirectURI := r.Form.Get("redirect_uri")
+	if !auth.AllowsRedirect(client, redirectURI) {
+		h.renderAuthorize(w, data, "The redirect URI was not registered by this application")
+		return
+	}
+	data["AppName"] = client.Name
+
+	if r.Method != http.MethodPost {
+		h.renderAuthorize(w, data, "")
+		return
+	}
+
+	code, userID, err := h.oauth.Authorize(ctx, client, r.Form.Get("login_code"), redirectURI, r.Form.Get("scope"))
+	if err != nil {
+		h.renderAuthorize(w, data, err.Error())
+		return
+	}
+	log.Printf("User %d authorized app %q", userID, client.Name)
+
+	if redirectURI == auth.OutOfBandRedirectURI {
+		data["Code"] = code
+		h.renderAuthorize(w, data, "")
+		return
+	}
+
+	target, _ := url.Parse(redirectURI)
+	query := target.Query()
+	query.Set("code", code)
+	if state := r.Form.Get("state"); state != "" {
+		query.Set("state", state)
+	}
+	target.RawQuery = query.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+// renderAuthorize renders the authorization page
+func (h *MastodonAPIHandler) renderAuthorize(w http.ResponseWriter, data map[string]any, message string) {
+	data["Error"] = message
+	if err := h.templates.ExecuteTemplate(w, "authorize.html", data); err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// Token handles POST /oauth/token for the authorization_code grant
+func (h *MastodonAPIHandler) Token(w http.ResponseWriter, r *http.Request) {
+	params, err := requestParams(r)
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if params.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error":             "unsupported_grant_type",
+			"error_description": "Only the authorization_code grant is supported",
+		})
+		return
+	}
+
+	accessToken, scopes, err := h.oauth.Exchange(r.Context(), params.Get("client_id"), params.Get("client_secret"), params.Get("code"), params.Get("redirect_uri"))
+	if errors.Is(err, auth.ErrInvalidGrant) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error":             "invalid_grant",
+			"error_description": "The authorization code or client credentials are invalid",
+		})
+		return
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "Failed to issue token")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"scope":        scopes,
+		"created_at":   time.Now().Unix(),
+	})
+}
+
+// Revoke handles POST /oauth/revoke, for the client the token was issued to
+func (h *MastodonAPIHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	params, err := requestParams(r)
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	err = h.oauth.Revoke(r.Context(), params.Get("client_id"), params.Get("client_secret"), params.Get("token"))
+	if errors.Is(err, auth.ErrInvalidGrant) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{
+			"error":             "invalid_client",
+			"error_description": "The client credentials are invalid",
+		})
+		return
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "Failed to revoke token")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{})
+}
+
+// account builds the Mastodon Account entity for a local user
+func (h *MastodonAPIHandler) account(ctx context.Context, user *models.User) services.MastodonAccount {
+	account := services.MastodonAccount{
+		ID:          strconv.Itoa(user.ID),
+		Username:    user.Username,
+		Acct:        user.Username,
+		DisplayName: user.DisplayName,
+		Note:        user.Bio,
+		URL:         fmt.Sprintf("%s/@%s", h.config.Server.BaseURL, user.Username),
+		Avatar:      user.AvatarURL,
+		CreatedAt:   user.CreatedAt,
+		Locked:      user.ManuallyApprovesFollowers,
+	}
+
+	// Counts are informational; a failed query leaves them at zero
+	h.db.QueryRow(ctx, "SELECT COUNT(*) FROM followers WHERE user_id = $1 AND accepted", user.ID).Scan(&account.FollowersCount)
+	h.db.QueryRow(ctx, "SELECT COUNT(*) FROM following WHERE user_id = $1 AND accepted", user.ID).Scan(&account.FollowingCount)
+	h.db.QueryRow(ctx, "SELECT COUNT(*) FROM posts WHERE user_id = $1 AND deleted_at IS NULL", user.ID).Scan(&account.StatusesCount)
+	return account
+}
+
+// VerifyCredentials handles GET /api/v1/accounts/verify_credentials
+func (h *MastodonAPIHandler) VerifyCredentials(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "read:accounts")
+	if !ok {
+		return
+	}
+	user, err := h.users.GetUserByID(r.Context(), token.UserID)
+	if err != nil {
+		writeAPIError(w, http.StatusUnauthorized, "The access token is invalid")
+		return
+	}
+	writeJSON(w, http.StatusOK, h.account(r.Context(), user))
+}
+
+// lookupAccount loads the local user named by the {id} path parameter
+func (h *MastodonAPIHandler) lookupAccount(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "Record not found")
+		return nil, false
+	}
+	user, err := h.users.GetUserByID(r.Context(), id)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "Record not found")
+		return nil, false
+	}
+	return user, true
+}
+
+// Account handles GET /api/v1/accounts/{id}
+func (h *MastodonAPIHandler) Account(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireScope(w, r, "read:accounts"); !ok {
+		return
+	}
+	user, ok := h.lookupAccount(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, h.account(r.Context(), user))
+}
+
+// AccountStatuses handles GET /api/v1/accounts/{id}/statuses
+func (h *MastodonAPIHandler) AccountStatuses(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireScope(w, r, "read:statuses"); !ok {
+		return
+	}
+	user, ok := h.lookupAccount(w, r)
+	if !ok {
+		return
+	}
+	limit, ok := pageLimit(w, r)
+	if !ok {
+		return
+	}
+	if r.URL.Query().Get("pinned") == "true" {
+		// Pinned posts are few, so they come in a single page
+		statuses, err := h.timelines.Pinned(r.Context(), user.ID)
+		h.writeStatuses(w, r, statuses, err, maxTimelineLimit+1)
+		return
+	}
+	statuses, err := h.timelines.Account(r.Context(), user.ID, limit, r.URL.Query().Get("max_id"))
+	h.writeStatuses(w, r, statuses, err, limit)
+}
+
+// HomeTimeline handles GET /api/v1/timelines/home
+func (h *MastodonAPIHandler) HomeTimeline(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "read:statuses")
+	if !ok {
+		return
+	}
+	limit, ok := pageLimit(w, r)
+	if !ok {
+		return
+	}
+	statuses, err := h.timelines.Home(r.Context(), token.UserID, limit, r.URL.Query().Get("max_id"))
+	h.writeStatuses(w, r, statuses, err, limit)
+}
+
+// pageLimit reads the limit query parameter, capped like Mastodon's
+func pageLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return 20, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		writeAPIError(w, http.StatusBadRequest, "Invalid limit")
+		return 0, false
+	}
+	return min(n, maxTimelineLimit), true
+}
+
+// writeStatuses writes a page of statuses with a Link header to the next page
+func (h *MastodonAPIHandler) writeStatuses(w http.ResponseWriter, r *http.Request, statuses []services.MastodonStatus, err error, limit int) {
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "Failed to load statuses")
+		return
+	}
+	if statuses == nil {
+		statuses = []services.MastodonStatus{}
+	}
+	if len(statuses) == limit {
+		next := *r.URL
+		query := next.Query()
+		query.Set("max_id", statuses[len(statuses)-1].ID)
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="next"`, h.config.Server.BaseURL, next.RequestURI()))
+	}
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+// Status handles GET /api/v1/statuses/{id}
+func (h *MastodonAPIHandler) Status(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "read:statuses")
+	if !ok {
+		return
+	}
+	status, err := h.timelines.Status(r.Context(), chi.URLParam(r, "id"), token.UserID)
+	if errors.Is(err, services.ErrPostNotFound) {
+		writeAPIError(w, http.StatusNotFound, "Record not found")
+		return
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "Failed to load status")
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// CreateStatus handles POST /api/v1/statuses
+func (h *MastodonAPIHandler) CreateStatus(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "write:statuses")
+	if !ok {
+		return
+	}
+	params, err := requestParams(r)
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	post, err := h.posts.Create(r.Context(), token.UserID, params.Get("status"), params.Get("visibility"))
+	if err != nil {
+		writeAPIError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	status, err := h.timelines.Status(r.Context(), services.StatusID(post), token.UserID)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "Failed to load status")
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// DeleteStatus handles DELETE /api/v1/statuses/{id}
+func (h *MastodonAPIHandler) DeleteStatus(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "write:statuses")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	status, err := h.timelines.Status(ctx, chi.URLParam(r, "id"), token.UserID)
+	if err == nil && status.Account.ID != strconv.Itoa(token.UserID) {
+		err = services.ErrPostNotFound
+	}
+	var postID int
+	if err == nil {
+		postID, err = h.timelines.PostID(ctx, status.ID)
+	}
+	if err == nil {
+		err = h.posts.Delete(ctx, token.UserID, postID)
+	}
+	if errors.Is(err, services.ErrPostNotFound) {
+		writeAPIError(w, http.StatusNotFound, "Record not found")
+		return
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "Failed to delete status")
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// PinStatus handles POST /api/v1/statuses/{id}/pin
+func (h *MastodonAPIHandler) PinStatus(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, true)
+}
+
+// UnpinStatus handles POST /api/v1/statuses/{id}/unpin
+func (h *MastodonAPIHandler) UnpinStatus(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, false)
+}
+
+// setPinned pins or unpins one of the token owner's statuses and writes it back
+func (h *MastodonAPIHandler) setPinned(w http.ResponseWriter, r *http.Request, pin bool) {
+	token, ok := requireScope(w, r, "write:accounts")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	statusID := chi.URLParam(r, "id")
+	status, err := h.timelines.Status(ctx, statusID, token.UserID)
+	if err == nil && status.Account.ID != strconv.Itoa(token.UserID) {
+		err = services.ErrPostNotFound
+	}
+	var postID int
+	if err == nil {
+		postID, err = h.timelines.PostID(ctx, statusID)
+	}
+	if err == nil {
+		if pin {
+			err = h.posts.Pin(ctx, token.UserID, postID)
+		} else {
+			err = h.posts.Unpin(ctx, token.UserID, postID)
+		}
+	}
+	if err == nil {
+		status, err = h.timelines.Status(ctx, statusID, token.UserID)
+	}
+	switch {
+	case errors.Is(err, services.ErrPostNotFound):
+		writeAPIError(w, http.StatusNotFound, "Record not found")
+	case errors.Is(err, services.ErrPinLimit), errors.Is(err, services.ErrPinDirect):
+		writeAPIError(w, http.StatusUnprocessableEntity, err.Error())
+	case err != nil:
+		writeAPIError(w, http.StatusInternalServerError, "Failed to update status")
+	default:
+		writeJSON(w, http.StatusOK, status)
+	}
+}
+
+// pushSubscription builds the Mastodon WebPushSubscription entity
+func (h *MastodonAPIHandler) pushSubscription(ctx context.Context, sub *models.PushSubscription) map[string]any {
+	_, vapidKey, err := h.push.VAPIDKeys(ctx)
+	if err != nil {
+		log.Printf("Failed to load VAPID key: %v", err)
+	}
+	return map[string]any{
+		"id":       strconv.Itoa(sub.ID),
+		"endpoint": sub.Endpoint,
+		"alerts": map[string]bool{
+			"mention":   sub.AlertMention,
+			"follow":    sub.AlertFollow,
+			"favourite": false,
+			"reblog":    false,
+			"poll":      false,
+			"status":    false,
+		},
+		"policy":     sub.Policy,
+		"server_key": vapidKey,
+	}
+}
+
+// writePushResult writes a subscription or the error loading it
+func (h *MastodonAPIHandler) writePushResult(w http.ResponseWriter, r *http.Request, sub *models.PushSubscription, err error) {
+	if errors.Is(err, services.ErrPushSubscriptionNotFound) {
+		writeAPIError(w, http.StatusNotFound, "Record not found")
+		return
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, h.pushSubscription(r.Context(), sub))
+}
+
+// CreatePushSubscription handles POST /api/v1/push/subscription. Only
+// mention and follow alerts are supported.
+func (h *MastodonAPIHandler) CreatePushSubscription(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "push")
+	if !ok {
+		return
+	}
+	params, err := requestParams(r)
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	sub := webpush.Subscription{
+		Endpoint: params.Get("subscription[endpoint]"),
+		P256dh:   params.Get("subscription[keys][p256dh]"),
+		Auth:     params.Get("subscription[keys][auth]"),
+	}
+	subscription, err := h.push.Subscribe(r.Context(), token, sub,
+		params.Get("data[alerts][mention]") == "true", params.Get("data[alerts][follow]") == "true", params.Get("data[policy]"))
+	h.writePushResult(w, r, subscription, err)
+}
+
+// PushSubscription handles GET /api/v1/push/subscription
+func (h *MastodonAPIHandler) PushSubscription(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "push")
+	if !ok {
+		return
+	}
+	subscription, err := h.push.Subscription(r.Context(), token.ID)
+	h.writePushResult(w, r, subscription, err)
+}
+
+// UpdatePushSubscription handles PUT /api/v1/push/subscription
+func (h *MastodonAPIHandler) UpdatePushSubscription(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "push")
+	if !ok {
+		return
+	}
+	params, err := requestParams(r)
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	subscription, err := h.push.UpdateAlerts(r.Context(), token.ID,
+		params.Get("data[alerts][mention]") == "true", params.Get("data[alerts][follow]") == "true", params.Get("data[policy]"))
+	h.writePushResult(w, r, subscription, err)
+}
+
+// DeletePushSubscription handles DELETE /api/v1/push/subscription
+func (h *MastodonAPIHandler) DeletePushSubscription(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "push")
+	if !ok {
+		return
+	}
+	if err := h.push.Unsubscribe(r.Context(), token.ID); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "Failed to delete push subscription")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{})
+}
+
+// Search handles GET /api/v2/search over local and cached remote posts.
+// Accounts are not searched; hashtags are the facets of the matching posts,
+// with the number of posts using each.
+func (h *MastodonAPIHandler) Search(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "read:search")
+	if !ok {
+		return
+	}
+	limit, ok := pageLimit(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	offset, _ := strconv.Atoi(query.Get("offset"))
+
+	results := &services.SearchResults{}
+	if kind := query.Get("type"); kind == "" || kind == "statuses" || kind == "hashtags" {
+		var err error
+		results, err = h.search.Search(r.Context(), token.UserID, query.Get("q"), limit, max(offset, 0))
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, "Search failed")
+			return
+		}
+	}
+
+	statuses := results.Statuses
+	if statuses == nil || query.Get("type") == "hashtags" {
+		statuses = []services.MastodonStatus{}
+	}
+	hashtags := []map[string]any{}
+	if query.Get("type") != "statuses" {
+		for _, tag := range results.Hashtags {
+			hashtags = append(hashtags, map[string]any{
+				"name":    tag.Name,
+				"url":     fmt.Sprintf("%s/api/v2/search?q=%%23%s", h.config.Server.BaseURL, url.QueryEscape(tag.Name)),
+				"history": []any{},
+				"count":   tag.Count,
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"accounts": []any{},
+		"statuses": statuses,
+		"hashtags": hashtags,
+	})
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/reporting"
+)
+
+// ReportPanics sends panics in HTTP handlers to error reporting, then lets
+// them go on to middleware.Recoverer, which answers 500
+func ReportPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if value := recover(); value != nil {
+				if value != http.ErrAbortHandler {
+					reporting.CapturePanic(value, reporting.Tags{"source": "http", "method": r.Method, "path": r.URL.Path})
+				}
+				panic(value)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// contextKey is the type for request context keys set by this package
+type contextKey string
+
+// apiTokenContextKey stores the authenticated API token in the request context
+const apiTokenContextKey contextKey = "api_token"
+
+// APITokenAuth authenticates requests with a personal API token, read from
+// the Authorization header ("Bearer tp_..."). Tokens are not taken from the
+// query string, where access logs, proxies and Referer headers would keep them.
+func APITokenAuth(tokenService *auth.APITokenService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			plaintext, bearer := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !bearer || strings.TrimSpace(plaintext) == "" {
+				http.Error(w, "Missing API token", http.StatusUnauthorized)
+				return
+			}
+
+			token, err := tokenService.ValidateToken(r.Context(), strings.TrimSpace(plaintext))
+			if err != nil {
+				http.Error(w, "Invalid API token", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), apiTokenContextKey, token)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// APITokenFromContext returns the API token authenticated by APITokenAuth
+func APITokenFromContext(ctx context.Context) (*models.APIToken, bool) {
+	token, ok := ctx.Value(apiTokenContextKey).(*models.APIToken)
+	return token, ok
+}
+
+// MaxAPIBodySize bounds the request bodies of the client and automation APIs
+const MaxAPIBodySize = 1 << 20
+
+// MaxBodySize limits request bodies to limit bytes. Requests that announce a
+// larger Content-Length are refused with 413 Request Entity Too Large before
+// anything is read; other bodies are cut off at the limit, and handlers
+// report the failed read with bodyTooLarge.
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bodyTooLarge reports whether reading a request body failed because it
+// exceeded the limit set by MaxBodySize
+func bodyTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+// writeBodyError reports an unreadable API request body: 413 when it was
+// too large, 400 otherwise
+func writeBodyError(w http.ResponseWriter, err error) {
+	if bodyTooLarge(err) {
+		writeAPIError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+		return
+	}
+	writeAPIError(w, http.StatusBadRequest, "Invalid request body")
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	// echo reads the whole body like the API handlers do
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		w.Write(body)
+	})
+	handler := MaxBodySize(16)(echo)
+
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64 // -1 for an unknown length, as with chunked bodies
+		want          int
+	}{
+		{"under the limit", "hello", 5, http.StatusOK},
+		{"at the limit", strings.Repeat("a", 16), 16, http.StatusOK},
+		{"announced too large", strings.Repeat("a", 17), 17, http.StatusRequestEntityTooLarge},
+		{"chunked under the limit", "hello", -1, http.StatusOK},
+		{"chunked too large", strings.Repeat("a", 64), -1, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/statuses", strings.NewReader(tt.body))
+			req.ContentLength = tt.contentLength
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestWriteBodyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"too large", &http.MaxBytesError{Limit: 16}, http.StatusRequestEntityTooLarge},
+		{"unreadable", io.ErrUnexpectedEOF, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writeBodyError(rec, tt.err)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
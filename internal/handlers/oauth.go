@@ -1,14 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"strings"
 
 	"github.com/fulgidus/terminalpub/internal/auth"
 	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
 	"github.com/fulgidus/terminalpub/internal/services"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
@@ -17,7 +20,7 @@ import (
 // OAuthHandler handles OAuth device flow requests
 type OAuthHandler struct {
 	db                *pgxpool.Pool
-	redis             *redis.Client
+	redis             redis.UniversalClient
 	cfg               *config.Config
 	deviceFlowService *auth.DeviceFlowService
 	tokenService      *auth.TokenService
@@ -25,13 +28,14 @@ type OAuthHandler struct {
 	sessionManager    *auth.SessionManager
 	userService       *services.UserService
 	mastodonService   *auth.MastodonService
+	audit             *auth.AuditLog
 	templates         *template.Template
 }
 
 // NewOAuthHandler creates a new OAuthHandler instance
 func NewOAuthHandler(
 	db *pgxpool.Pool,
-	redis *redis.Client,
+	redis redis.UniversalClient,
 	cfg *config.Config,
 ) *OAuthHandler {
 	// Initialize all services
@@ -57,6 +61,7 @@ func NewOAuthHandler(
 		tokenService:      tokenService,
 		sshKeyService:     sshKeyService,
 		sessionManager:    sessionManager,
+		audit:             auth.NewAuditLog(db),
 		userService:       userService,
 		mastodonService:   mastodonService,
 		templates:         tmpl,
@@ -112,6 +117,7 @@ func (h *OAuthHandler) handleDeviceCode(w http.ResponseWriter, r *http.Request)
 	ctx := r.Context()
 	deviceCode, err := h.deviceFlowService.GetDeviceCodeByUserCode(ctx, userCode)
 	if err != nil {
+		h.audit.Record(auth.WithClientIP(ctx, requestIP(r)), 0, models.AuditDeviceCodeFailed, "code "+userCode)
 		h.showError(w, "Invalid or expired code. Please try again from your SSH session.")
 		return
 	}
@@ -194,14 +200,11 @@ func (h *OAuthHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create or get existing user (with upsert logic)
-	username := fmt.Sprintf("%s@%s", token.Username, strings.TrimPrefix(deviceCode.InstanceURL, "https://"))
-	username = strings.ReplaceAll(username, ".", "_") // Sanitize username
-
-	user, err := h.userService.GetOrCreateUser(ctx, username, "")
+	// Find the account linked to this Mastodon identity, or register a new one
+	user, err := h.userService.GetOrRegisterMastodonUser(ctx, token, deviceCode.InviteCode, h.cfg)
 	if err != nil {
 		log.Printf("Failed to get or create user: %v", err)
-		h.showError(w, "Failed to create user account")
+		h.showError(w, registrationErrorMessage(err))
 		return
 	}
 
@@ -213,7 +216,7 @@ func (h *OAuthHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update user's primary Mastodon account
-	if err := h.userService.UpdatePrimaryMastodonAccount(ctx, user.ID, deviceCode.InstanceURL, token.MastodonID, token.Username); err != nil {
+	if err := h.userService.UpdatePrimaryMastodonAccount(ctx, user.ID, token.InstanceURL, token.MastodonID, token.Username); err != nil {
 		log.Printf("Failed to update primary mastodon account: %v", err)
 	}
 
@@ -227,3 +230,30 @@ func (h *OAuthHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	// Show success message
 	h.showSuccess(w, fmt.Sprintf("Successfully logged in as @%s! You can close this window and return to your SSH session.", token.Username))
 }
+
+// registrationErrorMessage explains why a signup was refused
+func registrationErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, services.ErrRegistrationClosed):
+		return "Registration is closed on this server"
+	case errors.Is(err, services.ErrInviteRequired):
+		return "An invite code is required to register. Enter it in your SSH session before logging in."
+	case errors.Is(err, services.ErrInvalidInvite):
+		return "Invite code is invalid, expired or already used"
+	case errors.Is(err, services.ErrAccountDeleted):
+		return "This account has been deleted"
+	case errors.Is(err, auth.ErrAccountSuspended):
+		return "This account has been suspended"
+	default:
+		return "Failed to create user account"
+	}
+}
+
+// requestIP returns the address a request comes from, without its port
+func requestIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/events"
+	"github.com/fulgidus/terminalpub/internal/services"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// PresenceMiddleware counts interactive SSH sessions as connected for as
+// long as they last. Sessions signing in with a registered key are linked
+// to their user right away; the TUI links the others once they log in.
+// Sessions of a user who gets suspended or deleted are closed.
+func PresenceMiddleware(presence *services.PresenceService, sshKeyService *auth.SSHKeyService) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			sessionID := s.Context().SessionID()
+
+			ctx, cancel := context.WithTimeout(s.Context(), 5*time.Second)
+			userID := 0
+			if s.PublicKey() != nil {
+				publicKey := strings.TrimSpace(string(gossh.MarshalAuthorizedKey(s.PublicKey())))
+				if user, err := sshKeyService.GetUserBySSHKey(ctx, publicKey); err == nil {
+					userID = user.ID
+				}
+			}
+			if err := presence.Join(ctx, sessionID, userID); err != nil {
+				log.Printf("Presence: %v", err)
+			}
+			events.Publish(ctx, events.SessionStarted{SessionID: sessionID, UserID: userID})
+			cancel()
+
+			watchCtx, stopWatching := context.WithCancel(context.Background())
+			defer stopWatching()
+			if ended, err := presence.Ended(watchCtx, sessionID); err != nil {
+				log.Printf("Presence: %v", err)
+			} else {
+				go func() {
+					select {
+					case <-ended:
+						log.Printf("Presence: ending session %s of a suspended or deleted user", sessionID)
+						s.Close()
+					case <-watchCtx.Done():
+					}
+				}()
+			}
+
+			done := make(chan struct{})
+			go func() {
+				ticker := time.NewTicker(services.PresenceHeartbeat)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-done:
+						return
+					case <-ticker.C:
+						if err := presence.Heartbeat(context.Background(), sessionID); err != nil {
+							log.Printf("Presence: %v", err)
+						}
+					}
+				}
+			}()
+
+			next(s)
+
+			close(done)
+			if err := presence.Leave(context.Background(), sessionID); err != nil {
+				log.Printf("Presence: %v", err)
+			}
+		}
+	}
+}
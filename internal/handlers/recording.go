@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/fulgidus/terminalpub/internal/asciicast"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// RecordingMiddleware puts a stopped asciicast.Recorder in front of every
+// interactive session and stores it in the session's context, where the
+// TUI finds it when the user asks to record. Nothing is captured before.
+func RecordingMiddleware(maxSize int) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			pty, _, ok := s.Pty()
+			if !ok {
+				next(s)
+				return
+			}
+			recorder := asciicast.NewRecorder(pty.Term, pty.Window.Width, pty.Window.Height, maxSize)
+			s.Context().SetValue(asciicast.ContextKey, recorder)
+			next(recordedSession{Session: s, recorder: recorder})
+		}
+	}
+}
+
+// recordedSession copies the output of a session to its recorder
+type recordedSession struct {
+	ssh.Session
+	recorder *asciicast.Recorder
+}
+
+func (s recordedSession) Write(p []byte) (int, error) {
+	n, err := s.Session.Write(p)
+	s.recorder.Write(p[:n])
+	return n, err
+}
+
+// RecordingHandler serves the casts users recorded of their sessions
+type RecordingHandler struct {
+	recordingService *services.RecordingService
+}
+
+// NewRecordingHandler creates a new recording handler
+func NewRecordingHandler(db *pgxpool.Pool, redisClient redis.UniversalClient, cfg *config.Config) *RecordingHandler {
+	return &RecordingHandler{recordingService: services.NewRecordingService(db, redisClient, cfg)}
+}
+
+// Download serves a recording for a one-time link (/recordings/{token})
+func (h *RecordingHandler) Download(w http.ResponseWriter, r *http.Request) {
+	userID, id, err := h.recordingService.ConsumeDownloadToken(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		http.Error(w, "Invalid or expired download link", http.StatusNotFound)
+		return
+	}
+
+	h.writeCast(w, r, userID, id)
+}
+
+// List handles GET /api/terminalpub/v1/recordings, newest first
+func (h *RecordingHandler) List(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "read")
+	if !ok {
+		return
+	}
+
+	recordings, err := h.recordingService.List(r.Context(), token.UserID)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "Failed to list recordings")
+		return
+	}
+	if recordings == nil {
+		recordings = []models.SessionRecording{}
+	}
+	writeJSON(w, http.StatusOK, recordings)
+}
+
+// Get handles GET /api/terminalpub/v1/recordings/{id}, serving the cast
+func (h *RecordingHandler) Get(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "read")
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "Record not found")
+		return
+	}
+	h.writeCast(w, r, token.UserID, id)
+}
+
+// writeCast sends one of the user's recordings as a file download
+func (h *RecordingHandler) writeCast(w http.ResponseWriter, r *http.Request, userID int, id int64) {
+	recording, cast, err := h.recordingService.Cast(r.Context(), userID, id)
+	if errors.Is(err, services.ErrRecordingNotFound) {
+		http.Error(w, "Recording not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load recording", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", asciicast.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, services.RecordingFilename(recording)))
+	w.Header().Set("Content-Length", strconv.Itoa(len(cast)))
+	w.Write(cast)
+}
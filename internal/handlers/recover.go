@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/fulgidus/terminalpub/internal/reporting"
+)
+
+// sessionPanic is what is logged about a panic in an SSH session, as one
+// JSON line
+type sessionPanic struct {
+	Session string   `json:"session"`
+	User    string   `json:"user"`
+	Remote  string   `json:"remote"`
+	Command []string `json:"command,omitempty"`
+	Panic   string   `json:"panic"`
+	Stack   string   `json:"stack"`
+}
+
+// RecoverMiddleware keeps a panic while serving one SSH session from taking
+// the whole server down: it is logged with its stack, and the client is
+// told and disconnected. The TUI recovers from its own panics without
+// disconnecting; see ui.Recover.
+func RecoverMiddleware() wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			defer func() {
+				value := recover()
+				if value == nil {
+					return
+				}
+				report := sessionPanic{
+					Session: s.Context().SessionID(),
+					User:    s.User(),
+					Remote:  s.RemoteAddr().String(),
+					Command: s.Command(),
+					Panic:   fmt.Sprint(value),
+					Stack:   string(debug.Stack()),
+				}
+				tags := reporting.Tags{"source": "ssh"}
+				if len(report.Command) > 0 {
+					tags["command"] = report.Command[0]
+				}
+				reporting.CapturePanic(value, tags)
+				if line, err := json.Marshal(report); err == nil {
+					log.Printf("SSH session panic: %s", line)
+				} else {
+					log.Printf("SSH session panic: %v\n%s", value, report.Stack)
+				}
+				wish.Fatalln(s, "Sorry, terminalpub ran into an error. Please try again.")
+			}()
+			next(s)
+		}
+	}
+}
@@ -0,0 +1,617 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/fulgidus/terminalpub/internal/version"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// maxImportSize caps the size of files uploaded through SSH commands
+const maxImportSize = 5 << 20
+
+// SSHCommandFunc runs a non-interactive command for an authenticated user
+type SSHCommandFunc func(ctx context.Context, s ssh.Session, user *models.User, args []string) error
+
+// SSHCommandHandler dispatches commands passed on the ssh command line
+// (e.g. "ssh terminalpub.example status") without starting the TUI
+type SSHCommandHandler struct {
+	sshKeyService   *auth.SSHKeyService
+	mastodonService *services.MastodonService
+	exportService   *services.ExportService
+	inviteService   *services.InviteService
+	motd            *services.MOTDService
+	guestbook       *services.GuestbookService
+	interactions    *services.InteractionService
+	migration       *services.MigrationService
+	posts           *services.PostService
+	gpg             *services.GPGService
+	audit           *services.AuditService
+	moderation      *services.ModerationService
+	oauth           *auth.OAuthServer
+	commands        map[string]SSHCommandFunc
+}
+
+// NewSSHCommandHandler creates a new SSH command handler with the built-in commands
+func NewSSHCommandHandler(db *pgxpool.Pool, redisClient redis.UniversalClient, cfg *config.Config) *SSHCommandHandler {
+	h := &SSHCommandHandler{
+		sshKeyService:   auth.NewSSHKeyService(db),
+		mastodonService: services.NewMastodonService(db, cfg),
+		exportService:   services.NewExportService(db, redisClient, cfg.Server.BaseURL),
+		inviteService:   services.NewInviteService(db, cfg),
+		motd:            services.NewMOTDService(db, cfg),
+		guestbook:       services.NewGuestbookService(db, redisClient, cfg),
+		interactions:    services.NewInteractionService(db, cfg),
+		migration:       services.NewMigrationService(db, cfg),
+		posts:           services.NewPostService(db, cfg),
+		gpg:             services.NewGPGService(db),
+		audit:           services.NewAuditService(db),
+		moderation:      services.NewModerationService(db, redisClient, cfg),
+		oauth:           auth.NewOAuthServer(db),
+		commands:        make(map[string]SSHCommandFunc),
+	}
+
+	h.Register("status", h.status)
+	h.Register("import-follows", h.importFollows)
+	h.Register("export", h.export)
+	h.Register("invite", h.invite)
+	h.Register("motd", h.motdCommand)
+	h.Register("guestbook", h.guestbookCommand)
+	h.Register("follow", h.interact("follow <user@domain|actor-url>", h.follow))
+	h.Register("unfollow", h.interact("unfollow <user@domain|actor-url>", h.interactions.Unfollow))
+	h.Register("like", h.interact("like <post-url>", h.interactions.Like))
+	h.Register("unlike", h.interact("unlike <post-url>", h.interactions.Unlike))
+	h.Register("boost", h.interact("boost <post-url>", h.interactions.Boost))
+	h.Register("unboost", h.interact("unboost <post-url>", h.interactions.Unboost))
+	h.Register("alias", h.alias)
+	h.Register("move", h.move)
+	h.Register("login-code", h.loginCode)
+	h.Register("post", h.post)
+	h.Register("gpg-key", h.gpgKey)
+	h.Register("audit", h.auditCommand)
+	h.Register("mod", h.modCommand)
+
+	return h
+}
+
+// Register adds a command to the handler
+func (h *SSHCommandHandler) Register(name string, fn SSHCommandFunc) {
+	h.commands[name] = fn
+}
+
+// Middleware returns a wish middleware that handles known commands and passes
+// interactive sessions through to the next handler
+func (h *SSHCommandHandler) Middleware() wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			args := s.Command()
+			if len(args) == 0 {
+				next(s)
+				return
+			}
+
+			if args[0] == "version" {
+				// Needs no account, to check a server before signing up
+				wish.Println(s, version.String())
+				return
+			}
+
+			fn, ok := h.commands[args[0]]
+			if !ok {
+				wish.Fatalf(s, "unknown command: %s\n", args[0])
+				return
+			}
+
+			if s.PublicKey() == nil {
+				wish.Fatalln(s, "public key authentication required")
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(s.Context(), 15*time.Second)
+			defer cancel()
+
+			publicKey := strings.TrimSpace(string(gossh.MarshalAuthorizedKey(s.PublicKey())))
+			user, err := h.sshKeyService.GetUserBySSHKey(ctx, publicKey)
+			if errors.Is(err, auth.ErrAccountSuspended) {
+				wish.Fatalln(s, err.Error())
+				return
+			}
+			if err != nil {
+				wish.Fatalln(s, "no account linked to this SSH key; connect interactively to log in first")
+				return
+			}
+
+			if err := fn(ctx, s, user, args[1:]); err != nil {
+				wish.Fatalln(s, err.Error())
+				return
+			}
+		}
+	}
+}
+
+// status prints the one-line unread summary; "status --json" and "status --kv" change the format
+func (h *SSHCommandHandler) status(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+	summary, err := h.mastodonService.GetStatusSummary(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch status: %w", err)
+	}
+
+	format := ""
+	if len(args) > 0 {
+		format = strings.TrimPrefix(args[0], "--")
+	}
+
+	if format == "json" {
+		data, err := json.Marshal(summary)
+		if err != nil {
+			return fmt.Errorf("failed to encode status: %w", err)
+		}
+		wish.Println(s, string(data))
+		return nil
+	}
+
+	wish.Println(s, services.FormatStatusSummary(summary, format))
+	return nil
+}
+
+// importFollows follows every account in a Mastodon "following" CSV read from stdin:
+// ssh <host> import-follows < following_accounts.csv
+func (h *SSHCommandHandler) importFollows(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+	accts, err := services.ParseFollowingCSV(io.LimitReader(s, maxImportSize))
+	if err != nil {
+		return err
+	}
+
+	// Imports are throttled and can take a while; only stop when the client disconnects
+	importCtx := s.Context()
+
+	wish.Printf(s, "Importing %d accounts...\n", len(accts))
+	failures := h.mastodonService.ImportFollows(importCtx, user.ID, accts, func(done, total int, result services.FollowImportResult) {
+		if result.Err != nil {
+			wish.Printf(s, "[%d/%d] %s: failed: %v\n", done, total, result.Acct, result.Err)
+			return
+		}
+		wish.Printf(s, "[%d/%d] %s: followed\n", done, total, result.Acct)
+	})
+
+	wish.Printf(s, "Done: %d followed, %d failed\n", len(accts)-len(failures), len(failures))
+	return nil
+}
+
+// export writes the user's data archive to stdout:
+// ssh <host> export > terminalpub-export.tar.gz
+func (h *SSHCommandHandler) export(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+	if _, _, isPty := s.Pty(); isPty {
+		return fmt.Errorf("refusing to write an archive to a terminal; redirect the output: ssh <host> export > export.tar.gz")
+	}
+
+	// Large archives can take longer than the default command timeout
+	return h.exportService.WriteArchive(s.Context(), user.ID, s)
+}
+
+// auditCommand writes the user's audit log as JSON; admins get every
+// user's events with "audit --all"
+func (h *SSHCommandHandler) auditCommand(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+	all := false
+	switch {
+	case len(args) == 0:
+	case len(args) == 1 && args[0] == "--all":
+		all = true
+	default:
+		return fmt.Errorf("usage: audit [--all] > audit.json")
+	}
+	return h.audit.WriteJSON(ctx, user.ID, all, s)
+}
+
+// post publishes a native post read from stdin, signed if it is a
+// clear-signed message: gpg --clearsign -o - note.txt | ssh <host> post [visibility]
+func (h *SSHCommandHandler) post(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+	if _, _, isPty := s.Pty(); isPty {
+		return fmt.Errorf("usage: echo hello | ssh <host> post [public|unlisted|private|direct]")
+	}
+	visibility := ""
+	if len(args) > 0 {
+		visibility = args[0]
+	}
+	content, err := io.ReadAll(io.LimitReader(s, maxImportSize))
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+
+	post, err := h.posts.Create(ctx, user.ID, string(content), visibility)
+	if err != nil {
+		return err
+	}
+	if post.GPGSignature != "" {
+		wish.Println(s, "Posted, signed:", post.APID)
+		return nil
+	}
+	wish.Println(s, "Posted:", post.APID)
+	return nil
+}
+
+// gpgKey registers the GPG public key signed posts are checked against:
+// gpg --export --armor KEYID | ssh <host> gpg-key, gpg-key show, gpg-key remove
+func (h *SSHCommandHandler) gpgKey(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+	const usage = "usage: gpg --export --armor KEYID | ssh <host> gpg-key | gpg-key show | gpg-key remove"
+	_, _, isPty := s.Pty()
+	switch {
+	case len(args) > 0 && args[0] == "show", len(args) == 0 && isPty:
+		fingerprint, err := h.gpg.Fingerprint(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if fingerprint == "" {
+			wish.Println(s, "No GPG key registered")
+			wish.Println(s, usage)
+			return nil
+		}
+		wish.Println(s, services.FormatGPGFingerprint(fingerprint))
+		return nil
+	case len(args) > 0 && args[0] == "remove":
+		if err := h.gpg.RemoveKey(ctx, user.ID); err != nil {
+			return err
+		}
+		wish.Println(s, "GPG key removed")
+		return nil
+	case len(args) > 0:
+		return fmt.Errorf(usage)
+	}
+
+	key, err := io.ReadAll(io.LimitReader(s, maxImportSize))
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	fingerprint, err := h.gpg.SetKey(ctx, user.ID, string(key))
+	if err != nil {
+		return err
+	}
+	wish.Println(s, "GPG key registered:", services.FormatGPGFingerprint(fingerprint))
+	return nil
+}
+
+// invite creates registration invite codes or lists the caller's invites:
+// ssh <host> invite [uses] [days], ssh <host> invite list
+func (h *SSHCommandHandler) invite(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+	if len(args) > 0 && args[0] == "list" {
+		invites, err := h.inviteService.ListInvites(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if len(invites) == 0 {
+			wish.Println(s, "No invites yet")
+			return nil
+		}
+		for _, invite := range invites {
+			expires := "never expires"
+			if invite.ExpiresAt != nil {
+				expires = "expires " + invite.ExpiresAt.Format("2006-01-02")
+			}
+			wish.Printf(s, "%s  %d/%d used  %s\n", invite.Code, invite.Uses, invite.MaxUses, expires)
+		}
+		return nil
+	}
+
+	maxUses, days := 1, 7
+	if len(args) > 0 {
+		if _, err := fmt.Sscanf(args[0], "%d", &maxUses); err != nil || maxUses < 1 {
+			return fmt.Errorf("usage: invite [uses] [days] | invite list")
+		}
+	}
+	if len(args) > 1 {
+		if _, err := fmt.Sscanf(args[1], "%d", &days); err != nil || days < 0 {
+			return fmt.Errorf("usage: invite [uses] [days] | invite list")
+		}
+	}
+
+	invite, err := h.inviteService.CreateInvite(ctx, user.ID, maxUses, time.Duration(days)*24*time.Hour)
+	if errors.Is(err, services.ErrInviteQuotaExceeded) {
+		return fmt.Errorf("you cannot create more invites")
+	}
+	if err != nil {
+		return err
+	}
+
+	wish.Println(s, invite.Code)
+	return nil
+}
+
+// motdCommand lets admins post, list and remove messages of the day:
+// ssh <host> motd add [--days N] <message>, motd list, motd remove <id>
+func (h *SSHCommandHandler) motdCommand(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+	const usage = "usage: motd list | motd add [--days N] <message> | motd remove <id>"
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+
+	var err error
+	switch args[0] {
+	case "list":
+		var messages []models.MOTD
+		messages, err = h.motd.List(ctx, user.ID)
+		if err != nil {
+			break
+		}
+		if len(messages) == 0 {
+			wish.Println(s, "No messages of the day")
+			return nil
+		}
+		for _, motd := range messages {
+			expires := "never expires"
+			if motd.ExpiresAt != nil {
+				expires = "expires " + motd.ExpiresAt.Format("2006-01-02")
+			}
+			wish.Printf(s, "%d  %s  %s\n", motd.ID, expires, motd.Message)
+		}
+		return nil
+
+	case "add":
+		args = args[1:]
+		days := 0
+		if len(args) > 1 && args[0] == "--days" {
+			if _, err := fmt.Sscanf(args[1], "%d", &days); err != nil || days < 0 {
+				return fmt.Errorf(usage)
+			}
+			args = args[2:]
+		}
+		if len(args) == 0 {
+			return fmt.Errorf(usage)
+		}
+		var motd *models.MOTD
+		motd, err = h.motd.Create(ctx, user.ID, strings.Join(args, " "), time.Duration(days)*24*time.Hour)
+		if err == nil {
+			wish.Printf(s, "Posted message %d\n", motd.ID)
+			return nil
+		}
+
+	case "remove":
+		var id int
+		if len(args) != 2 {
+			return fmt.Errorf(usage)
+		}
+		if _, err := fmt.Sscanf(args[1], "%d", &id); err != nil {
+			return fmt.Errorf(usage)
+		}
+		if err = h.motd.Remove(ctx, user.ID, id); err == nil {
+			wish.Println(s, "OK")
+			return nil
+		}
+
+	default:
+		return fmt.Errorf(usage)
+	}
+
+	if errors.Is(err, services.ErrNotAdmin) {
+		return fmt.Errorf("only admins can manage messages of the day")
+	}
+	return err
+}
+
+// guestbookCommand lets admins moderate the guestbook:
+// ssh <host> guestbook list, guestbook remove <id>
+func (h *SSHCommandHandler) guestbookCommand(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+	const usage = "usage: guestbook list | guestbook remove <id>"
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+
+	switch args[0] {
+	case "list":
+		entries, err := h.guestbook.Recent(ctx, 100)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			wish.Println(s, "The guestbook is empty")
+			return nil
+		}
+		for _, entry := range entries {
+			wish.Printf(s, "%d  %s  %s: %s\n", entry.ID, entry.CreatedAt.Format("2006-01-02 15:04"), entry.Name, entry.Message)
+		}
+		return nil
+
+	case "remove":
+		var id int
+		if len(args) != 2 {
+			return fmt.Errorf(usage)
+		}
+		if _, err := fmt.Sscanf(args[1], "%d", &id); err != nil {
+			return fmt.Errorf(usage)
+		}
+		err := h.guestbook.Remove(ctx, user.ID, id)
+		if errors.Is(err, services.ErrNotAdmin) {
+			return fmt.Errorf("only admins can remove guestbook entries")
+		}
+		if err != nil {
+			return err
+		}
+		wish.Println(s, "OK")
+		return nil
+	}
+	return fmt.Errorf(usage)
+}
+
+// modCommand runs the moderation tools, which only admins can use
+func (h *SSHCommandHandler) modCommand(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+	const usage = "usage: mod suspend|unsuspend|silence|unsilence <username> | mod remove <post-id> | " +
+		"mod reject <user@domain|actor-url> [reason] | mod unreject <user@domain|actor-url> | mod rejected"
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+
+	var err error
+	switch args[0] {
+	case "suspend", "unsuspend", "silence", "unsilence":
+		if len(args) != 2 {
+			return fmt.Errorf(usage)
+		}
+		action := map[string]func(context.Context, int, string) error{
+			"suspend":   h.moderation.Suspend,
+			"unsuspend": h.moderation.Unsuspend,
+			"silence":   h.moderation.Silence,
+			"unsilence": h.moderation.Unsilence,
+		}[args[0]]
+		err = action(ctx, user.ID, args[1])
+
+	case "remove":
+		var id int
+		if len(args) != 2 {
+			return fmt.Errorf(usage)
+		}
+		// A post's URL ends with its ID
+		if _, err := fmt.Sscanf(args[1][strings.LastIndex(args[1], "/")+1:], "%d", &id); err != nil {
+			return fmt.Errorf(usage)
+		}
+		err = h.moderation.RemovePost(ctx, user.ID, id)
+
+	case "reject":
+		if len(args) < 2 {
+			return fmt.Errorf(usage)
+		}
+		var actorID string
+		actorID, err = h.moderation.RejectActor(ctx, user.ID, args[1], strings.Join(args[2:], " "))
+		if err == nil {
+			wish.Println(s, "Rejected", actorID)
+			return nil
+		}
+
+	case "unreject":
+		if len(args) != 2 {
+			return fmt.Errorf(usage)
+		}
+		err = h.moderation.UnrejectActor(ctx, user.ID, args[1])
+
+	case "rejected":
+		var actors []models.RejectedActor
+		actors, err = h.moderation.RejectedActors(ctx, user.ID)
+		if err != nil {
+			break
+		}
+		if len(actors) == 0 {
+			wish.Println(s, "No rejected actors")
+			return nil
+		}
+		for _, actor := range actors {
+			wish.Printf(s, "%s  %s  %s\n", actor.CreatedAt.Format("2006-01-02"), actor.ActorID, actor.Reason)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf(usage)
+	}
+
+	if errors.Is(err, services.ErrNotAdmin) {
+		return fmt.Errorf("only admins can moderate")
+	}
+	if err != nil {
+		return err
+	}
+	wish.Println(s, "OK")
+	return nil
+}
+
+// loginCode prints a one-time code for authorizing a Mastodon app at /oauth/authorize
+func (h *SSHCommandHandler) loginCode(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+	code, err := h.oauth.CreateLoginCode(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	wish.Println(s, code)
+	wish.Println(s, "Enter this code on the app's authorization page within 10 minutes")
+	return nil
+}
+
+// interact wraps a native ActivityPub interaction taking a single target argument
+func (h *SSHCommandHandler) interact(usage string, fn func(ctx context.Context, userID int, target string) error) SSHCommandFunc {
+	return func(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s", usage)
+		}
+		if err := fn(ctx, user.ID, args[0]); err != nil {
+			if errors.Is(err, services.ErrNothingToUndo) {
+				return fmt.Errorf("nothing to undo for %s", args[0])
+			}
+			return err
+		}
+		wish.Println(s, "OK")
+		return nil
+	}
+}
+
+// follow sends a native Follow, discarding the resolved actor ID
+func (h *SSHCommandHandler) follow(ctx context.Context, userID int, target string) error {
+	_, err := h.interactions.Follow(ctx, userID, target)
+	return err
+}
+
+// alias manages the accounts listed in the user's alsoKnownAs
+func (h *SSHCommandHandler) alias(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+	const usage = "usage: alias list | alias add <user@domain> | alias remove <user@domain>"
+
+	if len(args) == 0 || args[0] == "list" {
+		aliases, err := h.migration.ListAliases(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if len(aliases) == 0 {
+			wish.Println(s, "No aliases")
+			return nil
+		}
+		for _, alias := range aliases {
+			wish.Println(s, alias)
+		}
+		return nil
+	}
+
+	if len(args) != 2 {
+		return fmt.Errorf(usage)
+	}
+	switch args[0] {
+	case "add":
+		actorID, err := h.migration.AddAlias(ctx, user.ID, args[1])
+		if err != nil {
+			return err
+		}
+		wish.Printf(s, "Added %s; that account can now move to this one\n", actorID)
+	case "remove":
+		if err := h.migration.RemoveAlias(ctx, user.ID, args[1]); err != nil {
+			return err
+		}
+		wish.Println(s, "Removed")
+	default:
+		return fmt.Errorf(usage)
+	}
+	return nil
+}
+
+// move migrates the user's followers to another account
+func (h *SSHCommandHandler) move(ctx context.Context, s ssh.Session, user *models.User, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: move <user@domain>")
+	}
+
+	targetID, err := h.migration.MoveTo(ctx, user.ID, args[0])
+	if errors.Is(err, services.ErrMoveNotVerified) {
+		return fmt.Errorf("add this account as an alias on %s first", args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	wish.Printf(s, "Moved to %s; followers are being redirected\n", targetID)
+	return nil
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StatusHandler serves a compact unread summary for status bars (tmux, screen, polybar)
+type StatusHandler struct {
+	mastodonService *services.MastodonService
+}
+
+// NewStatusHandler creates a new status summary handler
+func NewStatusHandler(db *pgxpool.Pool, cfg *config.Config) *StatusHandler {
+	return &StatusHandler{
+		mastodonService: services.NewMastodonService(db, cfg),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+// Responds with a single plain-text line by default; ?format=json returns JSON
+// and ?format=kv returns key=value pairs for easy parsing in shell scripts.
+// The token must grant the read scope.
+func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := requireScope(w, r, "read")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	summary, err := h.mastodonService.GetStatusSummary(ctx, token.UserID)
+	if err != nil {
+		http.Error(w, "Failed to fetch status", http.StatusBadGateway)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summary)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, services.FormatStatusSummary(summary, format))
+}
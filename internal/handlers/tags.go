@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	"html/template"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// tagPageSize is how many posts a hashtag page and collection show
+const tagPageSize = 20
+
+// htmlTag matches an HTML tag in post content
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// plainText turns the HTML content of a post back into text, keeping its
+// line and paragraph breaks
+func plainText(content string) string {
+	content = strings.ReplaceAll(content, "</p><p>", "\n\n")
+	content = strings.ReplaceAll(content, "<br>", "\n")
+	return stripControl(html.UnescapeString(htmlTag.ReplaceAllString(content, "")))
+}
+
+// stripControl removes control characters other than line breaks from text
+// served to terminals, so that posts cannot carry their own escape
+// sequences. Tabs become spaces.
+func stripControl(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n':
+			return r
+		case r == '\t':
+			return ' '
+		case unicode.IsControl(r):
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// TagHandler serves local hashtag pages at /tags/{tag}, as an ActivityPub
+// collection or as HTML depending on the Accept header
+type TagHandler struct {
+	config    *config.Config
+	timelines *services.TimelineService
+	templates *template.Template
+}
+
+// NewTagHandler creates a new hashtag handler
+func NewTagHandler(db *pgxpool.Pool, cfg *config.Config) *TagHandler {
+	tmpl, err := template.ParseGlob("web/templates/*.html")
+	if err != nil {
+		log.Printf("Warning: Failed to load templates: %v", err)
+		tmpl = template.New("fallback")
+	}
+
+	return &TagHandler{
+		config:    cfg,
+		timelines: services.NewTimelineService(db, cfg),
+		templates: tmpl,
+	}
+}
+
+// wantsActivityJSON reports whether a request asks for ActivityPub JSON
+func wantsActivityJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/activity+json") || strings.Contains(accept, "application/ld+json")
+}
+
+// Page handles GET /tags/{tag}
+func (h *TagHandler) Page(w http.ResponseWriter, r *http.Request) {
+	tag := strings.ToLower(chi.URLParam(r, "tag"))
+	if tag == "" {
+		http.Error(w, "Tag not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	count, err := h.timelines.TagCount(ctx, tag)
+	if err != nil {
+		http.Error(w, "Failed to load tag", http.StatusInternalServerError)
+		return
+	}
+	statuses, err := h.timelines.Tag(ctx, tag, tagPageSize, "")
+	if err != nil {
+		http.Error(w, "Failed to load tag", http.StatusInternalServerError)
+		return
+	}
+
+	if wantsActivityJSON(r) {
+		items := []any{}
+		for _, status := range statuses {
+			items = append(items, status.URL)
+		}
+		collection := models.OrderedCollection{
+			Context:      "https://www.w3.org/ns/activitystreams",
+			ID:           activitypub.TagURL(h.config.Server.BaseURL, tag),
+			Type:         "OrderedCollection",
+			TotalItems:   count,
+			OrderedItems: items,
+		}
+		w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
+		json.NewEncoder(w).Encode(collection)
+		return
+	}
+
+	type post struct {
+		Name, Acct, Content, URL string
+		Published                time.Time
+	}
+	var posts []post
+	for _, status := range statuses {
+		name := status.Account.DisplayName
+		if name == "" {
+			name = status.Account.Username
+		}
+		posts = append(posts, post{name, status.Account.Acct, plainText(status.Content), status.URL, status.CreatedAt})
+	}
+
+	data := map[string]any{
+		"Tag":    tag,
+		"Count":  count,
+		"Posts":  posts,
+		"Domain": h.config.Server.Domain,
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := h.templates.ExecuteTemplate(w, "tag.html", data); err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxTimelineLimit matches the page size cap of Mastodon's timeline API
+const maxTimelineLimit = 40
+
+// TimelineHandler serves the server's timelines in Mastodon API format
+type TimelineHandler struct {
+	config    *config.Config
+	timelines *services.TimelineService
+}
+
+// NewTimelineHandler creates a new timeline handler
+func NewTimelineHandler(db *pgxpool.Pool, cfg *config.Config) *TimelineHandler {
+	return &TimelineHandler{
+		config:    cfg,
+		timelines: services.NewTimelineService(db, cfg),
+	}
+}
+
+// Public handles /api/v1/timelines/public. Like Mastodon it takes local,
+// limit and max_id, and links the next page in the Link header.
+func (h *TimelineHandler) Public(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := 20
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxTimelineLimit)
+	}
+	local := query.Get("local") == "true" || query.Get("local") == "1"
+	maxID := query.Get("max_id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var statuses []services.MastodonStatus
+	var err error
+	if local {
+		statuses, err = h.timelines.Local(ctx, limit, maxID)
+	} else {
+		statuses, err = h.timelines.Federated(ctx, limit, maxID)
+	}
+	if err != nil {
+		http.Error(w, "Failed to load timeline", http.StatusInternalServerError)
+		return
+	}
+	if statuses == nil {
+		statuses = []services.MastodonStatus{}
+	}
+
+	if len(statuses) == limit {
+		next := fmt.Sprintf("%s/api/v1/timelines/public?limit=%d&max_id=%s", h.config.Server.BaseURL, limit, statuses[len(statuses)-1].ID)
+		if local {
+			next += "&local=true"
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// Tag handles /api/v1/timelines/tag/{hashtag}: public local posts using the hashtag
+func (h *TimelineHandler) Tag(w http.ResponseWriter, r *http.Request) {
+	limit, ok := pageLimit(w, r)
+	if !ok {
+		return
+	}
+	tag := chi.URLParam(r, "hashtag")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	statuses, err := h.timelines.Tag(ctx, tag, limit, r.URL.Query().Get("max_id"))
+	if err != nil {
+		http.Error(w, "Failed to load timeline", http.StatusInternalServerError)
+		return
+	}
+	if statuses == nil {
+		statuses = []services.MastodonStatus{}
+	}
+
+	if len(statuses) == limit {
+		next := fmt.Sprintf("%s/api/v1/timelines/tag/%s?limit=%d&max_id=%s", h.config.Server.BaseURL, url.PathEscape(tag), limit, statuses[len(statuses)-1].ID)
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(statuses)
+}
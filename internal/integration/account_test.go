@@ -0,0 +1,59 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TestDeleteAccount checks that deleting an account ends its connected
+// sessions and that purging it keeps its username from being taken again
+func TestDeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	pool := database.Postgres
+	userID := newUser(t, "deleted")
+	users := services.NewUserService(pool)
+	accounts := services.NewAccountService(pool, database.Redis, newConfig(t, newInstance(t)))
+
+	var username string
+	err := pool.QueryRow(ctx, "UPDATE users SET username_confirmed = TRUE WHERE id = $1 RETURNING username", userID).Scan(&username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	presence := services.NewPresenceService(pool, database.Redis)
+	sessionID := "deleted-session-" + username
+	if err := presence.Join(ctx, sessionID, userID); err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	t.Cleanup(func() { presence.Leave(context.Background(), sessionID) })
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ended, err := presence.Ended(watchCtx, sessionID)
+	if err != nil {
+		t.Fatalf("Ended: %v", err)
+	}
+
+	if err := accounts.DeleteAccount(ctx, userID); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	select {
+	case <-ended:
+	case <-time.After(5 * time.Second):
+		t.Error("session of a deleted account was not ended")
+	}
+
+	if _, err := accounts.PurgeDeletedAccounts(ctx, 0); err != nil {
+		t.Fatalf("PurgeDeletedAccounts: %v", err)
+	}
+	available, err := users.IsUsernameAvailable(ctx, username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if available {
+		t.Errorf("username %s of a purged account is available again", username)
+	}
+}
@@ -0,0 +1,108 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TestBackupRestore backs the instance up, loses a post and a linked
+// Mastodon token, and restores them section by section
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	instance := newInstance(t)
+	cfg := newConfig(t, instance)
+	cfg.Security.SecretKey = "backup test secret"
+	token := instance.AddAccount("keeper")
+	userID := linkAccount(t, instance, token)
+
+	var postID int
+	err := database.Postgres.QueryRow(ctx, `INSERT INTO posts (user_id, content) VALUES ($1, 'kept safe') RETURNING id`, userID).Scan(&postID)
+	if err != nil {
+		t.Fatalf("Failed to add post: %v", err)
+	}
+	hostKey := filepath.Join(t.TempDir(), "term_ed25519")
+	if err := os.WriteFile(hostKey, []byte("host key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	backups := services.NewBackupService(database.Postgres, cfg)
+	var archive bytes.Buffer
+	manifest, err := backups.Backup(ctx, &archive, []string{hostKey})
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if manifest.Tables["posts"] == 0 || manifest.Tables["mastodon_tokens"] == 0 {
+		t.Fatalf("manifest tables = %v, want posts and tokens", manifest.Tables)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("archive is not gzipped: %v", err)
+	}
+	contents, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(contents, []byte(token)) {
+		t.Error("archive holds the access token in the clear")
+	}
+
+	if _, err := database.Postgres.Exec(ctx, `DELETE FROM posts WHERE id = $1`, postID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Postgres.Exec(ctx, `DELETE FROM mastodon_tokens WHERE user_id = $1`, userID); err != nil {
+		t.Fatal(err)
+	}
+	count := func(query string, args ...any) int {
+		t.Helper()
+		var n int
+		if err := database.Postgres.QueryRow(ctx, query, args...).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	posts := func() int { return count(`SELECT COUNT(*) FROM posts WHERE user_id = $1`, userID) }
+	tokens := func() int {
+		return count(`SELECT COUNT(*) FROM mastodon_tokens WHERE user_id = $1 AND access_token = $2`, userID, token)
+	}
+
+	result, err := backups.Restore(ctx, bytes.NewReader(archive.Bytes()), services.RestoreOptions{Sections: []string{"posts"}, DryRun: true})
+	if err != nil {
+		t.Fatalf("Restore dry run: %v", err)
+	}
+	if len(result.Tables) == 0 || result.Tables[0].Name != "posts" || result.Tables[0].Restored != 1 {
+		t.Errorf("dry run tables = %+v, want one post restored", result.Tables)
+	}
+	if posts() != 0 {
+		t.Error("dry run restored the post")
+	}
+
+	if _, err := backups.Restore(ctx, bytes.NewReader(archive.Bytes()), services.RestoreOptions{Sections: []string{"posts"}}); err != nil {
+		t.Fatalf("Restore posts: %v", err)
+	}
+	if posts() != 1 {
+		t.Error("post was not restored")
+	}
+	if tokens() != 0 {
+		t.Error("restoring posts restored tokens too")
+	}
+
+	result, err = backups.Restore(ctx, bytes.NewReader(archive.Bytes()), services.RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if tokens() != 1 {
+		t.Error("token was not restored")
+	}
+	if got := string(result.HostKeys["term_ed25519"]); got != "host key" {
+		t.Errorf("host key = %q, want %q", got, "host key")
+	}
+}
@@ -0,0 +1,101 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/mastodontest"
+	"github.com/fulgidus/terminalpub/internal/outbound"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TestFederationDelivery publishes a native post and checks that it reaches
+// a remote follower's inbox, signed with the author's key
+func TestFederationDelivery(t *testing.T) {
+	ctx := context.Background()
+	instance := newInstance(t)
+	cfg := newConfig(t, instance)
+	userID := newUser(t, "author")
+
+	_, err := database.Postgres.Exec(ctx, `
+		INSERT INTO followers (user_id, follower_actor_id, follower_inbox, follower_shared_inbox, accepted)
+		VALUES ($1, $2, $3, $4, true)
+	`, userID, instance.URL+"/users/bob", instance.URL+"/users/bob/inbox", instance.InboxURL())
+	if err != nil {
+		t.Fatalf("Failed to add follower: %v", err)
+	}
+
+	post, err := services.NewPostService(database.Postgres, cfg).Create(ctx, userID, "hello fediverse #terminalpub", "public")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	var delivered *mastodontest.Delivery
+	eventually(t, 10*time.Second, func() bool {
+		for _, d := range instance.Deliveries() {
+			if object, _ := d.Activity["object"].(map[string]any); d.Activity["type"] == "Create" && object["id"] == post.APID {
+				delivered = &d
+				return true
+			}
+		}
+		return false
+	})
+	if delivered == nil {
+		t.Fatalf("expected the Create to reach the shared inbox, got %+v", instance.Deliveries())
+	}
+	if delivered.Path != "/inbox" {
+		t.Errorf("expected delivery to the shared inbox, got %s", delivered.Path)
+	}
+	object := delivered.Activity["object"].(map[string]any)
+	if object["content"] == "" || object["attributedTo"] != delivered.Activity["actor"] {
+		t.Errorf("expected the note attributed to the actor, got %+v", object)
+	}
+
+	// The signature verifies against the author's public key
+	var publicKey string
+	if err := database.Postgres.QueryRow(ctx, "SELECT public_key FROM users WHERE id = $1", userID).Scan(&publicKey); err != nil {
+		t.Fatal(err)
+	}
+	req := &http.Request{Method: http.MethodPost, URL: &url.URL{Path: delivered.Path}, Host: delivered.Host, Header: delivered.Header}
+	if err := activitypub.VerifyRequest(req, publicKey); err != nil {
+		t.Errorf("expected a valid HTTP signature: %v", err)
+	}
+	if delivered.Header.Get("Digest") == "" {
+		t.Error("expected a Digest header")
+	}
+}
+
+// TestFederationDeliveryRefusesPrivateInbox keeps the default outbound
+// policy: without allow_private, an inbox on a private address gets nothing
+func TestFederationDeliveryRefusesPrivateInbox(t *testing.T) {
+	ctx := context.Background()
+	instance := newInstance(t)
+	cfg := newConfig(t, instance)
+	cfg.Outbound.AllowPrivate = false
+	if err := outbound.Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	userID := newUser(t, "private")
+
+	_, err := database.Postgres.Exec(ctx, `
+		INSERT INTO followers (user_id, follower_actor_id, follower_inbox, accepted)
+		VALUES ($1, $2, $3, true)
+	`, userID, instance.URL+"/users/bob", instance.URL+"/users/bob/inbox")
+	if err != nil {
+		t.Fatalf("Failed to add follower: %v", err)
+	}
+	if _, err := services.NewPostService(database.Postgres, cfg).Create(ctx, userID, "not for you", "public"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	time.Sleep(time.Second)
+	if n := len(instance.Deliveries()); n != 0 {
+		t.Fatalf("expected no deliveries to a private address, got %d", n)
+	}
+}
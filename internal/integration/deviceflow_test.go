@@ -0,0 +1,119 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/handlers"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TestDeviceFlow logs in from an SSH session the way a user does: the
+// session gets a code, the browser submits it on /device, approves the app
+// on the instance and lands on the callback, and the session's next poll
+// finds the account.
+func TestDeviceFlow(t *testing.T) {
+	ctx := context.Background()
+	instance := newInstance(t)
+	token := instance.AddAccount("alice")
+
+	mux := http.NewServeMux()
+	web := httptest.NewServer(mux)
+	defer web.Close()
+	cfg := newConfig(t, instance)
+	cfg.OAuth.CallbackURL = web.URL + "/oauth/callback"
+	oauth := handlers.NewOAuthHandler(database.Postgres, database.Redis, cfg)
+	mux.Handle("/device", oauth)
+	mux.HandleFunc("/oauth/callback", oauth.HandleCallback)
+
+	flow := auth.NewDeviceFlowService(database.Postgres, web.URL+"/device")
+	code, err := flow.InitiateDeviceFlow(ctx, instance.URL, "integration-session", "")
+	if err != nil {
+		t.Fatalf("InitiateDeviceFlow: %v", err)
+	}
+	if authorized, _, err := flow.PollDeviceCode(ctx, code.DeviceCode); err != nil || authorized {
+		t.Fatalf("expected a pending code, got authorized=%v err=%v", authorized, err)
+	}
+
+	// The browser, which must not follow redirects so each hop can be checked
+	browser := instance.Client()
+	browser.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	resp, err := browser.PostForm(web.URL+"/device", url.Values{"user_code": {strings.ToLower(code.UserCode)}})
+	if err != nil {
+		t.Fatalf("submitting the code: %v", err)
+	}
+	resp.Body.Close()
+	authorizeURL := resp.Header.Get("Location")
+	if resp.StatusCode != http.StatusFound || !strings.HasPrefix(authorizeURL, instance.URL+"/oauth/authorize?") {
+		t.Fatalf("expected a redirect to the instance, got %d %q", resp.StatusCode, authorizeURL)
+	}
+
+	resp, err = browser.Get(authorizeURL)
+	if err != nil {
+		t.Fatalf("authorizing: %v", err)
+	}
+	resp.Body.Close()
+	callbackURL := resp.Header.Get("Location")
+	if !strings.HasPrefix(callbackURL, cfg.OAuth.CallbackURL+"?") {
+		t.Fatalf("expected a redirect to the callback, got %d %q", resp.StatusCode, callbackURL)
+	}
+
+	resp, err = browser.Get(callbackURL)
+	if err != nil {
+		t.Fatalf("calling back: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the callback to succeed, got %d", resp.StatusCode)
+	}
+
+	authorized, userID, err := flow.PollDeviceCode(ctx, code.DeviceCode)
+	if err != nil || !authorized || userID == 0 {
+		t.Fatalf("expected the code to be authorized, got authorized=%v user=%d err=%v", authorized, userID, err)
+	}
+
+	user, err := services.NewUserService(database.Postgres).GetUserByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if want := auth.LocalUsername("alice", instance.URL); user.Username != want {
+		t.Errorf("expected username %q, got %q", want, user.Username)
+	}
+
+	// The stored token works against the instance
+	instance.AddStatus(token, "hello from alice")
+	statuses, err := services.NewMastodonService(database.Postgres, cfg).GetHomeTimeline(ctx, userID, 20, "")
+	if err != nil {
+		t.Fatalf("GetHomeTimeline: %v", err)
+	}
+	if len(statuses) != 1 || !strings.Contains(statuses[0].Content, "hello from alice") {
+		t.Errorf("expected alice's status on the home timeline, got %+v", statuses)
+	}
+}
+
+// TestDeviceFlowUnknownCode shows an error instead of redirecting
+func TestDeviceFlowUnknownCode(t *testing.T) {
+	instance := newInstance(t)
+	cfg := newConfig(t, instance)
+	oauth := handlers.NewOAuthHandler(database.Postgres, database.Redis, cfg)
+
+	req := httptest.NewRequest(http.MethodPost, "/device", strings.NewReader("user_code=NOPE-NOPE"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	oauth.ServeHTTP(rec, req)
+
+	if rec.Code == http.StatusFound {
+		t.Fatalf("expected no redirect for an unknown code, got one to %q", rec.Header().Get("Location"))
+	}
+	if instance.Requests() != 0 {
+		t.Errorf("expected no requests to the instance, got %d", instance.Requests())
+	}
+}
@@ -0,0 +1,74 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TestReceiveDirectMessage checks that a remote direct post is filed once
+// into the conversation of each local recipient, and of nobody else
+func TestReceiveDirectMessage(t *testing.T) {
+	ctx := context.Background()
+	pool := database.Postgres
+	cfg := newConfig(t, newInstance(t))
+	direct := services.NewDirectMessageService(pool, cfg)
+
+	usernames := map[int]string{}
+	recipient, copied, bystander := newUser(t, "dmto"), newUser(t, "dmcc"), newUser(t, "dmnot")
+	for _, userID := range []int{recipient, copied, bystander} {
+		var username string
+		if err := pool.QueryRow(ctx, "SELECT username FROM users WHERE id = $1", userID).Scan(&username); err != nil {
+			t.Fatal(err)
+		}
+		usernames[userID] = username
+	}
+
+	const alice = "https://example.social/users/alice"
+	activity := map[string]any{
+		"type":  "Create",
+		"actor": alice,
+		"object": map[string]any{
+			"type":         "Note",
+			"id":           alice + "/statuses/dm-" + usernames[recipient],
+			"attributedTo": alice,
+			"to":           []any{cfg.Server.BaseURL + "/users/" + usernames[recipient]},
+			"cc":           cfg.Server.BaseURL + "/users/" + usernames[copied],
+			"content":      "<p>psst</p>",
+			"published":    "2026-01-02T03:04:05Z",
+		},
+	}
+	// A redelivery must not file the message twice
+	for range 2 {
+		if err := direct.Receive(ctx, activity); err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+	}
+
+	for userID, want := range map[int]int{recipient: 1, copied: 1, bystander: 0} {
+		unread, err := direct.Unread(ctx, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if unread != want {
+			t.Errorf("%s has %d unread direct messages, want %d", usernames[userID], unread, want)
+		}
+	}
+
+	thread, err := direct.Thread(ctx, recipient, alice)
+	if err != nil {
+		t.Fatalf("Thread: %v", err)
+	}
+	if len(thread) != 1 || thread[0].Content != "<p>psst</p>" || thread[0].Direction != "inbound" {
+		t.Fatalf("thread = %+v, want the received message", thread)
+	}
+	if thread[0].PublishedAt.Year() != 2026 {
+		t.Errorf("published_at = %v, want the note's", thread[0].PublishedAt)
+	}
+	if unread, _ := direct.Unread(ctx, recipient); unread != 0 {
+		t.Errorf("%d direct messages unread after reading the thread", unread)
+	}
+}
@@ -0,0 +1,12 @@
+// Package integration holds end-to-end tests that run terminalpub's services
+// against real PostgreSQL and Redis servers and a fake Mastodon instance.
+// They are built only with the integration tag:
+//
+//	make test-integration
+//
+// starts the databases from docker-compose.test.yml, runs the tests and
+// stops them again. To use databases you started yourself, point
+// TERMINALPUB_TEST_POSTGRES and TERMINALPUB_TEST_REDIS at them (host:port)
+// and run go test -tags integration ./internal/integration. The test
+// database is wiped and migrated from scratch on every run.
+package integration
@@ -0,0 +1,63 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TestGuestbookRateLimit checks that a visitor is held to the rate limit by
+// address and by key, so changing only one of them does not help
+func TestGuestbookRateLimit(t *testing.T) {
+	ctx := context.Background()
+	cfg := newConfig(t, newInstance(t))
+	cfg.Features.AnonymousPosting.Enabled = true
+	cfg.Features.AnonymousPosting.RateLimit = 2
+	guestbook := services.NewGuestbookService(database.Postgres, database.Redis, cfg)
+
+	suffix := time.Now().UnixNano()
+	// Addresses are unique to the run, as counters outlive a test by an hour
+	address := func(host int) string {
+		return fmt.Sprintf("2001:db8:%x:%x::%x", suffix>>16&0xffff, suffix&0xffff, host)
+	}
+	ip := address(1)
+	key := fmt.Sprintf("ssh-ed25519 KEY%d", suffix)
+	sign := func(ip, key string) error {
+		_, err := guestbook.Sign(ctx, ip, key, "visitor", "hello")
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := sign(ip, key); err != nil {
+			t.Fatalf("Sign %d: %v", i+1, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		ip, key string
+		allowed bool
+	}{
+		{"same visitor", ip, key, false},
+		{"new key, same address", ip, key + "-new", false},
+		{"new address, same key", address(2), key, false},
+		{"new address and key", address(3), key + "-other", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sign(tt.ip, tt.key)
+			if tt.allowed && err != nil {
+				t.Errorf("Sign = %v, want it allowed", err)
+			}
+			if !tt.allowed && !errors.Is(err, services.ErrGuestbookRateLimited) {
+				t.Errorf("Sign = %v, want ErrGuestbookRateLimited", err)
+			}
+		})
+	}
+}
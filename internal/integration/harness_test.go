@@ -0,0 +1,213 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/db"
+	"github.com/fulgidus/terminalpub/internal/events"
+	"github.com/fulgidus/terminalpub/internal/mastodontest"
+	"github.com/fulgidus/terminalpub/internal/outbound"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5"
+)
+
+// Addresses of the databases in docker-compose.test.yml
+const (
+	defaultPostgresAddr = "localhost:55432"
+	defaultRedisAddr    = "localhost:56379"
+)
+
+// database is shared by every test; tests keep apart by using their own
+// users and fake instances
+var database *db.DB
+
+// userCount numbers the users tests create
+var userCount atomic.Int64
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	// Templates and migrations are found relative to the repository root
+	if err := os.Chdir("../.."); err != nil {
+		log.Printf("Failed to change to the repository root: %v", err)
+		return 1
+	}
+
+	cfg, err := testConfig()
+	if err != nil {
+		log.Printf("Invalid test database address: %v", err)
+		return 1
+	}
+	if err := resetDatabase(cfg); err != nil {
+		log.Printf("Failed to prepare the test database: %v (run make test-integration, or set TERMINALPUB_TEST_POSTGRES)", err)
+		return 1
+	}
+
+	database, err = db.Connect(cfg)
+	if err != nil {
+		log.Printf("Failed to connect to the test databases: %v", err)
+		return 1
+	}
+	defer database.Close()
+	if err := database.Redis.FlushDB(context.Background()).Err(); err != nil {
+		log.Printf("Failed to flush the test Redis database: %v", err)
+		return 1
+	}
+	// New posts are delivered by event handlers, as in the server
+	services.HandleEvents(events.Default, database.Postgres, cfg)
+
+	return m.Run()
+}
+
+// testConfig returns the default configuration pointed at the test databases
+func testConfig() (*config.Config, error) {
+	cfg := config.DefaultConfig()
+	cfg.Server.Domain = "terminalpub.test"
+	cfg.Server.BaseURL = "https://terminalpub.test"
+	cfg.Database.Postgres.Database = "terminalpub_test"
+	cfg.Database.Postgres.Password = "terminalpub_test"
+	cfg.Mastodon.RetryBaseDelay = 1
+	cfg.Mastodon.RetryMaxDelay = 10
+
+	var err error
+	if cfg.Database.Postgres.Host, cfg.Database.Postgres.Port, err = hostPort("TERMINALPUB_TEST_POSTGRES", defaultPostgresAddr); err != nil {
+		return nil, err
+	}
+	if cfg.Database.Redis.Host, cfg.Database.Redis.Port, err = hostPort("TERMINALPUB_TEST_REDIS", defaultRedisAddr); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// hostPort reads a host:port address from an environment variable
+func hostPort(name, fallback string) (string, int, error) {
+	addr := os.Getenv(name)
+	if addr == "" {
+		addr = fallback
+	}
+	host, portText, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, fmt.Errorf("%s: %w", name, err)
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		return "", 0, fmt.Errorf("%s: invalid port %q", name, portText)
+	}
+	return host, port, nil
+}
+
+// resetDatabase drops everything in the test database and runs every
+// migration, so each run starts from the schema a new install gets
+func resetDatabase(cfg *config.Config) error {
+	pg := cfg.Database.Postgres
+	url := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable", pg.User, pg.Password, pg.Host, pg.Port, pg.Database)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(ctx, "DROP SCHEMA public CASCADE; CREATE SCHEMA public")
+	conn.Close(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to drop schema: %w", err)
+	}
+
+	m, err := migrate.New("file://migrations", url)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to migrate: %w", err)
+	}
+	return nil
+}
+
+// newConfig returns a configuration for one test, whose outbound requests
+// may reach the fake instance
+func newConfig(t *testing.T, instance *mastodontest.Server) *config.Config {
+	t.Helper()
+	cfg, err := testConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Outbound.AllowPrivate = true
+	cfg.Outbound.CAFile = filepath.Join(t.TempDir(), "ca.pem")
+	if err := instance.WriteCertificate(cfg.Outbound.CAFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := outbound.Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// newInstance starts a fake Mastodon instance for one test
+func newInstance(t *testing.T) *mastodontest.Server {
+	t.Helper()
+	instance := mastodontest.NewServer()
+	t.Cleanup(instance.Close)
+	return instance
+}
+
+// newUser creates a local user with a unique name
+func newUser(t *testing.T, prefix string) int {
+	t.Helper()
+	username := fmt.Sprintf("%s%d_%d", prefix, time.Now().UnixNano()%1e6, userCount.Add(1))
+	user, err := services.NewUserService(database.Postgres).GetOrCreateUser(context.Background(), username, "")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	return user.ID
+}
+
+// linkAccount creates a local user logged in to the fake instance with an
+// access token, as if they had pasted it during onboarding
+func linkAccount(t *testing.T, instance *mastodontest.Server, token string) int {
+	t.Helper()
+	ctx := context.Background()
+	userID := newUser(t, "linked")
+	tokens := auth.NewTokenService(database.Postgres, auth.NewMastodonService(database.Postgres, "urn:ietf:wg:oauth:2.0:oob", []string{"read", "write", "follow"}))
+	verified, err := tokens.VerifyPersonalToken(ctx, instance.URL, token)
+	if err != nil {
+		t.Fatalf("VerifyPersonalToken: %v", err)
+	}
+	if err := tokens.StoreToken(ctx, userID, verified, true); err != nil {
+		t.Fatalf("StoreToken: %v", err)
+	}
+	return userID
+}
+
+// eventually retries check until it passes or timeout runs out
+func eventually(t *testing.T, timeout time.Duration, check func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if check() {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return check()
+}
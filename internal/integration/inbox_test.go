@@ -0,0 +1,62 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TestDeleteByAuthorOnly checks that a remote object is only removed by a
+// Delete from the actor it is attributed to, not by another actor on the
+// same server
+func TestDeleteByAuthorOnly(t *testing.T) {
+	ctx := context.Background()
+	pool := database.Postgres
+	worker := services.NewInboxWorker(pool, newConfig(t, newInstance(t)))
+
+	author := "https://remote.example/users/alice"
+	objectID := fmt.Sprintf("https://remote.example/notes/%d", time.Now().UnixNano())
+	_, err := pool.Exec(ctx, `
+		INSERT INTO remote_objects (object_id, object_type, attributed_to, object_json)
+		VALUES ($1, 'Note', $2, '{}')
+	`, objectID, author)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleteAs := func(actor string) bool {
+		t.Helper()
+		activity, _ := json.Marshal(map[string]any{"type": "Delete", "actor": actor, "object": objectID})
+		_, err := pool.Exec(ctx, `
+			INSERT INTO activities (activity_type, actor_id, object_id, activity_json, direction, processed)
+			VALUES ('Delete', $1, $2, $3, 'inbound', FALSE)
+		`, actor, objectID, activity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worker.ProcessPending(ctx, 100); err != nil {
+			t.Fatalf("ProcessPending: %v", err)
+		}
+		var exists bool
+		if err := pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM remote_objects WHERE object_id = $1)", objectID).Scan(&exists); err != nil {
+			t.Fatal(err)
+		}
+		return exists
+	}
+
+	if !deleteAs("https://remote.example/users/mallory") {
+		t.Error("another actor on the author's server deleted the object")
+	}
+	if !deleteAs(objectID) {
+		t.Error("the object deleted itself")
+	}
+	if deleteAs(author) {
+		t.Error("the author could not delete the object")
+	}
+}
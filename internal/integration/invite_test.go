@@ -0,0 +1,47 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TestCreateInviteQuota checks that concurrent requests cannot create more
+// invites than a user's quota
+func TestCreateInviteQuota(t *testing.T) {
+	ctx := context.Background()
+	userID := newUser(t, "inviter")
+	cfg := newConfig(t, newInstance(t))
+	cfg.Features.Registration.InvitesPerUser = 2
+	invites := services.NewInviteService(database.Postgres, cfg)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := invites.CreateInvite(ctx, userID, 1, time.Hour)
+			switch {
+			case err == nil:
+				mu.Lock()
+				created++
+				mu.Unlock()
+			case !errors.Is(err, services.ErrInviteQuotaExceeded):
+				t.Errorf("CreateInvite: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if created != 2 {
+		t.Errorf("created %d invites, want the quota of 2", created)
+	}
+}
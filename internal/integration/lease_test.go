@@ -0,0 +1,60 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TestLeases checks that a lease held by another node keeps a job from
+// running until it is released or runs out
+func TestLeases(t *testing.T) {
+	ctx := context.Background()
+	pool := database.Postgres
+	name := "test:" + t.Name()
+
+	acquire := func() bool {
+		t.Helper()
+		ok, err := services.AcquireLease(ctx, pool, name, time.Minute)
+		if err != nil {
+			t.Fatalf("AcquireLease: %v", err)
+		}
+		return ok
+	}
+
+	if !acquire() {
+		t.Fatal("free lease was not acquired")
+	}
+	if !acquire() {
+		t.Error("holder could not extend its lease")
+	}
+
+	// Hand the lease to another node
+	if _, err := pool.Exec(ctx, `UPDATE leases SET holder = 'other' WHERE name = $1`, name); err != nil {
+		t.Fatal(err)
+	}
+	if acquire() {
+		t.Error("lease held by another node was acquired")
+	}
+	if err := services.ReleaseLease(ctx, pool, name); err != nil {
+		t.Fatalf("ReleaseLease: %v", err)
+	}
+	if acquire() {
+		t.Error("releasing freed another node's lease")
+	}
+
+	// The other node stopped without releasing it
+	if _, err := pool.Exec(ctx, `UPDATE leases SET expires_at = NOW() - INTERVAL '1 second' WHERE name = $1`, name); err != nil {
+		t.Fatal(err)
+	}
+	if !acquire() {
+		t.Error("expired lease was not acquired")
+	}
+	if err := services.ReleaseLease(ctx, pool, name); err != nil {
+		t.Fatalf("ReleaseLease: %v", err)
+	}
+}
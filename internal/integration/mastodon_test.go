@@ -0,0 +1,164 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TestTimelineAndPosting posts, follows, replies and favourites through a
+// linked account and reads the results back from the timelines
+func TestTimelineAndPosting(t *testing.T) {
+	ctx := context.Background()
+	instance := newInstance(t)
+	cfg := newConfig(t, instance)
+	mastodon := services.NewMastodonService(database.Postgres, cfg)
+
+	aliceID := linkAccount(t, instance, instance.AddAccount("alice"))
+	bob := instance.AddAccount("bob")
+	bobStatus := instance.AddStatus(bob, "hello from bob #terminalpub")
+
+	posted, err := mastodon.PostStatus(ctx, aliceID, "hello from alice", "public", "", "")
+	if err != nil {
+		t.Fatalf("PostStatus: %v", err)
+	}
+	statuses := instance.Statuses()
+	if last := statuses[len(statuses)-1]; last.ID != posted || last.Account.Username != "alice" {
+		t.Fatalf("expected alice's status %s on the instance, got %+v", posted, last)
+	}
+
+	// Bob's status reaches alice's home timeline once she follows him
+	home, err := mastodon.GetHomeTimeline(ctx, aliceID, 20, "")
+	if err != nil {
+		t.Fatalf("GetHomeTimeline: %v", err)
+	}
+	if len(home) != 1 {
+		t.Fatalf("expected only alice's own status before following, got %d", len(home))
+	}
+	account, err := mastodon.LookupAccount(ctx, aliceID, "@bob")
+	if err != nil {
+		t.Fatalf("LookupAccount: %v", err)
+	}
+	if err := mastodon.FollowAccount(ctx, aliceID, account.ID); err != nil {
+		t.Fatalf("FollowAccount: %v", err)
+	}
+	relationship, err := mastodon.GetAccountRelationship(ctx, aliceID, account.ID)
+	if err != nil || !relationship.Following {
+		t.Fatalf("expected alice to follow bob, got %+v (%v)", relationship, err)
+	}
+	home, err = mastodon.GetHomeTimeline(ctx, aliceID, 20, "")
+	if err != nil {
+		t.Fatalf("GetHomeTimeline: %v", err)
+	}
+	if len(home) != 2 || home[0].ID != posted || home[1].ID != bobStatus.ID {
+		t.Fatalf("expected alice's and bob's statuses, newest first, got %+v", home)
+	}
+
+	// Paging with max_id continues where the last page ended
+	older, err := mastodon.GetHomeTimeline(ctx, aliceID, 1, home[0].ID)
+	if err != nil || len(older) != 1 || older[0].ID != bobStatus.ID {
+		t.Fatalf("expected bob's status on the next page, got %+v (%v)", older, err)
+	}
+
+	tagged, err := mastodon.GetTimeline(ctx, aliceID, services.TagTimeline("terminalpub"), 20, "")
+	if err != nil || len(tagged) != 1 || tagged[0].ID != bobStatus.ID {
+		t.Fatalf("expected bob's status on the hashtag timeline, got %+v (%v)", tagged, err)
+	}
+
+	// Interactions show up on the status
+	if err := mastodon.FavouriteStatus(ctx, aliceID, bobStatus.ID); err != nil {
+		t.Fatalf("FavouriteStatus: %v", err)
+	}
+	if err := mastodon.BookmarkStatus(ctx, aliceID, bobStatus.ID); err != nil {
+		t.Fatalf("BookmarkStatus: %v", err)
+	}
+	reply, err := mastodon.PostStatus(ctx, aliceID, "@bob nice", "public", bobStatus.ID, "")
+	if err != nil {
+		t.Fatalf("PostStatus reply: %v", err)
+	}
+	thread, err := mastodon.GetStatusContext(ctx, aliceID, bobStatus.ID)
+	if err != nil {
+		t.Fatalf("GetStatusContext: %v", err)
+	}
+	if len(thread.Descendants) != 1 || thread.Descendants[0].ID != reply {
+		t.Fatalf("expected the reply in the thread, got %+v", thread.Descendants)
+	}
+	home, err = mastodon.GetHomeTimeline(ctx, aliceID, 20, "")
+	if err != nil {
+		t.Fatalf("GetHomeTimeline: %v", err)
+	}
+	for _, status := range home {
+		if status.ID == bobStatus.ID && (!status.Favourited || !status.Bookmarked || status.FavouritesCount != 1 || status.RepliesCount != 1) {
+			t.Errorf("expected bob's status favourited, bookmarked and replied to, got %+v", status)
+		}
+	}
+}
+
+// TestNotifications reads, dismisses and clears notifications
+func TestNotifications(t *testing.T) {
+	ctx := context.Background()
+	instance := newInstance(t)
+	cfg := newConfig(t, instance)
+	mastodon := services.NewMastodonService(database.Postgres, cfg)
+
+	aliceID := linkAccount(t, instance, instance.AddAccount("alice"))
+	bob := instance.AddAccount("bob")
+	instance.AddStatus(bob, "@alice are you there?")
+	instance.AddStatus(bob, "@alice hello?")
+
+	notifications, err := mastodon.GetNotifications(ctx, aliceID, 20, "")
+	if err != nil {
+		t.Fatalf("GetNotifications: %v", err)
+	}
+	if len(notifications) != 2 || notifications[0].Type != services.NotificationMention || !strings.Contains(notifications[0].Status.Content, "hello?") {
+		t.Fatalf("expected two mentions, newest first, got %+v", notifications)
+	}
+
+	mentions, err := mastodon.GetMentionsSince(ctx, aliceID, notifications[1].ID, 20)
+	if err != nil {
+		t.Fatalf("GetMentionsSince: %v", err)
+	}
+	if len(mentions) != 1 || mentions[0].ID != notifications[0].ID {
+		t.Fatalf("expected only the newer mention, got %+v", mentions)
+	}
+
+	if err := mastodon.DismissNotification(ctx, aliceID, notifications[0].ID); err != nil {
+		t.Fatalf("DismissNotification: %v", err)
+	}
+	if notifications, err = mastodon.GetNotifications(ctx, aliceID, 20, ""); err != nil || len(notifications) != 1 {
+		t.Fatalf("expected one notification left, got %d (%v)", len(notifications), err)
+	}
+	if err := mastodon.ClearAllNotifications(ctx, aliceID); err != nil {
+		t.Fatalf("ClearAllNotifications: %v", err)
+	}
+	if notifications, err = mastodon.GetNotifications(ctx, aliceID, 20, ""); err != nil || len(notifications) != 0 {
+		t.Fatalf("expected no notifications left, got %d (%v)", len(notifications), err)
+	}
+}
+
+// TestTimelineRetriesUnavailableInstance keeps reading through a brief outage
+func TestTimelineRetriesUnavailableInstance(t *testing.T) {
+	ctx := context.Background()
+	instance := newInstance(t)
+	cfg := newConfig(t, instance)
+	mastodon := services.NewMastodonService(database.Postgres, cfg)
+
+	token := instance.AddAccount("alice")
+	aliceID := linkAccount(t, instance, token)
+	instance.AddStatus(token, "still here")
+
+	instance.FailNext(503)
+	home, err := mastodon.GetHomeTimeline(ctx, aliceID, 20, "")
+	if err != nil || len(home) != 1 {
+		t.Fatalf("expected the timeline after a retry, got %+v (%v)", home, err)
+	}
+
+	instance.FailNext(503, 503, 503)
+	if _, err := mastodon.GetHomeTimeline(ctx, aliceID, 10, ""); err == nil {
+		t.Fatal("expected an error once retries run out")
+	}
+}
@@ -0,0 +1,59 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TestSuspendEndsSessions checks that suspending a user revokes their API
+// tokens and ends their connected sessions
+func TestSuspendEndsSessions(t *testing.T) {
+	ctx := context.Background()
+	pool := database.Postgres
+	userID := newUser(t, "suspended")
+	admin := services.NewAdminService(pool, database.Redis, newConfig(t, newInstance(t)))
+	presence := services.NewPresenceService(pool, database.Redis)
+	tokens := auth.NewAPITokenService(pool)
+
+	var username string
+	if err := pool.QueryRow(ctx, "SELECT username FROM users WHERE id = $1", userID).Scan(&username); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := tokens.CreateToken(ctx, userID, "bot", "read"); err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+
+	sessionID := "suspended-session-" + username
+	if err := presence.Join(ctx, sessionID, userID); err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	t.Cleanup(func() { presence.Leave(context.Background(), sessionID) })
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ended, err := presence.Ended(watchCtx, sessionID)
+	if err != nil {
+		t.Fatalf("Ended: %v", err)
+	}
+
+	if err := admin.Suspend(ctx, username); err != nil {
+		t.Fatalf("Suspend: %v", err)
+	}
+	select {
+	case <-ended:
+	case <-time.After(5 * time.Second):
+		t.Error("session of a suspended user was not ended")
+	}
+	remaining, err := tokens.ListUserTokens(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("suspended user still has %d API tokens", len(remaining))
+	}
+}
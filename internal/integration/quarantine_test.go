@@ -0,0 +1,74 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TestInspectNewActors checks that only posts from new actors, by age or
+// because nobody here follows them, are held as spam
+func TestInspectNewActors(t *testing.T) {
+	ctx := context.Background()
+	pool := database.Postgres
+	quarantine := services.NewQuarantineService(pool, newConfig(t, newInstance(t)))
+	userID := newUser(t, "spamcheck")
+	suffix := time.Now().UnixNano()
+
+	actors := map[string]struct {
+		published string // "" for an actor never fetched
+		followed  bool
+		spam      bool
+	}{
+		"unknown":  {"", false, true},
+		"recent":   {time.Now().Add(-24 * time.Hour).Format(time.RFC3339), false, true},
+		"old":      {"2020-01-01T00:00:00Z", false, false},
+		"followed": {time.Now().Format(time.RFC3339), true, false},
+	}
+
+	for name, actor := range actors {
+		t.Run(name, func(t *testing.T) {
+			actorID := fmt.Sprintf("https://spam.example/users/%s%d", name, suffix)
+			if actor.published != "" {
+				_, err := pool.Exec(ctx, `
+					INSERT INTO remote_actors (actor_id, actor_json)
+					VALUES ($1, jsonb_build_object('id', $1::text, 'published', $2::text))
+				`, actorID, actor.published)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if actor.followed {
+				_, err := pool.Exec(ctx, `INSERT INTO following (user_id, target_actor_id, accepted) VALUES ($1, $2, TRUE)`, userID, actorID)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			activity := map[string]any{
+				"type":  "Create",
+				"actor": actorID,
+				"object": map[string]any{
+					"content": `<a href="https://spam.example/buy">buy</a>`,
+					"tag": []any{
+						map[string]any{"type": "Mention", "href": "https://a.example/users/1"},
+						map[string]any{"type": "Mention", "href": "https://a.example/users/2"},
+						map[string]any{"type": "Mention", "href": "https://a.example/users/3"},
+					},
+				},
+			}
+			reason, err := quarantine.Inspect(ctx, activity)
+			if err != nil {
+				t.Fatalf("Inspect: %v", err)
+			}
+			if (reason != "") != actor.spam {
+				t.Errorf("Inspect = %q, want spam %v", reason, actor.spam)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TestCanViewFollowersOnly checks that followers-only posts are shown to
+// accepted followers only
+func TestCanViewFollowersOnly(t *testing.T) {
+	ctx := context.Background()
+	pool := database.Postgres
+	userID := newUser(t, "private")
+	posts := services.NewPostService(pool, newConfig(t, newInstance(t)))
+
+	const (
+		follower = "https://example.social/users/follower"
+		pending  = "https://example.social/users/pending"
+		stranger = "https://example.social/users/stranger"
+	)
+	_, err := pool.Exec(ctx, `
+		INSERT INTO followers (user_id, follower_actor_id, follower_inbox, accepted)
+		VALUES ($1, $2, $2 || '/inbox', TRUE), ($1, $3, $3 || '/inbox', FALSE)
+	`, userID, follower, pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := &models.Post{UserID: userID, Visibility: "followers"}
+	for actorID, want := range map[string]bool{follower: true, pending: false, stranger: false} {
+		got, err := posts.CanView(ctx, post, actorID)
+		if err != nil {
+			t.Fatalf("CanView: %v", err)
+		}
+		if got != want {
+			t.Errorf("CanView(%s) = %v, want %v", actorID, got, want)
+		}
+	}
+}
@@ -0,0 +1,769 @@
+// Package mastodontest provides an in-memory Mastodon server for tests. It
+// implements the parts of the Mastodon API terminalpub uses (app
+// registration, OAuth, timelines, statuses, accounts and notifications) and
+// an ActivityPub inbox that records what is delivered to it.
+//
+// The server speaks TLS, because instance URLs are always https; trust it
+// by pointing outbound.ca_file at the file WriteCertificate creates and
+// setting outbound.allow_private.
+package mastodontest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Account is a Mastodon account
+type Account struct {
+	ID             string    `json:"id"`
+	Username       string    `json:"username"`
+	Acct           string    `json:"acct"`
+	DisplayName    string    `json:"display_name"`
+	Note           string    `json:"note"`
+	URL            string    `json:"url"`
+	Avatar         string    `json:"avatar"`
+	FollowersCount int       `json:"followers_count"`
+	FollowingCount int       `json:"following_count"`
+	StatusesCount  int       `json:"statuses_count"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Status is a Mastodon status as the authenticated account sees it
+type Status struct {
+	ID              string     `json:"id"`
+	URI             string     `json:"uri"`
+	URL             string     `json:"url"`
+	CreatedAt       time.Time  `json:"created_at"`
+	Content         string     `json:"content"`
+	SpoilerText     string     `json:"spoiler_text"`
+	Visibility      string     `json:"visibility"`
+	InReplyToID     *string    `json:"in_reply_to_id"`
+	Account         Account    `json:"account"`
+	Tags            []Tag      `json:"tags"`
+	RepliesCount    int        `json:"replies_count"`
+	ReblogsCount    int        `json:"reblogs_count"`
+	FavouritesCount int        `json:"favourites_count"`
+	Favourited      bool       `json:"favourited"`
+	Reblogged       bool       `json:"reblogged"`
+	Bookmarked      bool       `json:"bookmarked"`
+	Pinned          bool       `json:"pinned"`
+	EditedAt        *time.Time `json:"edited_at"`
+}
+
+// Tag is a hashtag used in a status
+type Tag struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Notification is a Mastodon notification
+type Notification struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Account   Account   `json:"account"`
+	Status    *Status   `json:"status,omitempty"`
+}
+
+// Delivery is an activity posted to the inbox
+type Delivery struct {
+	Path     string
+	Host     string
+	Header   http.Header
+	Activity map[string]any
+}
+
+// status is a stored status with the per-account state Status reports
+type status struct {
+	Status
+	author       string          // Token of the author
+	favouritedBy map[string]bool // By token, likewise below
+	rebloggedBy  map[string]bool
+	bookmarkedBy map[string]bool
+	pinned       bool
+}
+
+// account is a stored account with what it did
+type account struct {
+	Account
+	token         string
+	following     map[string]bool // By account ID
+	muting        map[string]bool
+	notifications []Notification
+}
+
+// Server is a fake Mastodon instance
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	nextID     int
+	accounts   []*account // In creation order; the first one logs in
+	apps       map[string]string
+	codes      map[string]string // Authorization code to token
+	statuses   []*status         // Oldest first
+	deliveries []Delivery
+	failures   []int // Statuses to answer the next requests with
+	requests   int
+}
+
+// mentionPattern matches @user and @user@domain mentions
+var mentionPattern = regexp.MustCompile(`@([A-Za-z0-9_]+)(?:@[A-Za-z0-9.-]+)?`)
+
+// tagPattern matches hashtags
+var tagPattern = regexp.MustCompile(`#(\w+)`)
+
+// NewServer starts a fake instance. Close it when done.
+func NewServer() *Server {
+	s := &Server{apps: map[string]string{}, codes: map[string]string{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/apps", s.registerApp)
+	mux.HandleFunc("GET /oauth/authorize", s.authorize)
+	mux.HandleFunc("POST /oauth/token", s.token)
+	mux.HandleFunc("GET /api/v1/accounts/verify_credentials", s.authenticated(s.verifyCredentials))
+	mux.HandleFunc("GET /api/v1/accounts/lookup", s.authenticated(s.lookupAccount))
+	mux.HandleFunc("GET /api/v1/accounts/relationships", s.authenticated(s.relationships))
+	mux.HandleFunc("GET /api/v1/accounts/{id}", s.authenticated(s.getAccount))
+	mux.HandleFunc("GET /api/v1/accounts/{id}/statuses", s.authenticated(s.accountStatuses))
+	mux.HandleFunc("POST /api/v1/accounts/{id}/{action}", s.authenticated(s.accountAction))
+	mux.HandleFunc("GET /api/v1/timelines/home", s.authenticated(s.homeTimeline))
+	mux.HandleFunc("GET /api/v1/timelines/public", s.optionalAuth(s.publicTimeline))
+	mux.HandleFunc("GET /api/v1/timelines/tag/{tag}", s.optionalAuth(s.tagTimeline))
+	mux.HandleFunc("POST /api/v1/statuses", s.authenticated(s.postStatus))
+	mux.HandleFunc("GET /api/v1/statuses/{id}", s.optionalAuth(s.getStatus))
+	mux.HandleFunc("GET /api/v1/statuses/{id}/context", s.optionalAuth(s.statusContext))
+	mux.HandleFunc("POST /api/v1/statuses/{id}/{action}", s.authenticated(s.statusAction))
+	mux.HandleFunc("GET /api/v1/notifications", s.authenticated(s.listNotifications))
+	mux.HandleFunc("POST /api/v1/notifications/clear", s.authenticated(s.clearNotifications))
+	mux.HandleFunc("POST /api/v1/notifications/{id}/dismiss", s.authenticated(s.dismissNotification))
+	mux.HandleFunc("POST /inbox", s.inbox)
+	mux.HandleFunc("POST /users/{name}/inbox", s.inbox)
+
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		var fail int
+		if len(s.failures) > 0 {
+			fail, s.failures = s.failures[0], s.failures[1:]
+		}
+		s.mu.Unlock()
+		if fail != 0 {
+			writeError(w, fail, http.StatusText(fail))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	// Clients that do not trust the certificate are not worth logging
+	s.Config.ErrorLog = log.New(io.Discard, "", 0)
+	s.StartTLS()
+	return s
+}
+
+// WriteCertificate writes the server's certificate as PEM to path
+func (s *Server) WriteCertificate(path string) error {
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}
+	return os.WriteFile(path, pem.EncodeToMemory(block), 0o600)
+}
+
+// InboxURL returns the URL of the server's shared inbox
+func (s *Server) InboxURL() string {
+	return s.URL + "/inbox"
+}
+
+// Domain returns the host and port the server runs on
+func (s *Server) Domain() string {
+	return strings.TrimPrefix(s.URL, "https://")
+}
+
+// AddAccount creates an account and returns its access token. The first
+// account created is the one the OAuth flow logs in as.
+func (s *Server) AddAccount(username string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := &account{
+		Account: Account{
+			ID:          s.newID(),
+			Username:    username,
+			Acct:        username,
+			DisplayName: username,
+			URL:         s.URL + "/@" + username,
+			CreatedAt:   time.Now().UTC(),
+		},
+		token:     randomString(),
+		following: map[string]bool{},
+		muting:    map[string]bool{},
+	}
+	s.accounts = append(s.accounts, a)
+	return a.token
+}
+
+// AddStatus publishes a public status as the account with token
+func (s *Server) AddStatus(token, content string) Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.publish(s.accountByToken(token), content, "public", "", "")
+	return st.view(token)
+}
+
+// Statuses returns every status, oldest first
+func (s *Server) Statuses() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]Status, len(s.statuses))
+	for i, st := range s.statuses {
+		statuses[i] = st.view(st.author)
+	}
+	return statuses
+}
+
+// Deliveries returns the activities posted to inboxes so far
+func (s *Server) Deliveries() []Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.deliveries)
+}
+
+// FailNext makes the next requests fail with the given statuses, one each
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, statuses...)
+}
+
+// Requests returns how many requests the server has received
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// newID returns the next id; ids grow like Mastodon's snowflakes, so later
+// items sort after earlier ones
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("%018d", s.nextID)
+}
+
+// accountByToken returns the account a token belongs to, or nil
+func (s *Server) accountByToken(token string) *account {
+	for _, a := range s.accounts {
+		if a.token == token {
+			return a
+		}
+	}
+	return nil
+}
+
+// accountByID returns an account by id, or nil
+func (s *Server) accountByID(id string) *account {
+	for _, a := range s.accounts {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// statusByID returns a status by id, or nil
+func (s *Server) statusByID(id string) *status {
+	for _, st := range s.statuses {
+		if st.ID == id {
+			return st
+		}
+	}
+	return nil
+}
+
+// publish stores a new status and notifies the accounts it mentions
+func (s *Server) publish(author *account, content, visibility, spoiler, inReplyTo string) *status {
+	id := s.newID()
+	st := &status{
+		Status: Status{
+			ID:          id,
+			URI:         fmt.Sprintf("%s/users/%s/statuses/%s", s.URL, author.Username, id),
+			URL:         fmt.Sprintf("%s/@%s/%s", s.URL, author.Username, id),
+			CreatedAt:   time.Now().UTC(),
+			Content:     "<p>" + content + "</p>",
+			SpoilerText: spoiler,
+			Visibility:  visibility,
+			Account:     author.Account,
+			Tags:        []Tag{},
+		},
+		author:       author.token,
+		favouritedBy: map[string]bool{},
+		rebloggedBy:  map[string]bool{},
+		bookmarkedBy: map[string]bool{},
+	}
+	if parent := s.statusByID(inReplyTo); parent != nil {
+		st.InReplyToID = &parent.ID
+		parent.RepliesCount++
+	}
+	for _, m := range tagPattern.FindAllStringSubmatch(content, -1) {
+		st.Tags = append(st.Tags, Tag{Name: strings.ToLower(m[1]), URL: s.URL + "/tags/" + strings.ToLower(m[1])})
+	}
+	s.statuses = append(s.statuses, st)
+	author.StatusesCount++
+
+	for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		for _, a := range s.accounts {
+			if a.Username == m[1] && a != author {
+				s.notify(a, "mention", author, st)
+			}
+		}
+	}
+	return st
+}
+
+// notify adds a notification for an account
+func (s *Server) notify(to *account, kind string, from *account, st *status) {
+	n := Notification{ID: s.newID(), Type: kind, CreatedAt: time.Now().UTC(), Account: from.Account}
+	if st != nil {
+		view := st.view(to.token)
+		n.Status = &view
+	}
+	to.notifications = append(to.notifications, n)
+}
+
+// view returns the status as the account with token sees it
+func (st *status) view(token string) Status {
+	v := st.Status
+	v.FavouritesCount = len(st.favouritedBy)
+	v.ReblogsCount = len(st.rebloggedBy)
+	v.Favourited = st.favouritedBy[token]
+	v.Reblogged = st.rebloggedBy[token]
+	v.Bookmarked = st.bookmarkedBy[token]
+	v.Pinned = st.pinned && st.author == token
+	return v
+}
+
+// visibleTo reports whether a status appears in public listings or to the
+// viewer with token
+func (st *status) visibleTo(token string) bool {
+	return st.Visibility == "public" || st.Visibility == "unlisted" || st.author == token
+}
+
+// page returns up to limit statuses matching keep, newest first, between
+// the max_id and since_id query parameters
+func (s *Server) page(r *http.Request, token string, keep func(*status) bool) []Status {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 || limit > 40 {
+		limit = 20
+	}
+	maxID, sinceID := r.URL.Query().Get("max_id"), r.URL.Query().Get("since_id")
+
+	statuses := []Status{}
+	for i := len(s.statuses) - 1; i >= 0 && len(statuses) < limit; i-- {
+		st := s.statuses[i]
+		if (maxID != "" && st.ID >= maxID) || st.ID <= sinceID {
+			continue
+		}
+		if keep(st) {
+			statuses = append(statuses, st.view(token))
+		}
+	}
+	return statuses
+}
+
+// authenticated wraps a handler that needs a valid bearer token
+func (s *Server) authenticated(next func(http.ResponseWriter, *http.Request, *account)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		a := s.accountByToken(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if a == nil {
+			writeError(w, http.StatusUnauthorized, "The access token is invalid")
+			return
+		}
+		next(w, r, a)
+	}
+}
+
+// optionalAuth wraps a handler that also serves anonymous requests, with a
+// nil account
+func (s *Server) optionalAuth(next func(http.ResponseWriter, *http.Request, *account)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		var a *account
+		if header := r.Header.Get("Authorization"); header != "" {
+			if a = s.accountByToken(strings.TrimPrefix(header, "Bearer ")); a == nil {
+				writeError(w, http.StatusUnauthorized, "The access token is invalid")
+				return
+			}
+		}
+		next(w, r, a)
+	}
+}
+
+// tokenOf returns the token of an account that may be nil
+func tokenOf(a *account) string {
+	if a == nil {
+		return ""
+	}
+	return a.token
+}
+
+func (s *Server) registerApp(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ClientName   string `json:"client_name"`
+		RedirectURIs string `json:"redirect_uris"`
+		Scopes       string `json:"scopes"`
+	}
+	if err := decodeBody(r, &req); err != nil || req.ClientName == "" || req.RedirectURIs == "" {
+		writeError(w, http.StatusUnprocessableEntity, "client_name and redirect_uris are required")
+		return
+	}
+	s.mu.Lock()
+	clientID, secret := randomString(), randomString()
+	s.apps[clientID] = secret
+	s.mu.Unlock()
+	writeJSON(w, map[string]string{
+		"id":            clientID,
+		"name":          req.ClientName,
+		"redirect_uri":  req.RedirectURIs,
+		"client_id":     clientID,
+		"client_secret": secret,
+	})
+}
+
+// authorize approves every request as the first account, as if it had
+// logged in, and redirects back with a code
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	s.mu.Lock()
+	_, known := s.apps[q.Get("client_id")]
+	if err != nil || !known || len(s.accounts) == 0 || q.Get("response_type") != "code" {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, "invalid authorization request")
+		return
+	}
+	code := randomString()
+	s.codes[code] = s.accounts[0].token
+	s.mu.Unlock()
+
+	params := redirect.Query()
+	params.Set("code", code)
+	if state := q.Get("state"); state != "" {
+		params.Set("state", state)
+	}
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid form")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if secret, ok := s.apps[r.PostForm.Get("client_id")]; !ok || secret != r.PostForm.Get("client_secret") {
+		writeError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	token, ok := s.codes[r.PostForm.Get("code")]
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	delete(s.codes, r.PostForm.Get("code"))
+	writeJSON(w, map[string]any{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"scope":        r.PostForm.Get("scope"),
+		"created_at":   time.Now().Unix(),
+	})
+}
+
+func (s *Server) verifyCredentials(w http.ResponseWriter, r *http.Request, a *account) {
+	writeJSON(w, a.Account)
+}
+
+func (s *Server) lookupAccount(w http.ResponseWriter, r *http.Request, _ *account) {
+	acct := strings.TrimPrefix(r.URL.Query().Get("acct"), "@")
+	username, domain, _ := strings.Cut(acct, "@")
+	for _, a := range s.accounts {
+		if a.Username == username && (domain == "" || domain == s.Domain()) {
+			writeJSON(w, a.Account)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Record not found")
+}
+
+func (s *Server) getAccount(w http.ResponseWriter, r *http.Request, _ *account) {
+	a := s.accountByID(r.PathValue("id"))
+	if a == nil {
+		writeError(w, http.StatusNotFound, "Record not found")
+		return
+	}
+	writeJSON(w, a.Account)
+}
+
+func (s *Server) relationships(w http.ResponseWriter, r *http.Request, viewer *account) {
+	relationships := []map[string]any{}
+	for _, id := range r.URL.Query()["id[]"] {
+		other := s.accountByID(id)
+		if other == nil {
+			continue
+		}
+		relationships = append(relationships, map[string]any{
+			"id":          id,
+			"following":   viewer.following[id],
+			"followed_by": other.following[viewer.ID],
+			"muting":      viewer.muting[id],
+		})
+	}
+	writeJSON(w, relationships)
+}
+
+func (s *Server) accountStatuses(w http.ResponseWriter, r *http.Request, viewer *account) {
+	a := s.accountByID(r.PathValue("id"))
+	if a == nil {
+		writeError(w, http.StatusNotFound, "Record not found")
+		return
+	}
+	pinned := r.URL.Query().Get("pinned") == "true"
+	writeJSON(w, s.page(r, viewer.token, func(st *status) bool {
+		return st.author == a.token && st.visibleTo(viewer.token) && (!pinned || st.pinned)
+	}))
+}
+
+func (s *Server) accountAction(w http.ResponseWriter, r *http.Request, viewer *account) {
+	other := s.accountByID(r.PathValue("id"))
+	if other == nil {
+		writeError(w, http.StatusNotFound, "Record not found")
+		return
+	}
+	switch r.PathValue("action") {
+	case "follow":
+		if !viewer.following[other.ID] {
+			viewer.following[other.ID] = true
+			viewer.FollowingCount++
+			other.FollowersCount++
+			s.notify(other, "follow", viewer, nil)
+		}
+	case "unfollow":
+		if viewer.following[other.ID] {
+			delete(viewer.following, other.ID)
+			viewer.FollowingCount--
+			other.FollowersCount--
+		}
+	case "mute":
+		viewer.muting[other.ID] = true
+	case "unmute":
+		delete(viewer.muting, other.ID)
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	writeJSON(w, map[string]any{
+		"id":        other.ID,
+		"following": viewer.following[other.ID],
+		"muting":    viewer.muting[other.ID],
+	})
+}
+
+func (s *Server) homeTimeline(w http.ResponseWriter, r *http.Request, viewer *account) {
+	writeJSON(w, s.page(r, viewer.token, func(st *status) bool {
+		author := s.accountByToken(st.author)
+		return (st.author == viewer.token || viewer.following[author.ID]) && !viewer.muting[author.ID]
+	}))
+}
+
+func (s *Server) publicTimeline(w http.ResponseWriter, r *http.Request, viewer *account) {
+	writeJSON(w, s.page(r, tokenOf(viewer), func(st *status) bool {
+		return st.Visibility == "public"
+	}))
+}
+
+func (s *Server) tagTimeline(w http.ResponseWriter, r *http.Request, viewer *account) {
+	tag := strings.ToLower(r.PathValue("tag"))
+	writeJSON(w, s.page(r, tokenOf(viewer), func(st *status) bool {
+		return st.Visibility == "public" && slices.ContainsFunc(st.Tags, func(t Tag) bool { return t.Name == tag })
+	}))
+}
+
+func (s *Server) postStatus(w http.ResponseWriter, r *http.Request, author *account) {
+	var req struct {
+		Status      string `json:"status"`
+		Visibility  string `json:"visibility"`
+		InReplyToID string `json:"in_reply_to_id"`
+		SpoilerText string `json:"spoiler_text"`
+	}
+	if err := decodeBody(r, &req); err != nil || strings.TrimSpace(req.Status) == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation failed: Text can't be blank")
+		return
+	}
+	switch req.Visibility {
+	case "":
+		req.Visibility = "public"
+	case "public", "unlisted", "private", "direct":
+	default:
+		writeError(w, http.StatusUnprocessableEntity, "Validation failed: Visibility is invalid")
+		return
+	}
+	st := s.publish(author, req.Status, req.Visibility, req.SpoilerText, req.InReplyToID)
+	writeJSON(w, st.view(author.token))
+}
+
+func (s *Server) getStatus(w http.ResponseWriter, r *http.Request, viewer *account) {
+	st := s.statusByID(r.PathValue("id"))
+	if st == nil || !st.visibleTo(tokenOf(viewer)) {
+		writeError(w, http.StatusNotFound, "Record not found")
+		return
+	}
+	writeJSON(w, st.view(tokenOf(viewer)))
+}
+
+func (s *Server) statusContext(w http.ResponseWriter, r *http.Request, viewer *account) {
+	st := s.statusByID(r.PathValue("id"))
+	if st == nil || !st.visibleTo(tokenOf(viewer)) {
+		writeError(w, http.StatusNotFound, "Record not found")
+		return
+	}
+	ancestors, descendants := []Status{}, []Status{}
+	for parent := st; parent.InReplyToID != nil; {
+		if parent = s.statusByID(*parent.InReplyToID); parent == nil {
+			break
+		}
+		ancestors = append([]Status{parent.view(tokenOf(viewer))}, ancestors...)
+	}
+	thread := map[string]bool{st.ID: true}
+	for _, other := range s.statuses {
+		if other.InReplyToID != nil && thread[*other.InReplyToID] && other.visibleTo(tokenOf(viewer)) {
+			thread[other.ID] = true
+			descendants = append(descendants, other.view(tokenOf(viewer)))
+		}
+	}
+	writeJSON(w, map[string][]Status{"ancestors": ancestors, "descendants": descendants})
+}
+
+func (s *Server) statusAction(w http.ResponseWriter, r *http.Request, viewer *account) {
+	st := s.statusByID(r.PathValue("id"))
+	if st == nil || !st.visibleTo(viewer.token) {
+		writeError(w, http.StatusNotFound, "Record not found")
+		return
+	}
+	author := s.accountByToken(st.author)
+	switch r.PathValue("action") {
+	case "favourite":
+		if !st.favouritedBy[viewer.token] && author != viewer {
+			s.notify(author, "favourite", viewer, st)
+		}
+		st.favouritedBy[viewer.token] = true
+	case "unfavourite":
+		delete(st.favouritedBy, viewer.token)
+	case "reblog":
+		if !st.rebloggedBy[viewer.token] && author != viewer {
+			s.notify(author, "reblog", viewer, st)
+		}
+		st.rebloggedBy[viewer.token] = true
+	case "unreblog":
+		delete(st.rebloggedBy, viewer.token)
+	case "bookmark":
+		st.bookmarkedBy[viewer.token] = true
+	case "unbookmark":
+		delete(st.bookmarkedBy, viewer.token)
+	case "pin", "unpin":
+		if author != viewer {
+			writeError(w, http.StatusUnprocessableEntity, "Validation failed: Status can't be pinned")
+			return
+		}
+		st.pinned = r.PathValue("action") == "pin"
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	writeJSON(w, st.view(viewer.token))
+}
+
+func (s *Server) listNotifications(w http.ResponseWriter, r *http.Request, a *account) {
+	types := r.URL.Query()["types[]"]
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	maxID, sinceID := r.URL.Query().Get("max_id"), r.URL.Query().Get("since_id")
+	notifications := []Notification{}
+	for i := len(a.notifications) - 1; i >= 0 && len(notifications) < limit; i-- {
+		n := a.notifications[i]
+		if (maxID != "" && n.ID >= maxID) || n.ID <= sinceID || (len(types) > 0 && !slices.Contains(types, n.Type)) {
+			continue
+		}
+		notifications = append(notifications, n)
+	}
+	writeJSON(w, notifications)
+}
+
+func (s *Server) dismissNotification(w http.ResponseWriter, r *http.Request, a *account) {
+	a.notifications = slices.DeleteFunc(a.notifications, func(n Notification) bool {
+		return n.ID == r.PathValue("id")
+	})
+	writeJSON(w, map[string]any{})
+}
+
+func (s *Server) clearNotifications(w http.ResponseWriter, r *http.Request, a *account) {
+	a.notifications = nil
+	writeJSON(w, map[string]any{})
+}
+
+// inbox records an activity delivered to the server. Signatures are not
+// checked; tests verify the recorded headers themselves.
+func (s *Server) inbox(w http.ResponseWriter, r *http.Request) {
+	var activity map[string]any
+	if err := decodeBody(r, &activity); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	s.mu.Lock()
+	s.deliveries = append(s.deliveries, Delivery{Path: r.URL.Path, Host: r.Host, Header: r.Header.Clone(), Activity: activity})
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// decodeBody reads a JSON request body
+func decodeBody(r *http.Request, v any) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// writeJSON writes a 200 response
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error the way Mastodon does
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// randomString returns a random token
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,94 @@
+package mastodontest_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/mastodontest"
+	"github.com/fulgidus/terminalpub/internal/outbound"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// newService starts a fake instance and a MastodonService that trusts it
+func newService(t *testing.T) (*mastodontest.Server, *services.MastodonService) {
+	t.Helper()
+	server := mastodontest.NewServer()
+	t.Cleanup(server.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.Outbound.AllowPrivate = true
+	cfg.Outbound.CAFile = filepath.Join(t.TempDir(), "ca.pem")
+	cfg.Mastodon.RetryBaseDelay = 1
+	cfg.Mastodon.RetryMaxDelay = 10
+	if err := server.WriteCertificate(cfg.Outbound.CAFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := outbound.Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { outbound.Configure(config.DefaultConfig()) })
+	return server, services.NewMastodonService(nil, cfg)
+}
+
+func TestPublicTimeline(t *testing.T) {
+	server, mastodon := newService(t)
+	alice := server.AddAccount("alice")
+	for _, content := range []string{"first", "second #go", "third"} {
+		server.AddStatus(alice, content)
+	}
+
+	statuses, err := mastodon.GetPublicTimeline(context.Background(), server.URL, false, 2, "")
+	if err != nil {
+		t.Fatalf("GetPublicTimeline: %v", err)
+	}
+	if len(statuses) != 2 || !strings.Contains(statuses[0].Content, "third") || !strings.Contains(statuses[1].Content, "second") {
+		t.Fatalf("expected the two newest statuses, got %+v", statuses)
+	}
+	if statuses[0].Account.Username != "alice" {
+		t.Errorf("expected author alice, got %q", statuses[0].Account.Username)
+	}
+
+	older, err := mastodon.GetPublicTimeline(context.Background(), server.URL, false, 20, statuses[1].ID)
+	if err != nil {
+		t.Fatalf("GetPublicTimeline with max_id: %v", err)
+	}
+	if len(older) != 1 || !strings.Contains(older[0].Content, "first") {
+		t.Fatalf("expected the oldest status, got %+v", older)
+	}
+}
+
+func TestPublicTimelineRetriesUnavailableServer(t *testing.T) {
+	server, mastodon := newService(t)
+	server.AddStatus(server.AddAccount("alice"), "hello")
+	server.FailNext(503, 502)
+
+	statuses, err := mastodon.GetPublicTimeline(context.Background(), server.URL, true, 20, "")
+	if err != nil {
+		t.Fatalf("GetPublicTimeline: %v", err)
+	}
+	if len(statuses) != 1 {
+		t.Fatalf("expected 1 status, got %d", len(statuses))
+	}
+	if got := server.Requests(); got != 3 {
+		t.Errorf("expected 3 requests, got %d", got)
+	}
+}
+
+func TestPublicTimelineRefusesUntrustedCertificate(t *testing.T) {
+	server := mastodontest.NewServer()
+	defer server.Close()
+	cfg := config.DefaultConfig()
+	cfg.Outbound.AllowPrivate = true
+	if err := outbound.Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	defer outbound.Configure(config.DefaultConfig())
+
+	mastodon := services.NewMastodonService(nil, cfg)
+	if _, err := mastodon.GetPublicTimeline(context.Background(), server.URL, false, 20, ""); err == nil {
+		t.Fatal("expected the self-signed certificate to be refused")
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// ActionType is the kind of Mastodon action recorded in the action log
+type ActionType string
+
+const (
+	ActionLike     ActionType = "like"
+	ActionBoost    ActionType = "boost"
+	ActionFollow   ActionType = "follow"
+	ActionBookmark ActionType = "bookmark"
+	ActionMute     ActionType = "mute"
+)
+
+// UserAction is an action the user performed through terminalpub
+type UserAction struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Action    ActionType `json:"action"`
+	TargetID  string     `json:"target_id"` // Status ID, or account ID for follows and mutes
+	Summary   string     `json:"summary"`   // What the action was about, for display
+	CreatedAt time.Time  `json:"created_at"`
+	UndoneAt  *time.Time `json:"undone_at"`
+}
+
+// Undone reports whether the action has been reverted
+func (a *UserAction) Undone() bool {
+	return a.UndoneAt != nil
+}
@@ -8,18 +8,27 @@ import (
 
 // Post represents a user's post/status
 type Post struct {
-	ID          int             `json:"id" db:"id"`
-	UserID      int             `json:"user_id" db:"user_id"`
-	Content     string          `json:"content" db:"content"`
-	ContentType string          `json:"content_type" db:"content_type"`
-	InReplyToID *int            `json:"in_reply_to_id,omitempty" db:"in_reply_to_id"`
-	Visibility  string          `json:"visibility" db:"visibility"`
-	PublishedAt time.Time       `json:"published_at" db:"published_at"`
-	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
-	APID        string          `json:"ap_id,omitempty" db:"ap_id"`
-	APType      string          `json:"ap_type" db:"ap_type"`
-	APObject    json.RawMessage `json:"ap_object,omitempty" db:"ap_object"`
+	ID           int             `json:"id" db:"id"`
+	UserID       int             `json:"user_id" db:"user_id"`
+	Content      string          `json:"content" db:"content"`
+	ContentType  string          `json:"content_type" db:"content_type"`
+	InReplyToID  *int            `json:"in_reply_to_id,omitempty" db:"in_reply_to_id"`
+	Visibility   string          `json:"visibility" db:"visibility"` // public, unlisted, followers or direct
+	PublishedAt  time.Time       `json:"published_at" db:"published_at"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at" db:"updated_at"`
+	APID         string          `json:"ap_id,omitempty" db:"ap_id"`
+	APType       string          `json:"ap_type" db:"ap_type"`
+	APObject     json.RawMessage `json:"ap_object,omitempty" db:"ap_object"`
+	PinnedAt     *time.Time      `json:"pinned_at,omitempty" db:"pinned_at"`         // Set while featured on the author's profile
+	Mentions     []PostMention   `json:"mentions,omitempty" db:"-"`                  // Actors the post is addressed to by mention
+	GPGSignature string          `json:"gpg_signature,omitempty" db:"gpg_signature"` // Armored detached OpenPGP signature of Content
+}
+
+// PostMention is an actor mentioned in a local post
+type PostMention struct {
+	ActorID string `json:"actor_id" db:"actor_id"`
+	Acct    string `json:"acct" db:"acct"` // user@domain, as written in the post
 }
 
 // Follower represents someone following a user
@@ -31,6 +40,7 @@ type Follower struct {
 	FollowerInbox       string    `json:"follower_inbox" db:"follower_inbox"`
 	FollowerSharedInbox string    `json:"follower_shared_inbox,omitempty" db:"follower_shared_inbox"`
 	Accepted            bool      `json:"accepted" db:"accepted"`
+	FollowActivityID    string    `json:"follow_activity_id,omitempty" db:"follow_activity_id"`
 	CreatedAt           time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -84,21 +94,42 @@ type Boost struct {
 
 // Actor represents an ActivityPub Actor (Person)
 type Actor struct {
-	Context                   any            `json:"@context"`
-	ID                        string         `json:"id"`
-	Type                      string         `json:"type"`
-	PreferredUsername         string         `json:"preferredUsername"`
-	Name                      string         `json:"name,omitempty"`
-	Summary                   string         `json:"summary,omitempty"`
-	Inbox                     string         `json:"inbox"`
-	Outbox                    string         `json:"outbox"`
-	Followers                 string         `json:"followers"`
-	Following                 string         `json:"following"`
-	PublicKey                 ActorPublicKey `json:"publicKey"`
-	Endpoints                 map[string]any `json:"endpoints,omitempty"`
-	URL                       string         `json:"url,omitempty"`
-	ManuallyApprovesFollowers bool           `json:"manuallyApprovesFollowers"`
-	Published                 string         `json:"published,omitempty"`
+	Context                   any             `json:"@context"`
+	ID                        string          `json:"id"`
+	Type                      string          `json:"type"`
+	PreferredUsername         string          `json:"preferredUsername"`
+	Name                      string          `json:"name,omitempty"`
+	Summary                   string          `json:"summary,omitempty"`
+	Icon                      *ActorImage     `json:"icon,omitempty"`
+	Inbox                     string          `json:"inbox"`
+	Outbox                    string          `json:"outbox"`
+	Followers                 string          `json:"followers"`
+	Following                 string          `json:"following"`
+	Featured                  string          `json:"featured,omitempty"` // Collection of pinned posts
+	PublicKey                 ActorPublicKey  `json:"publicKey"`
+	Endpoints                 map[string]any  `json:"endpoints,omitempty"`
+	URL                       string          `json:"url,omitempty"`
+	ManuallyApprovesFollowers bool            `json:"manuallyApprovesFollowers"`
+	Discoverable              bool            `json:"discoverable"`
+	Published                 string          `json:"published,omitempty"`
+	AlsoKnownAs               []string        `json:"alsoKnownAs,omitempty"`
+	MovedTo                   string          `json:"movedTo,omitempty"`
+	AssertionMethod           []ActorMultikey `json:"assertionMethod,omitempty"`
+	GPGKey                    string          `json:"gpgKey,omitempty"` // Armored OpenPGP key the actor signs posts with
+}
+
+// ActorMultikey represents a Multikey verification method, used for integrity proofs
+type ActorMultikey struct {
+	ID                 string `json:"id"`
+	Type               string `json:"type"`
+	Controller         string `json:"controller"`
+	PublicKeyMultibase string `json:"publicKeyMultibase"`
+}
+
+// ActorImage represents an Image object such as an actor's avatar
+type ActorImage struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
 }
 
 // ActorPublicKey represents the public key in an Actor object
@@ -123,6 +154,7 @@ type APNote struct {
 	Tag          []any             `json:"tag,omitempty"`
 	Attachment   []any             `json:"attachment,omitempty"`
 	Sensitive    bool              `json:"sensitive,omitempty"`
+	GPGSignature string            `json:"gpgSignature,omitempty"` // Armored detached OpenPGP signature of Content
 }
 
 // APActivity represents a generic ActivityPub Activity
@@ -133,6 +165,7 @@ type APActivity struct {
 	Actor     string   `json:"actor"`
 	Object    any      `json:"object,omitempty"`
 	Target    string   `json:"target,omitempty"`
+	Content   string   `json:"content,omitempty"` // Emoji of an EmojiReact
 	To        []string `json:"to,omitempty"`
 	CC        []string `json:"cc,omitempty"`
 	Published string   `json:"published,omitempty"`
@@ -146,6 +179,8 @@ type OrderedCollection struct {
 	TotalItems int    `json:"totalItems"`
 	First      string `json:"first,omitempty"`
 	Last       string `json:"last,omitempty"`
+	// OrderedItems is only set when the collection is rendered inline (e.g. data exports)
+	OrderedItems []any `json:"orderedItems,omitempty"`
 }
 
 // OrderedCollectionPage represents a page in an OrderedCollection
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// APIToken represents a personal API token used to access the HTTP API
+type APIToken struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	Name        string     `json:"name"`
+	TokenHash   string     `json:"-"`            // SHA256 hex of the plaintext token
+	TokenPrefix string     `json:"token_prefix"` // First characters, shown to identify the token
+	Scopes      string     `json:"scopes"`       // Space-separated, e.g. "read write"
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the token grants the given scope, itself or
+// through its parent: read grants read:statuses, but read:statuses does not
+// grant read
+func (t *APIToken) HasScope(scope string) bool {
+	parent, _, _ := strings.Cut(scope, ":")
+	for _, s := range strings.Fields(t.Scopes) {
+		if s == scope || s == parent {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package models
+
+import "testing"
+
+func TestAPITokenHasScope(t *testing.T) {
+	tests := []struct {
+		scopes string
+		scope  string
+		want   bool
+	}{
+		{scopes: "read write", scope: "read", want: true},
+		{scopes: "read write", scope: "write", want: true},
+		{scopes: "read", scope: "write", want: false},
+		// A parent scope grants its granular scopes
+		{scopes: "read", scope: "read:statuses", want: true},
+		{scopes: "write", scope: "write:accounts", want: true},
+		{scopes: "write", scope: "read:statuses", want: false},
+		// A granular scope grants itself only
+		{scopes: "read:statuses", scope: "read:statuses", want: true},
+		{scopes: "read:notifications", scope: "read", want: false},
+		{scopes: "read:notifications", scope: "read:statuses", want: false},
+		{scopes: "read:statuses write:statuses", scope: "write", want: false},
+		{scopes: "push", scope: "push", want: true},
+		{scopes: "readwrite", scope: "read", want: false},
+		{scopes: "", scope: "read", want: false},
+	}
+	for _, tt := range tests {
+		token := APIToken{Scopes: tt.scopes}
+		if got := token.HasScope(tt.scope); got != tt.want {
+			t.Errorf("HasScope(%q) with scopes %q = %v, want %v", tt.scope, tt.scopes, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// AuditEventType is the kind of security-sensitive event in the audit log
+type AuditEventType string
+
+const (
+	AuditLogin            AuditEventType = "login"
+	AuditSSHKeyAdded      AuditEventType = "ssh_key_added"
+	AuditSSHKeyRemoved    AuditEventType = "ssh_key_removed"
+	AuditTokenGranted     AuditEventType = "token_granted"
+	AuditTokenRevoked     AuditEventType = "token_revoked"
+	AuditAdminAction      AuditEventType = "admin_action"
+	AuditDeviceCodeFailed AuditEventType = "device_code_failed"
+)
+
+// AuditEvent is an entry of the audit log
+type AuditEvent struct {
+	ID        int64          `json:"id"`
+	UserID    *int           `json:"user_id"`  // Nil when no account is known
+	Username  string         `json:"username"` // Local username of UserID, for display
+	Event     AuditEventType `json:"event"`
+	Detail    string         `json:"detail"`
+	IPAddress string         `json:"ip_address"`
+	CreatedAt time.Time      `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// BlueskyAccount is the Bluesky account a user cross-posts to
+type BlueskyAccount struct {
+	UserID    int       `json:"user_id"`
+	Service   string    `json:"service"` // PDS the account lives on, e.g. https://bsky.social
+	Handle    string    `json:"handle"`
+	DID       string    `json:"did"`
+	CrossPost bool      `json:"crosspost"` // New posts go to Bluesky unless unchecked
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// ChatRoom represents a public chat room of the instance
+type ChatRoom struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"` // Without the leading #
+	Topic     string    `json:"topic"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatMessage represents a message said in a chat room
+type ChatMessage struct {
+	ID        int64     `json:"id"`
+	RoomID    int       `json:"room_id"`
+	UserID    *int      `json:"user_id"`
+	Username  string    `json:"username"` // Kept when the account is deleted
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatEvent is something that happened in a chat room, fanned out to every
+// session in it
+type ChatEvent struct {
+	Type      string       `json:"type"` // "message", "delete", "topic" or "clear"
+	Room      string       `json:"room"`
+	Message   *ChatMessage `json:"message,omitempty"`
+	MessageID int64        `json:"message_id,omitempty"`
+	Topic     string       `json:"topic,omitempty"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// EmailDigest is a user's subscription to a periodic email digest
+type EmailDigest struct {
+	UserID     int        `json:"user_id"`
+	Email      string     `json:"email"`
+	Frequency  string     `json:"frequency"` // daily or weekly
+	Confirmed  bool       `json:"confirmed"` // The address was confirmed through the emailed link
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
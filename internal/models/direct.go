@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// DirectMessage is a direct post in a conversation between a local user and
+// one other actor
+type DirectMessage struct {
+	ID                 int        `json:"id"`
+	UserID             int        `json:"user_id"`
+	CounterpartActorID string     `json:"counterpart_actor_id"`
+	ObjectID           string     `json:"object_id"`
+	PostID             *int       `json:"post_id,omitempty"` // Set for messages the user sent
+	Direction          string     `json:"direction"`         // inbound or outbound
+	Content            string     `json:"content"`           // HTML
+	PublishedAt        time.Time  `json:"published_at"`
+	ReadAt             *time.Time `json:"read_at,omitempty"`
+}
+
+// Outbound reports whether the user sent the message
+func (m *DirectMessage) Outbound() bool {
+	return m.Direction == "outbound"
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// DirectoryEntry is a local user listed in the profile directory
+type DirectoryEntry struct {
+	Username     string     `json:"username"`
+	DisplayName  string     `json:"display_name,omitempty"`
+	Bio          string     `json:"bio,omitempty"`
+	AvatarURL    string     `json:"avatar_url,omitempty"`
+	ActorURL     string     `json:"url"`
+	PostsCount   int        `json:"posts_count"`
+	LastActiveAt *time.Time `json:"last_active_at,omitempty"` // Last sign-in or public post
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Feed filter actions
+const (
+	FeedFilterHide = "hide" // Hide the posts that match
+	FeedFilterShow = "show" // Show only the posts that match
+)
+
+// FeedFilter represents a named filter a user applies to timelines
+type FeedFilter struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"` // See services.FilterExpr
+	Action     string    `json:"action"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
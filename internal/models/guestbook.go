@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// GuestbookEntry represents a message an anonymous visitor left on the
+// instance's guestbook
+type GuestbookEntry struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
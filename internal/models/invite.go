@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Invite represents a registration invite code
+type Invite struct {
+	ID        int        `json:"id"`
+	Code      string     `json:"code"`
+	CreatedBy *int       `json:"created_by"` // Null once the creator's account is purged
+	MaxUses   int        `json:"max_uses"`
+	Uses      int        `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Usable reports whether the invite can still be redeemed
+func (i *Invite) Usable(now time.Time) bool {
+	if i.Uses >= i.MaxUses {
+		return false
+	}
+	return i.ExpiresAt == nil || now.Before(*i.ExpiresAt)
+}
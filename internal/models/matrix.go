@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// MatrixBridge is the Matrix room a user's mentions and direct messages are
+// forwarded to while they are away
+type MatrixBridge struct {
+	UserID          int        `json:"user_id"`
+	Homeserver      string     `json:"homeserver"`     // e.g. https://matrix.org
+	MatrixUserID    string     `json:"matrix_user_id"` // Account the access token belongs to, e.g. @alice:matrix.org
+	RoomID          string     `json:"room_id"`
+	ForwardMentions bool       `json:"forward_mentions"`
+	ForwardDirect   bool       `json:"forward_direct"`
+	LastForwardedAt *time.Time `json:"last_forwarded_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"` // Why the last pass failed, if it did
+	CreatedAt       time.Time  `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RejectedActor is a remote actor whose activities the instance refuses
+type RejectedActor struct {
+	ActorID   string    `json:"actor_id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BlockedDomain is a remote server whose actors the instance refuses,
+// including those on its subdomains
+type BlockedDomain struct {
+	Domain    string    `json:"domain"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
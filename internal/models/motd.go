@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// MOTD represents a message of the day shown when users connect
+type MOTD struct {
+	Key       string     `json:"key"` // Identifies the message when it is dismissed
+	ID        int        `json:"id"`  // Zero for the message from the config file
+	Message   string     `json:"message"`
+	CreatedBy *int       `json:"created_by"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// NostrAccount is the Nostr key a user publishes notes with
+type NostrAccount struct {
+	UserID    int       `json:"user_id"`
+	PublicKey string    `json:"public_key"` // Hex; clients show it as an npub
+	Relays    []string  `json:"relays"`     // Relays notes go to; empty means the instance's
+	CrossPost bool      `json:"crosspost"`  // New posts go to Nostr unless unchecked
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NostrDelivery is how one relay took a published note
+type NostrDelivery struct {
+	EventID   string    `json:"event_id"`
+	Relay     string    `json:"relay"`
+	Accepted  bool      `json:"accepted"`
+	Message   string    `json:"message"` // The relay's reason, or why it could not be reached
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -27,6 +27,7 @@ type DeviceCode struct {
 	ExpiresAt       time.Time `json:"expires_at"`       // Code expiration time (typically 15 minutes)
 	Authorized      bool      `json:"authorized"`       // Whether user has authorized
 	UserID          *int      `json:"user_id"`          // Set after authorization completes
+	InviteCode      string    `json:"invite_code"`      // Invite entered for registration, if any
 	CreatedAt       time.Time `json:"created_at"`
 }
 
@@ -60,3 +61,16 @@ type Session struct {
 	LastSeenAt time.Time `json:"last_seen_at"` // Last activity timestamp
 	ExpiresAt  time.Time `json:"expires_at"`   // Session expiration
 }
+
+// OAuthClient is a client application, such as a Mastodon app, registered to
+// log users into terminalpub itself
+type OAuthClient struct {
+	ID           int       `json:"id"`
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret"`
+	Name         string    `json:"name"`
+	Website      string    `json:"website"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       string    `json:"scopes"` // Space-separated scopes the app may request
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// PushSubscription is a Web Push subscription registered by a client app
+// through the Mastodon API; each access token holds at most one
+type PushSubscription struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	TokenID      int       `json:"token_id"`
+	Endpoint     string    `json:"endpoint"`
+	P256dh       string    `json:"-"`
+	Auth         string    `json:"-"`
+	AlertMention bool      `json:"alert_mention"`
+	AlertFollow  bool      `json:"alert_follow"`
+	Policy       string    `json:"policy"` // all, followed, follower or none
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// QuarantinedActivity is an inbound activity held back as likely spam
+// until an admin releases or purges it
+type QuarantinedActivity struct {
+	ID           int64     `json:"id"`
+	Username     string    `json:"username"` // Local recipient; empty for the shared inbox
+	ActivityType string    `json:"activity_type"`
+	ActorID      string    `json:"actor_id"`
+	ObjectID     string    `json:"object_id"`
+	Content      string    `json:"content"` // Plain-text excerpt of the post, for review
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SessionRecording is an asciinema cast a user recorded of their own session
+type SessionRecording struct {
+	ID         int64     `json:"id"`
+	UserID     int       `json:"user_id"`
+	Title      string    `json:"title"`
+	DurationMS int64     `json:"duration_ms"`
+	Size       int       `json:"size"`      // Bytes of the cast
+	Truncated  bool      `json:"truncated"` // The cast stops at the size limit
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// TimelinePin is a timeline a user pinned to a numbered slot of the feed
+type TimelinePin struct {
+	UserID    int       `json:"user_id"`
+	Slot      int       `json:"slot"`     // 1 to 9
+	Timeline  string    `json:"timeline"` // See services.ParseTimeline
+	CreatedAt time.Time `json:"created_at"`
+}
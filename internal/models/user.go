@@ -1,25 +1,69 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
 
 // User represents a terminalpub user
 type User struct {
-	ID                      int       `json:"id"`
-	Username                string    `json:"username"`
-	Email                   string    `json:"email,omitempty"`
-	PasswordHash            string    `json:"-"`
-	PrimaryMastodonInstance string    `json:"primary_mastodon_instance,omitempty"`
-	PrimaryMastodonID       string    `json:"primary_mastodon_id,omitempty"`
-	PrimaryMastodonAcct     string    `json:"primary_mastodon_acct,omitempty"`
-	PrivateKey              string    `json:"-"`
-	PublicKey               string    `json:"public_key,omitempty"`
-	ActorURL                string    `json:"actor_url,omitempty"`
-	InboxURL                string    `json:"inbox_url,omitempty"`
-	OutboxURL               string    `json:"outbox_url,omitempty"`
-	FollowersURL            string    `json:"followers_url,omitempty"`
-	FollowingURL            string    `json:"following_url,omitempty"`
-	CreatedAt               time.Time `json:"created_at"`
-	UpdatedAt               time.Time `json:"updated_at"`
-	Bio                     string    `json:"bio,omitempty"`
-	AvatarURL               string    `json:"avatar_url,omitempty"`
+	ID                        int       `json:"id"`
+	Username                  string    `json:"username"`
+	Email                     string    `json:"email,omitempty"`
+	PasswordHash              string    `json:"-"`
+	PrimaryMastodonInstance   string    `json:"primary_mastodon_instance,omitempty"`
+	PrimaryMastodonID         string    `json:"primary_mastodon_id,omitempty"`
+	PrimaryMastodonAcct       string    `json:"primary_mastodon_acct,omitempty"`
+	PrivateKey                string    `json:"-"`
+	PublicKey                 string    `json:"public_key,omitempty"`
+	ActorURL                  string    `json:"actor_url,omitempty"`
+	InboxURL                  string    `json:"inbox_url,omitempty"`
+	OutboxURL                 string    `json:"outbox_url,omitempty"`
+	FollowersURL              string    `json:"followers_url,omitempty"`
+	FollowingURL              string    `json:"following_url,omitempty"`
+	CreatedAt                 time.Time `json:"created_at"`
+	UpdatedAt                 time.Time `json:"updated_at"`
+	Bio                       string    `json:"bio,omitempty"`
+	DisplayName               string    `json:"display_name,omitempty"`
+	AvatarURL                 string    `json:"avatar_url,omitempty"`
+	UsernameConfirmed         bool      `json:"-"` // False until the user picks a local username
+	ManuallyApprovesFollowers bool      `json:"manually_approves_followers"`
+	AlsoKnownAs               []string  `json:"also_known_as,omitempty"` // Actor IDs this account has moved from
+	MovedTo                   string    `json:"moved_to,omitempty"`      // Actor ID this account has moved to
+	ProofPublicKey            string    `json:"-"`                       // Ed25519 Multikey for integrity proofs
+	Discoverable              bool      `json:"discoverable"`            // Listed in the profile directory
+	GPGPublicKey              string    `json:"-"`                       // Armored OpenPGP key the user signs posts with
+}
+
+// MaxUsernameLength matches the limit Mastodon applies to local usernames
+const MaxUsernameLength = 30
+
+// usernamePattern allows the characters Mastodon accepts in a username
+var usernamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// reservedUsernames would clash with routes or impersonate the server
+var reservedUsernames = map[string]bool{
+	"admin": true, "administrator": true, "root": true, "system": true,
+	"api": true, "inbox": true, "outbox": true, "users": true, "export": true,
+	"terminalpub": true, "support": true, "moderator": true, "postmaster": true,
+}
+
+// ErrInvalidUsername is returned for usernames that cannot be used as actor names
+var ErrInvalidUsername = errors.New("invalid username")
+
+// ValidateUsername checks that a local username is safe to use in actor URLs and mentions
+func ValidateUsername(username string) error {
+	switch {
+	case username == "":
+		return fmt.Errorf("%w: username is required", ErrInvalidUsername)
+	case len(username) > MaxUsernameLength:
+		return fmt.Errorf("%w: at most %d characters", ErrInvalidUsername, MaxUsernameLength)
+	case !usernamePattern.MatchString(username):
+		return fmt.Errorf("%w: use lowercase letters, digits and underscores", ErrInvalidUsername)
+	case reservedUsernames[username]:
+		return fmt.Errorf("%w: %q is reserved", ErrInvalidUsername, username)
+	}
+	return nil
 }
@@ -39,3 +39,19 @@ func TestUserCreatedAt(t *testing.T) {
 		t.Error("Expected UpdatedAt to be set")
 	}
 }
+
+func TestValidateUsername(t *testing.T) {
+	valid := []string{"alice", "bob_42", "a", "abcdefghijklmnopqrstuvwxyz0123"}
+	for _, username := range valid {
+		if err := ValidateUsername(username); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", username, err)
+		}
+	}
+
+	invalid := []string{"", "Alice", "alice@mastodon_social", "al.ice", "al-ice", "admin", "abcdefghijklmnopqrstuvwxyz01234"}
+	for _, username := range invalid {
+		if err := ValidateUsername(username); err == nil {
+			t.Errorf("Expected %q to be invalid", username)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Webhook events
+const (
+	WebhookFollow         = "follow"          // Someone followed the user
+	WebhookMention        = "mention"         // A remote post mentioned the user
+	WebhookPostFederated  = "post.federated"  // A post was delivered to the followers' servers
+	WebhookDeliveryFailed = "delivery.failed" // A server could not be reached with an activity
+	WebhookPing           = "ping"            // Sent on request to test a webhook
+)
+
+// WebhookEvents lists the events a webhook can subscribe to
+var WebhookEvents = []string{WebhookFollow, WebhookMention, WebhookPostFederated, WebhookDeliveryFailed}
+
+// Webhook represents a URL that receives a signed JSON payload when one of
+// its events happens
+type Webhook struct {
+	ID             int        `json:"id"`
+	UserID         int        `json:"user_id"`
+	URL            string     `json:"url"`
+	Secret         string     `json:"-"` // HMAC-SHA256 key payloads are signed with
+	Events         []string   `json:"events"`
+	Instance       bool       `json:"instance"` // Receives the events of every user; admins only
+	Enabled        bool       `json:"enabled"`
+	LastStatus     string     `json:"last_status,omitempty"` // Outcome of the last delivery
+	LastDeliveryAt *time.Time `json:"last_delivery_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
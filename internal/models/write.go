@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// TerminalMessage is a short message one connected user writes to the
+// terminal of another, as with the Unix write command
+type TerminalMessage struct {
+	From   string    `json:"from"` // Sender's username
+	Body   string    `json:"body"`
+	SentAt time.Time `json:"sent_at"`
+}
@@ -0,0 +1,279 @@
+// Package outbound builds the HTTP clients terminalpub uses to reach other
+// servers. Every client shares one transport, which goes through the
+// configured proxy, refuses to connect to private and other denied
+// addresses so that user-supplied URLs cannot reach internal services, and
+// bounds how long connections and redirects may take.
+package outbound
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+)
+
+// ErrDeniedAddress is returned when a request would reach a denied address
+var ErrDeniedAddress = errors.New("destination address is not allowed")
+
+// Defaults used until Configure is called, and for unset settings
+const (
+	defaultTimeout      = 30 * time.Second
+	defaultMaxRedirects = 5
+)
+
+// privateNetworks are loopback, private, link-local and other ranges that
+// are never public internet hosts
+var privateNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// resolver looks up the hosts of outbound requests; tests replace it
+var resolver = net.DefaultResolver
+
+// settings are the parsed outbound configuration
+type settings struct {
+	resolver     *net.Resolver
+	proxy        *url.URL
+	denied       []netip.Prefix
+	maxRedirects int
+	rootCAs      *x509.CertPool // nil for the system roots
+	transport    *http.Transport
+}
+
+// current holds the settings applied by Configure
+var current atomic.Pointer[settings]
+
+func init() {
+	current.Store(newSettings(nil, privateNetworks, nil, defaultTimeout, defaultMaxRedirects))
+}
+
+// Configure applies the outbound section of the configuration to every
+// client built by this package, including those created before it is called
+func Configure(cfg *config.Config) error {
+	var proxy *url.URL
+	if cfg.Outbound.Proxy != "" {
+		u, err := url.Parse(cfg.Outbound.Proxy)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid outbound.proxy %q", cfg.Outbound.Proxy)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("outbound.proxy must be an http, https or socks5 URL")
+		}
+		proxy = u
+	}
+
+	var denied []netip.Prefix
+	if !cfg.Outbound.AllowPrivate {
+		denied = append(denied, privateNetworks...)
+	}
+	for _, network := range cfg.Outbound.DeniedNetworks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return fmt.Errorf("invalid network %q in outbound.denied_networks: %w", network, err)
+		}
+		denied = append(denied, prefix.Masked())
+	}
+
+	var rootCAs *x509.CertPool
+	if cfg.Outbound.CAFile != "" {
+		pem, err := os.ReadFile(cfg.Outbound.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read outbound.ca_file: %w", err)
+		}
+		if rootCAs, err = x509.SystemCertPool(); err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in outbound.ca_file %s", cfg.Outbound.CAFile)
+		}
+	}
+
+	timeout := defaultTimeout
+	if cfg.Outbound.Timeout > 0 {
+		timeout = time.Duration(cfg.Outbound.Timeout) * time.Second
+	}
+	maxRedirects := defaultMaxRedirects
+	if cfg.Outbound.MaxRedirects > 0 {
+		maxRedirects = cfg.Outbound.MaxRedirects
+	}
+
+	previous := current.Swap(newSettings(proxy, denied, rootCAs, timeout, maxRedirects))
+	previous.transport.CloseIdleConnections()
+	return nil
+}
+
+// newSettings builds the transport for a set of settings
+func newSettings(proxy *url.URL, denied []netip.Prefix, rootCAs *x509.CertPool, timeout time.Duration, maxRedirects int) *settings {
+	s := &settings{resolver: resolver, proxy: proxy, denied: denied, maxRedirects: maxRedirects, rootCAs: rootCAs}
+
+	guarded := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second, Resolver: s.resolver, Control: s.control}
+	direct := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second, Resolver: s.resolver}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		// The proxy itself is trusted, wherever it runs
+		if proxy != nil && addr == proxyAddr(proxy) {
+			return direct.DialContext(ctx, network, addr)
+		}
+		return guarded.DialContext(ctx, network, addr)
+	}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	} else {
+		transport.Proxy = nil
+	}
+	transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+	transport.TLSHandshakeTimeout = 10 * time.Second
+	transport.ResponseHeaderTimeout = timeout
+	transport.MaxResponseHeaderBytes = 64 << 10
+	s.transport = transport
+	return s
+}
+
+// proxyAddr returns the host:port the transport dials to reach a proxy
+func proxyAddr(proxy *url.URL) string {
+	if proxy.Port() != "" {
+		return proxy.Host
+	}
+	port := "1080"
+	switch proxy.Scheme {
+	case "http":
+		port = "80"
+	case "https":
+		port = "443"
+	}
+	return net.JoinHostPort(proxy.Hostname(), port)
+}
+
+// control runs after name resolution, right before a connection is made,
+// so a host cannot pass the check and then resolve somewhere else
+func (s *settings) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrDeniedAddress, host)
+	}
+	if s.isDenied(addr) {
+		return fmt.Errorf("%w: %s", ErrDeniedAddress, host)
+	}
+	return nil
+}
+
+// isDenied reports whether an address falls in a denied network
+func (s *settings) isDenied(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range s.denied {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHost resolves a host and fails if any of its addresses is denied.
+// Connections through a proxy are made by the proxy, so this is the only
+// check they get.
+func (s *settings) checkHost(ctx context.Context, host string) error {
+	if len(s.denied) == 0 {
+		return nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if s.isDenied(addr) {
+			return fmt.Errorf("%w: %s", ErrDeniedAddress, host)
+		}
+		return nil
+	}
+	addrs, err := s.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if s.isDenied(addr) {
+			return fmt.Errorf("%w: %s", ErrDeniedAddress, host)
+		}
+	}
+	return nil
+}
+
+// roundTripper sends requests through the current transport
+type roundTripper struct{}
+
+// RoundTrip implements http.RoundTripper
+func (roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	s := current.Load()
+	if s.proxy != nil {
+		if err := s.checkHost(req.Context(), req.URL.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+	return s.transport.RoundTrip(req)
+}
+
+// NewClient returns a client for requests to other servers that gives up
+// after timeout
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     roundTripper{},
+		CheckRedirect: checkRedirect,
+	}
+}
+
+// checkRedirect follows at most the configured number of redirects, and
+// never from https to plain http
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	// via holds the first request too, so it is one longer than the
+	// number of redirects followed
+	if maxRedirects := current.Load().maxRedirects; len(via) > maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if via[len(via)-1].URL.Scheme == "https" && req.URL.Scheme != "https" {
+		return fmt.Errorf("refusing redirect from https to %s", req.URL.Scheme)
+	}
+	return nil
+}
+
+// DialContext connects to addr, refusing denied addresses, for protocols
+// that do not go through an HTTP client, such as WebSockets
+func DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	s := current.Load()
+	dialer := &net.Dialer{Timeout: s.transport.ResponseHeaderTimeout, Resolver: s.resolver, Control: s.control}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// TLSConfig returns the TLS settings for a connection to serverName made
+// with DialContext, trusting the same certificates as the HTTP clients
+func TLSConfig(serverName string) *tls.Config {
+	return &tls.Config{ServerName: serverName, RootCAs: current.Load().rootCAs}
+}
@@ -0,0 +1,351 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeResolver answers A queries for hosts from a UDP DNS server on the
+// loopback interface and replaces the package resolver until the test ends
+func fakeResolver(t *testing.T, hosts map[string]string) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start DNS server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if reply, err := answer(buf[:n], hosts); err == nil {
+				conn.WriteTo(reply, from)
+			}
+		}
+	}()
+
+	previous := resolver
+	resolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", conn.LocalAddr().String())
+		},
+	}
+	t.Cleanup(func() {
+		resolver = previous
+		Configure(config.DefaultConfig())
+	})
+}
+
+// answer builds the reply to a DNS query from hosts, NXDOMAIN for others
+func answer(query []byte, hosts map[string]string) ([]byte, error) {
+	var p dnsmessage.Parser
+	header, err := p.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	question, err := p.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(question.Name.String(), ".")
+	ip, known := hosts[name]
+	header.Response = true
+	header.Authoritative = true
+	if !known {
+		header.RCode = dnsmessage.RCodeNameError
+	}
+
+	b := dnsmessage.NewBuilder(nil, header)
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(question); err != nil {
+		return nil, err
+	}
+	if err := b.StartAnswers(); err != nil {
+		return nil, err
+	}
+	if known && question.Type == dnsmessage.TypeA {
+		resource := dnsmessage.AResource{A: netip.MustParseAddr(ip).As4()}
+		rh := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60}
+		if err := b.AResource(rh, resource); err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish()
+}
+
+// configure applies an outbound configuration for the rest of the test
+func configure(t *testing.T, edit func(cfg *config.Config)) {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	edit(cfg)
+	if err := Configure(cfg); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	t.Cleanup(func() { Configure(config.DefaultConfig()) })
+}
+
+// serverPort returns the port an httptest server listens on
+func serverPort(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Port()
+}
+
+func TestIsDenied(t *testing.T) {
+	configure(t, func(cfg *config.Config) {
+		cfg.Outbound.DeniedNetworks = []string{"203.0.113.0/24", "2001:db8::1/32"}
+	})
+	s := current.Load()
+
+	tests := []struct {
+		addr   string
+		denied bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.20.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"224.0.0.1", true},
+		{"::1", true},
+		{"::", true},
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"203.0.113.7", true},
+		{"2001:db8:ffff::1", true},
+		{"93.184.216.34", false},
+		{"172.32.0.1", false},
+		{"2606:4700::1111", false},
+		{"::ffff:93.184.216.34", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := s.isDenied(netip.MustParseAddr(tt.addr)); got != tt.denied {
+				t.Errorf("isDenied(%s) = %v, want %v", tt.addr, got, tt.denied)
+			}
+		})
+	}
+}
+
+func TestIsDeniedAllowPrivate(t *testing.T) {
+	configure(t, func(cfg *config.Config) {
+		cfg.Outbound.AllowPrivate = true
+		cfg.Outbound.DeniedNetworks = []string{"10.9.0.0/16"}
+	})
+	s := current.Load()
+
+	if s.isDenied(netip.MustParseAddr("127.0.0.1")) {
+		t.Error("loopback is denied with allow_private")
+	}
+	if !s.isDenied(netip.MustParseAddr("10.9.1.1")) {
+		t.Error("denied_networks are not denied with allow_private")
+	}
+}
+
+func TestConfigureInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(cfg *config.Config)
+	}{
+		{"proxy without host", func(cfg *config.Config) { cfg.Outbound.Proxy = "http://" }},
+		{"proxy scheme", func(cfg *config.Config) { cfg.Outbound.Proxy = "ftp://proxy.example:21" }},
+		{"denied network", func(cfg *config.Config) { cfg.Outbound.DeniedNetworks = []string{"10.0.0.0"} }},
+		{"ca file", func(cfg *config.Config) { cfg.Outbound.CAFile = "/nonexistent/ca.pem" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			tt.edit(cfg)
+			if err := Configure(cfg); err == nil {
+				t.Error("Configure accepted an invalid outbound section")
+			}
+		})
+	}
+}
+
+// TestDialRechecksResolvedAddress checks that a public-looking host that
+// resolves to a denied address is refused when connecting
+func TestDialRechecksResolvedAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "internal")
+	}))
+	defer server.Close()
+	fakeResolver(t, map[string]string{"rebound.test": "127.0.0.1"})
+	target := "http://rebound.test:" + serverPort(t, server) + "/"
+
+	configure(t, func(cfg *config.Config) {})
+	_, err := NewClient(5 * time.Second).Get(target)
+	if !errors.Is(err, ErrDeniedAddress) {
+		t.Fatalf("Get = %v, want ErrDeniedAddress", err)
+	}
+
+	conn, err := DialContext(context.Background(), "tcp", "rebound.test:"+serverPort(t, server))
+	if err == nil {
+		conn.Close()
+	}
+	if !errors.Is(err, ErrDeniedAddress) {
+		t.Errorf("DialContext = %v, want ErrDeniedAddress", err)
+	}
+
+	// The same host is reachable once private addresses are allowed, so the
+	// refusal above came from the resolved address
+	configure(t, func(cfg *config.Config) { cfg.Outbound.AllowPrivate = true })
+	resp, err := NewClient(5 * time.Second).Get(target)
+	if err != nil {
+		t.Fatalf("Get with allow_private: %v", err)
+	}
+	resp.Body.Close()
+}
+
+// TestProxy checks that the proxy is reached even on a private address,
+// while the hosts requested through it are still checked
+func TestProxy(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		io.WriteString(w, "proxied "+r.URL.Host)
+	}))
+	defer proxy.Close()
+	fakeResolver(t, map[string]string{
+		"public.test":  "93.184.216.34",
+		"rebound.test": "10.0.0.1",
+	})
+	configure(t, func(cfg *config.Config) { cfg.Outbound.Proxy = proxy.URL })
+	client := NewClient(5 * time.Second)
+
+	resp, err := client.Get("http://public.test/")
+	if err != nil {
+		t.Fatalf("Get through proxy: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "proxied public.test" {
+		t.Errorf("body = %q, want the proxy's answer", body)
+	}
+
+	for _, target := range []string{"http://rebound.test/", "http://127.0.0.1/", "http://[::1]/", "http://unknown.test/"} {
+		if _, err := client.Get(target); err == nil {
+			t.Errorf("Get(%s) through proxy succeeded", target)
+		}
+	}
+	if n := proxied.Load(); n != 1 {
+		t.Errorf("proxy got %d requests, want only the allowed one", n)
+	}
+}
+
+func TestProxyAddr(t *testing.T) {
+	tests := []struct {
+		proxy string
+		want  string
+	}{
+		{"http://proxy.example", "proxy.example:80"},
+		{"https://proxy.example", "proxy.example:443"},
+		{"socks5://proxy.example", "proxy.example:1080"},
+		{"http://proxy.example:3128", "proxy.example:3128"},
+		{"http://[::1]", "[::1]:80"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proxy, func(t *testing.T) {
+			u, err := url.Parse(tt.proxy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := proxyAddr(u); got != tt.want {
+				t.Errorf("proxyAddr(%s) = %s, want %s", tt.proxy, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRedirect(t *testing.T) {
+	configure(t, func(cfg *config.Config) { cfg.Outbound.MaxRedirects = 2 })
+
+	request := func(target string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		via     []string
+		target  string
+		allowed bool
+	}{
+		{"http to https", []string{"http://a.example/"}, "https://b.example/", true},
+		{"https to https", []string{"https://a.example/"}, "https://b.example/", true},
+		{"http to http", []string{"http://a.example/"}, "http://b.example/", true},
+		{"https to http", []string{"https://a.example/"}, "http://b.example/", false},
+		{"at the limit", []string{"https://a.example/", "https://b.example/"}, "https://c.example/", true},
+		{"too many", []string{"https://a.example/", "https://b.example/", "https://c.example/"}, "https://d.example/", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var via []*http.Request
+			for _, previous := range tt.via {
+				via = append(via, request(previous))
+			}
+			err := checkRedirect(request(tt.target), via)
+			if (err == nil) != tt.allowed {
+				t.Errorf("checkRedirect = %v, want allowed %v", err, tt.allowed)
+			}
+		})
+	}
+}
+
+// TestRedirectLimit checks that a client stops following a redirect loop
+func TestRedirectLimit(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Redirect(w, r, "/again", http.StatusFound)
+	}))
+	defer server.Close()
+	configure(t, func(cfg *config.Config) {
+		cfg.Outbound.AllowPrivate = true
+		cfg.Outbound.MaxRedirects = 3
+	})
+
+	if _, err := NewClient(5 * time.Second).Get(server.URL); err == nil {
+		t.Fatal("Get followed a redirect loop")
+	}
+	if n := hits.Load(); n != 4 {
+		t.Errorf("server got %d requests, want the first and 3 redirects", n)
+	}
+}
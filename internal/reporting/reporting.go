@@ -0,0 +1,389 @@
+// Package reporting sends panics and errors worth an operator's attention
+// to a Sentry-compatible service (Sentry, GlitchTip, ...). It is off until
+// Configure is given a DSN, and then sends events in the background, so
+// capturing never blocks or fails the caller.
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+)
+
+const (
+	// queueSize is how many events may wait to be sent; more are dropped
+	queueSize = 64
+
+	// sendTimeout bounds each request to the service
+	sendTimeout = 10 * time.Second
+
+	// maxFrames is how many stack frames a panic is reported with
+	maxFrames = 64
+
+	// modulePrefix marks the frames of terminalpub's own code
+	modulePrefix = "github.com/fulgidus/terminalpub/"
+)
+
+// Tags describe where an event happened, e.g. screen, user and instance
+type Tags map[string]string
+
+// reporter sends events to the service named by a DSN
+type reporter struct {
+	endpoint    string // Envelope endpoint of the project
+	auth        string // X-Sentry-Auth header
+	dsn         string
+	environment string
+	serverName  string
+	salt        string // Keeps user hashes from being reversed by hashing every ID
+	apiFailures int
+	client      *http.Client
+	queue       chan *event
+	pending     sync.WaitGroup
+}
+
+// current is the reporter applied by Configure, nil while reporting is off
+var current atomic.Pointer[reporter]
+
+// Configure turns reporting on when the configuration has a DSN, or off
+func Configure(cfg *config.Config) error {
+	if cfg.Reporting.DSN == "" {
+		current.Store(nil)
+		return nil
+	}
+
+	endpoint, key, err := parseDSN(cfg.Reporting.DSN)
+	if err != nil {
+		return err
+	}
+	hostname, _ := os.Hostname()
+	r := &reporter{
+		endpoint:    endpoint,
+		auth:        "Sentry sentry_version=7, sentry_client=terminalpub, sentry_key=" + key,
+		dsn:         cfg.Reporting.DSN,
+		environment: cfg.Reporting.Environment,
+		serverName:  hostname,
+		salt:        cfg.Security.SecretKey,
+		apiFailures: max(cfg.Reporting.APIFailures, 1),
+		client:      &http.Client{Timeout: sendTimeout},
+		queue:       make(chan *event, queueSize),
+	}
+	go r.run()
+	current.Store(r)
+	return nil
+}
+
+// parseDSN returns the envelope endpoint and public key of a DSN such as
+// https://key@sentry.example/42
+func parseDSN(dsn string) (string, string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Host == "" || u.User == nil || u.User.Username() == "" {
+		return "", "", fmt.Errorf("invalid reporting.dsn: expected https://KEY@HOST/PROJECT")
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return "", "", fmt.Errorf("invalid reporting.dsn: unsupported scheme %q", u.Scheme)
+	}
+	path, project, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if project == "" {
+		// Most DSNs have no path besides the project
+		path, project = "", path
+	}
+	if _, err := strconv.Atoi(project); err != nil {
+		return "", "", fmt.Errorf("invalid reporting.dsn: project %q is not a number", project)
+	}
+	if path != "" {
+		path = "/" + path
+	}
+	return fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, path, project), u.User.Username(), nil
+}
+
+// Enabled reports whether events are sent anywhere
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// CapturePanic reports a recovered panic. Call it from the deferred function
+// that recovered, so the stack still shows where the panic happened.
+func CapturePanic(value any, tags Tags) {
+	r := current.Load()
+	if r == nil {
+		return
+	}
+	e := r.newEvent("fatal", tags)
+	e.Exception = &exceptions{Values: []exception{{
+		Type:       fmt.Sprintf("panic: %T", value),
+		Value:      fmt.Sprint(value),
+		Stacktrace: &stacktrace{Frames: callers(3)},
+		Mechanism:  &mechanism{Type: "panic", Handled: false},
+	}}}
+	r.enqueue(e)
+}
+
+// CaptureError reports an error
+func CaptureError(err error, tags Tags) {
+	r := current.Load()
+	if r == nil || err == nil {
+		return
+	}
+	e := r.newEvent("error", tags)
+	e.Exception = &exceptions{Values: []exception{{
+		Type:  errorType(err),
+		Value: err.Error(),
+	}}}
+	r.enqueue(e)
+}
+
+// apiFailures counts the failed Mastodon API calls in a row per instance
+var apiFailures = struct {
+	sync.Mutex
+	count map[string]int
+}{count: map[string]int{}}
+
+// APIFailure records a failed call to a Mastodon instance's API. An
+// instance failing reporting.api_failures times in a row is reported
+// once, until a call to it succeeds again.
+func APIFailure(instance string, err error) {
+	r := current.Load()
+	if r == nil {
+		return
+	}
+
+	apiFailures.Lock()
+	apiFailures.count[instance]++
+	count := apiFailures.count[instance]
+	apiFailures.Unlock()
+	if count != r.apiFailures {
+		return
+	}
+
+	e := r.newEvent("warning", Tags{"instance": instance, "kind": "mastodon_api"})
+	e.Exception = &exceptions{Values: []exception{{
+		Type:  "MastodonAPIFailure",
+		Value: fmt.Sprintf("%d calls in a row to %s failed; last: %v", count, instance, err),
+	}}}
+	e.Fingerprint = []string{"mastodon-api", instance}
+	r.enqueue(e)
+}
+
+// APISuccess records a call to a Mastodon instance's API that worked
+func APISuccess(instance string) {
+	if current.Load() == nil {
+		return
+	}
+	apiFailures.Lock()
+	delete(apiFailures.count, instance)
+	apiFailures.Unlock()
+}
+
+// UserHash identifies a user in reports without revealing who they are
+func UserHash(userID int) string {
+	salt := ""
+	if r := current.Load(); r != nil {
+		salt = r.salt
+	}
+	sum := sha256.Sum256([]byte(salt + ":" + strconv.Itoa(userID)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Flush waits up to timeout for queued events to be sent, e.g. before the
+// process exits, and reports whether they all were
+func Flush(timeout time.Duration) bool {
+	r := current.Load()
+	if r == nil {
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		r.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// enqueue hands an event to the sender, dropping it if too many are waiting
+func (r *reporter) enqueue(e *event) {
+	r.pending.Add(1)
+	select {
+	case r.queue <- e:
+	default:
+		r.pending.Done()
+		log.Printf("Error reporting: queue full, dropped event %s", e.EventID)
+	}
+}
+
+// run sends queued events one at a time
+func (r *reporter) run() {
+	for e := range r.queue {
+		if err := r.send(e); err != nil {
+			log.Printf("Error reporting: %v", err)
+		}
+		r.pending.Done()
+	}
+}
+
+// send posts an event to the service as an envelope
+func (r *reporter) send(e *event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	header, _ := json.Marshal(map[string]string{
+		"event_id": e.EventID,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339),
+		"dsn":      r.dsn,
+	})
+	item, _ := json.Marshal(map[string]any{"type": "event", "length": len(payload)})
+
+	var body bytes.Buffer
+	for _, line := range [][]byte{header, item, payload} {
+		body.Write(line)
+		body.WriteByte('\n')
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send event: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("event %s rejected with status %d", e.EventID, resp.StatusCode)
+	}
+	return nil
+}
+
+// event is a Sentry event, with the fields terminalpub fills in
+type event struct {
+	EventID     string      `json:"event_id"`
+	Timestamp   string      `json:"timestamp"`
+	Platform    string      `json:"platform"`
+	Level       string      `json:"level"`
+	Logger      string      `json:"logger"`
+	ServerName  string      `json:"server_name,omitempty"`
+	Environment string      `json:"environment,omitempty"`
+	Exception   *exceptions `json:"exception,omitempty"`
+	Tags        Tags        `json:"tags,omitempty"`
+	Fingerprint []string    `json:"fingerprint,omitempty"`
+}
+
+type exceptions struct {
+	Values []exception `json:"values"`
+}
+
+type exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *stacktrace `json:"stacktrace,omitempty"`
+	Mechanism  *mechanism  `json:"mechanism,omitempty"`
+}
+
+type mechanism struct {
+	Type    string `json:"type"`
+	Handled bool   `json:"handled"`
+}
+
+type stacktrace struct {
+	Frames []frame `json:"frames"` // Oldest call first
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// newEvent starts an event at level with the given tags
+func (r *reporter) newEvent(level string, tags Tags) *event {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return &event{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339Nano),
+		Platform:    "go",
+		Level:       level,
+		Logger:      "terminalpub",
+		ServerName:  r.serverName,
+		Environment: r.environment,
+		Tags:        tags,
+	}
+}
+
+// callers returns the stack of the calling goroutine, skipping skip frames
+func callers(skip int) []frame {
+	pcs := make([]uintptr, maxFrames)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []frame
+	for {
+		f, more := frames.Next()
+		module, function := splitFunction(f.Function)
+		stack = append(stack, frame{
+			Function: function,
+			Module:   module,
+			AbsPath:  f.File,
+			Lineno:   f.Line,
+			InApp:    strings.HasPrefix(f.Function, modulePrefix),
+		})
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(stack)-1; i < j; i, j = i+1, j-1 {
+		stack[i], stack[j] = stack[j], stack[i]
+	}
+	return stack
+}
+
+// splitFunction splits a qualified function name such as
+// github.com/a/b.(*T).M into its package and the rest
+func splitFunction(name string) (string, string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	return name[:slash+1+dot], name[slash+2+dot:]
+}
+
+// errorType names the innermost wrapped error's type, e.g. *url.Error
+func errorType(err error) string {
+	for {
+		inner := errors.Unwrap(err)
+		if inner == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = inner
+	}
+}
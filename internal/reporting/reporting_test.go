@@ -0,0 +1,124 @@
+package reporting
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+)
+
+func TestParseDSN(t *testing.T) {
+	tests := []struct {
+		dsn, endpoint, key string
+	}{
+		{"https://abc@o1.ingest.sentry.io/42", "https://o1.ingest.sentry.io/api/42/envelope/", "abc"},
+		{"http://abc@glitchtip.local:8000/sub/7", "http://glitchtip.local:8000/sub/api/7/envelope/", "abc"},
+	}
+	for _, tt := range tests {
+		endpoint, key, err := parseDSN(tt.dsn)
+		if err != nil || endpoint != tt.endpoint || key != tt.key {
+			t.Errorf("parseDSN(%q) = %q, %q, %v", tt.dsn, endpoint, key, err)
+		}
+	}
+
+	for _, dsn := range []string{"https://sentry.io/42", "ftp://abc@sentry.io/42", "https://abc@sentry.io/project"} {
+		if _, _, err := parseDSN(dsn); err == nil {
+			t.Errorf("expected %q to be rejected", dsn)
+		}
+	}
+}
+
+// receiver collects the events sent to a fake Sentry
+type receiver struct {
+	mu     sync.Mutex
+	auth   []string
+	events []event
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(nil, 1<<20)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	var e event
+	if len(lines) != 3 || json.Unmarshal([]byte(lines[2]), &e) != nil {
+		http.Error(w, "bad envelope", http.StatusBadRequest)
+		return
+	}
+	rc.mu.Lock()
+	rc.auth = append(rc.auth, r.Header.Get("X-Sentry-Auth"))
+	rc.events = append(rc.events, e)
+	rc.mu.Unlock()
+}
+
+func TestCapture(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Reporting.DSN = strings.Replace(server.URL, "http://", "http://key@", 1) + "/1"
+	cfg.Reporting.APIFailures = 3
+	if err := Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	defer Configure(config.DefaultConfig())
+
+	func() {
+		defer func() {
+			if value := recover(); value != nil {
+				CapturePanic(value, Tags{"screen": "Feed"})
+			}
+		}()
+		var statuses []string
+		_ = statuses[1]
+	}()
+	CaptureError(errors.New("inbox refused"), Tags{"kind": "federation_delivery"})
+	for range 2 {
+		APIFailure("example.social", errors.New("timeout"))
+	}
+	APISuccess("example.social")
+	for range 4 {
+		APIFailure("example.social", errors.New("timeout"))
+	}
+	if !Flush(5 * time.Second) {
+		t.Fatal("events not sent in time")
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if len(rc.events) != 3 {
+		t.Fatalf("expected a panic, an error and one API failure, got %+v", rc.events)
+	}
+	if !strings.Contains(rc.auth[0], "sentry_key=key") {
+		t.Errorf("unexpected auth header %q", rc.auth[0])
+	}
+
+	crash := rc.events[0]
+	frames := crash.Exception.Values[0].Stacktrace.Frames
+	last := frames[len(frames)-1]
+	if crash.Level != "fatal" || crash.Tags["screen"] != "Feed" || !strings.HasPrefix(last.Function, "TestCapture") || !last.InApp {
+		t.Errorf("expected the panic with the frame it happened in last, got %+v ending in %+v", crash, last)
+	}
+	if e := rc.events[1]; e.Exception.Values[0].Value != "inbox refused" || e.Tags["kind"] != "federation_delivery" {
+		t.Errorf("unexpected error event %+v", e)
+	}
+	if e := rc.events[2]; e.Tags["instance"] != "example.social" || !strings.HasPrefix(e.Exception.Values[0].Value, "3 calls") {
+		t.Errorf("unexpected API failure event %+v", e)
+	}
+}
+
+func TestUserHash(t *testing.T) {
+	if UserHash(1) == UserHash(2) || len(UserHash(1)) != 16 {
+		t.Errorf("unexpected hashes %q and %q", UserHash(1), UserHash(2))
+	}
+}
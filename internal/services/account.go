@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// AccountService handles account lifecycle operations
+type AccountService struct {
+	db       *pgxpool.Pool
+	presence *PresenceService
+	cfg      *config.Config
+}
+
+// NewAccountService creates a new AccountService instance. redisClient
+// carries the signal ending the connected sessions of deleted users; it may
+// be nil for a service that only delivers activities.
+func NewAccountService(db *pgxpool.Pool, redisClient redis.UniversalClient, cfg *config.Config) *AccountService {
+	s := &AccountService{db: db, cfg: cfg}
+	if redisClient != nil {
+		s.presence = NewPresenceService(db, redisClient)
+	}
+	return s
+}
+
+// DeleteAccount revokes all credentials of a user, ends their connected
+// sessions and marks the account deleted. The user row (and with it
+// posts and follows) is purged later by PurgeDeletedAccounts once the
+// retention window has passed.
+func (s *AccountService) DeleteAccount(ctx context.Context, userID int) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var username string
+	err = tx.QueryRow(ctx,
+		"UPDATE users SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING username",
+		userID,
+	).Scan(&username)
+	if err != nil {
+		return fmt.Errorf("account not found or already deleted: %w", err)
+	}
+
+	for _, query := range []string{
+		"DELETE FROM mastodon_tokens WHERE user_id = $1",
+		"DELETE FROM api_tokens WHERE user_id = $1",
+		"DELETE FROM user_ssh_keys WHERE user_id = $1",
+		"DELETE FROM cached_statuses WHERE user_id = $1",
+		"DELETE FROM read_markers WHERE user_id = $1",
+		"DELETE FROM user_actions WHERE user_id = $1",
+		"DELETE FROM sessions WHERE user_id = $1",
+	} {
+		if _, err := tx.Exec(ctx, query, userID); err != nil {
+			return fmt.Errorf("failed to revoke credentials: %w", err)
+		}
+	}
+
+	// Record the outbound Delete so it shows up in the activity log
+	activity := activitypub.NewDeleteActor(s.cfg.Server.BaseURL, username)
+	activityJSON, err := json.Marshal(activity)
+	if err != nil {
+		return fmt.Errorf("failed to encode delete activity: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO activities (user_id, activity_type, actor_id, object_id, activity_json, direction, processed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, userID, activity.Type, activity.Actor, activity.Actor, activityJSON, "outbound", false)
+	if err != nil {
+		return fmt.Errorf("failed to record delete activity: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit account deletion: %w", err)
+	}
+
+	// Connected sessions would otherwise stay signed in until they quit
+	if s.presence != nil {
+		if err := s.presence.EndSessions(ctx, userID); err != nil {
+			log.Printf("Failed to end sessions of deleted user %d: %v", userID, err)
+		}
+	}
+
+	return nil
+}
+
+// DeliverAccountDeletion sends the Delete activity for a deleted user to the
+// inboxes of their known followers, once per shared inbox
+func (s *AccountService) DeliverAccountDeletion(ctx context.Context, userID int) error {
+	var username, privateKey string
+	err := s.db.QueryRow(ctx,
+		"SELECT username, COALESCE(private_key, '') FROM users WHERE id = $1 AND deleted_at IS NOT NULL",
+		userID,
+	).Scan(&username, &privateKey)
+	if err != nil {
+		return fmt.Errorf("deleted account not found: %w", err)
+	}
+	if privateKey == "" {
+		// Without a key the remote servers would reject the activity anyway
+		return nil
+	}
+
+	activity := activitypub.NewDeleteActor(s.cfg.Server.BaseURL, username)
+	_, err = s.deliverToFollowers(ctx, userID, username, privateKey, activity)
+	return err
+}
+
+// ProfileUpdate holds the editable fields of a local profile
+type ProfileUpdate struct {
+	DisplayName string
+	Bio         string
+	AvatarURL   string
+}
+
+// UpdateProfile saves a user's profile and records the outbound Update activity
+func (s *AccountService) UpdateProfile(ctx context.Context, userID int, update ProfileUpdate) (*models.User, error) {
+	if len(update.DisplayName) > 100 {
+		return nil, fmt.Errorf("display name is too long (max 100 characters)")
+	}
+	if len(update.Bio) > 500 {
+		return nil, fmt.Errorf("bio is too long (max 500 characters)")
+	}
+	if update.AvatarURL != "" {
+		parsed, err := url.Parse(update.AvatarURL)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return nil, fmt.Errorf("avatar must be an http(s) URL")
+		}
+	}
+
+	_, err := s.db.Exec(ctx, `
+		UPDATE users
+		SET display_name = NULLIF($1, ''), bio = NULLIF($2, ''), avatar_url = NULLIF($3, ''), updated_at = NOW()
+		WHERE id = $4 AND deleted_at IS NULL
+	`, update.DisplayName, update.Bio, update.AvatarURL, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	user, err := NewUserService(s.db).GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := activitypub.NewUpdateActor(s.cfg.Server.BaseURL, user)
+	activityJSON, err := json.Marshal(activity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode update activity: %w", err)
+	}
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO activities (user_id, activity_type, actor_id, object_id, activity_json, direction, processed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, userID, activity.Type, activity.Actor, activity.Actor, activityJSON, "outbound", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record update activity: %w", err)
+	}
+
+	return user, nil
+}
+
+// DeliverProfileUpdate sends an Update activity with the current profile to the
+// inboxes of the user's known followers
+func (s *AccountService) DeliverProfileUpdate(ctx context.Context, userID int) error {
+	user, err := NewUserService(s.db).GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.PrivateKey == "" {
+		return nil
+	}
+
+	activity := activitypub.NewUpdateActor(s.cfg.Server.BaseURL, user)
+	_, err = s.deliverToFollowers(ctx, userID, user.Username, user.PrivateKey, activity)
+	return err
+}
+
+// deliverToFollowers signs and sends an activity to every follower inbox,
+// once per shared inbox; failures are logged and do not stop delivery
+func (s *AccountService) deliverToFollowers(ctx context.Context, userID int, username, privateKey string, activity any) (int, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT DISTINCT COALESCE(NULLIF(follower_shared_inbox, ''), follower_inbox)
+		FROM followers
+		WHERE user_id = $1 AND COALESCE(NULLIF(follower_shared_inbox, ''), follower_inbox) IS NOT NULL
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list follower inboxes: %w", err)
+	}
+	var inboxes []string
+	for rows.Next() {
+		var inbox string
+		if err := rows.Scan(&inbox); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan inbox: %w", err)
+		}
+		inboxes = append(inboxes, inbox)
+	}
+	rows.Close()
+
+	keyID := activitypub.ActorURL(s.cfg.Server.BaseURL, username) + "#main-key"
+
+	delivered := 0
+	webhooks := NewWebhookService(s.db, s.cfg)
+	for _, inbox := range inboxes {
+		if err := activitypub.Deliver(ctx, inbox, activity, privateKey, keyID, s.cfg.ActivityPub.UserAgent); err != nil {
+			log.Printf("Failed to deliver activity for user %d to %s: %v", userID, inbox, err)
+			webhooks.deliveryFailed(ctx, userID, activityType(activity), inbox, err)
+			continue
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+// activityType returns the type of an activity, whatever it is built as
+func activityType(activity any) string {
+	var typed struct {
+		Type string `json:"type"`
+	}
+	data, _ := json.Marshal(activity)
+	json.Unmarshal(data, &typed)
+	return typed.Type
+}
+
+// PurgeDeletedAccounts permanently removes accounts deleted more than
+// retention ago. Their chosen usernames are retired, so that nobody else can
+// take over the actor URLs remote servers still know.
+func (s *AccountService) PurgeDeletedAccounts(ctx context.Context, retention time.Duration) (int64, error) {
+	var purged int64
+	err := s.db.QueryRow(ctx, `
+		WITH purged AS (
+			DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < $1
+			RETURNING username, username_confirmed
+		), retired AS (
+			INSERT INTO retired_usernames (username)
+			SELECT username FROM purged WHERE username_confirmed
+			ON CONFLICT DO NOTHING
+		)
+		SELECT COUNT(*) FROM purged
+	`, time.Now().Add(-retention)).Scan(&purged)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted accounts: %w", err)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrActionNotUndoable is returned when an action is unknown or already undone
+var ErrActionNotUndoable = errors.New("action not found or already undone")
+
+// ActionLogService records actions performed through terminalpub so they can
+// be reviewed and undone later
+type ActionLogService struct {
+	db       *pgxpool.Pool
+	mastodon *MastodonService
+}
+
+// NewActionLogService creates a new ActionLogService instance
+func NewActionLogService(db *pgxpool.Pool, mastodon *MastodonService) *ActionLogService {
+	return &ActionLogService{db: db, mastodon: mastodon}
+}
+
+// Record adds an action to the user's log
+func (s *ActionLogService) Record(ctx context.Context, userID int, action models.ActionType, targetID, summary string) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO user_actions (user_id, action, target_id, summary)
+		VALUES ($1, $2, $3, $4)
+	`, userID, string(action), targetID, summary)
+	if err != nil {
+		return fmt.Errorf("failed to record action: %w", err)
+	}
+	return nil
+}
+
+// List returns the user's most recent actions, newest first
+func (s *ActionLogService) List(ctx context.Context, userID int, limit int) ([]models.UserAction, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, user_id, action, target_id, summary, created_at, undone_at
+		FROM user_actions
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list actions: %w", err)
+	}
+	defer rows.Close()
+
+	var actions []models.UserAction
+	for rows.Next() {
+		var action models.UserAction
+		if err := rows.Scan(&action.ID, &action.UserID, &action.Action, &action.TargetID,
+			&action.Summary, &action.CreatedAt, &action.UndoneAt); err != nil {
+			return nil, fmt.Errorf("failed to scan action: %w", err)
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, rows.Err()
+}
+
+// Undo reverts an action on the user's Mastodon account and marks it undone
+func (s *ActionLogService) Undo(ctx context.Context, userID, actionID int) error {
+	var action models.UserAction
+	err := s.db.QueryRow(ctx, `
+		SELECT action, target_id FROM user_actions
+		WHERE id = $1 AND user_id = $2 AND undone_at IS NULL
+	`, actionID, userID).Scan(&action.Action, &action.TargetID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrActionNotUndoable
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load action: %w", err)
+	}
+
+	switch action.Action {
+	case models.ActionLike:
+		err = s.mastodon.UnfavouriteStatus(ctx, userID, action.TargetID)
+	case models.ActionBoost:
+		err = s.mastodon.UnreblogStatus(ctx, userID, action.TargetID)
+	case models.ActionBookmark:
+		err = s.mastodon.UnbookmarkStatus(ctx, userID, action.TargetID)
+	case models.ActionFollow:
+		err = s.mastodon.UnfollowAccount(ctx, userID, action.TargetID)
+	case models.ActionMute:
+		err = s.mastodon.UnmuteAccount(ctx, userID, action.TargetID)
+	default:
+		return ErrActionNotUndoable
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(ctx, "UPDATE user_actions SET undone_at = NOW() WHERE id = $1", actionID)
+	if err != nil {
+		return fmt.Errorf("failed to mark action undone: %w", err)
+	}
+	return nil
+}
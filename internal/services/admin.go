@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// commandLineIP is the client address recorded in the audit log for
+// actions taken with "terminalpub admin"
+const commandLineIP = "command line"
+
+var (
+	// ErrInvalidDomain is returned when blocking something that is not a domain
+	ErrInvalidDomain = errors.New("not a domain name")
+	// ErrDomainNotBlocked is returned when unblocking a domain that is not blocked
+	ErrDomainNotBlocked = errors.New("domain is not blocked")
+)
+
+// AdminService runs the admin actions of the terminalpub command. Whoever
+// can run it can reach the database, so it checks no admin status; its
+// actions are recorded in the audit log without a user.
+type AdminService struct {
+	db         *pgxpool.Pool
+	accounts   *AccountService
+	moderation *ModerationService
+	tokens     *auth.APITokenService
+}
+
+// AdminUser is a local user as listed by "terminalpub admin user list"
+type AdminUser struct {
+	ID           int       `json:"id"`
+	Username     string    `json:"username"`
+	MastodonAcct string    `json:"mastodon_acct,omitempty"`
+	Admin        bool      `json:"admin"`
+	Suspended    bool      `json:"suspended"`
+	Silenced     bool      `json:"silenced"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// InstanceStats counts what the instance holds
+type InstanceStats struct {
+	Users           int `json:"users"`
+	Admins          int `json:"admins"`
+	SuspendedUsers  int `json:"suspended_users"`
+	Posts           int `json:"posts"`
+	Followers       int `json:"followers"`
+	Following       int `json:"following"`
+	RemoteActors    int `json:"remote_actors"`
+	PendingDelivery int `json:"pending_delivery"`
+	Quarantined     int `json:"quarantined"`
+	RejectedActors  int `json:"rejected_actors"`
+	BlockedDomains  int `json:"blocked_domains"`
+}
+
+// NewAdminService creates a new AdminService instance
+func NewAdminService(db *pgxpool.Pool, redisClient redis.UniversalClient, cfg *config.Config) *AdminService {
+	return &AdminService{
+		db:         db,
+		accounts:   NewAccountService(db, redisClient, cfg),
+		moderation: NewModerationService(db, redisClient, cfg),
+		tokens:     auth.NewAPITokenService(db),
+	}
+}
+
+// SetAdmin makes a local user an instance admin, or no longer one
+func (s *AdminService) SetAdmin(ctx context.Context, username string, admin bool) error {
+	username = normalizeUsername(username)
+	tag, err := s.db.Exec(ctx, `UPDATE users SET is_admin = $2 WHERE username = $1 AND deleted_at IS NULL`, username, admin)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	verb := "made %s an admin"
+	if !admin {
+		verb = "removed %s as admin"
+	}
+	s.record(ctx, verb, username)
+	return nil
+}
+
+// Users lists the local users that are not deleted, oldest first
+func (s *AdminService) Users(ctx context.Context) ([]AdminUser, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, username, COALESCE(primary_mastodon_acct, ''), is_admin,
+			suspended_at IS NOT NULL, silenced_at IS NOT NULL, created_at
+		FROM users
+		WHERE deleted_at IS NULL
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []AdminUser
+	for rows.Next() {
+		var user AdminUser
+		err := rows.Scan(&user.ID, &user.Username, &user.MastodonAcct, &user.Admin,
+			&user.Suspended, &user.Silenced, &user.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// Suspend locks a local user out and hides their account from other servers
+func (s *AdminService) Suspend(ctx context.Context, username string) error {
+	username = normalizeUsername(username)
+	if err := s.moderation.setSuspended(ctx, username, true); err != nil {
+		return err
+	}
+	s.record(ctx, "suspended %s", username)
+	return nil
+}
+
+// Unsuspend lifts a suspension
+func (s *AdminService) Unsuspend(ctx context.Context, username string) error {
+	username = normalizeUsername(username)
+	if err := s.moderation.setSuspended(ctx, username, false); err != nil {
+		return err
+	}
+	s.record(ctx, "unsuspended %s", username)
+	return nil
+}
+
+// DeleteUser deletes a local user's account as if they had deleted it
+// themselves and returns their ID. Followers are not told until
+// AccountService.DeliverAccountDeletion runs.
+func (s *AdminService) DeleteUser(ctx context.Context, username string) (int, error) {
+	userID, err := s.userID(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.accounts.DeleteAccount(ctx, userID); err != nil {
+		return 0, err
+	}
+	s.record(ctx, "deleted the account of %s", normalizeUsername(username))
+	return userID, nil
+}
+
+// Tokens lists a local user's API tokens
+func (s *AdminService) Tokens(ctx context.Context, username string) ([]models.APIToken, error) {
+	userID, err := s.userID(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return s.tokens.ListUserTokens(ctx, userID)
+}
+
+// RevokeToken deletes one of a local user's API tokens
+func (s *AdminService) RevokeToken(ctx context.Context, username string, tokenID int) error {
+	userID, err := s.userID(ctx, username)
+	if err != nil {
+		return err
+	}
+	if err := s.tokens.RevokeToken(ctx, userID, tokenID); err != nil {
+		return err
+	}
+	s.record(ctx, "revoked API token %d of %s", tokenID, normalizeUsername(username))
+	return nil
+}
+
+// BlockDomain refuses every actor on a remote server and its subdomains,
+// and returns the domain as stored
+func (s *AdminService) BlockDomain(ctx context.Context, domain, reason string) (string, error) {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return "", err
+	}
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO blocked_domains (domain, reason) VALUES ($1, $2)
+		ON CONFLICT (domain) DO UPDATE SET reason = $2
+	`, domain, strings.TrimSpace(reason))
+	if err != nil {
+		return "", fmt.Errorf("failed to block domain: %w", err)
+	}
+	s.record(ctx, "blocked domain %s", domain)
+	return domain, nil
+}
+
+// UnblockDomain accepts actors from a blocked domain again
+func (s *AdminService) UnblockDomain(ctx context.Context, domain string) error {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return err
+	}
+	tag, err := s.db.Exec(ctx, `DELETE FROM blocked_domains WHERE domain = $1`, domain)
+	if err != nil {
+		return fmt.Errorf("failed to unblock domain: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDomainNotBlocked
+	}
+	s.record(ctx, "unblocked domain %s", domain)
+	return nil
+}
+
+// BlockedDomains lists the blocked domains alphabetically
+func (s *AdminService) BlockedDomains(ctx context.Context) ([]models.BlockedDomain, error) {
+	rows, err := s.db.Query(ctx, `SELECT domain, reason, created_at FROM blocked_domains ORDER BY domain`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocked domains: %w", err)
+	}
+	defer rows.Close()
+
+	var domains []models.BlockedDomain
+	for rows.Next() {
+		var domain models.BlockedDomain
+		if err := rows.Scan(&domain.Domain, &domain.Reason, &domain.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan blocked domain: %w", err)
+		}
+		domains = append(domains, domain)
+	}
+	return domains, rows.Err()
+}
+
+// Stats counts the instance's users, posts, follows and federation backlog
+func (s *AdminService) Stats(ctx context.Context) (*InstanceStats, error) {
+	var stats InstanceStats
+	err := s.db.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM users WHERE deleted_at IS NULL),
+			(SELECT COUNT(*) FROM users WHERE deleted_at IS NULL AND is_admin),
+			(SELECT COUNT(*) FROM users WHERE deleted_at IS NULL AND suspended_at IS NOT NULL),
+			(SELECT COUNT(*) FROM posts WHERE deleted_at IS NULL),
+			(SELECT COUNT(*) FROM followers WHERE accepted),
+			(SELECT COUNT(*) FROM following WHERE accepted),
+			(SELECT COUNT(*) FROM remote_actors),
+			(SELECT COUNT(*) FROM activities WHERE direction = 'outbound' AND NOT processed),
+			(SELECT COUNT(*) FROM quarantined_activities),
+			(SELECT COUNT(*) FROM rejected_actors),
+			(SELECT COUNT(*) FROM blocked_domains)
+	`).Scan(&stats.Users, &stats.Admins, &stats.SuspendedUsers, &stats.Posts,
+		&stats.Followers, &stats.Following, &stats.RemoteActors, &stats.PendingDelivery,
+		&stats.Quarantined, &stats.RejectedActors, &stats.BlockedDomains)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count: %w", err)
+	}
+	return &stats, nil
+}
+
+// userID looks up a local user that is not deleted
+func (s *AdminService) userID(ctx context.Context, username string) (int, error) {
+	var id int
+	err := s.db.QueryRow(ctx, `SELECT id FROM users WHERE username = $1 AND deleted_at IS NULL`, normalizeUsername(username)).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrUserNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up user: %w", err)
+	}
+	return id, nil
+}
+
+// record adds an action to the audit log
+func (s *AdminService) record(ctx context.Context, format string, args ...any) {
+	recordAdminAction(auth.WithClientIP(ctx, commandLineIP), s.db, 0, format, args...)
+}
+
+// normalizeUsername accepts a username with or without its leading @
+func normalizeUsername(username string) string {
+	return strings.TrimPrefix(strings.TrimSpace(username), "@")
+}
+
+// normalizeDomain reduces a domain, or a URL on it, to its lowercase host name
+func normalizeDomain(domain string) (string, error) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if strings.Contains(domain, "://") {
+		u, err := url.Parse(domain)
+		if err != nil {
+			return "", ErrInvalidDomain
+		}
+		domain = u.Hostname()
+	}
+	domain = strings.TrimSuffix(domain, ".")
+	if domain == "" || strings.ContainsAny(domain, "/@:% ") || !strings.Contains(domain, ".") {
+		return "", ErrInvalidDomain
+	}
+	return domain, nil
+}
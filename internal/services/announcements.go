@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// MastodonAnnouncement is an announcement published by the admins of the
+// user's Mastodon instance
+type MastodonAnnouncement struct {
+	ID          string             `json:"id"`
+	Content     string             `json:"content"`
+	StartsAt    *time.Time         `json:"starts_at"`
+	EndsAt      *time.Time         `json:"ends_at"`
+	AllDay      bool               `json:"all_day"`
+	PublishedAt time.Time          `json:"published_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	Read        bool               `json:"read"`
+	Reactions   []MastodonReaction `json:"reactions"`
+}
+
+// GetAnnouncements fetches the current announcements of the user's
+// instance, including those already read
+func (s *MastodonService) GetAnnouncements(ctx context.Context, userID int) ([]MastodonAnnouncement, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var announcements []MastodonAnnouncement
+	apiURL := fmt.Sprintf("%s/api/v1/announcements?with_dismissed=true", instanceURL)
+	if err := s.doJSON(ctx, "GET", apiURL, accessToken, nil, &announcements); err != nil {
+		return nil, fmt.Errorf("failed to fetch announcements: %w", err)
+	}
+
+	return announcements, nil
+}
+
+// DismissAnnouncement marks an announcement as read
+func (s *MastodonService) DismissAnnouncement(ctx context.Context, userID int, announcementID string) error {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v1/announcements/%s/dismiss", instanceURL, announcementID)
+	if err := s.doJSON(ctx, "POST", apiURL, accessToken, nil, nil); err != nil {
+		return fmt.Errorf("failed to dismiss announcement: %w", err)
+	}
+
+	return nil
+}
+
+// ReactToAnnouncement adds an emoji reaction to an announcement, or
+// removes the user's reaction when remove is set
+func (s *MastodonService) ReactToAnnouncement(ctx context.Context, userID int, announcementID, emoji string, remove bool) error {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	method := "PUT"
+	if remove {
+		method = "DELETE"
+	}
+	apiURL := fmt.Sprintf("%s/api/v1/announcements/%s/reactions/%s", instanceURL, announcementID, url.PathEscape(emoji))
+	if err := s.doJSON(ctx, method, apiURL, accessToken, nil, nil); err != nil {
+		return fmt.Errorf("failed to react to announcement: %w", err)
+	}
+
+	return nil
+}
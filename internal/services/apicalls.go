@@ -0,0 +1,87 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiCallHistory is how many Mastodon API calls are remembered for the
+// debug overlay
+const apiCallHistory = 64
+
+// APICall is a Mastodon API call made on behalf of some user
+type APICall struct {
+	Host     string
+	Method   string
+	Endpoint string // Path with IDs and hashtags replaced, so calls reveal nobody's activity
+	Status   int    // 0 when no response came back
+	Duration time.Duration
+	At       time.Time
+}
+
+// apiCalls is a ring of the latest calls, the oldest overwritten first
+var apiCalls = struct {
+	sync.Mutex
+	calls [apiCallHistory]APICall
+	next  int
+	count int
+}{}
+
+// recordAPICall remembers a call that took elapsed, retries included
+func recordAPICall(req *http.Request, resp *http.Response, elapsed time.Duration) {
+	call := APICall{
+		Host:     req.URL.Host,
+		Method:   req.Method,
+		Endpoint: apiEndpoint(req.URL.Path),
+		Duration: elapsed,
+		At:       time.Now(),
+	}
+	if resp != nil {
+		call.Status = resp.StatusCode
+	}
+
+	apiCalls.Lock()
+	defer apiCalls.Unlock()
+	apiCalls.calls[apiCalls.next] = call
+	apiCalls.next = (apiCalls.next + 1) % apiCallHistory
+	apiCalls.count = min(apiCalls.count+1, apiCallHistory)
+}
+
+// RecentAPICalls returns up to limit of the latest calls to host, newest
+// first
+func RecentAPICalls(host string, limit int) []APICall {
+	apiCalls.Lock()
+	defer apiCalls.Unlock()
+
+	var calls []APICall
+	for i := 1; i <= apiCalls.count && len(calls) < limit; i++ {
+		call := apiCalls.calls[(apiCalls.next-i+apiCallHistory)%apiCallHistory]
+		if call.Host == host {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// apiEndpoint replaces the IDs and hashtags in an API path, e.g.
+// /api/v1/statuses/123 becomes /api/v1/statuses/:id
+func apiEndpoint(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch {
+		case segment == "":
+		case i > 0 && (segments[i-1] == "tag" || segments[i-1] == "tags"):
+			segments[i] = ":tag"
+		case strings.ContainsAny(segment, "0123456789") && !isAPIVersion(segment):
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isAPIVersion reports whether a path segment is an API version such as v1
+func isAPIVersion(segment string) bool {
+	return len(segment) >= 2 && segment[0] == 'v' && strings.Trim(segment[1:], "0123456789") == ""
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxAuditExport caps the events written by an audit log export
+const maxAuditExport = 10000
+
+// AuditService shows the audit log: users see their own events, admins
+// those of the whole instance
+type AuditService struct {
+	db *pgxpool.Pool
+}
+
+// NewAuditService creates a new AuditService instance
+func NewAuditService(db *pgxpool.Pool) *AuditService {
+	return &AuditService{db: db}
+}
+
+// CanViewAll reports whether a user may see the events of every user,
+// which only admins can
+func (s *AuditService) CanViewAll(ctx context.Context, userID int) (bool, error) {
+	err := requireAdmin(ctx, s.db, userID)
+	if errors.Is(err, ErrNotAdmin) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// List returns the most recent events, newest first: the user's own, or
+// with all set every user's, events without an account included
+func (s *AuditService) List(ctx context.Context, userID int, all bool, limit int) ([]models.AuditEvent, error) {
+	if all {
+		if err := requireAdmin(ctx, s.db, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT a.id, a.user_id, COALESCE(u.username, ''), a.event, a.detail, a.ip_address, a.created_at
+		FROM audit_log a
+		LEFT JOIN users u ON u.id = a.user_id
+		WHERE $1 OR a.user_id = $2
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT $3
+	`, all, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	for rows.Next() {
+		var event models.AuditEvent
+		if err := rows.Scan(&event.ID, &event.UserID, &event.Username, &event.Event,
+			&event.Detail, &event.IPAddress, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// WriteJSON writes the events List returns, up to maxAuditExport, as a
+// JSON array
+func (s *AuditService) WriteJSON(ctx context.Context, userID int, all bool, w io.Writer) error {
+	events, err := s.List(ctx, userID, all, maxAuditExport)
+	if err != nil {
+		return err
+	}
+	if events == nil {
+		events = []models.AuditEvent{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(events)
+}
+
+// recordAdminAction adds an action an admin took to the audit log
+func recordAdminAction(ctx context.Context, db *pgxpool.Pool, adminID int, format string, args ...any) {
+	auth.NewAuditLog(db).Record(ctx, adminID, models.AuditAdminAction, fmt.Sprintf(format, args...))
+}
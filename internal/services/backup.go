@@ -0,0 +1,431 @@
+package services
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/version"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// backupManifest is the name of the manifest inside a backup archive
+	backupManifest = "manifest.json"
+
+	// backupTablesDir and backupHostKeysDir hold table rows, one JSON
+	// object per line, and host key files inside a backup archive
+	backupTablesDir   = "tables/"
+	backupHostKeysDir = "host_keys/"
+
+	// SectionHostKeys is the section of a backup holding the SSH host keys
+	SectionHostKeys = "host_keys"
+)
+
+// ErrBackupSchema is returned when restoring a backup into a database at a
+// different migration than the one it was taken from
+var ErrBackupSchema = errors.New("the backup was taken at a different migration; migrate the database to the backup's version first")
+
+// backupTable is a table saved in backups. Columns in secrets are
+// encrypted with the instance's secret key, so the archive alone does not
+// give away private keys or access tokens; columns in omit refer to data
+// the backup leaves out.
+type backupTable struct {
+	section string
+	name    string
+	serial  bool
+	secrets []string
+	omit    []string
+}
+
+// backupTables lists the backed up tables, parents before the tables that
+// reference them
+var backupTables = []backupTable{
+	{section: "users", name: "users", serial: true, secrets: []string{"private_key", "proof_private_key"}, omit: []string{"invite_id"}},
+	{section: "users", name: "user_ssh_keys", serial: true},
+	{section: "keys", name: "instance_actor", secrets: []string{"private_key"}},
+	{section: "keys", name: "vapid_keys", secrets: []string{"private_key"}},
+	{section: "tokens", name: "mastodon_tokens", serial: true, secrets: []string{"access_token", "refresh_token"}},
+	{section: "tokens", name: "api_tokens", serial: true},
+	{section: "posts", name: "posts", serial: true},
+	{section: "posts", name: "post_tags"},
+	{section: "posts", name: "post_mentions"},
+	{section: "follows", name: "followers", serial: true},
+	{section: "follows", name: "following", serial: true},
+}
+
+// BackupSections lists the parts of a backup that can be restored on their own
+func BackupSections() []string {
+	var sections []string
+	for _, table := range backupTables {
+		if !slices.Contains(sections, table.section) {
+			sections = append(sections, table.section)
+		}
+	}
+	return append(sections, SectionHostKeys)
+}
+
+// BackupManifest describes a backup archive
+type BackupManifest struct {
+	Version   string         `json:"version"`   // terminalpub version that wrote it
+	Migration uint           `json:"migration"` // schema version the rows belong to
+	CreatedAt time.Time      `json:"created_at"`
+	Tables    map[string]int `json:"tables"` // rows per table
+	HostKeys  []string       `json:"host_keys"`
+}
+
+// RestoreOptions selects what Restore puts back
+type RestoreOptions struct {
+	Sections []string // empty for every section
+	DryRun   bool     // roll back instead of committing
+}
+
+// RestoredTable reports the rows of one table Restore inserted, and those it
+// skipped because a row with the same key was already there
+type RestoredTable struct {
+	Name     string
+	Restored int
+	Skipped  int
+}
+
+// RestoreResult reports what Restore did, or would have done in a dry run.
+// Host keys are returned rather than written, since where they go is up to
+// the caller.
+type RestoreResult struct {
+	Manifest BackupManifest
+	Tables   []RestoredTable
+	HostKeys map[string][]byte
+}
+
+// BackupService writes and restores instance backups: users with their
+// keys, linked Mastodon tokens, posts and follows, and the SSH host keys
+type BackupService struct {
+	db        *pgxpool.Pool
+	secretKey string
+}
+
+// NewBackupService creates a new BackupService instance
+func NewBackupService(db *pgxpool.Pool, cfg *config.Config) *BackupService {
+	return &BackupService{db: db, secretKey: cfg.Security.SecretKey}
+}
+
+// Backup writes a gzipped tar archive to w. The rows come from one
+// read-only snapshot, so they are consistent with each other however busy
+// the instance is; hostKeys are the paths of the host key files to include.
+func (s *BackupService) Backup(ctx context.Context, w io.Writer, hostKeys []string) (*BackupManifest, error) {
+	if s.secretKey == "" {
+		return nil, ErrNoSecretKey
+	}
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin snapshot: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	manifest := &BackupManifest{Version: version.String(), CreatedAt: time.Now().UTC(), Tables: map[string]int{}}
+	if manifest.Migration, err = schemaVersion(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string][]byte, len(backupTables))
+	for _, table := range backupTables {
+		data, count, err := s.dumpTable(ctx, tx, table)
+		if err != nil {
+			return nil, err
+		}
+		tables[table.name] = data
+		manifest.Tables[table.name] = count
+	}
+
+	keys := make(map[string][]byte, len(hostKeys))
+	for _, keyPath := range hostKeys {
+		data, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read host key: %w", err)
+		}
+		sealed, err := sealSecret(s.secretKey, string(data))
+		if err != nil {
+			return nil, err
+		}
+		name := filepath.Base(keyPath)
+		keys[name] = []byte(sealed)
+		manifest.HostKeys = append(manifest.HostKeys, name)
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	write := func(name string, data []byte) error {
+		header := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: manifest.CreatedAt}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+		return nil
+	}
+
+	// The manifest comes first so Restore can check it before any rows
+	if err := write(backupManifest, manifestJSON); err != nil {
+		return nil, err
+	}
+	for _, table := range backupTables {
+		if err := write(backupTablesDir+table.name+".jsonl", tables[table.name]); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range manifest.HostKeys {
+		if err := write(backupHostKeysDir+name, keys[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return manifest, nil
+}
+
+// dumpTable returns the rows of a table as JSON lines, with its secret
+// columns sealed
+func (s *BackupService) dumpTable(ctx context.Context, tx pgx.Tx, table backupTable) ([]byte, int, error) {
+	query := `SELECT row_to_json(t)::text FROM ` + table.name + ` t`
+	if table.serial {
+		query += ` ORDER BY id`
+	}
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", table.name, err)
+	}
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	count := 0
+	for rows.Next() {
+		var rowJSON string
+		if err := rows.Scan(&rowJSON); err != nil {
+			return nil, 0, fmt.Errorf("failed to read %s: %w", table.name, err)
+		}
+		var row map[string]any
+		if err := json.Unmarshal([]byte(rowJSON), &row); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode %s row: %w", table.name, err)
+		}
+		for _, column := range table.omit {
+			delete(row, column)
+		}
+		for _, column := range table.secrets {
+			if value, ok := row[column].(string); ok {
+				if row[column], err = sealSecret(s.secretKey, value); err != nil {
+					return nil, 0, err
+				}
+			}
+		}
+		line, err := json.Marshal(row)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to encode %s row: %w", table.name, err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", table.name, err)
+	}
+	return buf.Bytes(), count, nil
+}
+
+// Restore reads a backup archive from r and inserts its rows in one
+// transaction. Rows whose key is already taken are kept as they are, so a
+// restore can fill in what is missing from a live database as well as
+// refill an empty one; the database must be at the backup's migration.
+func (s *BackupService) Restore(ctx context.Context, r io.Reader, opts RestoreOptions) (*RestoreResult, error) {
+	if s.secretKey == "" {
+		return nil, ErrNoSecretKey
+	}
+	for _, section := range opts.Sections {
+		if !slices.Contains(BackupSections(), section) {
+			return nil, fmt.Errorf("unknown section %q; the sections are %s", section, strings.Join(BackupSections(), ", "))
+		}
+	}
+	wanted := func(section string) bool {
+		return len(opts.Sections) == 0 || slices.Contains(opts.Sections, section)
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	header, err := tr.Next()
+	if err != nil || header.Name != backupManifest {
+		return nil, fmt.Errorf("not a backup archive: %s is missing", backupManifest)
+	}
+	result := &RestoreResult{HostKeys: map[string][]byte{}}
+	if err := json.NewDecoder(tr).Decode(&result.Manifest); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", backupManifest, err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := schemaVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if current != result.Manifest.Migration {
+		return nil, fmt.Errorf("%w (backup at %d, database at %d)", ErrBackupSchema, result.Manifest.Migration, current)
+	}
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		switch {
+		case strings.HasPrefix(header.Name, backupTablesDir):
+			name := strings.TrimSuffix(strings.TrimPrefix(header.Name, backupTablesDir), ".jsonl")
+			i := slices.IndexFunc(backupTables, func(t backupTable) bool { return t.name == name })
+			if i < 0 {
+				return nil, fmt.Errorf("unexpected table %s in backup", name)
+			}
+			if !wanted(backupTables[i].section) {
+				continue
+			}
+			restored, err := s.restoreTable(ctx, tx, backupTables[i], tr)
+			if err != nil {
+				return nil, err
+			}
+			result.Tables = append(result.Tables, *restored)
+		case strings.HasPrefix(header.Name, backupHostKeysDir):
+			if !wanted(SectionHostKeys) {
+				continue
+			}
+			sealed, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read archive: %w", err)
+			}
+			key, err := openSecret(s.secretKey, string(sealed))
+			if err != nil {
+				return nil, err
+			}
+			result.HostKeys[path.Base(header.Name)] = []byte(key)
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit restore: %w", err)
+	}
+	return result, nil
+}
+
+// restoreTable inserts the JSON lines read from r into a table
+func (s *BackupService) restoreTable(ctx context.Context, tx pgx.Tx, table backupTable, r io.Reader) (*RestoredTable, error) {
+	// Generated columns, like the search vector of posts, are computed again
+	rows, err := tx.Query(ctx, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1 AND is_generated = 'NEVER'
+		ORDER BY ordinal_position
+	`, table.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the columns of %s: %w", table.name, err)
+	}
+	columns, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the columns of %s: %w", table.name, err)
+	}
+	columns = slices.DeleteFunc(columns, func(column string) bool { return slices.Contains(table.omit, column) })
+	list := strings.Join(columns, ", ")
+	insert := `INSERT INTO ` + table.name + ` (` + list + `) SELECT ` + list +
+		` FROM json_populate_record(NULL::` + table.name + `, $1::json) ON CONFLICT DO NOTHING`
+
+	restored := &RestoredTable{Name: table.name}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var row map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			return nil, fmt.Errorf("failed to decode %s row: %w", table.name, err)
+		}
+		for _, column := range table.secrets {
+			if sealed, ok := row[column].(string); ok {
+				if row[column], err = openSecret(s.secretKey, sealed); err != nil {
+					return nil, err
+				}
+			}
+		}
+		rowJSON, err := json.Marshal(row)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s row: %w", table.name, err)
+		}
+
+		tag, err := tx.Exec(ctx, insert, string(rowJSON))
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", table.name, err)
+		}
+		if tag.RowsAffected() == 0 {
+			restored.Skipped++
+		} else {
+			restored.Restored++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s from archive: %w", table.name, err)
+	}
+
+	// New rows must not be handed IDs the restored ones already use
+	if table.serial && restored.Restored > 0 {
+		_, err := tx.Exec(ctx, `SELECT setval(pg_get_serial_sequence($1, 'id'), MAX(id)) FROM `+table.name, table.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance the IDs of %s: %w", table.name, err)
+		}
+	}
+	return restored, nil
+}
+
+// schemaVersion returns the migration the database is at
+func schemaVersion(ctx context.Context, tx pgx.Tx) (uint, error) {
+	var current uint
+	var dirty bool
+	if err := tx.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations`).Scan(&current, &dirty); err != nil {
+		return 0, fmt.Errorf("failed to read the schema version: %w", err)
+	}
+	if dirty {
+		return 0, fmt.Errorf("migration %d failed halfway; fix it before backing up or restoring", current)
+	}
+	return current, nil
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/outbound"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// BlueskyTarget is the cross-post target of the user's Bluesky account
+	BlueskyTarget = "bluesky"
+
+	// defaultBlueskyService is the PDS accounts live on unless another is given
+	defaultBlueskyService = "https://bsky.social"
+
+	// maxBlueskyPost is the length limit of a Bluesky post. Bluesky counts
+	// graphemes; counting runes never lets a longer post through.
+	maxBlueskyPost = 300
+)
+
+// ErrBlueskyDisabled is returned when the instance does not offer Bluesky cross-posting
+var ErrBlueskyDisabled = errors.New("Bluesky cross-posting is not enabled on this instance")
+
+// blueskyClient talks to Bluesky servers
+var blueskyClient = outbound.NewClient(15 * time.Second)
+
+var (
+	// blueskyLink finds the links a post needs facets for, since Bluesky
+	// does not turn URLs into links by itself
+	blueskyLink = regexp.MustCompile(`https?://[^\s<>"]+`)
+	// blueskyTag finds hashtags, which need facets too
+	blueskyTag = regexp.MustCompile(`(?:^|\s)#([\pL\pN_]+)`)
+)
+
+// BlueskyService links Bluesky accounts and cross-posts to them over the AT
+// Protocol, signing in with an app password for every post
+type BlueskyService struct {
+	db  *pgxpool.Pool
+	cfg *config.Config
+}
+
+// NewBlueskyService creates a new BlueskyService instance
+func NewBlueskyService(db *pgxpool.Pool, cfg *config.Config) *BlueskyService {
+	return &BlueskyService{db: db, cfg: cfg}
+}
+
+// Enabled reports whether the instance offers Bluesky cross-posting
+func (s *BlueskyService) Enabled() bool {
+	return s.cfg.Features.Bluesky.Enabled
+}
+
+// Account returns the user's linked Bluesky account, or nil if there is none
+func (s *BlueskyService) Account(ctx context.Context, userID int) (*models.BlueskyAccount, error) {
+	account := &models.BlueskyAccount{UserID: userID}
+	err := s.db.QueryRow(ctx, `
+		SELECT service, handle, did, crosspost, created_at FROM bluesky_accounts WHERE user_id = $1
+	`, userID).Scan(&account.Service, &account.Handle, &account.DID, &account.CrossPost, &account.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Bluesky account: %w", err)
+	}
+	return account, nil
+}
+
+// Link signs in to Bluesky with a handle and an app password and, once that
+// works, stores them for cross-posting. service is the PDS of the account;
+// empty means bsky.social.
+func (s *BlueskyService) Link(ctx context.Context, userID int, identifier, appPassword, service string) (*models.BlueskyAccount, error) {
+	if !s.Enabled() {
+		return nil, ErrBlueskyDisabled
+	}
+	identifier = strings.TrimPrefix(strings.TrimSpace(identifier), "@")
+	appPassword = strings.TrimSpace(appPassword)
+	if identifier == "" || appPassword == "" {
+		return nil, fmt.Errorf("enter your handle and an app password")
+	}
+	service, err := normalizeBlueskyService(service)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.createSession(ctx, service, identifier, appPassword)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := sealSecret(s.cfg.Security.SecretKey, appPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &models.BlueskyAccount{UserID: userID, Service: service, Handle: session.Handle, DID: session.DID, CrossPost: true}
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO bluesky_accounts (user_id, service, handle, did, app_password)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE
+		SET service = $2, handle = $3, did = $4, app_password = $5, updated_at = NOW()
+		RETURNING crosspost, created_at
+	`, userID, service, session.Handle, session.DID, sealed).Scan(&account.CrossPost, &account.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save Bluesky account: %w", err)
+	}
+	return account, nil
+}
+
+// Unlink forgets the user's Bluesky account
+func (s *BlueskyService) Unlink(ctx context.Context, userID int) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM bluesky_accounts WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to unlink Bluesky account: %w", err)
+	}
+	return nil
+}
+
+// SetCrossPost sets whether new posts go to Bluesky unless unchecked
+func (s *BlueskyService) SetCrossPost(ctx context.Context, userID int, crossPost bool) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE bluesky_accounts SET crosspost = $2, updated_at = NOW() WHERE user_id = $1
+	`, userID, crossPost)
+	if err != nil {
+		return fmt.Errorf("failed to update Bluesky account: %w", err)
+	}
+	return nil
+}
+
+// Post publishes a post to the user's Bluesky account and returns its URL
+// on bsky.app. Text over Bluesky's limit is cut at a word and ends with
+// link, the full post elsewhere, when there is one.
+func (s *BlueskyService) Post(ctx context.Context, userID int, text, contentWarning, link string) (string, error) {
+	if !s.Enabled() {
+		return "", ErrBlueskyDisabled
+	}
+	var service, handle, sealed string
+	err := s.db.QueryRow(ctx, `
+		SELECT service, handle, app_password FROM bluesky_accounts WHERE user_id = $1
+	`, userID).Scan(&service, &handle, &sealed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("no Bluesky account linked")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load Bluesky account: %w", err)
+	}
+	appPassword, err := openSecret(s.cfg.Security.SecretKey, sealed)
+	if err != nil {
+		return "", err
+	}
+
+	session, err := s.createSession(ctx, service, handle, appPassword)
+	if err != nil {
+		return "", err
+	}
+
+	if contentWarning != "" {
+		// Bluesky has no content warnings, so the post starts with it
+		text = "CW: " + contentWarning + "\n\n" + text
+	}
+	text = fitBlueskyPost(strings.TrimSpace(text), link)
+	record := map[string]any{
+		"$type":     "app.bsky.feed.post",
+		"text":      text,
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+	}
+	if facets := blueskyFacets(text); len(facets) > 0 {
+		record["facets"] = facets
+	}
+
+	var created struct {
+		URI string `json:"uri"`
+	}
+	err = xrpc(ctx, service, "com.atproto.repo.createRecord", session.AccessJwt, map[string]any{
+		"repo":       session.DID,
+		"collection": "app.bsky.feed.post",
+		"record":     record,
+	}, &created)
+	if err != nil {
+		return "", err
+	}
+
+	// at://did/app.bsky.feed.post/rkey is shown at bsky.app/profile/handle/post/rkey
+	rkey := created.URI[strings.LastIndex(created.URI, "/")+1:]
+	return "https://bsky.app/profile/" + session.Handle + "/post/" + rkey, nil
+}
+
+// blueskySession is a signed-in session on a PDS
+type blueskySession struct {
+	AccessJwt string `json:"accessJwt"`
+	Handle    string `json:"handle"`
+	DID       string `json:"did"`
+}
+
+// createSession signs in to a PDS
+func (s *BlueskyService) createSession(ctx context.Context, service, identifier, appPassword string) (*blueskySession, error) {
+	var session blueskySession
+	err := xrpc(ctx, service, "com.atproto.server.createSession", "", map[string]string{
+		"identifier": identifier,
+		"password":   appPassword,
+	}, &session)
+	if err != nil {
+		return nil, fmt.Errorf("Bluesky sign-in failed: %w", err)
+	}
+	return &session, nil
+}
+
+// xrpc calls an XRPC procedure of a PDS, decoding the response into out
+func xrpc(ctx context.Context, service, method, accessJwt string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, service+"/xrpc/"+method, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if accessJwt != "" {
+		req.Header.Set("Authorization", "Bearer "+accessJwt)
+	}
+
+	resp, err := blueskyClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var xrpcErr struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(respBody, &xrpcErr) == nil && xrpcErr.Message != "" {
+			return fmt.Errorf("%s", xrpcErr.Message)
+		}
+		return fmt.Errorf("Bluesky error %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// normalizeBlueskyService checks the URL of a PDS, defaulting to bsky.social
+func normalizeBlueskyService(service string) (string, error) {
+	service = strings.TrimRight(strings.TrimSpace(service), "/")
+	if service == "" {
+		return defaultBlueskyService, nil
+	}
+	if !strings.Contains(service, "://") {
+		service = "https://" + service
+	}
+	parsed, err := url.Parse(service)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return "", fmt.Errorf("server must be an https URL")
+	}
+	return service, nil
+}
+
+// fitBlueskyPost shortens text to Bluesky's limit, cutting at a word and
+// ending with "…" and link when given
+func fitBlueskyPost(text, link string) string {
+	if utf8.RuneCountInString(text) <= maxBlueskyPost {
+		return text
+	}
+	suffix := "…"
+	if link != "" {
+		suffix += " " + link
+	}
+
+	runes := []rune(text)
+	cut := max(maxBlueskyPost-utf8.RuneCountInString(suffix), 0)
+	// Back up to the start of the word cut, unless the word is very long
+	for i := cut; i > 0 && cut-i < 40; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace) + suffix
+}
+
+// blueskyFacets returns the rich text facets turning the links and hashtags
+// of a post into links; their positions are UTF-8 byte offsets
+func blueskyFacets(text string) []map[string]any {
+	var facets []map[string]any
+	for _, loc := range blueskyLink.FindAllStringIndex(text, -1) {
+		end := loc[0] + len(strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?)"))
+		facets = append(facets, map[string]any{
+			"index": map[string]int{"byteStart": loc[0], "byteEnd": end},
+			"features": []map[string]string{
+				{"$type": "app.bsky.richtext.facet#link", "uri": text[loc[0]:end]},
+			},
+		})
+	}
+	for _, loc := range blueskyTag.FindAllStringSubmatchIndex(text, -1) {
+		// The facet covers the #, just before the captured name
+		facets = append(facets, map[string]any{
+			"index": map[string]int{"byteStart": loc[2] - 1, "byteEnd": loc[3]},
+			"features": []map[string]string{
+				{"$type": "app.bsky.richtext.facet#tag", "tag": text[loc[2]:loc[3]]},
+			},
+		})
+	}
+	return facets
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// MaxChatMessageLength caps the characters of a chat message
+	MaxChatMessageLength = 500
+
+	// redisChatPrefix is the prefix of the pub/sub channel of each room
+	redisChatPrefix = "chat:"
+)
+
+var (
+	// ErrInvalidRoom is returned for room names that are not a valid hashtag
+	ErrInvalidRoom = errors.New("room names use letters, digits and underscores, up to 32")
+	// ErrChatBanned is returned when a banned user speaks in a room
+	ErrChatBanned = errors.New("you are banned from this room")
+	// ErrChatMessageNotFound is returned when deleting an unknown message
+	ErrChatMessageNotFound = errors.New("message not found")
+	// ErrChatUserNotFound is returned when moderating an unknown user
+	ErrChatUserNotFound = errors.New("no local user with that name")
+)
+
+// roomPattern matches room names, which double as hashtags
+var roomPattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// ChatService handles the instance's public chat rooms. Messages are kept
+// in Postgres and fanned out to connected sessions through Redis pub/sub.
+type ChatService struct {
+	db    *pgxpool.Pool
+	redis redis.UniversalClient
+}
+
+// NewChatService creates a new ChatService instance
+func NewChatService(db *pgxpool.Pool, redisClient redis.UniversalClient) *ChatService {
+	return &ChatService{db: db, redis: redisClient}
+}
+
+// NormalizeRoom turns "#Linux" into the room name "linux"
+func NormalizeRoom(name string) (string, error) {
+	name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+	if !roomPattern.MatchString(name) {
+		return "", ErrInvalidRoom
+	}
+	return name, nil
+}
+
+// Room returns a room, creating it the first time someone joins it
+func (s *ChatService) Room(ctx context.Context, name string) (*models.ChatRoom, error) {
+	name, err := NormalizeRoom(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var room models.ChatRoom
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO chat_rooms (name) VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id, name, topic, created_at
+	`, name).Scan(&room.ID, &room.Name, &room.Topic, &room.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open room: %w", err)
+	}
+	return &room, nil
+}
+
+// Rooms lists the rooms, most recently active first
+func (s *ChatService) Rooms(ctx context.Context) ([]models.ChatRoom, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT r.id, r.name, r.topic, r.created_at
+		FROM chat_rooms r
+		LEFT JOIN LATERAL (SELECT MAX(created_at) AS last FROM chat_messages WHERE room_id = r.id) m ON TRUE
+		ORDER BY COALESCE(m.last, r.created_at) DESC
+		LIMIT 50
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rooms: %w", err)
+	}
+	defer rows.Close()
+
+	var rooms []models.ChatRoom
+	for rows.Next() {
+		var room models.ChatRoom
+		if err := rows.Scan(&room.ID, &room.Name, &room.Topic, &room.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan room: %w", err)
+		}
+		rooms = append(rooms, room)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list rooms: %w", err)
+	}
+	return rooms, nil
+}
+
+// History returns the latest messages of a room, oldest first
+func (s *ChatService) History(ctx context.Context, roomID, limit int) ([]models.ChatMessage, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, room_id, user_id, username, body, created_at FROM (
+			SELECT id, room_id, user_id, username, body, created_at
+			FROM chat_messages
+			WHERE room_id = $1
+			ORDER BY id DESC
+			LIMIT $2
+		) latest
+		ORDER BY id
+	`, roomID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []models.ChatMessage
+	for rows.Next() {
+		var message models.ChatMessage
+		if err := rows.Scan(&message.ID, &message.RoomID, &message.UserID, &message.Username, &message.Body, &message.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load messages: %w", err)
+	}
+	return messages, nil
+}
+
+// Send says something in a room on behalf of a user
+func (s *ChatService) Send(ctx context.Context, user *models.User, roomName, body string) (*models.ChatMessage, error) {
+	body = strings.TrimSpace(body)
+	switch {
+	case body == "":
+		return nil, fmt.Errorf("message is empty")
+	case utf8.RuneCountInString(body) > MaxChatMessageLength:
+		return nil, fmt.Errorf("messages are limited to %d characters", MaxChatMessageLength)
+	}
+
+	room, err := s.Room(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+
+	var banned bool
+	err = s.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM chat_bans
+			WHERE room_id = $1 AND user_id = $2 AND (expires_at IS NULL OR expires_at > NOW())
+		)
+	`, room.ID, user.ID).Scan(&banned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bans: %w", err)
+	}
+	if banned {
+		return nil, ErrChatBanned
+	}
+
+	message := &models.ChatMessage{RoomID: room.ID, UserID: &user.ID, Username: user.Username, Body: body}
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO chat_messages (room_id, user_id, username, body)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, room.ID, user.ID, user.Username, body).Scan(&message.ID, &message.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save message: %w", err)
+	}
+
+	s.publish(ctx, models.ChatEvent{Type: "message", Room: room.Name, Message: message})
+	return message, nil
+}
+
+// SetTopic changes the topic shown at the top of a room
+func (s *ChatService) SetTopic(ctx context.Context, adminID int, roomName, topic string) error {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return err
+	}
+	room, err := s.Room(ctx, roomName)
+	if err != nil {
+		return err
+	}
+
+	topic = strings.TrimSpace(topic)
+	if _, err := s.db.Exec(ctx, `UPDATE chat_rooms SET topic = $2 WHERE id = $1`, room.ID, topic); err != nil {
+		return fmt.Errorf("failed to set topic: %w", err)
+	}
+	recordAdminAction(ctx, s.db, adminID, "set the topic of #%s: %s", room.Name, topic)
+	s.publish(ctx, models.ChatEvent{Type: "topic", Room: room.Name, Topic: topic})
+	return nil
+}
+
+// DeleteMessage removes a message from a room
+func (s *ChatService) DeleteMessage(ctx context.Context, adminID int, roomName string, messageID int64) error {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return err
+	}
+	room, err := s.Room(ctx, roomName)
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.db.Exec(ctx, `DELETE FROM chat_messages WHERE id = $1 AND room_id = $2`, messageID, room.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrChatMessageNotFound
+	}
+	recordAdminAction(ctx, s.db, adminID, "deleted message %d in #%s", messageID, room.Name)
+	s.publish(ctx, models.ChatEvent{Type: "delete", Room: room.Name, MessageID: messageID})
+	return nil
+}
+
+// Clear removes every message of a room
+func (s *ChatService) Clear(ctx context.Context, adminID int, roomName string) error {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return err
+	}
+	room, err := s.Room(ctx, roomName)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.Exec(ctx, `DELETE FROM chat_messages WHERE room_id = $1`, room.ID); err != nil {
+		return fmt.Errorf("failed to clear room: %w", err)
+	}
+	recordAdminAction(ctx, s.db, adminID, "cleared #%s", room.Name)
+	s.publish(ctx, models.ChatEvent{Type: "clear", Room: room.Name})
+	return nil
+}
+
+// Ban stops a user from speaking in a room; a zero duration bans for good
+func (s *ChatService) Ban(ctx context.Context, adminID int, roomName, username string, duration time.Duration) error {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return err
+	}
+	room, userID, err := s.roomAndUser(ctx, roomName, username)
+	if err != nil {
+		return err
+	}
+
+	var expiresAt *time.Time
+	if duration > 0 {
+		t := time.Now().Add(duration)
+		expiresAt = &t
+	}
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO chat_bans (room_id, user_id, banned_by, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (room_id, user_id) DO UPDATE SET banned_by = $3, expires_at = $4, created_at = NOW()
+	`, room.ID, userID, adminID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to ban user: %w", err)
+	}
+	recordAdminAction(ctx, s.db, adminID, "banned %s from #%s", username, room.Name)
+	return nil
+}
+
+// Unban lets a banned user speak in a room again
+func (s *ChatService) Unban(ctx context.Context, adminID int, roomName, username string) error {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return err
+	}
+	room, userID, err := s.roomAndUser(ctx, roomName, username)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.Exec(ctx, `DELETE FROM chat_bans WHERE room_id = $1 AND user_id = $2`, room.ID, userID); err != nil {
+		return fmt.Errorf("failed to unban user: %w", err)
+	}
+	recordAdminAction(ctx, s.db, adminID, "unbanned %s from #%s", username, room.Name)
+	return nil
+}
+
+// Subscribe streams the events of a room until ctx is cancelled
+func (s *ChatService) Subscribe(ctx context.Context, roomName string) (<-chan models.ChatEvent, error) {
+	name, err := NormalizeRoom(roomName)
+	if err != nil {
+		return nil, err
+	}
+
+	pubsub := s.redis.Subscribe(ctx, redisChatPrefix+name)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to room: %w", err)
+	}
+
+	events := make(chan models.ChatEvent)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event models.ChatEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// publish fans an event out to the sessions in its room. The event is
+// already stored, so sessions that miss it see it in the history.
+func (s *ChatService) publish(ctx context.Context, event models.ChatEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	if err := s.redis.Publish(ctx, redisChatPrefix+event.Room, payload).Err(); err != nil {
+		fmt.Printf("warning: failed to publish chat event: %v\n", err)
+	}
+}
+
+// roomAndUser resolves the room and the local user a moderation command
+// is about
+func (s *ChatService) roomAndUser(ctx context.Context, roomName, username string) (*models.ChatRoom, int, error) {
+	room, err := s.Room(ctx, roomName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var userID int
+	err = s.db.QueryRow(ctx, `SELECT id FROM users WHERE username = $1 AND deleted_at IS NULL`,
+		strings.ToLower(strings.TrimPrefix(username, "@"))).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, 0, ErrChatUserNotFound
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find user: %w", err)
+	}
+	return room, userID, nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/db"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CollectionPageSize is how many items one page of an actor's outbox,
+// followers or following collection holds
+const CollectionPageSize = 20
+
+// CollectionService pages through the outbox, followers and following
+// collections of local actors. Pages are cut by id rather than by offset,
+// so a page costs the same however deep into a large collection it is.
+// maxID is the id the previous page ended at, or 0 for the first page; the
+// returned next id is 0 on the last page.
+type CollectionService struct {
+	db    *pgxpool.Pool
+	posts *PostService
+}
+
+// NewCollectionService creates a new CollectionService instance
+func NewCollectionService(db *pgxpool.Pool, cfg *config.Config) *CollectionService {
+	return &CollectionService{db: db, posts: NewPostService(db, cfg)}
+}
+
+// OutboxCount returns how many posts anyone may fetch the user has
+func (s *CollectionService) OutboxCount(ctx context.Context, userID int) (int, error) {
+	return s.count(ctx, `
+		SELECT COUNT(*) FROM posts
+		WHERE user_id = $1 AND deleted_at IS NULL AND visibility IN ('public', 'unlisted')
+	`, userID)
+}
+
+// FollowersCount returns how many accepted followers the user has
+func (s *CollectionService) FollowersCount(ctx context.Context, userID int) (int, error) {
+	return s.count(ctx, `SELECT COUNT(*) FROM followers WHERE user_id = $1 AND accepted = true`, userID)
+}
+
+// FollowingCount returns how many accounts accepted the user's follow
+func (s *CollectionService) FollowingCount(ctx context.Context, userID int) (int, error) {
+	return s.count(ctx, `SELECT COUNT(*) FROM following WHERE user_id = $1 AND accepted = true`, userID)
+}
+
+// count runs a COUNT query for a user
+func (s *CollectionService) count(ctx context.Context, query string, userID int) (int, error) {
+	var total int
+	if err := db.ForReads(s.db).QueryRow(ctx, query, userID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count collection: %w", err)
+	}
+	return total, nil
+}
+
+// OutboxPage returns a page of the user's public and unlisted posts,
+// newest first
+func (s *CollectionService) OutboxPage(ctx context.Context, userID, maxID int) ([]models.Post, int, error) {
+	rows, err := db.ForReads(s.db).Query(ctx, `
+		SELECT id, user_id, content, COALESCE(content_type, 'text/plain') AS content_type,
+			COALESCE(visibility, 'public') AS visibility, published_at, COALESCE(ap_id, '') AS ap_id,
+			COALESCE(gpg_signature, '') AS gpg_signature
+		FROM posts
+		WHERE user_id = $1 AND deleted_at IS NULL AND visibility IN ('public', 'unlisted')
+			AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`, userID, maxID, CollectionPageSize+1)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load outbox: %w", err)
+	}
+	posts, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[models.Post])
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load outbox: %w", err)
+	}
+
+	next := 0
+	if len(posts) > CollectionPageSize {
+		posts = posts[:CollectionPageSize]
+		next = posts[len(posts)-1].ID
+	}
+	for i := range posts {
+		if posts[i].Mentions, err = s.posts.Mentions(ctx, posts[i].ID); err != nil {
+			return nil, 0, err
+		}
+	}
+	return posts, next, nil
+}
+
+// FollowersPage returns a page of the actor IDs of the user's accepted
+// followers, most recent first
+func (s *CollectionService) FollowersPage(ctx context.Context, userID, maxID int) ([]string, int, error) {
+	return s.actorPage(ctx, `
+		SELECT id, follower_actor_id FROM followers
+		WHERE user_id = $1 AND accepted = true AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`, userID, maxID)
+}
+
+// FollowingPage returns a page of the actor IDs of the accounts the user
+// follows, most recent first
+func (s *CollectionService) FollowingPage(ctx context.Context, userID, maxID int) ([]string, int, error) {
+	return s.actorPage(ctx, `
+		SELECT id, target_actor_id FROM following
+		WHERE user_id = $1 AND accepted = true AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`, userID, maxID)
+}
+
+// actorPage runs a page query returning (id, actor ID) rows
+func (s *CollectionService) actorPage(ctx context.Context, query string, userID, maxID int) ([]string, int, error) {
+	rows, err := db.ForReads(s.db).Query(ctx, query, userID, maxID, CollectionPageSize+1)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load collection: %w", err)
+	}
+	defer rows.Close()
+
+	var actors []string
+	var lastID, next int
+	for rows.Next() {
+		var id int
+		var actorID string
+		if err := rows.Scan(&id, &actorID); err != nil {
+			return nil, 0, fmt.Errorf("failed to load collection: %w", err)
+		}
+		if len(actors) == CollectionPageSize {
+			next = lastID
+			break
+		}
+		actors = append(actors, actorID)
+		lastID = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to load collection: %w", err)
+	}
+	return actors, next, nil
+}
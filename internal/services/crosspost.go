@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NativeTarget is the cross-post target publishing through terminalpub's
+// own outbox, as the local account
+const NativeTarget = "native"
+
+// mastodonTargetPrefix starts the key of a linked Mastodon account target,
+// followed by the ID of its token
+const mastodonTargetPrefix = "mastodon:"
+
+// CrossPostTarget is an account a composed post can be published to
+type CrossPostTarget struct {
+	Key     string // NativeTarget, BlueskyTarget, NostrTarget or mastodon:<token ID>
+	Label   string // e.g. alice@mastodon.social
+	Default bool   // Selected unless the user opts out
+	Primary bool   // The primary Mastodon account, where posts go without a choice
+}
+
+// CrossPostResult is the outcome of publishing to one target
+type CrossPostResult struct {
+	Target   string
+	Label    string
+	StatusID string
+	URL      string
+	Detail   string // e.g. how many Nostr relays took the post
+	Err      error
+}
+
+// CrossPostService publishes one status to several of a user's accounts at once
+type CrossPostService struct {
+	db       *pgxpool.Pool
+	cfg      *config.Config
+	mastodon *MastodonService
+	posts    *PostService
+	bluesky  *BlueskyService
+	nostr    *NostrService
+}
+
+// NewCrossPostService creates a new CrossPostService instance
+func NewCrossPostService(db *pgxpool.Pool, cfg *config.Config) *CrossPostService {
+	return &CrossPostService{
+		db:       db,
+		cfg:      cfg,
+		mastodon: NewMastodonService(db, cfg),
+		posts:    NewPostService(db, cfg),
+		bluesky:  NewBlueskyService(db, cfg),
+		nostr:    NewNostrService(db, cfg),
+	}
+}
+
+// Targets lists the accounts the user can publish to: every linked Mastodon
+// account, the primary one selected by default, the native account once
+// its username is chosen and the linked Bluesky and Nostr accounts,
+// selected unless their owner turned that off
+func (s *CrossPostService) Targets(ctx context.Context, userID int) ([]CrossPostTarget, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, username, instance_url, is_primary
+		FROM mastodon_tokens
+		WHERE user_id = $1
+		ORDER BY is_primary DESC, id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list linked accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []CrossPostTarget
+	for rows.Next() {
+		var id int
+		var username, instanceURL string
+		var primary bool
+		if err := rows.Scan(&id, &username, &instanceURL, &primary); err != nil {
+			return nil, fmt.Errorf("failed to scan linked account: %w", err)
+		}
+		label := username
+		if parsed, err := url.Parse(instanceURL); err == nil && parsed.Host != "" {
+			label += "@" + parsed.Host
+		}
+		targets = append(targets, CrossPostTarget{Key: mastodonTargetPrefix + strconv.Itoa(id), Label: label, Default: primary, Primary: primary})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list linked accounts: %w", err)
+	}
+
+	var username string
+	var confirmed bool
+	err = s.db.QueryRow(ctx, `SELECT username, username_confirmed FROM users WHERE id = $1`, userID).Scan(&username, &confirmed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if confirmed {
+		targets = append(targets, CrossPostTarget{Key: NativeTarget, Label: username + "@" + s.cfg.Server.Domain + " (native)"})
+	}
+
+	if s.bluesky.Enabled() {
+		account, err := s.bluesky.Account(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if account != nil {
+			targets = append(targets, CrossPostTarget{Key: BlueskyTarget, Label: "@" + account.Handle + " (Bluesky)", Default: account.CrossPost})
+		}
+	}
+
+	if s.nostr.Enabled() {
+		account, err := s.nostr.Account(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if account != nil {
+			npub := NostrNpub(account.PublicKey)
+			targets = append(targets, CrossPostTarget{Key: NostrTarget, Label: npub[:12] + "…" + npub[len(npub)-6:] + " (Nostr)", Default: account.CrossPost})
+		}
+	}
+	return targets, nil
+}
+
+// Post publishes a status to each target at once and returns the outcome
+// for each, in the order of targets. A failure on one target does not stop
+// the others. Bluesky goes last, so that a post too long for it can link to
+// where it was published in full.
+func (s *CrossPostService) Post(ctx context.Context, userID int, targets []CrossPostTarget, content, visibility, contentWarning string) []CrossPostResult {
+	results := make([]CrossPostResult, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		if target.Key == BlueskyTarget {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if target.Key == NostrTarget {
+				results[i] = s.postToNostr(ctx, userID, target, content, visibility, contentWarning)
+				return
+			}
+			statusID, statusURL, err := s.postTo(ctx, userID, target.Key, content, visibility, contentWarning)
+			results[i] = CrossPostResult{Target: target.Key, Label: target.Label, StatusID: statusID, URL: statusURL, Err: err}
+		}()
+	}
+	wg.Wait()
+
+	for i, target := range targets {
+		if target.Key != BlueskyTarget {
+			continue
+		}
+		if visibility != "public" && visibility != "unlisted" {
+			// Everything on Bluesky is public
+			results[i] = CrossPostResult{Target: target.Key, Label: target.Label, Err: fmt.Errorf("Bluesky posts are public; uncheck it for a %s post", visibility)}
+			continue
+		}
+		link := ""
+		for _, result := range results {
+			if result.Err == nil && result.URL != "" {
+				link = result.URL
+				break
+			}
+		}
+		postURL, err := s.bluesky.Post(ctx, userID, content, contentWarning, link)
+		results[i] = CrossPostResult{Target: target.Key, Label: target.Label, URL: postURL, Err: err}
+	}
+	return results
+}
+
+// postToNostr publishes a status as a Nostr note, telling how many of the
+// user's relays took it
+func (s *CrossPostService) postToNostr(ctx context.Context, userID int, target CrossPostTarget, content, visibility, contentWarning string) CrossPostResult {
+	result := CrossPostResult{Target: target.Key, Label: target.Label}
+	if visibility != "public" && visibility != "unlisted" {
+		// Anyone can read notes on relays
+		result.Err = fmt.Errorf("Nostr notes are public; uncheck it for a %s post", visibility)
+		return result
+	}
+	eventID, deliveries, err := s.nostr.Post(ctx, userID, content, contentWarning)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	accepted := 0
+	for _, d := range deliveries {
+		if d.Accepted {
+			accepted++
+		}
+	}
+	result.StatusID = NostrNote(eventID)
+	result.Detail = fmt.Sprintf("%d of %d relays", accepted, len(deliveries))
+	return result
+}
+
+// postTo publishes a status to a single Mastodon or native target and
+// returns its ID and URL
+func (s *CrossPostService) postTo(ctx context.Context, userID int, target, content, visibility, contentWarning string) (string, string, error) {
+	if target == NativeTarget {
+		if contentWarning != "" {
+			return "", "", fmt.Errorf("native posts have no content warnings")
+		}
+		post, err := s.posts.Create(ctx, userID, content, visibility)
+		if err != nil {
+			return "", "", err
+		}
+		return strconv.Itoa(post.ID), post.APID, nil
+	}
+
+	tokenID, err := strconv.Atoi(strings.TrimPrefix(target, mastodonTargetPrefix))
+	if err != nil || !strings.HasPrefix(target, mastodonTargetPrefix) {
+		return "", "", fmt.Errorf("unknown target %q", target)
+	}
+	var accessToken, instanceURL string
+	err = s.db.QueryRow(ctx, `
+		SELECT access_token, instance_url FROM mastodon_tokens WHERE id = $1 AND user_id = $2
+	`, tokenID, userID).Scan(&accessToken, &instanceURL)
+	if err != nil {
+		return "", "", fmt.Errorf("linked account not found: %w", err)
+	}
+
+	var status MastodonStatus
+	body := PostStatusRequest{Status: content, Visibility: visibility, SpoilerText: contentWarning}
+	if err := s.mastodon.doJSON(ctx, "POST", instanceURL+"/api/v1/statuses", accessToken, body, &status); err != nil {
+		return "", "", err
+	}
+	return status.ID, status.URL, nil
+}
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Digest frequencies
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+const (
+	digestNotificationPage = 80 // Notifications fetched for one digest
+	digestTimelinePage     = 40 // Home posts ranked for one digest
+	digestTopPosts         = 5
+)
+
+// ErrDigestDisabled is returned when the instance does not send digests
+var ErrDigestDisabled = errors.New("email digests are not enabled on this instance")
+
+// DigestService sends users a daily or weekly email with their new
+// followers, mentions and the most popular posts of their home timeline
+type DigestService struct {
+	db       *pgxpool.Pool
+	cfg      *config.Config
+	mastodon *MastodonService
+	mailer   *Mailer
+}
+
+// NewDigestService creates a new DigestService instance
+func NewDigestService(db *pgxpool.Pool, cfg *config.Config) *DigestService {
+	return &DigestService{
+		db:       db,
+		cfg:      cfg,
+		mastodon: NewMastodonService(db, cfg),
+		mailer:   NewMailer(cfg),
+	}
+}
+
+// Enabled reports whether the instance sends digests
+func (s *DigestService) Enabled() bool {
+	return s.cfg.Features.Digest.Enabled && s.mailer.Enabled()
+}
+
+// Settings returns the user's digest subscription, or nil if there is none
+func (s *DigestService) Settings(ctx context.Context, userID int) (*models.EmailDigest, error) {
+	digest := &models.EmailDigest{UserID: userID}
+	err := s.db.QueryRow(ctx, `
+		SELECT email, frequency, confirmed, last_sent_at, created_at
+		FROM email_digests WHERE user_id = $1
+	`, userID).Scan(&digest.Email, &digest.Frequency, &digest.Confirmed, &digest.LastSentAt, &digest.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load digest settings: %w", err)
+	}
+	return digest, nil
+}
+
+// Subscribe opts the user into a digest. A new address has to be confirmed
+// through the link emailed to it before any digest is sent there.
+func (s *DigestService) Subscribe(ctx context.Context, userID int, email, frequency string) (*models.EmailDigest, error) {
+	if !s.Enabled() {
+		return nil, ErrDigestDisabled
+	}
+	if frequency != DigestDaily && frequency != DigestWeekly {
+		return nil, fmt.Errorf("frequency must be %s or %s", DigestDaily, DigestWeekly)
+	}
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return nil, fmt.Errorf("invalid email address")
+	}
+	token, err := generateDigestToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Changing the address resets confirmation and the token, so that links
+	// sent to the old one stop working
+	digest := &models.EmailDigest{UserID: userID, Email: addr.Address, Frequency: frequency}
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO email_digests (user_id, email, frequency, token)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET frequency = $3,
+		    confirmed = email_digests.confirmed AND email_digests.email = $2,
+		    token = CASE WHEN email_digests.email = $2 THEN email_digests.token ELSE $4 END,
+		    email = $2, updated_at = NOW()
+		RETURNING token, confirmed, last_sent_at, created_at
+	`, userID, addr.Address, frequency, token).Scan(&token, &digest.Confirmed, &digest.LastSentAt, &digest.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save digest settings: %w", err)
+	}
+	if digest.Confirmed {
+		return digest, nil
+	}
+
+	confirmURL := s.link("confirm", token)
+	text := fmt.Sprintf("Someone, hopefully you, asked for a %s terminalpub digest at this address.\n\n"+
+		"Confirm it by opening:\n%s\n\nIf it wasn't you, ignore this email.\n", frequency, confirmURL)
+	body := fmt.Sprintf(`<p>Someone, hopefully you, asked for a %s terminalpub digest at this address.</p>`+
+		`<p><a href="%s">Confirm your digest</a></p><p>If it wasn't you, ignore this email.</p>`,
+		frequency, html.EscapeString(confirmURL))
+	err = s.mailer.Send(Email{To: addr.Address, Subject: "Confirm your terminalpub digest", Text: text, HTML: body})
+	if err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+// Disable stops the user's digest
+func (s *DigestService) Disable(ctx context.Context, userID int) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM email_digests WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to disable digest: %w", err)
+	}
+	return nil
+}
+
+// Confirm confirms the address of the digest a token belongs to
+func (s *DigestService) Confirm(ctx context.Context, token string) (*models.EmailDigest, error) {
+	digest := &models.EmailDigest{}
+	err := s.db.QueryRow(ctx, `
+		UPDATE email_digests SET confirmed = TRUE, updated_at = NOW()
+		WHERE token = $1
+		RETURNING user_id, email, frequency, confirmed, last_sent_at, created_at
+	`, token).Scan(&digest.UserID, &digest.Email, &digest.Frequency, &digest.Confirmed, &digest.LastSentAt, &digest.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("invalid or expired link")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm digest: %w", err)
+	}
+	return digest, nil
+}
+
+// Unsubscribe stops the digest a token belongs to. Unknown tokens are not
+// an error: the digest may already be gone.
+func (s *DigestService) Unsubscribe(ctx context.Context, token string) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM email_digests WHERE token = $1`, token); err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	return nil
+}
+
+// SendDue sends up to limit digests whose period has passed, counting the
+// first from subscribing, and returns how many were sent. A digest that
+// fails is retried on a later pass.
+func (s *DigestService) SendDue(ctx context.Context, limit int) (int, error) {
+	if !s.Enabled() {
+		return 0, nil
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT user_id, email, frequency, token, last_sent_at, created_at
+		FROM email_digests
+		WHERE confirmed AND (
+			(frequency = 'daily' AND COALESCE(last_sent_at, created_at) < NOW() - INTERVAL '1 day')
+			OR (frequency = 'weekly' AND COALESCE(last_sent_at, created_at) < NOW() - INTERVAL '7 days')
+		)
+		ORDER BY COALESCE(last_sent_at, created_at)
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load due digests: %w", err)
+	}
+	type due struct {
+		digest models.EmailDigest
+		token  string
+	}
+	var digests []due
+	for rows.Next() {
+		var d due
+		if err := rows.Scan(&d.digest.UserID, &d.digest.Email, &d.digest.Frequency, &d.token, &d.digest.LastSentAt, &d.digest.CreatedAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan digest: %w", err)
+		}
+		digests = append(digests, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to load due digests: %w", err)
+	}
+
+	sent := 0
+	for _, d := range digests {
+		ok, err := s.send(ctx, d.digest, d.token)
+		if err != nil {
+			log.Printf("Failed to send digest to user %d: %v", d.digest.UserID, err)
+			continue
+		}
+		// A digest with nothing in it is skipped but still counts as sent,
+		// so that the next one covers a full period
+		_, err = s.db.Exec(ctx, `UPDATE email_digests SET last_sent_at = NOW() WHERE user_id = $1`, d.digest.UserID)
+		if err != nil {
+			return sent, fmt.Errorf("failed to update digest: %w", err)
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// send builds and emails one digest, reporting whether there was anything
+// to send
+func (s *DigestService) send(ctx context.Context, digest models.EmailDigest, token string) (bool, error) {
+	accessToken, instanceURL, err := s.mastodon.getPrimaryToken(ctx, digest.UserID)
+	if err != nil {
+		// Without a Mastodon account there is nothing to summarize
+		return false, nil
+	}
+	since := digest.CreatedAt
+	if digest.LastSentAt != nil {
+		since = *digest.LastSentAt
+	}
+
+	var notifications []MastodonNotification
+	apiURL := fmt.Sprintf("%s/api/v1/notifications?types[]=follow&types[]=mention&limit=%d", instanceURL, digestNotificationPage)
+	if err := s.mastodon.doJSON(ctx, "GET", apiURL, accessToken, nil, &notifications); err != nil {
+		return false, fmt.Errorf("failed to fetch notifications: %w", err)
+	}
+	var follows, mentions []MastodonNotification
+	for _, n := range notifications {
+		if !n.CreatedAt.After(since) {
+			continue
+		}
+		switch n.Type {
+		case NotificationFollow:
+			follows = append(follows, n)
+		case NotificationMention:
+			if n.Status != nil {
+				mentions = append(mentions, n)
+			}
+		}
+	}
+
+	var timeline []MastodonStatus
+	apiURL = fmt.Sprintf("%s/api/v1/timelines/home?limit=%d", instanceURL, digestTimelinePage)
+	if err := s.mastodon.doJSON(ctx, "GET", apiURL, accessToken, nil, &timeline); err != nil {
+		return false, fmt.Errorf("failed to fetch home timeline: %w", err)
+	}
+	var top []MastodonStatus
+	for _, status := range timeline {
+		if status.Reblog != nil {
+			status = *status.Reblog
+		}
+		if status.CreatedAt.After(since) && status.Visibility != "direct" {
+			top = append(top, status)
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].FavouritesCount+top[i].ReblogsCount > top[j].FavouritesCount+top[j].ReblogsCount
+	})
+	if len(top) > digestTopPosts {
+		top = top[:digestTopPosts]
+	}
+
+	if len(follows) == 0 && len(mentions) == 0 && len(top) == 0 {
+		return false, nil
+	}
+	unsubscribeURL := s.link("unsubscribe", token)
+	text, body := digestContent(follows, mentions, top, unsubscribeURL)
+	subject := fmt.Sprintf("Your %s terminalpub digest", digest.Frequency)
+	err = s.mailer.Send(Email{To: digest.Email, Subject: subject, Text: text, HTML: body, Unsubscribe: unsubscribeURL})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// link returns the URL of a digest page for a token
+func (s *DigestService) link(action, token string) string {
+	return fmt.Sprintf("%s/digest/%s/%s", strings.TrimRight(s.cfg.Server.BaseURL, "/"), action, url.PathEscape(token))
+}
+
+// digestContent formats a digest as plain text and HTML
+func digestContent(follows, mentions []MastodonNotification, top []MastodonStatus, unsubscribeURL string) (string, string) {
+	var text, body strings.Builder
+	section := func(title string) {
+		fmt.Fprintf(&text, "%s\n%s\n\n", title, strings.Repeat("-", len(title)))
+		fmt.Fprintf(&body, "<h2>%s</h2>", html.EscapeString(title))
+	}
+
+	if len(follows) > 0 {
+		section(fmt.Sprintf("New followers (%d)", len(follows)))
+		body.WriteString("<ul>")
+		for _, n := range follows {
+			name := digestAccountName(n.Account)
+			fmt.Fprintf(&text, "- %s\n", name)
+			fmt.Fprintf(&body, `<li><a href="%s">%s</a></li>`, html.EscapeString(n.Account.URL), html.EscapeString(name))
+		}
+		body.WriteString("</ul>")
+		text.WriteString("\n")
+	}
+
+	if len(mentions) > 0 {
+		section(fmt.Sprintf("Mentions (%d)", len(mentions)))
+		for _, n := range mentions {
+			digestPost(&text, &body, "@"+n.Account.Acct, *n.Status)
+		}
+	}
+
+	if len(top) > 0 {
+		section("Top posts on your home timeline")
+		for _, status := range top {
+			author := fmt.Sprintf("@%s · %d favourites · %d boosts", status.Account.Acct, status.FavouritesCount, status.ReblogsCount)
+			digestPost(&text, &body, author, status)
+		}
+	}
+
+	fmt.Fprintf(&text, "--\nUnsubscribe: %s\n", unsubscribeURL)
+	fmt.Fprintf(&body, `<hr><p><small><a href="%s">Unsubscribe</a> from these digests.</small></p>`, html.EscapeString(unsubscribeURL))
+	return text.String(), body.String()
+}
+
+// digestPost writes one post of a digest
+func digestPost(text, body *strings.Builder, heading string, status MastodonStatus) {
+	excerpt := notificationBody(status.Content)
+	if status.SpoilerText != "" {
+		excerpt = "CW: " + status.SpoilerText
+	}
+	fmt.Fprintf(text, "%s\n%s\n", heading, excerpt)
+	fmt.Fprintf(body, "<p><b>%s</b><br>%s", html.EscapeString(heading), html.EscapeString(excerpt))
+	if status.URL != "" {
+		fmt.Fprintf(text, "%s\n", status.URL)
+		fmt.Fprintf(body, `<br><a href="%s">Open</a>`, html.EscapeString(status.URL))
+	}
+	text.WriteString("\n")
+	body.WriteString("</p>")
+}
+
+// digestAccountName returns the display name and handle of an account
+func digestAccountName(account MastodonAccount) string {
+	if account.DisplayName == "" {
+		return "@" + account.Acct
+	}
+	return fmt.Sprintf("%s (@%s)", account.DisplayName, account.Acct)
+}
+
+// generateDigestToken returns the random token of digest links
+func generateDigestToken() (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate digest token: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxThreadMessages is how many of the latest messages a conversation shows
+const maxThreadMessages = 200
+
+// Conversation summarizes a local user's direct messages with one actor
+type Conversation struct {
+	ActorID     string    `json:"actor_id"`
+	Acct        string    `json:"acct"`
+	LastMessage string    `json:"last_message"` // HTML
+	LastAt      time.Time `json:"last_at"`
+	Unread      int       `json:"unread"`
+}
+
+// DirectMessageService sends direct posts and files those received into
+// per-actor conversations
+type DirectMessageService struct {
+	db     *pgxpool.Pool
+	cfg    *config.Config
+	posts  *PostService
+	actors *RemoteActorService
+}
+
+// NewDirectMessageService creates a new DirectMessageService instance
+func NewDirectMessageService(db *pgxpool.Pool, cfg *config.Config) *DirectMessageService {
+	return &DirectMessageService{
+		db:     db,
+		cfg:    cfg,
+		posts:  NewPostService(db, cfg),
+		actors: NewRemoteActorService(db, cfg),
+	}
+}
+
+// Send publishes a direct post to the given accounts (user@domain, or user
+// for local accounts), mentioning any the text does not already mention
+func (s *DirectMessageService) Send(ctx context.Context, userID int, accts []string, text string) (*models.Post, error) {
+	mentioned := map[string]bool{}
+	for _, acct := range activitypub.ExtractMentions(text) {
+		mentioned[strings.ToLower(acct)] = true
+	}
+	var prefix []string
+	for _, acct := range accts {
+		acct = strings.TrimPrefix(strings.TrimSpace(acct), "@")
+		if acct != "" && !mentioned[strings.ToLower(acct)] {
+			prefix = append(prefix, "@"+acct)
+		}
+	}
+	if len(prefix) > 0 {
+		text = strings.Join(prefix, " ") + " " + text
+	}
+	return s.posts.Create(ctx, userID, text, "direct")
+}
+
+// Conversations returns the user's conversations, most recent first
+func (s *DirectMessageService) Conversations(ctx context.Context, userID int) ([]Conversation, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT DISTINCT ON (counterpart_actor_id) counterpart_actor_id, content, published_at,
+			COUNT(*) FILTER (WHERE direction = 'inbound' AND read_at IS NULL) OVER (PARTITION BY counterpart_actor_id)
+		FROM direct_messages
+		WHERE user_id = $1
+		ORDER BY counterpart_actor_id, published_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var conversations []Conversation
+	for rows.Next() {
+		var c Conversation
+		if err := rows.Scan(&c.ActorID, &c.LastMessage, &c.LastAt, &c.Unread); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		conversations = append(conversations, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load conversations: %w", err)
+	}
+	rows.Close()
+
+	for i := range conversations {
+		conversations[i].Acct = s.acct(ctx, conversations[i].ActorID)
+	}
+	sort.SliceStable(conversations, func(i, j int) bool {
+		return conversations[i].LastAt.After(conversations[j].LastAt)
+	})
+	return conversations, nil
+}
+
+// Thread returns the latest messages of a conversation, oldest first, and
+// marks those received as read
+func (s *DirectMessageService) Thread(ctx context.Context, userID int, actorID string) ([]models.DirectMessage, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT * FROM (
+			SELECT id, user_id, counterpart_actor_id, object_id, post_id, direction, content, published_at, read_at
+			FROM direct_messages
+			WHERE user_id = $1 AND counterpart_actor_id = $2
+			ORDER BY published_at DESC
+			LIMIT $3
+		) latest ORDER BY published_at
+	`, userID, actorID, maxThreadMessages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []models.DirectMessage
+	for rows.Next() {
+		var m models.DirectMessage
+		if err := rows.Scan(&m.ID, &m.UserID, &m.CounterpartActorID, &m.ObjectID, &m.PostID,
+			&m.Direction, &m.Content, &m.PublishedAt, &m.ReadAt); err != nil {
+			return nil, fmt.Errorf("failed to scan direct message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	_, err = s.db.Exec(ctx, `
+		UPDATE direct_messages SET read_at = NOW()
+		WHERE user_id = $1 AND counterpart_actor_id = $2 AND read_at IS NULL
+	`, userID, actorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark conversation read: %w", err)
+	}
+	return messages, nil
+}
+
+// Unread returns how many received direct messages the user has not read
+func (s *DirectMessageService) Unread(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := s.db.QueryRow(ctx,
+		"SELECT COUNT(*) FROM direct_messages WHERE user_id = $1 AND direction = 'inbound' AND read_at IS NULL", userID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count direct messages: %w", err)
+	}
+	return count, nil
+}
+
+// Receive files a remote direct post carried by an inbound Create into the
+// conversations of the local users it is addressed to. Other activities are
+// ignored.
+func (s *DirectMessageService) Receive(ctx context.Context, activity map[string]any) error {
+	object, ok := activity["object"].(map[string]any)
+	if !ok || object["type"] != "Note" || !activitypub.IsDirect(object) {
+		return nil
+	}
+	actorID, _ := activity["actor"].(string)
+	objectID, _ := object["id"].(string)
+	if actorID == "" || objectID == "" || referenceID(object["attributedTo"]) != actorID {
+		return nil
+	}
+	content, _ := object["content"].(string)
+	published := time.Now()
+	if value, _ := object["published"].(string); value != "" {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			published = t
+		}
+	}
+
+	localPrefix := s.cfg.Server.BaseURL + "/users/"
+	addresses := append(activitypub.Addresses(object["to"]), activitypub.Addresses(object["cc"])...)
+	for _, addr := range addresses {
+		username, ok := strings.CutPrefix(addr, localPrefix)
+		if !ok || strings.Contains(username, "/") {
+			continue
+		}
+		_, err := s.db.Exec(ctx, `
+			INSERT INTO direct_messages (user_id, counterpart_actor_id, object_id, direction, content, published_at)
+			SELECT id, $2, $3, 'inbound', $4, $5 FROM users WHERE username = $1 AND deleted_at IS NULL
+			ON CONFLICT (user_id, counterpart_actor_id, object_id) DO NOTHING
+		`, username, actorID, objectID, content, published)
+		if err != nil {
+			return fmt.Errorf("failed to save direct message: %w", err)
+		}
+	}
+	return nil
+}
+
+// acct returns the handle of a conversation partner
+func (s *DirectMessageService) acct(ctx context.Context, actorID string) string {
+	if username, ok := strings.CutPrefix(actorID, s.cfg.Server.BaseURL+"/users/"); ok {
+		return username + "@" + s.cfg.Server.Domain
+	}
+	return s.actors.Acct(ctx, actorID)
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+)
+
+// TestReceiveIgnored covers the activities Receive must not file; the
+// service has no database, so filing any of them would panic
+func TestReceiveIgnored(t *testing.T) {
+	const alice = "https://example.social/users/alice"
+	const bob = "https://terminalpub.example/users/bob"
+	cfg := config.DefaultConfig()
+	cfg.Server.BaseURL = "https://terminalpub.example"
+	s := &DirectMessageService{cfg: cfg}
+
+	note := func(fields map[string]any) map[string]any {
+		object := map[string]any{"type": "Note", "id": alice + "/statuses/1", "attributedTo": alice, "to": []any{bob}, "content": "hi"}
+		for key, value := range fields {
+			object[key] = value
+		}
+		return object
+	}
+
+	tests := []struct {
+		name     string
+		activity map[string]any
+	}{
+		{"object by reference", map[string]any{"type": "Create", "actor": alice, "object": alice + "/statuses/1"}},
+		{"not a note", map[string]any{"type": "Create", "actor": alice, "object": note(map[string]any{"type": "Question"})}},
+		{"public", map[string]any{"type": "Create", "actor": alice, "object": note(map[string]any{"cc": []any{"https://www.w3.org/ns/activitystreams#Public"}})}},
+		{"followers-only", map[string]any{"type": "Create", "actor": alice, "object": note(map[string]any{"cc": alice + "/followers"})}},
+		{"no actor", map[string]any{"type": "Create", "object": note(nil)}},
+		{"no object id", map[string]any{"type": "Create", "actor": alice, "object": note(map[string]any{"id": ""})}},
+		{"attributed to someone else", map[string]any{"type": "Create", "actor": alice, "object": note(map[string]any{"attributedTo": "https://example.social/users/mallory"})}},
+		{"to remote actors only", map[string]any{"type": "Create", "actor": alice, "object": note(map[string]any{"to": []any{"https://other.example/users/carol"}})}},
+		{"to a local collection", map[string]any{"type": "Create", "actor": alice, "object": note(map[string]any{"to": []any{bob + "/following"}})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Receive(context.Background(), tt.activity); err != nil {
+				t.Errorf("Receive: %v", err)
+			}
+		})
+	}
+}
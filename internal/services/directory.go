@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MaxDirectoryPage caps how many users one page of the directory lists
+const MaxDirectoryPage = 80
+
+// DirectoryService lists the local users who opted into the profile
+// directory, most recently active first
+type DirectoryService struct {
+	db  *pgxpool.Pool
+	cfg *config.Config
+}
+
+// NewDirectoryService creates a new DirectoryService instance
+func NewDirectoryService(db *pgxpool.Pool, cfg *config.Config) *DirectoryService {
+	return &DirectoryService{db: db, cfg: cfg}
+}
+
+// List returns a page of the directory. Users are ordered by their last
+// sign-in or public post, whichever is later.
+func (s *DirectoryService) List(ctx context.Context, limit, offset int) ([]models.DirectoryEntry, error) {
+	if limit <= 0 || limit > MaxDirectoryPage {
+		limit = MaxDirectoryPage
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT u.username, COALESCE(u.display_name, ''), COALESCE(u.bio, ''), COALESCE(u.avatar_url, ''),
+		       p.count, GREATEST(u.last_active_at, p.latest), u.created_at
+		FROM users u
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS count, MAX(published_at) AS latest
+			FROM posts
+			WHERE user_id = u.id AND deleted_at IS NULL AND visibility = 'public'
+		) p
+		WHERE u.discoverable AND u.deleted_at IS NULL AND u.username_confirmed AND u.moved_to IS NULL
+			AND u.suspended_at IS NULL AND u.silenced_at IS NULL
+		ORDER BY GREATEST(u.last_active_at, p.latest) DESC NULLS LAST, u.created_at DESC
+		LIMIT $1 OFFSET $2
+	`, limit, max(offset, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load directory: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.DirectoryEntry
+	for rows.Next() {
+		var entry models.DirectoryEntry
+		if err := rows.Scan(&entry.Username, &entry.DisplayName, &entry.Bio, &entry.AvatarURL,
+			&entry.PostsCount, &entry.LastActiveAt, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read directory entry: %w", err)
+		}
+		entry.ActorURL = activitypub.ActorURL(s.cfg.Server.BaseURL, entry.Username)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Count returns how many users the directory lists
+func (s *DirectoryService) Count(ctx context.Context) (int, error) {
+	var count int
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM users
+		WHERE discoverable AND deleted_at IS NULL AND username_confirmed AND moved_to IS NULL
+			AND suspended_at IS NULL AND silenced_at IS NULL
+	`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count directory: %w", err)
+	}
+	return count, nil
+}
+
+// Discoverable reports whether a user chose to be listed in the directory
+func (s *DirectoryService) Discoverable(ctx context.Context, userID int) (bool, error) {
+	var discoverable bool
+	err := s.db.QueryRow(ctx, `SELECT discoverable FROM users WHERE id = $1`, userID).Scan(&discoverable)
+	if err != nil {
+		return false, fmt.Errorf("failed to load directory setting: %w", err)
+	}
+	return discoverable, nil
+}
+
+// SetDiscoverable chooses whether a user is listed in the directory. The
+// actor advertises the setting, so followers are sent an Update.
+func (s *DirectoryService) SetDiscoverable(ctx context.Context, userID int, discoverable bool) error {
+	_, err := s.db.Exec(ctx, `UPDATE users SET discoverable = $2, updated_at = NOW() WHERE id = $1`, userID, discoverable)
+	if err != nil {
+		return fmt.Errorf("failed to save directory setting: %w", err)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		defer cancel()
+		if err := NewAccountService(s.db, nil, s.cfg).DeliverProfileUpdate(ctx, userID); err != nil {
+			log.Printf("Failed to deliver profile update for user %d: %v", userID, err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"fmt"
+)
+
+// MastodonSuggestion is an account the user's instance suggests following
+type MastodonSuggestion struct {
+	Source  string          `json:"source"`  // Deprecated single reason, kept by older servers
+	Sources []string        `json:"sources"` // Reasons the account is suggested
+	Account MastodonAccount `json:"account"`
+}
+
+// suggestionReasons explains the suggestion sources returned by /api/v2/suggestions
+var suggestionReasons = map[string]string{
+	"featured":                     "Featured by your server's staff",
+	"staff":                        "Featured by your server's staff",
+	"most_followed":                "Popular on your server",
+	"most_interactions":            "Active on your server recently",
+	"global":                       "Popular on your server",
+	"similar_to_recently_followed": "Similar to accounts you recently followed",
+	"friends_of_friends":           "Followed by people you follow",
+	"past_interactions":            "You have interacted with them before",
+}
+
+// Reasons returns human readable explanations of why the account is suggested
+func (s MastodonSuggestion) Reasons() []string {
+	sources := s.Sources
+	if len(sources) == 0 && s.Source != "" {
+		sources = []string{s.Source}
+	}
+
+	var reasons []string
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		reason, ok := suggestionReasons[source]
+		if !ok {
+			reason = source
+		}
+		if !seen[reason] {
+			seen[reason] = true
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+// GetSuggestions returns accounts the user's instance suggests following
+func (s *MastodonService) GetSuggestions(ctx context.Context, userID int, limit int) ([]MastodonSuggestion, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > 80 {
+		limit = 40
+	}
+
+	var suggestions []MastodonSuggestion
+	apiURL := fmt.Sprintf("%s/api/v2/suggestions?limit=%d", instanceURL, limit)
+	if err := s.doJSON(ctx, "GET", apiURL, accessToken, nil, &suggestions); err != nil {
+		return nil, fmt.Errorf("failed to fetch suggestions: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// DismissSuggestion stops the instance from suggesting an account again
+func (s *MastodonService) DismissSuggestion(ctx context.Context, userID int, accountID string) error {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v1/suggestions/%s", instanceURL, accountID)
+	if err := s.doJSON(ctx, "DELETE", apiURL, accessToken, nil, nil); err != nil {
+		return fmt.Errorf("failed to dismiss suggestion: %w", err)
+	}
+
+	return nil
+}
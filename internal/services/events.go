@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/events"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// inboundFollowTimeout bounds answering a follow, which fetches the
+// follower's actor
+const inboundFollowTimeout = 2 * time.Minute
+
+// HandleEvents subscribes the services reacting to domain events to bus:
+// delivery of new posts, follows received in a user's inbox, and the
+// webhooks and push notifications of new followers. Call it once per
+// process, before anything is published.
+func HandleEvents(bus *events.Bus, db *pgxpool.Pool, cfg *config.Config) {
+	interactions := NewInteractionService(db, cfg)
+	followers := NewFollowerService(db, cfg)
+	webhooks := NewWebhookService(db, cfg)
+	push := NewPushService(db, cfg)
+
+	events.Subscribe(bus, func(ctx context.Context, e events.PostCreated) {
+		actor, err := interactions.loadActor(ctx, e.UserID)
+		if err != nil {
+			log.Printf("Failed to deliver post %d: %v", e.Post.ID, err)
+			return
+		}
+		if len(e.Inboxes) > 0 {
+			interactions.deliverAsync(actor, e.Inboxes, e.Activity)
+		}
+		if e.Post.Visibility != "direct" {
+			interactions.deliverToFollowersAsync(actor, "", e.Activity)
+		}
+	})
+
+	// Follows are answered after the inbox responds, since that fetches
+	// the remote actor
+	events.Subscribe(bus, func(_ context.Context, e events.ActivityReceived) {
+		if e.UserID == 0 || (e.Type != "Follow" && e.Type != "Accept" && e.Type != "Reject") {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), inboundFollowTimeout)
+			defer cancel()
+			if err := followers.HandleInbound(ctx, e.UserID, e.Activity); err != nil {
+				log.Printf("Failed to process %s from %s: %v", e.Type, e.ActorID, err)
+			}
+		}()
+	})
+
+	events.Subscribe(bus, func(ctx context.Context, e events.FollowerAdded) {
+		if err := webhooks.Trigger(ctx, e.UserID, models.WebhookFollow, map[string]any{"actor": e.ActorID, "acct": e.Acct}); err != nil {
+			log.Printf("Failed to trigger follow webhooks for user %d: %v", e.UserID, err)
+		}
+		title, body := "New follower", e.Acct+" followed you"
+		if !e.Accepted {
+			title, body = "New follow request", e.Acct+" asked to follow you"
+		}
+		if err := push.Notify(ctx, e.UserID, "follow", e.ActorID, title, body); err != nil {
+			log.Printf("Failed to notify user %d of follower: %v", e.UserID, err)
+		}
+	})
+}
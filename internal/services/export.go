@@ -0,0 +1,257 @@
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// exportLinkExpiry is how long a one-time download link stays valid
+	exportLinkExpiry = 15 * time.Minute
+
+	// redisExportPrefix is the prefix for download link keys in Redis
+	redisExportPrefix = "export:"
+)
+
+// ExportService builds data portability archives for users
+type ExportService struct {
+	db      *pgxpool.Pool
+	redis   redis.UniversalClient
+	baseURL string
+}
+
+// NewExportService creates a new ExportService instance
+func NewExportService(db *pgxpool.Pool, redisClient redis.UniversalClient, baseURL string) *ExportService {
+	return &ExportService{db: db, redis: redisClient, baseURL: baseURL}
+}
+
+// CreateDownloadLink returns a one-time URL for downloading the user's archive
+func (s *ExportService) CreateDownloadLink(ctx context.Context, userID int) (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate download token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	if err := s.redis.Set(ctx, redisExportPrefix+token, userID, exportLinkExpiry).Err(); err != nil {
+		return "", fmt.Errorf("failed to store download token: %w", err)
+	}
+
+	return fmt.Sprintf("%s/export/%s", s.baseURL, token), nil
+}
+
+// ConsumeDownloadToken validates a download token and invalidates it
+func (s *ExportService) ConsumeDownloadToken(ctx context.Context, token string) (int, error) {
+	userID, err := s.redis.GetDel(ctx, redisExportPrefix+token).Int()
+	if err != nil {
+		return 0, fmt.Errorf("invalid or expired download link")
+	}
+	return userID, nil
+}
+
+// LinkedAccount describes a linked Mastodon account without its credentials
+type LinkedAccount struct {
+	InstanceURL string    `json:"instance_url"`
+	MastodonID  string    `json:"mastodon_id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Scopes      string    `json:"scopes"`
+	IsPrimary   bool      `json:"is_primary"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ArchiveFilename returns the suggested filename for a user's export
+func ArchiveFilename(username string, now time.Time) string {
+	return fmt.Sprintf("terminalpub-%s-%s.tar.gz", username, now.Format("20060102"))
+}
+
+// WriteArchive writes a gzipped tar archive with the user's data to w.
+// The actor, outbox, followers and following files use ActivityPub formats
+// so they can be imported by other servers; secrets (private keys, access
+// tokens, token hashes) are never included.
+func (s *ExportService) WriteArchive(ctx context.Context, userID int, w io.Writer) error {
+	user, err := NewUserService(s.db).GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	actorID := activitypub.ActorURL(s.baseURL, user.Username)
+
+	posts, err := s.listPosts(ctx, userID)
+	if err != nil {
+		return err
+	}
+	outboxItems := make([]any, 0, len(posts))
+	for i := range posts {
+		outboxItems = append(outboxItems, activitypub.NewCreateNote(s.baseURL, user.Username, &posts[i]))
+	}
+
+	followers, err := s.listActorIDs(ctx, "SELECT follower_actor_id FROM followers WHERE user_id = $1 ORDER BY created_at DESC", userID)
+	if err != nil {
+		return fmt.Errorf("failed to list followers: %w", err)
+	}
+
+	following, err := s.listActorIDs(ctx, "SELECT target_actor_id FROM following WHERE user_id = $1 ORDER BY created_at DESC", userID)
+	if err != nil {
+		return fmt.Errorf("failed to list following: %w", err)
+	}
+
+	sshKeys, err := auth.NewSSHKeyService(s.db).ListUserSSHKeys(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if sshKeys == nil {
+		sshKeys = []models.SSHKey{}
+	}
+
+	accounts, err := s.listLinkedAccounts(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	apiTokens, err := auth.NewAPITokenService(s.db).ListUserTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if apiTokens == nil {
+		apiTokens = []models.APIToken{}
+	}
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"actor.json", activitypub.NewActor(s.baseURL, user)},
+		{"outbox.json", newExportCollection(actorID+"/outbox", outboxItems)},
+		{"followers.json", newExportCollection(actorID+"/followers", followers)},
+		{"following.json", newExportCollection(actorID+"/following", following)},
+		{"ssh_keys.json", sshKeys},
+		{"linked_accounts.json", accounts},
+		{"api_tokens.json", apiTokens},
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", file.name, err)
+		}
+
+		header := &tar.Header{
+			Name:    file.name,
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return gz.Close()
+}
+
+// newExportCollection builds an inline OrderedCollection
+func newExportCollection(id string, items []any) models.OrderedCollection {
+	return models.OrderedCollection{
+		Context:      "https://www.w3.org/ns/activitystreams",
+		ID:           id,
+		Type:         "OrderedCollection",
+		TotalItems:   len(items),
+		OrderedItems: items,
+	}
+}
+
+// listPosts returns all of a user's local posts, newest first
+func (s *ExportService) listPosts(ctx context.Context, userID int) ([]models.Post, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, user_id, content, COALESCE(visibility, 'public'), published_at, COALESCE(ap_id, ''), COALESCE(gpg_signature, '')
+		FROM posts
+		WHERE user_id = $1
+		ORDER BY published_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.ID, &post.UserID, &post.Content, &post.Visibility, &post.PublishedAt, &post.APID, &post.GPGSignature); err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+// listActorIDs runs a query returning a single actor ID column
+func (s *ExportService) listActorIDs(ctx context.Context, query string, userID int) ([]any, error) {
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []any{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// listLinkedAccounts returns the user's Mastodon accounts without tokens
+func (s *ExportService) listLinkedAccounts(ctx context.Context, userID int) ([]LinkedAccount, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT instance_url, mastodon_id, COALESCE(username, ''), COALESCE(display_name, ''),
+		       COALESCE(scopes, ''), is_primary, created_at
+		FROM mastodon_tokens
+		WHERE user_id = $1
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list linked accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []LinkedAccount{}
+	for rows.Next() {
+		var account LinkedAccount
+		if err := rows.Scan(&account.InstanceURL, &account.MastodonID, &account.Username, &account.DisplayName,
+			&account.Scopes, &account.IsPrimary, &account.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan linked account: %w", err)
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// MaxFeedFilters caps the filters a user can keep
+	MaxFeedFilters = 20
+
+	// MaxFeedFilterNameLength caps the characters of a filter name
+	MaxFeedFilterNameLength = 32
+
+	// MaxFeedFilterLength caps the characters of a filter expression
+	MaxFeedFilterLength = 500
+)
+
+var (
+	// ErrFeedFilterNotFound is returned when a filter does not exist or
+	// belongs to someone else
+	ErrFeedFilterNotFound = errors.New("filter not found")
+	// ErrFeedFilterExists is returned when a filter name is already taken
+	ErrFeedFilterExists = errors.New("a filter with that name already exists")
+	// ErrTooManyFeedFilters is returned when a user already has MaxFeedFilters
+	ErrTooManyFeedFilters = fmt.Errorf("you can keep at most %d filters", MaxFeedFilters)
+)
+
+// FeedFilterService stores the named feed filters of each user. Filters are
+// applied by the TUI to whichever timeline is shown, so nothing here touches
+// Mastodon.
+type FeedFilterService struct {
+	db *pgxpool.Pool
+}
+
+// NewFeedFilterService creates a new FeedFilterService instance
+func NewFeedFilterService(db *pgxpool.Pool) *FeedFilterService {
+	return &FeedFilterService{db: db}
+}
+
+// List returns a user's filters in alphabetical order
+func (s *FeedFilterService) List(ctx context.Context, userID int) ([]models.FeedFilter, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, user_id, name, expression, action, created_at, updated_at
+		FROM feed_filters
+		WHERE user_id = $1
+		ORDER BY LOWER(name), id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load filters: %w", err)
+	}
+	defer rows.Close()
+
+	var filters []models.FeedFilter
+	for rows.Next() {
+		var f models.FeedFilter
+		if err := rows.Scan(&f.ID, &f.UserID, &f.Name, &f.Expression, &f.Action, &f.CreatedAt, &f.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan filter: %w", err)
+		}
+		filters = append(filters, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load filters: %w", err)
+	}
+	return filters, nil
+}
+
+// Save creates a filter, or updates filter id when it is not zero. The
+// expression is parsed first so broken filters are never stored.
+func (s *FeedFilterService) Save(ctx context.Context, userID, id int, name, expression, action string) (*models.FeedFilter, error) {
+	f := &models.FeedFilter{
+		ID:         id,
+		UserID:     userID,
+		Name:       strings.TrimSpace(name),
+		Expression: strings.TrimSpace(expression),
+		Action:     action,
+	}
+	if f.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if utf8.RuneCountInString(f.Name) > MaxFeedFilterNameLength {
+		return nil, fmt.Errorf("name is longer than %d characters", MaxFeedFilterNameLength)
+	}
+	if utf8.RuneCountInString(f.Expression) > MaxFeedFilterLength {
+		return nil, fmt.Errorf("filter is longer than %d characters", MaxFeedFilterLength)
+	}
+	if _, err := ParseFilterExpr(f.Expression); err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	if f.Action != models.FeedFilterHide && f.Action != models.FeedFilterShow {
+		return nil, fmt.Errorf("action must be %q or %q", models.FeedFilterHide, models.FeedFilterShow)
+	}
+
+	var err error
+	if id == 0 {
+		var count int
+		if err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM feed_filters WHERE user_id = $1`, userID).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to count filters: %w", err)
+		}
+		if count >= MaxFeedFilters {
+			return nil, ErrTooManyFeedFilters
+		}
+		err = s.db.QueryRow(ctx, `
+			INSERT INTO feed_filters (user_id, name, expression, action)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at, updated_at
+		`, userID, f.Name, f.Expression, f.Action).Scan(&f.ID, &f.CreatedAt, &f.UpdatedAt)
+	} else {
+		err = s.db.QueryRow(ctx, `
+			UPDATE feed_filters
+			SET name = $3, expression = $4, action = $5, updated_at = NOW()
+			WHERE id = $1 AND user_id = $2
+			RETURNING created_at, updated_at
+		`, id, userID, f.Name, f.Expression, f.Action).Scan(&f.CreatedAt, &f.UpdatedAt)
+	}
+
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		return nil, ErrFeedFilterExists
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrFeedFilterNotFound
+	case err != nil:
+		return nil, fmt.Errorf("failed to save filter: %w", err)
+	}
+	return f, nil
+}
+
+// Delete removes one of a user's filters
+func (s *FeedFilterService) Delete(ctx context.Context, userID, id int) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM feed_filters WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete filter: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrFeedFilterNotFound
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// FilterExpr is a parsed feed filter expression such as
+// `regex:crypto AND NOT from:@friend`. Terms are:
+//
+//	word, "a phrase"      the text or content warning contains it
+//	regex:<pattern>       the text or content warning matches the pattern
+//	from:<user[@domain]>  the author is the user
+//	tag:<name>, #name     the post uses the hashtag
+//	has:media|cw|link
+//	is:reply|boost|sensitive
+//
+// Terms combine with NOT (or a leading -), AND and OR, in that order of
+// precedence, and parentheses; terms side by side are ANDed. Matching is
+// case-insensitive.
+type FilterExpr struct {
+	root filterNode
+}
+
+// filterNode is a node of a parsed filter expression
+type filterNode interface {
+	match(status MastodonStatus, text string) bool
+}
+
+type andNode struct{ left, right filterNode }
+type orNode struct{ left, right filterNode }
+type notNode struct{ node filterNode }
+
+// termNode is a single condition on a post
+type termNode struct {
+	field string // "", "regex", "from", "tag", "has" or "is"
+	value string // Lower-cased
+	re    *regexp.Regexp
+}
+
+func (n andNode) match(status MastodonStatus, text string) bool {
+	return n.left.match(status, text) && n.right.match(status, text)
+}
+
+func (n orNode) match(status MastodonStatus, text string) bool {
+	return n.left.match(status, text) || n.right.match(status, text)
+}
+
+func (n notNode) match(status MastodonStatus, text string) bool {
+	return !n.node.match(status, text)
+}
+
+func (n termNode) match(status MastodonStatus, text string) bool {
+	// Boosts are judged by the boosted post, except for is:boost
+	post := status
+	if status.Reblog != nil {
+		post = *status.Reblog
+	}
+
+	switch n.field {
+	case "":
+		return strings.Contains(text, n.value)
+	case "regex":
+		return n.re.MatchString(text)
+	case "from":
+		acct := strings.ToLower(post.Account.Acct)
+		return acct == n.value || (!strings.Contains(n.value, "@") && strings.HasPrefix(acct, n.value+"@"))
+	case "tag":
+		for _, tag := range post.Tags {
+			if strings.EqualFold(tag.Name, n.value) {
+				return true
+			}
+		}
+		return false
+	case "has":
+		switch n.value {
+		case "media":
+			return len(post.MediaAttachments) > 0
+		case "cw":
+			return post.SpoilerText != ""
+		case "link":
+			return post.Card != nil || strings.Contains(post.Content, "<a ")
+		}
+	case "is":
+		switch n.value {
+		case "reply":
+			return post.InReplyToID != nil
+		case "boost":
+			return status.Reblog != nil
+		case "sensitive":
+			return post.Sensitive
+		}
+	}
+	return false
+}
+
+// filterFields lists the term prefixes and the values those that take a
+// fixed set accept
+var filterFields = map[string][]string{
+	"regex": nil,
+	"from":  nil,
+	"tag":   nil,
+	"has":   {"media", "cw", "link"},
+	"is":    {"reply", "boost", "sensitive"},
+}
+
+// ParseFilterExpr parses a feed filter expression
+func ParseFilterExpr(text string) (*FilterExpr, error) {
+	tokens, err := tokenizeFilter(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("filter is empty")
+	}
+
+	p := &filterParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return &FilterExpr{root: root}, nil
+}
+
+// Match reports whether a post matches the expression
+func (e *FilterExpr) Match(status MastodonStatus) bool {
+	post := status
+	if status.Reblog != nil {
+		post = *status.Reblog
+	}
+	plain := html.UnescapeString(htmlTag.ReplaceAllString(post.Content, " "))
+	text := strings.ToLower(strings.Join(strings.Fields(post.SpoilerText+" "+plain), " "))
+	return e.root.match(status, text)
+}
+
+// filterToken is a word, an operator or a parenthesis of an expression
+type filterToken struct {
+	text   string
+	quoted bool // A "phrase", never an operator
+}
+
+// tokenizeFilter splits an expression into tokens. Quotes group words,
+// including in the value of a term such as regex:"a b".
+func tokenizeFilter(text string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, filterToken{text: string(r)})
+			i++
+		default:
+			var word strings.Builder
+			quoted := false
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+				if runes[i] != '"' {
+					word.WriteRune(runes[i])
+					i++
+					continue
+				}
+				end := i + 1
+				for end < len(runes) && runes[end] != '"' {
+					end++
+				}
+				if end == len(runes) {
+					return nil, fmt.Errorf("missing closing quote")
+				}
+				word.WriteString(string(runes[i+1 : end]))
+				quoted = true
+				i = end + 1
+			}
+			tokens = append(tokens, filterToken{text: word.String(), quoted: quoted})
+		}
+	}
+	return tokens, nil
+}
+
+// filterParser is a recursive descent parser over the tokens
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+// peekOperator returns the operator at the current token, if it is one
+func (p *filterParser) peekOperator() string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted {
+		return ""
+	}
+	switch text := strings.ToUpper(p.tokens[p.pos].text); text {
+	case "AND", "OR", "NOT", "(", ")":
+		return text
+	}
+	return ""
+}
+
+// parseOr parses terms joined by OR
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOperator() == "OR" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses terms joined by AND or simply side by side
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.tokens) {
+		switch p.peekOperator() {
+		case "AND":
+			p.pos++
+		case "OR", ")":
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+// parseNot parses a term, a parenthesized expression or their negation
+func (p *filterParser) parseNot() (filterNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("filter ends too early")
+	}
+
+	switch p.peekOperator() {
+	case "NOT":
+		p.pos++
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	case "(":
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peekOperator() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return node, nil
+	case "AND", "OR", ")":
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+	if !token.quoted && len(token.text) > 1 && strings.HasPrefix(token.text, "-") {
+		term, err := parseFilterTerm(filterToken{text: token.text[1:]})
+		if err != nil {
+			return nil, err
+		}
+		return notNode{term}, nil
+	}
+	return parseFilterTerm(token)
+}
+
+// parseFilterTerm parses a single condition such as from:@alice or "a phrase"
+func parseFilterTerm(token filterToken) (filterNode, error) {
+	if token.quoted && !strings.Contains(token.text, ":") {
+		return termNode{value: strings.ToLower(token.text)}, nil
+	}
+	if strings.HasPrefix(token.text, "#") && len(token.text) > 1 {
+		return termNode{field: "tag", value: strings.ToLower(token.text[1:])}, nil
+	}
+
+	field, value, ok := strings.Cut(token.text, ":")
+	field = strings.ToLower(field)
+	allowed, known := filterFields[field]
+	if !ok || !known {
+		// Not a term prefix, so a plain word such as "12:30"
+		return termNode{value: strings.ToLower(token.text)}, nil
+	}
+	if value == "" {
+		return nil, fmt.Errorf("%s: needs a value", field)
+	}
+
+	term := termNode{field: field, value: strings.ToLower(value)}
+	switch field {
+	case "regex":
+		re, err := regexp.Compile("(?i)" + value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", value, err)
+		}
+		term.re = re
+	case "from":
+		term.value = strings.TrimPrefix(term.value, "@")
+	case "tag":
+		term.value = strings.TrimPrefix(term.value, "#")
+	}
+	if allowed != nil {
+		valid := false
+		for _, option := range allowed {
+			valid = valid || option == term.value
+		}
+		if !valid {
+			return nil, fmt.Errorf("%s: must be one of %s", field, strings.Join(allowed, ", "))
+		}
+	}
+	return term, nil
+}
@@ -0,0 +1,116 @@
+package services
+
+import "testing"
+
+func TestFilterExpr(t *testing.T) {
+	reply := "1"
+	posts := map[string]MastodonStatus{
+		"crypto": {
+			Content: "<p>Buy crypto &amp; coins</p>",
+			Account: MastodonAccount{Acct: "bob@example.social"},
+		},
+		"lunch": {
+			Content:     "<p>Lunch at 12:30, then a long walk</p>",
+			Account:     MastodonAccount{Acct: "alice"},
+			Tags:        []MastodonTag{{Name: "Food"}},
+			SpoilerText: "food",
+		},
+		"reply": {
+			Content:     "<p>crypto is a long walk</p>",
+			Account:     MastodonAccount{Acct: "friend@example.social"},
+			InReplyToID: &reply,
+		},
+		"boost": {
+			Account: MastodonAccount{Acct: "carol@example.social"},
+			Reblog: &MastodonStatus{
+				Content:          "<p>A photo</p>",
+				Account:          MastodonAccount{Acct: "bob@other.example"},
+				MediaAttachments: []MastodonMedia{{Type: "image"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		expr string
+		want []string // Names of the matching posts, in the order of names
+	}{
+		{expr: "crypto", want: []string{"crypto", "reply"}},
+		{expr: "CRYPTO", want: []string{"crypto", "reply"}},
+		{expr: `"long walk"`, want: []string{"lunch", "reply"}},
+		{expr: `"coins"`, want: []string{"crypto"}},
+		{expr: "12:30", want: []string{"lunch"}},
+		{expr: `regex:"^buy \w+ &"`, want: []string{"crypto"}},
+		{expr: "from:@bob", want: []string{"crypto", "boost"}},
+		{expr: "from:bob@example.social", want: []string{"crypto"}},
+		{expr: "#food", want: []string{"lunch"}},
+		{expr: "tag:FOOD", want: []string{"lunch"}},
+		{expr: "has:media", want: []string{"boost"}},
+		{expr: "has:cw", want: []string{"lunch"}},
+		{expr: "is:reply", want: []string{"reply"}},
+		{expr: "is:boost", want: []string{"boost"}},
+
+		// NOT binds tighter than AND, and AND tighter than OR
+		{expr: "crypto AND NOT from:friend", want: []string{"crypto"}},
+		{expr: "crypto -from:friend", want: []string{"crypto"}},
+		{expr: "NOT crypto AND walk", want: []string{"lunch"}},
+		{expr: "photo OR crypto AND is:reply", want: []string{"reply", "boost"}},
+		{expr: "(photo OR crypto) AND is:reply", want: []string{"reply"}},
+		{expr: "crypto walk OR photo", want: []string{"reply", "boost"}},
+		{expr: "NOT (crypto OR photo)", want: []string{"lunch"}},
+		{expr: "not crypto", want: []string{"lunch", "boost"}},
+
+		// Quoted operators and a lone dash are words
+		{expr: `"AND"`, want: nil},
+		{expr: "-", want: nil},
+	}
+	names := []string{"crypto", "lunch", "reply", "boost"}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := ParseFilterExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseFilterExpr(%q): %v", tt.expr, err)
+			}
+			var got []string
+			for _, name := range names {
+				if expr.Match(posts[name]) {
+					got = append(got, name)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("matched %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("matched %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFilterExprErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"   ",
+		`"unclosed`,
+		`regex:"a b`,
+		"(crypto",
+		"crypto)",
+		"()",
+		"AND crypto",
+		"crypto AND",
+		"crypto OR",
+		"NOT",
+		"crypto OR OR walk",
+		"regex:(",
+		`regex:"a["`,
+		"from:",
+		"has:poll",
+		"is:",
+	}
+	for _, expr := range tests {
+		if _, err := ParseFilterExpr(expr); err == nil {
+			t.Errorf("expected ParseFilterExpr(%q) to fail", expr)
+		}
+	}
+}
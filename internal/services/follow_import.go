@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// followImportInterval spaces out follow requests; each import step makes two
+	// API calls, keeping us under Mastodon's default 300 requests per 5 minutes
+	followImportInterval = 2 * time.Second
+
+	// followImportBackoff is how long to wait after hitting a rate limit
+	followImportBackoff = 60 * time.Second
+)
+
+// FollowImportResult is the outcome of importing a single account
+type FollowImportResult struct {
+	Acct string
+	Err  error
+}
+
+// FollowImportProgress reports progress after each account is processed
+type FollowImportProgress func(done, total int, result FollowImportResult)
+
+// ParseFollowingCSV extracts account addresses from a Mastodon "following" CSV export.
+// The header row ("Account address,Show boosts,...") is optional, and plain
+// lists with one address per line are accepted as well.
+func ParseFollowingCSV(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	seen := make(map[string]bool)
+	var accts []string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		if len(record) == 0 {
+			continue
+		}
+
+		// Spreadsheets may save the file with a byte order mark
+		acct := strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(record[0], "\ufeff")), "@")
+		if acct == "" || strings.EqualFold(acct, "Account address") {
+			continue
+		}
+		user, domain, ok := strings.Cut(acct, "@")
+		if !ok || user == "" || domain == "" || strings.ContainsAny(acct, " \t/") || strings.Contains(domain, "@") {
+			return nil, fmt.Errorf("invalid account address: %s", acct)
+		}
+
+		key := strings.ToLower(acct)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		accts = append(accts, acct)
+	}
+
+	if len(accts) == 0 {
+		return nil, fmt.Errorf("no accounts found")
+	}
+
+	return accts, nil
+}
+
+// ResolveAccount finds an account by its address, asking the instance to
+// fetch it over federation if it is not yet known
+func (s *MastodonService) ResolveAccount(ctx context.Context, userID int, acct string) (*MastodonAccount, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{
+		"q":       {acct},
+		"type":    {"accounts"},
+		"resolve": {"true"},
+		"limit":   {"1"},
+	}
+
+	var result struct {
+		Accounts []MastodonAccount `json:"accounts"`
+	}
+	apiURL := fmt.Sprintf("%s/api/v2/search?%s", instanceURL, params.Encode())
+	if err := s.doJSON(ctx, "GET", apiURL, accessToken, nil, &result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Accounts) == 0 {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	return &result.Accounts[0], nil
+}
+
+// ImportFollows follows each account in accts, throttling requests and backing
+// off once when rate limited. It stops early if ctx is cancelled and returns
+// the accounts that could not be followed.
+func (s *MastodonService) ImportFollows(ctx context.Context, userID int, accts []string, progress FollowImportProgress) []FollowImportResult {
+	var failures []FollowImportResult
+
+	for i, acct := range accts {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return failures
+			case <-time.After(followImportInterval):
+			}
+		}
+
+		err := s.importFollow(ctx, userID, acct)
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+			select {
+			case <-ctx.Done():
+				return failures
+			case <-time.After(followImportBackoff):
+			}
+			err = s.importFollow(ctx, userID, acct)
+		}
+
+		result := FollowImportResult{Acct: acct, Err: err}
+		if err != nil {
+			failures = append(failures, result)
+		}
+		if progress != nil {
+			progress(i+1, len(accts), result)
+		}
+	}
+
+	return failures
+}
+
+// importFollow resolves and follows a single account
+func (s *MastodonService) importFollow(ctx context.Context, userID int, acct string) error {
+	account, err := s.ResolveAccount(ctx, userID, acct)
+	if err != nil {
+		return err
+	}
+
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v1/accounts/%s/follow", instanceURL, account.ID)
+	return s.doJSON(ctx, "POST", apiURL, accessToken, nil, nil)
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseFollowingCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "mastodon export",
+			csv:  "Account address,Show boosts,Notify on new posts,Languages\nalice@example.social,true,false,\nbob@other.example,false,false,en\n",
+			want: []string{"alice@example.social", "bob@other.example"},
+		},
+		{
+			name: "header in another case",
+			csv:  "account address,show boosts\nalice@example.social,true\n",
+			want: []string{"alice@example.social"},
+		},
+		{
+			name: "header with a byte order mark",
+			csv:  "\ufeffAccount address,Show boosts\nalice@example.social,true\n",
+			want: []string{"alice@example.social"},
+		},
+		{
+			name: "plain list without header",
+			csv:  "@alice@example.social\n  bob@other.example\n",
+			want: []string{"alice@example.social", "bob@other.example"},
+		},
+		{
+			name: "blank lines",
+			csv:  "alice@example.social\n\n\nbob@other.example\n\n",
+			want: []string{"alice@example.social", "bob@other.example"},
+		},
+		{
+			name: "CRLF line endings",
+			csv:  "Account address,Show boosts\r\nalice@example.social,true\r\n",
+			want: []string{"alice@example.social"},
+		},
+		{
+			name: "quoted fields",
+			csv:  "\"Account address\",\"Show boosts\"\n\"alice@example.social\",\"true\"\n\" bob@other.example\",\"a, b\"\n",
+			want: []string{"alice@example.social", "bob@other.example"},
+		},
+		{
+			name: "duplicates in any case",
+			csv:  "alice@example.social\nAlice@Example.social\n@alice@example.social\n",
+			want: []string{"alice@example.social"},
+		},
+		{name: "empty", csv: "", wantErr: true},
+		{name: "header only", csv: "Account address,Show boosts\n", wantErr: true},
+		{name: "no domain", csv: "alice\n", wantErr: true},
+		{name: "empty domain", csv: "alice@\n", wantErr: true},
+		{name: "empty user", csv: "@@example.social\n", wantErr: true},
+		{name: "two domains", csv: "alice@example.social@other.example\n", wantErr: true},
+		{name: "spaces", csv: "alice smith@example.social\n", wantErr: true},
+		{name: "URL", csv: "https://example.social/@alice\n", wantErr: true},
+		{name: "unclosed quote", csv: "\"alice@example.social\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFollowingCSV(strings.NewReader(tt.csv))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("accounts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
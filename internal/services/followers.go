@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/events"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrFollowRequestNotFound is returned when a follow request is unknown or already answered
+var ErrFollowRequestNotFound = errors.New("follow request not found")
+
+// FollowerService handles incoming follows, follow requests awaiting
+// approval, and remote answers to the user's own follows
+type FollowerService struct {
+	db           *pgxpool.Pool
+	cfg          *config.Config
+	interactions *InteractionService
+}
+
+// NewFollowerService creates a new FollowerService instance
+func NewFollowerService(db *pgxpool.Pool, cfg *config.Config) *FollowerService {
+	return &FollowerService{db: db, cfg: cfg, interactions: NewInteractionService(db, cfg)}
+}
+
+// HandleInbound processes the follow-related activities delivered to a user's inbox
+func (s *FollowerService) HandleInbound(ctx context.Context, userID int, activity map[string]any) error {
+	switch activity["type"] {
+	case "Follow":
+		return s.handleFollow(ctx, userID, activity)
+	case "Accept", "Reject":
+		return s.handleFollowResponse(ctx, userID, activity)
+	}
+	return nil
+}
+
+// handleFollow records a new follower, pending if the user approves followers
+// by hand, and accepts it right away otherwise
+func (s *FollowerService) handleFollow(ctx context.Context, userID int, activity map[string]any) error {
+	followerID, _ := activity["actor"].(string)
+	followID, _ := activity["id"].(string)
+	if followerID == "" || followID == "" {
+		return fmt.Errorf("follow is missing actor or id")
+	}
+
+	actor, err := s.interactions.loadActor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	remote, err := s.interactions.actors.Get(ctx, followerID, actor.privateKey, actor.keyID)
+	if err != nil {
+		return err
+	}
+	inbox, _ := remote["inbox"].(string)
+	if inbox == "" {
+		return fmt.Errorf("no inbox found in actor")
+	}
+	sharedInbox, _ := activitypub.GetActorInbox(remote)
+	handle := s.interactions.actors.Acct(ctx, followerID)
+
+	var manual bool
+	err = s.db.QueryRow(ctx, "SELECT manually_approves_followers FROM users WHERE id = $1", userID).Scan(&manual)
+	if err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+
+	// A repeated Follow from an accepted follower stays accepted; xmax is
+	// zero only for a row just inserted
+	var accepted, added bool
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO followers (user_id, follower_actor_id, follower_username, follower_inbox, follower_shared_inbox, accepted, follow_activity_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, follower_actor_id)
+		DO UPDATE SET follower_inbox = EXCLUDED.follower_inbox, follower_shared_inbox = EXCLUDED.follower_shared_inbox,
+			follow_activity_id = EXCLUDED.follow_activity_id, updated_at = NOW()
+		RETURNING accepted, xmax = 0
+	`, userID, followerID, handle, inbox, sharedInbox, !manual, followID).Scan(&accepted, &added)
+	if err != nil {
+		return fmt.Errorf("failed to save follower: %w", err)
+	}
+
+	if accepted {
+		s.answer(ctx, actor, followerID, followID, inbox, "Accept")
+	}
+	if added {
+		events.Publish(ctx, events.FollowerAdded{UserID: userID, ActorID: followerID, Acct: handle, Accepted: accepted})
+	}
+	return nil
+}
+
+// handleFollowResponse marks one of the user's follows accepted, or drops it when rejected
+func (s *FollowerService) handleFollowResponse(ctx context.Context, userID int, activity map[string]any) error {
+	targetID, _ := activity["actor"].(string)
+	if targetID == "" {
+		return fmt.Errorf("%v is missing actor", activity["type"])
+	}
+
+	var err error
+	if activity["type"] == "Accept" {
+		_, err = s.db.Exec(ctx, `
+			UPDATE following SET accepted = TRUE, updated_at = NOW()
+			WHERE user_id = $1 AND target_actor_id = $2
+		`, userID, targetID)
+	} else {
+		_, err = s.db.Exec(ctx, "DELETE FROM following WHERE user_id = $1 AND target_actor_id = $2", userID, targetID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update follow: %w", err)
+	}
+	return nil
+}
+
+// ListPending returns follow requests awaiting the user's approval, oldest first
+func (s *FollowerService) ListPending(ctx context.Context, userID int) ([]models.Follower, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, follower_actor_id, COALESCE(follower_username, ''), COALESCE(follower_inbox, ''), created_at
+		FROM followers
+		WHERE user_id = $1 AND accepted = FALSE
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list follow requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []models.Follower
+	for rows.Next() {
+		request := models.Follower{UserID: userID}
+		if err := rows.Scan(&request.ID, &request.FollowerActorID, &request.FollowerUsername,
+			&request.FollowerInbox, &request.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan follow request: %w", err)
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// Approve accepts a pending follow request and sends Accept to the follower
+func (s *FollowerService) Approve(ctx context.Context, userID, requestID int) error {
+	return s.respond(ctx, userID, requestID, "Accept",
+		"UPDATE followers SET accepted = TRUE, updated_at = NOW() WHERE id = $1")
+}
+
+// Reject removes a pending follow request and sends Reject to the follower
+func (s *FollowerService) Reject(ctx context.Context, userID, requestID int) error {
+	return s.respond(ctx, userID, requestID, "Reject", "DELETE FROM followers WHERE id = $1")
+}
+
+// respond applies query to a pending request and answers it with kind
+func (s *FollowerService) respond(ctx context.Context, userID, requestID int, kind, query string) error {
+	actor, err := s.interactions.loadActor(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var followerID, inbox, followID string
+	err = s.db.QueryRow(ctx, `
+		SELECT follower_actor_id, COALESCE(follower_inbox, ''), COALESCE(follow_activity_id, '')
+		FROM followers
+		WHERE id = $1 AND user_id = $2 AND accepted = FALSE
+	`, requestID, userID).Scan(&followerID, &inbox, &followID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrFollowRequestNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load follow request: %w", err)
+	}
+
+	if _, err := s.db.Exec(ctx, query, requestID); err != nil {
+		return fmt.Errorf("failed to answer follow request: %w", err)
+	}
+
+	if inbox != "" {
+		s.answer(ctx, actor, followerID, followID, inbox, kind)
+	}
+	return nil
+}
+
+// answer records and delivers an Accept or Reject of a Follow
+func (s *FollowerService) answer(ctx context.Context, actor *localActor, followerID, followID, inbox, kind string) {
+	activity := activitypub.NewAccept(s.cfg.Server.BaseURL, actor.username, followerID, followID)
+	if kind == "Reject" {
+		activity = activitypub.NewReject(s.cfg.Server.BaseURL, actor.username, followerID, followID)
+	}
+
+	err := s.interactions.inTx(ctx, func(tx pgx.Tx) error {
+		return recordOutbound(ctx, tx, actor.userID, activity, followID)
+	})
+	if err != nil {
+		// Delivery matters more than the log entry
+		log.Printf("Failed to record %s for user %d: %v", kind, actor.userID, err)
+	}
+	s.interactions.deliverAsync(actor, []string{inbox}, activity)
+}
+
+// ManualApproval reports whether the user approves followers by hand
+func (s *FollowerService) ManualApproval(ctx context.Context, userID int) (bool, error) {
+	var manual bool
+	err := s.db.QueryRow(ctx, "SELECT manually_approves_followers FROM users WHERE id = $1", userID).Scan(&manual)
+	if err != nil {
+		return false, fmt.Errorf("failed to load follower approval: %w", err)
+	}
+	return manual, nil
+}
+
+// SetManualApproval turns follower approval on or off and tells followers
+// about the change through an actor Update
+func (s *FollowerService) SetManualApproval(ctx context.Context, userID int, manual bool) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE users SET manually_approves_followers = $2, updated_at = NOW() WHERE id = $1
+	`, userID, manual)
+	if err != nil {
+		return fmt.Errorf("failed to update follower approval: %w", err)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		defer cancel()
+		if err := NewAccountService(s.db, nil, s.cfg).DeliverProfileUpdate(ctx, userID); err != nil {
+			log.Printf("Failed to deliver profile update for user %d: %v", userID, err)
+		}
+	}()
+	return nil
+}
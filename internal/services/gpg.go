@@ -0,0 +1,196 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/clearsign"
+)
+
+// maxGPGKeySize bounds the armored keys users can register
+const maxGPGKeySize = 64 << 10
+
+// clearSignedHeader starts an OpenPGP clear-signed message
+const clearSignedHeader = "-----BEGIN PGP SIGNED MESSAGE-----"
+
+// ErrNoGPGKey is returned when a signed post is published by a user who
+// has not registered a key
+var ErrNoGPGKey = errors.New("no GPG key registered; add one with: gpg --export --armor KEYID | ssh <server> gpg-key")
+
+// GPGService manages the OpenPGP keys users sign their native posts with.
+// Posts are signed on the user's machine, with gpg --clearsign; the server
+// only ever sees public keys.
+type GPGService struct {
+	db *pgxpool.Pool
+}
+
+// NewGPGService creates a new GPGService instance
+func NewGPGService(db *pgxpool.Pool) *GPGService {
+	return &GPGService{db: db}
+}
+
+// Fingerprint returns the fingerprint of the user's key, or "" if none is
+// registered
+func (s *GPGService) Fingerprint(ctx context.Context, userID int) (string, error) {
+	var fingerprint string
+	err := s.db.QueryRow(ctx, `SELECT COALESCE(gpg_fingerprint, '') FROM users WHERE id = $1`, userID).Scan(&fingerprint)
+	if err != nil {
+		return "", fmt.Errorf("failed to load GPG key: %w", err)
+	}
+	return fingerprint, nil
+}
+
+// SetKey registers the armored public key the user signs posts with,
+// replacing any previous one, and returns its fingerprint
+func (s *GPGService) SetKey(ctx context.Context, userID int, armored string) (string, error) {
+	if len(armored) > maxGPGKeySize {
+		return "", fmt.Errorf("key is too large")
+	}
+	armored = strings.TrimSpace(armored)
+	keyring, err := readGPGKey(armored)
+	if err != nil {
+		return "", err
+	}
+	if len(keyring) != 1 {
+		return "", fmt.Errorf("expected one key, got %d", len(keyring))
+	}
+	if keyring[0].PrivateKey != nil {
+		return "", fmt.Errorf("that is a private key; export the public one with gpg --export --armor")
+	}
+	fingerprint := gpgFingerprint(keyring[0])
+
+	_, err = s.db.Exec(ctx, `
+		UPDATE users SET gpg_public_key = $2, gpg_fingerprint = $3, updated_at = NOW() WHERE id = $1
+	`, userID, armored, fingerprint)
+	if err != nil {
+		return "", fmt.Errorf("failed to save GPG key: %w", err)
+	}
+	return fingerprint, nil
+}
+
+// RemoveKey forgets the user's key. Posts signed with it keep their
+// signatures, but other servers can no longer check them.
+func (s *GPGService) RemoveKey(ctx context.Context, userID int) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE users SET gpg_public_key = NULL, gpg_fingerprint = NULL, updated_at = NOW() WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove GPG key: %w", err)
+	}
+	return nil
+}
+
+// OpenSigned checks a clear-signed message against the user's key and
+// returns the signed text and its armored detached signature
+func (s *GPGService) OpenSigned(ctx context.Context, userID int, message string) (string, string, error) {
+	var armoredKey string
+	err := s.db.QueryRow(ctx, `SELECT COALESCE(gpg_public_key, '') FROM users WHERE id = $1`, userID).Scan(&armoredKey)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && armoredKey == "") {
+		return "", "", ErrNoGPGKey
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load GPG key: %w", err)
+	}
+	return openClearSigned(armoredKey, message)
+}
+
+// openClearSigned checks a clear-signed message against an armored key and
+// returns the signed text and its armored detached signature
+func openClearSigned(armoredKey, message string) (string, string, error) {
+	keyring, err := readGPGKey(armoredKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	block, _ := clearsign.Decode([]byte(strings.TrimSpace(message)))
+	if block == nil {
+		return "", "", fmt.Errorf("malformed signed message")
+	}
+	signature, err := io.ReadAll(block.ArmoredSignature.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("malformed signature: %w", err)
+	}
+	if _, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(block.Bytes), bytes.NewReader(signature)); err != nil {
+		return "", "", fmt.Errorf("signature does not match your GPG key: %w", err)
+	}
+
+	// The signed bytes are the text with CRLF line endings; posts keep LF
+	text := strings.ReplaceAll(string(block.Bytes), "\r\n", "\n")
+	if text != strings.TrimSpace(text) {
+		return "", "", fmt.Errorf("signed text must not start or end with blank lines")
+	}
+
+	var armored bytes.Buffer
+	w, err := armor.Encode(&armored, "PGP SIGNATURE", nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode signature: %w", err)
+	}
+	w.Write(signature)
+	w.Close()
+	return text, armored.String(), nil
+}
+
+// IsClearSigned reports whether a post was written as an OpenPGP
+// clear-signed message
+func IsClearSigned(content string) bool {
+	return strings.HasPrefix(strings.TrimSpace(content), clearSignedHeader)
+}
+
+// VerifyGPGSignature checks the detached signature of a post's text against
+// an armored key and returns the key's fingerprint
+func VerifyGPGSignature(armoredKey, text, armoredSignature string) (string, error) {
+	keyring, err := readGPGKey(armoredKey)
+	if err != nil {
+		return "", err
+	}
+	// Signatures of text cover it with trailing whitespace removed from
+	// every line and CRLF line endings
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	signed := strings.Join(lines, "\r\n")
+
+	signer, err := openpgp.CheckArmoredDetachedSignature(keyring, strings.NewReader(signed), strings.NewReader(armoredSignature))
+	if err != nil {
+		return "", fmt.Errorf("invalid signature: %w", err)
+	}
+	return gpgFingerprint(signer), nil
+}
+
+// readGPGKey parses an armored public key
+func readGPGKey(armored string) (openpgp.EntityList, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		// The OpenPGP implementation predates Ed25519 keys, gpg's default
+		return nil, fmt.Errorf("unreadable GPG key (RSA and ECDSA keys are supported): %w", err)
+	}
+	if len(keyring) == 0 {
+		return nil, fmt.Errorf("no GPG key found")
+	}
+	return keyring, nil
+}
+
+// gpgFingerprint returns the fingerprint of a key as gpg prints it
+func gpgFingerprint(entity *openpgp.Entity) string {
+	return strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint[:]))
+}
+
+// FormatGPGFingerprint groups a fingerprint in blocks of four for display
+func FormatGPGFingerprint(fingerprint string) string {
+	var groups []string
+	for len(fingerprint) > 4 {
+		groups = append(groups, fingerprint[:4])
+		fingerprint = fingerprint[4:]
+	}
+	return strings.Join(append(groups, fingerprint), " ")
+}
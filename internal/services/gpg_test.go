@@ -0,0 +1,100 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// newGPGKey creates a key pair as gpg would, returning it with its armored
+// public key
+func newGPGKey(t *testing.T, name string) (*openpgp.Entity, string) {
+	t.Helper()
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", &packet.Config{RSABits: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var armored bytes.Buffer
+	w, err := armor.Encode(&armored, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	return entity, armored.String()
+}
+
+// clearSign signs text as gpg --clearsign does
+func clearSign(t *testing.T, entity *openpgp.Entity, text string) string {
+	t.Helper()
+	var signed bytes.Buffer
+	w, err := clearsign.Encode(&signed, entity.PrivateKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(text))
+	w.Close()
+	return signed.String()
+}
+
+func TestGPGRoundTrip(t *testing.T) {
+	alice, aliceKey := newGPGKey(t, "alice")
+	_, bobKey := newGPGKey(t, "bob")
+	post := "Hello, fediverse  \n- signed from my terminal\n\nBye"
+	message := clearSign(t, alice, post)
+	if !IsClearSigned(message) || IsClearSigned(post) {
+		t.Error("expected only the signed message to count as clear-signed")
+	}
+
+	text, signature, err := openClearSigned(aliceKey, message)
+	if err != nil {
+		t.Fatalf("expected the message to open: %v", err)
+	}
+	if !strings.HasPrefix(text, "Hello, fediverse") || !strings.HasSuffix(text, "\n- signed from my terminal\n\nBye") {
+		t.Errorf("text = %q", text)
+	}
+
+	// As a remote server checks the post with the key and signature its
+	// actor and Note carry
+	fingerprint, err := VerifyGPGSignature(aliceKey, text, signature)
+	if err != nil {
+		t.Fatalf("expected the signature to verify: %v", err)
+	}
+	if want := gpgFingerprint(alice); fingerprint != want || len(fingerprint) != 40 {
+		t.Errorf("fingerprint = %s, want %s", fingerprint, want)
+	}
+
+	if _, err := VerifyGPGSignature(aliceKey, text+"!", signature); err == nil {
+		t.Error("expected a changed text to fail")
+	}
+	if _, err := VerifyGPGSignature(bobKey, text, signature); err == nil {
+		t.Error("expected another key to fail")
+	}
+	if _, _, err := openClearSigned(bobKey, message); err == nil {
+		t.Error("expected a message signed with another key to fail")
+	}
+	tampered := strings.Replace(message, "Bye", "Buy", 1)
+	if _, _, err := openClearSigned(aliceKey, tampered); err == nil {
+		t.Error("expected a tampered message to fail")
+	}
+	if _, _, err := openClearSigned(aliceKey, post); err == nil {
+		t.Error("expected an unsigned message to fail")
+	}
+	if _, _, err := openClearSigned(aliceKey, clearSign(t, alice, "\nHello\n")); err == nil {
+		t.Error("expected surrounding blank lines to be refused")
+	}
+}
+
+func TestFormatGPGFingerprint(t *testing.T) {
+	got := FormatGPGFingerprint("0123456789ABCDEF0123456789ABCDEF01234567")
+	if want := "0123 4567 89AB CDEF 0123 4567 89AB CDEF 0123 4567"; got != want {
+		t.Errorf("FormatGPGFingerprint = %q, want %q", got, want)
+	}
+}
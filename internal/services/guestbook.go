@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// MaxGuestbookMessageLength caps the characters of a guestbook message
+	MaxGuestbookMessageLength = 280
+
+	// MaxGuestbookNameLength caps the characters of the name it is signed with
+	MaxGuestbookNameLength = 32
+
+	// guestbookRateWindow is the period Features.AnonymousPosting.RateLimit
+	// counts messages over
+	guestbookRateWindow = time.Hour
+
+	// redisGuestbookPrefix is the prefix of the per-visitor message counters
+	redisGuestbookPrefix = "guestbook:rate:"
+)
+
+var (
+	// ErrGuestbookDisabled is returned when anonymous posting is turned off
+	ErrGuestbookDisabled = errors.New("the guestbook is closed")
+	// ErrGuestbookRateLimited is returned when a visitor signs too often
+	ErrGuestbookRateLimited = errors.New("you have signed the guestbook too often, try again later")
+	// ErrGuestbookEntryNotFound is returned when removing an unknown entry
+	ErrGuestbookEntryNotFound = errors.New("guestbook entry not found")
+)
+
+// GuestbookService handles the guestbook anonymous visitors sign. Entries
+// are kept in Postgres; the rate limit is counted in Redis.
+type GuestbookService struct {
+	db    *pgxpool.Pool
+	redis redis.UniversalClient
+	cfg   *config.Config
+}
+
+// NewGuestbookService creates a new GuestbookService instance
+func NewGuestbookService(db *pgxpool.Pool, redisClient redis.UniversalClient, cfg *config.Config) *GuestbookService {
+	return &GuestbookService{db: db, redis: redisClient, cfg: cfg}
+}
+
+// Enabled reports whether visitors may sign the guestbook
+func (s *GuestbookService) Enabled() bool {
+	return s.cfg.Features.AnonymousPosting.Enabled
+}
+
+// Sign adds a message to the guestbook. ip and publicKey identify the
+// visitor, and each is held to Features.AnonymousPosting.RateLimit messages
+// an hour so that neither a new key nor a new address gets around it.
+func (s *GuestbookService) Sign(ctx context.Context, ip, publicKey, name, message string) (*models.GuestbookEntry, error) {
+	if !s.Enabled() {
+		return nil, ErrGuestbookDisabled
+	}
+
+	entry := &models.GuestbookEntry{Name: strings.TrimSpace(name), Message: strings.TrimSpace(message)}
+	if entry.Message == "" {
+		return nil, fmt.Errorf("message is empty")
+	}
+	if utf8.RuneCountInString(entry.Message) > MaxGuestbookMessageLength {
+		return nil, fmt.Errorf("message is longer than %d characters", MaxGuestbookMessageLength)
+	}
+	if entry.Name == "" {
+		entry.Name = "anonymous"
+	}
+	if utf8.RuneCountInString(entry.Name) > MaxGuestbookNameLength {
+		return nil, fmt.Errorf("name is longer than %d characters", MaxGuestbookNameLength)
+	}
+
+	if err := s.allow(ctx, ip, publicKey); err != nil {
+		return nil, err
+	}
+
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO guestbook_entries (name, message)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`, entry.Name, entry.Message).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign guestbook: %w", err)
+	}
+	return entry, nil
+}
+
+// allow counts a message against each of the visitor's sources, returning
+// ErrGuestbookRateLimited once any of them is over the limit
+func (s *GuestbookService) allow(ctx context.Context, sources ...string) error {
+	limit := s.cfg.Features.AnonymousPosting.RateLimit
+	if limit <= 0 {
+		return nil
+	}
+
+	for _, source := range sources {
+		if source == "" {
+			continue
+		}
+		// Sources are hashed so addresses and keys are not kept in Redis
+		sum := sha256.Sum256([]byte(source))
+		key := redisGuestbookPrefix + hex.EncodeToString(sum[:16])
+
+		pipe := s.redis.TxPipeline()
+		count := pipe.Incr(ctx, key)
+		pipe.ExpireNX(ctx, key, guestbookRateWindow)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to check rate limit: %w", err)
+		}
+		if count.Val() > int64(limit) {
+			return ErrGuestbookRateLimited
+		}
+	}
+	return nil
+}
+
+// Recent returns the newest guestbook entries first
+func (s *GuestbookService) Recent(ctx context.Context, limit int) ([]models.GuestbookEntry, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, name, message, created_at
+		FROM guestbook_entries
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load guestbook: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.GuestbookEntry
+	for rows.Next() {
+		var entry models.GuestbookEntry
+		if err := rows.Scan(&entry.ID, &entry.Name, &entry.Message, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan guestbook entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load guestbook: %w", err)
+	}
+	return entries, nil
+}
+
+// Remove deletes a guestbook entry
+func (s *GuestbookService) Remove(ctx context.Context, userID, id int) error {
+	if err := requireAdmin(ctx, s.db, userID); err != nil {
+		return err
+	}
+
+	tag, err := s.db.Exec(ctx, `DELETE FROM guestbook_entries WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to remove guestbook entry: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrGuestbookEntryNotFound
+	}
+	recordAdminAction(ctx, s.db, userID, "removed guestbook entry %d", id)
+	return nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+)
+
+// TestSignInvalid covers the messages refused before the rate limit is
+// counted; the service has no database or Redis, so reaching them would panic
+func TestSignInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		signer  string
+		message string
+		want    string
+	}{
+		{"disabled", false, "alice", "hello", ErrGuestbookDisabled.Error()},
+		{"empty", true, "alice", "", "message is empty"},
+		{"blank", true, "alice", " \n\t ", "message is empty"},
+		{"message too long", true, "alice", strings.Repeat("é", MaxGuestbookMessageLength+1), "message is longer"},
+		{"name too long", true, strings.Repeat("ñ", MaxGuestbookNameLength+1), "hello", "name is longer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Features.AnonymousPosting.Enabled = tt.enabled
+			s := &GuestbookService{cfg: cfg}
+			_, err := s.Sign(context.Background(), "192.0.2.1", "ssh-ed25519 AAAA", tt.signer, tt.message)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Sign = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestAllowWithoutCounting covers the cases that never reach Redis
+func TestAllowWithoutCounting(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		sources []string
+	}{
+		{"no limit", 0, []string{"192.0.2.1", "ssh-ed25519 AAAA"}},
+		{"negative limit", -1, []string{"192.0.2.1"}},
+		{"no sources", 3, []string{"", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Features.AnonymousPosting.RateLimit = tt.limit
+			s := &GuestbookService{cfg: cfg}
+			if err := s.allow(context.Background(), tt.sources...); err != nil {
+				t.Errorf("allow = %v, want nil", err)
+			}
+		})
+	}
+}
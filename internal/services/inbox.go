@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// InboxKind tells mentions and direct conversations apart in the inbox
+type InboxKind string
+
+const (
+	InboxMention InboxKind = "mention"
+	InboxDirect  InboxKind = "direct"
+)
+
+// InboxItem is a mention or direct conversation in the unified inbox
+type InboxItem struct {
+	Kind           InboxKind
+	Status         MastodonStatus
+	Accounts       []MastodonAccount // Other participants
+	ConversationID string            // Set for direct conversations
+	Unread         bool
+	At             time.Time
+}
+
+// GetInbox merges mention notifications and direct conversations, newest first.
+// Mentions that are the latest post of a conversation are shown only once.
+func (s *MastodonService) GetInbox(ctx context.Context, userID int, limit int) ([]InboxItem, error) {
+	conversations, err := s.GetConversations(ctx, userID, limit, "")
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := s.GetMentionsSince(ctx, userID, "", limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]InboxItem, 0, len(conversations)+len(mentions))
+	seen := make(map[string]bool)
+
+	for _, conversation := range conversations {
+		if conversation.LastStatus == nil {
+			continue
+		}
+		seen[conversation.LastStatus.ID] = true
+		items = append(items, InboxItem{
+			Kind:           InboxDirect,
+			Status:         *conversation.LastStatus,
+			Accounts:       conversation.Accounts,
+			ConversationID: conversation.ID,
+			Unread:         conversation.Unread,
+			At:             conversation.LastStatus.CreatedAt,
+		})
+	}
+
+	for _, mention := range mentions {
+		if mention.Status == nil || seen[mention.Status.ID] {
+			continue
+		}
+		seen[mention.Status.ID] = true
+		items = append(items, InboxItem{
+			Kind:     InboxMention,
+			Status:   *mention.Status,
+			Accounts: []MastodonAccount{mention.Account},
+			At:       mention.CreatedAt,
+		})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].At.After(items[j].At)
+	})
+
+	return items, nil
+}
+
+// MarkConversationRead marks a direct conversation as read
+func (s *MastodonService) MarkConversationRead(ctx context.Context, userID int, conversationID string) error {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v1/conversations/%s/read", instanceURL, conversationID)
+	if err := s.doJSON(ctx, "POST", apiURL, accessToken, nil, nil); err != nil {
+		return fmt.Errorf("failed to mark conversation read: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// InboxWorker processes inbound activities stored by the inbox handler
+type InboxWorker struct {
+	db           *pgxpool.Pool
+	interactions *InteractionService
+	migration    *MigrationService
+	relays       *RelayService
+	push         *PushService
+	direct       *DirectMessageService
+	webhooks     *WebhookService
+}
+
+// NewInboxWorker creates a new InboxWorker instance
+func NewInboxWorker(db *pgxpool.Pool, cfg *config.Config) *InboxWorker {
+	return &InboxWorker{
+		db:           db,
+		interactions: NewInteractionService(db, cfg),
+		migration:    NewMigrationService(db, cfg),
+		relays:       NewRelayService(db, cfg),
+		push:         NewPushService(db, cfg),
+		direct:       NewDirectMessageService(db, cfg),
+		webhooks:     NewWebhookService(db, cfg),
+	}
+}
+
+// ProcessPending applies up to limit pending Delete, Move, Announce, Create,
+// reaction and Undo activities and returns how many were processed. Follows
+// are answered as they arrive, and their notifications sent then too.
+func (w *InboxWorker) ProcessPending(ctx context.Context, limit int) (int, error) {
+	rows, err := w.db.Query(ctx, `
+		SELECT id, COALESCE(user_id, 0), activity_json FROM activities
+		WHERE direction = 'inbound' AND activity_type IN ('Delete', 'Move', 'Announce', 'Create', 'Like', 'EmojiReact', 'Undo')
+			AND NOT processed
+		ORDER BY created_at
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load pending activities: %w", err)
+	}
+
+	type pending struct {
+		id           int
+		userID       int
+		activityJSON []byte
+	}
+	var activities []pending
+	for rows.Next() {
+		var a pending
+		if err := rows.Scan(&a.id, &a.userID, &a.activityJSON); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan activity: %w", err)
+		}
+		activities = append(activities, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to load pending activities: %w", err)
+	}
+
+	for _, a := range activities {
+		var activity map[string]any
+		if err := json.Unmarshal(a.activityJSON, &activity); err != nil {
+			log.Printf("Skipping malformed activity %d: %v", a.id, err)
+		} else if err := w.apply(ctx, a.userID, activity); err != nil {
+			// Failures are not retried; a broken activity must not block the queue
+			log.Printf("Failed to apply %v %d: %v", activity["type"], a.id, err)
+		}
+
+		if _, err := w.db.Exec(ctx, "UPDATE activities SET processed = TRUE WHERE id = $1", a.id); err != nil {
+			return 0, fmt.Errorf("failed to mark activity processed: %w", err)
+		}
+	}
+
+	return len(activities), nil
+}
+
+// apply dispatches an inbound activity by type
+func (w *InboxWorker) apply(ctx context.Context, userID int, activity map[string]any) error {
+	switch activity["type"] {
+	case "Delete":
+		return w.applyDelete(ctx, activity)
+	case "Move":
+		return w.migration.HandleMove(ctx, activity)
+	case "Announce", "Create":
+		// Relays deliver to the shared inbox, so their traffic has no user
+		if actorID, _ := activity["actor"].(string); userID == 0 && actorID != "" {
+			relay, err := w.relays.IsRelay(ctx, actorID)
+			if err != nil {
+				return err
+			}
+			if relay {
+				return w.relays.Receive(ctx, activity)
+			}
+		}
+		if activity["type"] == "Create" {
+			// Direct posts often arrive through the shared inbox
+			if err := w.direct.Receive(ctx, activity); err != nil {
+				return err
+			}
+		}
+		if activity["type"] == "Create" && userID != 0 {
+			if err := w.notifyMention(ctx, userID, activity); err != nil {
+				log.Printf("Failed to notify user %d of mention: %v", userID, err)
+			}
+		}
+		return w.fetchReferencedObject(ctx, userID, activity)
+	case "Like", "EmojiReact":
+		return w.applyReaction(ctx, activity)
+	case "Undo":
+		return w.applyUndo(ctx, activity)
+	}
+	return nil
+}
+
+// applyReaction records an emoji reaction to a local post. Misskey sends
+// reactions as a Like with content; plain Likes are not reactions.
+func (w *InboxWorker) applyReaction(ctx context.Context, activity map[string]any) error {
+	emoji, _ := activity["content"].(string)
+	if emoji == "" {
+		emoji, _ = activity["_misskey_reaction"].(string)
+	}
+	actorID, _ := activity["actor"].(string)
+	activityID, _ := activity["id"].(string)
+	objectID := referenceID(activity["object"])
+	if emoji == "" || actorID == "" || objectID == "" {
+		return nil
+	}
+
+	_, err := w.db.Exec(ctx, `
+		INSERT INTO reactions (post_id, actor_id, object_id, emoji, ap_id)
+		SELECT id, $2, $1, $3, NULLIF($4, '') FROM posts WHERE ap_id = $1 AND deleted_at IS NULL
+		ON CONFLICT DO NOTHING
+	`, objectID, actorID, emoji, activityID)
+	if err != nil {
+		return fmt.Errorf("failed to save reaction: %w", err)
+	}
+	return nil
+}
+
+// applyUndo reverses a remote emoji reaction; other undone activities are
+// not tracked
+func (w *InboxWorker) applyUndo(ctx context.Context, activity map[string]any) error {
+	actorID, _ := activity["actor"].(string)
+	reactionID := referenceID(activity["object"])
+	if actorID == "" || reactionID == "" {
+		return nil
+	}
+	// Only the actor who reacted can undo the reaction
+	_, err := w.db.Exec(ctx, "DELETE FROM reactions WHERE ap_id = $1 AND actor_id = $2", reactionID, actorID)
+	if err != nil {
+		return fmt.Errorf("failed to remove reaction: %w", err)
+	}
+	return nil
+}
+
+// notifyMention pushes a notification when a Create carries a post that
+// mentions the user
+func (w *InboxWorker) notifyMention(ctx context.Context, userID int, activity map[string]any) error {
+	object, ok := activity["object"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	actor, err := w.interactions.loadActor(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	tags, _ := object["tag"].([]any)
+	for _, entry := range tags {
+		tag, _ := entry.(map[string]any)
+		if tag["type"] != "Mention" || tag["href"] != actor.id {
+			continue
+		}
+		actorID, _ := activity["actor"].(string)
+		content, _ := object["content"].(string)
+		acct := w.interactions.actors.Acct(ctx, actorID)
+		err := w.webhooks.Trigger(ctx, userID, models.WebhookMention, map[string]any{
+			"actor":   actorID,
+			"acct":    acct,
+			"object":  object["id"],
+			"url":     object["url"],
+			"content": content,
+		})
+		if err != nil {
+			log.Printf("Failed to trigger mention webhooks for user %d: %v", userID, err)
+		}
+		title := "New mention from " + acct
+		return w.push.Notify(ctx, userID, "mention", actorID, title, notificationBody(content))
+	}
+	return nil
+}
+
+// fetchReferencedObject fetches and caches an object the activity names only
+// by ID, such as the note behind a boost, along with the posts it replies to
+func (w *InboxWorker) fetchReferencedObject(ctx context.Context, userID int, activity map[string]any) error {
+	objectID, ok := activity["object"].(string)
+	if !ok || objectID == "" || userID == 0 {
+		return nil
+	}
+
+	actor, err := w.interactions.loadActor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	object, err := w.interactions.objects.Get(ctx, objectID, actor.privateKey, actor.keyID)
+	if err != nil {
+		return err
+	}
+
+	// A Create must come from the object's author; anyone may boost
+	if activity["type"] == "Create" && activity["actor"] != referenceID(object["attributedTo"]) {
+		return fmt.Errorf("%v did not author %s", activity["actor"], objectID)
+	}
+
+	w.interactions.objects.Ancestors(ctx, object, actor.privateKey, actor.keyID)
+	return nil
+}
+
+// applyDelete removes what a remote Delete refers to: either a whole actor
+// or a single object
+func (w *InboxWorker) applyDelete(ctx context.Context, activity map[string]any) error {
+	actorID, _ := activity["actor"].(string)
+	var objectID string
+	switch object := activity["object"].(type) {
+	case string:
+		objectID = object
+	case map[string]any:
+		objectID, _ = object["id"].(string)
+	}
+	if actorID == "" || objectID == "" {
+		return fmt.Errorf("delete is missing actor or object")
+	}
+
+	// Servers may only delete their own content
+	actorDomain, err := activitypub.ExtractDomain(actorID)
+	if err != nil {
+		return err
+	}
+	objectDomain, err := activitypub.ExtractDomain(objectID)
+	if err != nil {
+		return err
+	}
+	if actorDomain != objectDomain {
+		return fmt.Errorf("%s cannot delete %s", actorID, objectID)
+	}
+
+	// Actors may only delete themselves and what they wrote. Content whose
+	// author is not known was never stored here, so there is nothing to remove.
+	if objectID != actorID {
+		author, err := w.objectAuthor(ctx, objectID)
+		if err != nil {
+			return err
+		}
+		if author == "" {
+			return nil
+		}
+		if author != actorID {
+			return fmt.Errorf("%s cannot delete %s by %s", actorID, objectID, author)
+		}
+	}
+
+	queries := []string{
+		"DELETE FROM cached_statuses WHERE status_json->>'uri' = $1 OR status_json->'reblog'->>'uri' = $1",
+		"DELETE FROM likes WHERE object_id = $1",
+		"DELETE FROM boosts WHERE object_id = $1",
+		"DELETE FROM remote_objects WHERE object_id = $1",
+		"DELETE FROM direct_messages WHERE object_id = $1 AND direction = 'inbound'",
+	}
+	if objectID == actorID {
+		queries = []string{
+			"DELETE FROM followers WHERE follower_actor_id = $1",
+			"DELETE FROM following WHERE target_actor_id = $1",
+			"DELETE FROM likes WHERE actor_id = $1",
+			"DELETE FROM boosts WHERE actor_id = $1",
+			"DELETE FROM remote_objects WHERE attributed_to = $1",
+			"DELETE FROM direct_messages WHERE counterpart_actor_id = $1",
+			"DELETE FROM reactions WHERE actor_id = $1",
+		}
+	}
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, query := range queries {
+		if _, err := tx.Exec(ctx, query, objectID); err != nil {
+			return fmt.Errorf("failed to remove deleted content: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// objectAuthor returns the actor a stored remote object is attributed to, or
+// "" when the object is not stored
+func (w *InboxWorker) objectAuthor(ctx context.Context, objectID string) (string, error) {
+	var author string
+	err := w.db.QueryRow(ctx, `
+		SELECT attributed_to FROM remote_objects WHERE object_id = $1
+		UNION ALL
+		SELECT counterpart_actor_id FROM direct_messages WHERE object_id = $1 AND direction = 'inbound'
+		LIMIT 1
+	`, objectID).Scan(&author)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up deleted object: %w", err)
+	}
+	return author, nil
+}
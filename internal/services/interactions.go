@@ -0,0 +1,576 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrNothingToUndo is returned when undoing a follow, like or boost that was never made
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// errAlreadyDone rolls back a like or boost that was already made, so it is
+// not delivered twice
+var errAlreadyDone = errors.New("already done")
+
+// deliveryTimeout bounds background delivery of a single interaction
+const deliveryTimeout = 2 * time.Minute
+
+// InteractionService performs follows, likes and boosts natively over
+// ActivityPub, and federates their Undo when the user reverses them
+type InteractionService struct {
+	db      *pgxpool.Pool
+	cfg     *config.Config
+	actors  *RemoteActorService
+	objects *RemoteObjectService
+}
+
+// NewInteractionService creates a new InteractionService instance
+func NewInteractionService(db *pgxpool.Pool, cfg *config.Config) *InteractionService {
+	return &InteractionService{
+		db:      db,
+		cfg:     cfg,
+		actors:  NewRemoteActorService(db, cfg),
+		objects: NewRemoteObjectService(db),
+	}
+}
+
+// localActor holds what is needed to sign activities for a local user
+type localActor struct {
+	userID     int
+	username   string
+	privateKey string
+	id         string
+	keyID      string
+	proofKey   ed25519.PrivateKey // Signs FEP-8b32 integrity proofs
+}
+
+// loadActor loads the signing identity of a local user, creating the
+// integrity proof key on first use
+func (s *InteractionService) loadActor(ctx context.Context, userID int) (*localActor, error) {
+	actor := &localActor{userID: userID}
+	var proofKey string
+	err := s.db.QueryRow(ctx,
+		"SELECT username, COALESCE(private_key, ''), COALESCE(proof_private_key, '') FROM users WHERE id = $1 AND deleted_at IS NULL",
+		userID,
+	).Scan(&actor.username, &actor.privateKey, &proofKey)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if actor.privateKey == "" {
+		return nil, fmt.Errorf("user has no signing key")
+	}
+	actor.id = activitypub.ActorURL(s.cfg.Server.BaseURL, actor.username)
+	actor.keyID = actor.id + "#main-key"
+
+	if proofKey == "" {
+		if proofKey, err = s.createProofKey(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	if actor.proofKey, err = activitypub.ParseProofKey(proofKey); err != nil {
+		return nil, err
+	}
+	return actor, nil
+}
+
+// createProofKey stores a new integrity proof key unless another request
+// created one first, and returns the stored key
+func (s *InteractionService) createProofKey(ctx context.Context, userID int) (string, error) {
+	privateKey, publicKey, err := activitypub.GenerateProofKey()
+	if err != nil {
+		return "", err
+	}
+	err = s.db.QueryRow(ctx, `
+		UPDATE users SET
+			proof_private_key = COALESCE(proof_private_key, $2),
+			proof_public_key = COALESCE(proof_public_key, $3)
+		WHERE id = $1
+		RETURNING proof_private_key
+	`, userID, privateKey, publicKey).Scan(&privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to save proof key: %w", err)
+	}
+	return privateKey, nil
+}
+
+// withProof attaches the actor's integrity proof to an activity; delivery
+// goes ahead without one if signing fails
+func (actor *localActor) withProof(activity any) any {
+	signed, err := activitypub.AddProof(activity, actor.proofKey, activitypub.ProofKeyID(actor.id))
+	if err != nil {
+		log.Printf("Failed to add integrity proof for user %d: %v", actor.userID, err)
+		return activity
+	}
+	return signed
+}
+
+// Follow sends a Follow to a remote actor (user@domain or actor URL)
+func (s *InteractionService) Follow(ctx context.Context, userID int, target string) (string, error) {
+	actor, err := s.loadActor(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	targetID, err := s.actors.Resolve(ctx, target)
+	if err != nil {
+		return "", err
+	}
+	remote, err := s.actors.Get(ctx, targetID, actor.privateKey, actor.keyID)
+	if err != nil {
+		return "", err
+	}
+
+	inbox, _ := remote["inbox"].(string)
+	if inbox == "" {
+		return "", fmt.Errorf("no inbox found in actor")
+	}
+	sharedInbox, _ := activitypub.GetActorInbox(remote)
+	handle := s.actors.Acct(ctx, targetID)
+
+	activity := activitypub.NewFollow(s.cfg.Server.BaseURL, actor.username, targetID)
+
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO following (user_id, target_actor_id, target_username, target_inbox, target_shared_inbox, accepted)
+			VALUES ($1, $2, $3, $4, $5, false)
+			ON CONFLICT (user_id, target_actor_id)
+			DO UPDATE SET target_inbox = EXCLUDED.target_inbox, target_shared_inbox = EXCLUDED.target_shared_inbox
+		`, userID, targetID, handle, inbox, sharedInbox)
+		if err != nil {
+			return fmt.Errorf("failed to save follow: %w", err)
+		}
+		return recordOutbound(ctx, tx, userID, activity, targetID)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.deliverAsync(actor, []string{inbox}, activity)
+	return targetID, nil
+}
+
+// Unfollow removes a follow and sends Undo{Follow} to the remote actor
+func (s *InteractionService) Unfollow(ctx context.Context, userID int, target string) error {
+	actor, err := s.loadActor(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	targetID, err := s.actors.Resolve(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	var inbox string
+	err = s.db.QueryRow(ctx, `
+		SELECT COALESCE(target_inbox, '') FROM following
+		WHERE user_id = $1 AND target_actor_id = $2
+	`, userID, targetID).Scan(&inbox)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNothingToUndo
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load follow: %w", err)
+	}
+
+	original, err := s.lastOutbound(ctx, userID, "Follow", targetID)
+	if err != nil {
+		return err
+	}
+	if original == nil {
+		// Follows made before activities were recorded; servers match on actor and object
+		follow := activitypub.NewFollow(s.cfg.Server.BaseURL, actor.username, targetID)
+		original = &follow
+	}
+	undo := activitypub.NewUndo(*original)
+
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "DELETE FROM following WHERE user_id = $1 AND target_actor_id = $2", userID, targetID)
+		if err != nil {
+			return fmt.Errorf("failed to remove follow: %w", err)
+		}
+		return recordOutbound(ctx, tx, userID, undo, original.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	if inbox != "" {
+		s.deliverAsync(actor, []string{inbox}, undo)
+	}
+	return nil
+}
+
+// Like sends a Like of a remote object to its author
+func (s *InteractionService) Like(ctx context.Context, userID int, objectID string) error {
+	actor, authorID, inbox, err := s.resolveObject(ctx, userID, objectID)
+	if err != nil {
+		return err
+	}
+
+	activity := activitypub.NewLike(s.cfg.Server.BaseURL, actor.username, objectID, authorID)
+
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO likes (user_id, actor_id, ap_id, object_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING
+		`, userID, actor.id, activity.ID, objectID)
+		if err != nil {
+			return fmt.Errorf("failed to save like: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return errAlreadyDone
+		}
+		return recordOutbound(ctx, tx, userID, activity, objectID)
+	})
+	if errors.Is(err, errAlreadyDone) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.deliverAsync(actor, []string{inbox}, activity)
+	return nil
+}
+
+// Unlike removes a like and sends Undo{Like} to the object's author
+func (s *InteractionService) Unlike(ctx context.Context, userID int, objectID string) error {
+	return s.undoObjectActivity(ctx, userID, "Like", "likes", objectID)
+}
+
+// Boost announces a remote object to the user's followers and its author
+func (s *InteractionService) Boost(ctx context.Context, userID int, objectID string) error {
+	actor, authorID, inbox, err := s.resolveObject(ctx, userID, objectID)
+	if err != nil {
+		return err
+	}
+
+	activity := activitypub.NewAnnounce(s.cfg.Server.BaseURL, actor.username, objectID, authorID)
+
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO boosts (user_id, actor_id, ap_id, object_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING
+		`, userID, actor.id, activity.ID, objectID)
+		if err != nil {
+			return fmt.Errorf("failed to save boost: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return errAlreadyDone
+		}
+		return recordOutbound(ctx, tx, userID, activity, objectID)
+	})
+	if errors.Is(err, errAlreadyDone) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.deliverToFollowersAsync(actor, inbox, activity)
+	return nil
+}
+
+// Unboost removes a boost and sends Undo{Announce} to the user's followers and the author
+func (s *InteractionService) Unboost(ctx context.Context, userID int, objectID string) error {
+	return s.undoObjectActivity(ctx, userID, "Announce", "boosts", objectID)
+}
+
+// React sends an emoji reaction to a remote object to its author
+func (s *InteractionService) React(ctx context.Context, userID int, objectID, emoji string) error {
+	emoji = strings.TrimSpace(emoji)
+	if emoji == "" {
+		return fmt.Errorf("emoji is required")
+	}
+	actor, authorID, inbox, err := s.resolveObject(ctx, userID, objectID)
+	if err != nil {
+		return err
+	}
+
+	activity := activitypub.NewEmojiReact(s.cfg.Server.BaseURL, actor.username, objectID, authorID, emoji)
+
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO reactions (user_id, actor_id, object_id, emoji, ap_id)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT DO NOTHING
+		`, userID, actor.id, objectID, emoji, activity.ID)
+		if err != nil {
+			return fmt.Errorf("failed to save reaction: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return errAlreadyDone
+		}
+		return recordOutbound(ctx, tx, userID, activity, objectID)
+	})
+	if errors.Is(err, errAlreadyDone) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.deliverAsync(actor, []string{inbox}, activity)
+	return nil
+}
+
+// Unreact removes an emoji reaction and sends Undo{EmojiReact} to the
+// object's author
+func (s *InteractionService) Unreact(ctx context.Context, userID int, objectID, emoji string) error {
+	actor, authorID, inbox, err := s.resolveObject(ctx, userID, objectID)
+	if err != nil {
+		return err
+	}
+
+	var undo models.APActivity
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		var apID string
+		err := tx.QueryRow(ctx, `
+			DELETE FROM reactions WHERE user_id = $1 AND actor_id = $2 AND object_id = $3 AND emoji = $4
+			RETURNING ap_id
+		`, userID, actor.id, objectID, emoji).Scan(&apID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNothingToUndo
+		}
+		if err != nil {
+			return fmt.Errorf("failed to remove reaction: %w", err)
+		}
+
+		original := activitypub.NewEmojiReact(s.cfg.Server.BaseURL, actor.username, objectID, authorID, emoji)
+		original.ID = apID
+		undo = activitypub.NewUndo(original)
+		return recordOutbound(ctx, tx, userID, undo, apID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.deliverAsync(actor, []string{inbox}, undo)
+	return nil
+}
+
+// Reactions returns the emoji reactions known to this server for each of
+// objectIDs, grouped by emoji and most used first. Me is set on the
+// reactions userID made.
+func (s *InteractionService) Reactions(ctx context.Context, userID int, objectIDs []string) (map[string][]MastodonReaction, error) {
+	reactions := map[string][]MastodonReaction{}
+	if len(objectIDs) == 0 {
+		return reactions, nil
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT object_id, emoji, COUNT(*), COALESCE(bool_or(user_id = $2), false)
+		FROM reactions
+		WHERE object_id = ANY($1)
+		GROUP BY object_id, emoji
+		ORDER BY object_id, COUNT(*) DESC, MIN(created_at)
+	`, objectIDs, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var objectID string
+		var reaction MastodonReaction
+		if err := rows.Scan(&objectID, &reaction.Name, &reaction.Count, &reaction.Me); err != nil {
+			return nil, fmt.Errorf("failed to scan reaction: %w", err)
+		}
+		reactions[objectID] = append(reactions[objectID], reaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load reactions: %w", err)
+	}
+	return reactions, nil
+}
+
+// undoObjectActivity reverses a Like or Announce of objectID recorded in table
+func (s *InteractionService) undoObjectActivity(ctx context.Context, userID int, activityType, table, objectID string) error {
+	actor, err := s.loadActor(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	original, err := s.lastOutbound(ctx, userID, activityType, objectID)
+	if err != nil {
+		return err
+	}
+	if original == nil {
+		return ErrNothingToUndo
+	}
+	undo := activitypub.NewUndo(*original)
+
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		// table is one of two constants chosen by the caller
+		tag, err := tx.Exec(ctx,
+			fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND actor_id = $2 AND object_id = $3", table),
+			userID, actor.id, objectID)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s: %w", activityType, err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNothingToUndo
+		}
+		return recordOutbound(ctx, tx, userID, undo, original.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The original was addressed to the author first
+	authorInbox := ""
+	for _, recipient := range append(original.To, original.CC...) {
+		if recipient == activitypub.PublicCollection || recipient == actor.id+"/followers" {
+			continue
+		}
+		if remote, err := s.actors.Get(ctx, recipient, actor.privateKey, actor.keyID); err == nil {
+			authorInbox, _ = activitypub.GetActorInbox(remote)
+		}
+		break
+	}
+
+	if activityType == "Announce" {
+		s.deliverToFollowersAsync(actor, authorInbox, undo)
+	} else if authorInbox != "" {
+		s.deliverAsync(actor, []string{authorInbox}, undo)
+	}
+	return nil
+}
+
+// resolveObject fetches a remote object and its author's inbox
+func (s *InteractionService) resolveObject(ctx context.Context, userID int, objectID string) (*localActor, string, string, error) {
+	actor, err := s.loadActor(ctx, userID)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	object, err := s.objects.Get(ctx, objectID, actor.privateKey, actor.keyID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	authorID := referenceID(object["attributedTo"])
+
+	author, err := s.actors.Get(ctx, authorID, actor.privateKey, actor.keyID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	inbox, err := activitypub.GetActorInbox(author)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return actor, authorID, inbox, nil
+}
+
+// lastOutbound returns the most recent outbound activity of a type about
+// objectID, or nil if none was recorded
+func (s *InteractionService) lastOutbound(ctx context.Context, userID int, activityType, objectID string) (*models.APActivity, error) {
+	var activityJSON []byte
+	err := s.db.QueryRow(ctx, `
+		SELECT activity_json FROM activities
+		WHERE user_id = $1 AND direction = 'outbound' AND activity_type = $2 AND object_id = $3
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, userID, activityType, objectID).Scan(&activityJSON)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s activity: %w", activityType, err)
+	}
+
+	var activity models.APActivity
+	if err := json.Unmarshal(activityJSON, &activity); err != nil {
+		return nil, fmt.Errorf("failed to decode %s activity: %w", activityType, err)
+	}
+	return &activity, nil
+}
+
+// inTx runs fn in a transaction
+func (s *InteractionService) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// recordOutbound stores an outbound activity in the activity log
+func recordOutbound(ctx context.Context, tx pgx.Tx, userID int, activity models.APActivity, objectID string) error {
+	activityJSON, err := json.Marshal(activity)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s activity: %w", activity.Type, err)
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO activities (user_id, activity_type, actor_id, object_id, activity_json, direction, processed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, userID, activity.Type, activity.Actor, objectID, activityJSON, "outbound", false)
+	if err != nil {
+		return fmt.Errorf("failed to record %s activity: %w", activity.Type, err)
+	}
+	return nil
+}
+
+// deliverAsync sends an activity to the given inboxes in the background
+func (s *InteractionService) deliverAsync(actor *localActor, inboxes []string, activity models.APActivity) {
+	signed := actor.withProof(activity)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		defer cancel()
+		for _, inbox := range inboxes {
+			if err := activitypub.Deliver(ctx, inbox, signed, actor.privateKey, actor.keyID, s.cfg.ActivityPub.UserAgent); err != nil {
+				log.Printf("Failed to deliver %s for user %d to %s: %v", activity.Type, actor.userID, inbox, err)
+				NewWebhookService(s.db, s.cfg).deliveryFailed(ctx, actor.userID, activity.Type, inbox, err)
+			}
+		}
+	}()
+}
+
+// deliverToFollowersAsync sends an activity to the user's followers and, if
+// set, one extra inbox in the background
+func (s *InteractionService) deliverToFollowersAsync(actor *localActor, extraInbox string, activity models.APActivity) {
+	if extraInbox != "" {
+		s.deliverAsync(actor, []string{extraInbox}, activity)
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		accounts := NewAccountService(s.db, nil, s.cfg)
+		delivered, err := accounts.deliverToFollowers(ctx, actor.userID, actor.username, actor.privateKey, actor.withProof(activity))
+		if err != nil {
+			log.Printf("Failed to deliver %s for user %d: %v", activity.Type, actor.userID, err)
+			return
+		}
+		if note, ok := activity.Object.(models.APNote); ok && activity.Type == "Create" {
+			err := NewWebhookService(s.db, s.cfg).Trigger(ctx, actor.userID, models.WebhookPostFederated, map[string]any{
+				"object":    note.ID,
+				"content":   note.Content,
+				"published": note.Published,
+				"inboxes":   delivered,
+			})
+			if err != nil {
+				log.Printf("Failed to trigger post webhooks for user %d: %v", actor.userID, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrRegistrationClosed is returned when new accounts are disabled
+	ErrRegistrationClosed = errors.New("registration is closed")
+	// ErrInviteRequired is returned when signing up without an invite code
+	ErrInviteRequired = errors.New("an invite code is required to register")
+	// ErrInvalidInvite is returned for unknown, expired or used up invite codes
+	ErrInvalidInvite = errors.New("invite code is invalid, expired or used up")
+	// ErrInviteQuotaExceeded is returned when a user may not create more invites
+	ErrInviteQuotaExceeded = errors.New("invite quota exceeded")
+)
+
+// inviteAlphabet avoids characters that are easily confused when typed
+const inviteAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// InviteService handles registration invite codes
+type InviteService struct {
+	db  *pgxpool.Pool
+	cfg *config.Config
+}
+
+// NewInviteService creates a new InviteService instance
+func NewInviteService(db *pgxpool.Pool, cfg *config.Config) *InviteService {
+	return &InviteService{db: db, cfg: cfg}
+}
+
+// CanCreateInvites reports how many more invites a user may create; -1 means unlimited
+func (s *InviteService) CanCreateInvites(ctx context.Context, userID int) (int, error) {
+	return s.remainingInvites(ctx, s.db, userID)
+}
+
+// remainingInvites counts the invites a user may still create through q
+func (s *InviteService) remainingInvites(ctx context.Context, q rowQuerier, userID int) (int, error) {
+	var isAdmin bool
+	var created int
+	err := q.QueryRow(ctx, `
+		SELECT u.is_admin, (SELECT COUNT(*) FROM invites WHERE created_by = u.id)
+		FROM users u
+		WHERE u.id = $1
+	`, userID).Scan(&isAdmin, &created)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check invite quota: %w", err)
+	}
+
+	if isAdmin {
+		return -1, nil
+	}
+	if remaining := s.cfg.Features.Registration.InvitesPerUser - created; remaining > 0 {
+		return remaining, nil
+	}
+	return 0, nil
+}
+
+// CreateInvite issues a new invite code on behalf of a user
+func (s *InviteService) CreateInvite(ctx context.Context, userID, maxUses int, ttl time.Duration) (*models.Invite, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// The user row stays locked until the invite is inserted, so concurrent
+	// requests cannot both pass the quota check. The count runs after the
+	// lock is taken, to see invites committed while waiting for it.
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return nil, fmt.Errorf("failed to check invite quota: %w", err)
+	}
+	remaining, err := s.remainingInvites(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if remaining == 0 {
+		return nil, ErrInviteQuotaExceeded
+	}
+
+	if maxUses < 1 {
+		maxUses = 1
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invite code: %w", err)
+	}
+
+	invite := &models.Invite{
+		Code:      code,
+		CreatedBy: &userID,
+		MaxUses:   maxUses,
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO invites (code, created_by, max_uses, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, invite.Code, invite.CreatedBy, invite.MaxUses, invite.ExpiresAt).Scan(&invite.ID, &invite.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invite: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit invite: %w", err)
+	}
+
+	return invite, nil
+}
+
+// ListInvites returns the invites created by a user, newest first
+func (s *InviteService) ListInvites(ctx context.Context, userID int) ([]models.Invite, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, code, created_by, max_uses, uses, expires_at, created_at
+		FROM invites
+		WHERE created_by = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invites: %w", err)
+	}
+	defer rows.Close()
+
+	var invites []models.Invite
+	for rows.Next() {
+		var invite models.Invite
+		if err := rows.Scan(&invite.ID, &invite.Code, &invite.CreatedBy, &invite.MaxUses,
+			&invite.Uses, &invite.ExpiresAt, &invite.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan invite: %w", err)
+		}
+		invites = append(invites, invite)
+	}
+
+	return invites, rows.Err()
+}
+
+// redeemInvite consumes one use of an invite inside a transaction
+func redeemInvite(ctx context.Context, tx pgx.Tx, code string) (int, error) {
+	var inviteID int
+	err := tx.QueryRow(ctx, `
+		UPDATE invites SET uses = uses + 1
+		WHERE code = $1 AND uses < max_uses
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING id
+	`, NormalizeInviteCode(code)).Scan(&inviteID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrInvalidInvite
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to redeem invite: %w", err)
+	}
+	return inviteID, nil
+}
+
+// NormalizeInviteCode uppercases a code and strips separators users may type
+func NormalizeInviteCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// generateInviteCode returns a random 12 character invite code
+func generateInviteCode() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = inviteAlphabet[int(b)%len(inviteAlphabet)]
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NodeID identifies this process among the server and worker nodes sharing
+// the databases: the host name and a random suffix, since several nodes may
+// run on one host
+var NodeID = newNodeID()
+
+// newNodeID builds NodeID
+func newNodeID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "node"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+// AcquireLease takes the named lease for ttl and reports whether it did.
+// It fails while another node holds the lease and the lease has not run
+// out; a node taking a lease it already holds extends it.
+func AcquireLease(ctx context.Context, db *pgxpool.Pool, name string, ttl time.Duration) (bool, error) {
+	var holder string
+	err := db.QueryRow(ctx, `
+		INSERT INTO leases (name, holder, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		WHERE leases.expires_at < NOW() OR leases.holder = EXCLUDED.holder
+		RETURNING holder
+	`, name, NodeID, ttl.Milliseconds()).Scan(&holder)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// ReleaseLease gives up a lease this node holds, so another node can take
+// it before it runs out
+func ReleaseLease(ctx context.Context, db *pgxpool.Pool, name string) error {
+	if _, err := db.Exec(ctx, `DELETE FROM leases WHERE name = $1 AND holder = $2`, name, NodeID); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+	return nil
+}
+
+// PurgeExpiredLeases removes the leases of nodes that stopped without
+// releasing them
+func PurgeExpiredLeases(ctx context.Context, db *pgxpool.Pool) (int64, error) {
+	tag, err := db.Exec(ctx, `DELETE FROM leases WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge leases: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
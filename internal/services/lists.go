@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+	"fmt"
+)
+
+// MastodonList is one of the lists of accounts a user keeps on Mastodon
+type MastodonList struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// GetLists fetches the user's lists
+func (s *MastodonService) GetLists(ctx context.Context, userID int) ([]MastodonList, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var lists []MastodonList
+	if err := s.doJSON(ctx, "GET", instanceURL+"/api/v1/lists", accessToken, nil, &lists); err != nil {
+		return nil, fmt.Errorf("failed to fetch lists: %w", err)
+	}
+	return lists, nil
+}
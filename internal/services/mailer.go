@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+)
+
+// ErrMailDisabled is returned when the instance has no mail server configured
+var ErrMailDisabled = errors.New("email is not set up on this instance")
+
+// Email is a message with a plain-text and an HTML version
+type Email struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Unsubscribe string // One-click unsubscribe URL (RFC 8058), if any
+}
+
+// Mailer sends email through the configured SMTP server
+type Mailer struct {
+	cfg *config.Config
+}
+
+// NewMailer creates a new Mailer instance
+func NewMailer(cfg *config.Config) *Mailer {
+	return &Mailer{cfg: cfg}
+}
+
+// Enabled reports whether a mail server is configured
+func (m *Mailer) Enabled() bool {
+	return m.cfg.SMTP.Host != "" && m.cfg.SMTP.From != ""
+}
+
+// Send delivers an email
+func (m *Mailer) Send(email Email) error {
+	if !m.Enabled() {
+		return ErrMailDisabled
+	}
+	from, err := mail.ParseAddress(m.cfg.SMTP.From)
+	if err != nil {
+		return fmt.Errorf("invalid smtp.from: %w", err)
+	}
+	to, err := mail.ParseAddress(email.To)
+	if err != nil {
+		return fmt.Errorf("invalid address: %w", err)
+	}
+	message, err := m.compose(from, to, email)
+	if err != nil {
+		return err
+	}
+
+	port := m.cfg.SMTP.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(m.cfg.SMTP.Host, strconv.Itoa(port))
+	var auth smtp.Auth
+	if m.cfg.SMTP.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.SMTP.Username, m.cfg.SMTP.Password, m.cfg.SMTP.Host)
+	}
+	if port != 465 {
+		// SendMail upgrades with STARTTLS whenever the server offers it
+		if err := smtp.SendMail(addr, auth, from.Address, []string{to.Address}, message); err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		return nil
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", addr, &tls.Config{ServerName: m.cfg.SMTP.Host})
+	if err != nil {
+		return fmt.Errorf("failed to connect to mail server: %w", err)
+	}
+	client, err := smtp.NewClient(conn, m.cfg.SMTP.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to mail server: %w", err)
+	}
+	defer client.Close()
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("mail server refused login: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return client.Quit()
+}
+
+// compose builds the MIME message of an email
+func (m *Mailer) compose(from, to *mail.Address, email Email) ([]byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate message ID: %w", err)
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", email.Text},
+		{"text/html; charset=utf-8", email.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to compose email: %w", err)
+		}
+		qp := quotedprintable.NewWriter(w)
+		qp.Write([]byte(part.content))
+		qp.Close()
+	}
+	parts.Close()
+
+	var message bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&message, "%s: %s\r\n", name, value)
+	}
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", email.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(id)+"@"+m.cfg.Server.Domain+">")
+	header("MIME-Version", "1.0")
+	if email.Unsubscribe != "" {
+		header("List-Unsubscribe", "<"+email.Unsubscribe+">")
+		header("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
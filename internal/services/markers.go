@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MarkerService keeps the user's read position per timeline, locally and on
+// their Mastodon instance, so it follows them across sessions and clients
+type MarkerService struct {
+	db       *pgxpool.Pool
+	mastodon *MastodonService
+}
+
+// NewMarkerService creates a new MarkerService instance
+func NewMarkerService(db *pgxpool.Pool, mastodon *MastodonService) *MarkerService {
+	return &MarkerService{db: db, mastodon: mastodon}
+}
+
+// StatusIDNewer reports whether status ID a is newer than b. Mastodon IDs are
+// numeric and other servers use fixed-length sortable IDs, so longer IDs are
+// newer and equal lengths compare lexically.
+func StatusIDNewer(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return a > b
+}
+
+// Get returns the last read post of a timeline, preferring whichever of the
+// local and server markers is newer and bringing the other one up to date
+func (s *MarkerService) Get(ctx context.Context, userID int, timeline string) (string, error) {
+	local, err := s.getLocal(ctx, userID, timeline)
+	if err != nil {
+		return "", err
+	}
+
+	// The server may be unreachable; the local marker is still useful
+	markers, err := s.mastodon.GetMarkers(ctx, userID, timeline)
+	if err != nil {
+		return local, nil
+	}
+	remote := markers[timeline].LastReadID
+
+	switch {
+	case StatusIDNewer(remote, local):
+		if err := s.saveLocal(ctx, userID, timeline, remote); err != nil {
+			return "", err
+		}
+		return remote, nil
+	case StatusIDNewer(local, remote):
+		if err := s.mastodon.SaveMarker(ctx, userID, timeline, local); err != nil {
+			fmt.Printf("Failed to sync read marker: %v\n", err)
+		}
+	}
+
+	return local, nil
+}
+
+// Save records lastReadID as the read position of a timeline if it is newer
+// than the stored one, and pushes it to the user's instance
+func (s *MarkerService) Save(ctx context.Context, userID int, timeline, lastReadID string) error {
+	local, err := s.getLocal(ctx, userID, timeline)
+	if err != nil {
+		return err
+	}
+	if !StatusIDNewer(lastReadID, local) {
+		return nil
+	}
+
+	if err := s.saveLocal(ctx, userID, timeline, lastReadID); err != nil {
+		return err
+	}
+
+	return s.mastodon.SaveMarker(ctx, userID, timeline, lastReadID)
+}
+
+// getLocal returns the stored marker, or "" if there is none
+func (s *MarkerService) getLocal(ctx context.Context, userID int, timeline string) (string, error) {
+	var lastReadID string
+	err := s.db.QueryRow(ctx, `
+		SELECT last_read_id FROM read_markers
+		WHERE user_id = $1 AND timeline = $2
+	`, userID, timeline).Scan(&lastReadID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load read marker: %w", err)
+	}
+	return lastReadID, nil
+}
+
+// saveLocal stores a marker
+func (s *MarkerService) saveLocal(ctx context.Context, userID int, timeline, lastReadID string) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO read_markers (user_id, timeline, last_read_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, timeline)
+		DO UPDATE SET last_read_id = EXCLUDED.last_read_id, updated_at = CURRENT_TIMESTAMP
+	`, userID, timeline, lastReadID)
+	if err != nil {
+		return fmt.Errorf("failed to save read marker: %w", err)
+	}
+	return nil
+}
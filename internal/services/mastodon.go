@@ -6,10 +6,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/db"
+	"github.com/fulgidus/terminalpub/internal/outbound"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/sync/singleflight"
 )
 
 // TimelineType represents different types of Mastodon timelines
@@ -24,45 +31,164 @@ const (
 	TimelineFederated TimelineType = "public"
 )
 
+// primaryTokenStmt loads the credentials of a user's primary Mastodon
+// account, which every timeline fetch and interaction needs
+var primaryTokenStmt = db.Prepare("mastodon_primary_token", `
+	SELECT access_token, instance_url
+	FROM mastodon_tokens
+	WHERE user_id = $1 AND is_primary = true
+	LIMIT 1
+`)
+
 // MastodonService handles communication with Mastodon APIs
 type MastodonService struct {
 	db     *pgxpool.Pool
 	client *http.Client
+	retry  retryPolicy
 }
 
 // NewMastodonService creates a new MastodonService instance
-func NewMastodonService(db *pgxpool.Pool) *MastodonService {
+func NewMastodonService(db *pgxpool.Pool, cfg *config.Config) *MastodonService {
 	return &MastodonService{
-		db: db,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		db:     db,
+		client: outbound.NewClient(30 * time.Second),
+		retry:  newRetryPolicy(cfg),
+	}
+}
+
+// APIError is returned when the Mastodon API responds with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error implements error
+func (e *APIError) Error() string {
+	return fmt.Sprintf("mastodon API error %d: %s", e.StatusCode, e.Body)
+}
+
+// getPrimaryToken returns the access token and instance URL of the user's primary Mastodon account
+func (s *MastodonService) getPrimaryToken(ctx context.Context, userID int) (string, string, error) {
+	var accessToken, instanceURL string
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
+
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get user token: %w", err)
+	}
+
+	return accessToken, instanceURL, nil
+}
+
+// doJSON performs an authenticated Mastodon API request, encoding body (if any)
+// as JSON and decoding the response into out (if non-nil)
+func (s *MastodonService) doJSON(ctx context.Context, method, apiURL, accessToken string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = strings.NewReader(string(jsonData))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if accessToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.retry.do(s.client, req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	if out == nil {
+		return nil
 	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
 }
 
 // MastodonStatus represents a Mastodon post/status
 type MastodonStatus struct {
-	ID                 string            `json:"id"`
-	CreatedAt          time.Time         `json:"created_at"`
-	Content            string            `json:"content"`
-	Visibility         string            `json:"visibility"`
-	Sensitive          bool              `json:"sensitive"`
-	SpoilerText        string            `json:"spoiler_text"`
-	ReblogsCount       int               `json:"reblogs_count"`
-	FavouritesCount    int               `json:"favourites_count"`
-	RepliesCount       int               `json:"replies_count"`
-	URL                string            `json:"url"`
-	InReplyToID        *string           `json:"in_reply_to_id"`
-	InReplyToAccountID *string           `json:"in_reply_to_account_id"`
-	Reblog             *MastodonStatus   `json:"reblog"`
-	Account            MastodonAccount   `json:"account"`
-	MediaAttachments   []MastodonMedia   `json:"media_attachments"`
-	Mentions           []MastodonMention `json:"mentions"`
-	Tags               []MastodonTag     `json:"tags"`
-	Card               *MastodonCard     `json:"card"`
-	Favourited         bool              `json:"favourited"`
-	Reblogged          bool              `json:"reblogged"`
-	Bookmarked         bool              `json:"bookmarked"`
+	ID                 string             `json:"id"`
+	CreatedAt          time.Time          `json:"created_at"`
+	EditedAt           *time.Time         `json:"edited_at"`
+	Content            string             `json:"content"`
+	Visibility         string             `json:"visibility"`
+	Sensitive          bool               `json:"sensitive"`
+	SpoilerText        string             `json:"spoiler_text"`
+	ReblogsCount       int                `json:"reblogs_count"`
+	FavouritesCount    int                `json:"favourites_count"`
+	RepliesCount       int                `json:"replies_count"`
+	URL                string             `json:"url"`
+	InReplyToID        *string            `json:"in_reply_to_id"`
+	InReplyToAccountID *string            `json:"in_reply_to_account_id"`
+	Reblog             *MastodonStatus    `json:"reblog"`
+	Account            MastodonAccount    `json:"account"`
+	MediaAttachments   []MastodonMedia    `json:"media_attachments"`
+	Mentions           []MastodonMention  `json:"mentions"`
+	Tags               []MastodonTag      `json:"tags"`
+	Card               *MastodonCard      `json:"card"`
+	Application        *MastodonApp       `json:"application"` // Client the status was posted with, if disclosed
+	Favourited         bool               `json:"favourited"`
+	Reblogged          bool               `json:"reblogged"`
+	Bookmarked         bool               `json:"bookmarked"`
+	Pinned             bool               `json:"pinned"`
+	URI                string             `json:"uri"`
+	Reactions          []MastodonReaction `json:"reactions,omitempty"` // Servers with Fedibird-style reactions
+	Pleroma            *PleromaStatus     `json:"pleroma,omitempty"`
+	GPGSigner          string             `json:"gpg_signer,omitempty"` // Fingerprint of the GPG key that signed a native post, once verified
+}
+
+// MastodonApp is the client application a status was posted with
+type MastodonApp struct {
+	Name    string `json:"name"`
+	Website string `json:"website"`
+}
+
+// MastodonStatusEdit is one revision of an edited status
+type MastodonStatusEdit struct {
+	Content          string          `json:"content"`
+	SpoilerText      string          `json:"spoiler_text"`
+	Sensitive        bool            `json:"sensitive"`
+	CreatedAt        time.Time       `json:"created_at"`
+	MediaAttachments []MastodonMedia `json:"media_attachments"`
+}
+
+// MastodonReaction is the count of one emoji reaction to a status
+type MastodonReaction struct {
+	Name  string `json:"name"` // Unicode emoji, or shortcode for custom emoji
+	Count int    `json:"count"`
+	Me    bool   `json:"me"` // The user reacted with this emoji
+}
+
+// PleromaStatus holds the Pleroma and Akkoma extensions to a status
+type PleromaStatus struct {
+	EmojiReactions []MastodonReaction `json:"emoji_reactions"`
+}
+
+// EmojiReactions returns the emoji reactions the user's instance reports
+// for a status, from whichever extension it uses
+func (s MastodonStatus) EmojiReactions() []MastodonReaction {
+	if s.Pleroma != nil && len(s.Pleroma.EmojiReactions) > 0 {
+		return s.Pleroma.EmojiReactions
+	}
+	return s.Reactions
 }
 
 // MastodonAccount represents a Mastodon account
@@ -120,16 +246,11 @@ func (s *MastodonService) GetHomeTimeline(ctx context.Context, userID int, limit
 	return s.GetTimeline(ctx, userID, TimelineHome, limit, maxID)
 }
 
-// GetTimeline fetches any timeline type (home, local, or federated)
+// GetTimeline fetches any timeline type (home, local, federated, a hashtag or a list)
 func (s *MastodonService) GetTimeline(ctx context.Context, userID int, timelineType TimelineType, limit int, maxID string) ([]MastodonStatus, error) {
 	// Get the user's primary Mastodon token
 	var accessToken, instanceURL string
-	err := s.db.QueryRow(ctx, `
-		SELECT access_token, instance_url
-		FROM mastodon_tokens
-		WHERE user_id = $1 AND is_primary = true
-		LIMIT 1
-	`, userID).Scan(&accessToken, &instanceURL)
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user token: %w", err)
@@ -138,6 +259,16 @@ func (s *MastodonService) GetTimeline(ctx context.Context, userID int, timelineT
 	return s.fetchTimeline(ctx, instanceURL, accessToken, timelineType, limit, maxID)
 }
 
+// HasAccount reports whether the user has linked a Mastodon account
+func (s *MastodonService) HasAccount(ctx context.Context, userID int) (bool, error) {
+	var linked bool
+	err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM mastodon_tokens WHERE user_id = $1)", userID).Scan(&linked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check Mastodon account: %w", err)
+	}
+	return linked, nil
+}
+
 // GetPublicTimeline fetches the public/federated timeline (for anonymous users)
 func (s *MastodonService) GetPublicTimeline(ctx context.Context, instanceURL string, local bool, limit int, maxID string) ([]MastodonStatus, error) {
 	timelineType := TimelineFederated
@@ -147,8 +278,38 @@ func (s *MastodonService) GetPublicTimeline(ctx context.Context, instanceURL str
 	return s.fetchTimeline(ctx, instanceURL, "", timelineType, limit, maxID)
 }
 
-// fetchTimeline is a helper function to fetch any timeline
+// timelineFlights coalesces identical timeline fetches in flight at the same
+// time, e.g. from several sessions of one user, into one upstream request.
+// It works per node; sessions of one user on different nodes rarely ask for
+// the same page at the same moment, and a duplicate fetch does no harm.
+var timelineFlights singleflight.Group
+
+// fetchTimeline is a helper function to fetch any timeline. Concurrent calls
+// for the same page of the same timeline share one request; each caller
+// still stops waiting when its own context is done.
 func (s *MastodonService) fetchTimeline(ctx context.Context, instanceURL, accessToken string, timelineType TimelineType, limit int, maxID string) ([]MastodonStatus, error) {
+	key := strings.Join([]string{instanceURL, accessToken, string(timelineType), strconv.Itoa(limit), maxID}, "\x00")
+	flight := timelineFlights.DoChan(key, func() (any, error) {
+		// The request outlives a caller that gives up, for the others
+		sharedCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		return s.requestTimeline(sharedCtx, instanceURL, accessToken, timelineType, limit, maxID)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-flight:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		// Callers get their own slice, free to sort or filter
+		return slices.Clone(result.Val.([]MastodonStatus)), nil
+	}
+}
+
+// requestTimeline fetches a page of a timeline from the instance
+func (s *MastodonService) requestTimeline(ctx context.Context, instanceURL, accessToken string, timelineType TimelineType, limit int, maxID string) ([]MastodonStatus, error) {
 	// Build API URL based on timeline type
 	var apiURL string
 	switch timelineType {
@@ -159,6 +320,14 @@ func (s *MastodonService) fetchTimeline(ctx context.Context, instanceURL, access
 	case TimelineFederated:
 		apiURL = fmt.Sprintf("%s/api/v1/timelines/public?limit=%d", instanceURL, limit)
 	default:
+		if tag, ok := timelineType.Tag(); ok {
+			apiURL = fmt.Sprintf("%s/api/v1/timelines/tag/%s?limit=%d", instanceURL, url.PathEscape(tag), limit)
+			break
+		}
+		if list, ok := timelineType.List(); ok {
+			apiURL = fmt.Sprintf("%s/api/v1/timelines/list/%s?limit=%d", instanceURL, url.PathEscape(list), limit)
+			break
+		}
 		return nil, fmt.Errorf("invalid timeline type: %s", timelineType)
 	}
 
@@ -179,7 +348,7 @@ func (s *MastodonService) fetchTimeline(ctx context.Context, instanceURL, access
 	req.Header.Set("Content-Type", "application/json")
 
 	// Execute request
-	resp, err := s.client.Do(req)
+	resp, err := s.retry.do(s.client, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch timeline: %w", err)
 	}
@@ -187,7 +356,7 @@ func (s *MastodonService) fetchTimeline(ctx context.Context, instanceURL, access
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("mastodon API error %d: %s", resp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	// Parse response
@@ -202,12 +371,7 @@ func (s *MastodonService) fetchTimeline(ctx context.Context, instanceURL, access
 // FavouriteStatus likes/favourites a status
 func (s *MastodonService) FavouriteStatus(ctx context.Context, userID int, statusID string) error {
 	var accessToken, instanceURL string
-	err := s.db.QueryRow(ctx, `
-		SELECT access_token, instance_url
-		FROM mastodon_tokens
-		WHERE user_id = $1 AND is_primary = true
-		LIMIT 1
-	`, userID).Scan(&accessToken, &instanceURL)
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
 
 	if err != nil {
 		return fmt.Errorf("failed to get user token: %w", err)
@@ -220,7 +384,7 @@ func (s *MastodonService) FavouriteStatus(ctx context.Context, userID int, statu
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-	resp, err := s.client.Do(req)
+	resp, err := s.retry.do(s.client, req)
 	if err != nil {
 		return fmt.Errorf("failed to favourite status: %w", err)
 	}
@@ -234,36 +398,107 @@ func (s *MastodonService) FavouriteStatus(ctx context.Context, userID int, statu
 	return nil
 }
 
-// BoostStatus reblogs/boosts a status
-func (s *MastodonService) BoostStatus(ctx context.Context, userID int, statusID string) error {
-	var accessToken, instanceURL string
-	err := s.db.QueryRow(ctx, `
-		SELECT access_token, instance_url
-		FROM mastodon_tokens
-		WHERE user_id = $1 AND is_primary = true
-		LIMIT 1
-	`, userID).Scan(&accessToken, &instanceURL)
-
+// BoostStatus reblogs/boosts a status; visibility may be "public", "unlisted",
+// "private" or empty for the instance default
+func (s *MastodonService) BoostStatus(ctx context.Context, userID int, statusID, visibility string) error {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get user token: %w", err)
 	}
 
+	var body any
+	if visibility != "" {
+		body = map[string]string{"visibility": visibility}
+	}
+
 	apiURL := fmt.Sprintf("%s/api/v1/statuses/%s/reblog", instanceURL, statusID)
-	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, nil)
+	if err := s.doJSON(ctx, "POST", apiURL, accessToken, body, nil); err != nil {
+		return fmt.Errorf("failed to boost status: %w", err)
+	}
+
+	return nil
+}
+
+// BookmarkStatus adds a status to the user's bookmarks
+func (s *MastodonService) BookmarkStatus(ctx context.Context, userID int, statusID string) error {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to get user token: %w", err)
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-	resp, err := s.client.Do(req)
+	apiURL := fmt.Sprintf("%s/api/v1/statuses/%s/bookmark", instanceURL, statusID)
+	if err := s.doJSON(ctx, "POST", apiURL, accessToken, nil, nil); err != nil {
+		return fmt.Errorf("failed to bookmark status: %w", err)
+	}
+
+	return nil
+}
+
+// MuteAccount hides an account's posts from the user's timelines
+func (s *MastodonService) MuteAccount(ctx context.Context, userID int, accountID string) error {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("failed to boost status: %w", err)
+		return fmt.Errorf("failed to get user token: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("mastodon API error %d: %s", resp.StatusCode, string(body))
+	apiURL := fmt.Sprintf("%s/api/v1/accounts/%s/mute", instanceURL, accountID)
+	if err := s.doJSON(ctx, "POST", apiURL, accessToken, nil, nil); err != nil {
+		return fmt.Errorf("failed to mute account: %w", err)
+	}
+
+	return nil
+}
+
+// statusAction posts to one of the /api/v1/statuses/:id/<action> endpoints
+func (s *MastodonService) statusAction(ctx context.Context, userID int, statusID, action string) error {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v1/statuses/%s/%s", instanceURL, statusID, action)
+	if err := s.doJSON(ctx, "POST", apiURL, accessToken, nil, nil); err != nil {
+		return fmt.Errorf("failed to %s status: %w", action, err)
+	}
+
+	return nil
+}
+
+// UnfavouriteStatus removes a like from a status
+func (s *MastodonService) UnfavouriteStatus(ctx context.Context, userID int, statusID string) error {
+	return s.statusAction(ctx, userID, statusID, "unfavourite")
+}
+
+// UnreblogStatus removes a boost of a status
+func (s *MastodonService) UnreblogStatus(ctx context.Context, userID int, statusID string) error {
+	return s.statusAction(ctx, userID, statusID, "unreblog")
+}
+
+// UnbookmarkStatus removes a status from the user's bookmarks
+func (s *MastodonService) UnbookmarkStatus(ctx context.Context, userID int, statusID string) error {
+	return s.statusAction(ctx, userID, statusID, "unbookmark")
+}
+
+// PinStatus features one of the user's own statuses on their profile
+func (s *MastodonService) PinStatus(ctx context.Context, userID int, statusID string) error {
+	return s.statusAction(ctx, userID, statusID, "pin")
+}
+
+// UnpinStatus removes a status from the user's profile
+func (s *MastodonService) UnpinStatus(ctx context.Context, userID int, statusID string) error {
+	return s.statusAction(ctx, userID, statusID, "unpin")
+}
+
+// UnmuteAccount shows a muted account's posts again
+func (s *MastodonService) UnmuteAccount(ctx context.Context, userID int, accountID string) error {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v1/accounts/%s/unmute", instanceURL, accountID)
+	if err := s.doJSON(ctx, "POST", apiURL, accessToken, nil, nil); err != nil {
+		return fmt.Errorf("failed to unmute account: %w", err)
 	}
 
 	return nil
@@ -275,17 +510,15 @@ type PostStatusRequest struct {
 	Visibility  string `json:"visibility,omitempty"`
 	InReplyToID string `json:"in_reply_to_id,omitempty"`
 	SpoilerText string `json:"spoiler_text,omitempty"`
+	// Quote parameters; which one an instance understands is detected by QuoteParam
+	QuotedStatusID string `json:"quoted_status_id,omitempty"`
+	QuoteID        string `json:"quote_id,omitempty"`
 }
 
 // PostStatus creates a new status (post) on Mastodon
 func (s *MastodonService) PostStatus(ctx context.Context, userID int, content, visibility, inReplyToID, contentWarning string) (string, error) {
 	var accessToken, instanceURL string
-	err := s.db.QueryRow(ctx, `
-		SELECT access_token, instance_url
-		FROM mastodon_tokens
-		WHERE user_id = $1 AND is_primary = true
-		LIMIT 1
-	`, userID).Scan(&accessToken, &instanceURL)
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
 
 	if err != nil {
 		return "", fmt.Errorf("failed to get user token: %w", err)
@@ -316,7 +549,7 @@ func (s *MastodonService) PostStatus(ctx context.Context, userID int, content, v
 	req.Header.Set("Content-Type", "application/json")
 
 	// Execute request
-	resp, err := s.client.Do(req)
+	resp, err := s.retry.do(s.client, req)
 	if err != nil {
 		return "", fmt.Errorf("failed to post status: %w", err)
 	}
@@ -342,15 +575,26 @@ type StatusContext struct {
 	Descendants []MastodonStatus `json:"descendants"`
 }
 
+// GetStatusHistory fetches every revision of a status, oldest first
+func (s *MastodonService) GetStatusHistory(ctx context.Context, userID int, statusID string) ([]MastodonStatusEdit, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user token: %w", err)
+	}
+
+	var history []MastodonStatusEdit
+	apiURL := fmt.Sprintf("%s/api/v1/statuses/%s/history", instanceURL, statusID)
+	if err := s.doJSON(ctx, "GET", apiURL, accessToken, nil, &history); err != nil {
+		return nil, fmt.Errorf("failed to fetch status history: %w", err)
+	}
+
+	return history, nil
+}
+
 // GetStatusContext fetches the context (thread) for a given status
 func (s *MastodonService) GetStatusContext(ctx context.Context, userID int, statusID string) (*StatusContext, error) {
 	var accessToken, instanceURL string
-	err := s.db.QueryRow(ctx, `
-		SELECT access_token, instance_url
-		FROM mastodon_tokens
-		WHERE user_id = $1 AND is_primary = true
-		LIMIT 1
-	`, userID).Scan(&accessToken, &instanceURL)
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user token: %w", err)
@@ -365,7 +609,7 @@ func (s *MastodonService) GetStatusContext(ctx context.Context, userID int, stat
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := s.client.Do(req)
+	resp, err := s.retry.do(s.client, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch status context: %w", err)
 	}
@@ -387,12 +631,7 @@ func (s *MastodonService) GetStatusContext(ctx context.Context, userID int, stat
 // GetAccount fetches account information for a given account ID
 func (s *MastodonService) GetAccount(ctx context.Context, userID int, accountID string) (*MastodonAccount, error) {
 	var accessToken, instanceURL string
-	err := s.db.QueryRow(ctx, `
-		SELECT access_token, instance_url
-		FROM mastodon_tokens
-		WHERE user_id = $1 AND is_primary = true
-		LIMIT 1
-	`, userID).Scan(&accessToken, &instanceURL)
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user token: %w", err)
@@ -407,7 +646,7 @@ func (s *MastodonService) GetAccount(ctx context.Context, userID int, accountID
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := s.client.Do(req)
+	resp, err := s.retry.do(s.client, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch account: %w", err)
 	}
@@ -426,15 +665,27 @@ func (s *MastodonService) GetAccount(ctx context.Context, userID int, accountID
 	return &account, nil
 }
 
+// LookupAccount finds an account by its handle, user@domain or just user
+// for accounts on the user's instance
+func (s *MastodonService) LookupAccount(ctx context.Context, userID int, acct string) (*MastodonAccount, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var account MastodonAccount
+	apiURL := fmt.Sprintf("%s/api/v1/accounts/lookup?acct=%s", instanceURL, url.QueryEscape(strings.TrimPrefix(acct, "@")))
+	if err := s.doJSON(ctx, "GET", apiURL, accessToken, nil, &account); err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", acct, err)
+	}
+
+	return &account, nil
+}
+
 // GetAccountStatuses fetches recent statuses for a given account
 func (s *MastodonService) GetAccountStatuses(ctx context.Context, userID int, accountID string, limit int) ([]MastodonStatus, error) {
 	var accessToken, instanceURL string
-	err := s.db.QueryRow(ctx, `
-		SELECT access_token, instance_url
-		FROM mastodon_tokens
-		WHERE user_id = $1 AND is_primary = true
-		LIMIT 1
-	`, userID).Scan(&accessToken, &instanceURL)
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user token: %w", err)
@@ -449,7 +700,7 @@ func (s *MastodonService) GetAccountStatuses(ctx context.Context, userID int, ac
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := s.client.Do(req)
+	resp, err := s.retry.do(s.client, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch account statuses: %w", err)
 	}
@@ -478,15 +729,26 @@ type AccountRelationship struct {
 	Requested  bool   `json:"requested"`
 }
 
+// GetPinnedStatuses fetches the statuses an account pinned to its profile
+func (s *MastodonService) GetPinnedStatuses(ctx context.Context, userID int, accountID string) ([]MastodonStatus, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user token: %w", err)
+	}
+
+	var statuses []MastodonStatus
+	apiURL := fmt.Sprintf("%s/api/v1/accounts/%s/statuses?pinned=true", instanceURL, accountID)
+	if err := s.doJSON(ctx, "GET", apiURL, accessToken, nil, &statuses); err != nil {
+		return nil, fmt.Errorf("failed to fetch pinned statuses: %w", err)
+	}
+
+	return statuses, nil
+}
+
 // GetAccountRelationship fetches the relationship with a given account
 func (s *MastodonService) GetAccountRelationship(ctx context.Context, userID int, accountID string) (*AccountRelationship, error) {
 	var accessToken, instanceURL string
-	err := s.db.QueryRow(ctx, `
-		SELECT access_token, instance_url
-		FROM mastodon_tokens
-		WHERE user_id = $1 AND is_primary = true
-		LIMIT 1
-	`, userID).Scan(&accessToken, &instanceURL)
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user token: %w", err)
@@ -501,7 +763,7 @@ func (s *MastodonService) GetAccountRelationship(ctx context.Context, userID int
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := s.client.Do(req)
+	resp, err := s.retry.do(s.client, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch relationship: %w", err)
 	}
@@ -527,12 +789,7 @@ func (s *MastodonService) GetAccountRelationship(ctx context.Context, userID int
 // FollowAccount follows a given account
 func (s *MastodonService) FollowAccount(ctx context.Context, userID int, accountID string) error {
 	var accessToken, instanceURL string
-	err := s.db.QueryRow(ctx, `
-		SELECT access_token, instance_url
-		FROM mastodon_tokens
-		WHERE user_id = $1 AND is_primary = true
-		LIMIT 1
-	`, userID).Scan(&accessToken, &instanceURL)
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
 
 	if err != nil {
 		return fmt.Errorf("failed to get user token: %w", err)
@@ -545,7 +802,7 @@ func (s *MastodonService) FollowAccount(ctx context.Context, userID int, account
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-	resp, err := s.client.Do(req)
+	resp, err := s.retry.do(s.client, req)
 	if err != nil {
 		return fmt.Errorf("failed to follow account: %w", err)
 	}
@@ -562,12 +819,7 @@ func (s *MastodonService) FollowAccount(ctx context.Context, userID int, account
 // UnfollowAccount unfollows a given account
 func (s *MastodonService) UnfollowAccount(ctx context.Context, userID int, accountID string) error {
 	var accessToken, instanceURL string
-	err := s.db.QueryRow(ctx, `
-		SELECT access_token, instance_url
-		FROM mastodon_tokens
-		WHERE user_id = $1 AND is_primary = true
-		LIMIT 1
-	`, userID).Scan(&accessToken, &instanceURL)
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
 
 	if err != nil {
 		return fmt.Errorf("failed to get user token: %w", err)
@@ -580,7 +832,7 @@ func (s *MastodonService) UnfollowAccount(ctx context.Context, userID int, accou
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-	resp, err := s.client.Do(req)
+	resp, err := s.retry.do(s.client, req)
 	if err != nil {
 		return fmt.Errorf("failed to unfollow account: %w", err)
 	}
@@ -620,12 +872,7 @@ type MastodonNotification struct {
 // GetNotifications fetches notifications for the authenticated user
 func (s *MastodonService) GetNotifications(ctx context.Context, userID int, limit int, maxID string) ([]MastodonNotification, error) {
 	var accessToken, instanceURL string
-	err := s.db.QueryRow(ctx, `
-		SELECT access_token, instance_url
-		FROM mastodon_tokens
-		WHERE user_id = $1 AND is_primary = true
-		LIMIT 1
-	`, userID).Scan(&accessToken, &instanceURL)
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user token: %w", err)
@@ -644,7 +891,7 @@ func (s *MastodonService) GetNotifications(ctx context.Context, userID int, limi
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := s.client.Do(req)
+	resp, err := s.retry.do(s.client, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch notifications: %w", err)
 	}
@@ -663,15 +910,51 @@ func (s *MastodonService) GetNotifications(ctx context.Context, userID int, limi
 	return notifications, nil
 }
 
+// GetMentionsSince fetches mention notifications (including direct messages) newer than sinceID
+func (s *MastodonService) GetMentionsSince(ctx context.Context, userID int, sinceID string, limit int) ([]MastodonNotification, error) {
+	var accessToken, instanceURL string
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user token: %w", err)
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v1/notifications?types[]=mention&limit=%d", instanceURL, limit)
+	if sinceID != "" {
+		apiURL += fmt.Sprintf("&since_id=%s", sinceID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.retry.do(s.client, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch mentions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("mastodon API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var notifications []MastodonNotification
+	if err := json.NewDecoder(resp.Body).Decode(&notifications); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return notifications, nil
+}
+
 // DismissNotification dismisses a single notification
 func (s *MastodonService) DismissNotification(ctx context.Context, userID int, notificationID string) error {
 	var accessToken, instanceURL string
-	err := s.db.QueryRow(ctx, `
-		SELECT access_token, instance_url
-		FROM mastodon_tokens
-		WHERE user_id = $1 AND is_primary = true
-		LIMIT 1
-	`, userID).Scan(&accessToken, &instanceURL)
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
 
 	if err != nil {
 		return fmt.Errorf("failed to get user token: %w", err)
@@ -684,7 +967,7 @@ func (s *MastodonService) DismissNotification(ctx context.Context, userID int, n
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-	resp, err := s.client.Do(req)
+	resp, err := s.retry.do(s.client, req)
 	if err != nil {
 		return fmt.Errorf("failed to dismiss notification: %w", err)
 	}
@@ -701,12 +984,7 @@ func (s *MastodonService) DismissNotification(ctx context.Context, userID int, n
 // ClearAllNotifications clears all notifications for the authenticated user
 func (s *MastodonService) ClearAllNotifications(ctx context.Context, userID int) error {
 	var accessToken, instanceURL string
-	err := s.db.QueryRow(ctx, `
-		SELECT access_token, instance_url
-		FROM mastodon_tokens
-		WHERE user_id = $1 AND is_primary = true
-		LIMIT 1
-	`, userID).Scan(&accessToken, &instanceURL)
+	err := s.db.QueryRow(ctx, primaryTokenStmt, userID).Scan(&accessToken, &instanceURL)
 
 	if err != nil {
 		return fmt.Errorf("failed to get user token: %w", err)
@@ -719,7 +997,7 @@ func (s *MastodonService) ClearAllNotifications(ctx context.Context, userID int)
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-	resp, err := s.client.Do(req)
+	resp, err := s.retry.do(s.client, req)
 	if err != nil {
 		return fmt.Errorf("failed to clear notifications: %w", err)
 	}
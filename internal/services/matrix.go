@@ -0,0 +1,422 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/outbound"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// matrixNotificationPage bounds the notifications fetched for one user per pass
+const matrixNotificationPage = 40
+
+// ErrMatrixDisabled is returned when the instance does not offer the Matrix bridge
+var ErrMatrixDisabled = errors.New("the Matrix bridge is not enabled on this instance")
+
+// matrixClient talks to Matrix homeservers
+var matrixClient = outbound.NewClient(15 * time.Second)
+
+// MatrixService forwards the mentions and direct messages users receive
+// while they are not connected to a Matrix room of their choice
+type MatrixService struct {
+	db       *pgxpool.Pool
+	cfg      *config.Config
+	mastodon *MastodonService
+	presence *PresenceService
+}
+
+// NewMatrixService creates a new MatrixService instance
+func NewMatrixService(db *pgxpool.Pool, redisClient redis.UniversalClient, cfg *config.Config) *MatrixService {
+	return &MatrixService{
+		db:       db,
+		cfg:      cfg,
+		mastodon: NewMastodonService(db, cfg),
+		presence: NewPresenceService(db, redisClient),
+	}
+}
+
+// Enabled reports whether the instance offers the Matrix bridge
+func (s *MatrixService) Enabled() bool {
+	return s.cfg.Features.Matrix.Enabled
+}
+
+// Bridge returns the user's Matrix bridge, or nil if there is none
+func (s *MatrixService) Bridge(ctx context.Context, userID int) (*models.MatrixBridge, error) {
+	bridge := &models.MatrixBridge{UserID: userID}
+	err := s.db.QueryRow(ctx, `
+		SELECT homeserver, matrix_user_id, room_id, forward_mentions, forward_direct,
+		       last_forwarded_at, last_error, created_at
+		FROM matrix_bridges WHERE user_id = $1
+	`, userID).Scan(&bridge.Homeserver, &bridge.MatrixUserID, &bridge.RoomID, &bridge.ForwardMentions,
+		&bridge.ForwardDirect, &bridge.LastForwardedAt, &bridge.LastError, &bridge.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Matrix bridge: %w", err)
+	}
+	return bridge, nil
+}
+
+// Link checks an access token on a homeserver, joins the room, given by ID
+// or alias, and stores both. Forwarding starts with notifications received
+// from then on.
+func (s *MatrixService) Link(ctx context.Context, userID int, homeserver, accessToken, room string) (*models.MatrixBridge, error) {
+	if !s.Enabled() {
+		return nil, ErrMatrixDisabled
+	}
+	accessToken, room = strings.TrimSpace(accessToken), strings.TrimSpace(room)
+	if accessToken == "" || room == "" {
+		return nil, fmt.Errorf("enter an access token and a room")
+	}
+	if !strings.HasPrefix(room, "!") && !strings.HasPrefix(room, "#") {
+		return nil, fmt.Errorf("room must be an ID (!abc:server) or an alias (#room:server)")
+	}
+	homeserver, err := normalizeHomeserver(homeserver)
+	if err != nil {
+		return nil, err
+	}
+
+	var whoami struct {
+		UserID string `json:"user_id"`
+	}
+	if err := matrixRequest(ctx, http.MethodGet, homeserver+"/_matrix/client/v3/account/whoami", accessToken, nil, &whoami); err != nil {
+		return nil, fmt.Errorf("Matrix sign-in failed: %w", err)
+	}
+	// Joining is a no-op in a room the account is already in
+	var joined struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := matrixRequest(ctx, http.MethodPost, homeserver+"/_matrix/client/v3/join/"+url.PathEscape(room), accessToken, map[string]any{}, &joined); err != nil {
+		return nil, fmt.Errorf("failed to join %s: %w", room, err)
+	}
+	sealed, err := sealSecret(s.cfg.Security.SecretKey, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	bridge := &models.MatrixBridge{UserID: userID, Homeserver: homeserver, MatrixUserID: whoami.UserID, RoomID: joined.RoomID}
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO matrix_bridges (user_id, homeserver, matrix_user_id, access_token, room_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE
+		SET homeserver = $2, matrix_user_id = $3, access_token = $4, room_id = $5,
+		    last_notification_id = '', last_error = '', updated_at = NOW()
+		RETURNING forward_mentions, forward_direct, created_at
+	`, userID, homeserver, whoami.UserID, sealed, joined.RoomID).Scan(&bridge.ForwardMentions, &bridge.ForwardDirect, &bridge.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save Matrix bridge: %w", err)
+	}
+	return bridge, nil
+}
+
+// Unlink stops forwarding and forgets the access token
+func (s *MatrixService) Unlink(ctx context.Context, userID int) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM matrix_bridges WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to unlink Matrix: %w", err)
+	}
+	return nil
+}
+
+// SetForwarding chooses what is forwarded: mentions, direct messages or both
+func (s *MatrixService) SetForwarding(ctx context.Context, userID int, mentions, direct bool) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE matrix_bridges SET forward_mentions = $2, forward_direct = $3, updated_at = NOW()
+		WHERE user_id = $1
+	`, userID, mentions, direct)
+	if err != nil {
+		return fmt.Errorf("failed to update Matrix bridge: %w", err)
+	}
+	return nil
+}
+
+// Test sends a message to the room of the user's bridge
+func (s *MatrixService) Test(ctx context.Context, userID int) error {
+	homeserver, accessToken, roomID, err := s.credentials(ctx, userID)
+	if err != nil {
+		return err
+	}
+	text := "terminalpub will forward your mentions and direct messages here while you are away."
+	return sendMatrixMessage(ctx, homeserver, accessToken, roomID, fmt.Sprintf("test-%d", time.Now().UnixNano()), text, html.EscapeString(text))
+}
+
+// credentials returns the homeserver, access token and room of a bridge
+func (s *MatrixService) credentials(ctx context.Context, userID int) (string, string, string, error) {
+	var homeserver, sealed, roomID string
+	err := s.db.QueryRow(ctx, `
+		SELECT homeserver, access_token, room_id FROM matrix_bridges WHERE user_id = $1
+	`, userID).Scan(&homeserver, &sealed, &roomID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", "", fmt.Errorf("no Matrix room linked")
+	}
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to load Matrix bridge: %w", err)
+	}
+	accessToken, err := openSecret(s.cfg.Security.SecretKey, sealed)
+	if err != nil {
+		return "", "", "", err
+	}
+	return homeserver, accessToken, roomID, nil
+}
+
+// ForwardPending forwards up to limit mentions and direct messages users
+// received while not connected and returns how many were sent. Connected
+// users are skipped and what arrived meanwhile is never forwarded, and
+// neither is anything up to the read marker of the notifications screen,
+// which they have seen already. A bridge whose room fails keeps its place
+// and is retried on a later pass.
+func (s *MatrixService) ForwardPending(ctx context.Context, limit int) (int, error) {
+	if !s.Enabled() {
+		return 0, nil
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT b.user_id, b.forward_mentions, b.forward_direct, b.last_notification_id,
+		       b.last_online_at, COALESCE(m.last_read_id, '')
+		FROM matrix_bridges b
+		LEFT JOIN read_markers m ON m.user_id = b.user_id AND m.timeline = 'notifications'
+		WHERE b.forward_mentions OR b.forward_direct
+		ORDER BY b.last_checked_at NULLS FIRST
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load Matrix bridges: %w", err)
+	}
+	type pending struct {
+		userID             int
+		mentions, direct   bool
+		cursor, readMarker string
+		lastOnline         *time.Time
+	}
+	var bridges []pending
+	for rows.Next() {
+		var b pending
+		if err := rows.Scan(&b.userID, &b.mentions, &b.direct, &b.cursor, &b.lastOnline, &b.readMarker); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan Matrix bridge: %w", err)
+		}
+		bridges = append(bridges, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to load Matrix bridges: %w", err)
+	}
+
+	forwarded := 0
+	for _, b := range bridges {
+		if forwarded >= limit {
+			break
+		}
+		connected, err := s.presence.Connected(ctx, b.userID)
+		if err != nil {
+			return forwarded, err
+		}
+		if connected {
+			_, err = s.db.Exec(ctx, `
+				UPDATE matrix_bridges SET last_online_at = NOW(), last_checked_at = NOW() WHERE user_id = $1
+			`, b.userID)
+			if err != nil {
+				return forwarded, fmt.Errorf("failed to update Matrix bridge: %w", err)
+			}
+			continue
+		}
+
+		sent, err := s.forward(ctx, b.userID, b.mentions, b.direct, b.cursor, b.readMarker, b.lastOnline, limit-forwarded)
+		forwarded += sent
+		lastError := ""
+		if err != nil {
+			log.Printf("Failed to forward notifications of user %d to Matrix: %v", b.userID, err)
+			lastError = err.Error()
+		}
+		_, err = s.db.Exec(ctx, `
+			UPDATE matrix_bridges SET last_error = $2, last_checked_at = NOW() WHERE user_id = $1
+		`, b.userID, lastError)
+		if err != nil {
+			return forwarded, fmt.Errorf("failed to update Matrix bridge: %w", err)
+		}
+	}
+	return forwarded, nil
+}
+
+// forward sends a user's new mentions to their room, oldest first, moving
+// the bridge's cursor past each one sent or skipped
+func (s *MatrixService) forward(ctx context.Context, userID int, mentions, direct bool, cursor, readMarker string, lastOnline *time.Time, limit int) (int, error) {
+	accessToken, instanceURL, err := s.mastodon.getPrimaryToken(ctx, userID)
+	if err != nil {
+		// Without a Mastodon account there is nothing to forward
+		return 0, nil
+	}
+
+	apiURL := instanceURL + "/api/v1/notifications?types[]=mention"
+	if cursor == "" {
+		// A new bridge starts from the latest notification
+		var latest []MastodonNotification
+		if err := s.mastodon.doJSON(ctx, "GET", apiURL+"&limit=1", accessToken, nil, &latest); err != nil {
+			return 0, fmt.Errorf("failed to fetch notifications: %w", err)
+		}
+		cursor = "0"
+		if len(latest) > 0 {
+			cursor = latest[0].ID
+		}
+		return 0, s.advance(ctx, userID, cursor, false)
+	}
+	if StatusIDNewer(readMarker, cursor) {
+		cursor = readMarker
+	}
+
+	// min_id pages forward from the cursor, newest first within the page
+	var notifications []MastodonNotification
+	apiURL += fmt.Sprintf("&limit=%d&min_id=%s", matrixNotificationPage, url.QueryEscape(cursor))
+	if err := s.mastodon.doJSON(ctx, "GET", apiURL, accessToken, nil, &notifications); err != nil {
+		return 0, fmt.Errorf("failed to fetch notifications: %w", err)
+	}
+	homeserver, matrixToken, roomID, err := s.credentials(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := len(notifications) - 1; i >= 0 && sent < limit; i-- {
+		n := notifications[i]
+		isDirect := n.Status != nil && n.Status.Visibility == "direct"
+		wanted := (isDirect && direct) || (!isDirect && mentions)
+		if wanted && n.Status != nil && (lastOnline == nil || n.CreatedAt.After(*lastOnline)) {
+			text, formatted := matrixNotification(n, isDirect)
+			// The notification ID as transaction ID makes the homeserver
+			// drop a message sent twice after a failed update
+			if err := sendMatrixMessage(ctx, homeserver, matrixToken, roomID, "terminalpub-"+n.ID, text, formatted); err != nil {
+				return sent, err
+			}
+			sent++
+			if err := s.advance(ctx, userID, n.ID, true); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		if err := s.advance(ctx, userID, n.ID, false); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// advance moves the cursor of a bridge past a notification
+func (s *MatrixService) advance(ctx context.Context, userID int, notificationID string, forwarded bool) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE matrix_bridges
+		SET last_notification_id = $2,
+		    last_forwarded_at = CASE WHEN $3 THEN NOW() ELSE last_forwarded_at END
+		WHERE user_id = $1
+	`, userID, notificationID, forwarded)
+	if err != nil {
+		return fmt.Errorf("failed to update Matrix bridge: %w", err)
+	}
+	return nil
+}
+
+// matrixNotification formats a mention as a plain and an HTML message
+func matrixNotification(n MastodonNotification, direct bool) (string, string) {
+	what := "mentioned you"
+	if direct {
+		what = "sent you a direct message"
+	}
+	acct := "@" + n.Account.Acct
+	body := notificationBody(n.Status.Content)
+	text := fmt.Sprintf("%s %s: %s", acct, what, body)
+	formatted := fmt.Sprintf("<b>%s</b> %s: %s", html.EscapeString(acct), what, html.EscapeString(body))
+	if n.Status.URL != "" {
+		text += "\n" + n.Status.URL
+		formatted += fmt.Sprintf(`<br><a href="%s">%s</a>`, html.EscapeString(n.Status.URL), html.EscapeString(n.Status.URL))
+	}
+	return text, formatted
+}
+
+// sendMatrixMessage sends a text message to a room
+func sendMatrixMessage(ctx context.Context, homeserver, accessToken, roomID, txnID, text, formatted string) error {
+	apiURL := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s", homeserver, url.PathEscape(roomID), url.PathEscape(txnID))
+	body := map[string]string{
+		"msgtype":        "m.notice",
+		"body":           text,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted,
+	}
+	var sent struct {
+		EventID string `json:"event_id"`
+	}
+	if err := matrixRequest(ctx, http.MethodPut, apiURL, accessToken, body, &sent); err != nil {
+		return fmt.Errorf("failed to send Matrix message: %w", err)
+	}
+	return nil
+}
+
+// matrixRequest calls the client-server API of a homeserver, decoding the
+// response into out
+func matrixRequest(ctx context.Context, method, apiURL, accessToken string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := matrixClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var matrixErr struct {
+			ErrCode string `json:"errcode"`
+			Error   string `json:"error"`
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(respBody, &matrixErr) == nil && matrixErr.Error != "" {
+			return fmt.Errorf("%s", matrixErr.Error)
+		}
+		return fmt.Errorf("Matrix error %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// normalizeHomeserver checks the URL of a homeserver
+func normalizeHomeserver(homeserver string) (string, error) {
+	homeserver = strings.TrimRight(strings.TrimSpace(homeserver), "/")
+	if homeserver == "" {
+		return "", fmt.Errorf("enter your homeserver, e.g. matrix.org")
+	}
+	if !strings.Contains(homeserver, "://") {
+		homeserver = "https://" + homeserver
+	}
+	parsed, err := url.Parse(homeserver)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return "", fmt.Errorf("homeserver must be an https URL")
+	}
+	return homeserver, nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxAliases bounds how many accounts a user can list as former identities
+const maxAliases = 10
+
+// ErrMoveNotVerified is returned when the target of a move does not list the
+// moving account as an alias
+var ErrMoveNotVerified = errors.New("target account does not list this account in alsoKnownAs")
+
+// ErrMoveNotAnnounced is returned when a remote account that sent a Move does
+// not name its target in movedTo on its own server
+var ErrMoveNotAnnounced = errors.New("moved account does not name the target in movedTo")
+
+// MigrationService handles account migration: aliases for moving to
+// terminalpub, Move activities for leaving it, and following remote moves
+type MigrationService struct {
+	db           *pgxpool.Pool
+	cfg          *config.Config
+	interactions *InteractionService
+}
+
+// NewMigrationService creates a new MigrationService instance
+func NewMigrationService(db *pgxpool.Pool, cfg *config.Config) *MigrationService {
+	return &MigrationService{db: db, cfg: cfg, interactions: NewInteractionService(db, cfg)}
+}
+
+// ListAliases returns the actor IDs the user has listed as former accounts
+func (s *MigrationService) ListAliases(ctx context.Context, userID int) ([]string, error) {
+	var aliases []string
+	err := s.db.QueryRow(ctx, "SELECT also_known_as FROM users WHERE id = $1", userID).Scan(&aliases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// AddAlias lists a remote account (user@domain or actor URL) as a former
+// identity, so that account can move its followers here
+func (s *MigrationService) AddAlias(ctx context.Context, userID int, account string) (string, error) {
+	actorID, err := s.interactions.actors.Resolve(ctx, account)
+	if err != nil {
+		return "", err
+	}
+
+	aliases, err := s.ListAliases(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if slices.Contains(aliases, actorID) {
+		return actorID, nil
+	}
+	if len(aliases) >= maxAliases {
+		return "", fmt.Errorf("at most %d aliases are allowed", maxAliases)
+	}
+
+	if err := s.saveAliases(ctx, userID, append(aliases, actorID)); err != nil {
+		return "", err
+	}
+	return actorID, nil
+}
+
+// RemoveAlias removes a former identity
+func (s *MigrationService) RemoveAlias(ctx context.Context, userID int, account string) error {
+	actorID, err := s.interactions.actors.Resolve(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	aliases, err := s.ListAliases(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(aliases, actorID) {
+		return fmt.Errorf("%s is not an alias", actorID)
+	}
+
+	return s.saveAliases(ctx, userID, slices.DeleteFunc(aliases, func(alias string) bool { return alias == actorID }))
+}
+
+// saveAliases stores the alias list and tells followers about the new actor
+func (s *MigrationService) saveAliases(ctx context.Context, userID int, aliases []string) error {
+	_, err := s.db.Exec(ctx, "UPDATE users SET also_known_as = $2, updated_at = NOW() WHERE id = $1", userID, aliases)
+	if err != nil {
+		return fmt.Errorf("failed to save aliases: %w", err)
+	}
+	s.deliverProfileUpdate(userID)
+	return nil
+}
+
+// MoveTo migrates the user's followers to another account, which must
+// already list this account in its alsoKnownAs
+func (s *MigrationService) MoveTo(ctx context.Context, userID int, account string) (string, error) {
+	actor, err := s.interactions.loadActor(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	targetID, err := s.interactions.actors.Resolve(ctx, account)
+	if err != nil {
+		return "", err
+	}
+	if targetID == actor.id {
+		return "", fmt.Errorf("cannot move to the same account")
+	}
+
+	target, err := s.interactions.actors.Refresh(ctx, targetID, actor.privateKey, actor.keyID)
+	if err != nil {
+		return "", err
+	}
+	if !listsAlias(target, actor.id) {
+		return "", ErrMoveNotVerified
+	}
+
+	_, err = s.db.Exec(ctx, "UPDATE users SET moved_to = $2, updated_at = NOW() WHERE id = $1", userID, targetID)
+	if err != nil {
+		return "", fmt.Errorf("failed to save move: %w", err)
+	}
+
+	move := activitypub.NewMove(s.cfg.Server.BaseURL, actor.username, targetID)
+	err = s.interactions.inTx(ctx, func(tx pgx.Tx) error {
+		return recordOutbound(ctx, tx, userID, move, actor.id)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Followers refetch the actor and check movedTo when handling the Move
+	s.deliverProfileUpdate(userID)
+	s.interactions.deliverToFollowersAsync(actor, "", move)
+	return targetID, nil
+}
+
+// HandleMove moves local follows of a remote account to its new account,
+// once the old account, fetched from its own server, names the new one in
+// movedTo and the new account confirms the alias
+func (s *MigrationService) HandleMove(ctx context.Context, activity map[string]any) error {
+	oldID, _ := activity["actor"].(string)
+	object, _ := activity["object"].(string)
+	if obj, ok := activity["object"].(map[string]any); ok {
+		object, _ = obj["id"].(string)
+	}
+	newID, _ := activity["target"].(string)
+	if oldID == "" || newID == "" || object != oldID {
+		return fmt.Errorf("move is missing actor or target")
+	}
+
+	rows, err := s.db.Query(ctx, "SELECT user_id FROM following WHERE target_actor_id = $1", oldID)
+	if err != nil {
+		return fmt.Errorf("failed to load followers of moved account: %w", err)
+	}
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan follower: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load followers of moved account: %w", err)
+	}
+	if len(userIDs) == 0 || !s.cfg.ActivityPub.FollowMoves {
+		return nil
+	}
+
+	// Verify with any local user's key. Anyone can alias an account, so the
+	// old account must point at the new one too, as its server says
+	actor, err := s.interactions.loadActor(ctx, userIDs[0])
+	if err != nil {
+		return err
+	}
+	origin, err := s.interactions.actors.Refresh(ctx, oldID, actor.privateKey, actor.keyID)
+	if err != nil {
+		return err
+	}
+	if movedTo, _ := origin["movedTo"].(string); movedTo != newID {
+		return ErrMoveNotAnnounced
+	}
+	target, err := s.interactions.actors.Refresh(ctx, newID, actor.privateKey, actor.keyID)
+	if err != nil {
+		return err
+	}
+	if !listsAlias(target, oldID) {
+		return ErrMoveNotVerified
+	}
+
+	for _, userID := range userIDs {
+		if _, err := s.interactions.Follow(ctx, userID, newID); err != nil {
+			log.Printf("Failed to follow %s for user %d after move: %v", newID, userID, err)
+			continue
+		}
+		if err := s.interactions.Unfollow(ctx, userID, oldID); err != nil {
+			log.Printf("Failed to unfollow %s for user %d after move: %v", oldID, userID, err)
+		}
+	}
+	return nil
+}
+
+// listsAlias reports whether a remote actor lists actorID in alsoKnownAs
+func listsAlias(actor map[string]any, actorID string) bool {
+	switch aliases := actor["alsoKnownAs"].(type) {
+	case string:
+		return aliases == actorID
+	case []any:
+		for _, alias := range aliases {
+			if alias == actorID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deliverProfileUpdate sends the changed actor to followers in the background
+func (s *MigrationService) deliverProfileUpdate(userID int) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		if err := NewAccountService(s.db, nil, s.cfg).DeliverProfileUpdate(ctx, userID); err != nil {
+			log.Printf("Failed to deliver profile update for user %d: %v", userID, err)
+		}
+	}()
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrUserNotFound is returned when moderating a local user that does not exist
+	ErrUserNotFound = errors.New("no local user with that name")
+	// ErrNotRejected is returned when lifting a rejection that does not exist
+	ErrNotRejected = errors.New("actor is not rejected")
+)
+
+// ModerationService holds the admin tools: suspending and silencing local
+// users, removing their posts and rejecting remote actors. Suspended users
+// cannot log in and their actor answers 410 Gone; silenced users' posts are
+// left out of the public timelines, hashtags, search and the directory.
+type ModerationService struct {
+	db       *pgxpool.Pool
+	posts    *PostService
+	actors   *RemoteActorService
+	presence *PresenceService
+}
+
+// NewModerationService creates a new ModerationService instance. redisClient
+// carries the signal ending the connected sessions of suspended users; it
+// may be nil for a service that never suspends anyone.
+func NewModerationService(db *pgxpool.Pool, redisClient redis.UniversalClient, cfg *config.Config) *ModerationService {
+	s := &ModerationService{db: db, posts: NewPostService(db, cfg), actors: NewRemoteActorService(db, cfg)}
+	if redisClient != nil {
+		s.presence = NewPresenceService(db, redisClient)
+	}
+	return s
+}
+
+// Suspend locks a local user out and hides their account from other servers
+func (s *ModerationService) Suspend(ctx context.Context, adminID int, username string) error {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return err
+	}
+	username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+	if err := s.setSuspended(ctx, username, true); err != nil {
+		return err
+	}
+	recordAdminAction(ctx, s.db, adminID, "suspended %s", username)
+	return nil
+}
+
+// Unsuspend lifts a suspension
+func (s *ModerationService) Unsuspend(ctx context.Context, adminID int, username string) error {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return err
+	}
+	username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+	if err := s.setSuspended(ctx, username, false); err != nil {
+		return err
+	}
+	recordAdminAction(ctx, s.db, adminID, "unsuspended %s", username)
+	return nil
+}
+
+// setSuspended suspends a local user or lifts their suspension, for both
+// the SSH commands and "terminalpub admin". Suspending revokes their API and
+// OAuth tokens, pending authorizations and sessions, and ends the sessions
+// still connected, so nothing they signed in with outlives the suspension.
+func (s *ModerationService) setSuspended(ctx context.Context, username string, suspended bool) error {
+	if !suspended {
+		return setModerationTimestamp(ctx, s.db, username, "suspended_at", false)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var userID int
+	err = tx.QueryRow(ctx, `
+		UPDATE users SET suspended_at = COALESCE(suspended_at, NOW())
+		WHERE username = $1 AND deleted_at IS NULL
+		RETURNING id
+	`, username).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	for _, query := range []string{
+		"DELETE FROM api_tokens WHERE user_id = $1",
+		"DELETE FROM oauth_authorization_codes WHERE user_id = $1",
+		"DELETE FROM oauth_login_codes WHERE user_id = $1",
+		"DELETE FROM sessions WHERE user_id = $1",
+	} {
+		if _, err := tx.Exec(ctx, query, userID); err != nil {
+			return fmt.Errorf("failed to revoke credentials: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit suspension: %w", err)
+	}
+
+	if s.presence != nil {
+		if err := s.presence.EndSessions(ctx, userID); err != nil {
+			log.Printf("Failed to end sessions of suspended user %d: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// Silence keeps a local user's posts out of public listings; their
+// followers still see them
+func (s *ModerationService) Silence(ctx context.Context, adminID int, username string) error {
+	return s.setUserState(ctx, adminID, username, "silenced_at", true, "silenced")
+}
+
+// Unsilence lifts a silence
+func (s *ModerationService) Unsilence(ctx context.Context, adminID int, username string) error {
+	return s.setUserState(ctx, adminID, username, "silenced_at", false, "unsilenced")
+}
+
+// setUserState sets or clears one of the moderation timestamps of a user
+func (s *ModerationService) setUserState(ctx context.Context, adminID int, username, column string, set bool, verb string) error {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return err
+	}
+	username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+	if err := setModerationTimestamp(ctx, s.db, username, column, set); err != nil {
+		return err
+	}
+	recordAdminAction(ctx, s.db, adminID, "%s %s", verb, username)
+	return nil
+}
+
+// setModerationTimestamp sets column, suspended_at or silenced_at, of a
+// local user to now, keeping an earlier time, or clears it
+func setModerationTimestamp(ctx context.Context, db *pgxpool.Pool, username, column string, set bool) error {
+	value := "NULL"
+	if set {
+		value = "COALESCE(" + column + ", NOW())"
+	}
+	tag, err := db.Exec(ctx, `UPDATE users SET `+column+` = `+value+` WHERE username = $1 AND deleted_at IS NULL`, username)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// RemovePost deletes a local user's post and federates the deletion, so it
+// answers 410 Gone from then on
+func (s *ModerationService) RemovePost(ctx context.Context, adminID, postID int) error {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return err
+	}
+
+	var authorID int
+	var username string
+	err := s.db.QueryRow(ctx, `
+		SELECT p.user_id, u.username FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.id = $1 AND p.deleted_at IS NULL
+	`, postID).Scan(&authorID, &username)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrPostNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load post: %w", err)
+	}
+
+	if err := s.posts.Delete(ctx, authorID, postID); err != nil {
+		return err
+	}
+	recordAdminAction(ctx, s.db, adminID, "removed post %d by %s", postID, username)
+	return nil
+}
+
+// RejectActor refuses every future activity from a remote actor, given as
+// user@domain or actor URL, and returns its actor ID
+func (s *ModerationService) RejectActor(ctx context.Context, adminID int, target, reason string) (string, error) {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return "", err
+	}
+	actorID, err := s.actors.Resolve(ctx, strings.TrimSpace(target))
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO rejected_actors (actor_id, reason, rejected_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (actor_id) DO UPDATE SET reason = $2, rejected_by = $3
+	`, actorID, strings.TrimSpace(reason), adminID)
+	if err != nil {
+		return "", fmt.Errorf("failed to reject actor: %w", err)
+	}
+	recordAdminAction(ctx, s.db, adminID, "rejected %s", actorID)
+	return actorID, nil
+}
+
+// UnrejectActor accepts activities from a rejected actor again
+func (s *ModerationService) UnrejectActor(ctx context.Context, adminID int, target string) error {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return err
+	}
+	actorID, err := s.actors.Resolve(ctx, strings.TrimSpace(target))
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.db.Exec(ctx, `DELETE FROM rejected_actors WHERE actor_id = $1`, actorID)
+	if err != nil {
+		return fmt.Errorf("failed to unreject actor: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotRejected
+	}
+	recordAdminAction(ctx, s.db, adminID, "unrejected %s", actorID)
+	return nil
+}
+
+// RejectedActors lists the rejected remote actors, most recent first
+func (s *ModerationService) RejectedActors(ctx context.Context, adminID int) ([]models.RejectedActor, error) {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, `SELECT actor_id, reason, created_at FROM rejected_actors ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rejected actors: %w", err)
+	}
+	defer rows.Close()
+
+	var actors []models.RejectedActor
+	for rows.Next() {
+		var actor models.RejectedActor
+		if err := rows.Scan(&actor.ActorID, &actor.Reason, &actor.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan rejected actor: %w", err)
+		}
+		actors = append(actors, actor)
+	}
+	return actors, rows.Err()
+}
+
+// ActorRejected reports whether activities from a remote actor are refused,
+// because the actor was rejected or its server's domain is blocked
+func (s *ModerationService) ActorRejected(ctx context.Context, actorID string) (bool, error) {
+	var host string
+	if u, err := url.Parse(actorID); err == nil {
+		host = strings.ToLower(u.Hostname())
+	}
+
+	var rejected bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM rejected_actors WHERE actor_id = $1)
+			OR EXISTS(SELECT 1 FROM blocked_domains WHERE $2::text <> '' AND ($2 = domain OR $2 LIKE '%.' || domain))
+	`, actorID, host).Scan(&rejected)
+	if err != nil {
+		return false, fmt.Errorf("failed to check rejected actors: %w", err)
+	}
+	return rejected, nil
+}
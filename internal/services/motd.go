@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrNotAdmin is returned when a non-admin manages instance-wide settings
+	ErrNotAdmin = errors.New("only admins can do this")
+	// ErrMOTDNotFound is returned when removing an unknown message of the day
+	ErrMOTDNotFound = errors.New("message of the day not found")
+)
+
+// MOTDService handles the messages of the day shown when users connect
+type MOTDService struct {
+	db  *pgxpool.Pool
+	cfg *config.Config
+}
+
+// NewMOTDService creates a new MOTDService instance
+func NewMOTDService(db *pgxpool.Pool, cfg *config.Config) *MOTDService {
+	return &MOTDService{db: db, cfg: cfg}
+}
+
+// configKey identifies the configured message by its text, so editing it
+// shows it again to users who dismissed the previous one
+func configKey(message string) string {
+	sum := sha256.Sum256([]byte(message))
+	return "config:" + hex.EncodeToString(sum[:8])
+}
+
+// Active returns the messages of the day to show a user, the configured one
+// first and then the newest, leaving out those the user dismissed. Guests
+// (userID 0) see every message.
+func (s *MOTDService) Active(ctx context.Context, userID int) ([]models.MOTD, error) {
+	dismissed := map[string]bool{}
+	if userID != 0 {
+		rows, err := s.db.Query(ctx, `SELECT motd_key FROM motd_dismissals WHERE user_id = $1`, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load dismissed messages: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				return nil, fmt.Errorf("failed to scan dismissed message: %w", err)
+			}
+			dismissed[key] = true
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to load dismissed messages: %w", err)
+		}
+	}
+
+	var messages []models.MOTD
+	if message := strings.TrimSpace(s.cfg.TUI.MOTD); message != "" {
+		if key := configKey(message); !dismissed[key] {
+			messages = append(messages, models.MOTD{Key: key, Message: message})
+		}
+	}
+
+	all, err := s.list(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, motd := range all {
+		if !dismissed[motd.Key] {
+			messages = append(messages, motd)
+		}
+	}
+	return messages, nil
+}
+
+// Dismiss hides a message of the day from a user for good
+func (s *MOTDService) Dismiss(ctx context.Context, userID int, key string) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO motd_dismissals (user_id, motd_key)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, motd_key) DO NOTHING
+	`, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to dismiss message: %w", err)
+	}
+	return nil
+}
+
+// Create posts a new message of the day; a zero ttl keeps it until removed
+func (s *MOTDService) Create(ctx context.Context, userID int, message string, ttl time.Duration) (*models.MOTD, error) {
+	if err := requireAdmin(ctx, s.db, userID); err != nil {
+		return nil, err
+	}
+
+	motd := &models.MOTD{Message: strings.TrimSpace(message), CreatedBy: &userID}
+	if motd.Message == "" {
+		return nil, fmt.Errorf("message is empty")
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		motd.ExpiresAt = &expiresAt
+	}
+
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO motd_messages (message, created_by, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, motd.Message, motd.CreatedBy, motd.ExpiresAt).Scan(&motd.ID, &motd.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create message: %w", err)
+	}
+	motd.Key = "db:" + strconv.Itoa(motd.ID)
+	recordAdminAction(ctx, s.db, userID, "posted message of the day %d: %s", motd.ID, motd.Message)
+
+	return motd, nil
+}
+
+// List returns every stored message of the day, expired ones included
+func (s *MOTDService) List(ctx context.Context, userID int) ([]models.MOTD, error) {
+	if err := requireAdmin(ctx, s.db, userID); err != nil {
+		return nil, err
+	}
+	return s.list(ctx, false)
+}
+
+// Remove deletes a stored message of the day
+func (s *MOTDService) Remove(ctx context.Context, userID, id int) error {
+	if err := requireAdmin(ctx, s.db, userID); err != nil {
+		return err
+	}
+
+	tag, err := s.db.Exec(ctx, `DELETE FROM motd_messages WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to remove message: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMOTDNotFound
+	}
+	recordAdminAction(ctx, s.db, userID, "removed message of the day %d", id)
+
+	// Dismissals of the message are no longer needed
+	_, err = s.db.Exec(ctx, `DELETE FROM motd_dismissals WHERE motd_key = $1`, "db:"+strconv.Itoa(id))
+	if err != nil {
+		return fmt.Errorf("failed to remove dismissals: %w", err)
+	}
+	return nil
+}
+
+// list returns stored messages, newest first
+func (s *MOTDService) list(ctx context.Context, activeOnly bool) ([]models.MOTD, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, message, created_by, expires_at, created_at
+		FROM motd_messages
+		WHERE NOT $1 OR expires_at IS NULL OR expires_at > NOW()
+		ORDER BY created_at DESC, id DESC
+	`, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []models.MOTD
+	for rows.Next() {
+		var motd models.MOTD
+		if err := rows.Scan(&motd.ID, &motd.Message, &motd.CreatedBy, &motd.ExpiresAt, &motd.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		motd.Key = "db:" + strconv.Itoa(motd.ID)
+		messages = append(messages, motd)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	return messages, nil
+}
+
+// requireAdmin returns ErrNotAdmin unless the user is an instance admin
+func requireAdmin(ctx context.Context, db *pgxpool.Pool, userID int) error {
+	var isAdmin bool
+	err := db.QueryRow(ctx, `SELECT is_admin FROM users WHERE id = $1`, userID).Scan(&isAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to check admin status: %w", err)
+	}
+	if !isAdmin {
+		return ErrNotAdmin
+	}
+	return nil
+}
@@ -0,0 +1,582 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// NostrTarget is the cross-post target of the user's Nostr key
+	NostrTarget = "nostr"
+
+	// MaxNostrRelays bounds the relays a user publishes to
+	MaxNostrRelays = 10
+
+	// nostrRelayTimeout bounds publishing to one relay, from connecting to
+	// its answer
+	nostrRelayTimeout = 10 * time.Second
+
+	// nostrDeliveryRetention is how long the outcome of publishing is kept
+	nostrDeliveryRetention = 30 * 24 * time.Hour
+)
+
+// ErrNostrDisabled is returned when the instance does not offer Nostr cross-posting
+var ErrNostrDisabled = errors.New("Nostr cross-posting is not enabled on this instance")
+
+// NostrService stores the Nostr keys of users and publishes their posts as
+// signed notes to their relays
+type NostrService struct {
+	db  *pgxpool.Pool
+	cfg *config.Config
+}
+
+// NewNostrService creates a new NostrService instance
+func NewNostrService(db *pgxpool.Pool, cfg *config.Config) *NostrService {
+	return &NostrService{db: db, cfg: cfg}
+}
+
+// Enabled reports whether the instance offers Nostr cross-posting
+func (s *NostrService) Enabled() bool {
+	return s.cfg.Features.Nostr.Enabled
+}
+
+// DefaultRelays returns the relays notes go to when the user chose none
+func (s *NostrService) DefaultRelays() []string {
+	return s.cfg.Features.Nostr.Relays
+}
+
+// Account returns the user's Nostr account, or nil if there is none
+func (s *NostrService) Account(ctx context.Context, userID int) (*models.NostrAccount, error) {
+	account := &models.NostrAccount{UserID: userID}
+	err := s.db.QueryRow(ctx, `
+		SELECT public_key, relays, crosspost, created_at FROM nostr_accounts WHERE user_id = $1
+	`, userID).Scan(&account.PublicKey, &account.Relays, &account.CrossPost, &account.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Nostr account: %w", err)
+	}
+	return account, nil
+}
+
+// Link stores the secret key notes are signed with, given as an nsec or in
+// hex. The relays chosen before are kept when the key changes.
+func (s *NostrService) Link(ctx context.Context, userID int, secretKey string) (*models.NostrAccount, error) {
+	if !s.Enabled() {
+		return nil, ErrNostrDisabled
+	}
+	key, err := parseNostrSecret(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := schnorrPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := sealSecret(s.cfg.Security.SecretKey, hex.EncodeToString(key))
+	if err != nil {
+		return nil, err
+	}
+
+	account := &models.NostrAccount{UserID: userID, PublicKey: hex.EncodeToString(publicKey)}
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO nostr_accounts (user_id, public_key, secret_key)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET public_key = $2, secret_key = $3, updated_at = NOW()
+		RETURNING relays, crosspost, created_at
+	`, userID, account.PublicKey, sealed).Scan(&account.Relays, &account.CrossPost, &account.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save Nostr account: %w", err)
+	}
+	return account, nil
+}
+
+// Unlink forgets the user's Nostr key and the outcome of their posts
+func (s *NostrService) Unlink(ctx context.Context, userID int) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM nostr_deliveries WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete Nostr deliveries: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM nostr_accounts WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to unlink Nostr account: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetCrossPost sets whether new posts go to Nostr unless unchecked
+func (s *NostrService) SetCrossPost(ctx context.Context, userID int, crossPost bool) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE nostr_accounts SET crosspost = $2, updated_at = NOW() WHERE user_id = $1
+	`, userID, crossPost)
+	if err != nil {
+		return fmt.Errorf("failed to update Nostr account: %w", err)
+	}
+	return nil
+}
+
+// SetRelays sets the relays the user's notes go to; none means the
+// instance's defaults
+func (s *NostrService) SetRelays(ctx context.Context, userID int, relays []string) error {
+	var normalized []string
+	for _, relay := range relays {
+		relay, err := normalizeRelay(relay)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(normalized, relay) {
+			normalized = append(normalized, relay)
+		}
+	}
+	if len(normalized) > MaxNostrRelays {
+		return fmt.Errorf("at most %d relays", MaxNostrRelays)
+	}
+	if normalized == nil {
+		normalized = []string{}
+	}
+
+	_, err := s.db.Exec(ctx, `
+		UPDATE nostr_accounts SET relays = $2, updated_at = NOW() WHERE user_id = $1
+	`, userID, normalized)
+	if err != nil {
+		return fmt.Errorf("failed to update Nostr relays: %w", err)
+	}
+	return nil
+}
+
+// Deliveries returns how publishing the user's latest note went on each relay
+func (s *NostrService) Deliveries(ctx context.Context, userID int) ([]models.NostrDelivery, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT event_id, relay, accepted, message, created_at
+		FROM nostr_deliveries
+		WHERE user_id = $1 AND event_id = (
+			SELECT event_id FROM nostr_deliveries WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT 1
+		)
+		ORDER BY relay
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Nostr deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.NostrDelivery
+	for rows.Next() {
+		var d models.NostrDelivery
+		if err := rows.Scan(&d.EventID, &d.Relay, &d.Accepted, &d.Message, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan Nostr delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list Nostr deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Post signs a post as a text note and publishes it to each of the user's
+// relays at once. It returns the ID of the note and how each relay took it,
+// and an error only when no relay accepted it.
+func (s *NostrService) Post(ctx context.Context, userID int, content, contentWarning string) (string, []models.NostrDelivery, error) {
+	if !s.Enabled() {
+		return "", nil, ErrNostrDisabled
+	}
+	var sealed string
+	var relays []string
+	err := s.db.QueryRow(ctx, `
+		SELECT secret_key, relays FROM nostr_accounts WHERE user_id = $1
+	`, userID).Scan(&sealed, &relays)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil, fmt.Errorf("no Nostr key linked")
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load Nostr account: %w", err)
+	}
+	if len(relays) == 0 {
+		relays = s.DefaultRelays()
+	}
+	if len(relays) == 0 {
+		return "", nil, fmt.Errorf("no Nostr relays chosen")
+	}
+	secret, err := openSecret(s.cfg.Security.SecretKey, sealed)
+	if err != nil {
+		return "", nil, err
+	}
+	key, err := hex.DecodeString(secret)
+	if err != nil {
+		return "", nil, fmt.Errorf("stored Nostr key is corrupt")
+	}
+
+	event, err := newNostrNote(key, content, contentWarning, time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+	message, err := json.Marshal([]any{"EVENT", event})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode note: %w", err)
+	}
+
+	deliveries := make([]models.NostrDelivery, len(relays))
+	var wg sync.WaitGroup
+	for i, relay := range relays {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			accepted, reason := publishToRelay(ctx, relay, event.ID, message)
+			deliveries[i] = models.NostrDelivery{EventID: event.ID, Relay: relay, Accepted: accepted, Message: reason, CreatedAt: time.Now()}
+		}()
+	}
+	wg.Wait()
+
+	s.recordDeliveries(ctx, userID, deliveries)
+
+	var failures []string
+	for _, d := range deliveries {
+		if d.Accepted {
+			return event.ID, deliveries, nil
+		}
+		failures = append(failures, strings.TrimPrefix(d.Relay, "wss://")+": "+d.Message)
+	}
+	return event.ID, deliveries, fmt.Errorf("no relay accepted the note (%s)", strings.Join(failures, "; "))
+}
+
+// recordDeliveries keeps how publishing went for the integrations screen.
+// It is best effort: the note is out either way.
+func (s *NostrService) recordDeliveries(ctx context.Context, userID int, deliveries []models.NostrDelivery) {
+	for _, d := range deliveries {
+		_, err := s.db.Exec(ctx, `
+			INSERT INTO nostr_deliveries (user_id, event_id, relay, accepted, message)
+			VALUES ($1, $2, $3, $4, $5)
+		`, userID, d.EventID, d.Relay, d.Accepted, d.Message)
+		if err != nil {
+			return
+		}
+	}
+	s.db.Exec(ctx, `
+		DELETE FROM nostr_deliveries WHERE user_id = $1 AND created_at < $2
+	`, userID, time.Now().Add(-nostrDeliveryRetention))
+}
+
+// nostrEvent is a signed Nostr event (NIP-01)
+type nostrEvent struct {
+	ID        string     `json:"id"`
+	PubKey    string     `json:"pubkey"`
+	CreatedAt int64      `json:"created_at"`
+	Kind      int        `json:"kind"`
+	Tags      [][]string `json:"tags"`
+	Content   string     `json:"content"`
+	Sig       string     `json:"sig"`
+}
+
+// newNostrNote signs a text note (kind 1). Hashtags get "t" tags so relays
+// index them, and a content warning the tag of NIP-36.
+func newNostrNote(secretKey []byte, content, contentWarning string, createdAt time.Time) (*nostrEvent, error) {
+	publicKey, err := schnorrPublicKey(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	event := &nostrEvent{
+		PubKey:    hex.EncodeToString(publicKey),
+		CreatedAt: createdAt.Unix(),
+		Kind:      1,
+		Tags:      [][]string{},
+		Content:   content,
+	}
+	if contentWarning != "" {
+		event.Tags = append(event.Tags, []string{"content-warning", contentWarning})
+	}
+	var tags []string
+	// The same hashtags Bluesky gets facets for
+	for _, match := range blueskyTag.FindAllStringSubmatch(content, -1) {
+		tag := strings.ToLower(match[1])
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+			event.Tags = append(event.Tags, []string{"t", tag})
+		}
+	}
+
+	id := event.hash()
+	sig, err := schnorrSign(secretKey, id)
+	if err != nil {
+		return nil, err
+	}
+	event.ID = hex.EncodeToString(id)
+	event.Sig = hex.EncodeToString(sig)
+	return event, nil
+}
+
+// hash returns the ID of an event: the SHA-256 of its serialization as
+// NIP-01 defines it, which relays check byte for byte
+func (e *nostrEvent) hash() []byte {
+	var b strings.Builder
+	b.WriteString(`[0,`)
+	b.WriteString(nostrJSONString(e.PubKey))
+	b.WriteString(`,` + strconv.FormatInt(e.CreatedAt, 10) + `,` + strconv.Itoa(e.Kind) + `,[`)
+	for i, tag := range e.Tags {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("[")
+		for j, value := range tag {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(nostrJSONString(value))
+		}
+		b.WriteString("]")
+	}
+	b.WriteString(`],`)
+	b.WriteString(nostrJSONString(e.Content))
+	b.WriteString(`]`)
+	sum := sha256.Sum256([]byte(b.String()))
+	return sum[:]
+}
+
+// nostrJSONString quotes a string as NIP-01 serializes it: only quotes,
+// backslashes and control characters are escaped, unlike encoding/json,
+// which also escapes HTML characters and line separators
+func nostrJSONString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// publishToRelay sends an EVENT message to a relay and waits for its OK,
+// returning whether the relay accepted the event and why not
+func publishToRelay(ctx context.Context, relay, eventID string, message []byte) (bool, string) {
+	ctx, cancel := context.WithTimeout(ctx, nostrRelayTimeout)
+	defer cancel()
+
+	ws, err := dialWebsocket(ctx, relay)
+	if err != nil {
+		return false, err.Error()
+	}
+	defer ws.Close()
+
+	if err := ws.WriteText(message); err != nil {
+		return false, err.Error()
+	}
+	for {
+		data, err := ws.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return false, "no answer"
+			}
+			return false, err.Error()
+		}
+		// Relays answer ["OK", <event id>, <accepted>, <message>] and may
+		// send NOTICE messages first
+		var answer []any
+		if json.Unmarshal(data, &answer) != nil || len(answer) < 3 || answer[0] != "OK" || answer[1] != eventID {
+			continue
+		}
+		accepted, _ := answer[2].(bool)
+		reason := ""
+		if len(answer) > 3 {
+			reason, _ = answer[3].(string)
+		}
+		if !accepted && reason == "" {
+			reason = "rejected"
+		}
+		return accepted, reason
+	}
+}
+
+// normalizeRelay checks the URL of a relay
+func normalizeRelay(relay string) (string, error) {
+	relay = strings.TrimRight(strings.TrimSpace(relay), "/")
+	if !strings.Contains(relay, "://") {
+		relay = "wss://" + relay
+	}
+	parsed, err := url.Parse(relay)
+	if err != nil || parsed.Scheme != "wss" || parsed.Host == "" {
+		return "", fmt.Errorf("relay must be a wss:// URL: %s", relay)
+	}
+	parsed.Host = strings.ToLower(parsed.Host)
+	return parsed.String(), nil
+}
+
+// parseNostrSecret parses a secret key given as an nsec or 64 hex digits
+func parseNostrSecret(secretKey string) ([]byte, error) {
+	secretKey = strings.TrimSpace(secretKey)
+	var key []byte
+	var err error
+	if strings.HasPrefix(strings.ToLower(secretKey), "nsec1") {
+		var hrp string
+		hrp, key, err = bech32Decode(secretKey)
+		if err == nil && hrp != "nsec" {
+			err = fmt.Errorf("not an nsec")
+		}
+	} else {
+		key, err = hex.DecodeString(secretKey)
+	}
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("enter your secret key as an nsec or 64 hex digits")
+	}
+	return key, nil
+}
+
+// NostrNpub returns a hex public key as the npub Nostr clients show
+func NostrNpub(publicKey string) string {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return publicKey
+	}
+	return bech32Encode("npub", key)
+}
+
+// NostrNote returns a hex event ID as the note1 Nostr clients link to
+func NostrNote(eventID string) string {
+	id, err := hex.DecodeString(eventID)
+	if err != nil {
+		return eventID
+	}
+	return bech32Encode("note", id)
+}
+
+// bech32Charset is the alphabet of bech32 (BIP-173), which NIP-19 encodes
+// keys and IDs with
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Polymod is the checksum function of BIP-173
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// bech32HRPExpand spreads the human-readable part for the checksum
+func bech32HRPExpand(hrp string) []byte {
+	values := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+	return values
+}
+
+// bech32Encode encodes bytes with a human-readable part
+func bech32Encode(hrp string, data []byte) string {
+	values, _ := convertBits(data, 8, 5, true)
+	polymod := bech32Polymod(append(append(bech32HRPExpand(hrp), values...), 0, 0, 0, 0, 0, 0)) ^ 1
+	for i := 0; i < 6; i++ {
+		values = append(values, byte(polymod>>(5*(5-i)))&31)
+	}
+
+	var b strings.Builder
+	b.WriteString(hrp + "1")
+	for _, v := range values {
+		b.WriteByte(bech32Charset[v])
+	}
+	return b.String()
+}
+
+// bech32Decode decodes a bech32 string into its human-readable part and bytes
+func bech32Decode(s string) (string, []byte, error) {
+	s = strings.ToLower(s)
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return "", nil, fmt.Errorf("invalid bech32")
+	}
+	hrp := s[:sep]
+	var values []byte
+	for _, c := range s[sep+1:] {
+		v := strings.IndexRune(bech32Charset, c)
+		if v < 0 {
+			return "", nil, fmt.Errorf("invalid bech32 character %q", c)
+		}
+		values = append(values, byte(v))
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), values...)) != 1 {
+		return "", nil, fmt.Errorf("invalid bech32 checksum")
+	}
+	data, err := convertBits(values[:len(values)-6], 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, data, nil
+}
+
+// convertBits regroups bits, e.g. from bytes to the 5-bit groups of bech32
+func convertBits(data []byte, from, to uint, pad bool) ([]byte, error) {
+	var acc uint32
+	var bits uint
+	var out []byte
+	maxValue := uint32(1)<<to - 1
+	for _, v := range data {
+		acc = acc<<from | uint32(v)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxValue))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(to-bits)&maxValue))
+		}
+	} else if bits >= from || acc<<(to-bits)&maxValue != 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return out, nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchnorrSign(t *testing.T) {
+	// BIP-340 test vectors 0 to 3
+	tests := []struct {
+		secretKey, publicKey, aux, message, signature string
+	}{
+		{
+			secretKey: "0000000000000000000000000000000000000000000000000000000000000003",
+			publicKey: "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+			aux:       "0000000000000000000000000000000000000000000000000000000000000000",
+			message:   "0000000000000000000000000000000000000000000000000000000000000000",
+			signature: "e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0",
+		},
+		{
+			secretKey: "b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfef",
+			publicKey: "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			aux:       "0000000000000000000000000000000000000000000000000000000000000001",
+			message:   "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			signature: "6896bd60eeae296db48a229ff71dfe071bde413e6d43f917dc8dcf8c78de33418906d11ac976abccb20b091292bff4ea897efcb639ea871cfa95f6de339e4b0a",
+		},
+		{
+			secretKey: "c90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74020bbea63b14e5c9",
+			publicKey: "dd308afec5777e13121fa72b9cc1b7cc0139715309b086c960e18fd969774eb8",
+			aux:       "c87aa53824b4d7ae2eb035a2b5bbbccc080e76cdc6d1692c4b0b62d798e6d906",
+			message:   "7e2d58d8b3bcdf1abadec7829054f90dda9805aab56c77333024b9d0a508b75c",
+			signature: "5831aaeed7b44bb74e5eab94ba9d4294c49bcf2a60728d8b4c200f50dd313c1bab745879a5ad954a72c45a91c3a51d3c7adea98d82f8481e0e1e03674a6f3fb7",
+		},
+		{
+			secretKey: "0b432b2677937381aef05bb02a66ecd012773062cf3fa2549e44f58ed2401710",
+			publicKey: "25d1dff95105f5253c4022f628a996ad3a0d95fbf21d468a1b33f8c160d8f517",
+			aux:       "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			message:   "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			signature: "7eb0509757e246f19449885651611cb965ecc1a187dd51b64fda1edc9637d5ec97582b9cb13db3933705b32ba982af5af25fd78881ebb32771fc5922efc66ea3",
+		},
+	}
+	for i, tt := range tests {
+		secretKey, _ := hex.DecodeString(tt.secretKey)
+		aux, _ := hex.DecodeString(tt.aux)
+		message, _ := hex.DecodeString(tt.message)
+
+		publicKey, err := schnorrPublicKey(secretKey)
+		if err != nil || hex.EncodeToString(publicKey) != tt.publicKey {
+			t.Errorf("vector %d: public key = %x, %v, want %s", i, publicKey, err, tt.publicKey)
+		}
+		signature, err := schnorrSignAux(secretKey, message, aux)
+		if err != nil || hex.EncodeToString(signature) != tt.signature {
+			t.Errorf("vector %d: signature = %x, %v, want %s", i, signature, err, tt.signature)
+		}
+	}
+
+	for _, secretKey := range []string{"", "00", strings.Repeat("00", 32), "fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"} {
+		key, _ := hex.DecodeString(secretKey)
+		if _, err := schnorrPublicKey(key); err == nil {
+			t.Errorf("expected secret key %q to be refused", secretKey)
+		}
+	}
+}
+
+func TestNostrEventID(t *testing.T) {
+	event := &nostrEvent{
+		PubKey:    "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+		CreatedAt: 1700000000,
+		Kind:      1,
+		Tags:      [][]string{{"content-warning", "spoilers"}, {"t", "go"}},
+		Content:   "Line one\n\"quoted\" \\ <b>é</b> \u2028 #go\x01",
+	}
+	// The serialization of NIP-01, escaping only what JSON requires: not
+	// HTML characters nor line separators, which encoding/json escapes
+	serialized := `[0,"dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",1700000000,1,` +
+		`[["content-warning","spoilers"],["t","go"]],` +
+		`"Line one\n\"quoted\" \\ <b>é</b> ` + "\u2028" + ` #go\u0001"]`
+	want := sha256.Sum256([]byte(serialized))
+	if got := event.hash(); hex.EncodeToString(got) != hex.EncodeToString(want[:]) {
+		t.Errorf("id = %x, want %x", got, want)
+	}
+}
+
+func TestNewNostrNote(t *testing.T) {
+	secretKey, _ := hex.DecodeString("b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfef")
+	event, err := newNostrNote(secretKey, "Hello #Go and #go", "spoilers", time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.PubKey != "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659" || event.Kind != 1 || event.CreatedAt != 1700000000 {
+		t.Errorf("event = %+v", event)
+	}
+	if len(event.Tags) != 2 || event.Tags[0][0] != "content-warning" || event.Tags[1][0] != "t" || event.Tags[1][1] != "go" {
+		t.Errorf("tags = %v", event.Tags)
+	}
+	if event.ID != hex.EncodeToString(event.hash()) {
+		t.Errorf("id %s is not the hash of the event", event.ID)
+	}
+	if len(event.Sig) != 128 {
+		t.Errorf("sig = %s", event.Sig)
+	}
+}
@@ -0,0 +1,475 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/events"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxPostLength matches Mastodon's default character limit
+const maxPostLength = 500
+
+// isDisallowedControl reports whether r is a control character posts may not
+// contain: any but line breaks and tabs
+func isDisallowedControl(r rune) bool {
+	return unicode.IsControl(r) && r != '\n' && r != '\t'
+}
+
+// ErrPostNotFound is returned for posts that do not exist, were deleted, or
+// are not visible to the requester
+var ErrPostNotFound = errors.New("post not found")
+
+// maxPinnedPosts matches Mastodon's default limit on pinned posts
+const maxPinnedPosts = 5
+
+// ErrPinLimit is returned when pinning a post would exceed maxPinnedPosts
+var ErrPinLimit = fmt.Errorf("at most %d posts can be pinned", maxPinnedPosts)
+
+// ErrPinDirect is returned when pinning a direct post, which only its
+// recipients may see
+var ErrPinDirect = errors.New("direct posts cannot be pinned")
+
+// postVisibilities maps the visibilities accepted from clients to stored ones
+var postVisibilities = map[string]string{
+	"public":    "public",
+	"unlisted":  "unlisted",
+	"private":   "followers",
+	"followers": "followers",
+	"direct":    "direct",
+}
+
+// recipient is an account mentioned in a post being published
+type recipient struct {
+	mention models.PostMention
+	userID  int    // Set for local accounts
+	inbox   string // Set for remote accounts
+}
+
+// PostService publishes and deletes native posts
+type PostService struct {
+	db           *pgxpool.Pool
+	cfg          *config.Config
+	interactions *InteractionService
+	gpg          *GPGService
+}
+
+// NewPostService creates a new PostService instance
+func NewPostService(db *pgxpool.Pool, cfg *config.Config) *PostService {
+	return &PostService{db: db, cfg: cfg, interactions: NewInteractionService(db, cfg), gpg: NewGPGService(db)}
+}
+
+// Create publishes a plain-text post. Accounts mentioned in it are resolved
+// and copied on the post; direct posts are delivered to them alone, and the
+// rest to the author's followers as well, by the handlers of the
+// PostCreated event published once it is saved. Content written as a clear-signed
+// OpenPGP message is checked against the author's key and published with
+// its signature.
+func (s *PostService) Create(ctx context.Context, userID int, content, visibility string) (*models.Post, error) {
+	var signature string
+	if IsClearSigned(content) {
+		var err error
+		if content, signature, err = s.gpg.OpenSigned(ctx, userID, content); err != nil {
+			return nil, err
+		}
+	}
+	content = strings.TrimSpace(strings.ReplaceAll(content, "\r\n", "\n"))
+	if content == "" {
+		return nil, fmt.Errorf("post is empty")
+	}
+	// Escape sequences would reach the terminals of everyone reading the post
+	if strings.ContainsFunc(content, isDisallowedControl) {
+		return nil, fmt.Errorf("post contains control characters")
+	}
+	if utf8.RuneCountInString(content) > maxPostLength {
+		return nil, fmt.Errorf("post is too long (max %d characters)", maxPostLength)
+	}
+	if visibility == "" {
+		visibility = "public"
+	}
+	stored, ok := postVisibilities[visibility]
+	if !ok {
+		return nil, fmt.Errorf("unsupported visibility %q", visibility)
+	}
+
+	actor, err := s.interactions.loadActor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A direct post no recipient can be found for would reach no one
+	recipients, err := s.resolveMentions(ctx, actor, content, stored == "direct")
+	if err != nil {
+		return nil, err
+	}
+	if stored == "direct" && len(recipients) == 0 {
+		return nil, fmt.Errorf("direct posts must mention at least one account")
+	}
+
+	post := &models.Post{UserID: userID, Content: content, ContentType: "text/plain", Visibility: stored, APType: "Note", GPGSignature: signature}
+	for _, r := range recipients {
+		post.Mentions = append(post.Mentions, r.mention)
+	}
+	var create models.APActivity
+	err = s.interactions.inTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `
+			INSERT INTO posts (user_id, content, content_type, visibility, gpg_signature)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+			RETURNING id, published_at, created_at
+		`, userID, content, post.ContentType, stored, signature).Scan(&post.ID, &post.PublishedAt, &post.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save post: %w", err)
+		}
+
+		create = activitypub.NewCreateNote(s.cfg.Server.BaseURL, actor.username, post)
+		create.Context = "https://www.w3.org/ns/activitystreams"
+		post.APID = create.Object.(models.APNote).ID
+		if _, err := tx.Exec(ctx, "UPDATE posts SET ap_id = $2 WHERE id = $1", post.ID, post.APID); err != nil {
+			return fmt.Errorf("failed to save post: %w", err)
+		}
+		for _, tag := range activitypub.ExtractHashtags(content) {
+			if _, err := tx.Exec(ctx, "INSERT INTO post_tags (post_id, tag) VALUES ($1, $2)", post.ID, tag); err != nil {
+				return fmt.Errorf("failed to save hashtag: %w", err)
+			}
+		}
+		for _, r := range recipients {
+			_, err := tx.Exec(ctx, "INSERT INTO post_mentions (post_id, actor_id, acct) VALUES ($1, $2, $3)",
+				post.ID, r.mention.ActorID, r.mention.Acct)
+			if err != nil {
+				return fmt.Errorf("failed to save mention: %w", err)
+			}
+		}
+		if stored == "direct" {
+			if err := s.recordDirect(ctx, tx, actor, post, recipients); err != nil {
+				return err
+			}
+		}
+		return recordOutbound(ctx, tx, userID, create, post.APID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var inboxes []string
+	for _, r := range recipients {
+		if r.inbox != "" && !slices.Contains(inboxes, r.inbox) {
+			inboxes = append(inboxes, r.inbox)
+		}
+	}
+	events.Publish(ctx, events.PostCreated{UserID: userID, Post: post, Activity: create, Inboxes: inboxes})
+	return post, nil
+}
+
+// resolveMentions resolves the accounts mentioned in content, leaving out
+// the author. Accounts that cannot be resolved are skipped unless strict.
+func (s *PostService) resolveMentions(ctx context.Context, actor *localActor, content string, strict bool) ([]recipient, error) {
+	var recipients []recipient
+	for _, acct := range activitypub.ExtractMentions(content) {
+		r, err := s.resolveMention(ctx, actor, acct)
+		if err != nil {
+			if strict {
+				return nil, err
+			}
+			log.Printf("Failed to resolve mention of %s: %v", acct, err)
+			continue
+		}
+		if r.mention.ActorID != actor.id {
+			recipients = append(recipients, *r)
+		}
+	}
+	return recipients, nil
+}
+
+// resolveMention finds the actor and inbox of a mentioned account; accounts
+// without a domain or on this server are local users
+func (s *PostService) resolveMention(ctx context.Context, actor *localActor, acct string) (*recipient, error) {
+	username, domain, _ := strings.Cut(acct, "@")
+	if domain == "" || strings.EqualFold(domain, s.cfg.Server.Domain) {
+		r := &recipient{}
+		err := s.db.QueryRow(ctx,
+			"SELECT id, username FROM users WHERE lower(username) = lower($1) AND deleted_at IS NULL", username,
+		).Scan(&r.userID, &username)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("no local account @%s", username)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up @%s: %w", username, err)
+		}
+		r.mention = models.PostMention{
+			ActorID: activitypub.ActorURL(s.cfg.Server.BaseURL, username),
+			Acct:    username + "@" + s.cfg.Server.Domain,
+		}
+		return r, nil
+	}
+
+	actorID, err := s.interactions.actors.Resolve(ctx, acct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve @%s: %w", acct, err)
+	}
+	doc, err := s.interactions.actors.Get(ctx, actorID, actor.privateKey, actor.keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch @%s: %w", acct, err)
+	}
+	inbox, err := activitypub.GetActorInbox(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find inbox of @%s: %w", acct, err)
+	}
+	return &recipient{
+		mention: models.PostMention{ActorID: actorID, Acct: strings.ToLower(acct)},
+		inbox:   inbox,
+	}, nil
+}
+
+// recordDirect files a direct post in the author's conversation with each
+// recipient, and in the recipient's own conversation when they are local
+func (s *PostService) recordDirect(ctx context.Context, tx pgx.Tx, actor *localActor, post *models.Post, recipients []recipient) error {
+	content := plainTextHTML(post.Content)
+	for _, r := range recipients {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO direct_messages (user_id, counterpart_actor_id, object_id, post_id, direction, content, published_at, read_at)
+			VALUES ($1, $2, $3, $4, 'outbound', $5, $6, $6)
+		`, actor.userID, r.mention.ActorID, post.APID, post.ID, content, post.PublishedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save direct message: %w", err)
+		}
+		if r.userID == 0 {
+			continue
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO direct_messages (user_id, counterpart_actor_id, object_id, direction, content, published_at)
+			VALUES ($1, $2, $3, 'inbound', $4, $5)
+		`, r.userID, actor.id, post.APID, content, post.PublishedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save direct message: %w", err)
+		}
+	}
+	return nil
+}
+
+// Mentions returns the actors mentioned in a post
+func (s *PostService) Mentions(ctx context.Context, postID int) ([]models.PostMention, error) {
+	rows, err := s.db.Query(ctx, "SELECT actor_id, acct FROM post_mentions WHERE post_id = $1 ORDER BY acct", postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load mentions: %w", err)
+	}
+	mentions, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.PostMention])
+	if err != nil {
+		return nil, fmt.Errorf("failed to load mentions: %w", err)
+	}
+	return mentions, nil
+}
+
+// CanView reports whether a remote actor is in the audience of a local
+// post: anyone for public and unlisted posts, accepted followers of the
+// author for followers-only posts, and mentioned actors for all of them
+func (s *PostService) CanView(ctx context.Context, post *models.Post, actorID string) (bool, error) {
+	if post.Visibility == "public" || post.Visibility == "unlisted" {
+		return true, nil
+	}
+	for _, mention := range post.Mentions {
+		if mention.ActorID == actorID {
+			return true, nil
+		}
+	}
+	if post.Visibility != "followers" {
+		return false, nil
+	}
+
+	var follower bool
+	err := s.db.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM followers WHERE user_id = $1 AND follower_actor_id = $2 AND accepted)",
+		post.UserID, actorID,
+	).Scan(&follower)
+	if err != nil {
+		return false, fmt.Errorf("failed to check follower: %w", err)
+	}
+	return follower, nil
+}
+
+// Delete removes one of the user's own posts and federates the deletion
+func (s *PostService) Delete(ctx context.Context, userID, postID int) error {
+	actor, err := s.interactions.loadActor(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	mentions, err := s.Mentions(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	var del models.APActivity
+	var visibility string
+	err = s.interactions.inTx(ctx, func(tx pgx.Tx) error {
+		var apID *string
+		err := tx.QueryRow(ctx, `
+			UPDATE posts SET deleted_at = NOW()
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			RETURNING ap_id, COALESCE(visibility, 'public')
+		`, postID, userID).Scan(&apID, &visibility)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete post: %w", err)
+		}
+
+		noteID := fmt.Sprintf("%s/statuses/%d", actor.id, postID)
+		if apID != nil && *apID != "" {
+			noteID = *apID
+		}
+		del = activitypub.NewDeleteNote(s.cfg.Server.BaseURL, actor.username, noteID)
+		// Local recipients' copies of a direct post go with it
+		if _, err := tx.Exec(ctx, "DELETE FROM direct_messages WHERE object_id = $1", noteID); err != nil {
+			return fmt.Errorf("failed to delete direct messages: %w", err)
+		}
+		return recordOutbound(ctx, tx, userID, del, noteID)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Mentioned actors may not follow the author, so they are told directly
+	var inboxes []string
+	for _, mention := range mentions {
+		if strings.HasPrefix(mention.ActorID, s.cfg.Server.BaseURL+"/") {
+			continue
+		}
+		doc, err := s.interactions.actors.Get(ctx, mention.ActorID, actor.privateKey, actor.keyID)
+		if err != nil {
+			log.Printf("Failed to fetch %s to deliver deletion: %v", mention.ActorID, err)
+			continue
+		}
+		if inbox, err := activitypub.GetActorInbox(doc); err == nil && !slices.Contains(inboxes, inbox) {
+			inboxes = append(inboxes, inbox)
+		}
+	}
+	if len(inboxes) > 0 {
+		s.interactions.deliverAsync(actor, inboxes, del)
+	}
+	if visibility != "direct" {
+		s.interactions.deliverToFollowersAsync(actor, "", del)
+	}
+	return nil
+}
+
+// Pin features one of the user's own posts on their profile and announces
+// it to their followers. Pinning a pinned post does nothing.
+func (s *PostService) Pin(ctx context.Context, userID, postID int) error {
+	return s.setPinned(ctx, userID, postID, true)
+}
+
+// Unpin removes a post from the user's profile and announces it to their
+// followers. Unpinning a post that is not pinned does nothing.
+func (s *PostService) Unpin(ctx context.Context, userID, postID int) error {
+	return s.setPinned(ctx, userID, postID, false)
+}
+
+// setPinned pins or unpins a post, federating an Add or Remove of it to
+// the author's featured collection
+func (s *PostService) setPinned(ctx context.Context, userID, postID int, pin bool) error {
+	actor, err := s.interactions.loadActor(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var activity *models.APActivity
+	err = s.interactions.inTx(ctx, func(tx pgx.Tx) error {
+		var apID *string
+		var visibility string
+		var pinned bool
+		err := tx.QueryRow(ctx, `
+			SELECT ap_id, COALESCE(visibility, 'public'), pinned_at IS NOT NULL
+			FROM posts
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			FOR UPDATE
+		`, postID, userID).Scan(&apID, &visibility, &pinned)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load post: %w", err)
+		}
+		if pinned == pin {
+			return nil
+		}
+		if pin && visibility == "direct" {
+			return ErrPinDirect
+		}
+
+		if pin {
+			var count int
+			err := tx.QueryRow(ctx, `
+				SELECT COUNT(*) FROM posts WHERE user_id = $1 AND pinned_at IS NOT NULL AND deleted_at IS NULL
+			`, userID).Scan(&count)
+			if err != nil {
+				return fmt.Errorf("failed to count pinned posts: %w", err)
+			}
+			if count >= maxPinnedPosts {
+				return ErrPinLimit
+			}
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE posts SET pinned_at = CASE WHEN $2 THEN NOW() END WHERE id = $1
+		`, postID, pin)
+		if err != nil {
+			return fmt.Errorf("failed to update post: %w", err)
+		}
+
+		noteID := fmt.Sprintf("%s/statuses/%d", actor.id, postID)
+		if apID != nil && *apID != "" {
+			noteID = *apID
+		}
+		change := activitypub.NewRemoveFeatured(s.cfg.Server.BaseURL, actor.username, noteID)
+		if pin {
+			change = activitypub.NewAddFeatured(s.cfg.Server.BaseURL, actor.username, noteID)
+		}
+		activity = &change
+		return recordOutbound(ctx, tx, userID, change, noteID)
+	})
+	if err != nil || activity == nil {
+		return err
+	}
+
+	s.interactions.deliverToFollowersAsync(actor, "", *activity)
+	return nil
+}
+
+// Pinned returns the public and unlisted posts a user pinned, most
+// recently pinned first
+func (s *PostService) Pinned(ctx context.Context, userID int) ([]models.Post, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, user_id, content, COALESCE(content_type, 'text/plain') AS content_type,
+			COALESCE(visibility, 'public') AS visibility, published_at, COALESCE(ap_id, '') AS ap_id, pinned_at,
+			COALESCE(gpg_signature, '') AS gpg_signature
+		FROM posts
+		WHERE user_id = $1 AND pinned_at IS NOT NULL AND deleted_at IS NULL AND visibility IN ('public', 'unlisted')
+		ORDER BY pinned_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pinned posts: %w", err)
+	}
+	posts, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[models.Post])
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pinned posts: %w", err)
+	}
+
+	for i := range posts {
+		if posts[i].Mentions, err = s.Mentions(ctx, posts[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return posts, nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+)
+
+func TestIsDisallowedControl(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want bool
+	}{
+		{'a', false},
+		{'é', false},
+		{'\n', false},
+		{'\t', false},
+		{'\u2028', false}, // A separator, not a control character
+		{'\x1b', true},
+		{'\a', true},
+		{'\r', true},
+		{'\x00', true},
+		{'\x7f', true},
+		{'\u009b', true}, // C1 CSI
+	}
+	for _, tt := range tests {
+		if got := isDisallowedControl(tt.r); got != tt.want {
+			t.Errorf("isDisallowedControl(%U) = %v, want %v", tt.r, got, tt.want)
+		}
+	}
+}
+
+// TestCanView covers the visibilities decided without looking up followers
+func TestCanView(t *testing.T) {
+	const bob = "https://example.social/users/bob"
+	const eve = "https://example.social/users/eve"
+	mentions := []models.PostMention{{ActorID: bob, Acct: "bob@example.social"}}
+	s := &PostService{}
+
+	tests := []struct {
+		name       string
+		visibility string
+		mentions   []models.PostMention
+		actorID    string
+		want       bool
+	}{
+		{"public", "public", nil, eve, true},
+		{"unlisted", "unlisted", nil, eve, true},
+		{"direct to the actor", "direct", mentions, bob, true},
+		{"direct to someone else", "direct", mentions, eve, false},
+		{"direct to nobody", "direct", nil, bob, false},
+		{"followers-only mentioning the actor", "followers", mentions, bob, true},
+		{"unknown visibility", "limited", nil, eve, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := &models.Post{UserID: 1, Visibility: tt.visibility, Mentions: tt.mentions}
+			got, err := s.CanView(context.Background(), post, tt.actorID)
+			if err != nil {
+				t.Fatalf("CanView: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CanView(%s, %s) = %v, want %v", tt.visibility, tt.actorID, got, tt.want)
+			}
+		})
+	}
+}
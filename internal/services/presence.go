@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// PresenceHeartbeat is how often connected sessions refresh their presence
+	PresenceHeartbeat = 30 * time.Second
+
+	// presenceTTL is how long a session counts as connected without a
+	// heartbeat, so sessions of a crashed server drop out on their own
+	presenceTTL = 3 * PresenceHeartbeat
+
+	// redisPresenceSessions is a sorted set of connected session IDs, scored
+	// by their last heartbeat
+	redisPresenceSessions = "presence:sessions"
+
+	// redisPresenceUsers maps connected session IDs to the signed-in user
+	redisPresenceUsers = "presence:users"
+
+	// redisPresenceEnd is the pub/sub channel telling every server to end
+	// the sessions of the user ID published on it
+	redisPresenceEnd = "presence:end"
+)
+
+// PresenceService tracks the SSH sessions connected to the instance
+type PresenceService struct {
+	db    *pgxpool.Pool
+	redis redis.UniversalClient
+}
+
+// NewPresenceService creates a new PresenceService instance
+func NewPresenceService(db *pgxpool.Pool, redisClient redis.UniversalClient) *PresenceService {
+	return &PresenceService{db: db, redis: redisClient}
+}
+
+// Join records a connected session; userID is 0 until someone signs in
+func (s *PresenceService) Join(ctx context.Context, sessionID string, userID int) error {
+	pipe := s.redis.TxPipeline()
+	pipe.ZAdd(ctx, redisPresenceSessions, redis.Z{Score: float64(time.Now().Unix()), Member: sessionID})
+	if userID != 0 {
+		pipe.HSet(ctx, redisPresenceUsers, sessionID, userID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record session: %w", err)
+	}
+	return nil
+}
+
+// Heartbeat keeps a connected session counted
+func (s *PresenceService) Heartbeat(ctx context.Context, sessionID string) error {
+	err := s.redis.ZAddXX(ctx, redisPresenceSessions, redis.Z{Score: float64(time.Now().Unix()), Member: sessionID}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to refresh session: %w", err)
+	}
+	return nil
+}
+
+// Identify links a connected session to the user who signed in on it, or
+// unlinks it when userID is 0 after logging out. Signing in also counts as
+// activity for the profile directory.
+func (s *PresenceService) Identify(ctx context.Context, sessionID string, userID int) error {
+	if userID == 0 {
+		if err := s.redis.HDel(ctx, redisPresenceUsers, sessionID).Err(); err != nil {
+			return fmt.Errorf("failed to identify session: %w", err)
+		}
+		return nil
+	}
+
+	if err := s.redis.HSet(ctx, redisPresenceUsers, sessionID, userID).Err(); err != nil {
+		return fmt.Errorf("failed to identify session: %w", err)
+	}
+	if _, err := s.db.Exec(ctx, `UPDATE users SET last_active_at = NOW() WHERE id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+	return nil
+}
+
+// Leave forgets a session that disconnected
+func (s *PresenceService) Leave(ctx context.Context, sessionID string) error {
+	pipe := s.redis.TxPipeline()
+	pipe.ZRem(ctx, redisPresenceSessions, sessionID)
+	pipe.HDel(ctx, redisPresenceUsers, sessionID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to remove session: %w", err)
+	}
+	return nil
+}
+
+// EndSessions ends every connected session of a user, on any server, for
+// when they are suspended or deleted
+func (s *PresenceService) EndSessions(ctx context.Context, userID int) error {
+	if err := s.redis.Publish(ctx, redisPresenceEnd, userID).Err(); err != nil {
+		return fmt.Errorf("failed to end sessions: %w", err)
+	}
+	return nil
+}
+
+// Ended returns a channel closed once EndSessions is called for the user
+// signed in on a session, until ctx is cancelled
+func (s *PresenceService) Ended(ctx context.Context, sessionID string) (<-chan struct{}, error) {
+	pubsub := s.redis.Subscribe(ctx, redisPresenceEnd)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to watch session: %w", err)
+	}
+
+	ended := make(chan struct{})
+	go func() {
+		defer pubsub.Close()
+		received := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-received:
+				if !ok {
+					return
+				}
+				// The user is looked up on every message, as sessions sign
+				// in and out while connected
+				userID, err := s.redis.HGet(ctx, redisPresenceUsers, sessionID).Result()
+				if err == nil && userID == msg.Payload {
+					close(ended)
+					return
+				}
+			}
+		}
+	}()
+	return ended, nil
+}
+
+// Count returns how many sessions are connected, signed in or not
+func (s *PresenceService) Count(ctx context.Context) (int, error) {
+	sessions, err := s.live(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(sessions), nil
+}
+
+// Online returns the usernames of connected users who chose to be listed,
+// in alphabetical order
+func (s *PresenceService) Online(ctx context.Context) ([]string, error) {
+	sessions, err := s.live(ctx)
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+
+	values, err := s.redis.HMGet(ctx, redisPresenceUsers, sessions...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load connected users: %w", err)
+	}
+	var userIDs []int
+	for _, value := range values {
+		if text, ok := value.(string); ok {
+			if id, err := strconv.Atoi(text); err == nil {
+				userIDs = append(userIDs, id)
+			}
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT username FROM users
+		WHERE id = ANY($1) AND show_presence AND deleted_at IS NULL
+		ORDER BY username
+	`, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list connected users: %w", err)
+	}
+	defer rows.Close()
+
+	var usernames []string
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, fmt.Errorf("failed to scan connected user: %w", err)
+		}
+		usernames = append(usernames, username)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list connected users: %w", err)
+	}
+	return usernames, nil
+}
+
+// Connected reports whether a user has a session connected, listed or not
+func (s *PresenceService) Connected(ctx context.Context, userID int) (bool, error) {
+	sessions, err := s.live(ctx)
+	if err != nil || len(sessions) == 0 {
+		return false, err
+	}
+
+	values, err := s.redis.HMGet(ctx, redisPresenceUsers, sessions...).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to load connected users: %w", err)
+	}
+	want := strconv.Itoa(userID)
+	for _, value := range values {
+		if value == want {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Visible reports whether a user chose to be listed as connected
+func (s *PresenceService) Visible(ctx context.Context, userID int) (bool, error) {
+	var visible bool
+	err := s.db.QueryRow(ctx, `SELECT show_presence FROM users WHERE id = $1`, userID).Scan(&visible)
+	if err != nil {
+		return false, fmt.Errorf("failed to load presence setting: %w", err)
+	}
+	return visible, nil
+}
+
+// SetVisible chooses whether a user is listed as connected
+func (s *PresenceService) SetVisible(ctx context.Context, userID int, visible bool) error {
+	_, err := s.db.Exec(ctx, `UPDATE users SET show_presence = $2, updated_at = NOW() WHERE id = $1`, userID, visible)
+	if err != nil {
+		return fmt.Errorf("failed to save presence setting: %w", err)
+	}
+	return nil
+}
+
+// live drops sessions whose heartbeat stopped and returns the rest
+func (s *PresenceService) live(ctx context.Context) ([]string, error) {
+	cutoff := strconv.FormatInt(time.Now().Add(-presenceTTL).Unix(), 10)
+
+	stale, err := s.redis.ZRangeByScore(ctx, redisPresenceSessions, &redis.ZRangeBy{Min: "-inf", Max: "(" + cutoff}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sessions: %w", err)
+	}
+	if len(stale) > 0 {
+		pipe := s.redis.TxPipeline()
+		pipe.ZRemRangeByScore(ctx, redisPresenceSessions, "-inf", "("+cutoff)
+		pipe.HDel(ctx, redisPresenceUsers, stale...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("failed to drop stale sessions: %w", err)
+		}
+	}
+
+	sessions, err := s.redis.ZRangeByScore(ctx, redisPresenceSessions, &redis.ZRangeBy{Min: cutoff, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sessions: %w", err)
+	}
+	return sessions, nil
+}
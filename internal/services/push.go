@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/webpush"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pushTTL is how long push services hold a message for an offline device
+const pushTTL = 24 * time.Hour
+
+// maxPushAttempts is how many times a notification is sent before it is dropped
+const maxPushAttempts = 5
+
+// ErrPushSubscriptionNotFound is returned when an access token has no push subscription
+var ErrPushSubscriptionNotFound = errors.New("push subscription not found")
+
+// maxNotificationBody caps the post excerpt shown in a notification, in runes
+const maxNotificationBody = 140
+
+// htmlTag matches an HTML tag in post content
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// pushPolicies are the Mastodon policies limiting whose activity is pushed
+var pushPolicies = map[string]bool{"all": true, "followed": true, "follower": true, "none": true}
+
+// PushService manages Web Push subscriptions and delivers queued
+// notifications for mentions and follows to them
+type PushService struct {
+	db  *pgxpool.Pool
+	cfg *config.Config
+}
+
+// NewPushService creates a new PushService instance
+func NewPushService(db *pgxpool.Pool, cfg *config.Config) *PushService {
+	return &PushService{db: db, cfg: cfg}
+}
+
+// VAPIDKeys returns the server's VAPID key pair, private key as PEM and
+// public key as base64url, creating it on first use
+func (s *PushService) VAPIDKeys(ctx context.Context) (string, string, error) {
+	var privateKey, publicKey string
+	err := s.db.QueryRow(ctx, "SELECT private_key, public_key FROM vapid_keys").Scan(&privateKey, &publicKey)
+	if err == nil {
+		return privateKey, publicKey, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", "", fmt.Errorf("failed to load VAPID key: %w", err)
+	}
+
+	if privateKey, publicKey, err = webpush.GenerateVAPIDKeys(); err != nil {
+		return "", "", err
+	}
+	// Another process may have created the key first; keep whichever is stored
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO vapid_keys (private_key, public_key) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET id = vapid_keys.id
+		RETURNING private_key, public_key
+	`, privateKey, publicKey).Scan(&privateKey, &publicKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to save VAPID key: %w", err)
+	}
+	return privateKey, publicKey, nil
+}
+
+// Subscribe registers the push subscription of an access token, replacing
+// any it already had
+func (s *PushService) Subscribe(ctx context.Context, token *models.APIToken, sub webpush.Subscription, mention, follow bool, policy string) (*models.PushSubscription, error) {
+	if sub.Endpoint == "" || sub.P256dh == "" || sub.Auth == "" {
+		return nil, fmt.Errorf("subscription endpoint and keys are required")
+	}
+	if policy == "" {
+		policy = "all"
+	}
+	if !pushPolicies[policy] {
+		return nil, fmt.Errorf("unsupported policy %q", policy)
+	}
+
+	subscription := &models.PushSubscription{
+		UserID:       token.UserID,
+		TokenID:      token.ID,
+		Endpoint:     sub.Endpoint,
+		P256dh:       sub.P256dh,
+		Auth:         sub.Auth,
+		AlertMention: mention,
+		AlertFollow:  follow,
+		Policy:       policy,
+	}
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO push_subscriptions (user_id, token_id, endpoint, p256dh, auth, alert_mention, alert_follow, policy)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (token_id) DO UPDATE SET
+			endpoint = EXCLUDED.endpoint,
+			p256dh = EXCLUDED.p256dh,
+			auth = EXCLUDED.auth,
+			alert_mention = EXCLUDED.alert_mention,
+			alert_follow = EXCLUDED.alert_follow,
+			policy = EXCLUDED.policy
+		RETURNING id, created_at
+	`, token.UserID, token.ID, sub.Endpoint, sub.P256dh, sub.Auth, mention, follow, policy).Scan(&subscription.ID, &subscription.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save push subscription: %w", err)
+	}
+	return subscription, nil
+}
+
+// Subscription returns the push subscription of an access token
+func (s *PushService) Subscription(ctx context.Context, tokenID int) (*models.PushSubscription, error) {
+	sub := &models.PushSubscription{}
+	err := s.db.QueryRow(ctx, `
+		SELECT id, user_id, token_id, endpoint, p256dh, auth, alert_mention, alert_follow, policy, created_at
+		FROM push_subscriptions WHERE token_id = $1
+	`, tokenID).Scan(&sub.ID, &sub.UserID, &sub.TokenID, &sub.Endpoint, &sub.P256dh, &sub.Auth,
+		&sub.AlertMention, &sub.AlertFollow, &sub.Policy, &sub.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPushSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load push subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// UpdateAlerts changes which notifications an access token's subscription receives
+func (s *PushService) UpdateAlerts(ctx context.Context, tokenID int, mention, follow bool, policy string) (*models.PushSubscription, error) {
+	if policy == "" {
+		policy = "all"
+	}
+	if !pushPolicies[policy] {
+		return nil, fmt.Errorf("unsupported policy %q", policy)
+	}
+	tag, err := s.db.Exec(ctx,
+		"UPDATE push_subscriptions SET alert_mention = $2, alert_follow = $3, policy = $4 WHERE token_id = $1",
+		tokenID, mention, follow, policy,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update push subscription: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrPushSubscriptionNotFound
+	}
+	return s.Subscription(ctx, tokenID)
+}
+
+// Unsubscribe removes the push subscription of an access token
+func (s *PushService) Unsubscribe(ctx context.Context, tokenID int) error {
+	if _, err := s.db.Exec(ctx, "DELETE FROM push_subscriptions WHERE token_id = $1", tokenID); err != nil {
+		return fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+	return nil
+}
+
+// Notify queues a notification for the user's subscriptions that want this
+// kind of notification ("mention" or "follow") from actorID
+func (s *PushService) Notify(ctx context.Context, userID int, kind, actorID, title, body string) error {
+	column := map[string]string{"mention": "alert_mention", "follow": "alert_follow"}[kind]
+	if column == "" {
+		return fmt.Errorf("unsupported notification type %q", kind)
+	}
+
+	// column is one of the constants above
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO push_notifications (subscription_id, notification_type, title, body)
+		SELECT s.id, $2, $4, $5 FROM push_subscriptions s
+		WHERE s.user_id = $1 AND s.`+column+` AND (
+			s.policy = 'all'
+			OR (s.policy = 'followed' AND EXISTS (
+				SELECT 1 FROM following WHERE user_id = $1 AND target_actor_id = $3 AND accepted))
+			OR (s.policy = 'follower' AND EXISTS (
+				SELECT 1 FROM followers WHERE user_id = $1 AND follower_actor_id = $3 AND accepted))
+		)
+	`, userID, kind, actorID, title, body)
+	if err != nil {
+		return fmt.Errorf("failed to queue push notification: %w", err)
+	}
+	return nil
+}
+
+// DeliverPending sends up to limit queued notifications and returns how many
+// were delivered. Failed sends are retried on later passes; subscriptions the
+// push service reports as gone are removed.
+func (s *PushService) DeliverPending(ctx context.Context, limit int) (int, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT n.id, n.notification_type, n.title, n.body, s.id, s.endpoint, s.p256dh, s.auth
+		FROM push_notifications n
+		JOIN push_subscriptions s ON s.id = n.subscription_id
+		ORDER BY n.created_at
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load push notifications: %w", err)
+	}
+
+	type pending struct {
+		id             int
+		kind           string
+		title, body    string
+		subscriptionID int
+		subscription   webpush.Subscription
+	}
+	var notifications []pending
+	for rows.Next() {
+		var n pending
+		if err := rows.Scan(&n.id, &n.kind, &n.title, &n.body, &n.subscriptionID,
+			&n.subscription.Endpoint, &n.subscription.P256dh, &n.subscription.Auth); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan push notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to load push notifications: %w", err)
+	}
+	if len(notifications) == 0 {
+		return 0, nil
+	}
+
+	privateKey, _, err := s.VAPIDKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, n := range notifications {
+		// The payload format Mastodon clients decrypt and display
+		payload, err := json.Marshal(map[string]any{
+			"notification_id":   strconv.Itoa(n.id),
+			"notification_type": n.kind,
+			"preferred_locale":  "en",
+			"title":             n.title,
+			"body":              n.body,
+			"icon":              s.cfg.Server.BaseURL + "/favicon.ico",
+		})
+		if err != nil {
+			return delivered, fmt.Errorf("failed to encode push payload: %w", err)
+		}
+
+		err = webpush.Send(ctx, n.subscription, payload, privateKey, s.cfg.Server.BaseURL, pushTTL)
+		switch {
+		case err == nil:
+			delivered++
+			_, err = s.db.Exec(ctx, "DELETE FROM push_notifications WHERE id = $1", n.id)
+		case errors.Is(err, webpush.ErrSubscriptionGone):
+			_, err = s.db.Exec(ctx, "DELETE FROM push_subscriptions WHERE id = $1", n.subscriptionID)
+		default:
+			log.Printf("Failed to push notification %d: %v", n.id, err)
+			var attempts int
+			err = s.db.QueryRow(ctx,
+				"UPDATE push_notifications SET attempts = attempts + 1 WHERE id = $1 RETURNING attempts", n.id,
+			).Scan(&attempts)
+			if err == nil && attempts >= maxPushAttempts {
+				_, err = s.db.Exec(ctx, "DELETE FROM push_notifications WHERE id = $1", n.id)
+			}
+		}
+		if err != nil {
+			return delivered, fmt.Errorf("failed to update push notification: %w", err)
+		}
+	}
+	return delivered, nil
+}
+
+// notificationBody turns HTML post content into a short plain-text excerpt
+func notificationBody(content string) string {
+	text := strings.Join(strings.Fields(html.UnescapeString(htmlTag.ReplaceAllString(content, " "))), " ")
+	if runes := []rune(text); len(runes) > maxNotificationBody {
+		text = string(runes[:maxNotificationBody-1]) + "…"
+	}
+	return text
+}
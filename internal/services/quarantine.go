@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/events"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrQuarantineNotFound is returned when reviewing an activity that is not
+// in quarantine
+var ErrQuarantineNotFound = errors.New("quarantined activity not found")
+
+// htmlLink matches the target of a link in post content
+var htmlLink = regexp.MustCompile(`<a\s[^>]*href="([^"]+)"`)
+
+// QuarantineService holds back inbound posts that look like spam, from new
+// actors with many mentions and links, for admins to release or purge
+type QuarantineService struct {
+	db  *pgxpool.Pool
+	cfg *config.Config
+}
+
+// NewQuarantineService creates a new QuarantineService instance
+func NewQuarantineService(db *pgxpool.Pool, cfg *config.Config) *QuarantineService {
+	return &QuarantineService{db: db, cfg: cfg}
+}
+
+// Inspect returns why an inbound activity looks like spam, or "" if it does
+// not. Only posts are inspected.
+func (s *QuarantineService) Inspect(ctx context.Context, activity map[string]any) (string, error) {
+	spam := s.cfg.Security.Spam
+	if !spam.Enabled || activity["type"] != "Create" {
+		return "", nil
+	}
+	object, ok := activity["object"].(map[string]any)
+	if !ok {
+		return "", nil
+	}
+
+	mentions, links := countMentionsAndLinks(object)
+	if mentions < max(spam.MinMentions, 1) || links < spam.MinLinks {
+		return "", nil
+	}
+	actorID, _ := activity["actor"].(string)
+	isNew, err := s.newActor(ctx, actorID, max(spam.NewActorDays, 1))
+	if err != nil || !isNew {
+		return "", err
+	}
+	return fmt.Sprintf("new actor, %d mentions, %d links", mentions, links), nil
+}
+
+// newActor reports whether an actor is unknown to the instance, or was
+// created less than days ago. Actors local users follow are never new.
+func (s *QuarantineService) newActor(ctx context.Context, actorID string, days int) (bool, error) {
+	var known, followed bool
+	var published string
+	err := s.db.QueryRow(ctx, `
+		SELECT
+			EXISTS(SELECT 1 FROM remote_actors WHERE actor_id = $1 AND actor_json ? 'id'),
+			COALESCE((SELECT actor_json->>'published' FROM remote_actors WHERE actor_id = $1), ''),
+			EXISTS(SELECT 1 FROM following WHERE target_actor_id = $1 AND accepted)
+	`, actorID).Scan(&known, &published, &followed)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up actor: %w", err)
+	}
+	if followed {
+		return false, nil
+	}
+	if !known {
+		return true, nil
+	}
+	created, err := time.Parse(time.RFC3339, published)
+	return err == nil && time.Since(created) < time.Duration(days)*24*time.Hour, nil
+}
+
+// countMentionsAndLinks counts the accounts a post mentions and the links
+// in its content that are neither mentions nor hashtags
+func countMentionsAndLinks(object map[string]any) (int, int) {
+	mentions := 0
+	tagged := map[string]bool{}
+	tags, _ := object["tag"].([]any)
+	for _, entry := range tags {
+		tag, _ := entry.(map[string]any)
+		if tag["type"] == "Mention" {
+			mentions++
+		}
+		if href, _ := tag["href"].(string); href != "" {
+			tagged[href] = true
+		}
+	}
+
+	content, _ := object["content"].(string)
+	links := map[string]bool{}
+	for _, match := range htmlLink.FindAllStringSubmatch(content, -1) {
+		if !tagged[match[1]] {
+			links[match[1]] = true
+		}
+	}
+	return mentions, len(links)
+}
+
+// Hold stores an inbound activity in quarantine instead of the inbox
+func (s *QuarantineService) Hold(ctx context.Context, userID *int, activity map[string]any, proofVerified bool, reason string) error {
+	activityJSON, err := json.Marshal(activity)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity: %w", err)
+	}
+	activityType, _ := activity["type"].(string)
+	actorID, _ := activity["actor"].(string)
+	object, _ := activity["object"].(map[string]any)
+	objectID, _ := object["id"].(string)
+
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO quarantined_activities (user_id, activity_type, actor_id, object_id, activity_json, proof_verified, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, userID, activityType, actorID, objectID, activityJSON, proofVerified, reason)
+	if err != nil {
+		return fmt.Errorf("failed to quarantine activity: %w", err)
+	}
+	return nil
+}
+
+// List returns the quarantined activities, oldest first
+func (s *QuarantineService) List(ctx context.Context, adminID, limit int) ([]models.QuarantinedActivity, error) {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT q.id, COALESCE(u.username, ''), q.activity_type, q.actor_id, COALESCE(q.object_id, ''),
+			COALESCE(q.activity_json->'object'->>'content', ''), q.reason, q.created_at
+		FROM quarantined_activities q
+		LEFT JOIN users u ON u.id = q.user_id
+		ORDER BY q.created_at
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantine: %w", err)
+	}
+	defer rows.Close()
+
+	var activities []models.QuarantinedActivity
+	for rows.Next() {
+		var a models.QuarantinedActivity
+		if err := rows.Scan(&a.ID, &a.Username, &a.ActivityType, &a.ActorID, &a.ObjectID,
+			&a.Content, &a.Reason, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan quarantined activity: %w", err)
+		}
+		a.Content = notificationBody(a.Content)
+		activities = append(activities, a)
+	}
+	return activities, rows.Err()
+}
+
+// Release moves a quarantined activity to the inbox and publishes it as
+// received, so that it is processed like any other
+func (s *QuarantineService) Release(ctx context.Context, adminID int, id int64) error {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return err
+	}
+
+	// The activity moves in a single statement, so it cannot be lost or doubled
+	var received events.ActivityReceived
+	var activityJSON []byte
+	err := s.db.QueryRow(ctx, `
+		WITH released AS (
+			DELETE FROM quarantined_activities WHERE id = $1
+			RETURNING user_id, activity_type, actor_id, object_id, activity_json, proof_verified
+		)
+		INSERT INTO activities (user_id, activity_type, actor_id, object_id, activity_json, direction, processed, proof_verified)
+		SELECT user_id, activity_type, actor_id, object_id, activity_json, 'inbound', FALSE, proof_verified FROM released
+		RETURNING COALESCE(user_id, 0), activity_type, actor_id, COALESCE(object_id, ''), activity_json
+	`, id).Scan(&received.UserID, &received.Type, &received.ActorID, &received.ObjectID, &activityJSON)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrQuarantineNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release activity: %w", err)
+	}
+	recordAdminAction(ctx, s.db, adminID, "released quarantined activity %d from %s", id, received.ActorID)
+	if err := json.Unmarshal(activityJSON, &received.Activity); err != nil {
+		return fmt.Errorf("failed to decode released activity: %w", err)
+	}
+	events.Publish(ctx, received)
+	return nil
+}
+
+// Purge deletes a quarantined activity for good
+func (s *QuarantineService) Purge(ctx context.Context, adminID int, id int64) error {
+	if err := requireAdmin(ctx, s.db, adminID); err != nil {
+		return err
+	}
+
+	var actorID string
+	err := s.db.QueryRow(ctx, `DELETE FROM quarantined_activities WHERE id = $1 RETURNING actor_id`, id).Scan(&actorID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrQuarantineNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to purge activity: %w", err)
+	}
+	recordAdminAction(ctx, s.db, adminID, "purged quarantined activity %d from %s", id, actorID)
+	return nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+)
+
+func TestCountMentionsAndLinks(t *testing.T) {
+	mention := func(href string) map[string]any { return map[string]any{"type": "Mention", "href": href} }
+	hashtag := func(href string) map[string]any { return map[string]any{"type": "Hashtag", "href": href} }
+
+	tests := []struct {
+		name     string
+		object   map[string]any
+		mentions int
+		links    int
+	}{
+		{"empty", map[string]any{}, 0, 0},
+		{"plain text", map[string]any{"content": "<p>hello https://spam.example</p>"}, 0, 0},
+		{
+			"mentions and links",
+			map[string]any{
+				"content": `<a href="https://a.example/@x">@x</a> <a href="https://b.example/@y">@y</a> <a href="https://spam.example/buy">buy</a>`,
+				"tag":     []any{mention("https://a.example/@x"), mention("https://b.example/@y")},
+			},
+			2, 1,
+		},
+		{
+			"hashtag links are not counted",
+			map[string]any{
+				"content": `<a href="https://a.example/tags/go" class="hashtag">#go</a>`,
+				"tag":     []any{hashtag("https://a.example/tags/go")},
+			},
+			0, 0,
+		},
+		{
+			"repeated links count once",
+			map[string]any{"content": `<a href="https://spam.example">1</a> <a  class="x" href="https://spam.example">2</a> <a href="https://other.example">3</a>`},
+			0, 2,
+		},
+		{
+			"mentions without links in the content",
+			map[string]any{"tag": []any{mention("https://a.example/users/x"), mention("https://b.example/users/y"), "junk", map[string]any{}}},
+			2, 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mentions, links := countMentionsAndLinks(tt.object)
+			if mentions != tt.mentions || links != tt.links {
+				t.Errorf("countMentionsAndLinks = %d mentions, %d links, want %d, %d", mentions, links, tt.mentions, tt.links)
+			}
+		})
+	}
+}
+
+// TestInspectWithoutLookup covers the activities Inspect lets through before
+// looking up the actor; the service has no database, so a lookup would panic
+func TestInspectWithoutLookup(t *testing.T) {
+	spammy := map[string]any{
+		"type":  "Create",
+		"actor": "https://spam.example/users/bot",
+		"object": map[string]any{
+			"content": `<a href="https://spam.example/buy">buy</a>`,
+			"tag": []any{
+				map[string]any{"type": "Mention", "href": "https://a.example/users/1"},
+				map[string]any{"type": "Mention", "href": "https://a.example/users/2"},
+				map[string]any{"type": "Mention", "href": "https://a.example/users/3"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		edit     func(cfg *config.Config)
+		activity map[string]any
+	}{
+		{"disabled", func(cfg *config.Config) { cfg.Security.Spam.Enabled = false }, spammy},
+		{"not a post", nil, map[string]any{"type": "Announce", "actor": spammy["actor"], "object": spammy["object"]}},
+		{"object by reference", nil, map[string]any{"type": "Create", "actor": spammy["actor"], "object": "https://spam.example/notes/1"}},
+		{"too few mentions", func(cfg *config.Config) { cfg.Security.Spam.MinMentions = 4 }, spammy},
+		{"too few links", func(cfg *config.Config) { cfg.Security.Spam.MinLinks = 2 }, spammy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			if tt.edit != nil {
+				tt.edit(cfg)
+			}
+			s := &QuarantineService{cfg: cfg}
+			reason, err := s.Inspect(context.Background(), tt.activity)
+			if err != nil || reason != "" {
+				t.Errorf("Inspect = %q, %v, want it let through", reason, err)
+			}
+		})
+	}
+}
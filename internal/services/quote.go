@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrQuotesUnsupported is returned when the user's instance cannot quote posts
+var ErrQuotesUnsupported = errors.New("instance does not support quote posts")
+
+// Quote request parameters understood by different server software
+const (
+	quoteParamMastodon = "quoted_status_id" // Mastodon 4.5+
+	quoteParamPleroma  = "quote_id"         // Pleroma, Akkoma, Fedibird
+)
+
+// mastodonQuoteAPIVersion is the first Mastodon API version with quote posts
+const mastodonQuoteAPIVersion = 7
+
+// instanceFeatures holds the parts of the instance document used for feature detection
+type instanceFeatures struct {
+	APIVersions struct {
+		Mastodon int `json:"mastodon"`
+	} `json:"api_versions"`
+	Pleroma struct {
+		Metadata struct {
+			Features []string `json:"features"`
+		} `json:"metadata"`
+	} `json:"pleroma"`
+	FeatureQuote bool `json:"feature_quote"` // Fedibird
+}
+
+// QuoteParam returns the status parameter the user's instance uses for quote
+// posts, or ErrQuotesUnsupported if it has none
+func (s *MastodonService) QuoteParam(ctx context.Context, userID int) (string, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	var features instanceFeatures
+	if err := s.doJSON(ctx, "GET", instanceURL+"/api/v2/instance", accessToken, nil, &features); err != nil {
+		// Older servers only have the v1 document
+		if err := s.doJSON(ctx, "GET", instanceURL+"/api/v1/instance", accessToken, nil, &features); err != nil {
+			return "", fmt.Errorf("failed to fetch instance info: %w", err)
+		}
+	}
+
+	switch {
+	case features.APIVersions.Mastodon >= mastodonQuoteAPIVersion:
+		return quoteParamMastodon, nil
+	case slices.Contains(features.Pleroma.Metadata.Features, "quote_posting"), features.FeatureQuote:
+		return quoteParamPleroma, nil
+	}
+
+	return "", ErrQuotesUnsupported
+}
+
+// QuoteStatus posts a new status quoting quotedStatusID using the parameter
+// returned by QuoteParam
+func (s *MastodonService) QuoteStatus(ctx context.Context, userID int, content, visibility, quotedStatusID, quoteParam string) (string, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	reqBody := PostStatusRequest{
+		Status:     content,
+		Visibility: visibility,
+	}
+	switch quoteParam {
+	case quoteParamMastodon:
+		reqBody.QuotedStatusID = quotedStatusID
+	case quoteParamPleroma:
+		reqBody.QuoteID = quotedStatusID
+	default:
+		return "", ErrQuotesUnsupported
+	}
+
+	var status MastodonStatus
+	if err := s.doJSON(ctx, "POST", instanceURL+"/api/v1/statuses", accessToken, reqBody, &status); err != nil {
+		return "", fmt.Errorf("failed to post quote: %w", err)
+	}
+
+	return status.ID, nil
+}
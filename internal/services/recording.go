@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/asciicast"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// recordingLinkExpiry is how long a one-time recording download link
+	// stays valid
+	recordingLinkExpiry = 15 * time.Minute
+
+	// redisRecordingPrefix is the prefix for recording link keys in Redis
+	redisRecordingPrefix = "recording:"
+)
+
+var (
+	// ErrRecordingDisabled is returned when the instance does not allow recordings
+	ErrRecordingDisabled = errors.New("session recording is not enabled on this instance")
+	// ErrRecordingNotFound is returned for recordings that do not exist,
+	// have expired or belong to someone else
+	ErrRecordingNotFound = errors.New("recording not found")
+)
+
+// RecordingService keeps the asciinema casts users record of their own
+// sessions until they expire
+type RecordingService struct {
+	db     *pgxpool.Pool
+	redis  redis.UniversalClient
+	config *config.Config
+}
+
+// NewRecordingService creates a new RecordingService instance
+func NewRecordingService(db *pgxpool.Pool, redisClient redis.UniversalClient, cfg *config.Config) *RecordingService {
+	return &RecordingService{db: db, redis: redisClient, config: cfg}
+}
+
+// Enabled reports whether users may record their sessions
+func (s *RecordingService) Enabled() bool {
+	return s.config.Features.Recording.Enabled
+}
+
+// MaxSize returns the bytes a cast may grow to
+func (s *RecordingService) MaxSize() int {
+	return s.config.Features.Recording.MaxSize << 10
+}
+
+// Save stores a finished cast for the user and returns its ID
+func (s *RecordingService) Save(ctx context.Context, userID int, recording *asciicast.Recording) (int64, error) {
+	if !s.Enabled() {
+		return 0, ErrRecordingDisabled
+	}
+
+	var id int64
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO session_recordings (user_id, title, cast_data, duration_ms, truncated)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, userID, recording.Title, recording.Cast, recording.Duration.Milliseconds(), recording.Truncated).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save recording: %w", err)
+	}
+	return id, nil
+}
+
+// List returns the user's recordings, newest first
+func (s *RecordingService) List(ctx context.Context, userID int) ([]models.SessionRecording, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, user_id, title, duration_ms, octet_length(cast_data), truncated, created_at
+		FROM session_recordings
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recordings: %w", err)
+	}
+	defer rows.Close()
+
+	var recordings []models.SessionRecording
+	for rows.Next() {
+		var recording models.SessionRecording
+		if err := rows.Scan(&recording.ID, &recording.UserID, &recording.Title, &recording.DurationMS,
+			&recording.Size, &recording.Truncated, &recording.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan recording: %w", err)
+		}
+		recordings = append(recordings, recording)
+	}
+
+	return recordings, rows.Err()
+}
+
+// Cast returns one of the user's recordings and its cast
+func (s *RecordingService) Cast(ctx context.Context, userID int, id int64) (*models.SessionRecording, []byte, error) {
+	var recording models.SessionRecording
+	var cast []byte
+	err := s.db.QueryRow(ctx, `
+		SELECT id, user_id, title, duration_ms, truncated, created_at, cast_data
+		FROM session_recordings
+		WHERE id = $1 AND user_id = $2
+	`, id, userID).Scan(&recording.ID, &recording.UserID, &recording.Title, &recording.DurationMS,
+		&recording.Truncated, &recording.CreatedAt, &cast)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrRecordingNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load recording: %w", err)
+	}
+	recording.Size = len(cast)
+	return &recording, cast, nil
+}
+
+// CreateDownloadLink returns a one-time URL for downloading one of the
+// user's recordings
+func (s *RecordingService) CreateDownloadLink(ctx context.Context, userID int, id int64) (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate download token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	value := fmt.Sprintf("%d:%d", userID, id)
+	if err := s.redis.Set(ctx, redisRecordingPrefix+token, value, recordingLinkExpiry).Err(); err != nil {
+		return "", fmt.Errorf("failed to store download token: %w", err)
+	}
+
+	return fmt.Sprintf("%s/recordings/%s", s.config.Server.BaseURL, token), nil
+}
+
+// ConsumeDownloadToken validates a download token, invalidates it and
+// returns the user and recording it was created for
+func (s *RecordingService) ConsumeDownloadToken(ctx context.Context, token string) (int, int64, error) {
+	value, err := s.redis.GetDel(ctx, redisRecordingPrefix+token).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid or expired download link")
+	}
+
+	user, id, _ := strings.Cut(value, ":")
+	userID, err := strconv.Atoi(user)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid or expired download link")
+	}
+	recordingID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid or expired download link")
+	}
+	return userID, recordingID, nil
+}
+
+// PurgeExpired deletes recordings older than the configured retention
+func (s *RecordingService) PurgeExpired(ctx context.Context) (int64, error) {
+	retention := time.Duration(s.config.Features.Recording.RetentionDays) * 24 * time.Hour
+	result, err := s.db.Exec(ctx, "DELETE FROM session_recordings WHERE created_at < $1", time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge recordings: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// RecordingFilename returns the suggested filename for a recording
+func RecordingFilename(recording *models.SessionRecording) string {
+	return fmt.Sprintf("terminalpub-%s-%d.cast", recording.CreatedAt.Format("20060102"), recording.ID)
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// federatedRetention is how long relayed posts stay in the federated timeline
+const federatedRetention = 7 * 24 * time.Hour
+
+// RelayService subscribes the instance actor to the configured ActivityPub
+// relays and collects the posts they announce into the federated timeline
+type RelayService struct {
+	db      *pgxpool.Pool
+	cfg     *config.Config
+	actors  *RemoteActorService
+	objects *RemoteObjectService
+}
+
+// NewRelayService creates a new RelayService instance
+func NewRelayService(db *pgxpool.Pool, cfg *config.Config) *RelayService {
+	return &RelayService{
+		db:      db,
+		cfg:     cfg,
+		actors:  NewRemoteActorService(db, cfg),
+		objects: NewRemoteObjectService(db),
+	}
+}
+
+// InstanceKeys returns the PEM key pair of the instance actor, creating it on first use
+func (s *RelayService) InstanceKeys(ctx context.Context) (string, string, error) {
+	var privateKey, publicKey string
+	err := s.db.QueryRow(ctx, "SELECT private_key, public_key FROM instance_actor").Scan(&privateKey, &publicKey)
+	if err == nil {
+		return privateKey, publicKey, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", "", fmt.Errorf("failed to load instance key: %w", err)
+	}
+
+	if privateKey, publicKey, err = activitypub.GenerateRSAKeyPair(); err != nil {
+		return "", "", err
+	}
+	// Another process may have created the key first; keep whichever is stored
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO instance_actor (private_key, public_key) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET id = instance_actor.id
+		RETURNING private_key, public_key
+	`, privateKey, publicKey).Scan(&privateKey, &publicKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to save instance key: %w", err)
+	}
+	return privateKey, publicKey, nil
+}
+
+// instanceActor returns the signing identity of the instance actor
+func (s *RelayService) instanceActor(ctx context.Context) (*localActor, error) {
+	privateKey, _, err := s.InstanceKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	actorID := activitypub.InstanceActorURL(s.cfg.Server.BaseURL)
+	return &localActor{privateKey: privateKey, id: actorID, keyID: actorID + "#main-key"}, nil
+}
+
+// Sync subscribes to configured relays that are not followed yet, cancels
+// subscriptions to relays removed from the configuration, and drops relayed
+// posts past retention
+func (s *RelayService) Sync(ctx context.Context) error {
+	actor, err := s.instanceActor(ctx)
+	if err != nil {
+		return err
+	}
+
+	configured := map[string]bool{}
+	for _, relay := range s.cfg.ActivityPub.Relays {
+		if relay = strings.TrimSpace(relay); relay != "" {
+			configured[activitypub.NormalizeURL(relay)] = true
+		}
+	}
+
+	type subscription struct {
+		actorID, inbox, followID string
+	}
+	rows, err := s.db.Query(ctx, "SELECT actor_id, inbox, follow_activity_id FROM relays")
+	if err != nil {
+		return fmt.Errorf("failed to load relays: %w", err)
+	}
+	var subscribed []subscription
+	for rows.Next() {
+		var sub subscription
+		if err := rows.Scan(&sub.actorID, &sub.inbox, &sub.followID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan relay: %w", err)
+		}
+		subscribed = append(subscribed, sub)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load relays: %w", err)
+	}
+
+	for _, sub := range subscribed {
+		if configured[sub.actorID] {
+			delete(configured, sub.actorID)
+			continue
+		}
+		undo := activitypub.NewRelayUnfollow(s.cfg.Server.BaseURL, sub.actorID, sub.followID)
+		if err := activitypub.Deliver(ctx, sub.inbox, undo, actor.privateKey, actor.keyID, s.cfg.ActivityPub.UserAgent); err != nil {
+			log.Printf("Failed to unsubscribe from relay %s: %v", sub.actorID, err)
+		}
+		if _, err := s.db.Exec(ctx, "DELETE FROM relays WHERE actor_id = $1", sub.actorID); err != nil {
+			return fmt.Errorf("failed to remove relay: %w", err)
+		}
+	}
+
+	for relayID := range configured {
+		if err := s.subscribe(ctx, actor, relayID); err != nil {
+			log.Printf("Failed to subscribe to relay %s: %v", relayID, err)
+		}
+	}
+
+	_, err = s.db.Exec(ctx, "DELETE FROM federated_timeline WHERE received_at < $1", time.Now().Add(-federatedRetention))
+	if err != nil {
+		return fmt.Errorf("failed to trim federated timeline: %w", err)
+	}
+	return nil
+}
+
+// subscribe sends the instance actor's Follow to a relay
+func (s *RelayService) subscribe(ctx context.Context, actor *localActor, relayID string) error {
+	relay, err := s.actors.Get(ctx, relayID, actor.privateKey, actor.keyID)
+	if err != nil {
+		return err
+	}
+	inbox, _ := relay["inbox"].(string)
+	if inbox == "" {
+		return fmt.Errorf("no inbox found in relay actor")
+	}
+
+	// Recorded first, since the relay may answer before delivery returns
+	follow := activitypub.NewRelayFollow(s.cfg.Server.BaseURL, relayID)
+	_, err = s.db.Exec(ctx,
+		"INSERT INTO relays (actor_id, inbox, follow_activity_id) VALUES ($1, $2, $3)",
+		relayID, inbox, follow.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save relay: %w", err)
+	}
+
+	if err := activitypub.Deliver(ctx, inbox, follow, actor.privateKey, actor.keyID, s.cfg.ActivityPub.UserAgent); err != nil {
+		// Forget the relay so the next sync tries again
+		if _, delErr := s.db.Exec(ctx, "DELETE FROM relays WHERE actor_id = $1", relayID); delErr != nil {
+			log.Printf("Failed to remove relay %s: %v", relayID, delErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// HandleInbound records a relay's Accept or Reject of the instance actor's Follow
+func (s *RelayService) HandleInbound(ctx context.Context, activity map[string]any) error {
+	state := map[any]string{"Accept": "accepted", "Reject": "rejected"}[activity["type"]]
+	relayID, _ := activity["actor"].(string)
+	followID := referenceID(activity["object"])
+	if state == "" || relayID == "" || followID == "" {
+		return nil
+	}
+
+	_, err := s.db.Exec(ctx,
+		"UPDATE relays SET state = $3 WHERE actor_id = $1 AND follow_activity_id = $2",
+		relayID, followID, state,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update relay: %w", err)
+	}
+	return nil
+}
+
+// IsRelay reports whether an actor is a relay that accepted our subscription
+func (s *RelayService) IsRelay(ctx context.Context, actorID string) (bool, error) {
+	var accepted bool
+	err := s.db.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM relays WHERE actor_id = $1 AND state = 'accepted')", actorID,
+	).Scan(&accepted)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up relay: %w", err)
+	}
+	return accepted, nil
+}
+
+// Receive adds the public post behind a relay's Announce to the federated
+// timeline. The post is fetched from its origin rather than trusted as sent.
+func (s *RelayService) Receive(ctx context.Context, activity map[string]any) error {
+	relayID, _ := activity["actor"].(string)
+	objectID := referenceID(activity["object"])
+	if activity["type"] != "Announce" || objectID == "" {
+		return nil
+	}
+
+	actor, err := s.instanceActor(ctx)
+	if err != nil {
+		return err
+	}
+	object, err := s.objects.Get(ctx, objectID, actor.privateKey, actor.keyID)
+	if err != nil {
+		return err
+	}
+	if !addressedToPublic(object) {
+		return fmt.Errorf("relayed object %s is not public", objectID)
+	}
+
+	// Cache the author so the timeline can show a handle
+	if _, err := s.actors.Get(ctx, referenceID(object["attributedTo"]), actor.privateKey, actor.keyID); err != nil {
+		log.Printf("Failed to fetch author of %s: %v", objectID, err)
+	}
+
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO federated_timeline (object_id, relay_actor_id) VALUES ($1, $2)
+		ON CONFLICT (object_id) DO NOTHING
+	`, objectID, relayID)
+	if err != nil {
+		return fmt.Errorf("failed to add relayed post: %w", err)
+	}
+	return nil
+}
+
+// addressedToPublic reports whether an object is addressed to the public collection
+func addressedToPublic(object map[string]any) bool {
+	for _, field := range []string{"to", "cc"} {
+		switch recipients := object[field].(type) {
+		case string:
+			if activitypub.IsPublicAddress(recipients) {
+				return true
+			}
+		case []any:
+			for _, recipient := range recipients {
+				if addr, _ := recipient.(string); activitypub.IsPublicAddress(addr) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
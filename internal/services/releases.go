@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/outbound"
+	"github.com/fulgidus/terminalpub/internal/version"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisLatestReleaseKey caches the latest release, so GitHub is asked
+	// once a day however many sessions check
+	redisLatestReleaseKey = "release:latest"
+
+	// releaseCheckInterval is how long the latest release is cached
+	releaseCheckInterval = 24 * time.Hour
+
+	// releaseRetryInterval is how long a failed check is cached, so an
+	// unreachable GitHub is not asked on every login
+	releaseRetryInterval = time.Hour
+
+	// githubAPIURL is where GitHub's REST API is served
+	githubAPIURL = "https://api.github.com"
+)
+
+// Release is a published release of terminalpub
+type Release struct {
+	Tag string `json:"tag_name"`
+	URL string `json:"html_url"`
+}
+
+// ReleaseService checks whether a newer release of terminalpub is out
+type ReleaseService struct {
+	db     *pgxpool.Pool
+	redis  redis.UniversalClient
+	cfg    *config.Config
+	apiURL string
+	client *http.Client
+}
+
+// NewReleaseService creates a new ReleaseService instance
+func NewReleaseService(db *pgxpool.Pool, redisClient redis.UniversalClient, cfg *config.Config) *ReleaseService {
+	return &ReleaseService{
+		db:     db,
+		redis:  redisClient,
+		cfg:    cfg,
+		apiURL: githubAPIURL,
+		client: outbound.NewClient(10 * time.Second),
+	}
+}
+
+// NewerRelease returns the latest release when it is newer than the
+// running build and the user is an admin, who can do something about it;
+// otherwise nil. Development builds are never told to update.
+func (s *ReleaseService) NewerRelease(ctx context.Context, userID int) (*Release, error) {
+	if !s.cfg.Updates.Check || s.cfg.Updates.Repository == "" || !version.IsRelease() {
+		return nil, nil
+	}
+	if err := requireAdmin(ctx, s.db, userID); err != nil {
+		if errors.Is(err, ErrNotAdmin) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	release, err := s.latest(ctx)
+	if err != nil || release == nil || !version.Newer(release.Tag) {
+		return nil, err
+	}
+	return release, nil
+}
+
+// latest returns the latest release, from the cache when it was checked
+// today; nil when the repository has none or the check failed recently
+func (s *ReleaseService) latest(ctx context.Context) (*Release, error) {
+	cached, err := s.redis.Get(ctx, redisLatestReleaseKey).Result()
+	if err == nil {
+		if cached == "" {
+			return nil, nil
+		}
+		var release Release
+		if err := json.Unmarshal([]byte(cached), &release); err != nil {
+			return nil, fmt.Errorf("failed to decode cached release: %w", err)
+		}
+		return &release, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to read cached release: %w", err)
+	}
+
+	release, fetchErr := s.fetchLatest(ctx)
+	if fetchErr != nil {
+		s.redis.Set(ctx, redisLatestReleaseKey, "", releaseRetryInterval)
+		return nil, fetchErr
+	}
+	data, err := json.Marshal(release)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode release: %w", err)
+	}
+	if err := s.redis.Set(ctx, redisLatestReleaseKey, data, releaseCheckInterval).Err(); err != nil {
+		return nil, fmt.Errorf("failed to cache release: %w", err)
+	}
+	return release, nil
+}
+
+// fetchLatest asks GitHub for the repository's latest release
+func (s *ReleaseService) fetchLatest(ctx context.Context) (*Release, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/releases/latest", s.apiURL, s.cfg.Updates.Repository)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "terminalpub/"+version.Version)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for releases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to check for releases: GitHub returned %s", resp.Status)
+	}
+
+	var release Release
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to decode release: %w", err)
+	}
+	if release.Tag == "" {
+		return nil, fmt.Errorf("failed to check for releases: no tag in GitHub's response")
+	}
+	return &release, nil
+}
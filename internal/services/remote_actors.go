@@ -0,0 +1,355 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// actorRefreshTimeout bounds a background refresh of a remote actor
+const actorRefreshTimeout = 30 * time.Second
+
+// RemoteActorService caches remote actor documents in the remote_actors
+// table, refetching them once they are older than the configured TTL
+type RemoteActorService struct {
+	db         *pgxpool.Pool
+	ttl        time.Duration
+	refreshing sync.Map // Actor IDs with a background refresh in flight
+}
+
+// NewRemoteActorService creates a new RemoteActorService instance
+func NewRemoteActorService(db *pgxpool.Pool, cfg *config.Config) *RemoteActorService {
+	ttl := time.Duration(cfg.ActivityPub.ActorCacheTTL) * time.Second
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &RemoteActorService{db: db, ttl: ttl}
+}
+
+// cachedActor is a remote actor document with the time it was fetched
+type cachedActor struct {
+	id        string
+	doc       map[string]any
+	publicKey string
+	fetchedAt time.Time
+}
+
+// stale reports whether the cached document is past its TTL
+func (s *RemoteActorService) stale(actor *cachedActor) bool {
+	return time.Since(actor.fetchedAt) > s.ttl
+}
+
+// Get returns an actor document, from the cache while it is fresh; if a
+// refetch fails the stale copy is returned instead
+func (s *RemoteActorService) Get(ctx context.Context, actorID, privateKeyPEM, keyID string) (map[string]any, error) {
+	cached, err := s.load(ctx, "actor_id", actorID)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && !s.stale(cached) {
+		return cached.doc, nil
+	}
+
+	doc, err := s.Refresh(ctx, actorID, privateKeyPEM, keyID)
+	if err != nil && cached != nil && cached.doc["id"] != nil {
+		log.Printf("Using stale actor %s: %v", actorID, err)
+		return cached.doc, nil
+	}
+	return doc, err
+}
+
+// Refresh fetches an actor from its server and updates the cache
+func (s *RemoteActorService) Refresh(ctx context.Context, actorID, privateKeyPEM, keyID string) (map[string]any, error) {
+	doc, err := activitypub.FetchActor(actorID, privateKeyPEM, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if id, _ := doc["id"].(string); id != "" && id != actorID {
+		return nil, fmt.Errorf("actor %s returned id %s", actorID, id)
+	}
+	if err := checkPublicKey(actorID, doc); err != nil {
+		return nil, err
+	}
+	if err := s.store(ctx, actorID, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// PublicKey returns the PEM public key for an HTTP signature key ID. A stale
+// cached key is returned right away and refreshed in the background, so
+// verification does not wait on the remote server; callers should Refresh the
+// owner and retry once if verification with a cached key fails.
+func (s *RemoteActorService) PublicKey(ctx context.Context, signingKeyID, privateKeyPEM, keyID string) (string, string, error) {
+	cached, err := s.load(ctx, "public_key_id", signingKeyID)
+	if err != nil {
+		return "", "", err
+	}
+	if cached != nil && cached.publicKey != "" {
+		if s.stale(cached) {
+			s.refreshAsync(cached.id, privateKeyPEM, keyID)
+		}
+		return cached.publicKey, cached.id, nil
+	}
+
+	// Key IDs are usually the actor ID with a fragment
+	actorID, _, _ := strings.Cut(signingKeyID, "#")
+	doc, err := s.Refresh(ctx, actorID, privateKeyPEM, keyID)
+	if err != nil {
+		return "", "", err
+	}
+	id, pem := actorPublicKey(doc)
+	if id != signingKeyID || pem == "" {
+		return "", "", fmt.Errorf("actor %s has no key %s", actorID, signingKeyID)
+	}
+	return pem, actorID, nil
+}
+
+// refreshAsync refreshes an actor in the background, once at a time per
+// actor on this node and, through a lease, across the nodes
+func (s *RemoteActorService) refreshAsync(actorID, privateKeyPEM, keyID string) {
+	if _, busy := s.refreshing.LoadOrStore(actorID, true); busy {
+		return
+	}
+	go func() {
+		defer s.refreshing.Delete(actorID)
+		ctx, cancel := context.WithTimeout(context.Background(), actorRefreshTimeout)
+		defer cancel()
+
+		lease := "actor-refresh:" + actorID
+		ok, err := AcquireLease(ctx, s.db, lease, actorRefreshTimeout)
+		if err != nil {
+			log.Printf("Failed to refresh actor %s: %v", actorID, err)
+		}
+		if !ok {
+			// Another node is refreshing it
+			return
+		}
+		defer ReleaseLease(context.Background(), s.db, lease)
+		if _, err := s.Refresh(ctx, actorID, privateKeyPEM, keyID); err != nil {
+			log.Printf("Failed to refresh actor %s: %v", actorID, err)
+		}
+	}()
+}
+
+// Resolve turns user@domain or an actor URL into an actor ID, using the
+// cached WebFinger mapping when there is one
+func (s *RemoteActorService) Resolve(ctx context.Context, identifier string) (string, error) {
+	if strings.HasPrefix(identifier, "http://") || strings.HasPrefix(identifier, "https://") {
+		return identifier, nil
+	}
+
+	acct := strings.ToLower(strings.TrimPrefix(identifier, "@"))
+	var actorID string
+	err := s.db.QueryRow(ctx, `
+		SELECT actor_id FROM remote_actors
+		WHERE acct = $1 AND fetched_at > $2
+		ORDER BY fetched_at DESC
+		LIMIT 1
+	`, acct, time.Now().Add(-s.ttl)).Scan(&actorID)
+	if err == nil {
+		return actorID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("failed to look up %s: %w", acct, err)
+	}
+
+	actorID, err = activitypub.NormalizeActorID(acct)
+	if err != nil {
+		return "", err
+	}
+	// WebFinger is authoritative for the handle, which may use another domain
+	// than the actor; an unknown actor gets a placeholder that is fetched on first use
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO remote_actors (actor_id, acct, actor_json, fetched_at)
+		VALUES ($1, $2, '{}', 'epoch')
+		ON CONFLICT (actor_id) DO UPDATE SET acct = EXCLUDED.acct
+	`, actorID, acct)
+	if err != nil {
+		return "", fmt.Errorf("failed to save %s: %w", acct, err)
+	}
+	return actorID, nil
+}
+
+// Acct returns the cached user@domain handle of an actor, or the actor ID if
+// it is unknown
+func (s *RemoteActorService) Acct(ctx context.Context, actorID string) string {
+	var acct string
+	err := s.db.QueryRow(ctx, "SELECT COALESCE(acct, '') FROM remote_actors WHERE actor_id = $1", actorID).Scan(&acct)
+	if err != nil || acct == "" {
+		return actorID
+	}
+	return acct
+}
+
+// load reads a cached actor by actor_id or public_key_id; nil means not cached
+func (s *RemoteActorService) load(ctx context.Context, column, value string) (*cachedActor, error) {
+	actor := &cachedActor{}
+	var actorJSON []byte
+	// column is one of two constants chosen by the caller
+	err := s.db.QueryRow(ctx,
+		fmt.Sprintf("SELECT actor_id, actor_json, COALESCE(public_key_pem, ''), fetched_at FROM remote_actors WHERE %s = $1", column),
+		value,
+	).Scan(&actor.id, &actorJSON, &actor.publicKey, &actor.fetchedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cached actor: %w", err)
+	}
+	if err := json.Unmarshal(actorJSON, &actor.doc); err != nil {
+		return nil, fmt.Errorf("failed to decode cached actor: %w", err)
+	}
+	return actor, nil
+}
+
+// store saves a fetched actor document
+func (s *RemoteActorService) store(ctx context.Context, actorID string, doc map[string]any) error {
+	actorJSON, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode actor: %w", err)
+	}
+
+	inbox, _ := doc["inbox"].(string)
+	sharedInbox, _ := activitypub.GetActorInbox(doc)
+	publicKeyID, publicKeyPEM := actorPublicKey(doc)
+
+	// Reverse mapping from the document; a WebFinger handle already stored wins
+	var acct string
+	if name, _ := doc["preferredUsername"].(string); name != "" {
+		if domain, err := activitypub.ExtractDomain(actorID); err == nil {
+			acct = strings.ToLower(name + "@" + domain)
+		}
+	}
+
+	// A key ID belongs to one actor; one claimed by another actor of the same
+	// server, which checkPublicKey ensures, was moved there
+	if publicKeyID != "" {
+		_, err = s.db.Exec(ctx, `
+			UPDATE remote_actors SET public_key_id = NULL, public_key_pem = NULL
+			WHERE public_key_id = $1 AND actor_id <> $2
+		`, publicKeyID, actorID)
+		if err != nil {
+			return fmt.Errorf("failed to cache actor: %w", err)
+		}
+	}
+
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO remote_actors (actor_id, acct, inbox, shared_inbox, public_key_id, public_key_pem, actor_json, fetched_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, NOW())
+		ON CONFLICT (actor_id) DO UPDATE SET
+			acct = COALESCE(remote_actors.acct, EXCLUDED.acct),
+			inbox = EXCLUDED.inbox,
+			shared_inbox = EXCLUDED.shared_inbox,
+			public_key_id = EXCLUDED.public_key_id,
+			public_key_pem = EXCLUDED.public_key_pem,
+			actor_json = EXCLUDED.actor_json,
+			fetched_at = NOW()
+	`, actorID, acct, inbox, sharedInbox, publicKeyID, publicKeyPEM, actorJSON)
+	if err != nil {
+		return fmt.Errorf("failed to cache actor: %w", err)
+	}
+	return nil
+}
+
+// VerifyProof checks the FEP-8b32 integrity proof on a received document
+// against the assertion methods of the actor that made it, and returns that
+// actor. Relayed activities verified this way are trusted as if delivered by
+// their origin.
+func (s *RemoteActorService) VerifyProof(ctx context.Context, doc map[string]any, privateKeyPEM, keyID string) (string, error) {
+	method := activitypub.ProofVerificationMethod(doc)
+	if method == "" {
+		return "", fmt.Errorf("document has no proof")
+	}
+	actorID, _, _ := strings.Cut(method, "#")
+	if doc["actor"] != nil && doc["actor"] != actorID {
+		return "", fmt.Errorf("proof by %s does not match actor %v", actorID, doc["actor"])
+	}
+
+	verify := func(actor map[string]any) error {
+		publicKey, err := assertionKey(actor, method)
+		if err != nil {
+			return err
+		}
+		return activitypub.VerifyProof(doc, publicKey)
+	}
+
+	actor, err := s.Get(ctx, actorID, privateKeyPEM, keyID)
+	if err != nil {
+		return "", err
+	}
+	if err := verify(actor); err != nil {
+		// The cached actor may predate a new key
+		if actor, err = s.Refresh(ctx, actorID, privateKeyPEM, keyID); err != nil {
+			return "", err
+		}
+		if err := verify(actor); err != nil {
+			return "", err
+		}
+	}
+	return actorID, nil
+}
+
+// assertionKey finds the Ed25519 Multikey with the given ID among an actor's assertion methods
+func assertionKey(actor map[string]any, method string) (ed25519.PublicKey, error) {
+	var methods []any
+	switch value := actor["assertionMethod"].(type) {
+	case []any:
+		methods = value
+	case map[string]any:
+		methods = []any{value}
+	}
+	for _, entry := range methods {
+		key, _ := entry.(map[string]any)
+		if key["id"] != method || key["type"] != "Multikey" {
+			continue
+		}
+		multibase, _ := key["publicKeyMultibase"].(string)
+		return activitypub.ParseMultikey(multibase)
+	}
+	return nil, fmt.Errorf("actor has no assertion method %s", method)
+}
+
+// checkPublicKey refuses an actor document whose key is hosted on another
+// server or owned by someone else, so that an actor cannot claim the key ID
+// of another and have requests signed by one taken for the other
+func checkPublicKey(actorID string, doc map[string]any) error {
+	key, _ := doc["publicKey"].(map[string]any)
+	if key == nil {
+		return nil
+	}
+	id, _ := key["id"].(string)
+	if owner, _ := key["owner"].(string); owner != actorID {
+		return fmt.Errorf("key %s of actor %s is owned by %q", id, actorID, owner)
+	}
+	actorDomain, err := activitypub.ExtractDomain(actorID)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(id, "https://") && !strings.HasPrefix(id, "http://") {
+		return fmt.Errorf("key %q of actor %s is not a URL", id, actorID)
+	}
+	if keyDomain, err := activitypub.ExtractDomain(id); err != nil || keyDomain != actorDomain {
+		return fmt.Errorf("key %s of actor %s is not on its server", id, actorID)
+	}
+	return nil
+}
+
+// actorPublicKey extracts the key ID and PEM from an actor document
+func actorPublicKey(doc map[string]any) (string, string) {
+	key, _ := doc["publicKey"].(map[string]any)
+	id, _ := key["id"].(string)
+	pem, _ := key["publicKeyPem"].(string)
+	return id, pem
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrInstanceBlocked is returned when browsing an instance this server
+	// blocks
+	ErrInstanceBlocked = errors.New("this instance is blocked on this server")
+	// ErrTimelineRequiresLogin is returned when an instance only shows its
+	// timeline to its own signed-in users
+	ErrTimelineRequiresLogin = errors.New("this instance only shows its timeline to signed-in users")
+)
+
+// RemoteInstanceService reads the public local timeline of other Mastodon
+// instances without an account there
+type RemoteInstanceService struct {
+	mastodon *MastodonService
+	cfg      *config.Config
+}
+
+// NewRemoteInstanceService creates a new RemoteInstanceService instance
+func NewRemoteInstanceService(db *pgxpool.Pool, cfg *config.Config) *RemoteInstanceService {
+	return &RemoteInstanceService{mastodon: NewMastodonService(db, cfg), cfg: cfg}
+}
+
+// Timeline fetches the public local timeline of an instance. Blocked
+// instances are never contacted.
+func (s *RemoteInstanceService) Timeline(ctx context.Context, domain string, limit int, maxID string) ([]MastodonStatus, error) {
+	domain, err := NormalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.InstanceBlocked(domain) {
+		return nil, ErrInstanceBlocked
+	}
+
+	statuses, err := s.mastodon.GetPublicTimeline(ctx, "https://"+domain, true, limit, maxID)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		// Instances closing their timelines answer 401, or 422 on older
+		// versions of Mastodon
+		switch apiErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusUnprocessableEntity:
+			return nil, ErrTimelineRequiresLogin
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", domain, err)
+	}
+	return statuses, nil
+}
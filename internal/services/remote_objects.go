@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxAncestors bounds how far up a reply chain objects are fetched
+const maxAncestors = 5
+
+// RemoteObjectService fetches remote objects referenced only by ID and caches
+// them in the remote_objects table
+type RemoteObjectService struct {
+	db *pgxpool.Pool
+}
+
+// NewRemoteObjectService creates a new RemoteObjectService instance
+func NewRemoteObjectService(db *pgxpool.Pool) *RemoteObjectService {
+	return &RemoteObjectService{db: db}
+}
+
+// Get returns a remote object, fetching it with a signed GET if it is not cached
+func (s *RemoteObjectService) Get(ctx context.Context, objectID, privateKeyPEM, keyID string) (map[string]any, error) {
+	var objectJSON []byte
+	err := s.db.QueryRow(ctx, "SELECT object_json FROM remote_objects WHERE object_id = $1", objectID).Scan(&objectJSON)
+	if err == nil {
+		var object map[string]any
+		if err := json.Unmarshal(objectJSON, &object); err != nil {
+			return nil, fmt.Errorf("failed to decode cached object: %w", err)
+		}
+		return object, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to load cached object: %w", err)
+	}
+
+	object, err := activitypub.FetchObject(objectID, privateKeyPEM, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch object: %w", err)
+	}
+	authorID, err := validateObject(objectID, object)
+	if err != nil {
+		return nil, err
+	}
+
+	objectType, _ := object["type"].(string)
+	inReplyTo := referenceID(object["inReplyTo"])
+	objectJSON, err = json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode object: %w", err)
+	}
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO remote_objects (object_id, object_type, attributed_to, in_reply_to, object_json)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		ON CONFLICT (object_id) DO UPDATE SET
+			object_json = EXCLUDED.object_json, in_reply_to = EXCLUDED.in_reply_to, fetched_at = NOW()
+	`, objectID, objectType, authorID, inReplyTo, objectJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cache object: %w", err)
+	}
+
+	return object, nil
+}
+
+// Ancestors fetches the posts an object replies to, nearest first, stopping
+// at maxAncestors or the first one that cannot be fetched
+func (s *RemoteObjectService) Ancestors(ctx context.Context, object map[string]any, privateKeyPEM, keyID string) []map[string]any {
+	var ancestors []map[string]any
+	seen := map[string]bool{}
+	for parentID := referenceID(object["inReplyTo"]); parentID != "" && len(ancestors) < maxAncestors; {
+		if seen[parentID] {
+			break
+		}
+		seen[parentID] = true
+
+		parent, err := s.Get(ctx, parentID, privateKeyPEM, keyID)
+		if err != nil {
+			break
+		}
+		ancestors = append(ancestors, parent)
+		parentID = referenceID(parent["inReplyTo"])
+	}
+	return ancestors
+}
+
+// validateObject checks that a fetched object is the one requested and is
+// attributed to an actor on the same server, and returns that actor
+func validateObject(objectID string, object map[string]any) (string, error) {
+	if id, _ := object["id"].(string); id != objectID {
+		return "", fmt.Errorf("fetched %s but got %q", objectID, id)
+	}
+
+	authorID := referenceID(object["attributedTo"])
+	if authorID == "" {
+		return "", fmt.Errorf("object %s has no author", objectID)
+	}
+
+	objectDomain, err := activitypub.ExtractDomain(objectID)
+	if err != nil {
+		return "", err
+	}
+	authorDomain, err := activitypub.ExtractDomain(authorID)
+	if err != nil {
+		return "", err
+	}
+	if objectDomain != authorDomain {
+		return "", fmt.Errorf("object %s is attributed to %s on another server", objectID, authorID)
+	}
+
+	return authorID, nil
+}
+
+// referenceID returns the ID of an object reference, which may be a bare ID,
+// an embedded object, or a list whose first entry is used
+func referenceID(ref any) string {
+	switch ref := ref.(type) {
+	case string:
+		return ref
+	case map[string]any:
+		id, _ := ref["id"].(string)
+		return id
+	case []any:
+		if len(ref) > 0 {
+			return referenceID(ref[0])
+		}
+	}
+	return ""
+}
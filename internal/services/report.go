@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+)
+
+// ReportCategory is the reason given for a report
+type ReportCategory string
+
+const (
+	ReportSpam      ReportCategory = "spam"
+	ReportLegal     ReportCategory = "legal"
+	ReportViolation ReportCategory = "violation" // Breaks the instance rules
+	ReportOther     ReportCategory = "other"
+)
+
+// ReportRequest represents the request body for filing a report
+type ReportRequest struct {
+	AccountID string         `json:"account_id"`
+	StatusIDs []string       `json:"status_ids,omitempty"`
+	Comment   string         `json:"comment,omitempty"`
+	Forward   bool           `json:"forward"`
+	Category  ReportCategory `json:"category,omitempty"`
+}
+
+// MastodonReport represents a report filed with the user's instance
+type MastodonReport struct {
+	ID       string `json:"id"`
+	Category string `json:"category"`
+	Comment  string `json:"comment"`
+}
+
+// ReportAccount reports an account to the moderators of the user's instance.
+// forward also sends an anonymized copy to the account's home instance.
+func (s *MastodonService) ReportAccount(ctx context.Context, userID int, accountID string, category ReportCategory, comment string, forward bool) (*MastodonReport, error) {
+	return s.fileReport(ctx, userID, ReportRequest{
+		AccountID: accountID,
+		Comment:   comment,
+		Forward:   forward,
+		Category:  category,
+	})
+}
+
+// ReportStatus reports a post and its author to the moderators of the user's instance
+func (s *MastodonService) ReportStatus(ctx context.Context, userID int, accountID, statusID string, category ReportCategory, comment string, forward bool) (*MastodonReport, error) {
+	return s.fileReport(ctx, userID, ReportRequest{
+		AccountID: accountID,
+		StatusIDs: []string{statusID},
+		Comment:   comment,
+		Forward:   forward,
+		Category:  category,
+	})
+}
+
+// fileReport sends a report to /api/v1/reports
+func (s *MastodonService) fileReport(ctx context.Context, userID int, report ReportRequest) (*MastodonReport, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result MastodonReport
+	if err := s.doJSON(ctx, "POST", instanceURL+"/api/v1/reports", accessToken, report, &result); err != nil {
+		return nil, fmt.Errorf("failed to file report: %w", err)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/reporting"
+)
+
+// Defaults for unset retry settings
+const (
+	defaultRetryAttempts  = 3
+	defaultRetryBaseDelay = 250 * time.Millisecond
+	defaultRetryMaxDelay  = 2 * time.Second
+)
+
+// retryPolicy retries requests to other servers that fail transiently.
+// Idempotent requests are retried on network errors and on 429, 502, 503
+// and 504 responses; other requests only on network errors, and carry an
+// Idempotency-Key so that servers supporting it apply them once.
+type retryPolicy struct {
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+// newRetryPolicy reads the retry settings for Mastodon API calls
+func newRetryPolicy(cfg *config.Config) retryPolicy {
+	p := retryPolicy{attempts: defaultRetryAttempts, baseDelay: defaultRetryBaseDelay, maxDelay: defaultRetryMaxDelay}
+	if cfg == nil {
+		return p
+	}
+	if cfg.Mastodon.RetryMaxAttempts > 0 {
+		p.attempts = cfg.Mastodon.RetryMaxAttempts
+	}
+	if cfg.Mastodon.RetryBaseDelay > 0 {
+		p.baseDelay = time.Duration(cfg.Mastodon.RetryBaseDelay) * time.Millisecond
+	}
+	if cfg.Mastodon.RetryMaxDelay > 0 {
+		p.maxDelay = time.Duration(cfg.Mastodon.RetryMaxDelay) * time.Millisecond
+	}
+	return p
+}
+
+// do sends a request with client, retrying it under the policy. The
+// request body is replayed with GetBody, so requests whose body cannot be
+// replayed are sent once. An instance that keeps failing is reported, and
+// every call is kept for the debug overlay.
+func (p retryPolicy) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := p.send(client, req)
+	recordAPICall(req, resp, time.Since(start))
+	switch {
+	case req.Context().Err() != nil:
+		// Given up on by the caller, not failed
+	case err != nil:
+		reporting.APIFailure(req.URL.Host, err)
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		reporting.APIFailure(req.URL.Host, fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, resp.Status))
+	default:
+		reporting.APISuccess(req.URL.Host)
+	}
+	return resp, err
+}
+
+// send makes the attempts do allows
+func (p retryPolicy) send(client *http.Client, req *http.Request) (*http.Response, error) {
+	idempotent := isIdempotent(req.Method)
+	if !idempotent && req.Header.Get("Idempotency-Key") == "" {
+		req.Header.Set("Idempotency-Key", fmt.Sprintf("%016x%016x", rand.Uint64(), rand.Uint64()))
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req)
+		if attempt >= p.attempts || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		// A response that cannot be retried is handed back as it is
+		hasBody := req.Body != nil && req.Body != http.NoBody
+		replayable := !hasBody || req.GetBody != nil
+		var retryAfter time.Duration
+		switch {
+		case err != nil:
+			if !replayable {
+				return nil, err
+			}
+		case idempotent && replayable && retryableStatus(resp.StatusCode):
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		default:
+			return resp, err
+		}
+
+		if hasBody {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, fmt.Errorf("retry: cannot replay body: %w", bodyErr)
+			}
+			req.Body = body
+		}
+
+		timer := time.NewTimer(max(p.backoff(attempt), min(retryAfter, p.maxDelay)))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before retry n: an exponentially growing
+// ceiling with full jitter, so that clients do not retry in lockstep
+func (p retryPolicy) backoff(n int) time.Duration {
+	ceiling := p.baseDelay << min(n-1, 16)
+	if ceiling <= 0 || ceiling > p.maxDelay {
+		ceiling = p.maxDelay
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
+
+// isIdempotent reports whether repeating a request with this method has
+// the same effect as sending it once
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryableStatus reports whether a response status means the server may
+// succeed if asked again
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as a date
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
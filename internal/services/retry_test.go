@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryReplaysBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, "busy")
+	}))
+	defer server.Close()
+	policy := retryPolicy{attempts: 3, baseDelay: time.Millisecond, maxDelay: time.Millisecond}
+
+	tests := []struct {
+		name     string
+		body     func() io.Reader
+		getBody  func(*http.Request)
+		attempts int
+		wantErr  bool
+	}{
+		{name: "replayable", body: func() io.Reader { return strings.NewReader("x") }, attempts: 3},
+		{
+			// Not a type NewRequest knows how to replay
+			name:     "not replayable",
+			body:     func() io.Reader { return io.MultiReader(strings.NewReader("x")) },
+			attempts: 1,
+		},
+		{
+			name: "replay fails",
+			body: func() io.Reader { return strings.NewReader("x") },
+			getBody: func(req *http.Request) {
+				req.GetBody = func() (io.ReadCloser, error) { return nil, errors.New("gone") }
+			},
+			attempts: 1,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies = nil
+			req, _ := http.NewRequest(http.MethodPut, server.URL, tt.body())
+			if tt.getBody != nil {
+				tt.getBody(req)
+			}
+			resp, err := policy.send(server.Client(), req)
+			if len(bodies) != tt.attempts {
+				t.Errorf("sent %d times, want %d", len(bodies), tt.attempts)
+			}
+			for _, body := range bodies {
+				if body != "x" {
+					t.Errorf("sent body %q", body)
+				}
+			}
+			if tt.wantErr {
+				if err == nil || resp != nil {
+					t.Errorf("expected an error and no response, got %v, %v", resp, err)
+				}
+				return
+			}
+			if err != nil || resp == nil {
+				t.Fatalf("expected the last response, got %v, %v", resp, err)
+			}
+			defer resp.Body.Close()
+			if body, _ := io.ReadAll(resp.Body); resp.StatusCode != http.StatusServiceUnavailable || string(body) != "busy" {
+				t.Errorf("got %s %q", resp.Status, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+)
+
+// secp256k1 is the curve y² = x³ + 7 over the field of p, with generator
+// (gx, gy) of order n. Nostr signs events with BIP-340 Schnorr signatures
+// over it, which the standard library does not offer.
+var secp256k1 = struct {
+	p, n, gx, gy *big.Int
+}{
+	p:  hexInt("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f"),
+	n:  hexInt("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"),
+	gx: hexInt("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"),
+	gy: hexInt("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"),
+}
+
+// hexInt parses a hexadecimal constant
+func hexInt(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid constant " + s)
+	}
+	return n
+}
+
+// curvePoint is a point of secp256k1 in affine coordinates; nil x is the
+// point at infinity
+type curvePoint struct {
+	x, y *big.Int
+}
+
+// add returns a + b
+func (a curvePoint) add(b curvePoint) curvePoint {
+	p := secp256k1.p
+	if a.x == nil {
+		return b
+	}
+	if b.x == nil {
+		return a
+	}
+
+	var slope *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if new(big.Int).Add(a.y, b.y).Mod(new(big.Int).Add(a.y, b.y), p).Sign() == 0 {
+			return curvePoint{}
+		}
+		// Doubling: slope = 3x² / 2y
+		num := new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(a.y, 1)
+		slope = num.Mul(num, den.ModInverse(den, p))
+	} else {
+		num := new(big.Int).Sub(b.y, a.y)
+		den := new(big.Int).Sub(b.x, a.x)
+		den.Mod(den, p)
+		slope = num.Mul(num, den.ModInverse(den, p))
+	}
+	slope.Mod(slope, p)
+
+	x := new(big.Int).Mul(slope, slope)
+	x.Sub(x, a.x).Sub(x, b.x).Mod(x, p)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, slope).Sub(y, a.y).Mod(y, p)
+	return curvePoint{x: x, y: y}
+}
+
+// baseMul returns k·G
+func baseMul(k *big.Int) curvePoint {
+	result := curvePoint{}
+	addend := curvePoint{x: secp256k1.gx, y: secp256k1.gy}
+	for i := 0; i < k.BitLen(); i++ {
+		if k.Bit(i) == 1 {
+			result = result.add(addend)
+		}
+		addend = addend.add(addend)
+	}
+	return result
+}
+
+// bytes32 encodes n as 32 big-endian bytes
+func bytes32(n *big.Int) []byte {
+	return n.FillBytes(make([]byte, 32))
+}
+
+// taggedHash is the tagged SHA-256 of BIP-340
+func taggedHash(tag string, parts ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+// schnorrSecret parses a 32-byte secret key, which must be in [1, n)
+func schnorrSecret(secretKey []byte) (*big.Int, error) {
+	d := new(big.Int).SetBytes(secretKey)
+	if len(secretKey) != 32 || d.Sign() == 0 || d.Cmp(secp256k1.n) >= 0 {
+		return nil, fmt.Errorf("invalid secret key")
+	}
+	return d, nil
+}
+
+// schnorrPublicKey returns the x-only public key of a secret key
+func schnorrPublicKey(secretKey []byte) ([]byte, error) {
+	d, err := schnorrSecret(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return bytes32(baseMul(d).x), nil
+}
+
+// schnorrSign signs a 32-byte message with a secret key as BIP-340 does,
+// with fresh auxiliary randomness
+func schnorrSign(secretKey, message []byte) ([]byte, error) {
+	aux := make([]byte, 32)
+	if _, err := rand.Read(aux); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return schnorrSignAux(secretKey, message, aux)
+}
+
+// schnorrSignAux signs a message with the given auxiliary randomness
+func schnorrSignAux(secretKey, message, aux []byte) ([]byte, error) {
+	n := secp256k1.n
+	d, err := schnorrSecret(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	public := baseMul(d)
+	if public.y.Bit(0) == 1 {
+		d.Sub(n, d)
+	}
+
+	t := taggedHash("BIP0340/aux", aux)
+	for i, b := range bytes32(d) {
+		t[i] ^= b
+	}
+	k := new(big.Int).SetBytes(taggedHash("BIP0340/nonce", t, bytes32(public.x), message))
+	k.Mod(k, n)
+	if k.Sign() == 0 {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+	r := baseMul(k)
+	if r.y.Bit(0) == 1 {
+		k.Sub(n, k)
+	}
+
+	e := new(big.Int).SetBytes(taggedHash("BIP0340/challenge", bytes32(r.x), bytes32(public.x), message))
+	e.Mod(e, n)
+	s := e.Mul(e, d)
+	s.Add(s, k).Mod(s, n)
+	return append(bytes32(r.x), bytes32(s)...), nil
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxSearchFacets is how many hashtags a search reports
+const maxSearchFacets = 10
+
+// publicAddresses are the forms of the public collection found in to and cc
+var publicAddresses = []string{activitypub.PublicCollection, "as:Public", "Public"}
+
+// searchMatches selects the posts matching a query: public local posts and
+// the viewer's own, and cached remote posts addressed to the public. $1 is
+// the query, $2 the viewer and $3 the public addresses.
+const searchMatches = `
+	WITH q AS (SELECT websearch_to_tsquery('simple', $1) AS query),
+	matches AS (
+		SELECT 'local' AS source, p.id::text AS key, ts_rank_cd(p.search_vector, q.query) AS rank,
+			p.published_at AS at, p.content AS body
+		FROM posts p JOIN users u ON u.id = p.user_id, q
+		WHERE p.search_vector @@ q.query AND p.deleted_at IS NULL AND u.deleted_at IS NULL AND u.suspended_at IS NULL
+			AND ((p.visibility = 'public' AND u.silenced_at IS NULL) OR p.user_id = $2)
+		UNION ALL
+		SELECT 'remote', o.object_id, ts_rank_cd(o.search_vector, q.query),
+			o.fetched_at, COALESCE(o.object_json->>'content', '')
+		FROM remote_objects o, q
+		WHERE o.search_vector @@ q.query
+			AND (o.object_json->'to' ?| $3 OR o.object_json->'cc' ?| $3)
+	)`
+
+// HashtagFacet is a hashtag used in search results and how many results use it
+type HashtagFacet struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// SearchResults is one page of search results with the hashtags across all matches
+type SearchResults struct {
+	Statuses []MastodonStatus `json:"statuses"`
+	Hashtags []HashtagFacet   `json:"hashtags"`
+}
+
+// SearchService searches local posts and cached remote posts by full text
+type SearchService struct {
+	db        *pgxpool.Pool
+	timelines *TimelineService
+}
+
+// NewSearchService creates a new SearchService instance
+func NewSearchService(db *pgxpool.Pool, cfg *config.Config) *SearchService {
+	return &SearchService{db: db, timelines: NewTimelineService(db, cfg)}
+}
+
+// Search returns posts matching query, best matches first. The query uses
+// web search syntax: quoted phrases, "or" and -excluded words.
+func (s *SearchService) Search(ctx context.Context, viewerID int, query string, limit, offset int) (*SearchResults, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return &SearchResults{}, nil
+	}
+
+	rows, err := s.db.Query(ctx, searchMatches+`
+		SELECT source, key, at FROM matches
+		ORDER BY rank DESC, at DESC
+		LIMIT $4 OFFSET $5
+	`, query, viewerID, publicAddresses, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search posts: %w", err)
+	}
+	// Results are keyed by source and status ID, the time each entered its timeline
+	var order []string
+	var localIDs []int
+	var objectIDs []string
+	for rows.Next() {
+		var source, key string
+		var at time.Time
+		if err := rows.Scan(&source, &key, &at); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		order = append(order, source+":"+timelineID(at))
+		if source == "local" {
+			id, _ := strconv.Atoi(key)
+			localIDs = append(localIDs, id)
+		} else {
+			objectIDs = append(objectIDs, key)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search posts: %w", err)
+	}
+
+	results := &SearchResults{}
+	if results.Statuses, err = s.load(ctx, order, localIDs, objectIDs); err != nil {
+		return nil, err
+	}
+	if results.Hashtags, err = s.facets(ctx, viewerID, query); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// load fetches the matched posts and puts them in match order
+func (s *SearchService) load(ctx context.Context, order []string, localIDs []int, objectIDs []string) ([]MastodonStatus, error) {
+	byKey := map[string]MastodonStatus{}
+	if len(localIDs) > 0 {
+		local, err := s.timelines.localPosts(ctx, len(localIDs), time.Time{}, "p.id = ANY($3)", localIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range local {
+			byKey["local:"+status.ID] = status
+		}
+	}
+	if len(objectIDs) > 0 {
+		remote, err := s.timelines.remotePosts(ctx, len(objectIDs), time.Time{}, `
+			SELECT o.fetched_at, o.object_json, o.attributed_to, COALESCE(a.acct, ''),
+				COALESCE(a.actor_json->>'name', ''), COALESCE(a.actor_json->>'preferredUsername', '')
+			FROM remote_objects o
+			LEFT JOIN remote_actors a ON a.actor_id = o.attributed_to
+			WHERE ($2::timestamp IS NULL OR o.fetched_at < $2) AND o.object_id = ANY($3)
+			LIMIT $1
+		`, objectIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range remote {
+			byKey["remote:"+status.ID] = status
+		}
+	}
+
+	statuses := make([]MastodonStatus, 0, len(order))
+	for _, key := range order {
+		if status, ok := byKey[key]; ok {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+// facets counts the hashtags used across every post matching query
+func (s *SearchService) facets(ctx context.Context, viewerID int, query string) ([]HashtagFacet, error) {
+	rows, err := s.db.Query(ctx, searchMatches+`
+		SELECT lower(tag[1]), COUNT(DISTINCT matches.source || matches.key)
+		FROM matches, regexp_matches(regexp_replace(matches.body, '<[^>]*>', '', 'g'), '(?:^|[^[:alnum:]_&])#([[:alnum:]_]+)', 'g') AS tag
+		GROUP BY 1
+		ORDER BY 2 DESC, 1
+		LIMIT $4
+	`, query, viewerID, publicAddresses, maxSearchFacets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count hashtags: %w", err)
+	}
+	defer rows.Close()
+
+	var facets []HashtagFacet
+	for rows.Next() {
+		var facet HashtagFacet
+		if err := rows.Scan(&facet.Name, &facet.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan hashtag: %w", err)
+		}
+		facets = append(facets, facet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count hashtags: %w", err)
+	}
+	return facets, nil
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrNoSecretKey is returned when a credential has to be stored or read but
+// the instance has no security.secret_key to encrypt it with
+var ErrNoSecretKey = errors.New("linking other services is not set up on this instance (security.secret_key)")
+
+// sealSecret encrypts a credential of a linked service with AES-GCM, keyed
+// with the SHA-256 of the instance's secret key
+func sealSecret(secretKey, plaintext string) (string, error) {
+	gcm, err := secretCipher(secretKey)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openSecret decrypts a credential sealed by sealSecret
+func openSecret(secretKey, sealed string) (string, error) {
+	gcm, err := secretCipher(secretKey)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("stored credential is corrupt")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("stored credential can't be decrypted; was security.secret_key changed?")
+	}
+	return string(plaintext), nil
+}
+
+// secretCipher returns the AES-GCM cipher of the instance's secret key
+func secretCipher(secretKey string) (cipher.AEAD, error) {
+	if secretKey == "" {
+		return nil, ErrNoSecretKey
+	}
+	key := sha256.Sum256([]byte(secretKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
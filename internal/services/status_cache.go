@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// statusCacheLimit is how many posts are kept per user and timeline
+const statusCacheLimit = 200
+
+// StatusCacheService keeps recently fetched timelines for offline reading
+type StatusCacheService struct {
+	db *pgxpool.Pool
+}
+
+// NewStatusCacheService creates a new StatusCacheService instance
+func NewStatusCacheService(db *pgxpool.Pool) *StatusCacheService {
+	return &StatusCacheService{db: db}
+}
+
+// Store saves fetched posts of a timeline, replacing older copies, and drops
+// the oldest posts beyond the per-timeline limit
+func (s *StatusCacheService) Store(ctx context.Context, userID int, timeline TimelineType, statuses []MastodonStatus) error {
+	if len(statuses) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, status := range statuses {
+		statusJSON, err := json.Marshal(status)
+		if err != nil {
+			return fmt.Errorf("failed to encode status: %w", err)
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO cached_statuses (user_id, timeline, status_id, status_json, status_created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id, timeline, status_id)
+			DO UPDATE SET status_json = EXCLUDED.status_json, cached_at = CURRENT_TIMESTAMP
+		`, userID, string(timeline), status.ID, statusJSON, status.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to cache status: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM cached_statuses
+		WHERE user_id = $1 AND timeline = $2 AND status_id NOT IN (
+			SELECT status_id FROM cached_statuses
+			WHERE user_id = $1 AND timeline = $2
+			ORDER BY status_created_at DESC
+			LIMIT $3
+		)
+	`, userID, string(timeline), statusCacheLimit)
+	if err != nil {
+		return fmt.Errorf("failed to prune status cache: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Load returns the newest cached posts of a timeline and when the cache was
+// last refreshed; it returns no posts if nothing has been cached
+func (s *StatusCacheService) Load(ctx context.Context, userID int, timeline TimelineType, limit int) ([]MastodonStatus, time.Time, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT status_json, cached_at
+		FROM cached_statuses
+		WHERE user_id = $1 AND timeline = $2
+		ORDER BY status_created_at DESC
+		LIMIT $3
+	`, userID, string(timeline), limit)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to load cached statuses: %w", err)
+	}
+	defer rows.Close()
+
+	var statuses []MastodonStatus
+	var cachedAt time.Time
+	for rows.Next() {
+		var statusJSON []byte
+		var rowCachedAt time.Time
+		if err := rows.Scan(&statusJSON, &rowCachedAt); err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to scan cached status: %w", err)
+		}
+
+		var status MastodonStatus
+		if err := json.Unmarshal(statusJSON, &status); err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to decode cached status: %w", err)
+		}
+		statuses = append(statuses, status)
+
+		if rowCachedAt.After(cachedAt) {
+			cachedAt = rowCachedAt
+		}
+	}
+
+	return statuses, cachedAt, rows.Err()
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// maxSummaryCount caps unread counters fetched for the status summary
+const maxSummaryCount = 40
+
+// MastodonMarker represents a saved read position for a timeline
+type MastodonMarker struct {
+	LastReadID string `json:"last_read_id"`
+	Version    int    `json:"version"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// MastodonConversation represents a direct message conversation
+type MastodonConversation struct {
+	ID         string            `json:"id"`
+	Unread     bool              `json:"unread"`
+	Accounts   []MastodonAccount `json:"accounts"`
+	LastStatus *MastodonStatus   `json:"last_status"`
+}
+
+// StatusSummary is a compact overview of a user's unread activity
+type StatusSummary struct {
+	UnreadNotifications int  `json:"unread_notifications"`
+	UnreadDirect        int  `json:"unread_direct"`
+	Followers           int  `json:"followers"`
+	Truncated           bool `json:"truncated"`
+}
+
+// Line formats the summary as a single line suitable for tmux/screen status bars
+func (s StatusSummary) Line() string {
+	notifications := fmt.Sprintf("%d", s.UnreadNotifications)
+	if s.Truncated {
+		notifications += "+"
+	}
+	return fmt.Sprintf("@ %s  DM %d  followers %d", notifications, s.UnreadDirect, s.Followers)
+}
+
+// VerifyCredentials fetches the account of the user's primary Mastodon token
+func (s *MastodonService) VerifyCredentials(ctx context.Context, userID int) (*MastodonAccount, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var account MastodonAccount
+	apiURL := fmt.Sprintf("%s/api/v1/accounts/verify_credentials", instanceURL)
+	if err := s.doJSON(ctx, "GET", apiURL, accessToken, nil, &account); err != nil {
+		return nil, fmt.Errorf("failed to verify credentials: %w", err)
+	}
+
+	return &account, nil
+}
+
+// UpdateCredentials updates the display name and bio of the user's primary
+// Mastodon account; avatars require a file upload and are not synced
+func (s *MastodonService) UpdateCredentials(ctx context.Context, userID int, displayName, note string) (*MastodonAccount, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]string{
+		"display_name": displayName,
+		"note":         note,
+	}
+
+	var account MastodonAccount
+	apiURL := fmt.Sprintf("%s/api/v1/accounts/update_credentials", instanceURL)
+	if err := s.doJSON(ctx, "PATCH", apiURL, accessToken, body, &account); err != nil {
+		return nil, fmt.Errorf("failed to update credentials: %w", err)
+	}
+
+	return &account, nil
+}
+
+// GetMarkers fetches saved read positions for the given timelines ("home", "notifications")
+func (s *MastodonService) GetMarkers(ctx context.Context, userID int, timelines ...string) (map[string]MastodonMarker, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	for _, timeline := range timelines {
+		params.Add("timeline[]", timeline)
+	}
+
+	markers := make(map[string]MastodonMarker)
+	apiURL := fmt.Sprintf("%s/api/v1/markers?%s", instanceURL, params.Encode())
+	if err := s.doJSON(ctx, "GET", apiURL, accessToken, nil, &markers); err != nil {
+		return nil, fmt.Errorf("failed to fetch markers: %w", err)
+	}
+
+	return markers, nil
+}
+
+// SaveMarker stores the read position of a timeline on the user's instance
+func (s *MastodonService) SaveMarker(ctx context.Context, userID int, timeline, lastReadID string) error {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]map[string]string{
+		timeline: {"last_read_id": lastReadID},
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v1/markers", instanceURL)
+	if err := s.doJSON(ctx, "POST", apiURL, accessToken, body, nil); err != nil {
+		return fmt.Errorf("failed to save marker: %w", err)
+	}
+
+	return nil
+}
+
+// GetConversations fetches direct message conversations
+func (s *MastodonService) GetConversations(ctx context.Context, userID int, limit int, maxID string) ([]MastodonConversation, error) {
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v1/conversations?limit=%d", instanceURL, limit)
+	if maxID != "" {
+		apiURL += fmt.Sprintf("&max_id=%s", maxID)
+	}
+
+	var conversations []MastodonConversation
+	if err := s.doJSON(ctx, "GET", apiURL, accessToken, nil, &conversations); err != nil {
+		return nil, fmt.Errorf("failed to fetch conversations: %w", err)
+	}
+
+	return conversations, nil
+}
+
+// GetStatusSummary counts unread notifications and DMs and fetches the follower count
+func (s *MastodonService) GetStatusSummary(ctx context.Context, userID int) (*StatusSummary, error) {
+	account, err := s.VerifyCredentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &StatusSummary{Followers: account.FollowersCount}
+
+	// Notifications newer than the server-side read marker are unread
+	lastReadID := ""
+	if markers, err := s.GetMarkers(ctx, userID, "notifications"); err == nil {
+		lastReadID = markers["notifications"].LastReadID
+	}
+
+	accessToken, instanceURL, err := s.getPrimaryToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v1/notifications?limit=%d", instanceURL, maxSummaryCount)
+	if lastReadID != "" {
+		apiURL += fmt.Sprintf("&since_id=%s", lastReadID)
+	}
+
+	var notifications []MastodonNotification
+	if err := s.doJSON(ctx, "GET", apiURL, accessToken, nil, &notifications); err != nil {
+		return nil, fmt.Errorf("failed to fetch notifications: %w", err)
+	}
+	summary.UnreadNotifications = len(notifications)
+	summary.Truncated = len(notifications) >= maxSummaryCount
+
+	conversations, err := s.GetConversations(ctx, userID, maxSummaryCount, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, conversation := range conversations {
+		if conversation.Unread {
+			summary.UnreadDirect++
+		}
+	}
+
+	return summary, nil
+}
+
+// FormatStatusSummary renders a summary either as a status line or as key=value pairs
+func FormatStatusSummary(summary *StatusSummary, format string) string {
+	switch strings.ToLower(format) {
+	case "kv":
+		return fmt.Sprintf("notifications=%d dm=%d followers=%d",
+			summary.UnreadNotifications, summary.UnreadDirect, summary.Followers)
+	default:
+		return summary.Line()
+	}
+}
@@ -0,0 +1,423 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/db"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// publicListing selects the posts listed publicly: public posts by users
+// who are not silenced
+const publicListing = "p.visibility = 'public' AND u.silenced_at IS NULL"
+
+// TimelineService serves the server's own timelines: public posts by local
+// users, and those merged with posts relayed from the wider network. Status
+// IDs are the microsecond timestamp a post entered the timeline, so both
+// sources page together and sort like Mastodon IDs.
+type TimelineService struct {
+	db  *pgxpool.Pool
+	cfg *config.Config
+}
+
+// NewTimelineService creates a new TimelineService instance
+func NewTimelineService(db *pgxpool.Pool, cfg *config.Config) *TimelineService {
+	return &TimelineService{db: db, cfg: cfg}
+}
+
+// Local returns public posts by local users, newest first. maxID pages back
+// from an earlier result.
+func (s *TimelineService) Local(ctx context.Context, limit int, maxID string) ([]MastodonStatus, error) {
+	before, err := timelinePosition(maxID)
+	if err != nil {
+		return nil, err
+	}
+	return s.localPosts(ctx, limit, before, publicListing)
+}
+
+// Tag returns public local posts using a hashtag, newest first
+func (s *TimelineService) Tag(ctx context.Context, tag string, limit int, maxID string) ([]MastodonStatus, error) {
+	before, err := timelinePosition(maxID)
+	if err != nil {
+		return nil, err
+	}
+	return s.localPosts(ctx, limit, before,
+		publicListing+" AND EXISTS (SELECT 1 FROM post_tags t WHERE t.post_id = p.id AND t.tag = $3)",
+		strings.ToLower(strings.TrimPrefix(tag, "#")))
+}
+
+// TagCount returns how many public local posts use a hashtag
+func (s *TimelineService) TagCount(ctx context.Context, tag string) (int, error) {
+	var count int
+	err := db.ForReads(s.db).QueryRow(ctx, `
+		SELECT COUNT(*) FROM post_tags t
+		JOIN posts p ON p.id = t.post_id
+		JOIN users u ON u.id = p.user_id
+		WHERE t.tag = $1 AND `+publicListing+` AND p.deleted_at IS NULL AND u.deleted_at IS NULL
+			AND u.suspended_at IS NULL
+	`, strings.ToLower(strings.TrimPrefix(tag, "#"))).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count hashtag posts: %w", err)
+	}
+	return count, nil
+}
+
+// Account returns a local user's public and unlisted posts, newest first
+func (s *TimelineService) Account(ctx context.Context, userID, limit int, maxID string) ([]MastodonStatus, error) {
+	before, err := timelinePosition(maxID)
+	if err != nil {
+		return nil, err
+	}
+	return s.localPosts(ctx, limit, before, "p.user_id = $3 AND p.visibility IN ('public', 'unlisted')", userID)
+}
+
+// Pinned returns the public and unlisted posts a local user pinned to their profile
+func (s *TimelineService) Pinned(ctx context.Context, userID int) ([]MastodonStatus, error) {
+	return s.localPosts(ctx, maxPinnedPosts, time.Time{},
+		"p.user_id = $3 AND p.pinned_at IS NOT NULL AND p.visibility IN ('public', 'unlisted')", userID)
+}
+
+// Home returns a user's own posts and the cached posts of accounts they
+// follow, newest first
+func (s *TimelineService) Home(ctx context.Context, userID, limit int, maxID string) ([]MastodonStatus, error) {
+	before, err := timelinePosition(maxID)
+	if err != nil {
+		return nil, err
+	}
+
+	own, err := s.localPosts(ctx, limit, before, "p.user_id = $3 AND p.visibility <> 'direct'", userID)
+	if err != nil {
+		return nil, err
+	}
+	followed, err := s.remotePosts(ctx, limit, before, `
+		SELECT o.fetched_at, o.object_json, o.attributed_to, COALESCE(a.acct, ''),
+			COALESCE(a.actor_json->>'name', ''), COALESCE(a.actor_json->>'preferredUsername', '')
+		FROM remote_objects o
+		JOIN following f ON f.target_actor_id = o.attributed_to AND f.user_id = $3 AND f.accepted
+		LEFT JOIN remote_actors a ON a.actor_id = o.attributed_to
+		WHERE $2::timestamp IS NULL OR o.fetched_at < $2
+		ORDER BY o.fetched_at DESC
+		LIMIT $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return mergeStatuses(own, followed, limit), nil
+}
+
+// Status returns a local post by status ID. Posts other than public and
+// unlisted ones are only returned to their author.
+func (s *TimelineService) Status(ctx context.Context, statusID string, viewerID int) (*MastodonStatus, error) {
+	published, err := timelinePosition(statusID)
+	if err != nil || published.IsZero() {
+		return nil, ErrPostNotFound
+	}
+	statuses, err := s.localPosts(ctx, 1, time.Time{},
+		"p.published_at = $3 AND (p.visibility IN ('public', 'unlisted') OR p.user_id = $4)", published, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		return nil, ErrPostNotFound
+	}
+	return &statuses[0], nil
+}
+
+// Post returns a public or unlisted local post by its posts table ID, the
+// ID in its ActivityPub URL
+func (s *TimelineService) Post(ctx context.Context, postID int) (*MastodonStatus, error) {
+	statuses, err := s.localPosts(ctx, 1, time.Time{}, "p.id = $3 AND p.visibility IN ('public', 'unlisted')", postID)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		return nil, ErrPostNotFound
+	}
+	return &statuses[0], nil
+}
+
+// PostID returns the posts table ID of a local status
+func (s *TimelineService) PostID(ctx context.Context, statusID string) (int, error) {
+	published, err := timelinePosition(statusID)
+	if err != nil || published.IsZero() {
+		return 0, ErrPostNotFound
+	}
+	var postID int
+	err = s.db.QueryRow(ctx, "SELECT id FROM posts WHERE published_at = $1 AND deleted_at IS NULL", published).Scan(&postID)
+	if err != nil {
+		return 0, ErrPostNotFound
+	}
+	return postID, nil
+}
+
+// Federated returns public local posts and posts received through relays,
+// newest first. maxID pages back from an earlier result.
+func (s *TimelineService) Federated(ctx context.Context, limit int, maxID string) ([]MastodonStatus, error) {
+	before, err := timelinePosition(maxID)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := s.localPosts(ctx, limit, before, publicListing)
+	if err != nil {
+		return nil, err
+	}
+	relayed, err := s.remotePosts(ctx, limit, before, `
+		SELECT f.received_at, o.object_json, o.attributed_to, COALESCE(a.acct, ''),
+			COALESCE(a.actor_json->>'name', ''), COALESCE(a.actor_json->>'preferredUsername', '')
+		FROM federated_timeline f
+		JOIN remote_objects o ON o.object_id = f.object_id
+		LEFT JOIN remote_actors a ON a.actor_id = o.attributed_to
+		WHERE $2::timestamp IS NULL OR f.received_at < $2
+		ORDER BY f.received_at DESC
+		LIMIT $1
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return mergeStatuses(local, relayed, limit), nil
+}
+
+// mergeStatuses combines two newest-first pages into one of at most limit statuses
+func mergeStatuses(a, b []MastodonStatus, limit int) []MastodonStatus {
+	statuses := append(a, b...)
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return StatusIDNewer(statuses[i].ID, statuses[j].ID)
+	})
+	if len(statuses) > limit {
+		statuses = statuses[:limit]
+	}
+	return statuses
+}
+
+// StatusID returns the status ID of a local post
+func StatusID(post *models.Post) string {
+	return timelineID(post.PublishedAt)
+}
+
+// timelinePosition converts a max_id into the time to page back from; the
+// zero time means the newest page
+func timelinePosition(maxID string) (time.Time, error) {
+	if maxID == "" {
+		return time.Time{}, nil
+	}
+	micros, err := strconv.ParseInt(maxID, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timeline position %q", maxID)
+	}
+	return time.UnixMicro(micros).UTC(), nil
+}
+
+// timelineID returns the status ID of a post that entered a timeline at t
+func timelineID(t time.Time) string {
+	return strconv.FormatInt(t.UnixMicro(), 10)
+}
+
+// localPosts loads undeleted posts by local users published before a
+// position and matching filter, whose parameters start at $3
+func (s *TimelineService) localPosts(ctx context.Context, limit int, before time.Time, filter string, args ...any) ([]MastodonStatus, error) {
+	rows, err := db.ForReads(s.db).Query(ctx, `
+		SELECT p.id, p.content, COALESCE(p.content_type, 'text/plain'), COALESCE(p.visibility, 'public'), p.published_at, COALESCE(p.ap_id, ''),
+			p.pinned_at, u.id, u.username, COALESCE(u.display_name, ''), COALESCE(u.avatar_url, ''),
+			COALESCE(p.gpg_signature, ''), COALESCE(u.gpg_public_key, '')
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.deleted_at IS NULL AND u.deleted_at IS NULL AND u.suspended_at IS NULL AND `+filter+`
+			AND ($2::timestamp IS NULL OR p.published_at < $2)
+		ORDER BY p.published_at DESC
+		LIMIT $1
+	`, append([]any{limit, nullTime(before)}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load local timeline: %w", err)
+	}
+	defer rows.Close()
+
+	var statuses []MastodonStatus
+	for rows.Next() {
+		var post models.Post
+		var account MastodonAccount
+		var gpgKey string
+		if err := rows.Scan(&post.ID, &post.Content, &post.ContentType, &post.Visibility, &post.PublishedAt, &post.APID,
+			&post.PinnedAt, &post.UserID, &account.Username, &account.DisplayName, &account.Avatar, &post.GPGSignature, &gpgKey); err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+
+		actorID := activitypub.ActorURL(s.cfg.Server.BaseURL, account.Username)
+		account.ID = strconv.Itoa(post.UserID)
+		account.Acct = account.Username
+		account.URL = fmt.Sprintf("%s/@%s", s.cfg.Server.BaseURL, account.Username)
+
+		content := post.Content
+		if post.ContentType != "text/html" {
+			content = plainTextHTML(content)
+		}
+
+		url := post.APID
+		if url == "" {
+			url = fmt.Sprintf("%s/statuses/%d", actorID, post.ID)
+		}
+
+		tags := []MastodonTag{}
+		for _, tag := range activitypub.ExtractHashtags(post.Content) {
+			tags = append(tags, MastodonTag{Name: tag, URL: activitypub.TagURL(s.cfg.Server.BaseURL, tag)})
+		}
+
+		statuses = append(statuses, MastodonStatus{
+			ID:         timelineID(post.PublishedAt),
+			CreatedAt:  post.PublishedAt,
+			Content:    content,
+			Visibility: mastodonVisibility(post.Visibility),
+			URL:        url,
+			URI:        url,
+			Account:    account,
+			Tags:       tags,
+			Pinned:     post.PinnedAt != nil,
+			GPGSigner:  gpgSigner(gpgKey, post.Content, post.GPGSignature),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load local timeline: %w", err)
+	}
+	return statuses, nil
+}
+
+// remotePosts loads cached remote posts with a query selecting the time
+// each entered the timeline, the object, its author, and the author's acct,
+// name and username; $1 is the limit, $2 the position and the rest args
+func (s *TimelineService) remotePosts(ctx context.Context, limit int, before time.Time, query string, args ...any) ([]MastodonStatus, error) {
+	rows, err := db.ForReads(s.db).Query(ctx, query, append([]any{limit, nullTime(before)}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load remote posts: %w", err)
+	}
+	defer rows.Close()
+
+	var statuses []MastodonStatus
+	signatures := map[int]string{} // Index in statuses of signed posts
+	for rows.Next() {
+		var receivedAt time.Time
+		var objectJSON []byte
+		var account MastodonAccount
+		if err := rows.Scan(&receivedAt, &objectJSON, &account.URL, &account.Acct, &account.DisplayName, &account.Username); err != nil {
+			return nil, fmt.Errorf("failed to scan remote post: %w", err)
+		}
+		var object map[string]any
+		if err := json.Unmarshal(objectJSON, &object); err != nil {
+			return nil, fmt.Errorf("failed to decode remote post: %w", err)
+		}
+
+		account.ID = account.URL
+		if account.Acct == "" {
+			account.Acct = account.URL
+		}
+		status := MastodonStatus{
+			ID:         timelineID(receivedAt),
+			CreatedAt:  receivedAt,
+			Visibility: "public",
+			Account:    account,
+		}
+		status.Content, _ = object["content"].(string)
+		status.SpoilerText, _ = object["summary"].(string)
+		status.Sensitive, _ = object["sensitive"].(bool)
+		if status.URL, _ = object["url"].(string); status.URL == "" {
+			status.URL, _ = object["id"].(string)
+		}
+		if published, _ := object["published"].(string); published != "" {
+			if t, err := time.Parse(time.RFC3339, published); err == nil {
+				status.CreatedAt = t
+			}
+		}
+		if signature, _ := object["gpgSignature"].(string); signature != "" {
+			signatures[len(statuses)] = signature
+		}
+		statuses = append(statuses, status)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load remote posts: %w", err)
+	}
+	if err := s.verifySignatures(ctx, statuses, signatures); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// verifySignatures checks the GPG signatures of remote posts, which other
+// terminalpub servers attach to signed posts, against the keys their
+// authors publish, and marks the statuses that pass
+func (s *TimelineService) verifySignatures(ctx context.Context, statuses []MastodonStatus, signatures map[int]string) error {
+	if len(signatures) == 0 {
+		return nil
+	}
+	var authors []string
+	for i := range signatures {
+		authors = append(authors, statuses[i].Account.URL)
+	}
+	rows, err := db.ForReads(s.db).Query(ctx, `
+		SELECT actor_id, actor_json->>'gpgKey' FROM remote_actors
+		WHERE actor_id = ANY($1) AND actor_json->>'gpgKey' IS NOT NULL
+	`, authors)
+	if err != nil {
+		return fmt.Errorf("failed to load GPG keys: %w", err)
+	}
+	keys := map[string]string{}
+	for rows.Next() {
+		var actorID, key string
+		if err := rows.Scan(&actorID, &key); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan GPG key: %w", err)
+		}
+		keys[actorID] = key
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load GPG keys: %w", err)
+	}
+
+	for i, signature := range signatures {
+		statuses[i].GPGSigner = gpgSigner(keys[statuses[i].Account.URL], statuses[i].Content, signature)
+	}
+	return nil
+}
+
+// gpgSigner returns the fingerprint of the key a post was signed with, or
+// "" if it is unsigned or the signature does not check out
+func gpgSigner(armoredKey, text, signature string) string {
+	if armoredKey == "" || signature == "" {
+		return ""
+	}
+	fingerprint, err := VerifyGPGSignature(armoredKey, text, signature)
+	if err != nil {
+		return ""
+	}
+	return fingerprint
+}
+
+// mastodonVisibility maps a stored post visibility to Mastodon's name for it
+func mastodonVisibility(visibility string) string {
+	if visibility == "followers" {
+		return "private"
+	}
+	return visibility
+}
+
+// plainTextHTML renders plain-text post content as HTML
+func plainTextHTML(text string) string {
+	return "<p>" + strings.ReplaceAll(html.EscapeString(text), "\n", "<br>") + "</p>"
+}
+
+// nullTime maps the zero time to NULL
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
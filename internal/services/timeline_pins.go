@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Prefixes of the timelines that take a parameter, followed by it in the
+// TimelineType
+const (
+	timelineTagPrefix      = "tag:"
+	timelineListPrefix     = "list:"
+	timelineInstancePrefix = "instance:"
+)
+
+// MaxTimelinePin is the highest pin slot; slots start at 1
+const MaxTimelinePin = 9
+
+var (
+	tagPattern    = regexp.MustCompile(`^[\p{L}\p{N}_]{1,100}$`)
+	listPattern   = regexp.MustCompile(`^[A-Za-z0-9]{1,64}$`)
+	domainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}(:[0-9]{1,5})?$`)
+
+	// ErrTimelinePinNotFound is returned when a slot has nothing pinned
+	ErrTimelinePinNotFound = errors.New("nothing pinned there")
+)
+
+// TagTimeline is the Mastodon timeline of a hashtag
+func TagTimeline(tag string) TimelineType {
+	return TimelineType(timelineTagPrefix + strings.ToLower(strings.TrimPrefix(tag, "#")))
+}
+
+// ListTimeline is the timeline of one of the user's Mastodon lists
+func ListTimeline(id string) TimelineType {
+	return TimelineType(timelineListPrefix + id)
+}
+
+// InstanceTimeline is the public local timeline of any Mastodon instance,
+// read without an account there
+func InstanceTimeline(domain string) TimelineType {
+	return TimelineType(timelineInstancePrefix + domain)
+}
+
+// Tag returns the hashtag of a TagTimeline
+func (t TimelineType) Tag() (string, bool) {
+	return strings.CutPrefix(string(t), timelineTagPrefix)
+}
+
+// List returns the list ID of a ListTimeline
+func (t TimelineType) List() (string, bool) {
+	return strings.CutPrefix(string(t), timelineListPrefix)
+}
+
+// Instance returns the domain of an InstanceTimeline
+func (t TimelineType) Instance() (string, bool) {
+	return strings.CutPrefix(string(t), timelineInstancePrefix)
+}
+
+// ParseTimeline reads a timeline as typed by a user: home, local,
+// federated, #tag, list:<id> or an instance domain such as
+// instance:example.social
+func ParseTimeline(text string) (TimelineType, error) {
+	text = strings.TrimSpace(text)
+	switch lower := strings.ToLower(text); {
+	case lower == "home":
+		return TimelineHome, nil
+	case lower == "local":
+		return TimelineLocal, nil
+	case lower == "federated" || lower == "public":
+		return TimelineFederated, nil
+	case strings.HasPrefix(lower, "#") || strings.HasPrefix(lower, timelineTagPrefix):
+		tag := strings.TrimPrefix(strings.TrimPrefix(lower, timelineTagPrefix), "#")
+		if !tagPattern.MatchString(tag) {
+			return "", fmt.Errorf("invalid hashtag %q", tag)
+		}
+		return TagTimeline(tag), nil
+	case strings.HasPrefix(lower, timelineListPrefix):
+		id := text[len(timelineListPrefix):]
+		if !listPattern.MatchString(id) {
+			return "", fmt.Errorf("invalid list %q", id)
+		}
+		return ListTimeline(id), nil
+	default:
+		domain, err := NormalizeDomain(strings.TrimPrefix(lower, timelineInstancePrefix))
+		if err != nil {
+			return "", fmt.Errorf("unknown timeline %q: %w", text, err)
+		}
+		return InstanceTimeline(domain), nil
+	}
+}
+
+// NormalizeDomain reduces an instance address such as
+// https://Example.Social/ to its domain
+func NormalizeDomain(text string) (string, error) {
+	domain := strings.ToLower(strings.TrimSpace(text))
+	domain = strings.TrimPrefix(domain, "https://")
+	domain = strings.TrimPrefix(domain, "http://")
+	domain = strings.TrimPrefix(domain, "@")
+	domain = strings.TrimSuffix(domain, "/")
+	if !domainPattern.MatchString(domain) {
+		return "", fmt.Errorf("invalid instance domain %q", text)
+	}
+	return domain, nil
+}
+
+// TimelinePinService stores the timelines users pin to the numbered slots
+// of the feed
+type TimelinePinService struct {
+	db *pgxpool.Pool
+}
+
+// NewTimelinePinService creates a new TimelinePinService instance
+func NewTimelinePinService(db *pgxpool.Pool) *TimelinePinService {
+	return &TimelinePinService{db: db}
+}
+
+// List returns a user's pins in slot order
+func (s *TimelinePinService) List(ctx context.Context, userID int) ([]models.TimelinePin, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT user_id, slot, timeline, created_at
+		FROM timeline_pins
+		WHERE user_id = $1
+		ORDER BY slot
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pins: %w", err)
+	}
+	defer rows.Close()
+
+	var pins []models.TimelinePin
+	for rows.Next() {
+		var pin models.TimelinePin
+		if err := rows.Scan(&pin.UserID, &pin.Slot, &pin.Timeline, &pin.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan pin: %w", err)
+		}
+		pins = append(pins, pin)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load pins: %w", err)
+	}
+	return pins, nil
+}
+
+// Pin puts a timeline in a slot, replacing what was pinned there
+func (s *TimelinePinService) Pin(ctx context.Context, userID, slot int, timeline TimelineType) (*models.TimelinePin, error) {
+	if slot < 1 || slot > MaxTimelinePin {
+		return nil, fmt.Errorf("slot must be between 1 and %d", MaxTimelinePin)
+	}
+	if _, err := ParseTimeline(string(timeline)); err != nil {
+		return nil, err
+	}
+
+	pin := &models.TimelinePin{UserID: userID, Slot: slot, Timeline: string(timeline)}
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO timeline_pins (user_id, slot, timeline)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, slot) DO UPDATE SET timeline = EXCLUDED.timeline, created_at = NOW()
+		RETURNING created_at
+	`, userID, slot, pin.Timeline).Scan(&pin.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pin timeline: %w", err)
+	}
+	return pin, nil
+}
+
+// Unpin empties a slot
+func (s *TimelinePinService) Unpin(ctx context.Context, userID, slot int) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM timeline_pins WHERE user_id = $1 AND slot = $2`, userID, slot)
+	if err != nil {
+		return fmt.Errorf("failed to unpin timeline: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTimelinePinNotFound
+	}
+	return nil
+}
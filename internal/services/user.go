@@ -6,12 +6,26 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/config"
 	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	// ErrAccountDeleted is returned when logging into an account scheduled for deletion
+	ErrAccountDeleted = errors.New("account has been deleted")
+	// ErrUsernameTaken is returned when a chosen username already exists
+	ErrUsernameTaken = errors.New("username is already taken")
+)
+
 // UserService handles user-related operations
 type UserService struct {
 	db *pgxpool.Pool
@@ -81,10 +95,11 @@ func (s *UserService) CreateUser(ctx context.Context, username, email string) (*
 // GetUserByID retrieves a user by ID
 func (s *UserService) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, primary_mastodon_instance,
-		       primary_mastodon_id, primary_mastodon_acct, private_key, public_key,
-		       actor_url, inbox_url, outbox_url, followers_url, following_url,
-		       created_at, updated_at, bio, avatar_url
+		SELECT id, username, COALESCE(email, ''), COALESCE(password_hash, ''), COALESCE(primary_mastodon_instance, ''),
+		       COALESCE(primary_mastodon_id, ''), COALESCE(primary_mastodon_acct, ''), COALESCE(private_key, ''), COALESCE(public_key, ''),
+		       COALESCE(actor_url, ''), COALESCE(inbox_url, ''), COALESCE(outbox_url, ''), COALESCE(followers_url, ''), COALESCE(following_url, ''),
+		       created_at, updated_at, COALESCE(bio, ''), COALESCE(avatar_url, ''), username_confirmed, COALESCE(display_name, ''),
+		       manually_approves_followers, also_known_as, COALESCE(moved_to, ''), COALESCE(proof_public_key, ''), discoverable
 		FROM users
 		WHERE id = $1
 	`
@@ -109,6 +124,13 @@ func (s *UserService) GetUserByID(ctx context.Context, id int) (*models.User, er
 		&user.UpdatedAt,
 		&user.Bio,
 		&user.AvatarURL,
+		&user.UsernameConfirmed,
+		&user.DisplayName,
+		&user.ManuallyApprovesFollowers,
+		&user.AlsoKnownAs,
+		&user.MovedTo,
+		&user.ProofPublicKey,
+		&user.Discoverable,
 	)
 
 	if err != nil {
@@ -121,10 +143,11 @@ func (s *UserService) GetUserByID(ctx context.Context, id int) (*models.User, er
 // GetUserByUsername retrieves a user by username
 func (s *UserService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, primary_mastodon_instance,
-		       primary_mastodon_id, primary_mastodon_acct, private_key, public_key,
-		       actor_url, inbox_url, outbox_url, followers_url, following_url,
-		       created_at, updated_at, bio, avatar_url
+		SELECT id, username, COALESCE(email, ''), COALESCE(password_hash, ''), COALESCE(primary_mastodon_instance, ''),
+		       COALESCE(primary_mastodon_id, ''), COALESCE(primary_mastodon_acct, ''), COALESCE(private_key, ''), COALESCE(public_key, ''),
+		       COALESCE(actor_url, ''), COALESCE(inbox_url, ''), COALESCE(outbox_url, ''), COALESCE(followers_url, ''), COALESCE(following_url, ''),
+		       created_at, updated_at, COALESCE(bio, ''), COALESCE(avatar_url, ''), username_confirmed, COALESCE(display_name, ''),
+		       manually_approves_followers, also_known_as, COALESCE(moved_to, ''), COALESCE(proof_public_key, ''), discoverable
 		FROM users
 		WHERE username = $1
 	`
@@ -149,6 +172,13 @@ func (s *UserService) GetUserByUsername(ctx context.Context, username string) (*
 		&user.UpdatedAt,
 		&user.Bio,
 		&user.AvatarURL,
+		&user.UsernameConfirmed,
+		&user.DisplayName,
+		&user.ManuallyApprovesFollowers,
+		&user.AlsoKnownAs,
+		&user.MovedTo,
+		&user.ProofPublicKey,
+		&user.Discoverable,
 	)
 
 	if err != nil {
@@ -163,11 +193,33 @@ func (s *UserService) GetOrCreateUser(ctx context.Context, username, email strin
 	// Try to get existing user first
 	user, err := s.GetUserByUsername(ctx, username)
 	if err == nil {
+		// Accounts pending purge cannot be logged into again, nor
+		// suspended ones
+		var deleted, suspended bool
+		err := s.db.QueryRow(ctx, "SELECT deleted_at IS NOT NULL, suspended_at IS NOT NULL FROM users WHERE id = $1", user.ID).Scan(&deleted, &suspended)
+		if err == nil && deleted {
+			return nil, ErrAccountDeleted
+		}
+		if err == nil && suspended {
+			return nil, auth.ErrAccountSuspended
+		}
 		// User exists, return it
 		return user, nil
 	}
 
 	// User doesn't exist, create new one
+	return insertUser(ctx, s.db, username, email)
+}
+
+// rowQuerier runs a query returning a single row, on the pool or in a
+// transaction
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// insertUser creates a user with a new ActivityPub keypair through q, or
+// returns the user that took username first
+func insertUser(ctx context.Context, q rowQuerier, username, email string) (*models.User, error) {
 	// Generate ActivityPub keypair for the user
 	privateKey, publicKey, err := generateKeyPair()
 	if err != nil {
@@ -193,11 +245,12 @@ func (s *UserService) GetOrCreateUser(ctx context.Context, username, email strin
 		RETURNING id, username, email, COALESCE(password_hash, ''), COALESCE(primary_mastodon_instance, ''),
 		          COALESCE(primary_mastodon_id, ''), COALESCE(primary_mastodon_acct, ''), private_key, public_key,
 		          actor_url, inbox_url, outbox_url, followers_url, following_url,
-		          created_at, updated_at, COALESCE(bio, ''), COALESCE(avatar_url, '')
+		          created_at, updated_at, COALESCE(bio, ''), COALESCE(avatar_url, ''), username_confirmed, COALESCE(display_name, ''),
+		          manually_approves_followers, also_known_as, COALESCE(moved_to, ''), COALESCE(proof_public_key, ''), discoverable
 	`
 
-	user = &models.User{}
-	err = s.db.QueryRow(ctx, query,
+	user := &models.User{}
+	err = q.QueryRow(ctx, query,
 		username,
 		email,
 		privateKey,
@@ -226,6 +279,13 @@ func (s *UserService) GetOrCreateUser(ctx context.Context, username, email strin
 		&user.UpdatedAt,
 		&user.Bio,
 		&user.AvatarURL,
+		&user.UsernameConfirmed,
+		&user.DisplayName,
+		&user.ManuallyApprovesFollowers,
+		&user.AlsoKnownAs,
+		&user.MovedTo,
+		&user.ProofPublicKey,
+		&user.Discoverable,
 	)
 
 	if err != nil {
@@ -235,6 +295,141 @@ func (s *UserService) GetOrCreateUser(ctx context.Context, username, email strin
 	return user, nil
 }
 
+// GetOrRegisterMastodonUser returns the user linked to a Mastodon account, or
+// registers one under a provisional username to be replaced during onboarding
+func (s *UserService) GetOrRegisterMastodonUser(ctx context.Context, token *models.MastodonToken, inviteCode string, cfg *config.Config) (*models.User, error) {
+	var userID int
+	var deleted, suspended bool
+	err := s.db.QueryRow(ctx, `
+		SELECT id, deleted_at IS NOT NULL, suspended_at IS NOT NULL
+		FROM users
+		WHERE primary_mastodon_instance = $1 AND primary_mastodon_id = $2
+		ORDER BY id
+		LIMIT 1
+	`, token.InstanceURL, token.MastodonID).Scan(&userID, &deleted, &suspended)
+	if err == nil {
+		if deleted {
+			return nil, ErrAccountDeleted
+		}
+		if suspended {
+			return nil, auth.ErrAccountSuspended
+		}
+		return s.GetUserByID(ctx, userID)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to look up mastodon account: %w", err)
+	}
+
+	return s.GetOrRegisterUser(ctx, auth.LocalUsername(token.Username, token.InstanceURL), inviteCode, cfg)
+}
+
+// GetOrRegisterUser returns an existing user or registers a new one, enforcing
+// the registration policy and redeeming inviteCode when invites are required
+func (s *UserService) GetOrRegisterUser(ctx context.Context, username, inviteCode string, cfg *config.Config) (*models.User, error) {
+	var exists bool
+	if err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)", username).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check user: %w", err)
+	}
+	if exists {
+		return s.GetOrCreateUser(ctx, username, "")
+	}
+
+	if !cfg.Features.Registration.Enabled {
+		return nil, ErrRegistrationClosed
+	}
+	if !cfg.Features.Registration.RequireInvite {
+		return s.GetOrCreateUser(ctx, username, "")
+	}
+	if strings.TrimSpace(inviteCode) == "" {
+		return nil, ErrInviteRequired
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// The invite row stays locked until commit, so concurrent signups cannot overuse it
+	inviteID, err := redeemInvite(ctx, tx, inviteCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Created in the same transaction, so that a registration that fails
+	// leaves neither the account nor a spent invite behind
+	user, err := insertUser(ctx, tx, username, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE users SET invite_id = $1 WHERE id = $2", inviteID, user.ID); err != nil {
+		return nil, fmt.Errorf("failed to record invite: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit registration: %w", err)
+	}
+
+	return user, nil
+}
+
+// IsUsernameAvailable reports whether a local username is free to take:
+// neither in use nor retired with a purged account
+func (s *UserService) IsUsernameAvailable(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)
+		    OR EXISTS(SELECT 1 FROM retired_usernames WHERE username = $1)
+	`, username).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check username: %w", err)
+	}
+	return !exists, nil
+}
+
+// ChooseUsername sets the local username picked during onboarding and
+// rebuilds the ActivityPub URLs; it only works once per account
+func (s *UserService) ChooseUsername(ctx context.Context, userID int, username, baseURL string) (*models.User, error) {
+	if err := models.ValidateUsername(username); err != nil {
+		return nil, err
+	}
+
+	available, err := s.IsUsernameAvailable(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if !available {
+		return nil, ErrUsernameTaken
+	}
+
+	actorURL := activitypub.ActorURL(baseURL, username)
+	result, err := s.db.Exec(ctx, `
+		UPDATE users
+		SET username = $1,
+		    actor_url = $2,
+		    inbox_url = $2 || '/inbox',
+		    outbox_url = $2 || '/outbox',
+		    followers_url = $2 || '/followers',
+		    following_url = $2 || '/following',
+		    username_confirmed = TRUE,
+		    updated_at = NOW()
+		WHERE id = $3 AND NOT username_confirmed
+	`, username, actorURL, userID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrUsernameTaken
+		}
+		return nil, fmt.Errorf("failed to set username: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return nil, fmt.Errorf("username has already been chosen")
+	}
+
+	return s.GetUserByID(ctx, userID)
+}
+
 // UpdatePrimaryMastodonAccount updates the user's primary Mastodon account info
 func (s *UserService) UpdatePrimaryMastodonAccount(ctx context.Context, userID int, instance, mastodonID, acct string) error {
 	query := `
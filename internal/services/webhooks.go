@@ -0,0 +1,391 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/outbound"
+	"github.com/fulgidus/terminalpub/internal/reporting"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// MaxWebhooks caps the webhooks a user can register
+	MaxWebhooks = 10
+
+	// maxWebhookAttempts is how many times a payload is sent before it is
+	// dropped; attempts back off exponentially from webhookRetryDelay
+	maxWebhookAttempts = 6
+
+	// webhookRetryDelay is the wait before the first retry of a payload
+	webhookRetryDelay = time.Minute
+)
+
+var (
+	// ErrWebhookNotFound is returned when a webhook does not exist or
+	// belongs to someone else
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrTooManyWebhooks is returned when a user already has MaxWebhooks
+	ErrTooManyWebhooks = fmt.Errorf("you can register at most %d webhooks", MaxWebhooks)
+)
+
+// webhookClient sends webhook payloads; redirects are not followed so a
+// payload only ever reaches the registered URL
+var webhookClient = func() *http.Client {
+	client := outbound.NewClient(10 * time.Second)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return client
+}()
+
+// WebhookService manages the webhooks of users and delivers the payloads
+// queued for them. Events are queued where they happen and sent by the
+// worker, which retries failed deliveries.
+type WebhookService struct {
+	db  *pgxpool.Pool
+	cfg *config.Config
+}
+
+// NewWebhookService creates a new WebhookService instance
+func NewWebhookService(db *pgxpool.Pool, cfg *config.Config) *WebhookService {
+	return &WebhookService{db: db, cfg: cfg}
+}
+
+// List returns a user's webhooks, oldest first
+func (s *WebhookService) List(ctx context.Context, userID int) ([]models.Webhook, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, user_id, url, secret, events, instance, enabled, COALESCE(last_status, ''), last_delivery_at, created_at
+		FROM webhooks
+		WHERE user_id = $1
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		var w models.Webhook
+		if err := rows.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, &w.Events, &w.Instance, &w.Enabled,
+			&w.LastStatus, &w.LastDeliveryAt, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// CanWatchInstance reports whether a user may register webhooks receiving
+// the events of every user, which only admins can
+func (s *WebhookService) CanWatchInstance(ctx context.Context, userID int) (bool, error) {
+	err := requireAdmin(ctx, s.db, userID)
+	if errors.Is(err, ErrNotAdmin) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Save registers a webhook, or updates webhook id when it is not zero. New
+// webhooks get a fresh secret, returned in the webhook.
+func (s *WebhookService) Save(ctx context.Context, userID, id int, rawURL string, events []string, instance, enabled bool) (*models.Webhook, error) {
+	w := &models.Webhook{ID: id, UserID: userID, URL: strings.TrimSpace(rawURL), Instance: instance, Enabled: enabled}
+	parsed, err := url.Parse(w.URL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, fmt.Errorf("URL must be an http(s) URL")
+	}
+	for _, event := range models.WebhookEvents {
+		if slices.Contains(events, event) {
+			w.Events = append(w.Events, event)
+		}
+	}
+	if len(w.Events) == 0 {
+		return nil, fmt.Errorf("choose at least one event")
+	}
+	if instance {
+		if err := requireAdmin(ctx, s.db, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if id == 0 {
+		var count int
+		if err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM webhooks WHERE user_id = $1`, userID).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to count webhooks: %w", err)
+		}
+		if count >= MaxWebhooks {
+			return nil, ErrTooManyWebhooks
+		}
+		if w.Secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+		err = s.db.QueryRow(ctx, `
+			INSERT INTO webhooks (user_id, url, secret, events, instance, enabled)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, created_at
+		`, userID, w.URL, w.Secret, w.Events, instance, enabled).Scan(&w.ID, &w.CreatedAt)
+	} else {
+		err = s.db.QueryRow(ctx, `
+			UPDATE webhooks
+			SET url = $3, events = $4, instance = $5, enabled = $6, updated_at = NOW()
+			WHERE id = $1 AND user_id = $2
+			RETURNING secret, created_at
+		`, id, userID, w.URL, w.Events, instance, enabled).Scan(&w.Secret, &w.CreatedAt)
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save webhook: %w", err)
+	}
+	if instance {
+		recordAdminAction(ctx, s.db, userID, "saved instance-wide webhook %d to %s", w.ID, w.URL)
+	}
+	return w, nil
+}
+
+// Delete removes one of a user's webhooks along with its queued payloads
+func (s *WebhookService) Delete(ctx context.Context, userID, id int) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM webhooks WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// RotateSecret gives a webhook a new secret and returns it
+func (s *WebhookService) RotateSecret(ctx context.Context, userID, id int) (string, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	tag, err := s.db.Exec(ctx, `
+		UPDATE webhooks SET secret = $3, updated_at = NOW() WHERE id = $1 AND user_id = $2
+	`, id, userID, secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return "", ErrWebhookNotFound
+	}
+	return secret, nil
+}
+
+// Ping queues a test payload for one of a user's webhooks, whatever its events
+func (s *WebhookService) Ping(ctx context.Context, userID, id int) error {
+	payload, err := s.payload(ctx, userID, models.WebhookPing, map[string]any{"webhook_id": id})
+	if err != nil {
+		return err
+	}
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT id, $3, $4 FROM webhooks WHERE id = $1 AND user_id = $2
+	`, id, userID, models.WebhookPing, payload)
+	if err != nil {
+		return fmt.Errorf("failed to queue webhook ping: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// Trigger queues an event of a user for their enabled webhooks subscribed
+// to it and for instance-wide ones. data describes the event and is sent as
+// the payload's "data".
+func (s *WebhookService) Trigger(ctx context.Context, userID int, event string, data map[string]any) error {
+	payload, err := s.payload(ctx, userID, event, data)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT id, $2, $3 FROM webhooks
+		WHERE enabled AND $2 = ANY(events) AND (user_id = $1 OR instance)
+	`, userID, event, payload)
+	if err != nil {
+		return fmt.Errorf("failed to queue webhook: %w", err)
+	}
+	return nil
+}
+
+// deliveryFailed reports an activity a remote inbox did not accept to the
+// user's delivery.failed webhooks and to error reporting; it is best
+// effort, like the delivery
+func (s *WebhookService) deliveryFailed(ctx context.Context, userID int, activityType, inbox string, deliveryErr error) {
+	tags := reporting.Tags{"kind": "federation_delivery", "activity": activityType, "user": reporting.UserHash(userID)}
+	if u, err := url.Parse(inbox); err == nil {
+		tags["instance"] = u.Hostname()
+	}
+	reporting.CaptureError(deliveryErr, tags)
+
+	err := s.Trigger(ctx, userID, models.WebhookDeliveryFailed, map[string]any{
+		"activity": activityType,
+		"inbox":    inbox,
+		"error":    deliveryErr.Error(),
+	})
+	if err != nil {
+		log.Printf("Failed to report delivery failure for user %d: %v", userID, err)
+	}
+}
+
+// payload builds the JSON body sent for an event of a user
+func (s *WebhookService) payload(ctx context.Context, userID int, event string, data map[string]any) ([]byte, error) {
+	var username string
+	if err := s.db.QueryRow(ctx, `SELECT username FROM users WHERE id = $1`, userID).Scan(&username); err != nil {
+		return nil, fmt.Errorf("failed to load webhook user: %w", err)
+	}
+	payload, err := json.Marshal(map[string]any{
+		"event":      event,
+		"instance":   s.cfg.Server.Domain,
+		"user":       username,
+		"created_at": time.Now().UTC().Format(time.RFC3339),
+		"data":       data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	return payload, nil
+}
+
+// DeliverPending sends up to limit queued payloads that are due and
+// returns how many were delivered. Failed sends are retried with
+// exponential backoff, up to maxWebhookAttempts times.
+func (s *WebhookService) DeliverPending(ctx context.Context, limit int) (int, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT d.id, d.webhook_id, d.event, d.payload, d.attempts, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.next_attempt_at <= NOW()
+		ORDER BY d.next_attempt_at
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load webhook deliveries: %w", err)
+	}
+
+	type pending struct {
+		id, webhookID int
+		event         string
+		payload       []byte
+		attempts      int
+		url, secret   string
+	}
+	var deliveries []pending
+	for rows.Next() {
+		var d pending
+		if err := rows.Scan(&d.id, &d.webhookID, &d.event, &d.payload, &d.attempts, &d.url, &d.secret); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to load webhook deliveries: %w", err)
+	}
+
+	delivered := 0
+	for _, d := range deliveries {
+		status, sendErr := s.send(ctx, d.url, d.secret, d.id, d.event, d.payload)
+		if sendErr != nil {
+			status = "failed: " + sendErr.Error()
+		}
+		if _, err := s.db.Exec(ctx, `
+			UPDATE webhooks SET last_status = LEFT($2, 255), last_delivery_at = NOW() WHERE id = $1
+		`, d.webhookID, status); err != nil {
+			return delivered, fmt.Errorf("failed to update webhook: %w", err)
+		}
+
+		switch {
+		case sendErr == nil:
+			delivered++
+			_, err = s.db.Exec(ctx, "DELETE FROM webhook_deliveries WHERE id = $1", d.id)
+		case d.attempts+1 >= maxWebhookAttempts:
+			log.Printf("Dropping webhook delivery %d after %d attempts: %v", d.id, d.attempts+1, sendErr)
+			_, err = s.db.Exec(ctx, "DELETE FROM webhook_deliveries WHERE id = $1", d.id)
+		default:
+			delay := webhookRetryDelay << d.attempts
+			_, err = s.db.Exec(ctx, `
+				UPDATE webhook_deliveries SET attempts = attempts + 1, next_attempt_at = NOW() + $2 WHERE id = $1
+			`, d.id, delay)
+		}
+		if err != nil {
+			return delivered, fmt.Errorf("failed to update webhook delivery: %w", err)
+		}
+	}
+	return delivered, nil
+}
+
+// signWebhook returns the X-Terminalpub-Signature header of a payload:
+// sha256= and the hex HMAC-SHA256, keyed with the secret, of the
+// X-Terminalpub-Timestamp header, a dot and the body
+func signWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// send POSTs a payload to a webhook URL, signed with signWebhook, and
+// returns the response status
+func (s *WebhookService) send(ctx context.Context, target, secret string, deliveryID int, event string, payload []byte) (string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", s.cfg.ActivityPub.UserAgent)
+	req.Header.Set("X-Terminalpub-Event", event)
+	req.Header.Set("X-Terminalpub-Delivery", strconv.Itoa(deliveryID))
+	req.Header.Set("X-Terminalpub-Timestamp", timestamp)
+	req.Header.Set("X-Terminalpub-Signature", signWebhook(secret, timestamp, payload))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("status %s", resp.Status)
+	}
+	return resp.Status, nil
+}
+
+// newWebhookSecret generates a random webhook secret
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+)
+
+func TestSignWebhook(t *testing.T) {
+	// Receivers check this exact format, so it must not change
+	got := signWebhook("whsec", "1700000000", []byte(`{"event":"ping"}`))
+	want := "sha256=0ff8d7f72a6d7a501c184edd94066018e47a9ec212addd18991db46e0ad6bcf1"
+	if got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+}
+
+func TestWebhookHeaders(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+	defer func(client *http.Client) { webhookClient = client }(webhookClient)
+	webhookClient = server.Client()
+
+	s := NewWebhookService(nil, &config.Config{})
+	payload := []byte(`{"event":"follow"}`)
+	if _, err := s.send(context.Background(), server.URL, "whsec", 7, "follow", payload); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := strconv.ParseInt(received.Header.Get("X-Terminalpub-Timestamp"), 10, 64); err != nil {
+		t.Errorf("expected a Unix timestamp: %v", err)
+	}
+	if string(body) != string(payload) {
+		t.Errorf("body = %s", body)
+	}
+	headers := map[string]string{
+		"Content-Type":            "application/json",
+		"X-Terminalpub-Event":     "follow",
+		"X-Terminalpub-Delivery":  "7",
+		"X-Terminalpub-Signature": signWebhook("whsec", received.Header.Get("X-Terminalpub-Timestamp"), payload),
+	}
+	for name, want := range headers {
+		if got := received.Header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/outbound"
+)
+
+// maxWebsocketMessage bounds the messages read from a WebSocket server
+const maxWebsocketMessage = 1 << 20
+
+// websocketGUID is appended to the key of a handshake to compute the accept
+// value the server must answer with
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket frame opcodes
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xa
+)
+
+// wsConn is the client end of a WebSocket connection (RFC 6455), with just
+// what talking to Nostr relays needs: text messages, pings and closing
+type wsConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dialWebsocket opens a WebSocket connection to a ws:// or wss:// URL. The
+// deadline of ctx applies to the whole connection, not only the handshake.
+func dialWebsocket(ctx context.Context, rawURL string) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		switch u.Scheme {
+		case "wss":
+			host += ":443"
+		case "ws":
+			host += ":80"
+		}
+	}
+
+	var conn net.Conn
+	switch u.Scheme {
+	case "wss":
+		conn, err = outbound.DialContext(ctx, "tcp", host)
+		if err == nil {
+			tlsConn := tls.Client(conn, outbound.TLSConfig(u.Hostname()))
+			if err = tlsConn.HandshakeContext(ctx); err != nil {
+				conn.Close()
+			}
+			conn = tlsConn
+		}
+	case "ws":
+		conn, err = outbound.DialContext(ctx, "tcp", host)
+	default:
+		return nil, fmt.Errorf("not a WebSocket URL: %s", rawURL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	ws := &wsConn{conn: conn, r: bufio.NewReader(conn)}
+	if err := ws.handshake(u); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ws, nil
+}
+
+// handshake upgrades the connection from HTTP
+func (ws *wsConn) handshake(u *url.URL) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: u.Path, RawQuery: u.RawQuery},
+		Host:       u.Host,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Upgrade":               {"websocket"},
+			"Connection":            {"Upgrade"},
+			"Sec-WebSocket-Key":     {key},
+			"Sec-WebSocket-Version": {"13"},
+			"User-Agent":            {"terminalpub"},
+		},
+	}
+	if req.URL.Path == "" {
+		req.URL.Path = "/"
+	}
+	if err := req.Write(ws.conn); err != nil {
+		return fmt.Errorf("failed to send handshake: %w", err)
+	}
+
+	resp, err := http.ReadResponse(ws.r, req)
+	if err != nil {
+		return fmt.Errorf("failed to read handshake: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return fmt.Errorf("server refused WebSocket: %s", resp.Status)
+	}
+	accept := sha1.Sum([]byte(key + websocketGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(accept[:]) {
+		return fmt.Errorf("invalid WebSocket handshake")
+	}
+	return nil
+}
+
+// WriteText sends a text message
+func (ws *wsConn) WriteText(data []byte) error {
+	return ws.writeFrame(wsText, data)
+}
+
+// writeFrame sends a single frame; client frames are always masked
+func (ws *wsConn) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch {
+	case len(payload) < 126:
+		header = append(header, 0x80|byte(len(payload)))
+	case len(payload) <= 0xffff:
+		header = append(header, 0x80|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	default:
+		header = append(header, 0x80|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(len(payload)))
+	}
+
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return fmt.Errorf("failed to generate mask: %w", err)
+	}
+	frame := append(header, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := ws.conn.Write(frame); err != nil {
+		return fmt.Errorf("failed to send: %w", err)
+	}
+	return nil
+}
+
+// ReadMessage returns the next text or binary message, answering pings on
+// the way. It returns io.EOF once the server closes the connection.
+func (ws *wsConn) ReadMessage() ([]byte, error) {
+	var message []byte
+	for {
+		var header [2]byte
+		if _, err := io.ReadFull(ws.r, header[:]); err != nil {
+			return nil, err
+		}
+		fin, opcode := header[0]&0x80 != 0, header[0]&0x0f
+		length := uint64(header[1] & 0x7f)
+		switch length {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+				return nil, err
+			}
+			length = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+				return nil, err
+			}
+			length = binary.BigEndian.Uint64(ext[:])
+		}
+		var mask []byte
+		if header[1]&0x80 != 0 {
+			mask = make([]byte, 4)
+			if _, err := io.ReadFull(ws.r, mask); err != nil {
+				return nil, err
+			}
+		}
+		if length > maxWebsocketMessage || uint64(len(message))+length > maxWebsocketMessage {
+			return nil, fmt.Errorf("message too large")
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(ws.r, payload); err != nil {
+			return nil, err
+		}
+		for i := range payload {
+			if mask != nil {
+				payload[i] ^= mask[i%4]
+			}
+		}
+
+		switch opcode {
+		case wsPing:
+			if err := ws.writeFrame(wsPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			return nil, io.EOF
+		case wsText, wsBinary, wsContinuation:
+			message = append(message, payload...)
+		default:
+			return nil, fmt.Errorf("unknown WebSocket opcode %d", opcode)
+		}
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// Close says goodbye to the server and closes the connection
+func (ws *wsConn) Close() error {
+	ws.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	ws.writeFrame(wsClose, nil)
+	return ws.conn.Close()
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// MaxTerminalMessageLength caps the characters of a terminal message
+	MaxTerminalMessageLength = 280
+
+	// redisWritePrefix is the prefix of the pub/sub channel of each user's
+	// connected sessions
+	redisWritePrefix = "write:"
+)
+
+var (
+	// ErrWriteNoUser is returned when writing to an unknown user
+	ErrWriteNoUser = errors.New("no local user with that name")
+	// ErrWriteNotConnected is returned when the recipient is not connected
+	ErrWriteNotConnected = errors.New("that user is not connected")
+	// ErrWriteRefused is returned when the recipient does not accept messages
+	ErrWriteRefused = errors.New("that user is not accepting messages")
+)
+
+// WriteService delivers short messages between connected users, like the
+// Unix write command. Messages are not stored: they reach the recipient's
+// open sessions through Redis pub/sub, or nobody.
+type WriteService struct {
+	db       *pgxpool.Pool
+	redis    redis.UniversalClient
+	presence *PresenceService
+}
+
+// NewWriteService creates a new WriteService instance
+func NewWriteService(db *pgxpool.Pool, redisClient redis.UniversalClient) *WriteService {
+	return &WriteService{db: db, redis: redisClient, presence: NewPresenceService(db, redisClient)}
+}
+
+// Send writes body to the terminals of the user named to, who must be
+// connected and accept messages
+func (s *WriteService) Send(ctx context.Context, fromUserID int, to, body string) error {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return fmt.Errorf("message is empty")
+	}
+	if utf8.RuneCountInString(body) > MaxTerminalMessageLength {
+		return fmt.Errorf("message is longer than %d characters", MaxTerminalMessageLength)
+	}
+
+	var from string
+	err := s.db.QueryRow(ctx, `SELECT username FROM users WHERE id = $1`, fromUserID).Scan(&from)
+	if err != nil {
+		return fmt.Errorf("failed to load sender: %w", err)
+	}
+
+	var toUserID int
+	var accepts bool
+	err = s.db.QueryRow(ctx, `SELECT id, accept_writes FROM users WHERE username = $1 AND deleted_at IS NULL`,
+		strings.ToLower(strings.TrimPrefix(to, "@"))).Scan(&toUserID, &accepts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrWriteNoUser
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load recipient: %w", err)
+	}
+	if !accepts {
+		return ErrWriteRefused
+	}
+	connected, err := s.presence.Connected(ctx, toUserID)
+	if err != nil {
+		return err
+	}
+	if !connected {
+		return ErrWriteNotConnected
+	}
+
+	payload, err := json.Marshal(models.TerminalMessage{From: from, Body: body, SentAt: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	receivers, err := s.redis.Publish(ctx, redisWritePrefix+strconv.Itoa(toUserID), payload).Result()
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if receivers == 0 {
+		// Connected, but signed out since the last heartbeat
+		return ErrWriteNotConnected
+	}
+	return nil
+}
+
+// Subscribe returns the messages written to a user until ctx is cancelled
+func (s *WriteService) Subscribe(ctx context.Context, userID int) (<-chan models.TerminalMessage, error) {
+	pubsub := s.redis.Subscribe(ctx, redisWritePrefix+strconv.Itoa(userID))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to messages: %w", err)
+	}
+
+	messages := make(chan models.TerminalMessage)
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+		received := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-received:
+				if !ok {
+					return
+				}
+				var message models.TerminalMessage
+				if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+					continue
+				}
+				select {
+				case messages <- message:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return messages, nil
+}
+
+// Accepts reports whether a user accepts messages from other users
+func (s *WriteService) Accepts(ctx context.Context, userID int) (bool, error) {
+	var accepts bool
+	err := s.db.QueryRow(ctx, `SELECT accept_writes FROM users WHERE id = $1`, userID).Scan(&accepts)
+	if err != nil {
+		return false, fmt.Errorf("failed to load message setting: %w", err)
+	}
+	return accepts, nil
+}
+
+// SetAccepts chooses whether a user accepts messages from other users
+func (s *WriteService) SetAccepts(ctx context.Context, userID int, accept bool) error {
+	_, err := s.db.Exec(ctx, `UPDATE users SET accept_writes = $2, updated_at = NOW() WHERE id = $1`, userID, accept)
+	if err != nil {
+		return fmt.Errorf("failed to save message setting: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// accessibleEnv is the SSH environment variable that starts a session in
+// accessibility mode, e.g. ssh -o SetEnv=TERMINALPUB_ACCESSIBLE=1
+const accessibleEnv = "TERMINALPUB_ACCESSIBLE"
+
+// screenNames are announced at the top of every screen in accessibility mode
+var screenNames = map[screenType]string{
+	screenWelcome:        "Welcome",
+	screenLogin:          "Login",
+	screenLoginInstance:  "Login: Mastodon instance",
+	screenLoginWaiting:   "Login: waiting for authorization",
+	screenLoginToken:     "Login: access token",
+	screenLoginInvite:    "Login: invite code",
+	screenChooseUsername: "Choose a username",
+	screenAuthenticated:  "Main menu",
+	screenAnonymous:      "Anonymous mode",
+	screenFeed:           "Feed",
+	screenCompose:        "Compose",
+	screenThread:         "Thread",
+	screenProfile:        "Profile",
+	screenNotifications:  "Notifications",
+	screenAPITokens:      "API tokens",
+	screenImportFollows:  "Import follows",
+	screenDeleteAccount:  "Delete account",
+	screenEditProfile:    "Edit profile",
+	screenReport:         "Report",
+	screenDiscover:       "Discover",
+	screenInbox:          "Inbox",
+	screenActionLog:      "Action log",
+	screenFollowRequests: "Follow requests",
+	screenSearch:         "Search",
+	screenHashtag:        "Hashtag",
+	screenDirect:         "Direct messages",
+	screenPostDetail:     "Post details",
+	screenAnnouncements:  "Instance announcements",
+	screenWho:            "Who's online",
+	screenChat:           "Chat",
+	screenGames:          "Games",
+	screenFeedFilters:    "Feed filters",
+	screenDirectory:      "Directory",
+	screenWebhooks:       "Webhooks",
+	screenIntegrations:   "Integrations",
+	screenDigest:         "Email digest",
+	screenAudit:          "Audit log",
+	screenQuarantine:     "Quarantine",
+	screenReader:         "Reader",
+}
+
+// startAccessible reports whether a session starts in accessibility mode,
+// either for everyone by configuration or because the client asked for it
+func startAccessible(ctx *AppContext, s ssh.Session) bool {
+	if ctx != nil && ctx.Config != nil && ctx.Config.TUI.Accessible {
+		return true
+	}
+	if s == nil {
+		return false
+	}
+	return slices.ContainsFunc(s.Environ(), func(env string) bool {
+		name, value, _ := strings.Cut(env, "=")
+		return name == accessibleEnv && value != "" && value != "0"
+	})
+}
+
+// toggleAccessible switches accessibility mode on or off for the session
+func (m Model) toggleAccessible() Model {
+	m.accessible = !m.accessible
+	m.message = "Accessibility mode off"
+	if m.accessible {
+		m.message = "Accessibility mode on"
+	}
+	return m
+}
+
+// accessibilityLabel describes the accessibility mode menu entry
+func (m Model) accessibilityLabel() string {
+	if m.accessible {
+		return " Accessibility mode: on"
+	}
+	return " Accessibility mode: off"
+}
+
+// accessibleView turns a rendered screen into plain text for screen readers
+// and braille terminals: colors and box drawing are removed, lines are
+// left-aligned with single spaces, runs of blank lines are collapsed, and
+// the screen's name comes first so a change of screen is announced
+func accessibleView(screen screenType, content string) string {
+	name := screenNames[screen]
+	if name == "" {
+		name = "terminalpub"
+	}
+	lines := []string{"Screen: " + name, ""}
+
+	blank := true
+	for _, line := range strings.Split(ansi.Strip(content), "\n") {
+		line = strings.Join(strings.Fields(strings.Map(plainRune, line)), " ")
+		if line == "" {
+			if !blank {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+		lines = append(lines, line)
+		blank = false
+	}
+	return strings.Join(lines, "\n")
+}
+
+// plainRune replaces characters braille tables render poorly: box drawing
+// and block elements become spaces, and markers become ASCII
+func plainRune(r rune) rune {
+	switch {
+	case r == '►' || r == '▶':
+		return '>'
+	case r == '•' || r == '·':
+		return '*'
+	case r >= 0x2500 && r <= 0x259F: // Box Drawing and Block Elements
+		return ' '
+	}
+	return r
+}
+
+// mediaAltText describes a media attachment by its alt text
+func mediaAltText(media services.MastodonMedia) string {
+	kind := mediaKind(media)
+	if media.Description == "" {
+		return kind + " without description"
+	}
+	return kind + ": " + media.Description
+}
+
+// mediaKind names the type of an attachment, e.g. "Image"
+func mediaKind(media services.MastodonMedia) string {
+	kind := media.Type
+	if kind == "" || kind == "unknown" {
+		kind = "attachment"
+	}
+	return strings.ToUpper(kind[:1]) + kind[1:]
+}
@@ -0,0 +1,214 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// actionLogLimit is how many recent actions the Activity screen shows
+const actionLogLimit = 50
+
+// actionLabels describes each action type in the Activity screen
+var actionLabels = map[models.ActionType]string{
+	models.ActionLike:     "Liked",
+	models.ActionBoost:    "Boosted",
+	models.ActionFollow:   "Followed",
+	models.ActionBookmark: "Bookmarked",
+	models.ActionMute:     "Muted",
+}
+
+// actionSummary describes a post for the action log
+func actionSummary(status services.MastodonStatus) string {
+	return "@" + status.Account.Acct + ": " + truncate(stripHTML(status.Content), 60)
+}
+
+// recordAction logs a successful action; a failure only loses the undo entry
+func recordAction(actionLog *services.ActionLogService, userID int, action models.ActionType, targetID, summary string) {
+	if actionLog == nil {
+		return
+	}
+	if err := actionLog.Record(context.Background(), userID, action, targetID, summary); err != nil {
+		fmt.Printf("Failed to record action: %v\n", err)
+	}
+}
+
+// ActionLogModel represents the Activity screen listing the user's own actions
+type ActionLogModel struct {
+	userID        int
+	actionLog     *services.ActionLogService
+	actions       []models.UserAction
+	selectedIndex int
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// actionsLoadedMsg is sent when the action log has been fetched
+type actionsLoadedMsg struct {
+	actions []models.UserAction
+	err     error
+}
+
+// actionUndoneMsg is sent when an action has been undone
+type actionUndoneMsg struct {
+	actionID int
+	err      error
+}
+
+// NewActionLogModel creates a new Activity screen model
+func NewActionLogModel(userID int, actionLog *services.ActionLogService) ActionLogModel {
+	return ActionLogModel{
+		userID:        userID,
+		actionLog:     actionLog,
+		loading:       true,
+		statusMessage: "Loading activity...",
+	}
+}
+
+// Init fetches the action log
+func (m ActionLogModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// Update handles messages for the Activity screen
+func (m ActionLogModel) Update(msg tea.Msg) (ActionLogModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case actionsLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.actions = msg.actions
+		if m.selectedIndex >= len(m.actions) {
+			m.selectedIndex = 0
+		}
+		m.statusMessage = ""
+		return m, nil
+
+	case actionUndoneMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		now := time.Now()
+		for i := range m.actions {
+			if m.actions[i].ID == msg.actionID {
+				m.actions[i].UndoneAt = &now
+				m.statusMessage = "Undone: " + strings.ToLower(actionLabels[m.actions[i].Action]) + " " + m.actions[i].Summary
+			}
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.selectedIndex > 0 {
+				m.selectedIndex--
+			}
+		case "down", "j":
+			if m.selectedIndex < len(m.actions)-1 {
+				m.selectedIndex++
+			}
+		case "u", "U", "enter":
+			if m.selectedIndex < len(m.actions) && !m.actions[m.selectedIndex].Undone() {
+				m.statusMessage = "Undoing..."
+				return m, m.undoCmd(m.actions[m.selectedIndex].ID)
+			}
+		case "ctrl+r":
+			m.loading = true
+			return m, m.fetchCmd()
+		}
+	}
+
+	return m, nil
+}
+
+// fetchCmd loads the user's recent actions
+func (m ActionLogModel) fetchCmd() tea.Cmd {
+	actionLog, userID := m.actionLog, m.userID
+	return func() tea.Msg {
+		actions, err := actionLog.List(context.Background(), userID, actionLogLimit)
+		return actionsLoadedMsg{actions: actions, err: err}
+	}
+}
+
+// undoCmd reverts an action on the user's Mastodon account
+func (m ActionLogModel) undoCmd(actionID int) tea.Cmd {
+	actionLog, userID := m.actionLog, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		err := actionLog.Undo(ctx, userID, actionID)
+		return actionUndoneMsg{actionID: actionID, err: err}
+	}
+}
+
+// View renders the Activity screen
+func (m ActionLogModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Activity") + "\n")
+	b.WriteString(subtleStyle.Render("Your recent likes, boosts and follows") + "\n\n")
+
+	if m.loading {
+		b.WriteString(subtleStyle.Render(m.statusMessage) + "\n")
+		return b.String()
+	}
+
+	if len(m.actions) == 0 {
+		b.WriteString("Nothing yet. Likes, boosts and follows made here will show up in this list.\n\n")
+	}
+
+	visible := max(m.height-10, 5)
+	start := 0
+	if m.selectedIndex >= visible {
+		start = m.selectedIndex - visible + 1
+	}
+	end := min(start+visible, len(m.actions))
+
+	for i := start; i < end; i++ {
+		action := m.actions[i]
+
+		selector := "  "
+		if i == m.selectedIndex {
+			selector = promptStyle.Render("► ")
+		}
+
+		label := actionLabels[action.Action]
+		if label == "" {
+			label = string(action.Action)
+		}
+		line := fmt.Sprintf("%s%-10s %s", selector, label, truncate(action.Summary, 50))
+		age := subtleStyle.Render(formatAge(time.Since(action.CreatedAt)))
+		if action.Undone() {
+			line = selector + subtleStyle.Render(fmt.Sprintf("%-10s %s (undone)", label, truncate(action.Summary, 50)))
+		}
+		b.WriteString(line + " " + age + "\n")
+	}
+
+	b.WriteString("\n" + keyStyle.Render("[U]") + " Undo  " +
+		keyStyle.Render("[Ctrl+R]") + " Refresh  " +
+		keyStyle.Render("[Esc]") + " Back\n")
+
+	if m.statusMessage != "" {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
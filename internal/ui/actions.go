@@ -0,0 +1,248 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fulgidus/terminalpub/internal/activitypub"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// postActionsMenuTitle identifies the post action menu in menu messages
+const postActionsMenuTitle = "Post actions"
+
+// newPostActionsMenu lists everything that can be done with a post; own
+// posts can also be pinned to the user's profile
+func newPostActionsMenu(status services.MastodonStatus, own bool) MenuModel {
+	like := "Like"
+	if status.Favourited {
+		like = "Remove like"
+	}
+	items := []MenuItem{
+		{ID: "reply", Label: "Reply", Key: "r"},
+		{ID: "boost", Label: "Boost or quote…", Key: "s"},
+		{ID: "like", Label: like, Key: "x"},
+		{ID: "react", Label: "React…", Key: "e"},
+		{ID: "bookmark", Label: "Bookmark", Key: "b"},
+		{ID: "thread", Label: "Open thread", Key: "t"},
+		{ID: "details", Label: "Details and edit history", Key: "d"},
+		{ID: "reader", Label: "Read in reader mode", Key: "z"},
+		{ID: "profile", Label: "View author's profile", Key: "p"},
+		{ID: "copy", Label: "Copy…", Key: "c"},
+		{ID: "links", Label: "Links…", Key: "o"},
+		{ID: "mute", Label: "Mute author", Key: "m"},
+		{ID: "report", Label: "Report…", Key: "!"},
+	}
+	if own && status.Visibility != "direct" {
+		label := "Pin to profile"
+		if status.Pinned {
+			label = "Unpin from profile"
+		}
+		items = append(items, MenuItem{ID: "pin", Label: label, Key: "i"})
+	}
+	return NewMenuModel(postActionsMenuTitle, items)
+}
+
+// ownsStatus reports whether the user wrote status, natively on this server
+// or from their linked Mastodon account
+func (m Model) ownsStatus(status services.MastodonStatus) bool {
+	if m.user == nil {
+		return false
+	}
+	if m.ctx != nil && m.ctx.Config != nil && status.URI != "" &&
+		strings.HasPrefix(status.URI, activitypub.ActorURL(m.ctx.Config.Server.BaseURL, m.user.Username)+"/") {
+		return true
+	}
+	return m.user.PrimaryMastodonID != "" && status.Account.ID == m.user.PrimaryMastodonID
+}
+
+// selectedFeedStatus returns the selected post in the feed, unwrapping boosts
+func (m Model) selectedFeedStatus() (services.MastodonStatus, bool) {
+	if m.feed.selectedIndex >= len(m.feed.statuses) {
+		return services.MastodonStatus{}, false
+	}
+	status := m.feed.statuses[m.feed.selectedIndex]
+	if status.Reblog != nil {
+		status = *status.Reblog
+	}
+	return status, true
+}
+
+// openMenu shows a popup menu acting on status
+func (m Model) openMenu(menu MenuModel, status services.MastodonStatus) Model {
+	m.menu = &menu
+	m.menuTarget = status
+	return m
+}
+
+// handlePostAction performs an entry chosen from the post action menu
+func (m Model) handlePostAction(action string, status services.MastodonStatus) (tea.Model, tea.Cmd) {
+	switch action {
+	case "reply":
+		return m.openReply(status)
+	case "boost":
+		return m.openMenu(newBoostMenu(status.Reblogged), status), nil
+	case "like":
+		return m.interact(interactionKey{statusID: status.ID}, status, !status.Favourited, "")
+	case "react":
+		return m.openMenu(newReactionMenu(m.statusReactions(status)), status), nil
+	case "bookmark":
+		return m, bookmarkStatusCmd(m.ctx.statusActions(), m.actionLog, m.user.ID, status)
+	case "thread":
+		return m.openThread(status)
+	case "profile":
+		return m.openProfile(status.Account.ID)
+	case "details":
+		return m.openDetail(status)
+	case "reader":
+		return m.openReader(status)
+	case "copy":
+		return m.openMenu(newCopyMenu(), status), nil
+	case "links":
+		return m.openLinks(status)
+	case "mute":
+		return m, muteAccountCmd(m.ctx.statusActions(), m.actionLog, m.user.ID, status.Account.ID, status.Account.Acct)
+	case "report":
+		return m.openMenu(newReportMenu(), status), nil
+	case "pin":
+		return m, pinStatusCmd(m.ctx, m.user.ID, status, !status.Pinned)
+	}
+	return m, nil
+}
+
+// openReply opens the compose screen replying to status
+func (m Model) openReply(status services.MastodonStatus) (Model, tea.Cmd) {
+	m.compose = NewReplyModel(status, m.user.PrimaryMastodonAcct)
+	m.compose.width = m.width
+	m.compose.height = m.height
+	m = m.pushScreen(screenCompose)
+	return m, tea.Batch(m.compose.Init(), fetchReplyChainCmd(m.ctx.timelines(), m.user.ID, status.ID))
+}
+
+// openThread opens the thread view for status
+func (m Model) openThread(status services.MastodonStatus) (Model, tea.Cmd) {
+	m.thread = NewThreadModel(context.Background(), m.user.ID, m.ctx.timelines(), status)
+	m.thread.width = m.width
+	m.thread.height = m.height
+	m = m.pushScreen(screenThread)
+	return m, m.thread.Init()
+}
+
+// openProfile opens the profile view for an account
+func (m Model) openProfile(accountID string) (Model, tea.Cmd) {
+	m.profile = NewProfileModel(context.Background(), m.user.ID, m.ctx.timelines(), accountID)
+	m.profile.width = m.width
+	m.profile.height = m.height
+	m = m.pushScreen(screenProfile)
+	return m, m.profile.Init()
+}
+
+// openDetail opens the detail pane for status
+func (m Model) openDetail(status services.MastodonStatus) (Model, tea.Cmd) {
+	m.detail = NewPostDetailModel(m.user.ID, m.mastodonSvc, status)
+	m.detail.width = m.width
+	m.detail.height = m.height
+	m = m.pushScreen(screenPostDetail)
+	return m, m.detail.Init()
+}
+
+// openReader shows status on its own in the reader
+func (m Model) openReader(status services.MastodonStatus) (Model, tea.Cmd) {
+	m.reader = NewReaderModel(m.user.ID, m.ctx.timelines(), status)
+	m.reader.width = m.width
+	m.reader.height = m.height
+	m = m.pushScreen(screenReader)
+	return m, m.reader.Init()
+}
+
+// postActionMsg reports the outcome of a post action run in the background
+type postActionMsg struct {
+	message string
+	err     error
+}
+
+// bookmarkStatusCmd bookmarks a status
+func bookmarkStatusCmd(actions StatusActions, actionLog *services.ActionLogService, userID int, status services.MastodonStatus) tea.Cmd {
+	return func() tea.Msg {
+		err := actions.BookmarkStatus(context.Background(), userID, status.ID)
+		if err == nil {
+			recordAction(actionLog, userID, models.ActionBookmark, status.ID, actionSummary(status))
+		}
+		return postActionMsg{message: "Post bookmarked!", err: err}
+	}
+}
+
+// muteAccountCmd mutes the author of a post
+func muteAccountCmd(actions StatusActions, actionLog *services.ActionLogService, userID int, accountID, acct string) tea.Cmd {
+	return func() tea.Msg {
+		err := actions.MuteAccount(context.Background(), userID, accountID)
+		if err == nil {
+			recordAction(actionLog, userID, models.ActionMute, accountID, "@"+acct)
+		}
+		return postActionMsg{message: fmt.Sprintf("Muted @%s", acct), err: err}
+	}
+}
+
+// pinnedMsg reports the outcome of pinning or unpinning a post
+type pinnedMsg struct {
+	statusID string
+	pinned   bool
+	err      error
+}
+
+// pinStatusCmd pins or unpins one of the user's posts: natively for posts
+// published on this server, through Mastodon for the rest
+func pinStatusCmd(ctx *AppContext, userID int, status services.MastodonStatus, pin bool) tea.Cmd {
+	return func() tea.Msg {
+		bg := context.Background()
+		if status.URI != "" && strings.HasPrefix(status.URI, ctx.Config.Server.BaseURL+"/") {
+			postID, err := services.NewTimelineService(ctx.DB, ctx.Config).PostID(bg, status.ID)
+			if err != nil {
+				return pinnedMsg{err: err}
+			}
+			posts := services.NewPostService(ctx.DB, ctx.Config)
+			if pin {
+				err = posts.Pin(bg, userID, postID)
+			} else {
+				err = posts.Unpin(bg, userID, postID)
+			}
+			return pinnedMsg{statusID: status.ID, pinned: pin, err: err}
+		}
+
+		actions := ctx.statusActions()
+		var err error
+		if pin {
+			err = actions.PinStatus(bg, userID, status.ID)
+		} else {
+			err = actions.UnpinStatus(bg, userID, status.ID)
+		}
+		return pinnedMsg{statusID: status.ID, pinned: pin, err: err}
+	}
+}
+
+// applyPinned records that a post in the feed was pinned or unpinned
+func (f *FeedModel) applyPinned(statusID string, pinned bool) {
+	for i := range f.statuses {
+		if f.statuses[i].ID == statusID {
+			f.statuses[i].Pinned = pinned
+		}
+		if reblog := f.statuses[i].Reblog; reblog != nil && reblog.ID == statusID {
+			reblog.Pinned = pinned
+		}
+	}
+}
+
+// copyToClipboardCmd asks the user's terminal to copy text using OSC 52
+func copyToClipboardCmd(w io.Writer, text string) tea.Cmd {
+	return func() tea.Msg {
+		if w != nil {
+			_, _ = io.WriteString(w, ansi.SetSystemClipboard(text))
+		}
+		return nil
+	}
+}
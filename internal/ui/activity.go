@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// defaultActivityPollInterval is used when the config does not set one
+const defaultActivityPollInterval = 60 * time.Second
+
+// activityTickMsg triggers a background check for new mentions and DMs
+type activityTickMsg time.Time
+
+// newActivityMsg is returned when the background mention check completes
+type newActivityMsg struct {
+	mentions []services.MastodonNotification
+	err      error
+}
+
+// activityPollInterval returns the configured interval between mention checks
+func (m Model) activityPollInterval() time.Duration {
+	if m.ctx == nil || m.ctx.Config == nil || m.ctx.Config.TUI.ActivityPollInterval <= 0 {
+		return defaultActivityPollInterval
+	}
+	return time.Duration(m.ctx.Config.TUI.ActivityPollInterval) * time.Second
+}
+
+// activityTickCmd schedules the next background mention check
+func activityTickCmd(interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(t time.Time) tea.Msg {
+		return activityTickMsg(t)
+	})
+}
+
+// checkNewActivityCmd fetches mentions newer than the last one we have seen
+func checkNewActivityCmd(notifier Notifier, userID int, sinceID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		limit := 40
+		if sinceID == "" {
+			// First check only establishes a baseline
+			limit = 1
+		}
+
+		mentions, err := notifier.GetMentionsSince(ctx, userID, sinceID, limit)
+		return newActivityMsg{mentions: mentions, err: err}
+	}
+}
+
+// handleNewActivity updates unread counters and signals the terminal
+func (m Model) handleNewActivity(msg newActivityMsg) (Model, tea.Cmd) {
+	next := activityTickCmd(m.activityPollInterval())
+	if msg.err != nil || len(msg.mentions) == 0 {
+		return m, next
+	}
+
+	baseline := m.lastMentionID == ""
+	m.lastMentionID = msg.mentions[0].ID
+	if baseline || m.screen == screenNotifications {
+		return m, next
+	}
+
+	for _, mention := range msg.mentions {
+		if mention.Status != nil && mention.Status.Visibility == "direct" {
+			m.unreadDirect++
+		} else {
+			m.unreadMentions++
+		}
+	}
+
+	return m, tea.Batch(next, m.signalActivityCmd(true))
+}
+
+// clearUnreadActivity resets the unread counters once notifications are viewed
+func (m Model) clearUnreadActivity() (Model, tea.Cmd) {
+	if m.unreadMentions == 0 && m.unreadDirect == 0 {
+		return m, nil
+	}
+	m.unreadMentions = 0
+	m.unreadDirect = 0
+	return m, m.signalActivityCmd(false)
+}
+
+// activityTitle builds the terminal title including the unread count
+func (m Model) activityTitle() string {
+	unread := m.unreadMentions + m.unreadDirect
+	if unread == 0 {
+		return "terminalpub"
+	}
+	if m.unreadDirect > 0 {
+		return fmt.Sprintf("(%d) terminalpub - %d DM", unread, m.unreadDirect)
+	}
+	return fmt.Sprintf("(%d) terminalpub", unread)
+}
+
+// signalActivityCmd rings the bell and updates the terminal title as configured
+func (m Model) signalActivityCmd(ring bool) tea.Cmd {
+	if m.sshSession == nil || m.ctx == nil || m.ctx.Config == nil {
+		return nil
+	}
+
+	var seq string
+	if ring && m.ctx.Config.TUI.Bell {
+		seq += "\a"
+	}
+	if m.ctx.Config.TUI.TitleUpdates {
+		seq += ansi.SetIconNameWindowTitle(m.activityTitle())
+	}
+	if seq == "" {
+		return nil
+	}
+
+	session := m.sshSession
+	return func() tea.Msg {
+		_, _ = session.Write([]byte(seq))
+		return nil
+	}
+}
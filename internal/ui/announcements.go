@@ -0,0 +1,319 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// AnnouncementsModel represents the screen listing the announcements of the
+// user's Mastodon instance
+type AnnouncementsModel struct {
+	userID          int
+	mastodonService *services.MastodonService
+	announcements   []services.MastodonAnnouncement
+	selectedIndex   int
+	loading         bool
+	statusMessage   string
+	width           int
+	height          int
+}
+
+// announcementsLoadedMsg is sent when announcements have been fetched
+type announcementsLoadedMsg struct {
+	announcements []services.MastodonAnnouncement
+	err           error
+}
+
+// announcementReadMsg is sent when an announcement has been marked read
+type announcementReadMsg struct {
+	id  string
+	err error
+}
+
+// announcementReactedMsg reports the outcome of reacting to an announcement
+type announcementReactedMsg struct {
+	id      string
+	emoji   string
+	removed bool
+	err     error
+}
+
+// unreadAnnouncementsMsg carries the number of unread announcements for the
+// main menu
+type unreadAnnouncementsMsg struct {
+	count int
+}
+
+// NewAnnouncementsModel creates a new announcements model
+func NewAnnouncementsModel(userID int, mastodonService *services.MastodonService) AnnouncementsModel {
+	return AnnouncementsModel{
+		userID:          userID,
+		mastodonService: mastodonService,
+		loading:         true,
+		statusMessage:   "Loading announcements...",
+	}
+}
+
+// Init fetches the announcements
+func (m AnnouncementsModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// Unread returns how many announcements have not been read
+func (m AnnouncementsModel) Unread() int {
+	return countUnreadAnnouncements(m.announcements)
+}
+
+// countUnreadAnnouncements counts the announcements not yet marked read
+func countUnreadAnnouncements(announcements []services.MastodonAnnouncement) int {
+	unread := 0
+	for _, announcement := range announcements {
+		if !announcement.Read {
+			unread++
+		}
+	}
+	return unread
+}
+
+// Update handles messages for the announcements screen
+func (m AnnouncementsModel) Update(msg tea.Msg) (AnnouncementsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case announcementsLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.announcements = msg.announcements
+		m.selectedIndex = 0
+		// Start at the first unread announcement
+		for i, announcement := range m.announcements {
+			if !announcement.Read {
+				m.selectedIndex = i
+				break
+			}
+		}
+		m.statusMessage = ""
+		return m, nil
+
+	case announcementReadMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		for i := range m.announcements {
+			if m.announcements[i].ID == msg.id {
+				m.announcements[i].Read = true
+			}
+		}
+		m.statusMessage = "Marked as read"
+		return m, nil
+
+	case announcementReactedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		for i := range m.announcements {
+			if m.announcements[i].ID == msg.id {
+				m.announcements[i].Reactions = toggleReaction(m.announcements[i].Reactions, msg.emoji, msg.removed)
+			}
+		}
+		m.statusMessage = "Reacted with " + msg.emoji
+		if msg.removed {
+			m.statusMessage = "Removed " + msg.emoji
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+
+		switch key := msg.String(); key {
+		case "up", "k":
+			if m.selectedIndex > 0 {
+				m.selectedIndex--
+			}
+		case "down", "j":
+			if m.selectedIndex < len(m.announcements)-1 {
+				m.selectedIndex++
+			}
+		case "r", "R", "enter":
+			if announcement := m.selected(); announcement != nil && !announcement.Read {
+				return m, m.readCmd(announcement.ID)
+			}
+		case "ctrl+r":
+			m.loading = true
+			return m, m.fetchCmd()
+		default:
+			// Number keys toggle the matching emoji of the reaction picker
+			n, err := strconv.Atoi(key)
+			announcement := m.selected()
+			if err != nil || n < 1 || n > len(reactionEmoji) || announcement == nil {
+				return m, nil
+			}
+			emoji := reactionEmoji[n-1]
+			remove := false
+			for _, reaction := range announcement.Reactions {
+				if reaction.Name == emoji && reaction.Me {
+					remove = true
+				}
+			}
+			return m, m.reactCmd(announcement.ID, emoji, remove)
+		}
+	}
+
+	return m, nil
+}
+
+// selected returns the highlighted announcement, if any
+func (m AnnouncementsModel) selected() *services.MastodonAnnouncement {
+	if m.selectedIndex < len(m.announcements) {
+		return &m.announcements[m.selectedIndex]
+	}
+	return nil
+}
+
+// fetchCmd loads the instance's announcements
+func (m AnnouncementsModel) fetchCmd() tea.Cmd {
+	svc, userID := m.mastodonService, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		announcements, err := svc.GetAnnouncements(ctx, userID)
+		return announcementsLoadedMsg{announcements: announcements, err: err}
+	}
+}
+
+// readCmd marks an announcement as read
+func (m AnnouncementsModel) readCmd(id string) tea.Cmd {
+	svc, userID := m.mastodonService, m.userID
+	return func() tea.Msg {
+		err := svc.DismissAnnouncement(context.Background(), userID, id)
+		return announcementReadMsg{id: id, err: err}
+	}
+}
+
+// reactCmd adds or removes the user's reaction to an announcement
+func (m AnnouncementsModel) reactCmd(id, emoji string, remove bool) tea.Cmd {
+	svc, userID := m.mastodonService, m.userID
+	return func() tea.Msg {
+		err := svc.ReactToAnnouncement(context.Background(), userID, id, emoji, remove)
+		return announcementReactedMsg{id: id, emoji: emoji, removed: remove, err: err}
+	}
+}
+
+// checkAnnouncementsCmd counts unread announcements in the background;
+// failures leave the main menu without a count
+func checkAnnouncementsCmd(svc *services.MastodonService, userID int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		announcements, err := svc.GetAnnouncements(ctx, userID)
+		if err != nil {
+			return nil
+		}
+		return unreadAnnouncementsMsg{count: countUnreadAnnouncements(announcements)}
+	}
+}
+
+// View renders the announcements screen
+func (m AnnouncementsModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Instance announcements") + "\n")
+	b.WriteString(subtleStyle.Render(fmt.Sprintf("%d unread", m.Unread())) + "\n\n")
+
+	if m.loading {
+		b.WriteString(subtleStyle.Render(m.statusMessage) + "\n")
+		return b.String()
+	}
+
+	if len(m.announcements) == 0 {
+		b.WriteString("Your instance has no announcements.\n\n")
+	}
+
+	// Announcements are shown whole, from the selected one down
+	width := max(min(m.width, 100)-6, 20)
+	budget := max(m.height-12, 5)
+	for i := m.selectedIndex; i < len(m.announcements) && budget > 0; i++ {
+		lines := m.renderAnnouncement(i, width)
+		if i > m.selectedIndex && len(lines) > budget {
+			break
+		}
+		b.WriteString(strings.Join(lines, "\n") + "\n\n")
+		budget -= len(lines) + 1
+	}
+
+	picker := make([]string, len(reactionEmoji))
+	for i, emoji := range reactionEmoji {
+		picker[i] = fmt.Sprintf("%d %s", i+1, emoji)
+	}
+	b.WriteString(subtleStyle.Render("React: "+strings.Join(picker, " ")) + "\n")
+	b.WriteString(keyStyle.Render("[R]") + " Mark read  " +
+		keyStyle.Render("[↑/↓]") + " Browse  " +
+		keyStyle.Render("[Ctrl+R]") + " Refresh  " +
+		keyStyle.Render("[Esc]") + " Back\n")
+
+	if m.statusMessage != "" {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
+
+// renderAnnouncement renders one announcement with its dates and reactions
+func (m AnnouncementsModel) renderAnnouncement(i, width int) []string {
+	announcement := m.announcements[i]
+
+	selector := "  "
+	if i == m.selectedIndex {
+		selector = promptStyle.Render("► ")
+	}
+	header := subtleStyle.Render(formatAge(time.Since(announcement.PublishedAt)))
+	if !announcement.Read {
+		header = successStyle.Render("NEW") + " " + header
+	}
+	if when := announcementPeriod(announcement); when != "" {
+		header += " " + subtleStyle.Render("· "+when)
+	}
+
+	lines := []string{selector + header}
+	content := lipgloss.NewStyle().Width(width).Render(stripHTML(announcement.Content))
+	for _, line := range strings.Split(content, "\n") {
+		lines = append(lines, "    "+line)
+	}
+	if reactions := renderReactions(announcement.Reactions); reactions != "" {
+		lines = append(lines, "    "+reactions)
+	}
+	return lines
+}
+
+// announcementPeriod describes when an announced event takes place
+func announcementPeriod(announcement services.MastodonAnnouncement) string {
+	layout := "2 Jan 15:04"
+	if announcement.AllDay {
+		layout = "2 Jan"
+	}
+	switch {
+	case announcement.StartsAt != nil && announcement.EndsAt != nil:
+		return announcement.StartsAt.Format(layout) + " – " + announcement.EndsAt.Format(layout)
+	case announcement.StartsAt != nil:
+		return "from " + announcement.StartsAt.Format(layout)
+	case announcement.EndsAt != nil:
+		return "until " + announcement.EndsAt.Format(layout)
+	}
+	return ""
+}
@@ -0,0 +1,248 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/models"
+)
+
+// APITokensModel represents the personal API token management view
+type APITokensModel struct {
+	ctx           context.Context
+	userID        int
+	tokenService  *auth.APITokenService
+	domain        string
+	tokens        []models.APIToken
+	selectedIndex int
+	creating      bool   // Whether the name prompt is active
+	nameInput     string // Name for the token being created
+	write         bool   // Token being created may post, not only read
+	newToken      string // Plaintext of the last created token, shown once
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// apiTokensLoadedMsg is sent when the token list is fetched
+type apiTokensLoadedMsg struct {
+	tokens []models.APIToken
+	err    error
+}
+
+// apiTokenCreatedMsg is sent when a new token has been issued
+type apiTokenCreatedMsg struct {
+	plaintext string
+	err       error
+}
+
+// apiTokenRevokedMsg is sent when a token has been revoked
+type apiTokenRevokedMsg struct {
+	tokenID int
+	err     error
+}
+
+// NewAPITokensModel creates a new API token management model
+func NewAPITokensModel(ctx context.Context, userID int, tokenService *auth.APITokenService, domain string) APITokensModel {
+	return APITokensModel{
+		ctx:           ctx,
+		userID:        userID,
+		tokenService:  tokenService,
+		domain:        domain,
+		loading:       true,
+		statusMessage: "Loading tokens...",
+	}
+}
+
+// Init fetches the user's tokens
+func (m APITokensModel) Init() tea.Cmd {
+	return m.fetchTokensCmd()
+}
+
+// Update handles messages for the API token view
+func (m APITokensModel) Update(msg tea.Msg) (APITokensModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case apiTokensLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.tokens = msg.tokens
+		if m.selectedIndex >= len(m.tokens) {
+			m.selectedIndex = 0
+		}
+		if m.newToken == "" {
+			m.statusMessage = ""
+		}
+		return m, nil
+
+	case apiTokenCreatedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.newToken = msg.plaintext
+		m.statusMessage = "Token created. Copy it now, it will not be shown again."
+		return m, m.fetchTokensCmd()
+
+	case apiTokenRevokedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.statusMessage = "Token revoked"
+		return m, m.fetchTokensCmd()
+
+	case tea.KeyMsg:
+		if m.creating {
+			return m.handleNameInput(msg)
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.selectedIndex > 0 {
+				m.selectedIndex--
+			}
+		case "down", "j":
+			if m.selectedIndex < len(m.tokens)-1 {
+				m.selectedIndex++
+			}
+		case "c", "C":
+			m.creating = true
+			m.nameInput = ""
+			m.write = false
+			m.newToken = ""
+			m.statusMessage = ""
+		case "d", "D":
+			if m.selectedIndex < len(m.tokens) {
+				return m, m.revokeTokenCmd(m.tokens[m.selectedIndex].ID)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// handleNameInput handles keys while the token name prompt is active
+func (m APITokensModel) handleNameInput(msg tea.KeyMsg) (APITokensModel, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		name := strings.TrimSpace(m.nameInput)
+		if name == "" {
+			return m, nil
+		}
+		m.creating = false
+		scopes := "read"
+		if m.write {
+			scopes = "read write"
+		}
+		return m, m.createTokenCmd(name, scopes)
+	case "tab":
+		m.write = !m.write
+	case "esc":
+		m.creating = false
+		m.nameInput = ""
+	case "backspace":
+		if len(m.nameInput) > 0 {
+			m.nameInput = m.nameInput[:len(m.nameInput)-1]
+		}
+	default:
+		if len(msg.String()) == 1 && len(m.nameInput) < 100 {
+			m.nameInput += msg.String()
+		}
+	}
+	return m, nil
+}
+
+// View renders the API token view
+func (m APITokensModel) View() string {
+	var b strings.Builder
+	width := 70
+
+	b.WriteString(centerText(titleStyle.Render("API Tokens"), width) + "\n\n")
+	b.WriteString(subtleStyle.Render("Personal tokens for scripts and status bars (tmux, screen).") + "\n\n")
+
+	if m.loading {
+		b.WriteString(m.statusMessage + "\n")
+		return b.String()
+	}
+
+	if len(m.tokens) == 0 {
+		b.WriteString("No tokens yet\n")
+	}
+	for i, token := range m.tokens {
+		selector := "  "
+		if i == m.selectedIndex {
+			selector = promptStyle.Render("► ")
+		}
+		lastUsed := "never used"
+		if token.LastUsedAt != nil {
+			lastUsed = "used " + formatTimeAgo(*token.LastUsedAt)
+		}
+		b.WriteString(fmt.Sprintf("%s%-20s %s…  %-10s %s\n",
+			selector, truncate(token.Name, 20), token.TokenPrefix, token.Scopes, subtleStyle.Render(lastUsed)))
+	}
+	b.WriteString("\n")
+
+	if m.creating {
+		b.WriteString("Token name:\n")
+		b.WriteString(promptStyle.Render("> "+m.nameInput+"█") + "\n\n")
+		access := "[ ] Can post (read-only otherwise)"
+		if m.write {
+			access = "[x] Can post (read-only otherwise)"
+		}
+		b.WriteString(access + "\n\n")
+		b.WriteString(keyStyle.Render("[Enter]") + " Create  " + keyStyle.Render("[Tab]") + " Toggle posting  " + keyStyle.Render("[Esc]") + " Cancel\n")
+		return b.String()
+	}
+
+	if m.newToken != "" {
+		b.WriteString(successStyle.Render(m.newToken) + "\n\n")
+		b.WriteString(subtleStyle.Render("tmux: set -g status-right '#(curl -s -H \"Authorization: Bearer TOKEN\" \\") + "\n")
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("        https://%s/api/terminalpub/v1/status)'", m.domain)) + "\n")
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("   or: ssh %s status", m.domain)) + "\n")
+		b.WriteString(subtleStyle.Render("post: echo hello | curl -H \"Authorization: Bearer TOKEN\" -H 'Content-Type: text/plain' \\") + "\n")
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("        --data-binary @- https://%s/api/terminalpub/v1/post", m.domain)) + "\n\n")
+	}
+
+	b.WriteString(keyStyle.Render("[C]") + " Create  " + keyStyle.Render("[D]") + " Revoke  " + keyStyle.Render("[Esc]") + " Back\n")
+
+	if m.statusMessage != "" {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
+
+// fetchTokensCmd fetches the user's tokens
+func (m APITokensModel) fetchTokensCmd() tea.Cmd {
+	return func() tea.Msg {
+		tokens, err := m.tokenService.ListUserTokens(m.ctx, m.userID)
+		return apiTokensLoadedMsg{tokens: tokens, err: err}
+	}
+}
+
+// createTokenCmd issues a new token with the given scopes
+func (m APITokensModel) createTokenCmd(name, scopes string) tea.Cmd {
+	return func() tea.Msg {
+		_, plaintext, err := m.tokenService.CreateToken(m.ctx, m.userID, name, scopes)
+		return apiTokenCreatedMsg{plaintext: plaintext, err: err}
+	}
+}
+
+// revokeTokenCmd revokes a token
+func (m APITokensModel) revokeTokenCmd(tokenID int) tea.Cmd {
+	return func() tea.Msg {
+		err := m.tokenService.RevokeToken(m.ctx, m.userID, tokenID)
+		return apiTokenRevokedMsg{tokenID: tokenID, err: err}
+	}
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/ssh"
+)
+
+// asciiEnv is the SSH environment variable that forces ASCII-only output
+const asciiEnv = "TERMINALPUB_ASCII"
+
+// asciiTerms are terminal types that predate UTF-8
+var asciiTerms = []string{"dumb", "vt52", "vt100", "vt102", "vt220", "vt320"}
+
+// asciiSymbols replaces the symbols the UI draws with; box drawing not
+// listed becomes a corner
+var asciiSymbols = map[rune]string{
+	'─': "-", '━': "-", '═': "-", '┄': "-", '┅': "-", '┈': "-", '┉': "-", '╌': "-", '╍': "-",
+	'│': "|", '┃': "|", '║': "|", '┆': "|", '┇': "|", '┊': "|", '┋': "|", '╎': "|", '╏': "|",
+	'►': ">", '▶': ">", '▸': ">", '→': "->", '◄': "<", '◀': "<", '←': "<-", '›': ">", '×': "x",
+	'↑': "^", '↓': "v", '⏎': "Enter", '↻': "RT", '•': "*", '●': "*", '·': "*", '…': "...", '✓': "v", '✔': "v", '✗': "x", '✘': "x",
+	'“': "\"", '”': "\"", '‘': "'", '’': "'", '–': "-", '—': "--", '\u00a0': " ",
+}
+
+// startASCII reports whether a session should be drawn with ASCII only:
+// when configured for everyone, when the client asks for it, when its
+// locale is not UTF-8, or when its terminal type predates UTF-8
+func startASCII(ctx *AppContext, s ssh.Session) bool {
+	if ctx != nil && ctx.Config != nil && ctx.Config.TUI.ASCII {
+		return true
+	}
+	if s == nil {
+		return false
+	}
+
+	env := map[string]string{}
+	for _, entry := range s.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		env[name] = value
+	}
+	if value := env[asciiEnv]; value != "" && value != "0" {
+		return true
+	}
+
+	// The first locale variable set decides, as with setlocale(3)
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := env[name]; locale != "" {
+			return !isUTF8Locale(locale)
+		}
+	}
+
+	if pty, _, ok := s.Pty(); ok {
+		return slices.Contains(asciiTerms, pty.Term)
+	}
+	return false
+}
+
+// isUTF8Locale reports whether a locale name selects UTF-8, e.g. en_US.UTF-8
+// or C.utf8
+func isUTF8Locale(locale string) bool {
+	normalized := strings.ToLower(strings.ReplaceAll(locale, "-", ""))
+	return strings.Contains(normalized, "utf8")
+}
+
+// asciiOnly rewrites rendered output for terminals without UTF-8: borders,
+// bullets and indicators become their ASCII look-alikes, combining marks
+// and other invisible characters are dropped, and any other character a
+// legacy terminal cannot show is replaced with a question mark
+func asciiOnly(content string) string {
+	var b strings.Builder
+	b.Grow(len(content))
+	for _, r := range content {
+		switch {
+		case r <= unicode.MaxASCII:
+			b.WriteRune(r)
+		case asciiSymbols[r] != "":
+			b.WriteString(asciiSymbols[r])
+		case r >= 0x2500 && r <= 0x257F: // Box Drawing
+			b.WriteByte('+')
+		case r >= 0x2580 && r <= 0x259F: // Block Elements
+			b.WriteByte('#')
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+			// Variation selectors, joiners and accents on letters
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,233 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/auth"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// auditLimit is how many recent events the audit log screen shows
+const auditLimit = 100
+
+// auditLabels describes each event type in the audit log screen
+var auditLabels = map[models.AuditEventType]string{
+	models.AuditLogin:            "Login",
+	models.AuditSSHKeyAdded:      "SSH key added",
+	models.AuditSSHKeyRemoved:    "SSH key removed",
+	models.AuditTokenGranted:     "Token granted",
+	models.AuditTokenRevoked:     "Token revoked",
+	models.AuditAdminAction:      "Admin action",
+	models.AuditDeviceCodeFailed: "Bad device code",
+}
+
+// AuditModel lists security-sensitive events: the user's own, or for
+// admins those of every user
+type AuditModel struct {
+	audit         *services.AuditService
+	userID        int
+	isAdmin       bool // Whether the user may switch to every user's events
+	all           bool // Showing every user's events
+	events        []models.AuditEvent
+	selectedIndex int
+	loading       bool
+	statusMessage string
+	domain        string
+	width         int
+	height        int
+}
+
+// auditLoadedMsg is sent when the audit log has been fetched
+type auditLoadedMsg struct {
+	events  []models.AuditEvent
+	isAdmin bool
+	err     error
+}
+
+// NewAuditModel creates a new audit log model
+func NewAuditModel(audit *services.AuditService, userID int, domain string) AuditModel {
+	return AuditModel{
+		audit:         audit,
+		userID:        userID,
+		domain:        domain,
+		loading:       true,
+		statusMessage: "Loading audit log...",
+	}
+}
+
+// Init fetches the audit log
+func (m AuditModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// Update handles messages for the audit log screen
+func (m AuditModel) Update(msg tea.Msg) (AuditModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case auditLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.events = msg.events
+		m.isAdmin = msg.isAdmin
+		if m.selectedIndex >= len(m.events) {
+			m.selectedIndex = 0
+		}
+		m.statusMessage = ""
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.selectedIndex > 0 {
+				m.selectedIndex--
+			}
+		case "down", "j":
+			if m.selectedIndex < len(m.events)-1 {
+				m.selectedIndex++
+			}
+		case "tab":
+			if m.isAdmin {
+				m.all = !m.all
+				m.selectedIndex = 0
+				m.loading = true
+				return m, m.fetchCmd()
+			}
+		case "ctrl+r":
+			m.loading = true
+			return m, m.fetchCmd()
+		}
+	}
+
+	return m, nil
+}
+
+// fetchCmd loads the most recent events
+func (m AuditModel) fetchCmd() tea.Cmd {
+	audit, userID, all := m.audit, m.userID, m.all
+	return func() tea.Msg {
+		ctx := context.Background()
+		isAdmin, err := audit.CanViewAll(ctx, userID)
+		if err != nil {
+			return auditLoadedMsg{err: err}
+		}
+		events, err := audit.List(ctx, userID, all, auditLimit)
+		return auditLoadedMsg{events: events, isAdmin: isAdmin, err: err}
+	}
+}
+
+// View renders the audit log screen
+func (m AuditModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Audit Log") + "\n")
+	if m.all {
+		b.WriteString(subtleStyle.Render("Logins, key and token changes and admin actions of every user") + "\n\n")
+	} else {
+		b.WriteString(subtleStyle.Render("Logins, key and token changes and admin actions on your account") + "\n\n")
+	}
+
+	if m.loading {
+		b.WriteString(subtleStyle.Render(m.statusMessage) + "\n")
+		return b.String()
+	}
+
+	if len(m.events) == 0 {
+		b.WriteString("No events yet.\n\n")
+	}
+
+	visible := max(m.height-12, 5)
+	start := 0
+	if m.selectedIndex >= visible {
+		start = m.selectedIndex - visible + 1
+	}
+	end := min(start+visible, len(m.events))
+
+	for i := start; i < end; i++ {
+		event := m.events[i]
+
+		selector := "  "
+		if i == m.selectedIndex {
+			selector = promptStyle.Render("► ")
+		}
+
+		label := auditLabels[event.Event]
+		if label == "" {
+			label = string(event.Event)
+		}
+		detail := event.Detail
+		if m.all {
+			who := "-"
+			if event.Username != "" {
+				who = "@" + event.Username
+			}
+			detail = who + " " + detail
+		}
+		line := fmt.Sprintf("%s%-16s %s", selector, label, truncate(detail, 40))
+		meta := formatAge(time.Since(event.CreatedAt))
+		if event.IPAddress != "" {
+			meta += " from " + event.IPAddress
+		}
+		b.WriteString(line + " " + subtleStyle.Render(meta) + "\n")
+	}
+
+	if m.selectedIndex < len(m.events) {
+		event := m.events[m.selectedIndex]
+		b.WriteString("\n" + subtleStyle.Render(event.CreatedAt.Format("2006-01-02 15:04:05")+"  "+event.Detail) + "\n")
+	}
+
+	export := "ssh " + m.domain + " audit > audit.json"
+	if m.all {
+		export = "ssh " + m.domain + " audit --all > audit.json"
+	}
+	b.WriteString("\n" + subtleStyle.Render("Export as JSON: "+export) + "\n\n")
+
+	help := keyStyle.Render("[Ctrl+R]") + " Refresh  "
+	if m.isAdmin {
+		if m.all {
+			help = keyStyle.Render("[Tab]") + " Your events  " + help
+		} else {
+			help = keyStyle.Render("[Tab]") + " All users  " + help
+		}
+	}
+	b.WriteString(help + keyStyle.Render("[Esc]") + " Back\n")
+
+	if m.statusMessage != "" {
+		b.WriteString("\n" + errorStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
+
+// recordLoginCmd adds a login to the audit log; method is empty when the
+// user was already logged in
+func (m Model) recordLoginCmd(method string) tea.Cmd {
+	if method == "" || m.user == nil || m.ctx == nil || m.ctx.DB == nil {
+		return nil
+	}
+	db, userID := m.ctx.DB, m.user.ID
+	ctx := auth.WithClientIP(context.Background(), sessionIP(m.sshSession))
+	return func() tea.Msg {
+		auth.NewAuditLog(db).Record(ctx, userID, models.AuditLogin, method)
+		return nil
+	}
+}
+
+// openAudit switches to the audit log screen
+func (m Model) openAudit() (Model, tea.Cmd) {
+	m.audit = NewAuditModel(services.NewAuditService(m.ctx.DB), m.user.ID, m.ctx.Config.Server.Domain)
+	m.audit.width = m.width
+	m.audit.height = m.height
+	m = m.pushScreen(screenAudit)
+	return m, m.audit.Init()
+}
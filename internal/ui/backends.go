@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"context"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// TimelineFetcher reads the timelines, conversations and profiles the TUI
+// shows
+type TimelineFetcher interface {
+	GetTimeline(ctx context.Context, userID int, timelineType services.TimelineType, limit int, maxID string) ([]services.MastodonStatus, error)
+	GetStatusContext(ctx context.Context, userID int, statusID string) (*services.StatusContext, error)
+	GetAccount(ctx context.Context, userID int, accountID string) (*services.MastodonAccount, error)
+	GetAccountStatuses(ctx context.Context, userID int, accountID string, limit int) ([]services.MastodonStatus, error)
+	GetPinnedStatuses(ctx context.Context, userID int, accountID string) ([]services.MastodonStatus, error)
+	GetAccountRelationship(ctx context.Context, userID int, accountID string) (*services.AccountRelationship, error)
+}
+
+// StatusActions posts and acts on statuses and accounts for the user
+type StatusActions interface {
+	PostStatus(ctx context.Context, userID int, content, visibility, inReplyToID, contentWarning string) (string, error)
+	FavouriteStatus(ctx context.Context, userID int, statusID string) error
+	UnfavouriteStatus(ctx context.Context, userID int, statusID string) error
+	BoostStatus(ctx context.Context, userID int, statusID, visibility string) error
+	UnreblogStatus(ctx context.Context, userID int, statusID string) error
+	BookmarkStatus(ctx context.Context, userID int, statusID string) error
+	PinStatus(ctx context.Context, userID int, statusID string) error
+	UnpinStatus(ctx context.Context, userID int, statusID string) error
+	FollowAccount(ctx context.Context, userID int, accountID string) error
+	UnfollowAccount(ctx context.Context, userID int, accountID string) error
+	MuteAccount(ctx context.Context, userID int, accountID string) error
+}
+
+// Notifier reads and dismisses the user's notifications
+type Notifier interface {
+	GetNotifications(ctx context.Context, userID int, limit int, maxID string) ([]services.MastodonNotification, error)
+	GetMentionsSince(ctx context.Context, userID int, sinceID string, limit int) ([]services.MastodonNotification, error)
+	DismissNotification(ctx context.Context, userID int, notificationID string) error
+	ClearAllNotifications(ctx context.Context, userID int) error
+}
+
+// MastodonService is what the TUI uses unless AppContext injects another
+var (
+	_ TimelineFetcher = (*services.MastodonService)(nil)
+	_ StatusActions   = (*services.MastodonService)(nil)
+	_ Notifier        = (*services.MastodonService)(nil)
+)
+
+// timelines returns the injected TimelineFetcher, or the Mastodon API
+func (c *AppContext) timelines() TimelineFetcher {
+	if c.Timelines != nil {
+		return c.Timelines
+	}
+	return services.NewMastodonService(c.DB, c.Config)
+}
+
+// statusActions returns the injected StatusActions, or the Mastodon API
+func (c *AppContext) statusActions() StatusActions {
+	if c.Actions != nil {
+		return c.Actions
+	}
+	return services.NewMastodonService(c.DB, c.Config)
+}
+
+// notifier returns the injected Notifier, or the Mastodon API
+func (c *AppContext) notifier() Notifier {
+	if c.Notifications != nil {
+		return c.Notifications
+	}
+	return services.NewMastodonService(c.DB, c.Config)
+}
+
+// actionLog returns the log of the user's own actions, or nil without a
+// database to keep it in
+func (c *AppContext) actionLog(mastodonSvc *services.MastodonService) *services.ActionLogService {
+	if c.DB == nil {
+		return nil
+	}
+	return services.NewActionLogService(c.DB, mastodonSvc)
+}
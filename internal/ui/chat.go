@@ -0,0 +1,395 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// chatHistory is how many earlier messages are shown when joining a room
+const chatHistory = 100
+
+// chatHelp lists the commands typed into the chat input
+const chatHelp = "/join <room>  /rooms  /ids  — admins: /topic <text>  /delete <id>  /clear  /ban <user> [minutes]  /unban <user>"
+
+// ChatModel represents the screen of an instance chat room
+type ChatModel struct {
+	chat          *services.ChatService
+	user          *models.User
+	session       context.Context // Ends the room subscription on disconnect
+	room          models.ChatRoom
+	messages      []models.ChatMessage
+	events        <-chan models.ChatEvent
+	cancel        context.CancelFunc // Leaves the room
+	input         textinput.Model
+	scroll        int  // Lines scrolled up from the newest message
+	showIDs       bool // Show message numbers, for /delete
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// chatJoinedMsg is sent when a room has been joined
+type chatJoinedMsg struct {
+	room     *models.ChatRoom
+	messages []models.ChatMessage
+	events   <-chan models.ChatEvent
+	cancel   context.CancelFunc
+	err      error
+}
+
+// chatEventMsg carries an event of the room from the subscription events
+type chatEventMsg struct {
+	event  models.ChatEvent
+	events <-chan models.ChatEvent
+}
+
+// chatResultMsg reports the outcome of sending a message or a command
+type chatResultMsg struct {
+	status string
+	err    error
+}
+
+// NewChatModel creates a chat model for user; session bounds the life of
+// room subscriptions
+func NewChatModel(session context.Context, chat *services.ChatService, user *models.User) ChatModel {
+	input := textinput.New()
+	input.Placeholder = "Say something, or /help"
+	input.CharLimit = services.MaxChatMessageLength
+	input.Focus()
+
+	return ChatModel{
+		chat:    chat,
+		user:    user,
+		session: session,
+		input:   input,
+		loading: true,
+	}
+}
+
+// Open joins a room, leaving the current one
+func (m ChatModel) Open(room string) (ChatModel, tea.Cmd) {
+	m = m.Leave()
+	m.loading = true
+	m.statusMessage = "Joining #" + strings.TrimPrefix(room, "#") + "..."
+	return m, tea.Batch(textinput.Blink, m.joinCmd(room))
+}
+
+// Leave stops listening to the current room
+func (m ChatModel) Leave() ChatModel {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.cancel = nil
+	m.events = nil
+	return m
+}
+
+// Update handles messages for the chat screen
+func (m ChatModel) Update(msg tea.Msg) (ChatModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case chatJoinedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m = m.Leave()
+		m.room = *msg.room
+		m.messages = msg.messages
+		m.events = msg.events
+		m.cancel = msg.cancel
+		m.scroll = 0
+		m.statusMessage = ""
+		return m, waitForChatEventCmd(m.events)
+
+	case chatEventMsg:
+		if msg.events != m.events {
+			// From a room already left
+			return m, nil
+		}
+		m = m.applyEvent(msg.event)
+		return m, waitForChatEventCmd(m.events)
+
+	case chatResultMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+		} else {
+			m.statusMessage = msg.status
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			text := strings.TrimSpace(m.input.Value())
+			if text == "" || m.loading {
+				return m, nil
+			}
+			m.input.SetValue("")
+			m.scroll = 0
+			if strings.HasPrefix(text, "/") {
+				return m.runCommand(text)
+			}
+			return m, m.sendCmd(text)
+		case "pgup":
+			m.scroll += max(m.messageLines()/2, 1)
+			return m, nil
+		case "pgdown":
+			m.scroll = max(m.scroll-max(m.messageLines()/2, 1), 0)
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// applyEvent updates the room with an event from another session
+func (m ChatModel) applyEvent(event models.ChatEvent) ChatModel {
+	switch event.Type {
+	case "message":
+		if event.Message == nil {
+			break
+		}
+		m.messages = append(m.messages, *event.Message)
+		if len(m.messages) > chatHistory*2 {
+			m.messages = m.messages[len(m.messages)-chatHistory:]
+		}
+		if m.scroll > 0 {
+			// Keep the lines being read in place
+			m.scroll++
+		}
+	case "delete":
+		for i, message := range m.messages {
+			if message.ID == event.MessageID {
+				m.messages = append(m.messages[:i:i], m.messages[i+1:]...)
+				break
+			}
+		}
+	case "clear":
+		m.messages = nil
+		m.scroll = 0
+	case "topic":
+		m.room.Topic = event.Topic
+	}
+	return m
+}
+
+// runCommand runs a slash command typed into the chat input
+func (m ChatModel) runCommand(text string) (ChatModel, tea.Cmd) {
+	fields := strings.Fields(text)
+	name, args := strings.ToLower(fields[0]), fields[1:]
+	chat, userID, room := m.chat, m.user.ID, m.room.Name
+	m.statusMessage = ""
+
+	run := func(status string, fn func(ctx context.Context) error) tea.Cmd {
+		return func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return chatResultMsg{status: status, err: fn(ctx)}
+		}
+	}
+
+	switch {
+	case name == "/join" && len(args) == 1:
+		return m.Open(args[0])
+	case name == "/rooms":
+		return m, m.roomsCmd()
+	case name == "/ids":
+		m.showIDs = !m.showIDs
+		return m, nil
+	case name == "/topic":
+		topic := strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+		return m, run("Topic changed", func(ctx context.Context) error {
+			return chat.SetTopic(ctx, userID, room, topic)
+		})
+	case name == "/delete" && len(args) == 1:
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+		if err != nil {
+			m.statusMessage = "Error: /delete takes a message number; /ids shows them"
+			return m, nil
+		}
+		return m, run("Message deleted", func(ctx context.Context) error {
+			return chat.DeleteMessage(ctx, userID, room, id)
+		})
+	case name == "/clear":
+		return m, run("Room cleared", func(ctx context.Context) error {
+			return chat.Clear(ctx, userID, room)
+		})
+	case name == "/ban" && (len(args) == 1 || len(args) == 2):
+		var duration time.Duration
+		if len(args) == 2 {
+			minutes, err := strconv.Atoi(args[1])
+			if err != nil || minutes <= 0 {
+				m.statusMessage = "Error: usage: /ban <user> [minutes]"
+				return m, nil
+			}
+			duration = time.Duration(minutes) * time.Minute
+		}
+		return m, run("Banned "+args[0]+" from #"+room, func(ctx context.Context) error {
+			return chat.Ban(ctx, userID, room, args[0], duration)
+		})
+	case name == "/unban" && len(args) == 1:
+		return m, run("Unbanned "+args[0], func(ctx context.Context) error {
+			return chat.Unban(ctx, userID, room, args[0])
+		})
+	}
+
+	m.statusMessage = chatHelp
+	return m, nil
+}
+
+// joinCmd loads a room's history and subscribes to its events
+func (m ChatModel) joinCmd(name string) tea.Cmd {
+	chat, session := m.chat, m.session
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		room, err := chat.Room(ctx, name)
+		if err != nil {
+			return chatJoinedMsg{err: err}
+		}
+		messages, err := chat.History(ctx, room.ID, chatHistory)
+		if err != nil {
+			return chatJoinedMsg{err: err}
+		}
+
+		listenCtx, stop := context.WithCancel(session)
+		events, err := chat.Subscribe(listenCtx, room.Name)
+		if err != nil {
+			stop()
+			return chatJoinedMsg{err: err}
+		}
+		return chatJoinedMsg{room: room, messages: messages, events: events, cancel: stop}
+	}
+}
+
+// sendCmd says text in the room; it shows up through the subscription
+func (m ChatModel) sendCmd(text string) tea.Cmd {
+	chat, user, room := m.chat, m.user, m.room.Name
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err := chat.Send(ctx, user, room, text)
+		return chatResultMsg{err: err}
+	}
+}
+
+// roomsCmd lists the rooms in the status line
+func (m ChatModel) roomsCmd() tea.Cmd {
+	chat := m.chat
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		rooms, err := chat.Rooms(ctx)
+		if err != nil {
+			return chatResultMsg{err: err}
+		}
+		names := make([]string, len(rooms))
+		for i, room := range rooms {
+			names[i] = "#" + room.Name
+		}
+		return chatResultMsg{status: "Rooms: " + strings.Join(names, " ")}
+	}
+}
+
+// waitForChatEventCmd waits for the next event of the room
+func waitForChatEventCmd(events <-chan models.ChatEvent) tea.Cmd {
+	if events == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		event, ok := <-events
+		if !ok {
+			return nil
+		}
+		return chatEventMsg{event: event, events: events}
+	}
+}
+
+// messageLines is how many lines of messages fit on screen
+func (m ChatModel) messageLines() int {
+	return max(m.height-8, 3)
+}
+
+// View renders the chat room
+func (m ChatModel) View() string {
+	var b strings.Builder
+	width := max(min(m.width, 120)-4, 20)
+
+	title := "#" + m.room.Name
+	if m.room.Name == "" {
+		title = "Chat"
+	}
+	b.WriteString(titleStyle.Render(title))
+	if m.room.Topic != "" {
+		b.WriteString(" " + subtleStyle.Render("— "+m.room.Topic))
+	}
+	b.WriteString("\n\n")
+
+	// Wrap every message, then show the page ending scroll lines up
+	var lines []string
+	nameStyle := lipgloss.NewStyle().Bold(true)
+	for _, message := range m.messages {
+		prefix := subtleStyle.Render(message.CreatedAt.Local().Format("15:04")) + " "
+		if m.showIDs {
+			prefix += subtleStyle.Render(fmt.Sprintf("#%d", message.ID)) + " "
+		}
+		name := nameStyle.Render(message.Username)
+		if m.user != nil && message.Username == m.user.Username {
+			name = promptStyle.Render(message.Username)
+		}
+		text := lipgloss.NewStyle().Width(width).Render(prefix + name + ": " + message.Body)
+		lines = append(lines, strings.Split(text, "\n")...)
+	}
+
+	// Messages sit at the bottom, above the input, as in a terminal
+	page := m.messageLines()
+	scroll := min(m.scroll, max(len(lines)-page, 0))
+	switch {
+	case m.loading:
+		b.WriteString(strings.Repeat("\n", page-1) + subtleStyle.Render("Loading...") + "\n")
+	case len(lines) == 0:
+		b.WriteString(strings.Repeat("\n", page-1) + subtleStyle.Render("No messages yet. Say hello!") + "\n")
+	default:
+		end := len(lines) - scroll
+		start := max(end-page, 0)
+		b.WriteString(strings.Repeat("\n", page-(end-start)))
+		b.WriteString(strings.Join(lines[start:end], "\n") + "\n")
+	}
+	if scroll > 0 {
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("── %d more lines below ──", scroll)) + "\n")
+	} else {
+		b.WriteString("\n")
+	}
+
+	input := m.input
+	input.Width = width - 2
+	b.WriteString(input.View() + "\n")
+	status := m.statusMessage
+	if status == "" {
+		status = keyStyle.Render("[Enter]") + " Send  " +
+			keyStyle.Render("[PgUp/PgDn]") + " Scroll  " +
+			keyStyle.Render("[Esc]") + " Leave  " +
+			subtleStyle.Render("/help for commands")
+	} else if strings.HasPrefix(status, "Error") {
+		status = errorStyle.Render(status)
+	} else {
+		status = subtleStyle.Render(status)
+	}
+	b.WriteString(status + "\n")
+
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// clipboardEnv is the SSH environment variable that turns OSC 52 copies
+// off for a session whose terminal ignores them, e.g.
+// ssh -o SetEnv=TERMINALPUB_CLIPBOARD=0
+const clipboardEnv = "TERMINALPUB_CLIPBOARD"
+
+// copyMenuTitle identifies the copy menu in menu messages
+const copyMenuTitle = "Copy"
+
+// newCopyMenu lists what can be copied from a post
+func newCopyMenu() MenuModel {
+	return NewMenuModel(copyMenuTitle, []MenuItem{
+		{ID: "text", Label: "Text", Key: "t"},
+		{ID: "url", Label: "URL", Key: "u"},
+		{ID: "author", Label: "Author's handle", Key: "a"},
+		{ID: "show", Label: "Show as plain text to select", Key: "p"},
+	})
+}
+
+// startClipboard reports whether a session copies with OSC 52: unless
+// turned off for everyone by configuration, or by the client
+func startClipboard(ctx *AppContext, s ssh.Session) bool {
+	if ctx != nil && ctx.Config != nil && !ctx.Config.TUI.Clipboard {
+		return false
+	}
+	if s == nil {
+		return true
+	}
+	return !slices.Contains(s.Environ(), clipboardEnv+"=0")
+}
+
+// postText returns the text of a post as it reads, without styles:
+// paragraphs, quotes and lists are kept, and links show their address
+func postText(status services.MastodonStatus) string {
+	lines := (&htmlRenderer{width: 1 << 20, addresses: true}).render(status.Content)
+	text := ansi.Strip(strings.Join(lines, "\n"))
+	if status.SpoilerText != "" {
+		text = "CW: " + stripControl(status.SpoilerText) + "\n\n" + text
+	}
+	return text
+}
+
+// handleCopyChoice copies the part of status chosen from the copy menu, or
+// shows all of them plainly
+func (m Model) handleCopyChoice(id string, status services.MastodonStatus) (Model, tea.Cmd) {
+	handle := "@" + stripControl(status.Account.Acct)
+	switch id {
+	case "text":
+		return m.copyText(postText(status), "post text")
+	case "url":
+		if !linkable(status.URL) {
+			return m.setStatusMessage("Error: post has no URL"), nil
+		}
+		return m.copyText(status.URL, status.URL)
+	case "author":
+		return m.copyText(handle, handle)
+	case "show":
+		address := status.URL
+		if !linkable(address) {
+			address = ""
+		}
+		m.copyBlock = strings.TrimSpace(postText(status) + "\n\n" + address + "\n" + handle)
+	}
+	return m, nil
+}
+
+// copyText puts text on the user's clipboard using OSC 52, naming it what in
+// the status line. When the session doesn't copy that way, the text is
+// shown plainly instead for the user to select.
+func (m Model) copyText(text, what string) (Model, tea.Cmd) {
+	if !m.clipboard {
+		m.copyBlock = text
+		return m, nil
+	}
+	m = m.setStatusMessage("Copied " + what)
+	return m, copyToClipboardCmd(m.sshSession, text)
+}
+
+// renderCopyBlock shows text to copy with nothing around or inside it that
+// a mouse selection would pick up: no borders, indentation or styles
+func (m Model) renderCopyBlock() string {
+	help := "Select the text below to copy it; press any key to go back"
+	return help + "\n\n" + ansi.Wrap(m.copyBlock, max(m.width, 20), "")
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// chooseCopy opens the copy menu on the selected post and picks key
+func chooseCopy(t *testing.T, m Model, key string) (Model, tea.Cmd) {
+	t.Helper()
+	m = send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	if m.menu == nil {
+		t.Fatal("expected c to open the copy menu")
+	}
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	if cmd == nil {
+		t.Fatalf("expected %s to choose from the copy menu", key)
+	}
+	next, cmd = next.(Model).Update(cmd())
+	return next.(Model), cmd
+}
+
+func TestCopy(t *testing.T) {
+	m := newTestModel(t, 100, 30)
+	m.screen = screenFeed
+	status := linkedStatus()
+	status.Content = "<p>First</p><blockquote><p>quoted</p></blockquote>"
+	m = send(m, timelineMsg{statuses: []services.MastodonStatus{status}, timelineType: services.TimelineHome})
+
+	m, cmd := chooseCopy(t, m, "a")
+	if cmd == nil || m.feed.statusMessage != "Copied @bob@example.social" {
+		t.Errorf("expected the author's handle to be copied, got status %q", m.feed.statusMessage)
+	}
+
+	// Without OSC 52 the text is shown to select instead
+	m.clipboard = false
+	m, _ = chooseCopy(t, m, "t")
+	if want := "First\n\n│ quoted"; m.copyBlock != want {
+		t.Errorf("copy block = %q, want %q", m.copyBlock, want)
+	}
+	if view := m.View(); !strings.Contains(view, "First\n\n│ quoted") {
+		t.Errorf("expected the text shown plainly, got\n%s", view)
+	}
+	m = send(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.copyBlock != "" || m.screen != screenFeed || m.menu != nil {
+		t.Error("expected any key to go back to the feed")
+	}
+}
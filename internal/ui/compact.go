@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// Terminals narrower or shorter than this get the compact layout
+const (
+	compactWidth  = 60
+	compactHeight = 20
+)
+
+// isCompact reports whether a terminal of the given size needs the compact
+// layout: single-line posts, abbreviated footers and stacked menus
+func isCompact(width, height int) bool {
+	return width < compactWidth || height < compactHeight
+}
+
+// contentWidth is the width of the centered menu screens, which shrink to
+// fit small terminals
+func (m Model) contentWidth() int {
+	return min(60, m.width)
+}
+
+// fitLine cuts a rendered line to width cells
+func fitLine(line string, width int) string {
+	return ansi.Truncate(line, max(width, 1), "…")
+}
+
+// renderCompactFeed shows the timeline with one line per post
+func (m *Model) renderCompactFeed() string {
+	var b strings.Builder
+
+	title := fmt.Sprintf("%s (%d)", getTimelineName(m.feed.timelineType), len(m.feed.statuses))
+	if m.feed.offline {
+		title += " " + errorStyle.Render("offline "+formatAge(time.Since(m.feed.cachedAt)))
+	}
+	if m.feed.filter != nil {
+		title += " " + subtleStyle.Render(m.feed.filter.Name)
+	}
+	b.WriteString(fitLine(titleStyle.Render(title), m.width) + "\n")
+	b.WriteString(strings.Repeat("─", m.width) + "\n")
+
+	page := m.feedPage()
+	start := keepVisible(m.feed.scrollOffset, m.feed.selectedIndex, page, m.feed.matches)
+	visible := visibleIndexes(start, page, len(m.feed.statuses), m.feed.matches)
+
+	for _, i := range visible {
+		b.WriteString(m.renderPostLine(m.feed.statuses[i], i == m.feed.selectedIndex) + "\n")
+	}
+	for i := len(visible); i < page; i++ {
+		b.WriteString("\n")
+	}
+
+	statusMsg := m.feed.statusMessage
+	if statusMsg == "" && m.feed.loadingMore {
+		statusMsg = "Loading more..."
+	}
+	b.WriteString(strings.Repeat("─", m.width) + "\n")
+	b.WriteString(fitLine(keyStyle.Render("⏎")+" menu "+keyStyle.Render("I")+" info "+keyStyle.Render("R")+" reply "+
+		keyStyle.Render("X")+" like "+keyStyle.Render("B")+" back", m.width) + "\n")
+
+	position := fmt.Sprintf("%d/%d", m.feed.selectedIndex+1, len(m.feed.statuses))
+	if filter := m.feed.nav.View(); filter != "" {
+		position = filter
+	}
+	if statusMsg != "" {
+		style := successStyle
+		if strings.Contains(statusMsg, "Error") {
+			style = errorStyle
+		}
+		position += " " + style.Render(statusMsg)
+	}
+	b.WriteString(fitLine(position, m.width))
+
+	return b.String()
+}
+
+// renderPostLine renders a post on a single line: author and the start of
+// its content, marked when boosted or liked
+func (m *Model) renderPostLine(status services.MastodonStatus, selected bool) string {
+	original := status
+	prefix := ""
+	if status.Reblog != nil {
+		original = *status.Reblog
+		prefix = "↻ "
+	}
+	if original.Favourited {
+		prefix += "* "
+	}
+
+	indicator := "  "
+	if selected {
+		indicator = promptStyle.Render("► ")
+	}
+	line := indicator + prefix + authorStyle.Render("@"+original.Account.Username) + " " + stripHTML(original.Content)
+	if len(original.MediaAttachments) > 0 {
+		line += subtleStyle.Render(fmt.Sprintf(" [%d media]", len(original.MediaAttachments)))
+	}
+	return fitLine(line, m.width)
+}
+
+// StackedView renders the menu as a plain list filling a small screen
+// rather than a box drawn over it
+func (m MenuModel) StackedView(width int) string {
+	var b strings.Builder
+
+	b.WriteString(fitLine(titleStyle.Render(m.title), width) + "\n")
+	for i, item := range m.items {
+		line := "  "
+		if i == m.selected {
+			line = promptStyle.Render("► ")
+		}
+		if item.Key != "" {
+			line += keyStyle.Render(item.Key) + " "
+		}
+		line += item.Label
+		b.WriteString(fitLine(line, width) + "\n")
+	}
+	b.WriteString(fitLine(subtleStyle.Render("⏎ choose  Esc close"), width))
+
+	return b.String()
+}
+
+// mainMenuEntry is an item of the main menu
+type mainMenuEntry struct {
+	key   string
+	label string
+	short string // Label in the compact layout
+}
+
+// renderCompactMenu lays the main menu out in as many columns as the
+// terminal's height requires, with short labels
+func (m Model) renderCompactMenu(username string, entries []mainMenuEntry) string {
+	var b strings.Builder
+
+	b.WriteString(fitLine(titleStyle.Render("@"+username), m.width) + "\n\n")
+
+	// Leave room for the header, the message of the day and a message below
+	rows := max(m.height-5, 1)
+	if motd := m.renderCompactMOTD(); motd != "" {
+		b.WriteString(motd + "\n")
+		rows = max(rows-1, 1)
+	}
+	columns := (len(entries) + rows - 1) / rows
+	rows = (len(entries) + columns - 1) / columns
+	columnWidth := m.width / columns
+
+	for row := range rows {
+		var line strings.Builder
+		for column := range columns {
+			i := column*rows + row
+			if i >= len(entries) {
+				break
+			}
+			cell := fitLine(keyStyle.Render(entries[i].key)+" "+entries[i].short, columnWidth-1)
+			line.WriteString(cell + strings.Repeat(" ", max(columnWidth-lipgloss.Width(cell), 0)))
+		}
+		b.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+
+	if m.message != "" {
+		style := successStyle
+		if strings.Contains(m.message, "Error") {
+			style = errorStyle
+		}
+		b.WriteString("\n" + fitLine(style.Render(strings.Split(m.message, "\n")[0]), m.width) + "\n")
+	}
+
+	return b.String()
+}
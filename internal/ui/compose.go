@@ -1,12 +1,15 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/fulgidus/terminalpub/internal/services"
 )
 
 // ComposeMode indicates whether user is composing a new post or replying
@@ -15,6 +18,7 @@ type ComposeMode int
 const (
 	ComposeNew ComposeMode = iota
 	ComposeReply
+	ComposeQuote
 )
 
 // VisibilityOption represents Mastodon post visibility settings
@@ -34,6 +38,10 @@ type ComposeModel struct {
 	replyToID      string
 	replyToAuthor  string
 	replyToContent string
+	quoteID        string                    // Status being quoted natively; empty when falling back to a link
+	quoteParam     string                    // Instance parameter for native quotes
+	replyChain     []services.MastodonStatus // Conversation up to the parent, oldest first
+	chainScroll    int                       // Context lines scrolled up from the newest
 	visibility     VisibilityOption
 	contentWarning string
 	cwEnabled      bool
@@ -43,6 +51,11 @@ type ComposeModel struct {
 	posting        bool
 	posted         bool
 	err            error
+	targets        []services.CrossPostTarget // Accounts a new post can go to
+	selected       map[string]bool            // Targets chosen, by key
+	targetFocus    bool                       // Keys move through the targets rather than the text
+	targetIndex    int
+	results        []services.CrossPostResult // Outcome of the last cross-post, per target
 }
 
 // NewComposeModel creates a new compose screen model
@@ -64,17 +77,76 @@ func NewComposeModel() ComposeModel {
 	}
 }
 
-// NewReplyModel creates a compose model for replying to a post
-func NewReplyModel(replyToID, replyToAuthor, replyToContent string) ComposeModel {
+// NewReplyModel creates a compose model for replying to a post. Everyone taking
+// part in the conversation except selfAcct is mentioned, and the parent's
+// visibility and content warning are inherited.
+func NewReplyModel(parent services.MastodonStatus, selfAcct string) ComposeModel {
 	m := NewComposeModel()
 	m.mode = ComposeReply
-	m.replyToID = replyToID
-	m.replyToAuthor = replyToAuthor
-	m.replyToContent = replyToContent
+	m.replyToID = parent.ID
+	m.replyToAuthor = parent.Account.Acct
+	m.replyToContent = stripHTML(parent.Content)
+	m.replyChain = []services.MastodonStatus{parent}
+
+	// Pre-populate with @mentions
+	if mentions := replyMentions(parent, selfAcct); len(mentions) > 0 {
+		m.textarea.SetValue("@" + strings.Join(mentions, " @") + " ")
+	}
+
+	switch VisibilityOption(parent.Visibility) {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate, VisibilityDirect:
+		m.visibility = VisibilityOption(parent.Visibility)
+	}
 
-	// Pre-populate with @mention
-	if replyToAuthor != "" {
-		m.textarea.SetValue("@" + replyToAuthor + " ")
+	if parent.SpoilerText != "" {
+		m.cwEnabled = true
+		m.contentWarning = parent.SpoilerText
+	}
+
+	return m
+}
+
+// replyMentions returns the accounts to mention in a reply: the author first,
+// then everyone the parent mentioned, without duplicates or selfAcct
+func replyMentions(parent services.MastodonStatus, selfAcct string) []string {
+	seen := map[string]bool{selfAcct: true}
+	var mentions []string
+
+	add := func(acct string) {
+		if acct == "" || seen[acct] {
+			return
+		}
+		seen[acct] = true
+		mentions = append(mentions, acct)
+	}
+
+	add(parent.Account.Acct)
+	for _, mention := range parent.Mentions {
+		add(mention.Acct)
+	}
+
+	return mentions
+}
+
+// NewQuoteModel creates a compose model quoting a post. When the instance has
+// no native quote support (quoteParam is empty) the post URL is appended instead.
+func NewQuoteModel(statusID, author, content, statusURL, quoteParam string) ComposeModel {
+	m := NewComposeModel()
+	m.mode = ComposeQuote
+	m.replyToAuthor = author
+	m.replyToContent = content
+
+	if quoteParam != "" {
+		m.quoteID = statusID
+		m.quoteParam = quoteParam
+	} else {
+		m.textarea.SetValue("\n\n" + statusURL)
+		// Leave the cursor on the first line, above the link
+		for i := 0; i < 2; i++ {
+			m.textarea.CursorUp()
+		}
+		m.textarea.CursorStart()
+		m.status = "Quotes unsupported by your instance; the link will be included"
 	}
 
 	return m
@@ -91,7 +163,22 @@ func (m ComposeModel) Update(msg tea.Msg) (ComposeModel, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
+	case crossPostTargetsMsg:
+		// Without the list, posts go to the primary account as always
+		if msg.err == nil && m.mode == ComposeNew {
+			m.targets = msg.targets
+			m.selected = map[string]bool{}
+			for _, target := range m.targets {
+				m.selected[target.Key] = target.Default
+			}
+		}
+		return m, nil
+
 	case tea.KeyMsg:
+		if m.targetFocus {
+			return m.updateTargets(msg)
+		}
+
 		// Handle special keys first
 		switch msg.String() {
 		case "esc":
@@ -114,9 +201,29 @@ func (m ComposeModel) Update(msg tea.Msg) (ComposeModel, tea.Cmd) {
 				m.status = "Status exceeds 500 characters"
 				return m, nil
 			}
+			if m.crossPosting() {
+				targets := m.selectedTargets()
+				if len(targets) == 0 {
+					m.status = "Choose at least one account to post to (Ctrl+T)"
+					return m, nil
+				}
+				m.posting = true
+				m.status = fmt.Sprintf("Posting to %d accounts...", len(targets))
+				return m, func() tea.Msg {
+					return postStatusMsg{content: content, visibility: m.visibility, contentWarning: m.contentWarning, targets: targets}
+				}
+			}
 			m.posting = true
 			m.status = "Posting..."
-			return m, postStatusCmd(content, m.visibility, m.replyToID, m.contentWarning)
+			return m, postStatusCmd(content, m.visibility, m.replyToID, m.contentWarning, m.quoteID, m.quoteParam)
+
+		case "ctrl+t":
+			// Choose the accounts to post to
+			if len(m.targets) > 1 && !m.posting {
+				m.targetFocus = true
+				m.textarea.Blur()
+			}
+			return m, nil
 
 		case "ctrl+w":
 			// Toggle content warning
@@ -131,6 +238,17 @@ func (m ComposeModel) Update(msg tea.Msg) (ComposeModel, tea.Cmd) {
 			m.visibility = m.nextVisibility()
 			return m, nil
 
+		case "pgup":
+			// Scroll the conversation context back
+			m.chainScroll++
+			return m, nil
+
+		case "pgdown":
+			if m.chainScroll > 0 {
+				m.chainScroll--
+			}
+			return m, nil
+
 		default:
 			// Pass all other keys to textarea
 			if !m.posting {
@@ -140,6 +258,13 @@ func (m ComposeModel) Update(msg tea.Msg) (ComposeModel, tea.Cmd) {
 			return m, tea.Batch(cmds...)
 		}
 
+	case replyChainMsg:
+		// Keep the parent alone if the conversation could not be loaded
+		if msg.err == nil && msg.parentID == m.replyToID && len(m.replyChain) > 0 {
+			m.replyChain = append(msg.ancestors, m.replyChain[len(m.replyChain)-1])
+		}
+		return m, nil
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -159,6 +284,8 @@ func (m ComposeModel) View() string {
 	title := "Compose New Post"
 	if m.mode == ComposeReply {
 		title = "Reply to Post"
+	} else if m.mode == ComposeQuote {
+		title = "Quote Post"
 	}
 
 	// Use dynamic width constraints
@@ -182,25 +309,18 @@ func (m ComposeModel) View() string {
 	b.WriteString(titleLine + "\n")
 	b.WriteString(separator + "\n")
 
-	// If replying, show context
-	if m.mode == ComposeReply {
+	// If replying or quoting, show context
+	if m.mode == ComposeReply || m.mode == ComposeQuote {
+		contextLabel := "Replying to:"
+		if m.mode == ComposeQuote {
+			contextLabel = "Quoting:"
+		}
 		b.WriteString("║" + strings.Repeat(" ", contentWidth-2) + "║\n")
-		b.WriteString("║  Replying to:" + strings.Repeat(" ", contentWidth-16) + "║\n")
+		b.WriteString("║  " + padRight(contextLabel, contentWidth-4) + "  ║\n")
 		b.WriteString("║  " + padRight("┌"+strings.Repeat("─", contentWidth-6)+"┐", contentWidth-2) + "║\n")
 
-		// Show reply context (truncated)
-		authorLine := fmt.Sprintf("│ %s", m.replyToAuthor)
-		b.WriteString("║  " + padRight(authorLine, contentWidth-4) + "  ║\n")
-
-		// Truncate content if too long
-		contentLines := wrapText(m.replyToContent, contentWidth-10)
-		maxContextLines := 3
-		for i, line := range contentLines {
-			if i >= maxContextLines {
-				b.WriteString("║  " + padRight("│ ...", contentWidth-4) + "  ║\n")
-				break
-			}
-			b.WriteString("║  " + padRight(fmt.Sprintf("│ %s", line), contentWidth-4) + "  ║\n")
+		for _, line := range m.contextLines(contentWidth - 10) {
+			b.WriteString("║  " + padRight("│ "+line, contentWidth-4) + "  ║\n")
 		}
 
 		b.WriteString("║  " + padRight("└"+strings.Repeat("─", contentWidth-6)+"┘", contentWidth-2) + "║\n")
@@ -256,9 +376,19 @@ func (m ComposeModel) View() string {
 	cwStr := "Content Warning: [ ] Add CW"
 	if m.cwEnabled {
 		cwStr = "Content Warning: [X] CW Enabled"
+		if m.contentWarning != "" {
+			cwStr = "Content Warning: [X] " + truncate(m.contentWarning, contentWidth-30)
+		}
 	}
 	b.WriteString("║  " + padRight(cwStyle.Render(cwStr), contentWidth-2) + "║\n")
 
+	if len(m.targets) > 1 {
+		b.WriteString("║" + strings.Repeat(" ", contentWidth-2) + "║\n")
+		for _, line := range m.renderTargets(contentWidth - 4) {
+			b.WriteString("║  " + line + strings.Repeat(" ", max(contentWidth-4-lipgloss.Width(line), 0)) + "  ║\n")
+		}
+	}
+
 	b.WriteString("║" + strings.Repeat(" ", contentWidth-2) + "║\n")
 
 	// Keyboard shortcuts with colors
@@ -269,6 +399,19 @@ func (m ComposeModel) View() string {
 		keyStyle.Render("[Ctrl+W]"),
 		keyStyle.Render("[Ctrl+V]"),
 		keyStyle.Render("[Esc]"))
+	if m.targetFocus {
+		shortcuts = fmt.Sprintf("%s Select  %s On/Off  %s Back to the text",
+			keyStyle.Render("[↑/↓]"),
+			keyStyle.Render("[Space]"),
+			keyStyle.Render("[Esc]"))
+	} else if len(m.targets) > 1 {
+		shortcuts = fmt.Sprintf("%s Post  %s CW  %s Visibility  %s Accounts  %s Cancel",
+			keyStyle.Render("[Ctrl+P]"),
+			keyStyle.Render("[Ctrl+W]"),
+			keyStyle.Render("[Ctrl+V]"),
+			keyStyle.Render("[Ctrl+T]"),
+			keyStyle.Render("[Esc]"))
+	}
 	b.WriteString("║  " + padRight(shortcuts, contentWidth-2) + "║\n")
 
 	b.WriteString("║" + strings.Repeat(" ", contentWidth-2) + "║\n")
@@ -295,6 +438,111 @@ func (m ComposeModel) View() string {
 	return b.String()
 }
 
+// crossPosting reports whether the targets chosen differ from the primary
+// account alone, which posts go to without a choice
+func (m ComposeModel) crossPosting() bool {
+	for _, target := range m.targets {
+		if m.selected[target.Key] != target.Primary {
+			return true
+		}
+	}
+	return false
+}
+
+// selectedTargets returns the targets chosen, in the order listed
+func (m ComposeModel) selectedTargets() []services.CrossPostTarget {
+	var targets []services.CrossPostTarget
+	for _, target := range m.targets {
+		if m.selected[target.Key] {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// updateTargets handles keys while the targets have the focus: ↑/↓ move,
+// Space switches a target on or off and Esc or Ctrl+T go back to the text
+func (m ComposeModel) updateTargets(msg tea.KeyMsg) (ComposeModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+t", "tab":
+		m.targetFocus = false
+		return m, m.textarea.Focus()
+	case "up", "k":
+		m.targetIndex = max(m.targetIndex-1, 0)
+	case "down", "j":
+		m.targetIndex = min(m.targetIndex+1, len(m.targets)-1)
+	case " ", "x":
+		key := m.targets[m.targetIndex].Key
+		m.selected[key] = !m.selected[key]
+	}
+	return m, nil
+}
+
+// renderTargets lists the accounts a post can go to with their checkboxes,
+// and how the last attempt went on each
+func (m ComposeModel) renderTargets(width int) []string {
+	lines := []string{"Post to:"}
+	for i, target := range m.targets {
+		box := "[ ] "
+		if m.selected[target.Key] {
+			box = "[x] "
+		}
+		label := truncate(target.Label, max(width-6, 10))
+		line := "  " + box + label
+		if m.targetFocus && i == m.targetIndex {
+			line = promptStyle.Render("► " + box + label)
+		}
+		for _, result := range m.results {
+			if result.Target != target.Key {
+				continue
+			}
+			if result.Err != nil {
+				line += "  " + errorStyle.Render(truncate("✗ "+result.Err.Error(), max(width-lipgloss.Width(line)-2, 5)))
+			} else {
+				posted := "✓ posted"
+				if result.Detail != "" {
+					posted += " (" + result.Detail + ")"
+				}
+				line += "  " + successStyle.Render(posted)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// maxContextLines is how many lines of conversation are shown above the textarea
+const maxContextLines = 6
+
+// contextLines returns the visible window of the conversation being replied
+// to or the quoted post, newest at the bottom
+func (m ComposeModel) contextLines(width int) []string {
+	var lines []string
+	if len(m.replyChain) == 0 {
+		lines = append(lines, m.replyToAuthor)
+		lines = append(lines, wrapText(m.replyToContent, width)...)
+	}
+	for i, status := range m.replyChain {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "@"+status.Account.Acct)
+		lines = append(lines, wrapText(stripHTML(status.Content), width)...)
+	}
+
+	if len(lines) <= maxContextLines {
+		return lines
+	}
+
+	scroll := min(m.chainScroll, len(lines)-maxContextLines)
+	end := len(lines) - scroll
+	window := lines[end-maxContextLines : end]
+	if end-maxContextLines > 0 {
+		window[0] = "↑ more (PgUp)"
+	}
+	return window
+}
+
 // nextVisibility cycles to the next visibility option
 func (m ComposeModel) nextVisibility() VisibilityOption {
 	switch m.visibility {
@@ -314,6 +562,24 @@ func (m ComposeModel) nextVisibility() VisibilityOption {
 // Messages for compose screen
 type composeCancelMsg struct{}
 
+// replyChainMsg carries the ancestors of the post being replied to
+type replyChainMsg struct {
+	parentID  string
+	ancestors []services.MastodonStatus
+	err       error
+}
+
+// fetchReplyChainCmd loads the conversation above the post being replied to
+func fetchReplyChainCmd(timelines TimelineFetcher, userID int, parentID string) tea.Cmd {
+	return func() tea.Msg {
+		statusContext, err := timelines.GetStatusContext(context.Background(), userID, parentID)
+		if err != nil {
+			return replyChainMsg{parentID: parentID, err: err}
+		}
+		return replyChainMsg{parentID: parentID, ancestors: statusContext.Ancestors}
+	}
+}
+
 type composeSuccessMsg struct {
 	statusID string
 }
@@ -324,7 +590,7 @@ type postStatusResultMsg struct {
 }
 
 // postStatusCmd posts a status to Mastodon
-func postStatusCmd(content string, visibility VisibilityOption, replyToID string, contentWarning string) tea.Cmd {
+func postStatusCmd(content string, visibility VisibilityOption, replyToID string, contentWarning, quoteID, quoteParam string) tea.Cmd {
 	return func() tea.Msg {
 		// This will be implemented in tui.go to access the app context
 		// For now, return a placeholder
@@ -333,6 +599,8 @@ func postStatusCmd(content string, visibility VisibilityOption, replyToID string
 			visibility:     visibility,
 			replyToID:      replyToID,
 			contentWarning: contentWarning,
+			quoteID:        quoteID,
+			quoteParam:     quoteParam,
 		}
 	}
 }
@@ -342,4 +610,68 @@ type postStatusMsg struct {
 	visibility     VisibilityOption
 	replyToID      string
 	contentWarning string
+	quoteID        string
+	quoteParam     string
+	targets        []services.CrossPostTarget // Set to cross-post a new post
+}
+
+// crossPostTargetsMsg carries the accounts a new post can be published to
+type crossPostTargetsMsg struct {
+	targets []services.CrossPostTarget
+	err     error
+}
+
+// crossPostResultMsg reports how publishing to each target went
+type crossPostResultMsg struct {
+	results []services.CrossPostResult
+}
+
+// loadCrossPostTargetsCmd lists the accounts a new post can go to
+func loadCrossPostTargetsCmd(appCtx *AppContext, userID int) tea.Cmd {
+	if appCtx == nil || appCtx.DB == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		targets, err := services.NewCrossPostService(appCtx.DB, appCtx.Config).Targets(ctx, userID)
+		return crossPostTargetsMsg{targets: targets, err: err}
+	}
+}
+
+// crossPostCmd publishes a post to several accounts at once
+func crossPostCmd(appCtx *AppContext, userID int, targets []services.CrossPostTarget, content, visibility, contentWarning string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		results := services.NewCrossPostService(appCtx.DB, appCtx.Config).Post(ctx, userID, targets, content, visibility, contentWarning)
+		return crossPostResultMsg{results: results}
+	}
+}
+
+// handleCrossPostResult leaves compose when every target got the post;
+// otherwise the targets that did are unchecked so Ctrl+P retries the rest
+func (m Model) handleCrossPostResult(msg crossPostResultMsg) (tea.Model, tea.Cmd) {
+	m.compose.posting = false
+	m.compose.results = msg.results
+	failed := 0
+	for _, result := range msg.results {
+		if result.Err != nil {
+			failed++
+		} else {
+			m.compose.selected[result.Target] = false
+		}
+	}
+	if failed > 0 {
+		m.compose.status = fmt.Sprintf("Error: posting failed on %d of %d accounts; Ctrl+P retries them", failed, len(msg.results))
+		return m, nil
+	}
+
+	m = m.popScreen()
+	m.message = fmt.Sprintf("Posted to %d accounts!", len(msg.results))
+	if m.screen == screenFeed {
+		m.feed.loading = true
+		return m, fetchTimelineCmd(m.ctx, m.user.ID, m.feed.timelineType, 20)
+	}
+	return m, nil
 }
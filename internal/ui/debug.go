@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+const (
+	// debugKey toggles the debug overlay; it has no other use, so nobody
+	// opens it by accident
+	debugKey = "ctrl+_"
+
+	// debugRefresh is how often the open overlay updates its stats
+	debugRefresh = time.Second
+
+	// debugAPICalls is how many recent API calls the overlay lists
+	debugAPICalls = 5
+
+	// debugWidth is the widest the overlay is drawn
+	debugWidth = 56
+)
+
+// debugStyle frames the debug overlay
+var debugStyle = lipgloss.NewStyle().
+	Border(lipgloss.NormalBorder()).
+	BorderForeground(lipgloss.Color("241")).
+	Padding(0, 1)
+
+// debugStats is what the debug overlay measures while it is open. It is
+// shared by the copies of the model, so that View can count its renders.
+type debugStats struct {
+	frames []time.Time // Renders in the last second
+}
+
+// debugTickMsg refreshes the debug overlay opened as stats
+type debugTickMsg struct {
+	stats *debugStats
+}
+
+// debugTickCmd schedules the next refresh of the debug overlay
+func debugTickCmd(stats *debugStats) tea.Cmd {
+	return tea.Tick(debugRefresh, func(time.Time) tea.Msg {
+		return debugTickMsg{stats: stats}
+	})
+}
+
+// toggleDebug opens or closes the debug overlay
+func (m Model) toggleDebug() (Model, tea.Cmd) {
+	if m.debug != nil {
+		m.debug = nil
+		return m, nil
+	}
+	m.debug = &debugStats{}
+	return m, debugTickCmd(m.debug)
+}
+
+// handleDebugTick keeps refreshing the overlay for as long as it is open
+func (m Model) handleDebugTick(msg debugTickMsg) (Model, tea.Cmd) {
+	if m.debug == nil || msg.stats != m.debug {
+		// Closed, or reopened with a refresh of its own
+		return m, nil
+	}
+	return m, debugTickCmd(m.debug)
+}
+
+// countFrame records a render and returns the renders in the last second
+func (d *debugStats) countFrame(now time.Time) int {
+	cutoff := now.Add(-time.Second)
+	kept := d.frames[:0]
+	for _, t := range d.frames {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	d.frames = append(kept, now)
+	return len(d.frames)
+}
+
+// renderDebug renders the debug overlay's stats
+func (m Model) renderDebug() string {
+	fps := m.debug.countFrame(time.Now())
+
+	size := fmt.Sprintf("%d×%d", m.width, m.height)
+	var modes []string
+	for _, mode := range []struct {
+		on   bool
+		name string
+	}{{m.compact, "compact"}, {m.accessible, "accessible"}, {m.ascii, "ascii"}} {
+		if mode.on {
+			modes = append(modes, mode.name)
+		}
+	}
+	if len(modes) > 0 {
+		size += " (" + strings.Join(modes, ", ") + ")"
+	}
+
+	var stack []string
+	for _, entry := range m.screens {
+		stack = append(stack, screenName(entry.screen))
+	}
+	stack = append(stack, screenName(m.screen))
+
+	lines := []string{
+		titleStyle.Render("Debug") + subtleStyle.Render("  Ctrl+_ to close"),
+		"",
+		"Terminal    " + size,
+		fmt.Sprintf("Renders     %d/s", fps),
+		fmt.Sprintf("Goroutines  %d", runtime.NumGoroutine()),
+		"Node        " + services.NodeID,
+		"Screens     " + strings.Join(stack, " › "),
+		"",
+	}
+
+	host := m.apiHost()
+	calls := services.RecentAPICalls(host, debugAPICalls)
+	switch {
+	case host == "":
+		lines = append(lines, subtleStyle.Render("No Mastodon instance signed in"))
+	case len(calls) == 0:
+		lines = append(lines, subtleStyle.Render("No API calls to "+host+" yet"))
+	default:
+		lines = append(lines, "API calls to "+host)
+		for _, call := range calls {
+			status := "failed"
+			if call.Status != 0 {
+				status = fmt.Sprint(call.Status)
+			}
+			lines = append(lines, fmt.Sprintf("%5dms %-6s %s %s",
+				call.Duration.Milliseconds(), status, call.Method, call.Endpoint))
+		}
+	}
+
+	width := max(min(debugWidth, m.width-4), 10)
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, width, "…")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// apiHost returns the host of the signed-in user's Mastodon instance
+func (m Model) apiHost() string {
+	if m.user == nil || m.user.PrimaryMastodonInstance == "" {
+		return ""
+	}
+	instance := m.user.PrimaryMastodonInstance
+	if !strings.Contains(instance, "://") {
+		instance = "https://" + instance
+	}
+	u, err := url.Parse(instance)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// withDebug draws the debug overlay in the top right corner of view
+func (m Model) withDebug(view string) string {
+	stats := m.renderDebug()
+	if m.accessible {
+		// Read before the screen rather than over it
+		return stats + "\n\n" + view
+	}
+	box := debugStyle.Render(stats)
+	y := 0
+	if m.recording() {
+		y = 1 // Below the recording mark
+	}
+	return overlayAt(view, box, max(m.width-lipgloss.Width(box), 0), y, m.height)
+}
@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDebugOverlay(t *testing.T) {
+	m := newTestModel(t, 100, 30)
+	m.screen = screenFeed
+	m = m.pushScreen(screenNotifications)
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlUnderscore})
+	m = next.(Model)
+	if m.debug == nil || cmd == nil {
+		t.Fatal("expected Ctrl+_ to open the overlay with a refresh")
+	}
+	view := m.View()
+	for _, want := range []string{"Terminal    100×30", "Renders", "Goroutines", "Screens     " + screenName(screenFeed) + " › " + screenName(screenNotifications)} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the overlay, got\n%s", want, view)
+		}
+	}
+	if lines := strings.Split(view, "\n"); len(lines) != 30 {
+		t.Errorf("expected the overlay to keep the screen's %d lines, got %d", 30, len(lines))
+	}
+
+	stats := m.debug
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlUnderscore})
+	m = next.(Model)
+	if m.debug != nil || strings.Contains(m.View(), "Goroutines") {
+		t.Fatal("expected Ctrl+_ to close the overlay")
+	}
+	if _, cmd := m.Update(debugTickMsg{stats: stats}); cmd != nil {
+		t.Error("expected refreshes to stop once closed")
+	}
+}
@@ -0,0 +1,204 @@
+package ui
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// demoFixtures holds the accounts, posts and notifications demo sessions see
+//
+//go:embed fixtures/demo.json
+var demoFixtures []byte
+
+// demoData is the layout of demoFixtures. Posts and notifications carry an
+// age rather than a date, so the demo always looks current.
+type demoData struct {
+	Self          services.MastodonAccount   `json:"self"`
+	Accounts      []services.MastodonAccount `json:"accounts"`
+	Following     []string                   `json:"following"`
+	Statuses      []demoStatus               `json:"statuses"`
+	Notifications []demoNotification         `json:"notifications"`
+}
+
+type demoStatus struct {
+	ID         string   `json:"id"`
+	Age        string   `json:"age"`
+	Account    string   `json:"account"`
+	InReplyTo  string   `json:"in_reply_to"`
+	Content    string   `json:"content"`
+	Visibility string   `json:"visibility"`
+	Spoiler    string   `json:"spoiler"`
+	Tags       []string `json:"tags"`
+	Pinned     bool     `json:"pinned"`
+	Replies    int      `json:"replies"`
+	Reblogs    int      `json:"reblogs"`
+	Favourites int      `json:"favourites"`
+}
+
+type demoNotification struct {
+	ID      string                    `json:"id"`
+	Type    services.NotificationType `json:"type"`
+	Age     string                    `json:"age"`
+	Account string                    `json:"account"`
+	Status  string                    `json:"status"`
+}
+
+// demoUser is who every demo session is signed in as
+var demoUser = models.User{
+	ID:                  1,
+	Username:            "demo",
+	DisplayName:         "Demo User",
+	PrimaryMastodonAcct: "demo",
+	UsernameConfirmed:   true,
+}
+
+// demoMenuKeys are the main menu entries that work without a database
+var demoMenuKeys = map[string]bool{"P": true, "F": true, "N": true, "G": true, "Y": true, "Q": true}
+
+// demoPaletteCommands are the commands besides demoMenuKeys that the demo
+// supports
+var demoPaletteCommands = map[string]bool{"home": true, "local": true, "federated": true, "games": true, "menu": true, "quit": true}
+
+// demoBlockedActions are the post menu entries the demo leaves out
+var demoBlockedActions = map[string]bool{"quote": true, "report": true}
+
+// demoUnavailable is shown for features the demo leaves out
+const demoUnavailable = "Not available in the demo"
+
+// demoBlocksMenuKey reports whether key opens a main menu entry the demo
+// leaves out
+func (m Model) demoBlocksMenuKey(key string) bool {
+	if !m.ctx.Demo || demoMenuKeys[strings.ToUpper(key)] {
+		return false
+	}
+	for _, entry := range m.mainMenuEntries() {
+		if strings.EqualFold(entry.key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// menuEntries returns the main menu entries shown, which in the demo are
+// only those it supports
+func (m Model) menuEntries() []mainMenuEntry {
+	entries := m.mainMenuEntries()
+	if m.ctx == nil || !m.ctx.Demo {
+		return entries
+	}
+	var kept []mainMenuEntry
+	for _, entry := range entries {
+		if demoMenuKeys[entry.key] {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// NewDemoContext returns an AppContext for demo mode: sessions skip the
+// login, and timelines, notifications and profiles come from fixtures
+// embedded in the binary instead of databases and Mastodon. Each session
+// gets its own copy, so what one visitor likes or posts no one else sees.
+func NewDemoContext(cfg *config.Config) (*AppContext, error) {
+	if _, err := newDemoMastodon(time.Now()); err != nil {
+		return nil, err
+	}
+	return &AppContext{Config: cfg, Demo: true}, nil
+}
+
+// demoSession returns a copy of a demo context with fresh fixtures
+func demoSession(ctx *AppContext) *AppContext {
+	fake, _ := newDemoMastodon(time.Now()) // NewDemoContext checked the fixtures
+	session := *ctx
+	session.Timelines, session.Actions, session.Notifications = fake, fake, fake
+	return &session
+}
+
+// newDemoMastodon loads the fixtures into a FakeMastodon, dating them back
+// from now
+func newDemoMastodon(now time.Time) (*FakeMastodon, error) {
+	var data demoData
+	if err := json.Unmarshal(demoFixtures, &data); err != nil {
+		return nil, fmt.Errorf("invalid demo fixtures: %w", err)
+	}
+
+	fake := NewFakeMastodon(data.Self)
+	accounts := map[string]services.MastodonAccount{data.Self.ID: data.Self}
+	for _, account := range data.Accounts {
+		fake.AddAccount(account)
+		accounts[account.ID] = account
+	}
+	for _, id := range data.Following {
+		fake.Follow(id)
+	}
+
+	statuses := map[string]services.MastodonStatus{}
+	for _, s := range data.Statuses {
+		age, err := time.ParseDuration(s.Age)
+		if err != nil {
+			return nil, fmt.Errorf("demo status %s: %w", s.ID, err)
+		}
+		account, ok := accounts[s.Account]
+		if !ok {
+			return nil, fmt.Errorf("demo status %s: unknown account %s", s.ID, s.Account)
+		}
+		status := services.MastodonStatus{
+			ID:              s.ID,
+			CreatedAt:       now.Add(-age),
+			Content:         s.Content,
+			Visibility:      s.Visibility,
+			Sensitive:       s.Spoiler != "",
+			SpoilerText:     s.Spoiler,
+			Pinned:          s.Pinned,
+			RepliesCount:    s.Replies,
+			ReblogsCount:    s.Reblogs,
+			FavouritesCount: s.Favourites,
+			Account:         account,
+			URL:             strings.TrimSuffix(account.URL, "/") + "/" + s.ID,
+		}
+		if status.Visibility == "" {
+			status.Visibility = "public"
+		}
+		if s.InReplyTo != "" {
+			parent, ok := statuses[s.InReplyTo]
+			if !ok {
+				return nil, fmt.Errorf("demo status %s: replies to unknown status %s", s.ID, s.InReplyTo)
+			}
+			status.InReplyToID = &parent.ID
+			status.InReplyToAccountID = &parent.Account.ID
+		}
+		for _, tag := range s.Tags {
+			status.Tags = append(status.Tags, services.MastodonTag{Name: tag})
+		}
+		statuses[s.ID] = fake.AddStatus(status)
+	}
+
+	for _, n := range data.Notifications {
+		age, err := time.ParseDuration(n.Age)
+		if err != nil {
+			return nil, fmt.Errorf("demo notification %s: %w", n.ID, err)
+		}
+		notification := services.MastodonNotification{
+			ID:        n.ID,
+			Type:      n.Type,
+			CreatedAt: now.Add(-age),
+			Account:   accounts[n.Account],
+		}
+		if n.Status != "" {
+			status, ok := statuses[n.Status]
+			if !ok {
+				return nil, fmt.Errorf("demo notification %s: unknown status %s", n.ID, n.Status)
+			}
+			notification.Status = &status
+		}
+		fake.AddNotification(notification)
+	}
+	return fake, nil
+}
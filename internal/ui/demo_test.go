@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+func TestDemoSession(t *testing.T) {
+	ctx, err := NewDemoContext(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("NewDemoContext: %v", err)
+	}
+	m := NewModel(ctx, testSession{})
+
+	// The session signs in without a login screen
+	batch, ok := m.Init()().(tea.BatchMsg)
+	if !ok {
+		t.Fatal("expected Init to batch its commands")
+	}
+	var signedIn bool
+	for _, cmd := range batch {
+		if cmd == nil {
+			continue
+		}
+		if msg, ok := peek(cmd).(authenticatedMsg); ok {
+			m = send(m, msg)
+			signedIn = true
+		}
+	}
+	if !signedIn || m.screen != screenAuthenticated || m.user.Username != "demo" {
+		t.Fatalf("expected the demo user on the main menu, got screen %d", m.screen)
+	}
+
+	// Features that need a database are left out
+	if m = send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")}); m.message != demoUnavailable {
+		t.Errorf("expected API tokens to be unavailable, got %q", m.message)
+	}
+
+	home, err := m.ctx.timelines().GetTimeline(context.Background(), m.user.ID, services.TimelineHome, 40, "")
+	if err != nil || len(home) < 10 {
+		t.Fatalf("expected a full home timeline, got %d posts (%v)", len(home), err)
+	}
+	thread, err := m.ctx.timelines().GetStatusContext(context.Background(), m.user.ID, "1001")
+	if err != nil || len(thread.Descendants) != 2 {
+		t.Fatalf("expected two replies in the demo thread, got %+v (%v)", thread, err)
+	}
+	notifications, err := m.ctx.notifier().GetNotifications(context.Background(), m.user.ID, 20, "")
+	if err != nil || len(notifications) == 0 || notifications[0].Status == nil {
+		t.Fatalf("expected notifications about the demo posts, got %+v (%v)", notifications, err)
+	}
+}
+
+func TestDemoSessionsAreIndependent(t *testing.T) {
+	ctx, err := NewDemoContext(config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := NewModel(ctx, testSession{}), NewModel(ctx, testSession{})
+
+	if _, err := first.ctx.statusActions().PostStatus(context.Background(), 1, "only mine", "public", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	theirs, _ := second.ctx.timelines().GetTimeline(context.Background(), 1, services.TimelineHome, 1, "")
+	if len(theirs) == 1 && theirs[0].Content == "<p>only mine</p>" {
+		t.Error("expected a post in one session not to show up in another")
+	}
+}
+
+// peek runs cmd, giving up on commands that wait, such as ticks
+func peek(cmd tea.Cmd) tea.Msg {
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	select {
+	case msg := <-done:
+		return msg
+	case <-time.After(100 * time.Millisecond):
+		return nil
+	}
+}
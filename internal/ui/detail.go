@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// detailTimeFormat shows timestamps in full, with seconds and zone
+const detailTimeFormat = "Mon 2 Jan 2006 15:04:05 MST"
+
+// PostDetailModel represents the detail pane of a single post: everything
+// the feed leaves out, including its edit history and media descriptions
+type PostDetailModel struct {
+	userID          int
+	mastodonService *services.MastodonService
+	status          services.MastodonStatus
+	history         []services.MastodonStatusEdit
+	historyErr      error
+	loading         bool
+	scrollOffset    int
+	width           int
+	height          int
+}
+
+// statusHistoryLoadedMsg is sent when a post's edit history is fetched
+type statusHistoryLoadedMsg struct {
+	statusID string
+	history  []services.MastodonStatusEdit
+	err      error
+}
+
+// NewPostDetailModel creates a new detail pane for status
+func NewPostDetailModel(userID int, mastodonService *services.MastodonService, status services.MastodonStatus) PostDetailModel {
+	return PostDetailModel{
+		userID:          userID,
+		mastodonService: mastodonService,
+		status:          status,
+		loading:         status.EditedAt != nil,
+	}
+}
+
+// Init fetches the edit history of edited posts
+func (m PostDetailModel) Init() tea.Cmd {
+	if !m.loading {
+		return nil
+	}
+	svc, userID, statusID := m.mastodonService, m.userID, m.status.ID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		history, err := svc.GetStatusHistory(ctx, userID, statusID)
+		return statusHistoryLoadedMsg{statusID: statusID, history: history, err: err}
+	}
+}
+
+// Update handles messages for the detail pane
+func (m PostDetailModel) Update(msg tea.Msg) (PostDetailModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case statusHistoryLoadedMsg:
+		if msg.statusID != m.status.ID {
+			return m, nil
+		}
+		m.loading = false
+		m.history, m.historyErr = msg.history, msg.err
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.scrollOffset > 0 {
+				m.scrollOffset--
+			}
+		case "down", "j":
+			if m.scrollOffset < len(m.lines())-1 {
+				m.scrollOffset++
+			}
+		case "home", "g":
+			m.scrollOffset = 0
+		}
+	}
+	return m, nil
+}
+
+// View renders the part of the detail pane that fits the screen
+func (m PostDetailModel) View() string {
+	lines := m.lines()
+	visible := max(m.height-4, 5)
+	start := min(m.scrollOffset, max(len(lines)-1, 0))
+	end := min(start+visible, len(lines))
+
+	var b strings.Builder
+	b.WriteString(strings.Join(lines[start:end], "\n") + "\n\n")
+	b.WriteString(subtleStyle.Render("↑/↓") + " Scroll  " + keyStyle.Render("[Esc]") + " Back")
+	if end < len(lines) {
+		b.WriteString("  " + subtleStyle.Render(fmt.Sprintf("(%d more lines)", len(lines)-end)))
+	}
+	return b.String()
+}
+
+// lines renders the whole detail pane, one screen line each
+func (m PostDetailModel) lines() []string {
+	status := m.status
+	width := max(min(m.width, 100)-4, 20)
+	wrap := func(text string) []string {
+		return strings.Split(lipgloss.NewStyle().Width(width).Render(text), "\n")
+	}
+
+	name := status.Account.DisplayName
+	if name == "" {
+		name = status.Account.Username
+	}
+	lines := []string{
+		titleStyle.Render("Post details"),
+		"",
+		authorStyle.Render(name) + " " + handleStyle.Render("@"+status.Account.Acct),
+		"",
+	}
+	lines = append(lines, wrap(stripHTML(status.Content))...)
+	lines = append(lines, "")
+
+	field := func(label, value string) {
+		lines = append(lines, subtleStyle.Render(fmt.Sprintf("%-12s", label))+value)
+	}
+	field("Posted", status.CreatedAt.Format(detailTimeFormat))
+	if status.EditedAt != nil {
+		field("Edited", status.EditedAt.Format(detailTimeFormat))
+	}
+	field("Visibility", status.Visibility)
+	switch {
+	case status.Application == nil || status.Application.Name == "":
+		field("Application", "not disclosed")
+	case status.Application.Website != "":
+		field("Application", status.Application.Name+" ("+status.Application.Website+")")
+	default:
+		field("Application", status.Application.Name)
+	}
+	if status.SpoilerText != "" {
+		field("Warning", status.SpoilerText)
+	}
+	if status.GPGSigner != "" {
+		field("Signed", successStyle.Render("GPG key "+services.FormatGPGFingerprint(status.GPGSigner)))
+	}
+	field("Replies", fmt.Sprintf("%d", status.RepliesCount))
+	field("Boosts", fmt.Sprintf("%d", status.ReblogsCount))
+	field("Likes", fmt.Sprintf("%d", status.FavouritesCount))
+	if status.URL != "" {
+		field("URL", status.URL)
+	}
+
+	if len(status.MediaAttachments) > 0 {
+		lines = append(lines, "", titleStyle.Render(fmt.Sprintf("Media (%d)", len(status.MediaAttachments))))
+		lines = append(lines, mediaLines(status.MediaAttachments, wrap)...)
+	}
+
+	lines = append(lines, "", titleStyle.Render("Edit history"))
+	switch {
+	case status.EditedAt == nil:
+		lines = append(lines, subtleStyle.Render("Never edited"))
+	case m.loading:
+		lines = append(lines, subtleStyle.Render("Loading..."))
+	case m.historyErr != nil:
+		lines = append(lines, errorStyle.Render(fmt.Sprintf("Error: %v", m.historyErr)))
+	default:
+		// Newest revision first, as it is the one shown in the feed
+		for i := len(m.history) - 1; i >= 0; i-- {
+			edit := m.history[i]
+			label := fmt.Sprintf("Revision %d", i+1)
+			if i == 0 {
+				label = "Original"
+			}
+			lines = append(lines, "", handleStyle.Render(label+" · "+edit.CreatedAt.Format(detailTimeFormat)))
+			if edit.SpoilerText != "" {
+				lines = append(lines, wrap("CW: "+edit.SpoilerText)...)
+			}
+			lines = append(lines, wrap(stripHTML(edit.Content))...)
+			lines = append(lines, mediaLines(edit.MediaAttachments, wrap)...)
+		}
+	}
+	return lines
+}
+
+// mediaLines lists media attachments with their complete alt text
+func mediaLines(media []services.MastodonMedia, wrap func(string) []string) []string {
+	var lines []string
+	for i, attachment := range media {
+		lines = append(lines, fmt.Sprintf("[%d] %s %s", i+1, attachment.Type, subtleStyle.Render(attachment.URL)))
+		description := attachment.Description
+		if description == "" {
+			lines = append(lines, "    "+errorStyle.Render("No alt text"))
+			continue
+		}
+		for _, line := range wrap("Alt: " + description) {
+			lines = append(lines, "    "+line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,246 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// DigestModel lets the user opt into a daily or weekly email digest of
+// their new followers, mentions and top home posts
+type DigestModel struct {
+	ctx           context.Context
+	digest        *services.DigestService
+	userID        int
+	settings      *models.EmailDigest
+	editing       bool   // Whether the subscription form is open
+	emailInput    string // Address being entered
+	frequency     string // Frequency chosen in the form
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// digestLoadedMsg is sent when the user's digest settings are fetched
+type digestLoadedMsg struct {
+	settings *models.EmailDigest
+	err      error
+}
+
+// digestUpdatedMsg is sent when the user subscribed or unsubscribed
+type digestUpdatedMsg struct {
+	settings *models.EmailDigest
+	message  string
+	err      error
+}
+
+// NewDigestModel creates a new email digest model
+func NewDigestModel(digest *services.DigestService, userID int) DigestModel {
+	return DigestModel{
+		ctx:           context.Background(),
+		digest:        digest,
+		userID:        userID,
+		loading:       true,
+		statusMessage: "Loading...",
+	}
+}
+
+// Init fetches the user's digest settings
+func (m DigestModel) Init() tea.Cmd {
+	return m.loadCmd()
+}
+
+// Editing reports whether the subscription form is open, so that Esc
+// closes it rather than the screen
+func (m DigestModel) Editing() bool {
+	return m.editing
+}
+
+// Update handles messages for the digest view
+func (m DigestModel) Update(msg tea.Msg) (DigestModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case digestLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.settings = msg.settings
+		m.statusMessage = ""
+		return m, nil
+
+	case digestUpdatedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.settings = msg.settings
+		m.editing = false
+		m.statusMessage = msg.message
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.editing {
+			return m.handleFormInput(msg)
+		}
+		if m.loading || !m.digest.Enabled() {
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "s", "S":
+			m.editing = true
+			m.emailInput = ""
+			m.frequency = services.DigestWeekly
+			if m.settings != nil {
+				m.emailInput = m.settings.Email
+				m.frequency = m.settings.Frequency
+			}
+			m.statusMessage = ""
+		case "d", "D":
+			if m.settings != nil {
+				return m, m.disableCmd()
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// handleFormInput handles keys while the subscription form is open
+func (m DigestModel) handleFormInput(msg tea.KeyMsg) (DigestModel, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		if strings.TrimSpace(m.emailInput) == "" {
+			return m, nil
+		}
+		m.statusMessage = "Saving..."
+		return m, m.subscribeCmd(m.emailInput, m.frequency)
+	case "tab":
+		if m.frequency == services.DigestDaily {
+			m.frequency = services.DigestWeekly
+		} else {
+			m.frequency = services.DigestDaily
+		}
+	case "esc":
+		m.editing = false
+		m.statusMessage = ""
+	case "backspace":
+		if len(m.emailInput) > 0 {
+			m.emailInput = m.emailInput[:len(m.emailInput)-1]
+		}
+	default:
+		if len(msg.String()) == 1 && len(m.emailInput) < 254 {
+			m.emailInput += msg.String()
+		}
+	}
+	return m, nil
+}
+
+// View renders the digest view
+func (m DigestModel) View() string {
+	var b strings.Builder
+	width := 70
+
+	b.WriteString(centerText(titleStyle.Render("Email Digest"), width) + "\n\n")
+	b.WriteString(subtleStyle.Render("New followers, mentions and the top posts of your home timeline,") + "\n")
+	b.WriteString(subtleStyle.Render("by email once a day or once a week.") + "\n\n")
+
+	if m.loading {
+		b.WriteString(m.statusMessage + "\n")
+		return b.String()
+	}
+	if !m.digest.Enabled() {
+		b.WriteString("Email digests are not enabled on this instance.\n\n")
+		b.WriteString(keyStyle.Render("[Esc]") + " Back\n")
+		return b.String()
+	}
+
+	if m.editing {
+		b.WriteString("Email address:\n")
+		b.WriteString(promptStyle.Render("> "+m.emailInput+"█") + "\n\n")
+		daily, weekly := "( )", "( )"
+		if m.frequency == services.DigestDaily {
+			daily = "(•)"
+		} else {
+			weekly = "(•)"
+		}
+		b.WriteString(fmt.Sprintf("%s Daily   %s Weekly\n\n", daily, weekly))
+		b.WriteString(keyStyle.Render("[Enter]") + " Save  " + keyStyle.Render("[Tab]") + " Daily/weekly  " + keyStyle.Render("[Esc]") + " Cancel\n")
+	} else {
+		switch {
+		case m.settings == nil:
+			b.WriteString("Status: " + subtleStyle.Render("off") + "\n\n")
+			b.WriteString(keyStyle.Render("[S]") + " Subscribe  " + keyStyle.Render("[Esc]") + " Back\n")
+		default:
+			status := successStyle.Render(m.settings.Frequency)
+			if !m.settings.Confirmed {
+				status = subtleStyle.Render(m.settings.Frequency + ", waiting for you to confirm the emailed link")
+			}
+			b.WriteString("Address: " + m.settings.Email + "\n")
+			b.WriteString("Status:  " + status + "\n")
+			if m.settings.LastSentAt != nil {
+				b.WriteString("Last sent " + formatTimeAgo(*m.settings.LastSentAt) + "\n")
+			}
+			b.WriteString("\n" + keyStyle.Render("[S]") + " Change  " + keyStyle.Render("[D]") + " Unsubscribe  " + keyStyle.Render("[Esc]") + " Back\n")
+		}
+	}
+
+	if m.statusMessage != "" {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
+
+// loadCmd fetches the user's digest settings
+func (m DigestModel) loadCmd() tea.Cmd {
+	return func() tea.Msg {
+		settings, err := m.digest.Settings(m.ctx, m.userID)
+		return digestLoadedMsg{settings: settings, err: err}
+	}
+}
+
+// subscribeCmd saves the subscription, emailing a confirmation link to a
+// new address
+func (m DigestModel) subscribeCmd(email, frequency string) tea.Cmd {
+	return func() tea.Msg {
+		settings, err := m.digest.Subscribe(m.ctx, m.userID, email, frequency)
+		if err != nil {
+			return digestUpdatedMsg{err: err}
+		}
+		message := "Saved"
+		if !settings.Confirmed {
+			message = "Check your inbox for the confirmation link"
+		}
+		return digestUpdatedMsg{settings: settings, message: message}
+	}
+}
+
+// disableCmd unsubscribes the user
+func (m DigestModel) disableCmd() tea.Cmd {
+	return func() tea.Msg {
+		if err := m.digest.Disable(m.ctx, m.userID); err != nil {
+			return digestUpdatedMsg{err: err}
+		}
+		return digestUpdatedMsg{message: "Unsubscribed"}
+	}
+}
+
+// openDigest switches to the email digest screen
+func (m Model) openDigest() (Model, tea.Cmd) {
+	m.digest = NewDigestModel(services.NewDigestService(m.ctx.DB, m.ctx.Config), m.user.ID)
+	m.digest.width = m.width
+	m.digest.height = m.height
+	m = m.pushScreen(screenDigest)
+	return m, m.digest.Init()
+}
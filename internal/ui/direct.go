@@ -0,0 +1,334 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// DirectMessagesModel represents the native direct message screen: a list
+// of conversations, and the thread of the one opened
+type DirectMessagesModel struct {
+	userID        int
+	direct        *services.DirectMessageService
+	conversations []services.Conversation
+	selectedIndex int
+	recipient     textinput.Model // Account a new conversation is with
+	input         textinput.Model // Message being written
+	threadActorID string          // Conversation open, if any
+	threadAcct    string
+	messages      []models.DirectMessage
+	inThread      bool
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// conversationsLoadedMsg is sent when the conversation list is loaded
+type conversationsLoadedMsg struct {
+	conversations []services.Conversation
+	err           error
+}
+
+// directThreadLoadedMsg is sent when a conversation's messages are loaded
+type directThreadLoadedMsg struct {
+	actorID  string
+	messages []models.DirectMessage
+	err      error
+}
+
+// directSentMsg is sent when a direct message has been published
+type directSentMsg struct {
+	actorID string // Recipient, known once the message is sent
+	err     error
+}
+
+// NewDirectMessagesModel creates a new direct message screen model
+func NewDirectMessagesModel(userID int, direct *services.DirectMessageService) DirectMessagesModel {
+	recipient := textinput.New()
+	recipient.Prompt = "To: @"
+	recipient.Placeholder = "user@domain"
+	recipient.CharLimit = 255
+	recipient.Width = 40
+
+	input := textinput.New()
+	input.Placeholder = "Write a message"
+	input.CharLimit = 500
+	input.Width = 60
+
+	return DirectMessagesModel{userID: userID, direct: direct, recipient: recipient, input: input, loading: true}
+}
+
+// Init loads the conversation list
+func (m DirectMessagesModel) Init() tea.Cmd {
+	return m.loadConversationsCmd()
+}
+
+// Browsing reports whether the conversation list is shown with nothing being
+// typed, so Esc leaves the screen
+func (m DirectMessagesModel) Browsing() bool {
+	return !m.inThread && !m.recipient.Focused()
+}
+
+// Update handles messages for the direct message screen
+func (m DirectMessagesModel) Update(msg tea.Msg) (DirectMessagesModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case conversationsLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.conversations = msg.conversations
+		m.selectedIndex = min(m.selectedIndex, max(len(m.conversations)-1, 0))
+		m.statusMessage = ""
+		if len(m.conversations) == 0 {
+			m.statusMessage = "No direct messages yet"
+		}
+		return m, nil
+
+	case directThreadLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		if msg.actorID == m.threadActorID {
+			m.messages = msg.messages
+			m.statusMessage = ""
+		}
+		return m, nil
+
+	case directSentMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.input.SetValue("")
+		m.statusMessage = "Message sent"
+		if m.threadActorID == "" {
+			m.threadActorID = msg.actorID
+		}
+		return m, m.loadThreadCmd(m.threadActorID)
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+		if m.inThread {
+			return m.updateThread(msg)
+		}
+
+		if m.recipient.Focused() {
+			switch msg.String() {
+			case "enter":
+				acct := strings.TrimPrefix(strings.TrimSpace(m.recipient.Value()), "@")
+				if acct == "" {
+					return m, nil
+				}
+				m.recipient.Blur()
+				m.recipient.SetValue("")
+				return m, m.openThread("", acct)
+			case "esc":
+				m.recipient.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.recipient, cmd = m.recipient.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.selectedIndex > 0 {
+				m.selectedIndex--
+			}
+		case "down", "j":
+			if m.selectedIndex < len(m.conversations)-1 {
+				m.selectedIndex++
+			}
+		case "enter":
+			if m.selectedIndex < len(m.conversations) {
+				c := m.conversations[m.selectedIndex]
+				return m, m.openThread(c.ActorID, c.Acct)
+			}
+		case "n", "N":
+			m.recipient.Focus()
+			return m, textinput.Blink
+		case "r", "R":
+			m.loading = true
+			return m, m.loadConversationsCmd()
+		}
+	}
+
+	return m, nil
+}
+
+// updateThread handles keys while a conversation is open; typing goes to
+// the message field
+func (m DirectMessagesModel) updateThread(msg tea.KeyMsg) (DirectMessagesModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.inThread = false
+		m.threadActorID, m.threadAcct, m.messages = "", "", nil
+		m.input.Blur()
+		m.loading = true
+		return m, m.loadConversationsCmd()
+	case "enter":
+		text := strings.TrimSpace(m.input.Value())
+		if text == "" {
+			return m, nil
+		}
+		m.loading = true
+		m.statusMessage = "Sending..."
+		svc, userID, acct := m.direct, m.userID, m.threadAcct
+		return m, func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			post, err := svc.Send(ctx, userID, []string{acct}, text)
+			if err != nil {
+				return directSentMsg{err: err}
+			}
+			var actorID string
+			if len(post.Mentions) > 0 {
+				actorID = post.Mentions[0].ActorID
+			}
+			return directSentMsg{actorID: actorID}
+		}
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// openThread shows a conversation; actorID is empty for a new one
+func (m *DirectMessagesModel) openThread(actorID, acct string) tea.Cmd {
+	m.inThread = true
+	m.threadActorID, m.threadAcct, m.messages = actorID, acct, nil
+	m.statusMessage = ""
+	m.input.Focus()
+	if actorID == "" {
+		return textinput.Blink
+	}
+	return tea.Batch(textinput.Blink, m.loadThreadCmd(actorID))
+}
+
+// loadConversationsCmd loads the conversation list
+func (m DirectMessagesModel) loadConversationsCmd() tea.Cmd {
+	svc, userID := m.direct, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conversations, err := svc.Conversations(ctx, userID)
+		return conversationsLoadedMsg{conversations: conversations, err: err}
+	}
+}
+
+// loadThreadCmd loads the messages of a conversation
+func (m *DirectMessagesModel) loadThreadCmd(actorID string) tea.Cmd {
+	m.loading = true
+	svc, userID := m.direct, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		messages, err := svc.Thread(ctx, userID, actorID)
+		return directThreadLoadedMsg{actorID: actorID, messages: messages, err: err}
+	}
+}
+
+// View renders the direct message screen
+func (m DirectMessagesModel) View() string {
+	var b strings.Builder
+
+	if m.inThread {
+		b.WriteString(titleStyle.Render("Conversation with @"+m.threadAcct) + "\n\n")
+		b.WriteString(m.threadView())
+		b.WriteString("\n" + m.input.View() + "\n\n")
+		b.WriteString(keyStyle.Render("[Enter]") + " Send  " +
+			keyStyle.Render("[Esc]") + " Back to conversations\n")
+	} else {
+		b.WriteString(titleStyle.Render("Direct messages") + "\n\n")
+		if m.recipient.Focused() {
+			b.WriteString(m.recipient.View() + "\n\n")
+		}
+
+		// Each conversation takes two lines plus a blank one
+		visible := max((m.height-10)/3, 1)
+		start := 0
+		if m.selectedIndex >= visible {
+			start = m.selectedIndex - visible + 1
+		}
+		end := min(start+visible, len(m.conversations))
+
+		for i := start; i < end; i++ {
+			c := m.conversations[i]
+
+			selector := "  "
+			if i == m.selectedIndex {
+				selector = promptStyle.Render("► ")
+			}
+			line := selector + titleStyle.Render("@"+c.Acct) + " " + subtleStyle.Render(formatTimeAgo(c.LastAt))
+			if c.Unread > 0 {
+				line += " " + successStyle.Render(fmt.Sprintf("(%d new)", c.Unread))
+			}
+			b.WriteString(line + "\n")
+			b.WriteString("    " + truncate(stripHTML(c.LastMessage), 70) + "\n\n")
+		}
+
+		if m.recipient.Focused() {
+			b.WriteString(keyStyle.Render("[Enter]") + " Start conversation  " +
+				keyStyle.Render("[Esc]") + " Cancel\n")
+		} else {
+			b.WriteString(keyStyle.Render("[Enter]") + " Open  " +
+				keyStyle.Render("[N]") + " New message  " +
+				keyStyle.Render("[R]") + " Refresh  " +
+				keyStyle.Render("[Esc]") + " Back\n")
+		}
+	}
+
+	if m.statusMessage != "" {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
+
+// threadView renders the latest messages of the open conversation that fit
+// the screen, oldest at the top
+func (m DirectMessagesModel) threadView() string {
+	width := max(min(m.width, 100)-8, 20)
+
+	var lines []string
+	for _, message := range m.messages {
+		author := "@" + m.threadAcct
+		if message.Outbound() {
+			author = "You"
+		}
+		lines = append(lines, titleStyle.Render(author)+" "+subtleStyle.Render(formatTimeAgo(message.PublishedAt)))
+		for _, line := range wrapText(stripHTML(message.Content), width) {
+			lines = append(lines, "  "+line)
+		}
+		lines = append(lines, "")
+	}
+	if len(lines) == 0 {
+		return subtleStyle.Render("No messages yet") + "\n"
+	}
+
+	visible := max(m.height-12, 3)
+	if len(lines) > visible {
+		lines = lines[len(lines)-visible:]
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,226 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// DirectoryModel represents the screen listing the local users who chose
+// to be in the profile directory
+type DirectoryModel struct {
+	directory     *services.DirectoryService
+	userID        int
+	entries       []models.DirectoryEntry
+	selected      int
+	more          bool // The last page was full, so there may be more
+	discoverable  bool // Whether the user is listed
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// directoryLoadedMsg is sent when a page of the directory has been fetched
+type directoryLoadedMsg struct {
+	entries      []models.DirectoryEntry
+	offset       int
+	discoverable bool
+	err          error
+}
+
+// discoverableMsg reports the outcome of changing whether the user is listed
+type discoverableMsg struct {
+	discoverable bool
+	err          error
+}
+
+// NewDirectoryModel creates a new directory model
+func NewDirectoryModel(directory *services.DirectoryService, userID int) DirectoryModel {
+	return DirectoryModel{
+		directory:     directory,
+		userID:        userID,
+		loading:       true,
+		statusMessage: "Loading...",
+	}
+}
+
+// Init fetches the first page of the directory
+func (m DirectoryModel) Init() tea.Cmd {
+	return m.fetchCmd(0)
+}
+
+// Selected returns the selected user, or nil when the list is empty
+func (m DirectoryModel) Selected() *models.DirectoryEntry {
+	if m.selected >= 0 && m.selected < len(m.entries) {
+		return &m.entries[m.selected]
+	}
+	return nil
+}
+
+// Update handles messages for the directory screen. Enter is left to the
+// caller, which opens the selected profile.
+func (m DirectoryModel) Update(msg tea.Msg) (DirectoryModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case directoryLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		if msg.offset == 0 {
+			m.entries = nil
+			m.selected = 0
+		}
+		m.entries = append(m.entries, msg.entries...)
+		m.more = len(msg.entries) == services.MaxDirectoryPage
+		m.discoverable = msg.discoverable
+		m.statusMessage = ""
+		return m, nil
+
+	case discoverableMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.discoverable = msg.discoverable
+		m.statusMessage = "You are no longer listed"
+		if msg.discoverable {
+			m.statusMessage = "You are now listed in the directory"
+		}
+		m.loading = true
+		return m, m.fetchCmd(0)
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+		switch msg.String() {
+		case "up", "k":
+			m.selected = max(m.selected-1, 0)
+		case "down", "j":
+			if m.selected < len(m.entries)-1 {
+				m.selected++
+			} else if m.more {
+				// Reaching the end loads the next page
+				m.loading = true
+				return m, m.fetchCmd(len(m.entries))
+			}
+		case "v", "V":
+			return m, m.setDiscoverableCmd(!m.discoverable)
+		case "ctrl+r":
+			m.loading = true
+			return m, m.fetchCmd(0)
+		}
+	}
+
+	return m, nil
+}
+
+// fetchCmd loads a page of the directory and the user's setting
+func (m DirectoryModel) fetchCmd(offset int) tea.Cmd {
+	directory, userID := m.directory, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		entries, err := directory.List(ctx, services.MaxDirectoryPage, offset)
+		if err != nil {
+			return directoryLoadedMsg{err: err}
+		}
+		discoverable, err := directory.Discoverable(ctx, userID)
+		return directoryLoadedMsg{entries: entries, offset: offset, discoverable: discoverable, err: err}
+	}
+}
+
+// setDiscoverableCmd chooses whether the user is listed
+func (m DirectoryModel) setDiscoverableCmd(discoverable bool) tea.Cmd {
+	directory, userID := m.directory, m.userID
+	return func() tea.Msg {
+		err := directory.SetDiscoverable(context.Background(), userID, discoverable)
+		return discoverableMsg{discoverable: discoverable, err: err}
+	}
+}
+
+// View renders the directory screen
+func (m DirectoryModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Directory") + "\n")
+	b.WriteString(subtleStyle.Render("People on this instance, most recently active first") + "\n\n")
+	if m.loading && len(m.entries) == 0 {
+		b.WriteString(subtleStyle.Render(m.statusMessage) + "\n")
+		return b.String()
+	}
+
+	if len(m.entries) == 0 {
+		b.WriteString("Nobody chose to be listed yet.\n")
+	}
+	// Each user takes up to two lines; the list scrolls to keep the selection
+	// shown
+	visible := max((m.height-12)/2, 3)
+	start := max(min(m.selected-visible/2, len(m.entries)-visible), 0)
+	end := min(start+visible, len(m.entries))
+	width := max(min(m.width, 100)-4, 20)
+	for i := start; i < end; i++ {
+		entry := m.entries[i]
+		name := entry.DisplayName
+		if name == "" {
+			name = entry.Username
+		}
+		active := "never active"
+		if entry.LastActiveAt != nil {
+			active = "active " + formatAge(time.Since(*entry.LastActiveAt))
+		}
+		line := fmt.Sprintf("%s @%s", name, entry.Username)
+		meta := fmt.Sprintf("  %d posts · %s", entry.PostsCount, active)
+		if i == m.selected {
+			b.WriteString(promptStyle.Render("► "+line) + subtleStyle.Render(meta) + "\n")
+		} else {
+			b.WriteString("  " + line + subtleStyle.Render(meta) + "\n")
+		}
+		if bio := strings.Join(strings.Fields(entry.Bio), " "); bio != "" {
+			b.WriteString("    " + subtleStyle.Render(fitLine(bio, width)) + "\n")
+		}
+	}
+	if m.more {
+		b.WriteString(subtleStyle.Render("  ...more below") + "\n")
+	}
+
+	listed := "You are not listed"
+	toggle := "List me"
+	if m.discoverable {
+		listed = "You are listed"
+		toggle = "Hide me"
+	}
+	b.WriteString("\n" + subtleStyle.Render(listed) + "\n")
+	b.WriteString(keyStyle.Render("[↑/↓]") + " Select  " +
+		keyStyle.Render("[Enter]") + " Open profile  " +
+		keyStyle.Render("[V]") + " " + toggle + "  " +
+		keyStyle.Render("[Ctrl+R]") + " Refresh  " +
+		keyStyle.Render("[Esc]") + " Back\n")
+
+	if m.statusMessage != "" && !(m.loading && len(m.entries) == 0) {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
+
+// openDirectory shows the directory of this instance's users
+func (m Model) openDirectory() (Model, tea.Cmd) {
+	m.directory = NewDirectoryModel(services.NewDirectoryService(m.ctx.DB, m.ctx.Config), m.user.ID)
+	m.directory.width = m.width
+	m.directory.height = m.height
+	m = m.pushScreen(screenDirectory)
+	return m, m.directory.Init()
+}
@@ -0,0 +1,246 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// DiscoverModel represents the Discover screen listing suggested follows
+type DiscoverModel struct {
+	userID          int
+	mastodonService *services.MastodonService
+	actionLog       *services.ActionLogService
+	suggestions     []services.MastodonSuggestion
+	followed        map[string]bool // Accounts followed from this screen
+	selectedIndex   int
+	loading         bool
+	statusMessage   string
+	width           int
+	height          int
+}
+
+// suggestionsLoadedMsg is sent when suggestions are fetched
+type suggestionsLoadedMsg struct {
+	suggestions []services.MastodonSuggestion
+	err         error
+}
+
+// suggestionFollowedMsg is sent when a suggested account has been followed
+type suggestionFollowedMsg struct {
+	accountID string
+	acct      string
+	err       error
+}
+
+// suggestionDismissedMsg is sent when a suggestion has been dismissed
+type suggestionDismissedMsg struct {
+	accountID string
+	err       error
+}
+
+// NewDiscoverModel creates a new Discover screen model
+func NewDiscoverModel(userID int, mastodonService *services.MastodonService, actionLog *services.ActionLogService) DiscoverModel {
+	return DiscoverModel{
+		userID:          userID,
+		mastodonService: mastodonService,
+		actionLog:       actionLog,
+		followed:        make(map[string]bool),
+		loading:         true,
+		statusMessage:   "Loading suggestions...",
+	}
+}
+
+// Init fetches the suggestions
+func (m DiscoverModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// SelectedAccount returns the account of the selected suggestion
+func (m DiscoverModel) SelectedAccount() *services.MastodonAccount {
+	if m.selectedIndex >= 0 && m.selectedIndex < len(m.suggestions) {
+		return &m.suggestions[m.selectedIndex].Account
+	}
+	return nil
+}
+
+// Update handles messages for the Discover screen
+func (m DiscoverModel) Update(msg tea.Msg) (DiscoverModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case suggestionsLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.suggestions = msg.suggestions
+		m.selectedIndex = 0
+		m.statusMessage = ""
+		return m, nil
+
+	case suggestionFollowedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.followed[msg.accountID] = true
+		m.statusMessage = "Following @" + msg.acct
+		return m, nil
+
+	case suggestionDismissedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		for i, suggestion := range m.suggestions {
+			if suggestion.Account.ID == msg.accountID {
+				m.suggestions = append(m.suggestions[:i], m.suggestions[i+1:]...)
+				break
+			}
+		}
+		if m.selectedIndex >= len(m.suggestions) && m.selectedIndex > 0 {
+			m.selectedIndex--
+		}
+		m.statusMessage = "Suggestion dismissed"
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.selectedIndex > 0 {
+				m.selectedIndex--
+			}
+		case "down", "j":
+			if m.selectedIndex < len(m.suggestions)-1 {
+				m.selectedIndex++
+			}
+		case "r", "R":
+			m.loading = true
+			m.statusMessage = "Loading suggestions..."
+			return m, m.fetchCmd()
+		case "f", "F":
+			if account := m.SelectedAccount(); account != nil && !m.followed[account.ID] {
+				m.statusMessage = "Following..."
+				return m, m.followCmd(account.ID, account.Acct)
+			}
+		case "x", "X":
+			if account := m.SelectedAccount(); account != nil {
+				return m, m.dismissCmd(account.ID)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// fetchCmd loads suggestions from the user's instance
+func (m DiscoverModel) fetchCmd() tea.Cmd {
+	svc, userID := m.mastodonService, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		suggestions, err := svc.GetSuggestions(ctx, userID, 40)
+		return suggestionsLoadedMsg{suggestions: suggestions, err: err}
+	}
+}
+
+// followCmd follows a suggested account
+func (m DiscoverModel) followCmd(accountID, acct string) tea.Cmd {
+	svc, actionLog, userID := m.mastodonService, m.actionLog, m.userID
+	return func() tea.Msg {
+		err := svc.FollowAccount(context.Background(), userID, accountID)
+		if err == nil {
+			recordAction(actionLog, userID, models.ActionFollow, accountID, "@"+acct)
+		}
+		return suggestionFollowedMsg{accountID: accountID, acct: acct, err: err}
+	}
+}
+
+// dismissCmd removes an account from the user's suggestions
+func (m DiscoverModel) dismissCmd(accountID string) tea.Cmd {
+	svc, userID := m.mastodonService, m.userID
+	return func() tea.Msg {
+		err := svc.DismissSuggestion(context.Background(), userID, accountID)
+		return suggestionDismissedMsg{accountID: accountID, err: err}
+	}
+}
+
+// View renders the Discover screen
+func (m DiscoverModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Discover people") + "\n\n")
+
+	if m.loading {
+		b.WriteString(subtleStyle.Render(m.statusMessage) + "\n")
+		return b.String()
+	}
+
+	if len(m.suggestions) == 0 {
+		b.WriteString("No suggestions right now. Follow a few accounts and check back later.\n\n")
+	}
+
+	// Each suggestion takes three lines plus a blank one
+	visible := max((m.height-8)/4, 1)
+	start := 0
+	if m.selectedIndex >= visible {
+		start = m.selectedIndex - visible + 1
+	}
+	end := min(start+visible, len(m.suggestions))
+
+	for i := start; i < end; i++ {
+		suggestion := m.suggestions[i]
+		account := suggestion.Account
+
+		selector := "  "
+		if i == m.selectedIndex {
+			selector = promptStyle.Render("► ")
+		}
+
+		name := account.DisplayName
+		if name == "" {
+			name = account.Username
+		}
+		header := selector + titleStyle.Render(name) + " " + subtleStyle.Render("@"+account.Acct)
+		if m.followed[account.ID] {
+			header += " " + successStyle.Render("[Following]")
+		}
+		b.WriteString(header + "\n")
+
+		bio := truncate(stripHTML(account.Note), 70)
+		if bio == "" {
+			bio = fmt.Sprintf("%d followers", account.FollowersCount)
+		}
+		b.WriteString("    " + bio + "\n")
+
+		if reasons := suggestion.Reasons(); len(reasons) > 0 {
+			b.WriteString("    " + subtleStyle.Render(strings.Join(reasons, " · ")) + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(keyStyle.Render("[F]") + " Follow  " +
+		keyStyle.Render("[X]") + " Dismiss  " +
+		keyStyle.Render("[P]") + " Profile  " +
+		keyStyle.Render("[R]") + " Refresh  " +
+		keyStyle.Render("[Esc]") + " Back\n")
+
+	if m.statusMessage != "" {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,396 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// FakeMastodon is an in-memory Mastodon account for tests and demo mode. It
+// implements TimelineFetcher, StatusActions and Notifier for every user, who
+// all act as Self. It is safe for concurrent use.
+type FakeMastodon struct {
+	Self services.MastodonAccount
+
+	mu            sync.Mutex
+	accounts      map[string]services.MastodonAccount
+	statuses      []services.MastodonStatus       // Newest first
+	notifications []services.MastodonNotification // Newest first
+	following     map[string]bool
+	muted         map[string]bool
+	lastID        int64
+}
+
+var (
+	_ TimelineFetcher = (*FakeMastodon)(nil)
+	_ StatusActions   = (*FakeMastodon)(nil)
+	_ Notifier        = (*FakeMastodon)(nil)
+)
+
+// NewFakeMastodon returns an instance with only the self account on it
+func NewFakeMastodon(self services.MastodonAccount) *FakeMastodon {
+	return &FakeMastodon{
+		Self:      self,
+		accounts:  map[string]services.MastodonAccount{self.ID: self},
+		following: map[string]bool{},
+		muted:     map[string]bool{},
+	}
+}
+
+// AddAccount adds or replaces an account
+func (f *FakeMastodon) AddAccount(account services.MastodonAccount) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.accounts[account.ID] = account
+}
+
+// Follow makes Self follow an account, so its posts reach the home timeline
+func (f *FakeMastodon) Follow(accountID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.following[accountID] = true
+}
+
+// AddStatus adds a status, assigning an ID newer than any other when it has
+// none. Its author is added as an account if unknown.
+func (f *FakeMastodon) AddStatus(status services.MastodonStatus) services.MastodonStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if status.ID == "" {
+		status.ID = f.nextID()
+	} else {
+		f.seeID(status.ID)
+	}
+	if status.CreatedAt.IsZero() {
+		status.CreatedAt = time.Now()
+	}
+	if _, ok := f.accounts[status.Account.ID]; !ok {
+		f.accounts[status.Account.ID] = status.Account
+	}
+	f.statuses = append(f.statuses, status)
+	slices.SortStableFunc(f.statuses, func(a, b services.MastodonStatus) int {
+		return compareIDs(b.ID, a.ID)
+	})
+	return status
+}
+
+// AddNotification adds a notification, assigning an ID when it has none
+func (f *FakeMastodon) AddNotification(notification services.MastodonNotification) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if notification.ID == "" {
+		notification.ID = f.nextID()
+	} else {
+		f.seeID(notification.ID)
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+	f.notifications = append(f.notifications, notification)
+	slices.SortStableFunc(f.notifications, func(a, b services.MastodonNotification) int {
+		return compareIDs(b.ID, a.ID)
+	})
+}
+
+// nextID returns an ID newer than every one handed out or seen
+func (f *FakeMastodon) nextID() string {
+	f.lastID++
+	return strconv.FormatInt(f.lastID, 10)
+}
+
+// seeID keeps generated IDs newer than id
+func (f *FakeMastodon) seeID(id string) {
+	if n, err := strconv.ParseInt(id, 10, 64); err == nil && n > f.lastID {
+		f.lastID = n
+	}
+}
+
+// compareIDs orders status IDs oldest first
+func compareIDs(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case services.StatusIDNewer(a, b):
+		return 1
+	}
+	return -1
+}
+
+// status returns the index of a status, or an error if there is none
+func (f *FakeMastodon) status(statusID string) (int, error) {
+	i := slices.IndexFunc(f.statuses, func(s services.MastodonStatus) bool { return s.ID == statusID })
+	if i < 0 {
+		return 0, fmt.Errorf("status %s not found", statusID)
+	}
+	return i, nil
+}
+
+// page returns up to limit statuses matching keep and older than maxID
+func (f *FakeMastodon) page(limit int, maxID string, keep func(services.MastodonStatus) bool) []services.MastodonStatus {
+	var page []services.MastodonStatus
+	for _, status := range f.statuses {
+		if len(page) == limit {
+			break
+		}
+		if maxID != "" && !services.StatusIDNewer(maxID, status.ID) {
+			continue
+		}
+		if f.muted[status.Account.ID] || !keep(status) {
+			continue
+		}
+		page = append(page, status)
+	}
+	return page
+}
+
+// GetTimeline returns a page of a timeline. Home has Self's posts and those
+// of followed accounts, hashtag timelines the posts tagged with it, and
+// every other timeline all public posts.
+func (f *FakeMastodon) GetTimeline(ctx context.Context, userID int, timelineType services.TimelineType, limit int, maxID string) ([]services.MastodonStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if timelineType == services.TimelineHome {
+		return f.page(limit, maxID, func(s services.MastodonStatus) bool {
+			return s.Account.ID == f.Self.ID || f.following[s.Account.ID]
+		}), nil
+	}
+	if tag, ok := timelineType.Tag(); ok {
+		return f.page(limit, maxID, func(s services.MastodonStatus) bool {
+			return slices.ContainsFunc(s.Tags, func(t services.MastodonTag) bool { return t.Name == tag })
+		}), nil
+	}
+	return f.page(limit, maxID, func(s services.MastodonStatus) bool { return s.Visibility == "public" }), nil
+}
+
+// GetStatusContext returns the replies a status answers and those answering
+// it, oldest first
+func (f *FakeMastodon) GetStatusContext(ctx context.Context, userID int, statusID string) (*services.StatusContext, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, err := f.status(statusID)
+	if err != nil {
+		return nil, err
+	}
+
+	thread := &services.StatusContext{}
+	for parent := f.statuses[i].InReplyToID; parent != nil; {
+		j, err := f.status(*parent)
+		if err != nil {
+			break
+		}
+		thread.Ancestors = append([]services.MastodonStatus{f.statuses[j]}, thread.Ancestors...)
+		parent = f.statuses[j].InReplyToID
+	}
+
+	inThread := map[string]bool{statusID: true}
+	for j := len(f.statuses) - 1; j >= 0; j-- {
+		status := f.statuses[j]
+		if status.InReplyToID != nil && inThread[*status.InReplyToID] {
+			inThread[status.ID] = true
+			thread.Descendants = append(thread.Descendants, status)
+		}
+	}
+	return thread, nil
+}
+
+// GetAccount returns an account by ID
+func (f *FakeMastodon) GetAccount(ctx context.Context, userID int, accountID string) (*services.MastodonAccount, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	account, ok := f.accounts[accountID]
+	if !ok {
+		return nil, fmt.Errorf("account %s not found", accountID)
+	}
+	return &account, nil
+}
+
+// GetAccountStatuses returns an account's newest statuses
+func (f *FakeMastodon) GetAccountStatuses(ctx context.Context, userID int, accountID string, limit int) ([]services.MastodonStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.page(limit, "", func(s services.MastodonStatus) bool { return s.Account.ID == accountID }), nil
+}
+
+// GetPinnedStatuses returns the statuses an account pinned
+func (f *FakeMastodon) GetPinnedStatuses(ctx context.Context, userID int, accountID string) ([]services.MastodonStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.page(len(f.statuses), "", func(s services.MastodonStatus) bool { return s.Account.ID == accountID && s.Pinned }), nil
+}
+
+// GetAccountRelationship reports whether Self follows or muted an account
+func (f *FakeMastodon) GetAccountRelationship(ctx context.Context, userID int, accountID string) (*services.AccountRelationship, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &services.AccountRelationship{ID: accountID, Following: f.following[accountID], Muting: f.muted[accountID]}, nil
+}
+
+// PostStatus publishes a status as Self
+func (f *FakeMastodon) PostStatus(ctx context.Context, userID int, content, visibility, inReplyToID, contentWarning string) (string, error) {
+	f.mu.Lock()
+	status := services.MastodonStatus{
+		ID:          f.nextID(),
+		CreatedAt:   time.Now(),
+		Content:     "<p>" + html.EscapeString(content) + "</p>",
+		Visibility:  visibility,
+		Sensitive:   contentWarning != "",
+		SpoilerText: contentWarning,
+		Account:     f.Self,
+	}
+	if inReplyToID != "" {
+		i, err := f.status(inReplyToID)
+		if err != nil {
+			f.mu.Unlock()
+			return "", err
+		}
+		f.statuses[i].RepliesCount++
+		parentAccountID := f.statuses[i].Account.ID
+		status.InReplyToID = &inReplyToID
+		status.InReplyToAccountID = &parentAccountID
+	}
+	f.mu.Unlock()
+	return f.AddStatus(status).ID, nil
+}
+
+// update applies change to a status
+func (f *FakeMastodon) update(statusID string, change func(*services.MastodonStatus)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, err := f.status(statusID)
+	if err != nil {
+		return err
+	}
+	change(&f.statuses[i])
+	return nil
+}
+
+// FavouriteStatus likes a status
+func (f *FakeMastodon) FavouriteStatus(ctx context.Context, userID int, statusID string) error {
+	return f.update(statusID, func(s *services.MastodonStatus) {
+		if !s.Favourited {
+			s.Favourited = true
+			s.FavouritesCount++
+		}
+	})
+}
+
+// UnfavouriteStatus removes a like from a status
+func (f *FakeMastodon) UnfavouriteStatus(ctx context.Context, userID int, statusID string) error {
+	return f.update(statusID, func(s *services.MastodonStatus) {
+		if s.Favourited {
+			s.Favourited = false
+			s.FavouritesCount--
+		}
+	})
+}
+
+// BoostStatus boosts a status; the visibility of the boost is not kept
+func (f *FakeMastodon) BoostStatus(ctx context.Context, userID int, statusID, visibility string) error {
+	return f.update(statusID, func(s *services.MastodonStatus) {
+		if !s.Reblogged {
+			s.Reblogged = true
+			s.ReblogsCount++
+		}
+	})
+}
+
+// UnreblogStatus removes a boost of a status
+func (f *FakeMastodon) UnreblogStatus(ctx context.Context, userID int, statusID string) error {
+	return f.update(statusID, func(s *services.MastodonStatus) {
+		if s.Reblogged {
+			s.Reblogged = false
+			s.ReblogsCount--
+		}
+	})
+}
+
+// BookmarkStatus bookmarks a status
+func (f *FakeMastodon) BookmarkStatus(ctx context.Context, userID int, statusID string) error {
+	return f.update(statusID, func(s *services.MastodonStatus) { s.Bookmarked = true })
+}
+
+// PinStatus pins one of Self's statuses
+func (f *FakeMastodon) PinStatus(ctx context.Context, userID int, statusID string) error {
+	return f.update(statusID, func(s *services.MastodonStatus) { s.Pinned = true })
+}
+
+// UnpinStatus unpins one of Self's statuses
+func (f *FakeMastodon) UnpinStatus(ctx context.Context, userID int, statusID string) error {
+	return f.update(statusID, func(s *services.MastodonStatus) { s.Pinned = false })
+}
+
+// FollowAccount follows an account
+func (f *FakeMastodon) FollowAccount(ctx context.Context, userID int, accountID string) error {
+	f.Follow(accountID)
+	return nil
+}
+
+// UnfollowAccount unfollows an account
+func (f *FakeMastodon) UnfollowAccount(ctx context.Context, userID int, accountID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.following, accountID)
+	return nil
+}
+
+// MuteAccount hides an account's statuses from every timeline
+func (f *FakeMastodon) MuteAccount(ctx context.Context, userID int, accountID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.muted[accountID] = true
+	return nil
+}
+
+// GetNotifications returns a page of notifications older than maxID
+func (f *FakeMastodon) GetNotifications(ctx context.Context, userID int, limit int, maxID string) ([]services.MastodonNotification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var page []services.MastodonNotification
+	for _, notification := range f.notifications {
+		if len(page) == limit {
+			break
+		}
+		if maxID == "" || services.StatusIDNewer(maxID, notification.ID) {
+			page = append(page, notification)
+		}
+	}
+	return page, nil
+}
+
+// GetMentionsSince returns mentions newer than sinceID, newest first
+func (f *FakeMastodon) GetMentionsSince(ctx context.Context, userID int, sinceID string, limit int) ([]services.MastodonNotification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var mentions []services.MastodonNotification
+	for _, notification := range f.notifications {
+		if len(mentions) == limit {
+			break
+		}
+		if notification.Type == services.NotificationMention && (sinceID == "" || services.StatusIDNewer(notification.ID, sinceID)) {
+			mentions = append(mentions, notification)
+		}
+	}
+	return mentions, nil
+}
+
+// DismissNotification removes a notification
+func (f *FakeMastodon) DismissNotification(ctx context.Context, userID int, notificationID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.notifications = slices.DeleteFunc(f.notifications, func(n services.MastodonNotification) bool { return n.ID == notificationID })
+	return nil
+}
+
+// ClearAllNotifications removes every notification
+func (f *FakeMastodon) ClearAllNotifications(ctx context.Context, userID int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.notifications = nil
+	return nil
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// newFakeContext returns an AppContext served entirely by a FakeMastodon
+func newFakeContext() (*AppContext, *FakeMastodon) {
+	fake := NewFakeMastodon(fixtureAccount("1", "alice", "Alice"))
+	return &AppContext{Config: config.DefaultConfig(), Timelines: fake, Actions: fake, Notifications: fake}, fake
+}
+
+func TestFeedLoadsFromInjectedTimeline(t *testing.T) {
+	ctx, fake := newFakeContext()
+	for _, status := range fixtureStatuses() {
+		fake.AddStatus(status)
+	}
+	fake.Follow("2")
+
+	msg, ok := fetchTimelineCmd(ctx, 1, services.TimelineHome, 20)().(timelineMsg)
+	if !ok || msg.err != nil {
+		t.Fatalf("expected a timeline, got %+v", msg)
+	}
+	// Carol is not followed
+	if len(msg.statuses) != 2 || msg.statuses[0].ID != "103" || msg.statuses[1].ID != "101" {
+		t.Fatalf("expected bob's two posts, newest first, got %+v", msg.statuses)
+	}
+
+	more, _ := loadMorePostsCmd(ctx, 1, services.TimelineFederated, 20, "103")().(timelineMsg)
+	if len(more.statuses) != 1 || more.statuses[0].ID != "101" {
+		t.Fatalf("expected the public posts older than 103, got %+v", more.statuses)
+	}
+}
+
+func TestActionsUseInjectedServices(t *testing.T) {
+	ctx, fake := newFakeContext()
+	status := fake.AddStatus(fixtureStatuses()[0])
+
+	if msg := likeStatusCmd(ctx, 1, status)().(likeMsg); msg.err != nil {
+		t.Fatalf("likeStatusCmd: %v", msg.err)
+	}
+	if msg := boostStatusCmd(ctx, 1, status, "public")().(boostMsg); msg.err != nil {
+		t.Fatalf("boostStatusCmd: %v", msg.err)
+	}
+	reply := executePostStatusCmd(ctx, ctx.statusActions(), 1, "@bob congrats", "public", status.ID, "")().(postStatusResultMsg)
+	if reply.err != nil {
+		t.Fatalf("executePostStatusCmd: %v", reply.err)
+	}
+
+	thread, err := fake.GetStatusContext(context.Background(), 1, status.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thread.Descendants) != 1 || thread.Descendants[0].ID != reply.statusID {
+		t.Fatalf("expected the reply in the thread, got %+v", thread.Descendants)
+	}
+	got := thread.Descendants[0]
+	if got.Account.Username != "alice" || *got.InReplyToAccountID != "2" {
+		t.Errorf("expected alice replying to bob, got %+v", got)
+	}
+
+	statuses, _ := fake.GetAccountStatuses(context.Background(), 1, "2", 20)
+	if len(statuses) != 1 || !statuses[0].Favourited || statuses[0].FavouritesCount != 13 || !statuses[0].Reblogged || statuses[0].RepliesCount != 3 {
+		t.Errorf("expected bob's post liked, boosted and replied to, got %+v", statuses)
+	}
+}
+
+func TestNotificationsFromInjectedNotifier(t *testing.T) {
+	ctx, fake := newFakeContext()
+	for _, notification := range fixtureNotifications() {
+		fake.AddNotification(notification)
+	}
+
+	m := NewNotificationsModel(context.Background(), 1, ctx.notifier(), nil)
+	msg, ok := m.Init()().(notificationsLoadedMsg)
+	if !ok || msg.err != nil || len(msg.notifications) != 4 {
+		t.Fatalf("expected four notifications, got %+v", msg)
+	}
+
+	mentions, _ := checkNewActivityCmd(ctx.notifier(), 1, "1")().(newActivityMsg)
+	if len(mentions.mentions) != 1 || mentions.mentions[0].ID != "4" {
+		t.Fatalf("expected the one mention, got %+v", mentions.mentions)
+	}
+
+	if err := fake.DismissNotification(context.Background(), 1, "4"); err != nil {
+		t.Fatal(err)
+	}
+	if left, _ := fake.GetNotifications(context.Background(), 1, 20, ""); len(left) != 3 {
+		t.Errorf("expected three notifications left, got %d", len(left))
+	}
+}
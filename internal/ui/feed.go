@@ -2,28 +2,40 @@ package ui
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"html"
-	"regexp"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/fulgidus/terminalpub/internal/models"
 	"github.com/fulgidus/terminalpub/internal/services"
 )
 
 // FeedModel represents the feed view state
 type FeedModel struct {
-	statuses       []services.MastodonStatus
-	selectedIndex  int
-	scrollOffset   int
-	timelineType   services.TimelineType
-	loading        bool
-	loadingMore    bool
-	err            error
-	viewportHeight int
-	statusMessage  string
-	hasMore        bool
+	statuses         []services.MastodonStatus
+	selectedIndex    int
+	scrollOffset     int
+	timelineType     services.TimelineType
+	loading          bool
+	loadingMore      bool
+	err              error
+	viewportHeight   int
+	statusMessage    string
+	hasMore          bool
+	offline          bool                                   // Showing the cached timeline because Mastodon is unreachable
+	cachedAt         time.Time                              // When the cached timeline was fetched
+	lastReadID       string                                 // Newest home timeline post the user has looked at
+	savedReadID      string                                 // Read marker last stored locally and on the server
+	reactions        map[string][]services.MastodonReaction // Reactions stored on this server, by status URI
+	nav              Navigator                              // Movement keys and the post filter
+	filter           *feedFilter                            // Saved filter applied to the posts, if any
+	pinKey           string                                 // "m" or "'" while waiting for a pin slot
+	dropped          int                                    // Newest posts dropped to keep the feed within its cap
+	cache            *feedCache                             // Rendered posts, so navigating only renders what changed
+	reactionsVersion int                                    // Bumped whenever reactions change, which the cache cannot see
 }
 
 // NewFeedModel creates a new feed model
@@ -35,15 +47,101 @@ func NewFeedModel() FeedModel {
 		scrollOffset:  0,
 		timelineType:  services.TimelineHome,
 		loading:       false,
+		nav:           NewNavigator(),
+		cache:         newFeedCache(),
 	}
 }
 
+// matches reports whether the post at i passes the filters
+func (f FeedModel) matches(i int) bool {
+	return f.filter.allows(f.statuses[i]) && statusMatches(f.statuses[i], f.nav.Query())
+}
+
+// markRead remembers the selected home timeline post as read
+func (f *FeedModel) markRead() {
+	if f.timelineType != services.TimelineHome || f.selectedIndex >= len(f.statuses) {
+		return
+	}
+	if id := f.statuses[f.selectedIndex].ID; services.StatusIDNewer(id, f.lastReadID) {
+		f.lastReadID = id
+	}
+}
+
+// defaultFeedMaxPosts is how many posts a feed keeps in memory unless
+// tui.feed_max_posts says otherwise
+const defaultFeedMaxPosts = 500
+
+// feedMaxPosts returns how many posts the feed keeps in memory; it always
+// holds a few pages, so scrolling keeps working
+func (m *Model) feedMaxPosts() int {
+	if m.ctx == nil || m.ctx.Config == nil || m.ctx.Config.TUI.FeedMaxPosts <= 0 {
+		return defaultFeedMaxPosts
+	}
+	return max(m.ctx.Config.TUI.FeedMaxPosts, 100)
+}
+
+// appendStatuses adds a page of older posts to the feed. Past maxPosts the
+// newest posts are dropped, and the selection and scroll offset shift with
+// the remaining ones so the same posts stay on screen.
+func (f *FeedModel) appendStatuses(statuses []services.MastodonStatus, maxPosts int) {
+	f.statuses = append(f.statuses, statuses...)
+	excess := len(f.statuses) - maxPosts
+	if excess <= 0 {
+		return
+	}
+
+	for _, status := range f.statuses[:excess] {
+		delete(f.reactions, status.URI)
+		if status.Reblog != nil {
+			delete(f.reactions, status.Reblog.URI)
+		}
+	}
+	// Copy, so the dropped posts' backing array can be freed
+	f.statuses = append([]services.MastodonStatus(nil), f.statuses[excess:]...)
+	f.selectedIndex = max(f.selectedIndex-excess, 0)
+	f.scrollOffset = max(f.scrollOffset-excess, 0)
+	f.dropped += excess
+}
+
+// feedPage is how many posts the feed shows at once
+func (m *Model) feedPage() int {
+	if m.compact {
+		// Title, two rules and two footer lines surround the posts
+		return max(m.height-5, 1)
+	}
+	return max((m.height-8)/6, 3) // Estimate ~6 lines per post
+}
+
+// moveFeed selects the post at index, scrolling it into view and loading
+// more posts when nearing the end of the timeline
+func (m Model) moveFeed(index int) (tea.Model, tea.Cmd) {
+	if len(m.feed.statuses) == 0 {
+		return m, nil
+	}
+	m.feed.selectedIndex = index
+	m.feed.markRead()
+	m.feed.scrollOffset = keepVisible(m.feed.scrollOffset, index, m.feedPage(), m.feed.matches)
+
+	// Infinite scrolling: auto-load more when near the end
+	postsRemaining := len(m.feed.statuses) - m.feed.selectedIndex
+	if postsRemaining <= 5 && m.feed.hasMore && !m.feed.loadingMore && !m.feed.loading {
+		maxID := m.feed.statuses[len(m.feed.statuses)-1].ID
+		m.feed.loadingMore = true
+		m.feed.statusMessage = "Loading more..."
+		return m, loadMorePostsCmd(m.ctx, m.user.ID, m.feed.timelineType, 20, maxID)
+	}
+	return m, nil
+}
+
 // RenderFeed renders the feed screen
 func (m *Model) renderFeed() string {
 	if m.feed.loading {
 		return m.renderLoadingFeed()
 	}
 
+	if errors.Is(m.feed.err, services.ErrTimelineRequiresLogin) {
+		return m.renderLoginRequired()
+	}
 	if m.feed.err != nil {
 		return m.renderFeedError()
 	}
@@ -52,6 +150,9 @@ func (m *Model) renderFeed() string {
 		return m.renderEmptyFeed()
 	}
 
+	if m.compact {
+		return m.renderCompactFeed()
+	}
 	return m.renderFeedWithPosts()
 }
 
@@ -109,29 +210,36 @@ func (m *Model) renderFeedWithPosts() string {
 
 	// Top line with title
 	titleText := fmt.Sprintf("%s Timeline (%d posts)", timelineName, len(m.feed.statuses))
+	if m.feed.dropped > 0 {
+		titleText += "  " + subtleStyle.Render(fmt.Sprintf("%d newer posts unloaded, Ctrl+R to reload", m.feed.dropped))
+	}
+	if m.feed.offline {
+		titleText += "  " + errorStyle.Render(fmt.Sprintf("offline, cached %s", formatAge(time.Since(m.feed.cachedAt))))
+	}
+	if _, remote := m.feed.timelineType.Instance(); remote {
+		titleText += "  " + subtleStyle.Render("read-only, browsing anonymously")
+	}
+	if m.feed.filter != nil {
+		titleText += "  " + subtleStyle.Render(m.feed.filter.label())
+	}
 	b.WriteString(strings.Repeat("─", m.width) + "\n")
 	b.WriteString("  " + titleText + "\n")
-	b.WriteString(strings.Repeat("─", m.width) + "\n\n")
-
-	// Calculate which posts to show (viewport)
-	postsPerPage := (m.height - 8) / 6 // Estimate ~6 lines per post
-	if postsPerPage < 3 {
-		postsPerPage = 3
-	}
-
-	startIdx := m.feed.scrollOffset
-	endIdx := startIdx + postsPerPage
-	if endIdx > len(m.feed.statuses) {
-		endIdx = len(m.feed.statuses)
+	if pins := m.renderPins(); pins != "" {
+		b.WriteString("  " + fitLine(pins, m.width-2) + "\n")
 	}
+	b.WriteString(strings.Repeat("─", m.width) + "\n\n")
 
 	// Render visible posts
-	for i := startIdx; i < endIdx; i++ {
+	visible := visibleIndexes(m.feed.scrollOffset, m.feedPage(), len(m.feed.statuses), m.feed.matches)
+	if len(visible) == 0 {
+		b.WriteString("  " + subtleStyle.Render("No posts match the filter") + "\n\n")
+	}
+	for _, i := range visible {
 		status := m.feed.statuses[i]
-		isSelected := i == m.feed.selectedIndex
-
-		// Render post with full width
-		b.WriteString(m.renderPostMinimal(status, isSelected))
+		key := feedCacheKey{id: status.ID, width: m.width, selected: i == m.feed.selectedIndex, accessible: m.accessible}
+		b.WriteString(m.feed.cache.render(key, m.feed.postStamp(status), len(m.feed.statuses), func() string {
+			return m.renderPostMinimal(status, key.selected)
+		}))
 		b.WriteString("\n")
 	}
 
@@ -153,8 +261,12 @@ func (m *Model) renderFeedWithPosts() string {
 	keyColor := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("208"))
 	subtleColor := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
 
-	controls1 := fmt.Sprintf("  %s Navigate  %s %s %s",
-		subtleColor.Render("↑/↓"),
+	controls1 := fmt.Sprintf("  %s Navigate  %s Filter  %s Saved filters  %s Pin/Go to pin  %s Back/Forward  %s %s %s",
+		subtleColor.Render("↑/↓ gg G"),
+		subtleColor.Render("/"),
+		subtleColor.Render("V"),
+		subtleColor.Render("m1-9 '1-9"),
+		subtleColor.Render("[ ]"),
 		keyColor.Render("[H]")+"ome",
 		keyColor.Render("[L]")+"ocal",
 		keyColor.Render("[F]")+"ederated")
@@ -165,11 +277,17 @@ func (m *Model) renderFeedWithPosts() string {
 	}
 	b.WriteString(controls1 + "\n")
 
-	controls2 := fmt.Sprintf("  %s Reply  %s Thread  %s Profile  %s Like  %s Boost  %s  %s  %s\n",
+	controls2 := fmt.Sprintf("  %s Actions  %s Reply  %s Thread  %s Profile  %s Info  %s Reader  %s Links  %s Copy  %s Like  %s React  %s Boost/Quote  %s  %s  %s\n",
+		keyColor.Render("[Enter]"),
 		keyColor.Render("[R]"),
 		keyColor.Render("[T]"),
 		keyColor.Render("[P]"),
+		keyColor.Render("[I]"),
+		keyColor.Render("[Z]"),
+		keyColor.Render("[O]"),
+		keyColor.Render("[C]"),
 		keyColor.Render("[X]"),
+		keyColor.Render("[E]"),
 		keyColor.Render("[S]"),
 		keyColor.Render("[Ctrl+R]")+" Refresh",
 		keyColor.Render("[B]")+"ack",
@@ -182,6 +300,9 @@ func (m *Model) renderFeedWithPosts() string {
 		statusColor = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
 	}
 	b.WriteString(fmt.Sprintf("  Post %d/%d  •  %s\n", m.feed.selectedIndex+1, len(m.feed.statuses), statusColor.Render(statusMsg)))
+	if filter := m.feed.nav.View(); filter != "" {
+		b.WriteString("  " + filter + "\n")
+	}
 	b.WriteString(strings.Repeat("─", m.width) + "\n")
 
 	return b.String()
@@ -206,9 +327,9 @@ func (m *Model) renderPostMinimal(status services.MastodonStatus, selected bool)
 		author = originalStatus.Account.Username
 	}
 	handle := fmt.Sprintf("@%s", originalStatus.Account.Acct)
-
-	// Strip HTML from content
-	content := stripHTML(originalStatus.Content)
+	if originalStatus.GPGSigner != "" {
+		handle += " [signed]"
+	}
 
 	// Format metadata
 	likes := originalStatus.FavouritesCount
@@ -243,16 +364,18 @@ func (m *Model) renderPostMinimal(status services.MastodonStatus, selected bool)
 	if contentWidth < 60 {
 		contentWidth = 60
 	}
-	lines := wrapText(content, contentWidth)
-	maxContentLines := 4 // Show up to 4 lines of content
-	for i, line := range lines {
-		if i >= maxContentLines {
-			b.WriteString("  ...\n")
-			break
-		}
+	// Show up to 4 lines of content
+	for _, line := range renderHTMLLines(originalStatus.Content, contentWidth, 4) {
 		b.WriteString("  " + line + "\n")
 	}
 
+	// Media is invisible to screen readers, so its alt text is spelled out
+	if m.accessible {
+		for _, media := range originalStatus.MediaAttachments {
+			b.WriteString("  " + mediaAltText(media) + "\n")
+		}
+	}
+
 	// Interaction stats with indicators and colors
 	statsStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
 	highlightStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("10"))
@@ -270,6 +393,10 @@ func (m *Model) renderPostMinimal(status services.MastodonStatus, selected bool)
 	statsLine := fmt.Sprintf("%s  %s  Replies: %d", likesStr, boostsStr, replies)
 	b.WriteString("  " + statsStyle.Render(statsLine) + "\n")
 
+	if reactions := renderReactions(m.statusReactions(originalStatus)); reactions != "" {
+		b.WriteString("  " + reactions + "\n")
+	}
+
 	return b.String()
 }
 
@@ -287,6 +414,9 @@ func (m *Model) renderPostDynamic(status services.MastodonStatus, selected bool,
 		author = originalStatus.Account.Username
 	}
 	handle := fmt.Sprintf("@%s", originalStatus.Account.Acct)
+	if originalStatus.GPGSigner != "" {
+		handle += " [signed]"
+	}
 
 	// Strip HTML from content
 	content := stripHTML(originalStatus.Content)
@@ -361,23 +491,17 @@ func getTimelineName(t services.TimelineType) string {
 		return "Local"
 	case services.TimelineFederated:
 		return "Federated"
-	default:
-		return "Unknown"
 	}
-}
-
-func stripHTML(s string) string {
-	// Remove HTML tags
-	re := regexp.MustCompile("<[^>]*>")
-	s = re.ReplaceAllString(s, "")
-
-	// Decode HTML entities
-	s = html.UnescapeString(s)
-
-	// Replace multiple spaces with single space
-	s = regexp.MustCompile(`\s+`).ReplaceAllString(s, " ")
-
-	return strings.TrimSpace(s)
+	if tag, ok := t.Tag(); ok {
+		return "#" + tag
+	}
+	if list, ok := t.List(); ok {
+		return "List " + list
+	}
+	if domain, ok := t.Instance(); ok {
+		return domain
+	}
+	return "Unknown"
 }
 
 func truncate(s string, max int) string {
@@ -435,21 +559,84 @@ func wrapText(text string, width int) []string {
 	return lines
 }
 
+// formatAge describes how long ago something happened
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%d minutes ago", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%d hours ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%d days ago", int(age.Hours()/24))
+	}
+}
+
+// getTimeline fetches a page of a timeline. Users without a Mastodon account
+// read the local and federated timelines from this server.
+func getTimeline(ctx *AppContext, userID int, timelineType services.TimelineType, limit int, maxID string) ([]services.MastodonStatus, error) {
+	// An injected fetcher serves every timeline itself
+	if ctx.Timelines != nil {
+		return ctx.Timelines.GetTimeline(context.Background(), userID, timelineType, limit, maxID)
+	}
+
+	// Other instances are read anonymously, whoever the user is
+	if domain, ok := timelineType.Instance(); ok {
+		return services.NewRemoteInstanceService(ctx.DB, ctx.Config).Timeline(context.Background(), domain, limit, maxID)
+	}
+
+	mastodonService := services.NewMastodonService(ctx.DB, ctx.Config)
+	tag, isTag := timelineType.Tag()
+	if timelineType == services.TimelineLocal || timelineType == services.TimelineFederated || isTag {
+		linked, err := mastodonService.HasAccount(context.Background(), userID)
+		if err != nil {
+			return nil, err
+		}
+		if !linked {
+			timelines := services.NewTimelineService(ctx.DB, ctx.Config)
+			switch {
+			case isTag:
+				return timelines.Tag(context.Background(), tag, limit, maxID)
+			case timelineType == services.TimelineLocal:
+				return timelines.Local(context.Background(), limit, maxID)
+			}
+			return timelines.Federated(context.Background(), limit, maxID)
+		}
+	}
+	return mastodonService.GetTimeline(context.Background(), userID, timelineType, limit, maxID)
+}
+
 // fetchTimelineCmd fetches timeline from Mastodon
 func fetchTimelineCmd(ctx *AppContext, userID int, timelineType services.TimelineType, limit int) tea.Cmd {
 	return func() tea.Msg {
-		mastodonService := services.NewMastodonService(ctx.DB)
-
-		statuses, err := mastodonService.GetTimeline(
-			context.Background(),
-			userID,
-			timelineType,
-			limit,
-			"", // maxID for pagination
-		)
+		statuses, err := getTimeline(ctx, userID, timelineType, limit, "")
+		if errors.Is(err, services.ErrInstanceBlocked) || errors.Is(err, services.ErrTimelineRequiresLogin) {
+			// Cached posts would show what the instance no longer does
+			return timelineMsg{err: err, timelineType: timelineType}
+		}
+		if ctx.DB == nil {
+			// Nowhere to cache the timeline or to fall back to
+			return timelineMsg{statuses: statuses, timelineType: timelineType, err: err}
+		}
 
+		cache := services.NewStatusCacheService(ctx.DB)
 		if err != nil {
-			return timelineMsg{err: err}
+			// Fall back to the last timeline we fetched, if any
+			cached, cachedAt, cacheErr := cache.Load(context.Background(), userID, timelineType, limit)
+			if cacheErr != nil || len(cached) == 0 {
+				return timelineMsg{err: err}
+			}
+			return timelineMsg{
+				statuses:     cached,
+				timelineType: timelineType,
+				offline:      true,
+				cachedAt:     cachedAt,
+			}
+		}
+
+		if err := cache.Store(context.Background(), userID, timelineType, statuses); err != nil {
+			fmt.Printf("Failed to cache timeline: %v\n", err)
 		}
 
 		return timelineMsg{
@@ -460,23 +647,77 @@ func fetchTimelineCmd(ctx *AppContext, userID int, timelineType services.Timelin
 	}
 }
 
+// markerPages caps how many pages are fetched to reach the read marker
+const markerPages = 5
+
+// fetchHomeAtMarkerCmd fetches the home timeline far enough back to include
+// the last read post, so the feed can start there
+func fetchHomeAtMarkerCmd(ctx *AppContext, userID int, limit int) tea.Cmd {
+	fetch := fetchTimelineCmd(ctx, userID, services.TimelineHome, limit)
+	return func() tea.Msg {
+		msg, ok := fetch().(timelineMsg)
+		if !ok || msg.err != nil || len(msg.statuses) == 0 || ctx.DB == nil {
+			return msg
+		}
+
+		markers := services.NewMarkerService(ctx.DB, services.NewMastodonService(ctx.DB, ctx.Config))
+		markerID, err := markers.Get(context.Background(), userID, string(services.TimelineHome))
+		if err != nil || markerID == "" {
+			return msg
+		}
+		msg.readMarkerID = markerID
+
+		// Page back until the marker is reached
+		for page := 1; page < markerPages && !msg.offline; page++ {
+			last := msg.statuses[len(msg.statuses)-1]
+			if !services.StatusIDNewer(last.ID, markerID) {
+				break
+			}
+			more, err := ctx.timelines().GetTimeline(context.Background(), userID, services.TimelineHome, limit, last.ID)
+			if err != nil || len(more) == 0 {
+				break
+			}
+			msg.statuses = append(msg.statuses, more...)
+		}
+
+		return msg
+	}
+}
+
+// saveReadMarkerCmd stores the home timeline read position if it moved
+func (m *Model) saveReadMarkerCmd() tea.Cmd {
+	lastReadID := m.feed.lastReadID
+	if lastReadID == "" || lastReadID == m.feed.savedReadID || m.user == nil || m.ctx.DB == nil {
+		return nil
+	}
+	m.feed.savedReadID = lastReadID
+
+	appCtx, userID, mastodonSvc := m.ctx, m.user.ID, m.mastodonSvc
+	return func() tea.Msg {
+		markers := services.NewMarkerService(appCtx.DB, mastodonSvc)
+		if err := markers.Save(context.Background(), userID, string(services.TimelineHome), lastReadID); err != nil {
+			fmt.Printf("Failed to save read marker: %v\n", err)
+		}
+		return nil
+	}
+}
+
 // loadMorePostsCmd loads more posts for pagination
 func loadMorePostsCmd(ctx *AppContext, userID int, timelineType services.TimelineType, limit int, maxID string) tea.Cmd {
 	return func() tea.Msg {
-		mastodonService := services.NewMastodonService(ctx.DB)
-
-		statuses, err := mastodonService.GetTimeline(
-			context.Background(),
-			userID,
-			timelineType,
-			limit,
-			maxID,
-		)
+		statuses, err := getTimeline(ctx, userID, timelineType, limit, maxID)
 
 		if err != nil {
 			return timelineMsg{err: err, isLoadMore: true}
 		}
 
+		if ctx.DB != nil {
+			cache := services.NewStatusCacheService(ctx.DB)
+			if err := cache.Store(context.Background(), userID, timelineType, statuses); err != nil {
+				fmt.Printf("Failed to cache timeline: %v\n", err)
+			}
+		}
+
 		return timelineMsg{
 			statuses:     statuses,
 			timelineType: timelineType,
@@ -486,20 +727,75 @@ func loadMorePostsCmd(ctx *AppContext, userID int, timelineType services.Timelin
 }
 
 // likeStatusCmd likes a status
-func likeStatusCmd(ctx *AppContext, userID int, statusID string) tea.Cmd {
+func likeStatusCmd(ctx *AppContext, userID int, status services.MastodonStatus) tea.Cmd {
 	return func() tea.Msg {
-		mastodonService := services.NewMastodonService(ctx.DB)
-		err := mastodonService.FavouriteStatus(context.Background(), userID, statusID)
-		return likeMsg{err: err}
+		err := ctx.statusActions().FavouriteStatus(context.Background(), userID, status.ID)
+		if err == nil {
+			recordAction(ctx.actionLog(services.NewMastodonService(ctx.DB, ctx.Config)), userID, models.ActionLike, status.ID, actionSummary(status))
+		}
+		return likeMsg{status: status, liked: true, err: err}
 	}
 }
 
-// boostStatusCmd boosts a status
-func boostStatusCmd(ctx *AppContext, userID int, statusID string) tea.Cmd {
+// unlikeStatusCmd takes back the like of a status
+func unlikeStatusCmd(ctx *AppContext, userID int, status services.MastodonStatus) tea.Cmd {
+	return func() tea.Msg {
+		err := ctx.statusActions().UnfavouriteStatus(context.Background(), userID, status.ID)
+		return likeMsg{status: status, liked: false, err: err}
+	}
+}
+
+// boostStatusCmd boosts a status with the given visibility
+func boostStatusCmd(ctx *AppContext, userID int, status services.MastodonStatus, visibility string) tea.Cmd {
+	return func() tea.Msg {
+		err := ctx.statusActions().BoostStatus(context.Background(), userID, status.ID, visibility)
+		if err == nil {
+			recordAction(ctx.actionLog(services.NewMastodonService(ctx.DB, ctx.Config)), userID, models.ActionBoost, status.ID, actionSummary(status))
+		}
+		return boostMsg{status: status, boosted: true, err: err}
+	}
+}
+
+// unboostStatusCmd takes back the boost of a status
+func unboostStatusCmd(ctx *AppContext, userID int, status services.MastodonStatus) tea.Cmd {
+	return func() tea.Msg {
+		err := ctx.statusActions().UnreblogStatus(context.Background(), userID, status.ID)
+		return boostMsg{status: status, boosted: false, err: err}
+	}
+}
+
+// boostMenuTitle identifies the boost menu in menu messages
+const boostMenuTitle = "Boost"
+
+// newBoostMenu lists the boost visibilities and quoting; a post already
+// boosted offers to undo the boost instead
+func newBoostMenu(boosted bool) MenuModel {
+	if boosted {
+		return NewMenuModel(boostMenuTitle, []MenuItem{
+			{ID: "undo", Label: "Undo boost", Key: "s"},
+			{ID: "quote", Label: "Quote post", Key: "q"},
+		})
+	}
+	return NewMenuModel(boostMenuTitle, []MenuItem{
+		{ID: "public", Label: "Boost publicly", Key: "s"},
+		{ID: "unlisted", Label: "Boost unlisted", Key: "u"},
+		{ID: "private", Label: "Boost to followers only", Key: "f"},
+		{ID: "quote", Label: "Quote post", Key: "q"},
+	})
+}
+
+// quoteSupportMsg is returned once the instance's quote support is known
+type quoteSupportMsg struct {
+	status     services.MastodonStatus
+	quoteParam string
+}
+
+// checkQuoteSupportCmd detects how the user's instance quotes posts
+func checkQuoteSupportCmd(mastodonSvc *services.MastodonService, userID int, status services.MastodonStatus) tea.Cmd {
 	return func() tea.Msg {
-		mastodonService := services.NewMastodonService(ctx.DB)
-		err := mastodonService.BoostStatus(context.Background(), userID, statusID)
-		return boostMsg{err: err}
+		// Any failure falls back to quoting by link
+		param, _ := mastodonSvc.QuoteParam(context.Background(), userID)
+		return quoteSupportMsg{status: status, quoteParam: param}
 	}
 }
 
@@ -508,17 +804,24 @@ type timelineMsg struct {
 	statuses     []services.MastodonStatus
 	timelineType services.TimelineType
 	isLoadMore   bool
+	offline      bool      // statuses come from the offline cache
+	cachedAt     time.Time // When the cached statuses were fetched
+	readMarkerID string    // Last read post to start the feed at, if any
 	err          error
 }
 
-// likeMsg is returned when a status is liked
+// likeMsg is returned when a status is liked, or its like taken back
 type likeMsg struct {
-	err error
+	status services.MastodonStatus
+	liked  bool
+	err    error
 }
 
-// boostMsg is returned when a status is boosted
+// boostMsg is returned when a status is boosted, or its boost taken back
 type boostMsg struct {
-	err error
+	status  services.MastodonStatus
+	boosted bool
+	err     error
 }
 
 // centerText centers text within a given width
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// feedCacheKey identifies a rendering of a post: the same post looks
+// different at another width, when selected, or for a screen reader
+type feedCacheKey struct {
+	id         string
+	width      int
+	selected   bool
+	accessible bool
+}
+
+// feedCacheEntry is a rendered post and the data it was rendered from
+type feedCacheEntry struct {
+	stamp    feedPostStamp
+	rendered string
+}
+
+// feedPostStamp holds what can change about a post once it is loaded; a
+// cached rendering with a different stamp is stale
+type feedPostStamp struct {
+	content    string
+	likes      int
+	boosts     int
+	replies    int
+	favourited bool
+	reblogged  bool
+	reactions  int // FeedModel.reactionsVersion
+}
+
+// feedCache keeps rendered posts so that moving through the feed only
+// renders the posts whose selection changed. It is shared by the copies of
+// the model, like a map, and only used from the program's goroutine.
+type feedCache struct {
+	entries map[feedCacheKey]feedCacheEntry
+}
+
+// newFeedCache creates an empty feedCache
+func newFeedCache() *feedCache {
+	return &feedCache{entries: map[feedCacheKey]feedCacheEntry{}}
+}
+
+// postStamp returns the stamp of a feed post
+func (f *FeedModel) postStamp(status services.MastodonStatus) feedPostStamp {
+	original := status
+	if status.Reblog != nil {
+		original = *status.Reblog
+	}
+	return feedPostStamp{
+		content:    original.Content,
+		likes:      original.FavouritesCount,
+		boosts:     original.ReblogsCount,
+		replies:    original.RepliesCount,
+		favourited: original.Favourited,
+		reblogged:  original.Reblogged,
+		reactions:  f.reactionsVersion,
+	}
+}
+
+// render returns the cached rendering of a post, calling render only when
+// there is none or the post changed since. Renderings of posts no longer
+// in the feed are dropped once the cache outgrows it.
+func (c *feedCache) render(key feedCacheKey, stamp feedPostStamp, posts int, render func() string) string {
+	if c == nil {
+		return render()
+	}
+	if entry, ok := c.entries[key]; ok && entry.stamp == stamp {
+		return entry.rendered
+	}
+	// Each post is cached at most twice per width: selected and not
+	if len(c.entries) > 2*posts+64 {
+		clear(c.entries)
+	}
+	rendered := render()
+	c.entries[key] = feedCacheEntry{stamp: stamp, rendered: rendered}
+	return rendered
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// newLargeFeed returns a session showing a home timeline of n posts
+func newLargeFeed(t testing.TB, n int) Model {
+	t.Helper()
+	m := newTestModel(t, 120, 40)
+	statuses := make([]services.MastodonStatus, n)
+	for i := range statuses {
+		status := fixtureStatuses()[i%3]
+		status.ID = fmt.Sprint(10000 - i)
+		statuses[i] = status
+	}
+	m.screen = screenFeed
+	return send(m, timelineMsg{statuses: statuses, timelineType: services.TimelineHome})
+}
+
+func TestFeedCache(t *testing.T) {
+	m := newLargeFeed(t, 500)
+	uncached := func(m Model) string {
+		m.feed.cache = nil
+		return m.View()
+	}
+
+	for range 3 {
+		m = send(m, tea.KeyMsg{Type: tea.KeyDown})
+		if got, want := m.View(), uncached(m); got != want {
+			t.Fatalf("cached feed differs after moving down\n--- got ---\n%s\n--- want ---\n%s", got, want)
+		}
+	}
+
+	// A post liked in place must not be served from the cache
+	m.feed.statuses[m.feed.selectedIndex].FavouritesCount += 100
+	m.feed.statuses[m.feed.selectedIndex].Favourited = true
+	if got, want := m.View(), uncached(m); got != want {
+		t.Fatalf("cached feed is stale after a like\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+
+	m.feed.applyReaction(m.feed.statuses[m.feed.selectedIndex].URI, "🎉", false)
+	if got, want := m.View(), uncached(m); got != want {
+		t.Fatalf("cached feed is stale after a reaction\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+
+	if n := len(m.feed.cache.entries); n > 2*len(m.feed.statuses)+64 {
+		t.Errorf("cache holds %d renderings for %d posts", n, len(m.feed.statuses))
+	}
+}
+
+func BenchmarkFeedNavigation(b *testing.B) {
+	m := newLargeFeed(b, 500)
+	down, up := tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyUp}
+	m.View()
+
+	b.ResetTimer()
+	for i := range b.N {
+		key := down
+		if i/20%2 == 1 {
+			key = up
+		}
+		m = send(m, key)
+		m.View()
+	}
+}
@@ -0,0 +1,436 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// feedFilter is a saved filter ready to apply to the posts of the feed
+type feedFilter struct {
+	models.FeedFilter
+	expr *services.FilterExpr
+}
+
+// compileFeedFilter parses a saved filter; filters are checked when saved,
+// so this only fails if the syntax changed since
+func compileFeedFilter(filter models.FeedFilter) (*feedFilter, bool) {
+	expr, err := services.ParseFilterExpr(filter.Expression)
+	if err != nil {
+		return nil, false
+	}
+	return &feedFilter{FeedFilter: filter, expr: expr}, true
+}
+
+// allows reports whether a post is shown with the filter active
+func (f *feedFilter) allows(status services.MastodonStatus) bool {
+	if f == nil {
+		return true
+	}
+	return f.expr.Match(status) == (f.Action == models.FeedFilterShow)
+}
+
+// label describes the filter in the feed header
+func (f *feedFilter) label() string {
+	verb := "hiding"
+	if f.Action == models.FeedFilterShow {
+		verb = "only"
+	}
+	return fmt.Sprintf("filter: %s (%s)", f.Name, verb)
+}
+
+// FeedFiltersModel represents the screen managing a user's feed filters
+type FeedFiltersModel struct {
+	filterService *services.FeedFilterService
+	userID        int
+	filters       []models.FeedFilter
+	selectedIndex int
+	editing       bool // Form shown
+	editID        int  // Filter being edited, 0 for a new one
+	name          textinput.Model
+	expression    textinput.Model
+	action        string
+	focus         int  // Form field with the focus: name, expression or action
+	deleting      bool // Waiting for the deletion to be confirmed
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// feedFiltersLoadedMsg is sent when the user's filters have been fetched
+type feedFiltersLoadedMsg struct {
+	filters []models.FeedFilter
+	err     error
+}
+
+// feedFilterSavedMsg reports the outcome of saving a filter
+type feedFilterSavedMsg struct {
+	filter *models.FeedFilter
+	err    error
+}
+
+// feedFilterDeletedMsg reports the outcome of deleting a filter
+type feedFilterDeletedMsg struct {
+	err error
+}
+
+// NewFeedFiltersModel creates the filter screen of a user
+func NewFeedFiltersModel(filterService *services.FeedFilterService, userID int) FeedFiltersModel {
+	name := textinput.New()
+	name.Prompt = "Name: "
+	name.Placeholder = "no-crypto"
+	name.CharLimit = services.MaxFeedFilterNameLength
+
+	expression := textinput.New()
+	expression.Prompt = "Filter: "
+	expression.Placeholder = "regex:crypto AND NOT from:@friend"
+	expression.CharLimit = services.MaxFeedFilterLength
+
+	return FeedFiltersModel{
+		filterService: filterService,
+		userID:        userID,
+		name:          name,
+		expression:    expression,
+		action:        models.FeedFilterHide,
+		loading:       true,
+	}
+}
+
+// Init fetches the user's filters
+func (m FeedFiltersModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// Filters returns the saved filters, in the order V cycles through them
+func (m FeedFiltersModel) Filters() []models.FeedFilter {
+	return m.filters
+}
+
+// Editing reports whether the form takes the keys
+func (m FeedFiltersModel) Editing() bool {
+	return m.editing
+}
+
+// Update handles messages for the filter screen
+func (m FeedFiltersModel) Update(msg tea.Msg) (FeedFiltersModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case feedFiltersLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.filters = msg.filters
+		m.selectedIndex = min(m.selectedIndex, max(len(m.filters)-1, 0))
+		return m, nil
+
+	case feedFilterSavedMsg:
+		if msg.err != nil {
+			// The form comes back so the filter can be corrected
+			m.editing = true
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, m.focusField(m.focus)
+		}
+		m.statusMessage = fmt.Sprintf("Filter %q saved", msg.filter.Name)
+		return m, m.fetchCmd()
+
+	case feedFilterDeletedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.statusMessage = "Filter deleted"
+		return m, m.fetchCmd()
+
+	case tea.KeyMsg:
+		if m.editing {
+			return m.updateForm(msg)
+		}
+		if m.deleting {
+			m.deleting = false
+			if (msg.String() == "y" || msg.String() == "Y") && m.selectedIndex < len(m.filters) {
+				m.statusMessage = "Deleting..."
+				return m, m.deleteCmd(m.filters[m.selectedIndex].ID)
+			}
+			m.statusMessage = ""
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			m.selectedIndex = max(m.selectedIndex-1, 0)
+		case "down", "j":
+			m.selectedIndex = min(m.selectedIndex+1, max(len(m.filters)-1, 0))
+		case "n", "N":
+			return m.openForm(models.FeedFilter{Action: models.FeedFilterHide})
+		case "enter", "e", "E":
+			if m.selectedIndex < len(m.filters) {
+				return m.openForm(m.filters[m.selectedIndex])
+			}
+		case "d", "D":
+			if m.selectedIndex < len(m.filters) {
+				m.deleting = true
+				m.statusMessage = fmt.Sprintf("Delete %q? y/n", m.filters[m.selectedIndex].Name)
+			}
+		case "ctrl+r":
+			m.loading = true
+			m.statusMessage = ""
+			return m, m.fetchCmd()
+		}
+	}
+	return m, nil
+}
+
+// openForm shows the form filled in with filter, which is new when its ID
+// is zero
+func (m FeedFiltersModel) openForm(filter models.FeedFilter) (FeedFiltersModel, tea.Cmd) {
+	m.editing = true
+	m.editID = filter.ID
+	m.name.SetValue(filter.Name)
+	m.expression.SetValue(filter.Expression)
+	m.action = filter.Action
+	m.statusMessage = ""
+	return m, m.focusField(0)
+}
+
+// focusField moves the focus of the form to field 0 (name), 1 (expression)
+// or 2 (action)
+func (m *FeedFiltersModel) focusField(field int) tea.Cmd {
+	m.focus = field
+	m.name.Blur()
+	m.expression.Blur()
+	switch field {
+	case 0:
+		return m.name.Focus()
+	case 1:
+		return m.expression.Focus()
+	}
+	return nil
+}
+
+// updateForm handles keys while a filter is edited: Tab moves between the
+// fields, Space switches the action, Enter saves and Esc cancels
+func (m FeedFiltersModel) updateForm(msg tea.KeyMsg) (FeedFiltersModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.editing = false
+		m.name.Blur()
+		m.expression.Blur()
+		m.statusMessage = ""
+		return m, nil
+	case "tab":
+		return m, m.focusField((m.focus + 1) % 3)
+	case "shift+tab":
+		return m, m.focusField((m.focus + 2) % 3)
+	case "enter":
+		if m.focus < 2 {
+			return m, m.focusField(m.focus + 1)
+		}
+		m.editing = false
+		m.statusMessage = "Saving..."
+		return m, m.saveCmd(m.editID, m.name.Value(), m.expression.Value(), m.action)
+	}
+
+	var cmd tea.Cmd
+	switch m.focus {
+	case 0:
+		m.name, cmd = m.name.Update(msg)
+	case 1:
+		m.expression, cmd = m.expression.Update(msg)
+	default:
+		switch msg.String() {
+		case " ", "left", "right", "h", "l":
+			if m.action == models.FeedFilterHide {
+				m.action = models.FeedFilterShow
+			} else {
+				m.action = models.FeedFilterHide
+			}
+		}
+	}
+	return m, cmd
+}
+
+// fetchCmd loads the user's filters
+func (m FeedFiltersModel) fetchCmd() tea.Cmd {
+	if m.filterService == nil {
+		return nil
+	}
+	filterService, userID := m.filterService, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		filters, err := filterService.List(ctx, userID)
+		return feedFiltersLoadedMsg{filters: filters, err: err}
+	}
+}
+
+// saveCmd stores a new or edited filter
+func (m FeedFiltersModel) saveCmd(id int, name, expression, action string) tea.Cmd {
+	filterService, userID := m.filterService, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		filter, err := filterService.Save(ctx, userID, id, name, expression, action)
+		return feedFilterSavedMsg{filter: filter, err: err}
+	}
+}
+
+// deleteCmd removes a filter
+func (m FeedFiltersModel) deleteCmd(id int) tea.Cmd {
+	filterService, userID := m.filterService, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return feedFilterDeletedMsg{err: filterService.Delete(ctx, userID, id)}
+	}
+}
+
+// View renders the filter screen
+func (m FeedFiltersModel) View() string {
+	var b strings.Builder
+	width := max(min(m.width, 100)-4, 40)
+
+	b.WriteString(titleStyle.Render("Feed Filters") + "\n")
+	b.WriteString(subtleStyle.Render("Hide posts from any timeline, or show only the ones you want. Press V in the feed to switch filters.") + "\n\n")
+
+	if m.editing {
+		return b.String() + m.renderForm(width)
+	}
+
+	switch {
+	case m.loading:
+		b.WriteString(subtleStyle.Render("Loading...") + "\n")
+	case len(m.filters) == 0:
+		b.WriteString("No filters yet\n")
+	}
+	for i, filter := range m.filters {
+		selector := "  "
+		if i == m.selectedIndex {
+			selector = promptStyle.Render("► ")
+		}
+		b.WriteString(fmt.Sprintf("%s%-20s %-5s %s\n", selector, truncate(filter.Name, 20), filter.Action,
+			subtleStyle.Render(truncate(filter.Expression, max(width-30, 10)))))
+	}
+	b.WriteString("\n")
+
+	b.WriteString(keyStyle.Render("[N]") + " New  " +
+		keyStyle.Render("[Enter]") + " Edit  " +
+		keyStyle.Render("[D]") + " Delete  " +
+		keyStyle.Render("[Esc]") + " Back\n")
+
+	if m.statusMessage != "" {
+		msgStyle := subtleStyle
+		if strings.HasPrefix(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+	return b.String()
+}
+
+// renderForm renders the form editing a filter, checking the expression as
+// it is typed
+func (m FeedFiltersModel) renderForm(width int) string {
+	var b strings.Builder
+
+	name, expression := m.name, m.expression
+	name.Width = width - len(name.Prompt) - 1
+	expression.Width = width - len(expression.Prompt) - 1
+	b.WriteString(name.View() + "\n")
+	b.WriteString(expression.View() + "\n")
+
+	action := "Action: hide matching posts"
+	if m.action == models.FeedFilterShow {
+		action = "Action: show only matching posts"
+	}
+	if m.focus == 2 {
+		action = promptStyle.Render(action + "  ◄ ►")
+	}
+	b.WriteString(action + "\n\n")
+
+	if text := strings.TrimSpace(m.expression.Value()); text != "" {
+		if _, err := services.ParseFilterExpr(text); err != nil {
+			b.WriteString(errorStyle.Render(err.Error()) + "\n\n")
+		} else {
+			b.WriteString(successStyle.Render("Filter is valid") + "\n\n")
+		}
+	}
+
+	b.WriteString(subtleStyle.Render("Terms: word, \"a phrase\", regex:…, from:@user, #tag, has:media|cw|link, is:reply|boost|sensitive") + "\n")
+	b.WriteString(subtleStyle.Render("Combine with AND, OR, NOT (or -term) and parentheses") + "\n\n")
+
+	b.WriteString(keyStyle.Render("[Enter]") + " Next/Save  " +
+		keyStyle.Render("[Tab]") + " Field  " +
+		keyStyle.Render("[Space]") + " Hide/Show  " +
+		keyStyle.Render("[Esc]") + " Cancel\n")
+
+	if m.statusMessage != "" {
+		msgStyle := subtleStyle
+		if strings.HasPrefix(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+	return b.String()
+}
+
+// cycleFeedFilter applies the saved filter after the active one, or none
+// after the last
+func (m Model) cycleFeedFilter() (tea.Model, tea.Cmd) {
+	filters := m.feedFilters.Filters()
+	next := 0
+	if m.feed.filter != nil {
+		next = len(filters)
+		for i, filter := range filters {
+			if filter.ID == m.feed.filter.ID {
+				next = i + 1
+				break
+			}
+		}
+	}
+
+	for ; next < len(filters); next++ {
+		if filter, ok := compileFeedFilter(filters[next]); ok {
+			m.feed.statusMessage = "Filter " + filter.Name
+			return m.applyFeedFilter(filter)
+		}
+	}
+	if len(filters) == 0 {
+		m.feed.statusMessage = "No saved filters: add some from the main menu (V)"
+	} else {
+		m.feed.statusMessage = "Filter off"
+	}
+	return m.applyFeedFilter(nil)
+}
+
+// refreshFeedFilter returns the active filter as last loaded, or nil when it
+// has been deleted
+func (m Model) refreshFeedFilter() *feedFilter {
+	if m.feed.filter == nil {
+		return nil
+	}
+	for _, filter := range m.feedFilters.Filters() {
+		if filter.ID == m.feed.filter.ID {
+			compiled, _ := compileFeedFilter(filter)
+			return compiled
+		}
+	}
+	return nil
+}
+
+// applyFeedFilter makes filter the active one, keeping the selection on a
+// post it shows
+func (m Model) applyFeedFilter(filter *feedFilter) (tea.Model, tea.Cmd) {
+	m.feed.filter = filter
+	if m.screen != screenFeed {
+		return m, nil
+	}
+	return m.moveFeed(nearestIndex(m.feed.selectedIndex, len(m.feed.statuses), m.feed.matches))
+}
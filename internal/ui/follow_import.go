@@ -0,0 +1,228 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// followImportPhase tracks where the import flow is
+type followImportPhase int
+
+const (
+	importPhaseInput followImportPhase = iota
+	importPhaseRunning
+	importPhaseDone
+)
+
+// FollowImportModel represents the follow list import screen
+type FollowImportModel struct {
+	userID          int
+	mastodonService *services.MastodonService
+	textarea        textarea.Model
+	phase           followImportPhase
+	total           int
+	done            int
+	lastAcct        string
+	failures        []services.FollowImportResult
+	updates         chan tea.Msg
+	cancel          context.CancelFunc
+	statusMessage   string
+	width           int
+	height          int
+}
+
+// followImportProgressMsg is sent after each account is processed
+type followImportProgressMsg struct {
+	done   int
+	total  int
+	result services.FollowImportResult
+}
+
+// followImportDoneMsg is sent when the import finishes or is cancelled
+type followImportDoneMsg struct {
+	failures []services.FollowImportResult
+}
+
+// NewFollowImportModel creates a new follow import model
+func NewFollowImportModel(userID int, mastodonService *services.MastodonService) FollowImportModel {
+	ta := textarea.New()
+	ta.Placeholder = "Paste the contents of following_accounts.csv here"
+	ta.Focus()
+	ta.CharLimit = 0
+	ta.ShowLineNumbers = false
+	ta.SetWidth(74)
+	ta.SetHeight(10)
+
+	return FollowImportModel{
+		userID:          userID,
+		mastodonService: mastodonService,
+		textarea:        ta,
+	}
+}
+
+// Init initializes the follow import model
+func (m FollowImportModel) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+// Cancel stops a running import
+func (m FollowImportModel) Cancel() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+// Update handles messages for the follow import screen
+func (m FollowImportModel) Update(msg tea.Msg) (FollowImportModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case followImportProgressMsg:
+		m.done = msg.done
+		m.total = msg.total
+		m.lastAcct = msg.result.Acct
+		if msg.result.Err != nil {
+			m.failures = append(m.failures, msg.result)
+		}
+		return m, waitForImportUpdateCmd(m.updates)
+
+	case followImportDoneMsg:
+		m.phase = importPhaseDone
+		m.failures = msg.failures
+		m.cancel = nil
+		m.statusMessage = fmt.Sprintf("Followed %d of %d accounts", m.done-len(m.failures), m.total)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.phase != importPhaseInput {
+			return m, nil
+		}
+
+		if msg.String() == "ctrl+s" {
+			accts, err := services.ParseFollowingCSV(strings.NewReader(m.textarea.Value()))
+			if err != nil {
+				m.statusMessage = fmt.Sprintf("Error: %v", err)
+				return m, nil
+			}
+			return m.start(accts)
+		}
+
+		var cmd tea.Cmd
+		m.textarea, cmd = m.textarea.Update(msg)
+		return m, cmd
+	}
+
+	if m.phase == importPhaseInput {
+		var cmd tea.Cmd
+		m.textarea, cmd = m.textarea.Update(msg)
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// start launches the import in the background and streams progress back
+func (m FollowImportModel) start(accts []string) (FollowImportModel, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan tea.Msg, 1)
+
+	m.phase = importPhaseRunning
+	m.total = len(accts)
+	m.done = 0
+	m.failures = nil
+	m.updates = updates
+	m.cancel = cancel
+	m.statusMessage = ""
+
+	svc := m.mastodonService
+	userID := m.userID
+	go func() {
+		defer close(updates)
+		defer cancel()
+
+		// Drop updates once cancelled so an abandoned import never blocks
+		send := func(msg tea.Msg) {
+			select {
+			case updates <- msg:
+			case <-ctx.Done():
+			}
+		}
+
+		failures := svc.ImportFollows(ctx, userID, accts, func(done, total int, result services.FollowImportResult) {
+			send(followImportProgressMsg{done: done, total: total, result: result})
+		})
+		send(followImportDoneMsg{failures: failures})
+	}()
+
+	return m, waitForImportUpdateCmd(updates)
+}
+
+// waitForImportUpdateCmd waits for the next progress update from the import
+func waitForImportUpdateCmd(updates chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// View renders the follow import screen
+func (m FollowImportModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Import Follows") + "\n\n")
+
+	switch m.phase {
+	case importPhaseInput:
+		b.WriteString(subtleStyle.Render("Export \"Follows\" as CSV from your old instance (Preferences > Import and export),") + "\n")
+		b.WriteString(subtleStyle.Render("then paste it below, or run: ssh <host> import-follows < following_accounts.csv") + "\n\n")
+		b.WriteString(m.textarea.View() + "\n\n")
+		b.WriteString(keyStyle.Render("[Ctrl+S]") + " Start import  " + keyStyle.Render("[Esc]") + " Back\n")
+
+	case importPhaseRunning:
+		b.WriteString(renderProgressBar(m.done, m.total, 50) + fmt.Sprintf(" %d/%d\n", m.done, m.total))
+		if m.lastAcct != "" {
+			b.WriteString(subtleStyle.Render("Last: "+m.lastAcct) + "\n")
+		}
+		if len(m.failures) > 0 {
+			b.WriteString(errorStyle.Render(fmt.Sprintf("%d failed so far", len(m.failures))) + "\n")
+		}
+		b.WriteString("\n" + subtleStyle.Render("Requests are throttled to respect rate limits.") + "\n")
+		b.WriteString(keyStyle.Render("[Esc]") + " Cancel\n")
+
+	case importPhaseDone:
+		b.WriteString(successStyle.Render(m.statusMessage) + "\n\n")
+		if len(m.failures) > 0 {
+			b.WriteString(errorStyle.Render("Failed:") + "\n")
+			for i, failure := range m.failures {
+				if i == 10 {
+					b.WriteString(subtleStyle.Render(fmt.Sprintf("  ... and %d more", len(m.failures)-10)) + "\n")
+					break
+				}
+				b.WriteString(fmt.Sprintf("  %s %s\n", failure.Acct, subtleStyle.Render(truncate(failure.Err.Error(), 50))))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(keyStyle.Render("[Esc]") + " Back\n")
+	}
+
+	if m.phase == importPhaseInput && m.statusMessage != "" {
+		b.WriteString("\n" + errorStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
+
+// renderProgressBar renders a simple text progress bar
+func renderProgressBar(done, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = done * width / total
+	}
+	return "[" + successStyle.Render(strings.Repeat("=", filled)) + strings.Repeat(" ", width-filled) + "]"
+}
@@ -0,0 +1,243 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// FollowRequestsModel represents the screen listing follow requests awaiting approval
+type FollowRequestsModel struct {
+	userID        int
+	followers     *services.FollowerService
+	requests      []models.Follower
+	manual        bool // Whether the user approves followers by hand
+	selectedIndex int
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// followRequestsLoadedMsg is sent when pending requests have been fetched
+type followRequestsLoadedMsg struct {
+	requests []models.Follower
+	manual   bool
+	err      error
+}
+
+// followRequestAnsweredMsg is sent when a request has been accepted or rejected
+type followRequestAnsweredMsg struct {
+	requestID int
+	accepted  bool
+	err       error
+}
+
+// followApprovalToggledMsg is sent when follower approval has been turned on or off
+type followApprovalToggledMsg struct {
+	manual bool
+	err    error
+}
+
+// NewFollowRequestsModel creates a new follow requests model
+func NewFollowRequestsModel(userID int, followers *services.FollowerService) FollowRequestsModel {
+	return FollowRequestsModel{
+		userID:        userID,
+		followers:     followers,
+		loading:       true,
+		statusMessage: "Loading follow requests...",
+	}
+}
+
+// Init fetches the pending requests
+func (m FollowRequestsModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// Update handles messages for the follow requests screen
+func (m FollowRequestsModel) Update(msg tea.Msg) (FollowRequestsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case followRequestsLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.requests = msg.requests
+		m.manual = msg.manual
+		if m.selectedIndex >= len(m.requests) {
+			m.selectedIndex = 0
+		}
+		m.statusMessage = ""
+		return m, nil
+
+	case followRequestAnsweredMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		for i, request := range m.requests {
+			if request.ID == msg.requestID {
+				m.statusMessage = "Rejected " + followRequestName(request)
+				if msg.accepted {
+					m.statusMessage = "Accepted " + followRequestName(request)
+				}
+				m.requests = append(m.requests[:i], m.requests[i+1:]...)
+				break
+			}
+		}
+		if m.selectedIndex >= len(m.requests) && m.selectedIndex > 0 {
+			m.selectedIndex--
+		}
+		return m, nil
+
+	case followApprovalToggledMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.manual = msg.manual
+		m.statusMessage = "New followers are accepted automatically"
+		if m.manual {
+			m.statusMessage = "New followers now need your approval"
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.selectedIndex > 0 {
+				m.selectedIndex--
+			}
+		case "down", "j":
+			if m.selectedIndex < len(m.requests)-1 {
+				m.selectedIndex++
+			}
+		case "a", "A", "enter":
+			if m.selectedIndex < len(m.requests) {
+				return m, m.answerCmd(m.requests[m.selectedIndex].ID, true)
+			}
+		case "r", "R":
+			if m.selectedIndex < len(m.requests) {
+				return m, m.answerCmd(m.requests[m.selectedIndex].ID, false)
+			}
+		case "m", "M":
+			return m, m.toggleCmd(!m.manual)
+		case "ctrl+r":
+			m.loading = true
+			return m, m.fetchCmd()
+		}
+	}
+
+	return m, nil
+}
+
+// followRequestName returns the handle of a requesting account
+func followRequestName(request models.Follower) string {
+	if request.FollowerUsername != "" {
+		return "@" + request.FollowerUsername
+	}
+	return request.FollowerActorID
+}
+
+// fetchCmd loads pending requests and the approval setting
+func (m FollowRequestsModel) fetchCmd() tea.Cmd {
+	followers, userID := m.followers, m.userID
+	return func() tea.Msg {
+		ctx := context.Background()
+		manual, err := followers.ManualApproval(ctx, userID)
+		if err != nil {
+			return followRequestsLoadedMsg{err: err}
+		}
+		requests, err := followers.ListPending(ctx, userID)
+		return followRequestsLoadedMsg{requests: requests, manual: manual, err: err}
+	}
+}
+
+// answerCmd accepts or rejects a request
+func (m FollowRequestsModel) answerCmd(requestID int, accept bool) tea.Cmd {
+	followers, userID := m.followers, m.userID
+	return func() tea.Msg {
+		var err error
+		if accept {
+			err = followers.Approve(context.Background(), userID, requestID)
+		} else {
+			err = followers.Reject(context.Background(), userID, requestID)
+		}
+		return followRequestAnsweredMsg{requestID: requestID, accepted: accept, err: err}
+	}
+}
+
+// toggleCmd turns follower approval on or off
+func (m FollowRequestsModel) toggleCmd(manual bool) tea.Cmd {
+	followers, userID := m.followers, m.userID
+	return func() tea.Msg {
+		err := followers.SetManualApproval(context.Background(), userID, manual)
+		return followApprovalToggledMsg{manual: manual, err: err}
+	}
+}
+
+// View renders the follow requests screen
+func (m FollowRequestsModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Follow requests") + "\n")
+	mode := "Off: new followers are accepted automatically"
+	if m.manual {
+		mode = "On: new followers need your approval"
+	}
+	b.WriteString(subtleStyle.Render("Approve followers: "+mode) + "\n\n")
+
+	if m.loading {
+		b.WriteString(subtleStyle.Render(m.statusMessage) + "\n")
+		return b.String()
+	}
+
+	if len(m.requests) == 0 {
+		b.WriteString("No pending follow requests.\n\n")
+	}
+
+	visible := max(m.height-10, 5)
+	start := 0
+	if m.selectedIndex >= visible {
+		start = m.selectedIndex - visible + 1
+	}
+	end := min(start+visible, len(m.requests))
+
+	for i := start; i < end; i++ {
+		request := m.requests[i]
+
+		selector := "  "
+		if i == m.selectedIndex {
+			selector = promptStyle.Render("► ")
+		}
+
+		age := subtleStyle.Render(formatAge(time.Since(request.CreatedAt)))
+		b.WriteString(selector + truncate(followRequestName(request), 60) + " " + age + "\n")
+	}
+
+	b.WriteString("\n" + keyStyle.Render("[A]") + " Accept  " +
+		keyStyle.Render("[R]") + " Reject  " +
+		keyStyle.Render("[M]") + " Toggle approval  " +
+		keyStyle.Render("[Ctrl+R]") + " Refresh  " +
+		keyStyle.Render("[Esc]") + " Back\n")
+
+	if m.statusMessage != "" {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/games"
+)
+
+// GamesModel represents the games menu and the game being played
+type GamesModel struct {
+	games    []games.Game
+	selected int
+	playing  bool      // A game is running rather than the menu shown
+	game     tea.Model // Game being played
+	notice   string    // Shown above the game, e.g. when a login went through
+}
+
+// NewGamesModel creates a games menu listing every registered game
+func NewGamesModel() GamesModel {
+	return GamesModel{games: games.List()}
+}
+
+// Playing reports whether a game is running rather than the menu shown
+func (m GamesModel) Playing() bool {
+	return m.playing
+}
+
+// Start plays the named game, staying in the menu when there is none
+func (m GamesModel) Start(name string) (GamesModel, tea.Cmd) {
+	for i, game := range m.games {
+		if game.Name == name {
+			m.selected = i
+			return m.start()
+		}
+	}
+	return m, nil
+}
+
+// start plays the selected game
+func (m GamesModel) start() (GamesModel, tea.Cmd) {
+	if m.selected >= len(m.games) {
+		return m, nil
+	}
+	m.playing = true
+	m.game = m.games[m.selected].New()
+	return m, m.game.Init()
+}
+
+// Stop returns from the game to the menu
+func (m GamesModel) Stop() GamesModel {
+	m.playing = false
+	m.game = nil
+	return m
+}
+
+// Update passes messages to the game being played, or moves through the
+// menu. Esc is left to the caller.
+func (m GamesModel) Update(msg tea.Msg) (GamesModel, tea.Cmd) {
+	if m.playing {
+		var cmd tea.Cmd
+		m.game, cmd = m.game.Update(msg)
+		return m, cmd
+	}
+
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "up", "k":
+		m.selected = max(m.selected-1, 0)
+	case "down", "j":
+		m.selected = min(m.selected+1, max(len(m.games)-1, 0))
+	case "enter":
+		return m.start()
+	}
+	return m, nil
+}
+
+// View renders the game being played or the menu of games
+func (m GamesModel) View() string {
+	var b strings.Builder
+
+	if m.notice != "" {
+		b.WriteString(successStyle.Render(m.notice) + "\n\n")
+	}
+	if m.playing {
+		b.WriteString(m.game.View() + "\n\n")
+		b.WriteString(keyStyle.Render("[Esc]") + " Back to the games\n")
+		return b.String()
+	}
+
+	b.WriteString(titleStyle.Render("Games") + "\n\n")
+	for i, game := range m.games {
+		line := "  " + game.Title
+		if i == m.selected {
+			line = promptStyle.Render("► " + game.Title)
+		}
+		b.WriteString(line + "  " + subtleStyle.Render(game.Description) + "\n")
+	}
+	b.WriteString("\n" + keyStyle.Render("[↑/↓]") + " Select  " +
+		keyStyle.Render("[Enter]") + " Play  " +
+		keyStyle.Render("[Esc]") + " Back\n")
+	return b.String()
+}
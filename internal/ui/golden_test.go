@@ -0,0 +1,228 @@
+package ui
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/ssh"
+	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/muesli/termenv"
+)
+
+// update rewrites the golden files instead of comparing against them:
+// go test ./internal/ui -run TestGolden -update
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// goldenSizes are the terminal sizes every screen is rendered at: a
+// classic terminal, a roomy one, and one small enough for compact mode
+var goldenSizes = []struct{ width, height int }{
+	{80, 24},
+	{120, 40},
+	{60, 16},
+}
+
+// testSession is an SSH session from a UTF-8 xterm, without a key or address
+type testSession struct {
+	ssh.Session
+}
+
+func (testSession) PublicKey() ssh.PublicKey { return nil }
+func (testSession) RemoteAddr() net.Addr     { return nil }
+func (testSession) Environ() []string        { return []string{"LANG=C.UTF-8"} }
+func (testSession) Pty() (ssh.Pty, <-chan ssh.Window, bool) {
+	return ssh.Pty{Term: "xterm-256color"}, nil, true
+}
+
+// trailingSpace matches the padding lipgloss leaves at the end of lines
+var trailingSpace = regexp.MustCompile(`[ \t]+\n`)
+
+// requireGolden compares a rendered screen with testdata/<name>.golden
+func requireGolden(t *testing.T, name, out string) {
+	t.Helper()
+	out = trailingSpace.ReplaceAllString(strings.TrimRight(out, " \n")+"\n", "\n")
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(out), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	if out != string(want) {
+		t.Errorf("%s changed (run with -update if this is intended)\n--- got ---\n%s\n--- want ---\n%s", path, out, want)
+	}
+}
+
+// newTestModel returns a signed-in session sized to width x height
+func newTestModel(t testing.TB, width, height int) Model {
+	t.Helper()
+	lipgloss.SetColorProfile(termenv.Ascii)
+	lipgloss.SetHasDarkBackground(true)
+
+	m := NewModel(&AppContext{Config: config.DefaultConfig()}, testSession{})
+	m = send(m, tea.WindowSizeMsg{Width: width, Height: height})
+	m.user = &models.User{ID: 1, Username: "alice", PrimaryMastodonAcct: "alice@example.social"}
+	m.authenticated = true
+	return m
+}
+
+// send passes msg through Update, dropping any command it returns
+func send(m Model, msg tea.Msg) Model {
+	next, _ := m.Update(msg)
+	return next.(Model)
+}
+
+// fixtureAccount returns a remote account with a fixed join date
+func fixtureAccount(id, username, displayName string) services.MastodonAccount {
+	return services.MastodonAccount{
+		ID:             id,
+		Username:       username,
+		Acct:           username + "@example.social",
+		DisplayName:    displayName,
+		Note:           "<p>Writes about terminals, typography and the fediverse.</p>",
+		URL:            "https://example.social/@" + username,
+		FollowersCount: 1280,
+		FollowingCount: 311,
+		StatusesCount:  4821,
+		CreatedAt:      time.Date(2019, time.March, 14, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+// fixtureStatuses returns posts of varied length, ages well clear of any
+// boundary where their relative timestamps would change between runs
+func fixtureStatuses() []services.MastodonStatus {
+	now := time.Now()
+	bob := fixtureAccount("2", "bob", "Bob")
+	carol := fixtureAccount("3", "carol", "Carol 🌱")
+	long := strings.Repeat("Wrapping long lines without breaking words is the whole job of a reader. ", 4)
+
+	replyTo := "100"
+	return []services.MastodonStatus{
+		{
+			ID:              "103",
+			CreatedAt:       now.Add(-90 * time.Second),
+			Content:         "<p>Just shipped a terminal client for the fediverse! #terminalpub</p>",
+			Visibility:      "public",
+			Account:         bob,
+			RepliesCount:    2,
+			ReblogsCount:    5,
+			FavouritesCount: 12,
+			URL:             "https://example.social/@bob/103",
+			Tags:            []services.MastodonTag{{Name: "terminalpub"}},
+		},
+		{
+			ID:          "102",
+			CreatedAt:   now.Add(-150 * time.Minute),
+			Content:     "<p>" + long + "</p>",
+			Visibility:  "unlisted",
+			Account:     carol,
+			InReplyToID: &replyTo,
+			URL:         "https://example.social/@carol/102",
+		},
+		{
+			ID:          "101",
+			CreatedAt:   now.Add(-50 * time.Hour),
+			Content:     "<p>Spoilers for the season finale</p>",
+			Visibility:  "public",
+			Sensitive:   true,
+			SpoilerText: "TV spoilers",
+			Account:     bob,
+			URL:         "https://example.social/@bob/101",
+		},
+	}
+}
+
+// fixtureNotifications returns one notification of each common kind
+func fixtureNotifications() []services.MastodonNotification {
+	now := time.Now()
+	statuses := fixtureStatuses()
+	bob := fixtureAccount("2", "bob", "Bob")
+	carol := fixtureAccount("3", "carol", "Carol 🌱")
+	return []services.MastodonNotification{
+		{ID: "4", Type: services.NotificationMention, CreatedAt: now.Add(-3 * time.Minute), Account: bob, Status: &statuses[0]},
+		{ID: "3", Type: services.NotificationFavourite, CreatedAt: now.Add(-150 * time.Minute), Account: carol, Status: &statuses[1]},
+		{ID: "2", Type: services.NotificationReblog, CreatedAt: now.Add(-50 * time.Hour), Account: bob, Status: &statuses[2]},
+		{ID: "1", Type: services.NotificationFollow, CreatedAt: now.Add(-75 * time.Hour), Account: carol},
+	}
+}
+
+func TestGolden(t *testing.T) {
+	screens := []struct {
+		name   string
+		render func(m Model) Model
+	}{
+		{"welcome", func(m Model) Model {
+			m.user = nil
+			m.authenticated = false
+			return m
+		}},
+		{"feed", func(m Model) Model {
+			m.screen = screenFeed
+			return send(m, timelineMsg{statuses: fixtureStatuses(), timelineType: services.TimelineHome})
+		}},
+		{"compose", func(m Model) Model {
+			m.compose = NewComposeModel()
+			m.compose.width = m.width
+			m.compose.height = m.height
+			m = m.pushScreen(screenCompose)
+			return send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Hello from the terminal")})
+		}},
+		{"thread", func(m Model) Model {
+			statuses := fixtureStatuses()
+			m, _ = m.openThread(statuses[1])
+			return send(m, threadLoadedMsg{rootStatus: statuses[1], ancestors: statuses[2:], descendants: statuses[:1]})
+		}},
+		{"notifications", func(m Model) Model {
+			m.notifications = NewNotificationsModel(nil, m.user.ID, nil, nil)
+			m.notifications.width = m.width
+			m.notifications.height = m.height
+			m = m.pushScreen(screenNotifications)
+			return send(m, notificationsLoadedMsg{notifications: fixtureNotifications()})
+		}},
+		{"profile", func(m Model) Model {
+			account := fixtureAccount("2", "bob", "Bob")
+			statuses := fixtureStatuses()
+			m, _ = m.openProfile(account.ID)
+			return send(m, profileLoadedMsg{
+				account:      &account,
+				statuses:     []services.MastodonStatus{statuses[0], statuses[2]},
+				relationship: &services.AccountRelationship{ID: account.ID, Following: true},
+			})
+		}},
+		{"reader", func(m Model) Model {
+			statuses := fixtureStatuses()
+			status := statuses[0]
+			status.MediaAttachments = []services.MastodonMedia{{Type: "image", Description: "A terminal showing the feed"}}
+			status.Card = &services.MastodonCard{URL: "https://example.com/terminalpub", Title: "terminalpub", Description: "The fediverse over SSH"}
+			m, _ = m.openReader(status)
+			return send(m, readerRepliesMsg{statusID: status.ID, replies: statuses[1:2]})
+		}},
+	}
+
+	for _, screen := range screens {
+		for _, size := range goldenSizes {
+			name := fmt.Sprintf("%s_%dx%d", screen.name, size.width, size.height)
+			t.Run(name, func(t *testing.T) {
+				m := screen.render(newTestModel(t, size.width, size.height))
+				requireGolden(t, name, m.View())
+			})
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/ssh"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// guestbookSize is how many guestbook entries are loaded
+const guestbookSize = 50
+
+// guestbookPreview is how many of the newest entries the welcome screen shows
+const guestbookPreview = 3
+
+// GuestbookModel represents the guestbook anonymous visitors read and sign
+type GuestbookModel struct {
+	guestbook     *services.GuestbookService
+	ip            string // Visitor's address, for the rate limit
+	publicKey     string // Visitor's SSH key, for the rate limit
+	entries       []models.GuestbookEntry
+	name          textinput.Model
+	message       textinput.Model
+	writing       bool // Signing form shown
+	offset        int  // First entry shown
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// guestbookLoadedMsg is sent when the guestbook entries have been fetched
+type guestbookLoadedMsg struct {
+	entries []models.GuestbookEntry
+	err     error
+}
+
+// guestbookSignedMsg reports the outcome of signing the guestbook
+type guestbookSignedMsg struct {
+	entry *models.GuestbookEntry
+	err   error
+}
+
+// NewGuestbookModel creates a guestbook model for a visitor connected from
+// ip with publicKey, which may be empty
+func NewGuestbookModel(guestbook *services.GuestbookService, ip, publicKey string) GuestbookModel {
+	name := textinput.New()
+	name.Prompt = "Name: "
+	name.Placeholder = "anonymous"
+	name.CharLimit = services.MaxGuestbookNameLength
+
+	message := textinput.New()
+	message.Prompt = "Message: "
+	message.Placeholder = "Say hi to the instance"
+	message.CharLimit = services.MaxGuestbookMessageLength
+
+	return GuestbookModel{
+		guestbook: guestbook,
+		ip:        ip,
+		publicKey: publicKey,
+		name:      name,
+		message:   message,
+		loading:   true,
+	}
+}
+
+// sessionIP returns the address an SSH session connects from
+func sessionIP(s ssh.Session) string {
+	if s == nil || s.RemoteAddr() == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(s.RemoteAddr().String())
+	if err != nil {
+		return s.RemoteAddr().String()
+	}
+	return host
+}
+
+// Init fetches the guestbook entries
+func (m GuestbookModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// Writing reports whether the signing form takes the keys
+func (m GuestbookModel) Writing() bool {
+	return m.writing
+}
+
+// Recent returns up to n of the newest entries
+func (m GuestbookModel) Recent(n int) []models.GuestbookEntry {
+	return m.entries[:min(n, len(m.entries))]
+}
+
+// Update handles messages for the guestbook screen
+func (m GuestbookModel) Update(msg tea.Msg) (GuestbookModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case guestbookLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.entries = msg.entries
+		m.offset = min(m.offset, max(len(m.entries)-1, 0))
+		return m, nil
+
+	case guestbookSignedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.entries = append([]models.GuestbookEntry{*msg.entry}, m.Recent(guestbookSize-1)...)
+		m.offset = 0
+		m.statusMessage = "Thanks for signing the guestbook!"
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.writing {
+			return m.updateForm(msg)
+		}
+		switch msg.String() {
+		case "w", "W":
+			if m.guestbook == nil || !m.guestbook.Enabled() {
+				m.statusMessage = services.ErrGuestbookDisabled.Error()
+				return m, nil
+			}
+			m.writing = true
+			m.statusMessage = ""
+			m.name.Blur()
+			m.message.Focus()
+			return m, textinput.Blink
+		case "down", "j":
+			m.offset = min(m.offset+1, max(len(m.entries)-1, 0))
+		case "up", "k":
+			m.offset = max(m.offset-1, 0)
+		case "ctrl+r":
+			m.loading = true
+			m.statusMessage = ""
+			return m, m.fetchCmd()
+		}
+	}
+	return m, nil
+}
+
+// updateForm handles keys while the guestbook is being signed: Tab moves
+// between the name and the message, Enter signs and Esc cancels
+func (m GuestbookModel) updateForm(msg tea.KeyMsg) (GuestbookModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.writing = false
+		m.name.Blur()
+		m.message.Blur()
+		return m, nil
+	case "tab", "shift+tab":
+		if m.name.Focused() {
+			m.name.Blur()
+			m.message.Focus()
+		} else {
+			m.message.Blur()
+			m.name.Focus()
+		}
+		return m, nil
+	case "enter":
+		if m.name.Focused() {
+			m.name.Blur()
+			m.message.Focus()
+			return m, nil
+		}
+		if strings.TrimSpace(m.message.Value()) == "" {
+			return m, nil
+		}
+		cmd := m.signCmd(m.name.Value(), m.message.Value())
+		m.writing = false
+		m.message.Blur()
+		m.message.SetValue("")
+		m.statusMessage = "Signing..."
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	if m.name.Focused() {
+		m.name, cmd = m.name.Update(msg)
+	} else {
+		m.message, cmd = m.message.Update(msg)
+	}
+	return m, cmd
+}
+
+// fetchCmd loads the newest guestbook entries
+func (m GuestbookModel) fetchCmd() tea.Cmd {
+	if m.guestbook == nil {
+		return nil
+	}
+	guestbook := m.guestbook
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		entries, err := guestbook.Recent(ctx, guestbookSize)
+		return guestbookLoadedMsg{entries: entries, err: err}
+	}
+}
+
+// signCmd adds the visitor's message to the guestbook
+func (m GuestbookModel) signCmd(name, message string) tea.Cmd {
+	guestbook, ip, publicKey := m.guestbook, m.ip, m.publicKey
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		entry, err := guestbook.Sign(ctx, ip, publicKey, name, message)
+		return guestbookSignedMsg{entry: entry, err: err}
+	}
+}
+
+// renderGuestbookEntry renders a guestbook entry wrapped to width
+func renderGuestbookEntry(entry models.GuestbookEntry, width int) string {
+	header := lipgloss.NewStyle().Bold(true).Render(entry.Name) + " " + subtleStyle.Render(formatAge(time.Since(entry.CreatedAt)))
+	return header + "\n" + lipgloss.NewStyle().Width(width).Render(entry.Message)
+}
+
+// View renders the guestbook
+func (m GuestbookModel) View() string {
+	var b strings.Builder
+	width := max(min(m.width, 100)-4, 20)
+
+	b.WriteString(titleStyle.Render("Guestbook") + "\n")
+	b.WriteString(subtleStyle.Render("Leave a note for the people of this instance") + "\n\n")
+
+	if m.writing {
+		name, message := m.name, m.message
+		name.Width = width - len(name.Prompt) - 1
+		message.Width = width - len(message.Prompt) - 1
+		b.WriteString(name.View() + "\n")
+		b.WriteString(message.View() + "\n")
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("%d/%d", len([]rune(m.message.Value())), services.MaxGuestbookMessageLength)) + "\n\n")
+	}
+
+	// Entries fill the lines left above the controls
+	lines := max(m.height-14, 4)
+	if m.writing {
+		lines -= 4
+	}
+	switch {
+	case m.loading:
+		b.WriteString(subtleStyle.Render("Loading...") + "\n")
+	case len(m.entries) == 0:
+		b.WriteString(subtleStyle.Render("Nobody has signed the guestbook yet. Be the first!") + "\n")
+	default:
+		for i := m.offset; i < len(m.entries) && lines > 0; i++ {
+			entry := renderGuestbookEntry(m.entries[i], width)
+			b.WriteString(entry + "\n\n")
+			lines -= strings.Count(entry, "\n") + 2
+		}
+	}
+
+	b.WriteString("\n")
+	if m.statusMessage != "" {
+		if strings.HasPrefix(m.statusMessage, "Error") {
+			b.WriteString(errorStyle.Render(m.statusMessage) + "\n")
+		} else {
+			b.WriteString(subtleStyle.Render(m.statusMessage) + "\n")
+		}
+	}
+	if m.writing {
+		b.WriteString(keyStyle.Render("[Enter]") + " Sign  " +
+			keyStyle.Render("[Tab]") + " Name/Message  " +
+			keyStyle.Render("[Esc]") + " Cancel\n")
+	} else {
+		var controls []string
+		if m.guestbook != nil && m.guestbook.Enabled() {
+			controls = append(controls, keyStyle.Render("[W]")+" Sign")
+		}
+		controls = append(controls,
+			keyStyle.Render("[↑/↓]")+" Scroll",
+			keyStyle.Render("[Ctrl+R]")+" Refresh",
+			keyStyle.Render("[B]")+" Back",
+			keyStyle.Render("[Q]")+" Quit")
+		b.WriteString(strings.Join(controls, "  ") + "\n")
+	}
+
+	return b.String()
+}
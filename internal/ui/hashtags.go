@@ -0,0 +1,205 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// hashtagPageSize is how many posts a hashtag timeline loads at a time
+const hashtagPageSize = 20
+
+// HashtagModel represents the local hashtag timeline screen
+type HashtagModel struct {
+	timelines     *services.TimelineService
+	input         textinput.Model
+	tag           string // Tag of the posts shown
+	statuses      []services.MastodonStatus
+	hasMore       bool
+	selectedIndex int
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// hashtagLoadedMsg is sent when a page of a hashtag timeline is loaded
+type hashtagLoadedMsg struct {
+	tag      string
+	statuses []services.MastodonStatus
+	more     bool // Page follows the ones already shown
+	err      error
+}
+
+// NewHashtagModel creates a new hashtag timeline model
+func NewHashtagModel(timelines *services.TimelineService) HashtagModel {
+	input := textinput.New()
+	input.Prompt = "#"
+	input.Placeholder = "hashtag"
+	input.CharLimit = 100
+	input.Width = 40
+	input.Focus()
+
+	return HashtagModel{timelines: timelines, input: input}
+}
+
+// Init initializes the hashtag model
+func (m HashtagModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Focused reports whether the tag field has focus
+func (m HashtagModel) Focused() bool {
+	return m.input.Focused()
+}
+
+// Open shows the timeline of tag right away instead of asking for one
+func (m HashtagModel) Open(tag string) (HashtagModel, tea.Cmd) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	m.input.SetValue(tag)
+	m.input.Blur()
+	return m, m.loadCmd(tag, "")
+}
+
+// Update handles messages for the hashtag screen
+func (m HashtagModel) Update(msg tea.Msg) (HashtagModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case hashtagLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		if msg.more {
+			m.statuses = append(m.statuses, msg.statuses...)
+		} else {
+			m.tag, m.statuses, m.selectedIndex = msg.tag, msg.statuses, 0
+		}
+		m.hasMore = len(msg.statuses) == hashtagPageSize
+		m.statusMessage = ""
+		if len(m.statuses) == 0 {
+			m.statusMessage = "No local posts use #" + m.tag
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+
+		if m.input.Focused() {
+			switch msg.String() {
+			case "enter":
+				tag := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(m.input.Value()), "#"))
+				if tag == "" {
+					return m, nil
+				}
+				m.input.Blur()
+				return m, m.loadCmd(tag, "")
+			case "esc":
+				if m.tag != "" {
+					m.input.Blur()
+				}
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "/", "#":
+			m.input.Focus()
+			return m, textinput.Blink
+		case "up", "k":
+			if m.selectedIndex > 0 {
+				m.selectedIndex--
+			}
+		case "down", "j":
+			if m.selectedIndex < len(m.statuses)-1 {
+				m.selectedIndex++
+			}
+			// Load the next page on reaching the end
+			if m.selectedIndex == len(m.statuses)-1 && m.hasMore {
+				return m, m.loadCmd(m.tag, m.statuses[len(m.statuses)-1].ID)
+			}
+		case "r", "R":
+			return m, m.loadCmd(m.tag, "")
+		}
+	}
+
+	return m, nil
+}
+
+// loadCmd loads a page of the tag's timeline; an empty maxID loads the newest
+func (m *HashtagModel) loadCmd(tag, maxID string) tea.Cmd {
+	m.loading = true
+	m.statusMessage = "Loading #" + tag + "..."
+	timelines := m.timelines
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		statuses, err := timelines.Tag(ctx, tag, hashtagPageSize, maxID)
+		return hashtagLoadedMsg{tag: tag, statuses: statuses, more: maxID != "", err: err}
+	}
+}
+
+// View renders the hashtag screen
+func (m HashtagModel) View() string {
+	var b strings.Builder
+
+	title := "Local hashtag timeline"
+	if m.tag != "" {
+		title = "#" + m.tag + " on this instance"
+	}
+	b.WriteString(titleStyle.Render(title) + "\n\n")
+	b.WriteString(m.input.View() + "\n\n")
+
+	// Each post takes two lines plus a blank one
+	visible := max((m.height-10)/3, 1)
+	start := 0
+	if m.selectedIndex >= visible {
+		start = m.selectedIndex - visible + 1
+	}
+	end := min(start+visible, len(m.statuses))
+
+	for i := start; i < end; i++ {
+		status := m.statuses[i]
+
+		selector := "  "
+		if i == m.selectedIndex {
+			selector = promptStyle.Render("► ")
+		}
+		name := status.Account.DisplayName
+		if name == "" {
+			name = status.Account.Username
+		}
+		b.WriteString(selector + titleStyle.Render(name) + " " +
+			subtleStyle.Render("@"+status.Account.Acct+" · "+formatTimeAgo(status.CreatedAt)) + "\n")
+		b.WriteString("    " + truncate(stripHTML(status.Content), 70) + "\n\n")
+	}
+
+	if m.input.Focused() {
+		b.WriteString(keyStyle.Render("[Enter]") + " Open  " +
+			keyStyle.Render("[Esc]") + " Back\n")
+	} else {
+		b.WriteString(keyStyle.Render("[#]") + " Another tag  " +
+			keyStyle.Render("[R]") + " Refresh  " +
+			keyStyle.Render("[Esc]") + " Back\n")
+	}
+
+	if m.statusMessage != "" {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
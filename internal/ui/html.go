@@ -0,0 +1,428 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Styles of inline HTML formatting
+var (
+	htmlCodeStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("180"))
+	htmlBoldStyle   = lipgloss.NewStyle().Bold(true)
+	htmlItalicStyle = lipgloss.NewStyle().Italic(true)
+)
+
+// htmlQuotePrefix marks each line of a block quote
+const htmlQuotePrefix = "│ "
+
+// htmlContainer is a block that indents the lines inside it: a quote, or
+// a list item whose marker goes on its first line
+type htmlContainer struct {
+	quote  bool
+	marker string // List marker not yet written, e.g. "• " or "2. "
+	indent int    // Width of the list marker
+}
+
+// htmlList is an open list, numbering its items if ordered
+type htmlList struct {
+	ordered bool
+	next    int // Number of the next item, counted in unordered lists too
+}
+
+// htmlLink is an open link and where its text starts
+type htmlLink struct {
+	href  string
+	start int
+	class string
+}
+
+// htmlRenderer turns the HTML of posts and bios into terminal lines
+type htmlRenderer struct {
+	width      int
+	addresses  bool // Whether links show their address after their text
+	lines      []string
+	inline     strings.Builder // Text of the paragraph being read
+	space      bool            // The paragraph is empty or ends with a space or break
+	containers []htmlContainer
+	lists      []htmlList
+	links      []htmlLink
+	found      []postLink       // Links read so far, other than mentions and hashtags
+	styles     []lipgloss.Style // Open inline formatting, innermost last
+	pre        int              // Depth of <pre>; whitespace is kept inside
+	hidden     int              // Depth of Mastodon's invisible link parts
+	ellipsis   bool             // Inside the visible part of a shortened link
+	blank      bool             // A blank line is due before the next block
+}
+
+// renderHTML renders post HTML as lines of terminal text at most width
+// wide. Paragraphs and line breaks are kept, links show their address
+// unless their text already does, quotes are marked with a bar, list items
+// with a bullet or number, and code is styled; preformatted text keeps its
+// lines and spacing. Text that is not HTML comes out wrapped.
+func renderHTML(content string, width int) []string {
+	return (&htmlRenderer{width: max(width, 10), addresses: true}).render(content)
+}
+
+// render reads content to its end and returns the lines
+func (r *htmlRenderer) render(content string) []string {
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			r.endBlock()
+			if len(r.lines) == 0 {
+				return []string{""}
+			}
+			return r.lines
+		case html.TextToken:
+			r.text(string(tokenizer.Text()))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			r.start(token)
+		case html.EndTagToken:
+			token := tokenizer.Token()
+			r.end(token)
+		}
+	}
+}
+
+// renderHTMLLines renders post HTML like renderHTML, keeping at most
+// maxLines lines; the last one kept ends with an ellipsis when some are cut
+func renderHTMLLines(content string, width, maxLines int) []string {
+	lines := renderHTML(content, width)
+	if len(lines) <= maxLines {
+		return lines
+	}
+	lines = lines[:maxLines]
+	last := strings.TrimRight(lines[maxLines-1], " ")
+	lines[maxLines-1] = ansi.Truncate(last, max(width, 10)-1, "") + "…"
+	return lines
+}
+
+// stripHTML flattens post HTML into a single line of text without link
+// addresses or styles, for lists and searches
+func stripHTML(content string) string {
+	lines := (&htmlRenderer{width: 1 << 20}).render(content)
+	return strings.Join(strings.Fields(ansi.Strip(strings.Join(lines, " "))), " ")
+}
+
+// start handles an opening tag
+func (r *htmlRenderer) start(token html.Token) {
+	switch token.DataAtom {
+	case atom.P, atom.Div, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.endBlock()
+		if isHeading(token.DataAtom) {
+			r.styles = append(r.styles, htmlBoldStyle)
+		}
+	case atom.Br:
+		r.inline.WriteByte('\n')
+		r.space = true
+	case atom.Blockquote:
+		r.endBlock()
+		r.separate()
+		r.containers = append(r.containers, htmlContainer{quote: true})
+	case atom.Ul, atom.Ol:
+		r.endBlock()
+		r.lists = append(r.lists, htmlList{ordered: token.DataAtom == atom.Ol, next: 1})
+	case atom.Li:
+		r.endBlock()
+		marker := "• "
+		if n := len(r.lists); n > 0 {
+			list := &r.lists[n-1]
+			if list.ordered {
+				marker = strconv.Itoa(list.next) + ". "
+			}
+			// Items follow each other without a blank line
+			if list.next > 1 {
+				r.blank = false
+			}
+			list.next++
+		}
+		r.separate()
+		r.containers = append(r.containers, htmlContainer{marker: marker, indent: ansi.StringWidth(marker)})
+	case atom.Pre:
+		r.endBlock()
+		r.pre++
+	case atom.Code:
+		if r.pre == 0 {
+			r.styles = append(r.styles, htmlCodeStyle)
+		}
+	case atom.Strong, atom.B:
+		r.styles = append(r.styles, htmlBoldStyle)
+	case atom.Em, atom.I:
+		r.styles = append(r.styles, htmlItalicStyle)
+	case atom.A:
+		r.links = append(r.links, htmlLink{href: attr(token, "href"), start: r.inline.Len(), class: attr(token, "class")})
+	case atom.Span:
+		switch class := attr(token, "class"); {
+		case r.hidden > 0 || hasClass(class, "invisible"):
+			r.hidden++
+		case hasClass(class, "ellipsis"):
+			r.ellipsis = true
+		}
+	case atom.Img:
+		// Custom emoji and inline images are shown by their description
+		r.text(attr(token, "alt"))
+	}
+}
+
+// end handles a closing tag
+func (r *htmlRenderer) end(token html.Token) {
+	switch token.DataAtom {
+	case atom.P, atom.Div:
+		r.endBlock()
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.endBlock()
+		r.popStyle()
+	case atom.Blockquote:
+		r.endBlock()
+		r.popContainer(true)
+		r.blank = true
+	case atom.Ul, atom.Ol:
+		r.endBlock()
+		if len(r.lists) > 0 {
+			r.lists = r.lists[:len(r.lists)-1]
+		}
+		r.blank = true
+	case atom.Li:
+		r.endBlock()
+		r.popContainer(false)
+		r.blank = false
+	case atom.Pre:
+		r.endBlock()
+		r.pre = max(r.pre-1, 0)
+	case atom.Code:
+		if r.pre == 0 {
+			r.popStyle()
+		}
+	case atom.Strong, atom.B, atom.Em, atom.I:
+		r.popStyle()
+	case atom.A:
+		r.endLink()
+	case atom.Span:
+		if r.hidden > 0 {
+			r.hidden--
+		} else if r.ellipsis {
+			r.ellipsis = false
+			r.inline.WriteString("…")
+		}
+	}
+}
+
+// text adds text to the paragraph, styled by the formatting around it.
+// Outside preformatted text, runs of whitespace become one space.
+func (r *htmlRenderer) text(text string) {
+	if r.hidden > 0 {
+		return
+	}
+	text = stripControl(text)
+	if r.pre == 0 {
+		text = collapseSpace(text, r.inline.Len() == 0 || r.space)
+	}
+	if text == "" {
+		return
+	}
+	r.space = strings.HasSuffix(text, " ") || strings.HasSuffix(text, "\n")
+	if len(r.styles) == 0 {
+		r.inline.WriteString(text)
+		return
+	}
+	style := r.styles[len(r.styles)-1]
+	// Styled one line at a time, so wrapping never splits a style sequence
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			r.inline.WriteByte('\n')
+		}
+		if line != "" {
+			r.inline.WriteString(style.Render(line))
+		}
+	}
+}
+
+// mention reports whether a link with the given plain text is a mention
+// or a hashtag
+func (r *htmlLink) mention(plain string) bool {
+	return strings.HasPrefix(plain, "@") || strings.HasPrefix(plain, "#") ||
+		hasClass(r.class, "mention") || hasClass(r.class, "hashtag")
+}
+
+// shown reports whether a link's text already tells where it leads, as
+// mentions, hashtags and addresses shortened by Mastodon do
+func (r *htmlLink) shown(text string) bool {
+	plain := strings.TrimSpace(ansi.Strip(text))
+	if plain == "" || r.mention(plain) {
+		return true
+	}
+	address := strings.TrimPrefix(strings.TrimPrefix(r.href, "https://"), "http://")
+	plain = strings.TrimPrefix(strings.TrimPrefix(plain, "https://"), "http://")
+	return strings.HasPrefix(address, strings.TrimSuffix(plain, "…"))
+}
+
+// endLink closes the innermost link
+func (r *htmlRenderer) endLink() {
+	if len(r.links) == 0 {
+		return
+	}
+	link := r.links[len(r.links)-1]
+	r.links = r.links[:len(r.links)-1]
+	if link.href == "" || link.start > r.inline.Len() {
+		return
+	}
+	text := r.inline.String()[link.start:]
+	if plain := strings.TrimSpace(ansi.Strip(text)); !link.mention(plain) {
+		r.found = append(r.found, postLink{label: plain, url: link.href})
+	}
+	if r.addresses && !link.shown(text) {
+		r.inline.WriteString(" (" + stripControl(link.href) + ")")
+	}
+}
+
+// endBlock wraps the paragraph read so far into lines, prefixed by the
+// quotes and list items it is in
+func (r *htmlRenderer) endBlock() {
+	text := r.inline.String()
+	r.inline.Reset()
+	if r.pre == 0 {
+		text = strings.TrimSpace(text)
+	} else {
+		text = strings.Trim(text, "\n")
+	}
+	if text == "" {
+		return
+	}
+
+	r.separate()
+	r.blank = true
+
+	width := max(r.width-ansi.StringWidth(r.prefix(false)), 4)
+	if r.pre == 0 {
+		for _, line := range strings.Split(ansi.Wrap(text, width, ""), "\n") {
+			r.lines = append(r.lines, r.prefix(true)+strings.TrimRight(line, " "))
+		}
+		return
+	}
+	// Preformatted lines are cut where they overflow rather than rejoined
+	for _, line := range strings.Split(text, "\n") {
+		for _, part := range strings.Split(ansi.Hardwrap(line, width, true), "\n") {
+			r.lines = append(r.lines, r.prefix(true)+htmlCodeStyle.Render(strings.TrimRight(part, " ")))
+		}
+	}
+}
+
+// separate adds the blank line due before the next block, inside the
+// containers open at this point
+func (r *htmlRenderer) separate() {
+	if r.blank && len(r.lines) > 0 {
+		r.lines = append(r.lines, strings.TrimRight(r.prefix(false), " "))
+	}
+	r.blank = false
+}
+
+// prefix returns what starts a line inside the open containers. With
+// marker, the first line of a list item takes its marker, which is then
+// spent; otherwise the marker's place is left blank.
+func (r *htmlRenderer) prefix(marker bool) string {
+	var b strings.Builder
+	for i := range r.containers {
+		c := &r.containers[i]
+		switch {
+		case c.quote:
+			b.WriteString(htmlQuotePrefix)
+		case marker && c.marker != "":
+			b.WriteString(c.marker)
+			c.marker = ""
+		default:
+			b.WriteString(strings.Repeat(" ", c.indent))
+		}
+	}
+	return b.String()
+}
+
+// popContainer closes the innermost quote, or list item
+func (r *htmlRenderer) popContainer(quote bool) {
+	for i := len(r.containers) - 1; i >= 0; i-- {
+		if r.containers[i].quote == quote {
+			r.containers = r.containers[:i]
+			return
+		}
+	}
+}
+
+// popStyle closes the innermost inline formatting
+func (r *htmlRenderer) popStyle() {
+	if len(r.styles) > 0 {
+		r.styles = r.styles[:len(r.styles)-1]
+	}
+}
+
+// collapseSpace turns runs of whitespace into one space, dropping leading
+// space when the paragraph is empty or already ends with a space
+func collapseSpace(text string, trimLeft bool) string {
+	var b strings.Builder
+	space := trimLeft
+	for _, r := range text {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		b.WriteRune(r)
+		space = false
+	}
+	return b.String()
+}
+
+// stripControl removes the C0 and C1 control characters other than line
+// breaks and tabs from text, such as the ESC and BEL that "&#27;" and "&#7;"
+// decode to, which would otherwise reach the terminal as escape sequences.
+// Tabs become spaces.
+func stripControl(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n':
+			return r
+		case r == '\t':
+			return ' '
+		case unicode.IsControl(r):
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// attr returns the value of a tag's attribute, or "" without it
+func attr(token html.Token, name string) string {
+	for _, a := range token.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasClass reports whether a class attribute lists class
+func hasClass(classes, class string) bool {
+	for _, c := range strings.Fields(classes) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// isHeading reports whether a tag is h1 to h6
+func isHeading(a atom.Atom) bool {
+	switch a {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return true
+	}
+	return false
+}
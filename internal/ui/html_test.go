@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRenderHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		width   int
+		want    []string
+	}{
+		{
+			name:    "paragraphs and breaks",
+			content: "<p>First   paragraph</p><p>Second<br>line &amp; more</p>",
+			width:   40,
+			want:    []string{"First paragraph", "", "Second", "line & more"},
+		},
+		{
+			name:    "wrapping",
+			content: "<p>one two three four five six</p>",
+			width:   14,
+			want:    []string{"one two three", "four five six"},
+		},
+		{
+			name:    "links",
+			content: `<p>Read <a href="https://example.com/post">this post</a></p>`,
+			width:   60,
+			want:    []string{"Read this post (https://example.com/post)"},
+		},
+		{
+			name: "mentions, hashtags and shortened links",
+			content: `<p><span class="h-card"><a href="https://example.social/@bob" class="u-url mention">@<span>bob</span></a></span> ` +
+				`<a href="https://example.social/tags/go" class="mention hashtag">#<span>go</span></a> ` +
+				`<a href="https://example.com/a/very/long/path"><span class="invisible">https://</span>` +
+				`<span class="ellipsis">example.com/a/very</span><span class="invisible">/long/path</span></a></p>`,
+			width: 60,
+			want:  []string{"@bob #go example.com/a/very…"},
+		},
+		{
+			name:    "quotes",
+			content: "<blockquote><p>Quoted</p><p>twice</p></blockquote><p>Reply</p>",
+			width:   40,
+			want:    []string{"│ Quoted", "│", "│ twice", "", "Reply"},
+		},
+		{
+			name:    "lists",
+			content: "<ul><li>apples</li><li>pears</li></ul><ol><li>first</li><li>second item wraps</li></ol>",
+			width:   14,
+			want:    []string{"• apples", "• pears", "", "1. first", "2. second item", "   wraps"},
+		},
+		{
+			name:    "preformatted code",
+			content: "<pre><code>if x {\n    y()\n}</code></pre><p>Use <code>go   vet</code></p>",
+			width:   40,
+			want:    []string{"if x {", "    y()", "}", "", "Use go vet"},
+		},
+		{
+			name:    "control characters",
+			content: "<p>a&#27;]52;c;cHduZWQ=&#7;b\x1b[2Jc\u009b31md\u0085e\r</p><pre>x\ty</pre>",
+			width:   40,
+			want:    []string{"a]52;c;cHduZWQ=b[2Jc31mde", "", "x y"},
+		},
+		{
+			name:    "control characters in links",
+			content: `<p><a href="https://example.com/&#27;]0;pwned&#7;">here</a></p>`,
+			width:   60,
+			want:    []string{"here (https://example.com/]0;pwned)"},
+		},
+		{
+			name:    "plain text",
+			content: "no markup here",
+			width:   40,
+			want:    []string{"no markup here"},
+		},
+		{
+			name:    "empty",
+			content: "",
+			width:   40,
+			want:    []string{""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, line := range renderHTML(tt.content, tt.width) {
+				got = append(got, ansi.Strip(line))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("renderHTML(%q) =\n%q\nwant\n%q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderHTMLControlCharacters(t *testing.T) {
+	// Only the renderer's own styles may reach the terminal as escape sequences
+	content := "<p>a&#27;]0;title&#7;b <em>c&#27;[2J</em> <a href=\"https://example.com/&#27;]52;c;eA==&#7;\">d</a></p><pre>e\u009b31m</pre>"
+	for _, line := range renderHTML(content, 80) {
+		for _, r := range ansi.Strip(line) {
+			if r < 0x20 || r >= 0x7f && r < 0xa0 {
+				t.Errorf("control character %U in %q", r, line)
+			}
+		}
+		if strings.Contains(line, "\a") || strings.Contains(line, "\x1b]") || strings.Contains(line, "\x1b[2J") {
+			t.Errorf("escape sequence from the content in %q", line)
+		}
+	}
+}
+
+func TestRenderHTMLLines(t *testing.T) {
+	got := renderHTMLLines("<p>one</p><p>two</p><p>three</p>", 40, 3)
+	want := []string{"one", "", "two…"}
+	if !slices.Equal(got, want) {
+		t.Errorf("renderHTMLLines = %q, want %q", got, want)
+	}
+}
+
+func TestStripHTML(t *testing.T) {
+	got := stripHTML(`<p>Hello <a href="https://example.com">there</a></p><p>&lt;friend&gt;</p>`)
+	if want := "Hello there <friend>"; got != want {
+		t.Errorf("stripHTML = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// idleCheckInterval is how often the session checks how long it has been idle
+const idleCheckInterval = 30 * time.Second
+
+// idleTickMsg triggers a check of how long the session has been idle
+type idleTickMsg time.Time
+
+// unlockMsg reports whether the session's SSH key still signs in the user
+// who locked the screen
+type unlockMsg struct {
+	ok bool
+}
+
+// idleTickCmd schedules the next idle check
+func idleTickCmd() tea.Cmd {
+	return tea.Tick(idleCheckInterval, func(t time.Time) tea.Msg {
+		return idleTickMsg(t)
+	})
+}
+
+// idleLimits returns how long a session may be idle before it is locked and
+// before it is closed; zero means never
+func (m Model) idleLimits() (lock, disconnect time.Duration) {
+	if m.ctx == nil || m.ctx.Config == nil {
+		return 0, 0
+	}
+	tui := m.ctx.Config.TUI
+	return time.Duration(max(tui.IdleLock, 0)) * time.Minute, time.Duration(max(tui.IdleDisconnect, 0)) * time.Minute
+}
+
+// checkIdle locks the screen of a signed-in user who stepped away, and ends
+// sessions idle for longer to free the resources they hold
+func (m Model) checkIdle() (Model, tea.Cmd) {
+	idle := time.Since(m.lastKey)
+	lock, disconnect := m.idleLimits()
+
+	if disconnect > 0 && idle >= disconnect {
+		var saveCmd tea.Cmd
+		if m.screen == screenFeed && !m.locked {
+			saveCmd = m.saveReadMarkerCmd()
+		}
+		return m, tea.Sequence(saveCmd, tea.Quit)
+	}
+	if lock > 0 && idle >= lock && m.authenticated && m.user != nil && !m.locked {
+		m.locked = true
+		m.menu = nil
+		m.palette = nil
+		m.message = ""
+	}
+	return m, idleTickCmd()
+}
+
+// handleLockedKey unlocks the screen. Sessions signed in with an SSH key
+// check it again first, so a key removed meanwhile no longer gets in.
+func (m Model) handleLockedKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+	if m.unlocking {
+		return m, nil
+	}
+	if m.publicKey == "" || m.ctx == nil || m.ctx.SSHKeyService == nil {
+		m.locked = false
+		return m, nil
+	}
+	m.unlocking = true
+	return m, unlockCmd(m.ctx, m.publicKey, m.user.ID)
+}
+
+// unlockCmd checks that the session's SSH key still belongs to userID
+func unlockCmd(ctx *AppContext, publicKey string, userID int) tea.Cmd {
+	return func() tea.Msg {
+		checkCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		user, err := ctx.SSHKeyService.GetUserBySSHKey(checkCtx, publicKey)
+		return unlockMsg{ok: err == nil && user != nil && user.ID == userID}
+	}
+}
+
+// handleUnlock resumes the session, or ends it when the key was revoked
+func (m Model) handleUnlock(msg unlockMsg) (Model, tea.Cmd) {
+	m.unlocking = false
+	if !msg.ok {
+		m.message = "Your SSH key is no longer linked to this account."
+		return m, tea.Quit
+	}
+	m.locked = false
+	m.lastKey = time.Now()
+	return m, nil
+}
+
+// renderLock renders the blank screen shown while the session is locked
+func (m Model) renderLock() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Session locked") + "\n\n")
+	if m.user != nil {
+		b.WriteString(subtleStyle.Render("Signed in as "+m.user.Username) + "\n\n")
+	}
+	switch {
+	case m.message != "":
+		b.WriteString(errorStyle.Render(m.message) + "\n")
+	case m.unlocking:
+		b.WriteString(subtleStyle.Render("Checking your SSH key...") + "\n")
+	default:
+		b.WriteString("Press any key to resume.\n")
+	}
+	if _, disconnect := m.idleLimits(); disconnect > 0 {
+		b.WriteString("\n" + subtleStyle.Render(fmt.Sprintf("Sessions idle for %d minutes are closed", int(disconnect.Minutes()))) + "\n")
+	}
+
+	return m.centerContent(b.String())
+}
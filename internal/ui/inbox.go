@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// InboxModel represents the unified inbox of mentions and direct messages
+type InboxModel struct {
+	userID          int
+	mastodonService *services.MastodonService
+	items           []services.InboxItem
+	selectedIndex   int
+	loading         bool
+	statusMessage   string
+	width           int
+	height          int
+}
+
+// inboxLoadedMsg is sent when the inbox has been fetched
+type inboxLoadedMsg struct {
+	items []services.InboxItem
+	err   error
+}
+
+// NewInboxModel creates a new inbox model
+func NewInboxModel(userID int, mastodonService *services.MastodonService) InboxModel {
+	return InboxModel{
+		userID:          userID,
+		mastodonService: mastodonService,
+		loading:         true,
+		statusMessage:   "Loading inbox...",
+	}
+}
+
+// Init fetches the inbox
+func (m InboxModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// SelectedItem returns the selected inbox entry
+func (m InboxModel) SelectedItem() *services.InboxItem {
+	if m.selectedIndex >= 0 && m.selectedIndex < len(m.items) {
+		return &m.items[m.selectedIndex]
+	}
+	return nil
+}
+
+// MarkSelectedRead marks the selected conversation read and returns the
+// command that tells the server
+func (m InboxModel) MarkSelectedRead() (InboxModel, tea.Cmd) {
+	item := m.SelectedItem()
+	if item == nil || !item.Unread {
+		return m, nil
+	}
+	m.items[m.selectedIndex].Unread = false
+	if item.ConversationID == "" {
+		return m, nil
+	}
+
+	svc, userID, conversationID := m.mastodonService, m.userID, item.ConversationID
+	return m, func() tea.Msg {
+		if err := svc.MarkConversationRead(context.Background(), userID, conversationID); err != nil {
+			fmt.Printf("Failed to mark conversation read: %v\n", err)
+		}
+		return nil
+	}
+}
+
+// Update handles messages for the inbox
+func (m InboxModel) Update(msg tea.Msg) (InboxModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case inboxLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.items = msg.items
+		if m.selectedIndex >= len(m.items) {
+			m.selectedIndex = 0
+		}
+		m.statusMessage = ""
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.selectedIndex > 0 {
+				m.selectedIndex--
+			}
+		case "down", "j":
+			if m.selectedIndex < len(m.items)-1 {
+				m.selectedIndex++
+			}
+		case "ctrl+r":
+			m.loading = true
+			m.statusMessage = "Refreshing..."
+			return m, m.fetchCmd()
+		}
+	}
+
+	return m, nil
+}
+
+// fetchCmd loads mentions and conversations
+func (m InboxModel) fetchCmd() tea.Cmd {
+	svc, userID := m.mastodonService, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		items, err := svc.GetInbox(ctx, userID, 40)
+		return inboxLoadedMsg{items: items, err: err}
+	}
+}
+
+// View renders the inbox
+func (m InboxModel) View() string {
+	var b strings.Builder
+
+	unread := 0
+	for _, item := range m.items {
+		if item.Unread {
+			unread++
+		}
+	}
+	title := "Inbox"
+	if unread > 0 {
+		title = fmt.Sprintf("Inbox (%d unread)", unread)
+	}
+	b.WriteString(titleStyle.Render(title) + "\n\n")
+
+	if m.loading {
+		b.WriteString(subtleStyle.Render(m.statusMessage) + "\n")
+		return b.String()
+	}
+
+	if len(m.items) == 0 {
+		b.WriteString("No mentions or direct messages yet.\n\n")
+	}
+
+	// Each entry takes two lines plus a blank one
+	visible := max((m.height-8)/3, 1)
+	start := 0
+	if m.selectedIndex >= visible {
+		start = m.selectedIndex - visible + 1
+	}
+	end := min(start+visible, len(m.items))
+
+	for i := start; i < end; i++ {
+		item := m.items[i]
+
+		selector := "  "
+		if i == m.selectedIndex {
+			selector = promptStyle.Render("► ")
+		}
+
+		kind := subtleStyle.Render("@")
+		if item.Kind == services.InboxDirect {
+			kind = keyStyle.Render("DM")
+		}
+
+		var accts []string
+		for _, account := range item.Accounts {
+			accts = append(accts, "@"+account.Acct)
+		}
+		header := fmt.Sprintf("%s%s %s %s", selector, kind, titleStyle.Render(strings.Join(accts, ", ")),
+			subtleStyle.Render(formatAge(time.Since(item.At))))
+		if item.Unread {
+			header += " " + successStyle.Render("●")
+		}
+		b.WriteString(header + "\n")
+		b.WriteString("    " + truncate(stripHTML(item.Status.Content), 70) + "\n\n")
+	}
+
+	b.WriteString(keyStyle.Render("[R]") + " Quick reply  " +
+		keyStyle.Render("[Enter]") + " Open thread  " +
+		keyStyle.Render("[Ctrl+R]") + " Refresh  " +
+		keyStyle.Render("[Esc]") + " Back\n")
+
+	if m.statusMessage != "" {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// remoteReadOnlyKeys are the feed keys that act on a post through the
+// user's own instance, which doesn't know the IDs another instance gives
+// its posts
+var remoteReadOnlyKeys = map[string]bool{
+	"enter": true, "x": true, "X": true, "e": true, "E": true, "s": true, "S": true,
+	"r": true, "R": true, "t": true, "T": true, "p": true, "P": true,
+}
+
+// openInstance browses the public local timeline of another instance,
+// reopening the command line when the domain is invalid or blocked
+func (m Model) openInstance(text string) (Model, tea.Cmd) {
+	domain, err := services.NormalizeDomain(text)
+	if err != nil {
+		return m.reopenPalette("instance "+text, fmt.Sprintf("Error: %v", err))
+	}
+	if m.ctx != nil && m.ctx.Config != nil && m.ctx.Config.InstanceBlocked(domain) {
+		return m.reopenPalette("instance "+text, "Error: "+services.ErrInstanceBlocked.Error())
+	}
+	return m.openTimeline(services.InstanceTimeline(domain))
+}
+
+// remoteReadOnly reports whether key is an interaction refused because the
+// feed shows another instance, explaining why in the status line
+func (m *Model) remoteReadOnly(key string) bool {
+	domain, remote := m.feed.timelineType.Instance()
+	if !remote || !remoteReadOnlyKeys[key] {
+		return false
+	}
+	m.feed.statusMessage = fmt.Sprintf("Browsing %s read-only: press I for the post's link", domain)
+	return true
+}
+
+// renderLoginRequired explains, as a warning rather than an error, that an
+// instance keeps its timeline to its own users
+func (m *Model) renderLoginRequired() string {
+	var b strings.Builder
+	name := getTimelineName(m.feed.timelineType)
+
+	b.WriteString(strings.Repeat("─", m.width) + "\n")
+	b.WriteString(fmt.Sprintf("  %s Timeline\n", name))
+	b.WriteString(strings.Repeat("─", m.width) + "\n\n")
+	b.WriteString("  " + promptStyle.Render(name+" only shows its timeline to people signed in there.") + "\n")
+	b.WriteString("  " + subtleStyle.Render("Its posts still reach you when you follow its users.") + "\n\n")
+	b.WriteString("  [B] Back  [H] Home  [Q] Quit\n\n")
+	b.WriteString(strings.Repeat("─", m.width) + "\n")
+
+	return b.String()
+}
+
+// openRemoteDetail shows the details of a post from another instance. Its
+// edit history is left out, as the user's instance would look it up by an
+// ID that means another post there.
+func (m Model) openRemoteDetail(status services.MastodonStatus) Model {
+	m, _ = m.openDetail(status)
+	m.detail.loading = false
+	return m
+}
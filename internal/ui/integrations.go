@@ -0,0 +1,644 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// Networks listed on the integrations screen, in order
+const (
+	integrationBluesky = iota
+	integrationNostr
+	integrationMatrix
+	integrationCount
+)
+
+// Forms of the integrations screen
+const (
+	integrationFormNone    = iota
+	integrationFormBluesky // Handle, app password and server
+	integrationFormNostr   // Secret key
+	integrationFormRelays  // Nostr relays
+	integrationFormMatrix  // Homeserver, access token and room
+)
+
+// IntegrationsModel represents the screen linking the other networks new
+// posts can be cross-posted to and notifications forwarded to
+type IntegrationsModel struct {
+	bluesky       *services.BlueskyService
+	nostr         *services.NostrService
+	matrix        *services.MatrixService
+	userID        int
+	section       int                    // Selected network
+	blueskyAcct   *models.BlueskyAccount // Linked Bluesky account, if any
+	nostrAcct     *models.NostrAccount   // Linked Nostr key, if any
+	deliveries    []models.NostrDelivery // How relays took the latest note
+	matrixBridge  *models.MatrixBridge   // Matrix room notifications go to, if any
+	form          int                    // Open form, integrationFormNone if none
+	inputs        []textinput.Model      // Fields of the open form
+	focus         int
+	unlinking     bool // Waiting for the unlinking to be confirmed
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// integrationsLoadedMsg carries the user's linked accounts
+type integrationsLoadedMsg struct {
+	bluesky    *models.BlueskyAccount
+	nostr      *models.NostrAccount
+	deliveries []models.NostrDelivery
+	matrix     *models.MatrixBridge
+	err        error
+}
+
+// integrationUpdatedMsg reports the outcome of changing a linked account;
+// form is reopened if that failed, so its input can be corrected
+type integrationUpdatedMsg struct {
+	message string
+	form    int
+	err     error
+}
+
+// NewIntegrationsModel creates the integrations screen of a user
+func NewIntegrationsModel(bluesky *services.BlueskyService, nostr *services.NostrService, matrix *services.MatrixService, userID int) IntegrationsModel {
+	return IntegrationsModel{
+		bluesky: bluesky,
+		nostr:   nostr,
+		matrix:  matrix,
+		userID:  userID,
+		loading: true,
+	}
+}
+
+// Init loads the linked accounts
+func (m IntegrationsModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// Editing reports whether a form takes the keys
+func (m IntegrationsModel) Editing() bool {
+	return m.form != integrationFormNone
+}
+
+// enabled reports whether the instance offers a network
+func (m IntegrationsModel) enabled(section int) bool {
+	switch section {
+	case integrationBluesky:
+		return m.bluesky != nil && m.bluesky.Enabled()
+	case integrationNostr:
+		return m.nostr != nil && m.nostr.Enabled()
+	case integrationMatrix:
+		return m.matrix != nil && m.matrix.Enabled()
+	}
+	return false
+}
+
+// linked reports whether the user linked the selected network
+func (m IntegrationsModel) linked() bool {
+	switch m.section {
+	case integrationBluesky:
+		return m.blueskyAcct != nil
+	case integrationNostr:
+		return m.nostrAcct != nil
+	}
+	return m.matrixBridge != nil
+}
+
+// Update handles messages for the integrations screen
+func (m IntegrationsModel) Update(msg tea.Msg) (IntegrationsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case integrationsLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.blueskyAcct, m.nostrAcct, m.deliveries, m.matrixBridge = msg.bluesky, msg.nostr, msg.deliveries, msg.matrix
+		return m, nil
+
+	case integrationUpdatedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			if msg.form != integrationFormNone && m.inputs != nil {
+				m.form = msg.form
+				return m, m.focusInput(m.focus)
+			}
+			return m, nil
+		}
+		m.inputs = nil
+		m.statusMessage = msg.message
+		return m, m.fetchCmd()
+
+	case tea.KeyMsg:
+		if m.form != integrationFormNone {
+			return m.updateForm(msg)
+		}
+		if m.unlinking {
+			m.unlinking = false
+			if msg.String() == "y" || msg.String() == "Y" {
+				m.statusMessage = "Unlinking..."
+				return m, m.unlinkCmd()
+			}
+			m.statusMessage = ""
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "up", "k", "shift+tab":
+			m.section = (m.section + integrationCount - 1) % integrationCount
+			m.statusMessage = ""
+			return m, nil
+		case "down", "j", "tab":
+			m.section = (m.section + 1) % integrationCount
+			m.statusMessage = ""
+			return m, nil
+		case "ctrl+r":
+			m.loading = true
+			return m, m.fetchCmd()
+		}
+		if !m.enabled(m.section) || m.loading {
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "l", "L":
+			return m.openForm()
+		case "u", "U":
+			if m.section == integrationBluesky && m.blueskyAcct != nil {
+				m.unlinking = true
+				m.statusMessage = fmt.Sprintf("Unlink @%s? y/n", m.blueskyAcct.Handle)
+			} else if m.section == integrationNostr && m.nostrAcct != nil {
+				m.unlinking = true
+				m.statusMessage = "Forget your Nostr key? Notes already published stay on the relays. y/n"
+			} else if m.section == integrationMatrix && m.matrixBridge != nil {
+				m.unlinking = true
+				m.statusMessage = "Stop forwarding to Matrix? y/n"
+			}
+		case "c", "C":
+			if m.linked() && m.section != integrationMatrix {
+				return m, m.toggleCrossPostCmd()
+			}
+		case "m", "M":
+			if m.section == integrationMatrix && m.matrixBridge != nil {
+				return m, m.setForwardingCmd(!m.matrixBridge.ForwardMentions, m.matrixBridge.ForwardDirect)
+			}
+		case "d", "D":
+			if m.section == integrationMatrix && m.matrixBridge != nil {
+				return m, m.setForwardingCmd(m.matrixBridge.ForwardMentions, !m.matrixBridge.ForwardDirect)
+			}
+		case "t", "T":
+			if m.section == integrationMatrix && m.matrixBridge != nil {
+				m.statusMessage = "Sending..."
+				return m, m.testMatrixCmd()
+			}
+		case "r", "R":
+			if m.section == integrationNostr && m.nostrAcct != nil {
+				m.form = integrationFormRelays
+				relays := textinput.New()
+				relays.Prompt = "Relays: "
+				relays.Placeholder = strings.Join(m.nostr.DefaultRelays(), " ")
+				relays.SetValue(strings.Join(m.nostrAcct.Relays, " "))
+				m.inputs = []textinput.Model{relays}
+				m.statusMessage = ""
+				return m, m.focusInput(0)
+			}
+		}
+	}
+	return m, nil
+}
+
+// openForm opens the form linking the selected network
+func (m IntegrationsModel) openForm() (IntegrationsModel, tea.Cmd) {
+	m.statusMessage = ""
+	if m.section == integrationBluesky {
+		handle := textinput.New()
+		handle.Prompt = "Handle: "
+		handle.Placeholder = "alice.bsky.social"
+		if m.blueskyAcct != nil {
+			handle.SetValue(m.blueskyAcct.Handle)
+		}
+
+		password := textinput.New()
+		password.Prompt = "App password: "
+		password.Placeholder = "xxxx-xxxx-xxxx-xxxx"
+		password.EchoMode = textinput.EchoPassword
+
+		server := textinput.New()
+		server.Prompt = "Server: "
+		server.Placeholder = "bsky.social (leave empty unless you host your own)"
+
+		m.form = integrationFormBluesky
+		m.inputs = []textinput.Model{handle, password, server}
+		return m, m.focusInput(0)
+	}
+	if m.section == integrationMatrix {
+		homeserver := textinput.New()
+		homeserver.Prompt = "Homeserver: "
+		homeserver.Placeholder = "matrix.org"
+
+		token := textinput.New()
+		token.Prompt = "Access token: "
+		token.EchoMode = textinput.EchoPassword
+
+		room := textinput.New()
+		room.Prompt = "Room: "
+		room.Placeholder = "#alerts:matrix.org or !abc123:matrix.org"
+		if m.matrixBridge != nil {
+			homeserver.SetValue(strings.TrimPrefix(m.matrixBridge.Homeserver, "https://"))
+			room.SetValue(m.matrixBridge.RoomID)
+		}
+
+		m.form = integrationFormMatrix
+		m.inputs = []textinput.Model{homeserver, token, room}
+		return m, m.focusInput(0)
+	}
+
+	key := textinput.New()
+	key.Prompt = "Secret key: "
+	key.Placeholder = "nsec1..."
+	key.EchoMode = textinput.EchoPassword
+	m.form = integrationFormNostr
+	m.inputs = []textinput.Model{key}
+	return m, m.focusInput(0)
+}
+
+// focusInput moves the focus of the open form to input i
+func (m *IntegrationsModel) focusInput(i int) tea.Cmd {
+	m.focus = i
+	for j := range m.inputs {
+		m.inputs[j].Blur()
+	}
+	return m.inputs[i].Focus()
+}
+
+// updateForm handles keys while a form is open: Tab moves between the
+// fields, Enter saves and Esc cancels
+func (m IntegrationsModel) updateForm(msg tea.KeyMsg) (IntegrationsModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.form = integrationFormNone
+		m.inputs = nil
+		m.statusMessage = ""
+		return m, nil
+	case "tab", "down":
+		return m, m.focusInput((m.focus + 1) % len(m.inputs))
+	case "shift+tab", "up":
+		return m, m.focusInput((m.focus + len(m.inputs) - 1) % len(m.inputs))
+	case "enter":
+		// Enter moves on to the next field the form needs
+		if (m.form == integrationFormBluesky && m.focus == 0) || (m.form == integrationFormMatrix && m.focus < len(m.inputs)-1) {
+			return m, m.focusInput(m.focus + 1)
+		}
+		form := m.form
+		m.form = integrationFormNone
+		switch form {
+		case integrationFormBluesky:
+			m.statusMessage = "Signing in to Bluesky..."
+			return m, m.linkBlueskyCmd(m.inputs[0].Value(), m.inputs[1].Value(), m.inputs[2].Value())
+		case integrationFormNostr:
+			m.statusMessage = "Saving..."
+			return m, m.linkNostrCmd(m.inputs[0].Value())
+		case integrationFormMatrix:
+			m.statusMessage = "Joining the room..."
+			return m, m.linkMatrixCmd(m.inputs[0].Value(), m.inputs[1].Value(), m.inputs[2].Value())
+		case integrationFormRelays:
+			m.statusMessage = "Saving..."
+			return m, m.setRelaysCmd(strings.FieldsFunc(m.inputs[0].Value(), func(r rune) bool {
+				return r == ',' || r == ' '
+			}))
+		}
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+// fetchCmd loads the user's linked accounts
+func (m IntegrationsModel) fetchCmd() tea.Cmd {
+	bluesky, nostr, matrix, userID := m.bluesky, m.nostr, m.matrix, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		var msg integrationsLoadedMsg
+		if bluesky.Enabled() {
+			if msg.bluesky, msg.err = bluesky.Account(ctx, userID); msg.err != nil {
+				return msg
+			}
+		}
+		if nostr.Enabled() {
+			if msg.nostr, msg.err = nostr.Account(ctx, userID); msg.err != nil {
+				return msg
+			}
+			if msg.deliveries, msg.err = nostr.Deliveries(ctx, userID); msg.err != nil {
+				return msg
+			}
+		}
+		if matrix.Enabled() {
+			msg.matrix, msg.err = matrix.Bridge(ctx, userID)
+		}
+		return msg
+	}
+}
+
+// linkBlueskyCmd signs in to Bluesky and links the account
+func (m IntegrationsModel) linkBlueskyCmd(handle, appPassword, server string) tea.Cmd {
+	bluesky, userID := m.bluesky, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		account, err := bluesky.Link(ctx, userID, handle, appPassword, server)
+		if err != nil {
+			return integrationUpdatedMsg{form: integrationFormBluesky, err: err}
+		}
+		return integrationUpdatedMsg{message: "Linked @" + account.Handle}
+	}
+}
+
+// linkNostrCmd stores the Nostr key notes are signed with
+func (m IntegrationsModel) linkNostrCmd(secretKey string) tea.Cmd {
+	nostr, userID := m.nostr, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		account, err := nostr.Link(ctx, userID, secretKey)
+		if err != nil {
+			return integrationUpdatedMsg{form: integrationFormNostr, err: err}
+		}
+		return integrationUpdatedMsg{message: "Linked " + services.NostrNpub(account.PublicKey)}
+	}
+}
+
+// setRelaysCmd sets the relays Nostr notes go to
+func (m IntegrationsModel) setRelaysCmd(relays []string) tea.Cmd {
+	nostr, userID := m.nostr, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := nostr.SetRelays(ctx, userID, relays); err != nil {
+			return integrationUpdatedMsg{form: integrationFormRelays, err: err}
+		}
+		return integrationUpdatedMsg{message: "Relays saved"}
+	}
+}
+
+// linkMatrixCmd checks the Matrix access token, joins the room and starts
+// forwarding to it
+func (m IntegrationsModel) linkMatrixCmd(homeserver, accessToken, room string) tea.Cmd {
+	matrix, userID := m.matrix, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		bridge, err := matrix.Link(ctx, userID, homeserver, accessToken, room)
+		if err != nil {
+			return integrationUpdatedMsg{form: integrationFormMatrix, err: err}
+		}
+		return integrationUpdatedMsg{message: "Forwarding to " + bridge.RoomID + " as " + bridge.MatrixUserID}
+	}
+}
+
+// setForwardingCmd chooses what is forwarded to Matrix
+func (m IntegrationsModel) setForwardingCmd(mentions, direct bool) tea.Cmd {
+	matrix, userID := m.matrix, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return integrationUpdatedMsg{message: "Saved", err: matrix.SetForwarding(ctx, userID, mentions, direct)}
+	}
+}
+
+// testMatrixCmd sends a test message to the Matrix room
+func (m IntegrationsModel) testMatrixCmd() tea.Cmd {
+	matrix, userID := m.matrix, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		return integrationUpdatedMsg{message: "Test message sent", err: matrix.Test(ctx, userID)}
+	}
+}
+
+// unlinkCmd forgets the account of the selected network
+func (m IntegrationsModel) unlinkCmd() tea.Cmd {
+	bluesky, nostr, matrix, userID, section := m.bluesky, m.nostr, m.matrix, m.userID, m.section
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		switch section {
+		case integrationBluesky:
+			return integrationUpdatedMsg{message: "Bluesky account unlinked", err: bluesky.Unlink(ctx, userID)}
+		case integrationNostr:
+			return integrationUpdatedMsg{message: "Nostr key forgotten", err: nostr.Unlink(ctx, userID)}
+		}
+		return integrationUpdatedMsg{message: "Matrix forwarding stopped", err: matrix.Unlink(ctx, userID)}
+	}
+}
+
+// toggleCrossPostCmd flips whether new posts go to the selected network by
+// default
+func (m IntegrationsModel) toggleCrossPostCmd() tea.Cmd {
+	bluesky, nostr, userID, section := m.bluesky, m.nostr, m.userID, m.section
+	name, crossPost := "Bluesky", false
+	if section == integrationBluesky {
+		crossPost = !m.blueskyAcct.CrossPost
+	} else {
+		name, crossPost = "Nostr", !m.nostrAcct.CrossPost
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		var err error
+		if section == integrationBluesky {
+			err = bluesky.SetCrossPost(ctx, userID, crossPost)
+		} else {
+			err = nostr.SetCrossPost(ctx, userID, crossPost)
+		}
+		message := "New posts go to " + name + " unless unchecked"
+		if !crossPost {
+			message = "New posts go to " + name + " only when checked"
+		}
+		return integrationUpdatedMsg{message: message, err: err}
+	}
+}
+
+// View renders the integrations screen
+func (m IntegrationsModel) View() string {
+	var b strings.Builder
+	width := max(min(m.width, 100)-4, 40)
+
+	b.WriteString(titleStyle.Render("Integrations") + "\n")
+	b.WriteString(subtleStyle.Render("Other networks your posts can go to, chosen for each post with Ctrl+T when composing, and where to hear about mentions while away.") + "\n\n")
+
+	for section, name := range []string{"Bluesky", "Nostr", "Matrix"} {
+		selector := "  "
+		if section == m.section {
+			selector = promptStyle.Render("► ")
+		}
+		b.WriteString(selector + promptStyle.Render(name) + "\n")
+		switch {
+		case !m.enabled(section):
+			b.WriteString("  " + subtleStyle.Render("Not enabled on this instance") + "\n")
+		case m.loading:
+			b.WriteString("  " + subtleStyle.Render("Loading...") + "\n")
+		case section == integrationBluesky:
+			b.WriteString(m.renderBluesky(width))
+		case section == integrationNostr:
+			b.WriteString(m.renderNostr(width))
+		default:
+			b.WriteString(m.renderMatrix(width))
+		}
+		b.WriteString("\n")
+	}
+
+	switch {
+	case m.form != integrationFormNone:
+		for _, input := range m.inputs {
+			input.Width = width - len(input.Prompt) - 1
+			b.WriteString(input.View() + "\n")
+		}
+		switch m.form {
+		case integrationFormBluesky:
+			b.WriteString("\n" + subtleStyle.Render("Create an app password in Bluesky under Settings → Privacy and security → App passwords; never use your main password.") + "\n")
+		case integrationFormNostr:
+			b.WriteString("\n" + subtleStyle.Render("Your key is stored encrypted and only used to sign the posts you send to Nostr.") + "\n")
+		case integrationFormMatrix:
+			b.WriteString("\n" + subtleStyle.Render("Best with an account of its own. In Element, its access token is under Settings → Help & About; invite it to the room first if it is private.") + "\n")
+		case integrationFormRelays:
+			b.WriteString("\n" + subtleStyle.Render(fmt.Sprintf("wss:// URLs separated by spaces, up to %d; leave empty for the instance's.", services.MaxNostrRelays)) + "\n")
+		}
+		b.WriteString("\n" + keyStyle.Render("[Enter]") + " Save  " +
+			keyStyle.Render("[Tab]") + " Field  " +
+			keyStyle.Render("[Esc]") + " Cancel\n")
+	case m.linked() && m.section == integrationMatrix:
+		b.WriteString(keyStyle.Render("[↑/↓]") + " Select  " +
+			keyStyle.Render("[M]") + " Mentions  " +
+			keyStyle.Render("[D]") + " DMs  " +
+			keyStyle.Render("[T]") + " Test  " +
+			keyStyle.Render("[L]") + " Relink  " +
+			keyStyle.Render("[U]") + " Unlink  " +
+			keyStyle.Render("[Esc]") + " Back\n")
+	case m.linked():
+		keys := keyStyle.Render("[↑/↓]") + " Select  " +
+			keyStyle.Render("[C]") + " Change default  " +
+			keyStyle.Render("[L]") + " Relink  "
+		if m.section == integrationNostr {
+			keys += keyStyle.Render("[R]") + " Relays  "
+		}
+		b.WriteString(keys + keyStyle.Render("[U]") + " Unlink  " + keyStyle.Render("[Esc]") + " Back\n")
+	default:
+		keys := keyStyle.Render("[↑/↓]") + " Select  "
+		if m.enabled(m.section) {
+			keys += keyStyle.Render("[L]") + " Link  "
+		}
+		b.WriteString(keys + keyStyle.Render("[Esc]") + " Back\n")
+	}
+
+	if m.statusMessage != "" {
+		msgStyle := subtleStyle
+		if strings.HasPrefix(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+	return b.String()
+}
+
+// crossPostDefault describes when new posts go to a linked network
+func crossPostDefault(crossPost bool) string {
+	if crossPost {
+		return "New posts go there unless unchecked"
+	}
+	return "New posts go there only when checked"
+}
+
+// renderBluesky renders the state of the Bluesky account
+func (m IntegrationsModel) renderBluesky(width int) string {
+	if m.blueskyAcct == nil {
+		return "  Not linked\n"
+	}
+	account := m.blueskyAcct
+	return fmt.Sprintf("  Linked as @%s on %s\n", account.Handle, strings.TrimPrefix(account.Service, "https://")) +
+		"  " + subtleStyle.Render(truncate(crossPostDefault(account.CrossPost)+"; long posts are shortened to 300 characters with a link to the full one", max(width-2, 20))) + "\n"
+}
+
+// renderNostr renders the state of the Nostr key, its relays and how they
+// took the latest note
+func (m IntegrationsModel) renderNostr(width int) string {
+	if m.nostrAcct == nil {
+		return "  Not linked\n"
+	}
+	var b strings.Builder
+	account := m.nostrAcct
+	b.WriteString("  Publishing as " + services.NostrNpub(account.PublicKey) + "\n")
+	relays, source := account.Relays, ""
+	if len(relays) == 0 {
+		relays, source = m.nostr.DefaultRelays(), " (the instance's)"
+	}
+	b.WriteString("  " + subtleStyle.Render(crossPostDefault(account.CrossPost)) + "\n")
+	b.WriteString("  " + subtleStyle.Render(truncate(fmt.Sprintf("Relays%s: %s", source, strings.Join(relays, " ")), max(width-2, 20))) + "\n")
+
+	if len(m.deliveries) > 0 {
+		b.WriteString(fmt.Sprintf("  Latest note, %s:\n", formatAge(time.Since(m.deliveries[0].CreatedAt))))
+		for _, d := range m.deliveries {
+			line := "✓ " + d.Relay
+			style := successStyle
+			if !d.Accepted {
+				line, style = "✗ "+d.Relay+": "+d.Message, errorStyle
+			}
+			b.WriteString("    " + style.Render(truncate(line, max(width-4, 20))) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// renderMatrix renders the state of the Matrix bridge
+func (m IntegrationsModel) renderMatrix(width int) string {
+	bridge := m.matrixBridge
+	if bridge == nil {
+		return "  Not linked\n"
+	}
+	var forwarded []string
+	if bridge.ForwardMentions {
+		forwarded = append(forwarded, "mentions")
+	}
+	if bridge.ForwardDirect {
+		forwarded = append(forwarded, "direct messages")
+	}
+	what := "Nothing is forwarded"
+	if len(forwarded) > 0 {
+		what = "Forwarding " + strings.Join(forwarded, " and ") + " while you are away"
+	}
+
+	var b strings.Builder
+	b.WriteString("  " + truncate(fmt.Sprintf("Room %s, as %s", bridge.RoomID, bridge.MatrixUserID), max(width-2, 20)) + "\n")
+	b.WriteString("  " + subtleStyle.Render(truncate(what, max(width-2, 20))) + "\n")
+	if bridge.LastForwardedAt != nil {
+		b.WriteString("  " + subtleStyle.Render("Last forwarded "+formatAge(time.Since(*bridge.LastForwardedAt))) + "\n")
+	}
+	if bridge.LastError != "" {
+		b.WriteString("  " + errorStyle.Render(truncate("✗ "+bridge.LastError, max(width-2, 20))) + "\n")
+	}
+	return b.String()
+}
+
+// openIntegrations shows the screen linking other networks
+func (m Model) openIntegrations() (Model, tea.Cmd) {
+	m.integrations = NewIntegrationsModel(
+		services.NewBlueskyService(m.ctx.DB, m.ctx.Config),
+		services.NewNostrService(m.ctx.DB, m.ctx.Config),
+		services.NewMatrixService(m.ctx.DB, m.ctx.Redis, m.ctx.Config),
+		m.user.ID,
+	)
+	m.integrations.width = m.width
+	m.integrations.height = m.height
+	m = m.pushScreen(screenIntegrations)
+	return m, m.integrations.Init()
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// interactionDebounce is how long after a like or boost of a post another
+// press on it is ignored, taken for a key pressed twice by accident
+const interactionDebounce = 500 * time.Millisecond
+
+// interactionKey identifies the likes, or the boosts, of a post
+type interactionKey struct {
+	boost    bool
+	statusID string
+}
+
+// interactionSet holds the likes and boosts of a session's posts that were
+// pressed recently or are on their way to the server
+type interactionSet map[interactionKey]*interaction
+
+// interaction follows the like or boost of a post from the key presses to
+// the server. Presses while a request is out only change what is wanted;
+// when it answers, one more request catches up with the last of them, so
+// mashing a key never sends more than two.
+type interaction struct {
+	want       bool // As the user last asked, and the feed shows
+	confirmed  bool // As the server last said
+	inFlight   bool
+	visibility string // Of the boost asked for
+	pressed    time.Time
+}
+
+// interact likes or boosts a post, or takes the like or boost back. The
+// feed shows the change at once and goes back if the server refuses it.
+func (m Model) interact(key interactionKey, status services.MastodonStatus, on bool, visibility string) (Model, tea.Cmd) {
+	if m.interactions == nil {
+		m.interactions = interactionSet{}
+	}
+	it := m.interactions[key]
+	if it == nil {
+		it = &interaction{}
+		m.interactions[key] = it
+	}
+	now := time.Now()
+	if now.Sub(it.pressed) < interactionDebounce {
+		return m, nil
+	}
+	it.pressed = now
+	if !it.inFlight {
+		// Settled, so the feed knows best, even if reloaded since
+		current := status.Favourited
+		if key.boost {
+			current = status.Reblogged
+		}
+		it.want, it.confirmed = current, current
+	}
+	if on == it.want {
+		return m, nil
+	}
+
+	it.want, it.visibility = on, visibility
+	m.feed.applyInteraction(key, on)
+	if it.inFlight {
+		return m, nil
+	}
+	return m, m.sendInteraction(key, it, status)
+}
+
+// sendInteraction asks the server for the state the user wants
+func (m Model) sendInteraction(key interactionKey, it *interaction, status services.MastodonStatus) tea.Cmd {
+	it.inFlight = true
+	switch {
+	case key.boost && it.want:
+		return boostStatusCmd(m.ctx, m.user.ID, status, it.visibility)
+	case key.boost:
+		return unboostStatusCmd(m.ctx, m.user.ID, status)
+	case it.want:
+		return likeStatusCmd(m.ctx, m.user.ID, status)
+	default:
+		return unlikeStatusCmd(m.ctx, m.user.ID, status)
+	}
+}
+
+// interactionDone records the server's answer to a like or boost, and
+// catches up with the presses made while it was awaited
+func (m Model) interactionDone(key interactionKey, status services.MastodonStatus, on bool, err error) (Model, tea.Cmd) {
+	it := m.interactions[key]
+	if it == nil {
+		// Not sent by interact, so nothing else is awaited
+		it = &interaction{want: on}
+	}
+	it.inFlight = false
+
+	if err != nil {
+		m.feed.statusMessage = fmt.Sprintf("Error: %v", err)
+		it.want = it.confirmed
+		m.feed.applyInteraction(key, it.confirmed)
+		return m, nil
+	}
+	it.confirmed = on
+	if it.want != it.confirmed {
+		return m, m.sendInteraction(key, it, status)
+	}
+
+	switch {
+	case key.boost && on:
+		m.feed.statusMessage = "Post boosted!"
+	case key.boost:
+		m.feed.statusMessage = "Boost undone"
+	case on:
+		m.feed.statusMessage = "Post liked!"
+	default:
+		m.feed.statusMessage = "Like removed"
+	}
+	return m, nil
+}
+
+// applyInteraction shows a post as liked or boosted, or not, wherever it is
+// in the feed
+func (f *FeedModel) applyInteraction(key interactionKey, on bool) {
+	apply := func(s *services.MastodonStatus) {
+		if s.ID != key.statusID {
+			return
+		}
+		flag, count := &s.Favourited, &s.FavouritesCount
+		if key.boost {
+			flag, count = &s.Reblogged, &s.ReblogsCount
+		}
+		if *flag == on {
+			return
+		}
+		*flag = on
+		if on {
+			*count++
+		} else {
+			*count = max(*count-1, 0)
+		}
+	}
+	for i := range f.statuses {
+		apply(&f.statuses[i])
+		if f.statuses[i].Reblog != nil {
+			apply(f.statuses[i].Reblog)
+		}
+	}
+}
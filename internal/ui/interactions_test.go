@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// press sends a key and returns the command it started
+func press(m Model, key string) (Model, tea.Cmd) {
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	return next.(Model), cmd
+}
+
+func TestLikeMashing(t *testing.T) {
+	ctx, fake := newFakeContext()
+	status := fake.AddStatus(fixtureStatuses()[0])
+	m := newTestModel(t, 100, 30)
+	m.ctx = ctx
+	m.screen = screenFeed
+	m = send(m, timelineMsg{statuses: []services.MastodonStatus{status}, timelineType: services.TimelineHome})
+	likes := status.FavouritesCount
+
+	m, like := press(m, "x")
+	if like == nil || !m.feed.statuses[0].Favourited || m.feed.statuses[0].FavouritesCount != likes+1 {
+		t.Fatal("expected x to like the post at once")
+	}
+	if m, cmd := press(m, "x"); cmd != nil || !m.feed.statuses[0].Favourited {
+		t.Fatal("expected a second press right away to be ignored")
+	}
+
+	// Pressed again later, while the like is on its way: only the feed changes
+	m.interactions[interactionKey{statusID: status.ID}].pressed = time.Time{}
+	m, cmd := press(m, "x")
+	if cmd != nil || m.feed.statuses[0].Favourited || m.feed.statuses[0].FavouritesCount != likes {
+		t.Fatal("expected the like to be taken back in the feed without a second request")
+	}
+
+	// The like's answer sends one request catching up with the unlike
+	next, unlike := m.Update(like())
+	if unlike == nil {
+		t.Fatal("expected the unlike to be sent once the like was done")
+	}
+	next, _ = next.(Model).Update(unlike())
+	m = next.(Model)
+	if m.feed.statusMessage != "Like removed" {
+		t.Errorf("status = %q", m.feed.statusMessage)
+	}
+	if fake.statuses[0].Favourited {
+		t.Error("expected the post to end up not liked on the server")
+	}
+}
+
+func TestBoostRevertsOnError(t *testing.T) {
+	ctx, _ := newFakeContext()
+	ctx.Config.TUI.ConfirmBoosts = false
+	m := newTestModel(t, 100, 30)
+	m.ctx = ctx
+	m.screen = screenFeed
+	// Unknown to the server, so boosting it fails
+	status := fixtureStatuses()[0]
+	m = send(m, timelineMsg{statuses: []services.MastodonStatus{status}, timelineType: services.TimelineHome})
+
+	m, boost := press(m, "s")
+	if boost == nil || !m.feed.statuses[0].Reblogged {
+		t.Fatal("expected s to boost the post at once without confirming")
+	}
+	m = send(m, boost())
+	if m.feed.statuses[0].Reblogged || m.feed.statuses[0].ReblogsCount != status.ReblogsCount {
+		t.Error("expected the failed boost to be undone in the feed")
+	}
+}
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// linksMenuTitle identifies the list of a post's links in menu messages
+const linksMenuTitle = "Links"
+
+// maxPostLinks is how many links the list shows, one per digit key
+const maxPostLinks = 9
+
+// postLinkWidth is how wide a link may be drawn in the list
+const postLinkWidth = 64
+
+// postLink is an address found in a post and the text it was shown as
+type postLink struct {
+	label string
+	url   string
+}
+
+// postLinks lists the addresses in a post without repeats: the links in its
+// text other than mentions and hashtags, its attachments, its preview card
+// and finally the post itself
+func postLinks(status services.MastodonStatus) []postLink {
+	r := &htmlRenderer{width: 1 << 20}
+	r.render(status.Content)
+
+	var links []postLink
+	seen := map[string]bool{}
+	add := func(label, address string) {
+		if !linkable(address) || seen[address] || len(links) == maxPostLinks {
+			return
+		}
+		seen[address] = true
+		links = append(links, postLink{label: stripControl(label), url: address})
+	}
+	for _, link := range r.found {
+		add(link.label, link.url)
+	}
+	for _, media := range status.MediaAttachments {
+		add(mediaAltText(media), media.URL)
+	}
+	if status.Card != nil {
+		add(status.Card.Title, status.Card.URL)
+	}
+	add("This post", status.URL)
+	return links
+}
+
+// linkable reports whether an address from a remote post may be made a
+// hyperlink and copied: an http(s) URL without control characters, which
+// would end the OSC 8 or OSC 52 sequence it is written in and let the post
+// send its own escape sequences to the terminal
+func linkable(address string) bool {
+	if strings.ContainsFunc(address, unicode.IsControl) {
+		return false
+	}
+	parsed, err := url.Parse(address)
+	return err == nil && (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
+
+// text returns how a link is listed: its address, after its text when
+// that says something else
+func (l postLink) text() string {
+	text := l.url
+	address := strings.TrimPrefix(strings.TrimPrefix(l.url, "https://"), "http://")
+	if l.label != "" && !strings.HasPrefix(address, strings.TrimSuffix(l.label, "…")) {
+		text = l.label + " · " + l.url
+	}
+	return ansi.Truncate(text, postLinkWidth, "…")
+}
+
+// newLinksMenu numbers the links of a post. Each entry is a hyperlink
+// (OSC 8), which terminals that support them open when clicked; choosing
+// one copies it instead.
+func newLinksMenu(links []postLink) MenuModel {
+	items := make([]MenuItem, len(links))
+	for i, link := range links {
+		key := strconv.Itoa(i + 1)
+		items[i] = MenuItem{
+			ID:    key,
+			Label: ansi.SetHyperlink(link.url) + link.text() + ansi.ResetHyperlink(),
+			Key:   key,
+		}
+	}
+	return NewMenuModel(linksMenuTitle, items)
+}
+
+// openLinks lists the links of status to open or copy
+func (m Model) openLinks(status services.MastodonStatus) (Model, tea.Cmd) {
+	links := postLinks(status)
+	if len(links) == 0 {
+		m = m.setStatusMessage("This post has no links")
+		return m, nil
+	}
+	return m.openMenu(newLinksMenu(links), status), nil
+}
+
+// handleLinkChoice copies the link numbered id in status's list
+func (m Model) handleLinkChoice(id string, status services.MastodonStatus) (Model, tea.Cmd) {
+	links := postLinks(status)
+	n, err := strconv.Atoi(id)
+	if err != nil || n < 1 || n > len(links) {
+		return m, nil
+	}
+	url := links[n-1].url
+	return m.copyText(url, url)
+}
+
+// setStatusMessage shows message in the status line of the thread or, from
+// any other screen, of the feed
+func (m Model) setStatusMessage(message string) Model {
+	if m.screen == screenThread {
+		m.thread.statusMessage = message
+	} else {
+		m.feed.statusMessage = message
+	}
+	return m
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// linkedStatus returns a post with a mention, a hashtag, a shortened link,
+// a repeated link and an attachment
+func linkedStatus() services.MastodonStatus {
+	status := fixtureStatuses()[0]
+	status.Content = `<p><a href="https://example.social/@bob" class="u-url mention">@<span>bob</span></a> ` +
+		`<a href="https://example.social/tags/go" class="mention hashtag">#<span>go</span></a> ` +
+		`<a href="https://example.com/a/very/long/path"><span class="invisible">https://</span>` +
+		`<span class="ellipsis">example.com/a/very</span><span class="invisible">/long/path</span></a> ` +
+		`and <a href="https://go.dev/doc">the docs</a>, <a href="https://go.dev/doc">again</a></p>`
+	status.MediaAttachments = []services.MastodonMedia{{Type: "image", URL: "https://example.social/media/1.png", Description: "A cat"}}
+	status.URL = "https://example.social/@bob/1"
+	return status
+}
+
+func TestPostLinks(t *testing.T) {
+	var got []string
+	for _, link := range postLinks(linkedStatus()) {
+		got = append(got, link.text())
+	}
+	want := []string{
+		"https://example.com/a/very/long/path",
+		"the docs · https://go.dev/doc",
+		"Image: A cat · https://example.social/media/1.png",
+		"This post · https://example.social/@bob/1",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("postLinks listed\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPostLinksUnsafe(t *testing.T) {
+	status := fixtureStatuses()[0]
+	status.Content = `<p><a href="https://example.com/&#27;]52;c;eA==&#7;">escape</a> ` +
+		`<a href="https://example.com/&#7;">bell</a> <a href="javascript:alert(1)">script</a> ` +
+		`<a href="file:///etc/passwd">file</a> <a href="https:///nohost">no host</a> ` +
+		`<a href="https://example.com/ok">fine&#27;[2J</a></p>`
+	status.MediaAttachments = []services.MastodonMedia{{Type: "image", URL: "https://example.social/\u009b31m.png"}}
+	status.Card = &services.MastodonCard{Title: "Card", URL: "data:text/html,hi"}
+	status.URL = "https://example.social/@bob/1\x1b]0;title\a"
+
+	links := postLinks(status)
+	if len(links) != 1 || links[0].url != "https://example.com/ok" {
+		t.Fatalf("postLinks = %+v, want only https://example.com/ok", links)
+	}
+	if strings.ContainsRune(links[0].label, '\x1b') {
+		t.Errorf("label %q keeps a control character", links[0].label)
+	}
+}
+
+func TestLinksMenu(t *testing.T) {
+	m := newTestModel(t, 100, 30)
+	m.screen = screenFeed
+	m = send(m, timelineMsg{statuses: []services.MastodonStatus{linkedStatus()}, timelineType: services.TimelineHome})
+
+	m = send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	if m.menu == nil {
+		t.Fatal("expected o to list the post's links")
+	}
+	view := m.View()
+	if !strings.Contains(view, ansi.SetHyperlink("https://go.dev/doc")) {
+		t.Error("expected the links to be hyperlinks")
+	}
+	if !strings.Contains(ansi.Strip(view), "the docs · https://go.dev/doc [2]") {
+		t.Errorf("expected the second link numbered 2, got\n%s", ansi.Strip(view))
+	}
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	m = next.(Model)
+	if cmd == nil {
+		t.Fatal("expected 2 to choose the second link")
+	}
+	m = send(m, cmd())
+	if m.menu != nil || m.feed.statusMessage != "Copied https://go.dev/doc" {
+		t.Errorf("expected the second link to be copied, got menu %v and status %q", m.menu, m.feed.statusMessage)
+	}
+}
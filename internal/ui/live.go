@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/events"
+	"github.com/fulgidus/terminalpub/internal/models"
+)
+
+// liveEventBuffer is how many events may wait for the session to show
+// them; more are dropped
+const liveEventBuffer = 16
+
+// liveFeed passes the events concerning the signed-in user from the bus to
+// the session
+type liveFeed struct {
+	mu          sync.Mutex
+	events      chan events.Event
+	closed      bool
+	unsubscribe []func()
+}
+
+// liveEventMsg carries an event concerning the signed-in user
+type liveEventMsg struct {
+	event events.Event
+	feed  *liveFeed
+}
+
+// send queues an event unless the feed is full or stopped; it runs in the
+// publisher's goroutine, so it never waits
+func (f *liveFeed) send(event events.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return
+	}
+	select {
+	case f.events <- event:
+	default:
+	}
+}
+
+// stop unsubscribes the feed and ends waitForLiveEventCmd
+func (f *liveFeed) stop() {
+	for _, unsubscribe := range f.unsubscribe {
+		unsubscribe()
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.closed {
+		f.closed = true
+		close(f.events)
+	}
+}
+
+// startLive subscribes the session to events concerning the signed-in user
+// on this node, until logout or the end of the SSH session
+func (m Model) startLive() (Model, tea.Cmd) {
+	m = m.stopLive()
+	if m.ctx == nil || m.ctx.Events == nil || m.user == nil {
+		return m, nil
+	}
+	userID := m.user.ID
+	feed := &liveFeed{events: make(chan events.Event, liveEventBuffer)}
+	feed.unsubscribe = append(feed.unsubscribe,
+		events.Subscribe(m.ctx.Events, func(_ context.Context, e events.FollowerAdded) {
+			if e.UserID == userID {
+				feed.send(e)
+			}
+		}))
+	if m.sshSession != nil {
+		session := m.sshSession.Context()
+		go func() {
+			<-session.Done()
+			feed.stop()
+		}()
+	}
+	m.live = feed
+	return m, waitForLiveEventCmd(feed)
+}
+
+// stopLive stops the session's event subscription
+func (m Model) stopLive() Model {
+	if m.live != nil {
+		m.live.stop()
+		m.live = nil
+	}
+	return m
+}
+
+// waitForLiveEventCmd waits for the next event of a feed
+func waitForLiveEventCmd(feed *liveFeed) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-feed.events
+		if !ok {
+			return nil
+		}
+		return liveEventMsg{event: event, feed: feed}
+	}
+}
+
+// handleLiveEvent tells the user about an event, as a notice over the screen
+func (m Model) handleLiveEvent(msg liveEventMsg) (Model, tea.Cmd) {
+	if msg.feed != m.live {
+		// From before logging out
+		return m, nil
+	}
+	if e, ok := msg.event.(events.FollowerAdded); ok {
+		body := e.Acct + " followed you"
+		if !e.Accepted {
+			body = e.Acct + " asked to follow you; answer in Follow requests"
+		}
+		m = m.showWrite(models.TerminalMessage{Body: body, SentAt: time.Now()})
+	}
+	return m, waitForLiveEventCmd(m.live)
+}
@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// MenuItem is a single entry in a MenuModel
+type MenuItem struct {
+	ID    string // Returned in menuSelectedMsg when chosen
+	Label string
+	Key   string // Optional shortcut key that selects the item directly
+}
+
+// MenuModel is a small vertical menu navigated with the arrow keys
+type MenuModel struct {
+	title    string
+	items    []MenuItem
+	selected int
+}
+
+// menuSelectedMsg is sent when a menu item is chosen
+type menuSelectedMsg struct {
+	menu string
+	id   string
+}
+
+// menuClosedMsg is sent when a menu is dismissed without a choice
+type menuClosedMsg struct {
+	menu string
+}
+
+// menuBoxStyle draws the border around menus
+var menuBoxStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("99")).
+	Padding(0, 1)
+
+// NewMenuModel creates a menu; title also identifies it in the messages it sends
+func NewMenuModel(title string, items []MenuItem) MenuModel {
+	return MenuModel{title: title, items: items}
+}
+
+// Update handles keys for the menu
+func (m MenuModel) Update(msg tea.Msg) (MenuModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "k":
+		if m.selected > 0 {
+			m.selected--
+		}
+	case "down", "j":
+		if m.selected < len(m.items)-1 {
+			m.selected++
+		}
+	case "enter":
+		if m.selected < len(m.items) {
+			return m, m.choose(m.items[m.selected].ID)
+		}
+	case "esc":
+		title := m.title
+		return m, func() tea.Msg { return menuClosedMsg{menu: title} }
+	default:
+		for _, item := range m.items {
+			if item.Key != "" && keyMsg.String() == item.Key {
+				return m, m.choose(item.ID)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// choose returns a command announcing the chosen item
+func (m MenuModel) choose(id string) tea.Cmd {
+	title := m.title
+	return func() tea.Msg { return menuSelectedMsg{menu: title, id: id} }
+}
+
+// View renders the menu as a bordered box
+func (m MenuModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(m.title) + "\n\n")
+	for i, item := range m.items {
+		line := "  " + item.Label
+		if i == m.selected {
+			line = promptStyle.Render("► " + item.Label)
+		}
+		if item.Key != "" {
+			line += " " + subtleStyle.Render("["+item.Key+"]")
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n" + subtleStyle.Render("↑/↓ Select  Enter Choose  Esc Close"))
+
+	return menuBoxStyle.Render(b.String())
+}
+
+// overlay draws box centered on top of background, keeping the background
+// visible around it; width and height are the size of the screen
+func overlay(background, box string, width, height int) string {
+	x := max((width-lipgloss.Width(box))/2, 0)
+	y := max((height-lipgloss.Height(box))/2, 0)
+	return overlayAt(background, box, x, y, height)
+}
+
+// overlayAt draws box on top of background with its top left corner at
+// column x of row y
+func overlayAt(background, box string, x, y, height int) string {
+	bgLines := strings.Split(background, "\n")
+	for len(bgLines) < height {
+		bgLines = append(bgLines, "")
+	}
+
+	boxLines := strings.Split(box, "\n")
+	boxWidth := lipgloss.Width(box)
+	for i, boxLine := range boxLines {
+		row := y + i
+		if row >= len(bgLines) {
+			break
+		}
+		bgLine := bgLines[row]
+		left := ansi.Truncate(bgLine, x, "")
+		if pad := x - ansi.StringWidth(left); pad > 0 {
+			left += strings.Repeat(" ", pad)
+		}
+		right := ansi.TruncateLeft(bgLine, x+boxWidth, "")
+		// Reset styles so the background's colors don't bleed into the box
+		bgLines[row] = left + ansi.ResetStyle + boxLine + ansi.ResetStyle + right
+	}
+
+	return strings.Join(bgLines, "\n")
+}
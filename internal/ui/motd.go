@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// motdStyle frames the message of the day
+var motdStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("208")).
+	Padding(0, 1)
+
+// motdLoadedMsg is sent when the messages of the day are fetched
+type motdLoadedMsg struct {
+	messages []models.MOTD
+	err      error
+}
+
+// motdDismissedMsg is sent when a message of the day has been dismissed
+type motdDismissedMsg struct {
+	err error
+}
+
+// loadMOTDCmd fetches the messages of the day for a user, or for a guest
+// when userID is 0
+func loadMOTDCmd(ctx *AppContext, userID int) tea.Cmd {
+	if ctx == nil || ctx.DB == nil || ctx.Config == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		messages, err := services.NewMOTDService(ctx.DB, ctx.Config).Active(bgCtx, userID)
+		return motdLoadedMsg{messages: messages, err: err}
+	}
+}
+
+// dismissMOTDCmd records that a user dismissed a message of the day
+func dismissMOTDCmd(ctx *AppContext, userID int, key string) tea.Cmd {
+	return func() tea.Msg {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := services.NewMOTDService(ctx.DB, ctx.Config).Dismiss(bgCtx, userID, key)
+		return motdDismissedMsg{err: err}
+	}
+}
+
+// dismissMOTD hides the message of the day on screen and for the user's
+// later sessions
+func (m Model) dismissMOTD() (Model, tea.Cmd) {
+	if len(m.motd) == 0 || m.user == nil {
+		return m, nil
+	}
+	key := m.motd[0].Key
+	m.motd = m.motd[1:]
+	return m, dismissMOTDCmd(m.ctx, m.user.ID, key)
+}
+
+// renderBanner renders the operator's ASCII art, or the default title when
+// none is configured or it does not fit
+func (m Model) renderBanner(width int) string {
+	banner := ""
+	if m.ctx != nil && m.ctx.Config != nil {
+		banner = strings.TrimRight(m.ctx.Config.TUI.Banner, "\n")
+	}
+	if banner == "" || lipgloss.Width(banner) > width {
+		return centerText(titleStyle.Render("terminalpub"), width)
+	}
+
+	// Lines are padded to the same width so the art keeps its shape
+	lines := strings.Split(banner, "\n")
+	artWidth := lipgloss.Width(banner)
+	indent := strings.Repeat(" ", (width-artWidth)/2)
+	for i, line := range lines {
+		lines[i] = indent + titleStyle.Render(line+strings.Repeat(" ", artWidth-lipgloss.Width(line)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderMOTD frames the first message of the day, noting how many follow
+// and, for logged-in users, how to dismiss it
+func (m Model) renderMOTD(width int) string {
+	if len(m.motd) == 0 {
+		return ""
+	}
+
+	title := "Message of the day"
+	if len(m.motd) > 1 {
+		title += fmt.Sprintf(" (1 of %d)", len(m.motd))
+	}
+	body := titleStyle.Render(title) + "\n" + m.motd[0].Message
+	if m.user != nil {
+		body += "\n" + keyStyle.Render("[O]") + subtleStyle.Render(" Dismiss")
+	}
+
+	box := motdStyle.Width(max(width-2, 10)).Render(body)
+	return lipgloss.PlaceHorizontal(width, lipgloss.Center, box)
+}
+
+// renderCompactMOTD shows the first message of the day on one line
+func (m Model) renderCompactMOTD() string {
+	if len(m.motd) == 0 {
+		return ""
+	}
+	message := strings.Join(strings.Fields(m.motd[0].Message), " ")
+	return fitLine(keyStyle.Render("O")+" "+message, m.width)
+}
@@ -0,0 +1,246 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// Navigator interprets vim-style movement keys for the lists of posts:
+// count prefixes (5j), gg and G, Ctrl+D and Ctrl+U for half pages, and a
+// text filter opened with "/" that movement then keeps to
+type Navigator struct {
+	count     string // Digits typed before a motion
+	pendingG  bool   // First g of gg typed
+	filter    textinput.Model
+	filtering bool   // Filter being typed
+	query     string // Filter in effect
+}
+
+// NewNavigator creates a navigator without a filter
+func NewNavigator() Navigator {
+	filter := textinput.New()
+	filter.Prompt = "/"
+	filter.Placeholder = "filter posts"
+	filter.CharLimit = 100
+	filter.Cursor.SetMode(cursor.CursorStatic)
+	return Navigator{filter: filter}
+}
+
+// Filtering reports whether the filter is being typed and takes all keys
+func (n Navigator) Filtering() bool {
+	return n.filtering
+}
+
+// Query returns the filter in effect, lower-cased
+func (n Navigator) Query() string {
+	return n.query
+}
+
+// Update handles keys while the filter is typed: Enter applies it and Esc
+// discards the changes
+func (n Navigator) Update(msg tea.KeyMsg) (Navigator, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		n.query = strings.ToLower(strings.TrimSpace(n.filter.Value()))
+		n.filtering = false
+		n.filter.Blur()
+		return n, nil
+	case "esc":
+		n.filter.SetValue(n.query)
+		n.filtering = false
+		n.filter.Blur()
+		return n, nil
+	}
+	var cmd tea.Cmd
+	n.filter, cmd = n.filter.Update(msg)
+	return n, cmd
+}
+
+// ClearFilter removes the filter, reporting whether one was in effect
+func (n Navigator) ClearFilter() (Navigator, bool) {
+	if n.query == "" {
+		return n, false
+	}
+	n.query = ""
+	n.filter.SetValue("")
+	return n, true
+}
+
+// Key applies a navigation key to the selected index of a list of length
+// items, of which page fit on screen. matches reports which items pass the
+// filter. The second result is false when key is not a navigation key.
+func (n *Navigator) Key(key string, index, length, page int, matches func(int) bool) (int, bool) {
+	if key == "/" {
+		n.count, n.pendingG = "", false
+		n.filtering = true
+		n.filter.CursorEnd()
+		n.filter.Focus()
+		return index, true
+	}
+
+	// Digits build up a count; a leading 0 is not one
+	if len(key) == 1 && key[0] >= '0' && key[0] <= '9' && (key != "0" || n.count != "") {
+		n.count += key
+		return index, true
+	}
+	if key == "g" && !n.pendingG {
+		n.pendingG = true
+		return index, true
+	}
+
+	count, explicit := 1, n.count != ""
+	if explicit {
+		count, _ = strconv.Atoi(n.count)
+		count = max(count, 1)
+	}
+	pendingG := n.pendingG
+	n.count, n.pendingG = "", false
+
+	if length == 0 {
+		return index, isNavigationKey(key)
+	}
+	half := max(page/2, 1)
+
+	switch key {
+	case "down", "j":
+		return stepIndex(index, count, length, matches), true
+	case "up", "k":
+		return stepIndex(index, -count, length, matches), true
+	case "ctrl+d", "pgdown":
+		return stepIndex(index, count*half, length, matches), true
+	case "ctrl+u", "pgup":
+		return stepIndex(index, -count*half, length, matches), true
+	case "g", "G":
+		if key == "g" && !pendingG {
+			return index, false
+		}
+		switch {
+		case explicit:
+			// A count picks the item by its number, as a line number in vim
+			return nearestIndex(min(count, length)-1, length, matches), true
+		case key == "g":
+			return stepIndex(-1, 1, length, matches), true
+		}
+		return stepIndex(length, -1, length, matches), true
+	case "home":
+		return stepIndex(-1, 1, length, matches), true
+	case "end":
+		return stepIndex(length, -1, length, matches), true
+	}
+	return index, false
+}
+
+// isNavigationKey reports whether key moves the selection
+func isNavigationKey(key string) bool {
+	switch key {
+	case "down", "j", "up", "k", "ctrl+d", "ctrl+u", "pgdown", "pgup", "g", "G", "home", "end":
+		return true
+	}
+	return false
+}
+
+// View renders the filter line, or "" when there is no filter
+func (n Navigator) View() string {
+	if n.filtering {
+		return n.filter.View()
+	}
+	if n.query != "" {
+		return subtleStyle.Render("Filter: /"+n.query) + "  " + keyStyle.Render("[Esc]") + subtleStyle.Render(" clear")
+	}
+	return ""
+}
+
+// filtering reports whether a post filter is being typed on the screen
+func (m Model) filtering() bool {
+	return (m.screen == screenFeed && m.feed.nav.Filtering()) ||
+		(m.screen == screenThread && m.thread.nav.Filtering())
+}
+
+// stepIndex moves by steps matching items from index, stopping at the last
+// matching item in that direction. index may start outside the list.
+func stepIndex(index, steps, length int, matches func(int) bool) int {
+	direction := 1
+	if steps < 0 {
+		direction, steps = -1, -steps
+	}
+	result := index
+	for i := index + direction; i >= 0 && i < length && steps > 0; i += direction {
+		if matches == nil || matches(i) {
+			result = i
+			steps--
+		}
+	}
+	return min(max(result, 0), max(length-1, 0))
+}
+
+// nearestIndex returns index if it matches, otherwise the next matching
+// item, or the previous one when none follows
+func nearestIndex(index, length int, matches func(int) bool) int {
+	if matches == nil || length == 0 {
+		return index
+	}
+	for i := index; i < length; i++ {
+		if matches(i) {
+			return i
+		}
+	}
+	for i := index - 1; i >= 0; i-- {
+		if matches(i) {
+			return i
+		}
+	}
+	return index
+}
+
+// keepVisible returns the first item to draw so that index is among the
+// page matching items shown from it, moving offset as little as possible
+func keepVisible(offset, index, page int, matches func(int) bool) int {
+	if index < offset {
+		return index
+	}
+	// Walk back from the selection over a page of matching items
+	shown, start := 0, index
+	for i := index; i >= offset && shown < page; i-- {
+		if matches == nil || matches(i) {
+			shown++
+			start = i
+		}
+	}
+	if shown < page {
+		return offset
+	}
+	return start
+}
+
+// visibleIndexes lists up to count matching items from offset on
+func visibleIndexes(offset, count, length int, matches func(int) bool) []int {
+	var indexes []int
+	for i := max(offset, 0); i < length && len(indexes) < count; i++ {
+		if matches == nil || matches(i) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// statusMatches reports whether a post's text, content warning or author
+// contains query, which must be lower-case
+func statusMatches(status services.MastodonStatus, query string) bool {
+	if query == "" {
+		return true
+	}
+	if status.Reblog != nil {
+		status = *status.Reblog
+	}
+	for _, text := range []string{stripHTML(status.Content), status.SpoilerText, status.Account.Acct, status.Account.DisplayName} {
+		if strings.Contains(strings.ToLower(text), query) {
+			return true
+		}
+	}
+	return false
+}
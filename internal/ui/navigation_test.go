@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// navigate feeds keys to a navigator and returns the resulting index and
+// whether the last key was handled
+func navigate(n *Navigator, index, length, page int, matches func(int) bool, keys ...string) (int, bool) {
+	handled := false
+	for _, key := range keys {
+		index, handled = n.Key(key, index, length, page, matches)
+	}
+	return index, handled
+}
+
+func TestNavigatorKey(t *testing.T) {
+	even := func(i int) bool { return i%2 == 0 }
+
+	tests := []struct {
+		name    string
+		keys    []string
+		index   int
+		matches func(int) bool
+		want    int
+		handled bool
+	}{
+		{"down", []string{"j"}, 3, nil, 4, true},
+		{"up", []string{"k"}, 3, nil, 2, true},
+		{"arrow", []string{"down"}, 3, nil, 4, true},
+		{"count", []string{"5", "j"}, 3, nil, 8, true},
+		{"multi-digit count", []string{"1", "2", "j"}, 0, nil, 12, true},
+		{"count past the end", []string{"9", "9", "j"}, 3, nil, 19, true},
+		{"count past the start", []string{"9", "k"}, 3, nil, 0, true},
+		{"leading zero is not a count", []string{"0"}, 3, nil, 3, false},
+		{"leading zero then a motion", []string{"0", "j"}, 3, nil, 4, true},
+		{"zero inside a count", []string{"1", "0", "j"}, 0, nil, 10, true},
+		{"half page down", []string{"ctrl+d"}, 0, nil, 5, true},
+		{"half page up with a count", []string{"2", "ctrl+u"}, 15, nil, 5, true},
+		{"page down", []string{"pgdown"}, 17, nil, 19, true},
+		{"gg", []string{"g", "g"}, 12, nil, 0, true},
+		{"G", []string{"G"}, 2, nil, 19, true},
+		{"count G jumps to the item", []string{"7", "G"}, 0, nil, 6, true},
+		{"count gg jumps to the item", []string{"3", "g", "g"}, 0, nil, 2, true},
+		{"count G past the end", []string{"5", "0", "G"}, 0, nil, 19, true},
+		{"home", []string{"home"}, 9, nil, 0, true},
+		{"end", []string{"end"}, 9, nil, 19, true},
+		{"g then another key", []string{"g", "x"}, 9, nil, 9, false},
+		{"count then another key", []string{"3", "x"}, 9, nil, 9, false},
+		{"count is used up", []string{"3", "x", "j"}, 9, nil, 10, true},
+		{"filtered down", []string{"j"}, 2, even, 4, true},
+		{"filtered count", []string{"3", "j"}, 0, even, 6, true},
+		{"filtered G", []string{"G"}, 0, even, 18, true},
+		{"filtered count G lands on a match", []string{"4", "G"}, 0, even, 4, true},
+		{"filtered past the end", []string{"j"}, 18, even, 18, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewNavigator()
+			got, handled := navigate(&n, tt.index, 20, 10, tt.matches, tt.keys...)
+			if got != tt.want || handled != tt.handled {
+				t.Errorf("keys %v from %d = %d, %v, want %d, %v", tt.keys, tt.index, got, handled, tt.want, tt.handled)
+			}
+		})
+	}
+}
+
+func TestNavigatorEmptyList(t *testing.T) {
+	n := NewNavigator()
+	if index, handled := n.Key("j", 0, 0, 10, nil); index != 0 || !handled {
+		t.Errorf("j on an empty list = %d, %v, want 0, true", index, handled)
+	}
+	if _, handled := n.Key("x", 0, 0, 10, nil); handled {
+		t.Error("x on an empty list was handled")
+	}
+}
+
+func TestNavigatorFilter(t *testing.T) {
+	n := NewNavigator()
+	if _, handled := n.Key("/", 4, 20, 10, nil); !handled || !n.Filtering() {
+		t.Fatal("/ did not open the filter")
+	}
+	for _, r := range "GoLang" {
+		n, _ = n.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	n, _ = n.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if n.Filtering() || n.Query() != "golang" {
+		t.Fatalf("after Enter: filtering %v, query %q, want the lower-cased filter applied", n.Filtering(), n.Query())
+	}
+
+	// Esc discards changes to the filter being typed
+	n.Key("/", 4, 20, 10, nil)
+	n, _ = n.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	n, _ = n.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if n.Query() != "golang" {
+		t.Errorf("after Esc: query %q, want golang kept", n.Query())
+	}
+
+	n, cleared := n.ClearFilter()
+	if !cleared || n.Query() != "" {
+		t.Errorf("ClearFilter = %v, query %q", cleared, n.Query())
+	}
+	if _, cleared := n.ClearFilter(); cleared {
+		t.Error("ClearFilter without a filter reported one cleared")
+	}
+}
+
+func TestKeepVisible(t *testing.T) {
+	even := func(i int) bool { return i%2 == 0 }
+
+	tests := []struct {
+		name                string
+		offset, index, page int
+		matches             func(int) bool
+		want                int
+	}{
+		{"already visible", 0, 3, 5, nil, 0},
+		{"above", 6, 2, 5, nil, 2},
+		{"below", 0, 9, 5, nil, 5},
+		{"last visible", 5, 9, 5, nil, 5},
+		{"filtered below", 0, 12, 3, even, 8},
+		{"filtered visible", 4, 8, 3, even, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keepVisible(tt.offset, tt.index, tt.page, tt.matches); got != tt.want {
+				t.Errorf("keepVisible(%d, %d, %d) = %d, want %d", tt.offset, tt.index, tt.page, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVisibleIndexes(t *testing.T) {
+	even := func(i int) bool { return i%2 == 0 }
+	got := visibleIndexes(3, 3, 20, even)
+	if len(got) != 3 || got[0] != 4 || got[1] != 6 || got[2] != 8 {
+		t.Errorf("visibleIndexes = %v, want [4 6 8]", got)
+	}
+	if got := visibleIndexes(18, 5, 20, nil); len(got) != 2 {
+		t.Errorf("visibleIndexes at the end = %v, want the last 2", got)
+	}
+}
+
+func TestStatusMatches(t *testing.T) {
+	status := services.MastodonStatus{
+		Content:     "<p>Learning <b>Go</b> today</p>",
+		SpoilerText: "long post",
+		Account:     services.MastodonAccount{Acct: "alice@example.social", DisplayName: "Alice"},
+	}
+	boost := services.MastodonStatus{Account: services.MastodonAccount{Acct: "bob"}, Reblog: &status}
+
+	tests := []struct {
+		query  string
+		status services.MastodonStatus
+		want   bool
+	}{
+		{"", status, true},
+		{"go today", status, true},
+		{"long", status, true},
+		{"alice@", status, true},
+		{"alice", status, true},
+		{"<b>", status, false},
+		{"rust", status, false},
+		{"go", boost, true},
+		{"bob", boost, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := statusMatches(tt.status, tt.query); got != tt.want {
+				t.Errorf("statusMatches(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package ui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// maxScreenHistory bounds how many screens Back can return through
+const maxScreenHistory = 50
+
+// screenEntry is a screen left for another, with the model it showed so
+// returning to it restores the selection, scroll position and loaded posts
+type screenEntry struct {
+	screen screenType
+	state  any
+}
+
+// currentEntry captures the screen being shown and its model
+func (m Model) currentEntry() screenEntry {
+	entry := screenEntry{screen: m.screen}
+	switch m.screen {
+	case screenFeed:
+		entry.state = m.feed
+	case screenThread:
+		entry.state = m.thread
+	case screenProfile:
+		entry.state = m.profile
+	case screenPostDetail:
+		entry.state = m.detail
+	case screenNotifications:
+		entry.state = m.notifications
+	case screenInbox:
+		entry.state = m.inbox
+	case screenDiscover:
+		entry.state = m.discover
+	case screenSearch:
+		entry.state = m.search
+	case screenHashtag:
+		entry.state = m.hashtag
+	case screenDirect:
+		entry.state = m.direct
+	case screenAnnouncements:
+		entry.state = m.announcements
+	case screenActionLog:
+		entry.state = m.actions
+	case screenFollowRequests:
+		entry.state = m.followRequests
+	case screenWho:
+		entry.state = m.who
+	case screenDirectory:
+		entry.state = m.directory
+	case screenWebhooks:
+		entry.state = m.webhooks
+	case screenIntegrations:
+		entry.state = m.integrations
+	case screenDigest:
+		entry.state = m.digest
+	case screenAudit:
+		entry.state = m.audit
+	case screenQuarantine:
+		entry.state = m.quarantine
+	case screenReader:
+		entry.state = m.reader
+	}
+	return entry
+}
+
+// restoreEntry shows a saved screen again with the model it had, resized to
+// the terminal as it is now
+func (m Model) restoreEntry(entry screenEntry) Model {
+	switch state := entry.state.(type) {
+	case FeedModel:
+		// The saved filter applies to every timeline until switched
+		state.filter = m.feed.filter
+		m.feed = state
+	case ThreadModel:
+		state.width, state.height = m.width, m.height
+		m.thread = state
+	case ProfileModel:
+		state.width, state.height = m.width, m.height
+		m.profile = state
+	case PostDetailModel:
+		state.width, state.height = m.width, m.height
+		m.detail = state
+	case NotificationsModel:
+		state.width, state.height = m.width, m.height
+		m.notifications = state
+	case InboxModel:
+		state.width, state.height = m.width, m.height
+		m.inbox = state
+	case DiscoverModel:
+		state.width, state.height = m.width, m.height
+		m.discover = state
+	case SearchModel:
+		state.width, state.height = m.width, m.height
+		m.search = state
+	case HashtagModel:
+		state.width, state.height = m.width, m.height
+		m.hashtag = state
+	case DirectMessagesModel:
+		state.width, state.height = m.width, m.height
+		m.direct = state
+	case AnnouncementsModel:
+		state.width, state.height = m.width, m.height
+		m.announcements = state
+	case ActionLogModel:
+		state.width, state.height = m.width, m.height
+		m.actions = state
+	case FollowRequestsModel:
+		state.width, state.height = m.width, m.height
+		m.followRequests = state
+	case WhoModel:
+		state.width, state.height = m.width, m.height
+		m.who = state
+	case DirectoryModel:
+		state.width, state.height = m.width, m.height
+		m.directory = state
+	case WebhooksModel:
+		state.width, state.height = m.width, m.height
+		m.webhooks = state
+	case IntegrationsModel:
+		state.width, state.height = m.width, m.height
+		m.integrations = state
+	case DigestModel:
+		state.width, state.height = m.width, m.height
+		m.digest = state
+	case AuditModel:
+		state.width, state.height = m.width, m.height
+		m.audit = state
+	case QuarantineModel:
+		state.width, state.height = m.width, m.height
+		m.quarantine = state
+	case ReaderModel:
+		state.width, state.height = m.width, m.height
+		m.reader = state
+	}
+	m.screen = entry.screen
+	return m
+}
+
+// pushScreen switches to next, remembering the current screen so Back
+// returns to it as it was. The main menu is the root of navigation, so
+// leaving it starts a fresh history; going somewhere new drops the screens
+// Forward would have returned to, as in a browser.
+func (m Model) pushScreen(next screenType) Model {
+	m = m.pushEntry(m.currentEntry())
+	m.ahead = nil
+	m.screen = next
+	return m
+}
+
+// pushEntry adds a screen to the history Back returns through
+func (m Model) pushEntry(entry screenEntry) Model {
+	if entry.screen == screenAuthenticated {
+		m.screens = nil
+		return m
+	}
+	m.screens = append(m.screens, entry)
+	if len(m.screens) > maxScreenHistory {
+		m.screens = m.screens[len(m.screens)-maxScreenHistory:]
+	}
+	return m
+}
+
+// popScreen returns to the screen left last, or to the main menu when
+// there is none. Screens worth revisiting are kept for goForward; forms such
+// as compose are not.
+func (m Model) popScreen() Model {
+	if current := m.currentEntry(); current.state != nil {
+		m.ahead = append(m.ahead, current)
+	}
+	if len(m.screens) == 0 {
+		m.screen = screenAuthenticated
+		return m
+	}
+	entry := m.screens[len(m.screens)-1]
+	m.screens = m.screens[:len(m.screens)-1]
+	return m.restoreEntry(entry)
+}
+
+// goForward returns to the screen last left with Back, reporting false when
+// there is none
+func (m Model) goForward() (Model, bool) {
+	if len(m.ahead) == 0 {
+		return m, false
+	}
+	entry := m.ahead[len(m.ahead)-1]
+	m.ahead = m.ahead[:len(m.ahead)-1]
+	m = m.pushEntry(m.currentEntry())
+	return m.restoreEntry(entry), true
+}
+
+// historyKeys reports whether [ and ] move through the screen history
+// rather than being typed into a field
+func (m Model) historyKeys() bool {
+	switch m.screen {
+	case screenSearch:
+		return !m.search.Focused()
+	case screenHashtag:
+		return !m.hashtag.Focused()
+	}
+	return paletteScreens[m.screen] && !m.filtering()
+}
+
+// handleHistoryKey goes back with [ and forward with ]
+func (m Model) handleHistoryKey(key string) (tea.Model, tea.Cmd) {
+	// Leaving the feed remembers how far the user read
+	var saveCmd tea.Cmd
+	if m.screen == screenFeed {
+		saveCmd = m.saveReadMarkerCmd()
+	}
+
+	if key == "[" {
+		if len(m.screens) == 0 && m.screen == screenAuthenticated {
+			return m, nil
+		}
+		return m.popScreen(), saveCmd
+	}
+	next, ok := m.goForward()
+	if !ok {
+		return m, nil
+	}
+	return next, saveCmd
+}
@@ -3,8 +3,6 @@ package ui
 import (
 	"context"
 	"fmt"
-	"html"
-	"regexp"
 	"strings"
 	"time"
 
@@ -15,19 +13,20 @@ import (
 
 // NotificationsModel represents the notifications view state
 type NotificationsModel struct {
-	ctx             context.Context
-	userID          int
-	mastodonService *services.MastodonService
-	notifications   []services.MastodonNotification
-	selectedIndex   int
-	scrollOffset    int
-	loading         bool
-	loadingMore     bool
-	hasMore         bool
-	statusMessage   string
-	width           int
-	height          int
-	err             error
+	ctx           context.Context
+	userID        int
+	notifier      Notifier
+	markers       *services.MarkerService
+	notifications []services.MastodonNotification
+	selectedIndex int
+	scrollOffset  int
+	loading       bool
+	loadingMore   bool
+	hasMore       bool
+	statusMessage string
+	width         int
+	height        int
+	err           error
 }
 
 // notificationsLoadedMsg is sent when notifications are fetched
@@ -44,14 +43,15 @@ type dismissNotificationMsg struct {
 }
 
 // NewNotificationsModel creates a new notifications view model
-func NewNotificationsModel(ctx context.Context, userID int, mastodonService *services.MastodonService) NotificationsModel {
+func NewNotificationsModel(ctx context.Context, userID int, notifier Notifier, markers *services.MarkerService) NotificationsModel {
 	return NotificationsModel{
-		ctx:             ctx,
-		userID:          userID,
-		mastodonService: mastodonService,
-		loading:         true,
-		statusMessage:   "Loading notifications...",
-		hasMore:         true,
+		ctx:           ctx,
+		userID:        userID,
+		notifier:      notifier,
+		markers:       markers,
+		loading:       true,
+		statusMessage: "Loading notifications...",
+		hasMore:       true,
 	}
 }
 
@@ -279,11 +279,9 @@ func (m NotificationsModel) renderNotification(notif services.MastodonNotificati
 
 	// Second line: content (if status exists)
 	if notif.Status != nil {
-		content := stripHTMLNotif(notif.Status.Content)
-		if len(content) > 100 {
-			content = content[:97] + "..."
+		for _, line := range renderHTMLLines(notif.Status.Content, m.width-4, 2) {
+			b.WriteString(selector + "  " + line + "\n")
 		}
-		b.WriteString(selector + "  " + content + "\n")
 	}
 
 	// Third line: timestamp
@@ -304,11 +302,19 @@ func (m NotificationsModel) fetchNotificationsCmd(isLoadMore bool) tea.Cmd {
 			maxID = m.notifications[len(m.notifications)-1].ID
 		}
 
-		notifications, err := m.mastodonService.GetNotifications(m.ctx, m.userID, 20, maxID)
+		notifications, err := m.notifier.GetNotifications(m.ctx, m.userID, 20, maxID)
 		if err != nil {
 			return notificationsLoadedMsg{err: err}
 		}
 
+		// What was shown here is seen: the Matrix bridge skips it and other
+		// clients stop counting it as unread
+		if !isLoadMore && len(notifications) > 0 && m.markers != nil {
+			if err := m.markers.Save(m.ctx, m.userID, "notifications", notifications[0].ID); err != nil {
+				fmt.Printf("Failed to save notifications marker: %v\n", err)
+			}
+		}
+
 		return notificationsLoadedMsg{
 			notifications: notifications,
 			isLoadMore:    isLoadMore,
@@ -324,21 +330,6 @@ func (m NotificationsModel) GetSelectedNotification() *services.MastodonNotifica
 	return nil
 }
 
-// stripHTMLNotif removes HTML tags from notification content
-func stripHTMLNotif(content string) string {
-	// Remove HTML tags
-	re := regexp.MustCompile(`<[^>]*>`)
-	stripped := re.ReplaceAllString(content, "")
-
-	// Decode HTML entities
-	stripped = html.UnescapeString(stripped)
-
-	// Replace multiple spaces/newlines with single space
-	stripped = strings.Join(strings.Fields(stripped), " ")
-
-	return stripped
-}
-
 // formatTimeAgo formats a time as "X minutes/hours/days ago"
 func formatTimeAgo(t time.Time) string {
 	duration := time.Since(t)
@@ -0,0 +1,468 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/games"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// paletteSuggestions is how many completions are listed above the command line
+const paletteSuggestions = 5
+
+// paletteCommand is a command of the command line
+type paletteCommand struct {
+	name string
+	args string // Argument syntax, shown while completing
+	help string
+	key  string // Main menu key the command stands for, if any
+}
+
+// paletteCommands are the commands understood after ":"
+var paletteCommands = []paletteCommand{
+	{name: "home", help: "Home timeline"},
+	{name: "local", help: "Local timeline"},
+	{name: "federated", help: "Federated timeline"},
+	{name: "tag", args: "<hashtag>", help: "Local hashtag timeline"},
+	{name: "user", args: "<@user@domain>", help: "Open a profile"},
+	{name: "post", help: "Compose a new post", key: "p"},
+	{name: "notifications", help: "View notifications", key: "n"},
+	{name: "inbox", help: "Mentions and DMs", key: "m"},
+	{name: "dms", help: "Direct messages", key: "c"},
+	{name: "search", help: "Search this instance", key: "/"},
+	{name: "announcements", help: "Instance announcements", key: "b"},
+	{name: "who", help: "Who's online", key: "w"},
+	{name: "directory", help: "People on this instance who chose to be listed", key: "j"},
+	{name: "chat", args: "[room]", help: "Chat rooms of this instance"},
+	{name: "write", args: "<user> <message>", help: "Write to a connected user's terminal"},
+	{name: "mesg", args: "[y|n]", help: "Allow or refuse messages from other users"},
+	{name: "games", args: "[game]", help: "Play a mini game"},
+	{name: "filters", help: "Manage feed filters", key: "v"},
+	{name: "instance", args: "<domain>", help: "Browse the public timeline of another instance"},
+	{name: "pin", args: "<1-9> [timeline]", help: "Pin a timeline (#tag, list:name, instance domain) to a slot"},
+	{name: "unpin", args: "<1-9>", help: "Empty a pin slot"},
+	{name: "discover", help: "Discover people to follow", key: "s"},
+	{name: "activity", help: "Undo recent actions", key: "a"},
+	{name: "requests", help: "Follow requests", key: "r"},
+	{name: "profile", help: "Edit your profile", key: "u"},
+	{name: "tokens", help: "Manage API tokens", key: "t"},
+	{name: "webhooks", help: "Manage webhooks", key: "z"},
+	{name: "integrations", help: "Link Bluesky, Nostr and Matrix"},
+	{name: "digest", help: "Daily or weekly email digest"},
+	{name: "record", help: "Record this session as an asciinema cast; again to stop"},
+	{name: "audit", help: "Logins, key and token changes, admin actions"},
+	{name: "quarantine", help: "Review inbound posts held as spam (admins)"},
+	{name: "menu", help: "Main menu"},
+	{name: "quit", help: "Quit terminalpub"},
+}
+
+// paletteScreens are the screens where ":" opens the command line rather
+// than being typed into a field
+var paletteScreens = map[screenType]bool{
+	screenAuthenticated:  true,
+	screenFeed:           true,
+	screenThread:         true,
+	screenProfile:        true,
+	screenNotifications:  true,
+	screenDiscover:       true,
+	screenInbox:          true,
+	screenActionLog:      true,
+	screenFollowRequests: true,
+	screenPostDetail:     true,
+	screenReader:         true,
+	screenAnnouncements:  true,
+	screenWho:            true,
+	screenDirectory:      true,
+}
+
+// PaletteModel is the vim-style command line opened with ":"
+type PaletteModel struct {
+	input    textinput.Model
+	selected int    // Highlighted completion
+	err      string // Why the last command failed
+}
+
+// paletteUserMsg is sent when the account named by :user has been looked up
+type paletteUserMsg struct {
+	acct      string
+	accountID string
+	err       error
+}
+
+// NewPaletteModel creates an empty command line
+func NewPaletteModel() PaletteModel {
+	input := textinput.New()
+	input.Prompt = ":"
+	input.CharLimit = 200
+	// Blink messages go to the screen below, so the cursor stays solid
+	input.Cursor.SetMode(cursor.CursorStatic)
+	input.Focus()
+	return PaletteModel{input: input}
+}
+
+// Update handles keys typed into the command line, except Enter and Esc
+func (p PaletteModel) Update(msg tea.KeyMsg) (PaletteModel, tea.Cmd) {
+	switch msg.String() {
+	case "tab":
+		if matches := p.suggestions(); len(matches) > 0 {
+			command := matches[min(p.selected, len(matches)-1)]
+			value := command.name
+			if command.args != "" {
+				value += " "
+			}
+			if _, rest, ok := strings.Cut(p.input.Value(), " "); ok {
+				value = command.name + " " + rest
+			}
+			p.input.SetValue(value)
+			p.input.CursorEnd()
+			p.selected = 0
+		}
+		return p, nil
+	case "up", "shift+tab":
+		if p.selected > 0 {
+			p.selected--
+		}
+		return p, nil
+	case "down":
+		if p.selected < min(len(p.suggestions()), paletteSuggestions)-1 {
+			p.selected++
+		}
+		return p, nil
+	}
+
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	p.selected = 0
+	p.err = ""
+	return p, cmd
+}
+
+// suggestions lists the commands matching the name typed so far, best first
+func (p PaletteModel) suggestions() []paletteCommand {
+	name, _, _ := strings.Cut(strings.TrimSpace(p.input.Value()), " ")
+	name = strings.ToLower(name)
+
+	type scored struct {
+		command paletteCommand
+		score   int
+	}
+	var matches []scored
+	for _, command := range paletteCommands {
+		if score := fuzzyScore(name, command.name); score >= 0 {
+			matches = append(matches, scored{command, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	commands := make([]paletteCommand, len(matches))
+	for i, match := range matches {
+		commands[i] = match.command
+	}
+	return commands
+}
+
+// fuzzyScore rates how well pattern abbreviates candidate: its letters must
+// appear in order, prefixes score highest and consecutive letters beat
+// scattered ones. It returns -1 when pattern does not match.
+func fuzzyScore(pattern, candidate string) int {
+	if pattern == "" {
+		return 0
+	}
+	if pattern == candidate {
+		return 1000
+	}
+	if strings.HasPrefix(candidate, pattern) {
+		return 500 - len(candidate)
+	}
+
+	score, next, last := 0, 0, -2
+	for i := 0; i < len(candidate) && next < len(pattern); i++ {
+		if candidate[i] != pattern[next] {
+			continue
+		}
+		score++
+		if i == last+1 {
+			score += 5
+		}
+		last = i
+		next++
+	}
+	if next < len(pattern) {
+		return -1
+	}
+	return score
+}
+
+// resolve returns the command to run and its arguments. A name that is not
+// a command runs the highlighted completion, so abbreviations work.
+func (p PaletteModel) resolve() (paletteCommand, []string, bool) {
+	fields := strings.Fields(p.input.Value())
+	if len(fields) == 0 {
+		return paletteCommand{}, nil, false
+	}
+	for _, command := range paletteCommands {
+		if command.name == strings.ToLower(fields[0]) {
+			return command, fields[1:], true
+		}
+	}
+	matches := p.suggestions()
+	if len(matches) == 0 {
+		return paletteCommand{}, nil, false
+	}
+	return matches[min(p.selected, len(matches)-1)], fields[1:], true
+}
+
+// View renders the completions and the command line, one line each
+func (p PaletteModel) View(width int) string {
+	var lines []string
+
+	if p.err != "" {
+		lines = append(lines, errorStyle.Render(p.err))
+	} else {
+		for i, command := range p.suggestions() {
+			if i == paletteSuggestions {
+				break
+			}
+			line := "  "
+			if i == p.selected {
+				line = promptStyle.Render("► ")
+			}
+			name := command.name
+			if command.args != "" {
+				name += " " + command.args
+			}
+			line += keyStyle.Render(fmt.Sprintf("%-24s", name)) + subtleStyle.Render(command.help)
+			lines = append(lines, fitLine(line, width))
+		}
+	}
+	lines = append(lines, fitLine(p.input.View(), width))
+
+	return strings.Join(lines, "\n")
+}
+
+// openPalette opens the command line
+func (m Model) openPalette() (Model, tea.Cmd) {
+	palette := NewPaletteModel()
+	m.palette = &palette
+	return m, nil
+}
+
+// handlePaletteKey routes keys to the open command line
+func (m Model) handlePaletteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.palette = nil
+		return m, nil
+	case "backspace":
+		// As in vim, deleting past the colon closes the command line
+		if m.palette.input.Value() == "" {
+			m.palette = nil
+			return m, nil
+		}
+	case "enter":
+		command, args, ok := m.palette.resolve()
+		if !ok {
+			if strings.TrimSpace(m.palette.input.Value()) == "" {
+				m.palette = nil
+				return m, nil
+			}
+			m.palette.err = "Unknown command: " + m.palette.input.Value()
+			return m, nil
+		}
+		return m.runPaletteCommand(command, args)
+	}
+
+	palette, cmd := m.palette.Update(msg)
+	m.palette = &palette
+	return m, cmd
+}
+
+// runPaletteCommand runs a command from the command line
+func (m Model) runPaletteCommand(command paletteCommand, args []string) (tea.Model, tea.Cmd) {
+	if len(args) == 0 && strings.HasPrefix(command.args, "<") {
+		m.palette.err = "usage: " + command.name + " " + command.args
+		return m, nil
+	}
+	if m.ctx.Demo && !demoPaletteCommands[command.name] && !demoMenuKeys[strings.ToUpper(command.key)] {
+		m.palette.err = demoUnavailable
+		return m, nil
+	}
+	m.palette = nil
+
+	// Leaving the feed remembers how far the user read
+	var saveCmd tea.Cmd
+	if m.screen == screenFeed {
+		saveCmd = m.saveReadMarkerCmd()
+	}
+
+	var cmd tea.Cmd
+	switch command.name {
+	case "home":
+		m, cmd = m.openTimeline(services.TimelineHome)
+	case "local":
+		m, cmd = m.openTimeline(services.TimelineLocal)
+	case "federated":
+		m, cmd = m.openTimeline(services.TimelineFederated)
+	case "tag":
+		m = m.pushScreen(screenHashtag)
+		m.hashtag = NewHashtagModel(services.NewTimelineService(m.ctx.DB, m.ctx.Config))
+		m.hashtag.width = m.width
+		m.hashtag.height = m.height
+		m.hashtag, cmd = m.hashtag.Open(args[0])
+	case "user":
+		cmd = lookupUserCmd(m.mastodonSvc, m.user.ID, args[0])
+	case "chat":
+		room := "lobby"
+		if len(args) > 0 {
+			room = args[0]
+		}
+		var model tea.Model
+		model, cmd = m.openChat(room)
+		m = model.(Model)
+	case "write":
+		if len(args) < 2 {
+			return m.reopenPalette("write "+strings.Join(args, " ")+" ", "usage: write "+command.args)
+		}
+		cmd = m.writeCmd(args[0], strings.Join(args[1:], " "))
+	case "mesg":
+		var accept *bool
+		if len(args) > 0 {
+			switch strings.ToLower(args[0]) {
+			case "y", "yes", "on":
+				accept = new(bool)
+				*accept = true
+			case "n", "no", "off":
+				accept = new(bool)
+			default:
+				return m.reopenPalette("mesg ", "usage: mesg "+command.args)
+			}
+		}
+		cmd = m.acceptWritesCmd(accept)
+	case "games":
+		if len(args) > 0 {
+			if _, ok := games.Lookup(strings.ToLower(args[0])); !ok {
+				return m.reopenPalette("games "+args[0], "No game called "+args[0])
+			}
+		}
+		m.games = NewGamesModel()
+		m = m.pushScreen(screenGames)
+		if len(args) > 0 {
+			m.games, cmd = m.games.Start(strings.ToLower(args[0]))
+		}
+	case "instance":
+		m, cmd = m.openInstance(args[0])
+	case "pin", "unpin":
+		slot, err := strconv.Atoi(args[0])
+		if err != nil || slot < 1 || slot > services.MaxTimelinePin {
+			return m.reopenPalette(command.name+" "+strings.Join(args, " "), "usage: "+command.name+" "+command.args)
+		}
+		if command.name == "unpin" {
+			cmd = unpinTimelineCmd(m.ctx, m.user.ID, slot)
+			break
+		}
+		// Without a timeline, the one in the feed is pinned
+		timeline := string(m.feed.timelineType)
+		if len(args) > 1 {
+			timeline = strings.Join(args[1:], " ")
+			if !strings.HasPrefix(timeline, "list:") {
+				if _, err := services.ParseTimeline(timeline); err != nil {
+					return m.reopenPalette("pin "+strings.Join(args, " "), fmt.Sprintf("Error: %v", err))
+				}
+			}
+		}
+		cmd = pinTimelineCmd(m.ctx, m.mastodonSvc, m.user.ID, slot, timeline)
+	case "integrations":
+		m, cmd = m.openIntegrations()
+	case "digest":
+		m, cmd = m.openDigest()
+	case "record":
+		m, cmd = m.toggleRecording()
+	case "audit":
+		m, cmd = m.openAudit()
+	case "quarantine":
+		m, cmd = m.openQuarantine()
+	case "menu":
+		m.screen = screenAuthenticated
+		m.screens = nil
+	case "quit":
+		return m, tea.Sequence(saveCmd, tea.Quit)
+	default:
+		// Everything else is an entry of the main menu, which Back leaves
+		// for the screen the command was typed on
+		from, history := m.currentEntry(), m.screens
+		m.screen = screenAuthenticated
+		var model tea.Model
+		model, cmd = m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(command.key)})
+		m = model.(Model)
+		if m.screen != screenAuthenticated {
+			m.screens = history
+			m = m.pushEntry(from)
+		}
+	}
+	return m, tea.Batch(saveCmd, cmd)
+}
+
+// reopenPalette shows the command line again with text and why it failed
+func (m Model) reopenPalette(text, err string) (Model, tea.Cmd) {
+	palette := NewPaletteModel()
+	palette.input.SetValue(text)
+	palette.input.CursorEnd()
+	palette.err = err
+	m.palette = &palette
+	return m, nil
+}
+
+// openTimeline shows one of the timelines in the feed
+func (m Model) openTimeline(timeline services.TimelineType) (Model, tea.Cmd) {
+	m = m.pushScreen(screenFeed)
+	m.feed.loading = true
+	m.feed.err = nil
+	m.feed.timelineType = timeline
+	if timeline == services.TimelineHome {
+		return m, fetchHomeAtMarkerCmd(m.ctx, m.user.ID, 20)
+	}
+	return m, fetchTimelineCmd(m.ctx, m.user.ID, timeline, 20)
+}
+
+// lookupUserCmd finds the account named by :user
+func lookupUserCmd(svc *services.MastodonService, userID int, acct string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		account, err := svc.LookupAccount(ctx, userID, acct)
+		if err != nil {
+			return paletteUserMsg{acct: acct, err: err}
+		}
+		return paletteUserMsg{acct: acct, accountID: account.ID}
+	}
+}
+
+// handlePaletteUser opens the profile found by :user, or reopens the
+// command line with the error so the handle can be corrected
+func (m Model) handlePaletteUser(msg paletteUserMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		return m.reopenPalette("user "+msg.acct, fmt.Sprintf("Error: %v", msg.err))
+	}
+	return m.openProfile(msg.accountID)
+}
+
+// withPalette draws the command line over the bottom of a rendered screen
+func withPalette(view, palette string, height int) string {
+	lines := strings.Split(view, "\n")
+	keep := max(height-strings.Count(palette, "\n")-1, 0)
+	if len(lines) > keep {
+		lines = lines[:keep]
+	}
+	for len(lines) < keep {
+		lines = append(lines, "")
+	}
+	return strings.Join(append(lines, palette), "\n")
+}
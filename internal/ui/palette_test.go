@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typed returns a command line with text typed into it
+func typed(text string) PaletteModel {
+	p := NewPaletteModel()
+	p.input.SetValue(text)
+	p.input.CursorEnd()
+	return p
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern, candidate string
+		want               int
+	}{
+		{"", "home", 0},
+		{"home", "home", 1000},
+		{"not", "notifications", 500 - len("notifications")},
+		{"nf", "notifications", 2},
+		{"ntf", "notifications", 3},
+		{"xyz", "notifications", -1},
+		{"sn", "notifications", -1}, // Letters out of order
+		{"homes", "home", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.candidate, func(t *testing.T) {
+			if got := fuzzyScore(tt.pattern, tt.candidate); got != tt.want {
+				t.Errorf("fuzzyScore(%q, %q) = %d, want %d", tt.pattern, tt.candidate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyScoreRanking(t *testing.T) {
+	tests := []struct {
+		pattern, better, worse string
+	}{
+		{"dm", "dms", "directory"},      // Prefix beats scattered letters
+		{"in", "inbox", "integrations"}, // Shorter prefix first
+		{"ac", "activity", "announcements"},
+		{"dir", "directory", "discover"}, // Consecutive letters beat scattered ones
+		{"ho", "home", "who"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			better, worse := fuzzyScore(tt.pattern, tt.better), fuzzyScore(tt.pattern, tt.worse)
+			if better <= worse {
+				t.Errorf("fuzzyScore(%q): %s = %d, %s = %d, want %s ranked first", tt.pattern, tt.better, better, tt.worse, worse, tt.better)
+			}
+		})
+	}
+}
+
+func TestPaletteSuggestions(t *testing.T) {
+	if got := len(typed("").suggestions()); got != len(paletteCommands) {
+		t.Errorf("empty command line suggests %d commands, want all %d", got, len(paletteCommands))
+	}
+	if got := typed("qqq").suggestions(); len(got) != 0 {
+		t.Errorf("suggestions for qqq = %v, want none", got)
+	}
+
+	tests := []struct {
+		text, first string
+	}{
+		{"no", "notifications"},
+		{"NO", "notifications"},
+		{"quar", "quarantine"},
+		{"tag golang", "tag"}, // Only the name is matched
+		{"  fed", "federated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			matches := typed(tt.text).suggestions()
+			if len(matches) == 0 || matches[0].name != tt.first {
+				t.Errorf("suggestions(%q) start with %v, want %s", tt.text, matches, tt.first)
+			}
+		})
+	}
+}
+
+func TestPaletteResolve(t *testing.T) {
+	tests := []struct {
+		text     string
+		selected int
+		name     string
+		args     []string
+		ok       bool
+	}{
+		{"home", 0, "home", nil, true},
+		{"HOME", 0, "home", nil, true},
+		{"tag golang", 0, "tag", []string{"golang"}, true},
+		{"write bob  hello there", 0, "write", []string{"bob", "hello", "there"}, true},
+		{"notif", 0, "notifications", nil, true},
+		{"in", 1, "instance", nil, true}, // The highlighted completion runs
+		{"", 0, "", nil, false},
+		{"   ", 0, "", nil, false},
+		{"qqq", 0, "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			p := typed(tt.text)
+			p.selected = tt.selected
+			command, args, ok := p.resolve()
+			if ok != tt.ok || command.name != tt.name || len(args) != len(tt.args) {
+				t.Fatalf("resolve(%q) = %s %v %v, want %s %v %v", tt.text, command.name, args, ok, tt.name, tt.args, tt.ok)
+			}
+			for i := range args {
+				if args[i] != tt.args[i] {
+					t.Errorf("resolve(%q) args = %v, want %v", tt.text, args, tt.args)
+				}
+			}
+		})
+	}
+}
+
+func TestPaletteTabCompletion(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		keys []tea.KeyType
+		want string
+	}{
+		{"command", "notif", []tea.KeyType{tea.KeyTab}, "notifications"},
+		{"command taking arguments", "ta", []tea.KeyType{tea.KeyTab}, "tag "},
+		{"keeps arguments", "wri bob hi", []tea.KeyType{tea.KeyTab}, "write bob hi"},
+		{"highlighted completion", "in", []tea.KeyType{tea.KeyDown, tea.KeyDown, tea.KeyTab}, "integrations"},
+		{"up stops at the first", "in", []tea.KeyType{tea.KeyUp, tea.KeyTab}, "inbox"},
+		{"nothing to complete", "qqq", []tea.KeyType{tea.KeyTab}, "qqq"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := typed(tt.text)
+			for _, key := range tt.keys {
+				p, _ = p.Update(tea.KeyMsg{Type: key})
+			}
+			if got := p.input.Value(); got != tt.want {
+				t.Errorf("after completing %q: %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
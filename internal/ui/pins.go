@@ -0,0 +1,178 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// pinsLoadedMsg is sent when the user's pinned timelines have been fetched
+type pinsLoadedMsg struct {
+	pins []models.TimelinePin
+	err  error
+}
+
+// timelinePinnedMsg reports the outcome of pinning a timeline
+type timelinePinnedMsg struct {
+	pin *models.TimelinePin
+	err error
+}
+
+// timelineUnpinnedMsg reports the outcome of emptying a pin slot
+type timelineUnpinnedMsg struct {
+	slot int
+	err  error
+}
+
+// loadPinsCmd fetches the user's pinned timelines
+func loadPinsCmd(appCtx *AppContext, userID int) tea.Cmd {
+	if appCtx == nil || appCtx.DB == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		pins, err := services.NewTimelinePinService(appCtx.DB).List(ctx, userID)
+		return pinsLoadedMsg{pins: pins, err: err}
+	}
+}
+
+// pinTimelineCmd pins the timeline typed by the user, e.g. #rust or
+// list:Friends, to a slot
+func pinTimelineCmd(appCtx *AppContext, mastodonSvc *services.MastodonService, userID, slot int, text string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		timeline, err := resolveTimeline(ctx, mastodonSvc, userID, text)
+		if err != nil {
+			return timelinePinnedMsg{err: err}
+		}
+		pin, err := services.NewTimelinePinService(appCtx.DB).Pin(ctx, userID, slot, timeline)
+		return timelinePinnedMsg{pin: pin, err: err}
+	}
+}
+
+// resolveTimeline parses a timeline, looking lists up by title as well as
+// by ID since nobody knows the ID of their lists
+func resolveTimeline(ctx context.Context, mastodonSvc *services.MastodonService, userID int, text string) (services.TimelineType, error) {
+	name, isList := strings.CutPrefix(strings.TrimSpace(text), "list:")
+	if !isList {
+		return services.ParseTimeline(text)
+	}
+
+	lists, err := mastodonSvc.GetLists(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	for _, list := range lists {
+		if list.ID == name || strings.EqualFold(list.Title, name) {
+			return services.ListTimeline(list.ID), nil
+		}
+	}
+	return "", fmt.Errorf("you have no list named %q", name)
+}
+
+// unpinTimelineCmd empties a pin slot
+func unpinTimelineCmd(appCtx *AppContext, userID, slot int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := services.NewTimelinePinService(appCtx.DB).Unpin(ctx, userID, slot)
+		return timelineUnpinnedMsg{slot: slot, err: err}
+	}
+}
+
+// pinnedTimeline returns the timeline pinned to a slot
+func (m Model) pinnedTimeline(slot int) (services.TimelineType, bool) {
+	for _, pin := range m.pins {
+		if pin.Slot == slot {
+			return services.TimelineType(pin.Timeline), true
+		}
+	}
+	return "", false
+}
+
+// handlePinKey completes m<slot>, which pins the timeline shown, and
+// '<slot>, which switches to a pinned one. Any other key cancels.
+func (m Model) handlePinKey(key string) (tea.Model, tea.Cmd) {
+	action := m.feed.pinKey
+	m.feed.pinKey = ""
+	m.feed.statusMessage = ""
+	if len(key) != 1 || key[0] < '1' || key[0] > '0'+services.MaxTimelinePin {
+		return m, nil
+	}
+	slot := int(key[0] - '0')
+
+	if action == "m" {
+		if m.ctx.DB == nil {
+			m.feed.statusMessage = "Error: pinned timelines unavailable"
+			return m, nil
+		}
+		m.feed.statusMessage = "Pinning..."
+		return m, pinTimelineCmd(m.ctx, m.mastodonSvc, m.user.ID, slot, string(m.feed.timelineType))
+	}
+	timeline, ok := m.pinnedTimeline(slot)
+	if !ok {
+		m.feed.statusMessage = fmt.Sprintf("Nothing pinned to %d: press m%d to pin this timeline there", slot, slot)
+		return m, nil
+	}
+	return m.switchTimeline(timeline)
+}
+
+// switchTimeline shows another timeline in the feed, which Back returns from
+func (m Model) switchTimeline(timeline services.TimelineType) (tea.Model, tea.Cmd) {
+	if m.feed.timelineType != timeline {
+		m = m.pushScreen(screenFeed)
+	}
+	m.feed.loading = true
+	saveCmd := m.saveReadMarkerCmd()
+	m.feed.timelineType = timeline
+	return m, tea.Batch(saveCmd, fetchTimelineCmd(m.ctx, m.user.ID, timeline, 20))
+}
+
+// handlePinMsg keeps the pins up to date and reports on pinning
+func (m Model) handlePinMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pinsLoadedMsg:
+		// Pins are a shortcut; the feed works without them
+		if msg.err == nil {
+			m.pins = msg.pins
+		}
+	case timelinePinnedMsg:
+		if msg.err != nil {
+			m.feed.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.feed.statusMessage = fmt.Sprintf("Pinned %s to %d", getTimelineName(services.TimelineType(msg.pin.Timeline)), msg.pin.Slot)
+		return m, loadPinsCmd(m.ctx, m.user.ID)
+	case timelineUnpinnedMsg:
+		if msg.err != nil {
+			m.feed.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.feed.statusMessage = fmt.Sprintf("Unpinned %d", msg.slot)
+		return m, loadPinsCmd(m.ctx, m.user.ID)
+	}
+	return m, nil
+}
+
+// renderPins lists the pinned timelines, the one shown highlighted
+func (m Model) renderPins() string {
+	if len(m.pins) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(m.pins))
+	for _, pin := range m.pins {
+		name := getTimelineName(services.TimelineType(pin.Timeline))
+		if services.TimelineType(pin.Timeline) == m.feed.timelineType {
+			name = promptStyle.Render(name)
+		}
+		parts = append(parts, keyStyle.Render(fmt.Sprintf("'%d", pin.Slot))+" "+name)
+	}
+	return strings.Join(parts, "  ")
+}
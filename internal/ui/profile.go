@@ -3,8 +3,7 @@ package ui
 import (
 	"context"
 	"fmt"
-	"html"
-	"regexp"
+	"slices"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,26 +13,28 @@ import (
 
 // ProfileModel represents the user profile view state
 type ProfileModel struct {
-	ctx             context.Context
-	userID          int
-	mastodonService *services.MastodonService
-	accountID       string
-	account         *services.MastodonAccount
-	statuses        []services.MastodonStatus
-	relationship    *services.AccountRelationship
-	selectedIndex   int
-	scrollOffset    int
-	loading         bool
-	statusMessage   string
-	width           int
-	height          int
-	err             error
+	ctx           context.Context
+	userID        int
+	timelines     TimelineFetcher
+	accountID     string
+	account       *services.MastodonAccount
+	statuses      []services.MastodonStatus // Pinned posts first, then recent ones
+	pinnedCount   int
+	relationship  *services.AccountRelationship
+	selectedIndex int
+	scrollOffset  int
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+	err           error
 }
 
 // profileLoadedMsg is sent when profile data is fetched
 type profileLoadedMsg struct {
 	account      *services.MastodonAccount
 	statuses     []services.MastodonStatus
+	pinned       []services.MastodonStatus
 	relationship *services.AccountRelationship
 	err          error
 }
@@ -45,14 +46,14 @@ type followActionMsg struct {
 }
 
 // NewProfileModel creates a new profile view model
-func NewProfileModel(ctx context.Context, userID int, mastodonService *services.MastodonService, accountID string) ProfileModel {
+func NewProfileModel(ctx context.Context, userID int, timelines TimelineFetcher, accountID string) ProfileModel {
 	return ProfileModel{
-		ctx:             ctx,
-		userID:          userID,
-		mastodonService: mastodonService,
-		accountID:       accountID,
-		loading:         true,
-		statusMessage:   "Loading profile...",
+		ctx:           ctx,
+		userID:        userID,
+		timelines:     timelines,
+		accountID:     accountID,
+		loading:       true,
+		statusMessage: "Loading profile...",
 	}
 }
 
@@ -78,7 +79,7 @@ func (m ProfileModel) Update(msg tea.Msg) (ProfileModel, tea.Cmd) {
 		}
 
 		m.account = msg.account
-		m.statuses = msg.statuses
+		m.statuses, m.pinnedCount = pinnedFirst(msg.pinned, msg.statuses)
 		m.relationship = msg.relationship
 		m.statusMessage = ""
 		return m, nil
@@ -134,13 +135,9 @@ func (m ProfileModel) View() string {
 	b.WriteString(cyanColor.Render(displayName) + "\n")
 	b.WriteString(grayColor.Render("@"+m.account.Acct) + "\n\n")
 
-	// Bio (strip HTML)
+	// Bio, up to 6 lines
 	if m.account.Note != "" {
-		bio := stripHTMLProfile(m.account.Note)
-		if len(bio) > 200 {
-			bio = bio[:197] + "..."
-		}
-		b.WriteString(bio + "\n\n")
+		b.WriteString(strings.Join(renderHTMLLines(m.account.Note, m.width, 6), "\n") + "\n\n")
 	}
 
 	// Stats
@@ -200,12 +197,13 @@ func (m ProfileModel) View() string {
 			selector = selectionColor.Render("► ")
 		}
 
-		// Content
-		content := stripHTMLProfile(status.Content)
-		if len(content) > 150 {
-			content = content[:147] + "..."
+		// Content, up to 3 lines
+		if i < m.pinnedCount {
+			b.WriteString(selector + greenColor.Render("📌 Pinned") + "\n")
+		}
+		for _, line := range renderHTMLLines(status.Content, m.width-2, 3) {
+			b.WriteString(selector + line + "\n")
 		}
-		b.WriteString(selector + content + "\n")
 
 		// Stats
 		stats := fmt.Sprintf("Likes: %d  Boosts: %d  Replies: %d",
@@ -229,8 +227,9 @@ func (m ProfileModel) View() string {
 		followText = "Unfollow"
 	}
 
-	controls := fmt.Sprintf("  %s Navigate  %s %s  %s Reply  %s Thread  %s Back",
+	controls := fmt.Sprintf("  %s Navigate  %s Back/Forward  %s %s  %s Reply  %s Thread  %s Back",
 		subtleColor.Render("↑/↓"),
+		subtleColor.Render("[ ]"),
 		keyColor.Render("[F]"),
 		followText,
 		keyColor.Render("[R]"),
@@ -253,19 +252,25 @@ func (m ProfileModel) View() string {
 func (m ProfileModel) fetchProfileCmd() tea.Cmd {
 	return func() tea.Msg {
 		// Fetch account info
-		account, err := m.mastodonService.GetAccount(m.ctx, m.userID, m.accountID)
+		account, err := m.timelines.GetAccount(m.ctx, m.userID, m.accountID)
 		if err != nil {
 			return profileLoadedMsg{err: err}
 		}
 
 		// Fetch recent statuses
-		statuses, err := m.mastodonService.GetAccountStatuses(m.ctx, m.userID, m.accountID, 20)
+		statuses, err := m.timelines.GetAccountStatuses(m.ctx, m.userID, m.accountID, 20)
 		if err != nil {
 			return profileLoadedMsg{err: err}
 		}
 
+		// Fetch pinned statuses; the profile is still useful without them
+		pinned, err := m.timelines.GetPinnedStatuses(m.ctx, m.userID, m.accountID)
+		if err != nil {
+			pinned = nil
+		}
+
 		// Fetch relationship
-		relationship, err := m.mastodonService.GetAccountRelationship(m.ctx, m.userID, m.accountID)
+		relationship, err := m.timelines.GetAccountRelationship(m.ctx, m.userID, m.accountID)
 		if err != nil {
 			// Relationship fetch is not critical, continue without it
 			relationship = nil
@@ -274,11 +279,24 @@ func (m ProfileModel) fetchProfileCmd() tea.Cmd {
 		return profileLoadedMsg{
 			account:      account,
 			statuses:     statuses,
+			pinned:       pinned,
 			relationship: relationship,
 		}
 	}
 }
 
+// pinnedFirst puts pinned statuses ahead of recent ones, leaving out the
+// recent copies of pinned ones, and returns how many are pinned
+func pinnedFirst(pinned, recent []services.MastodonStatus) ([]services.MastodonStatus, int) {
+	statuses := append([]services.MastodonStatus{}, pinned...)
+	for _, status := range recent {
+		if !slices.ContainsFunc(pinned, func(p services.MastodonStatus) bool { return p.ID == status.ID }) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, len(pinned)
+}
+
 // GetSelectedStatus returns the currently selected status
 func (m ProfileModel) GetSelectedStatus() *services.MastodonStatus {
 	if m.selectedIndex >= 0 && m.selectedIndex < len(m.statuses) {
@@ -286,18 +304,3 @@ func (m ProfileModel) GetSelectedStatus() *services.MastodonStatus {
 	}
 	return nil
 }
-
-// stripHTMLProfile removes HTML tags from content (profile-specific version)
-func stripHTMLProfile(content string) string {
-	// Remove HTML tags
-	re := regexp.MustCompile(`<[^>]*>`)
-	stripped := re.ReplaceAllString(content, "")
-
-	// Decode HTML entities
-	stripped = html.UnescapeString(stripped)
-
-	// Replace multiple spaces/newlines with single space
-	stripped = strings.Join(strings.Fields(stripped), " ")
-
-	return stripped
-}
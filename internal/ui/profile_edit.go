@@ -0,0 +1,235 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// profileEditField identifies the focused field on the edit profile screen
+type profileEditField int
+
+const (
+	profileFieldDisplayName profileEditField = iota
+	profileFieldBio
+	profileFieldAvatar
+	profileFieldCount
+)
+
+// ProfileEditModel represents the edit profile screen for the local user
+type ProfileEditModel struct {
+	userID          int
+	accountService  *services.AccountService
+	mastodonService *services.MastodonService
+	displayName     textinput.Model
+	bio             textarea.Model
+	avatarURL       textinput.Model
+	focused         profileEditField
+	syncMastodon    bool // Also push display name and bio to the linked Mastodon account
+	saving          bool
+	statusMessage   string
+	width           int
+	height          int
+}
+
+// profileSavedMsg is sent when the profile has been saved
+type profileSavedMsg struct {
+	user    *models.User
+	err     error
+	syncErr error
+}
+
+// NewProfileEditModel creates an edit profile model prefilled from the user
+func NewProfileEditModel(user *models.User, accountService *services.AccountService, mastodonService *services.MastodonService) ProfileEditModel {
+	displayName := textinput.New()
+	displayName.Placeholder = user.Username
+	displayName.CharLimit = 100
+	displayName.Width = 50
+	displayName.SetValue(user.DisplayName)
+	displayName.Focus()
+
+	bio := textarea.New()
+	bio.Placeholder = "Tell people about yourself"
+	bio.CharLimit = 500
+	bio.ShowLineNumbers = false
+	bio.SetWidth(60)
+	bio.SetHeight(4)
+	bio.SetValue(user.Bio)
+	bio.Blur()
+
+	avatarURL := textinput.New()
+	avatarURL.Placeholder = "https://example.com/avatar.png"
+	avatarURL.CharLimit = 500
+	avatarURL.Width = 50
+	avatarURL.SetValue(user.AvatarURL)
+
+	return ProfileEditModel{
+		userID:          user.ID,
+		accountService:  accountService,
+		mastodonService: mastodonService,
+		displayName:     displayName,
+		bio:             bio,
+		avatarURL:       avatarURL,
+		syncMastodon:    true,
+	}
+}
+
+// Init initializes the edit profile model
+func (m ProfileEditModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles messages for the edit profile screen
+func (m ProfileEditModel) Update(msg tea.Msg) (ProfileEditModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case profileSavedMsg:
+		m.saving = false
+		switch {
+		case msg.err != nil:
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+		case msg.syncErr != nil:
+			m.statusMessage = fmt.Sprintf("Profile saved, but Mastodon sync failed: %v", msg.syncErr)
+		default:
+			m.statusMessage = "Profile saved and sent to your followers"
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.saving {
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "tab":
+			return m.focus((m.focused + 1) % profileFieldCount), nil
+		case "shift+tab":
+			return m.focus((m.focused + profileFieldCount - 1) % profileFieldCount), nil
+		case "ctrl+t":
+			m.syncMastodon = !m.syncMastodon
+			return m, nil
+		case "ctrl+s":
+			m.saving = true
+			m.statusMessage = "Saving..."
+			return m, m.saveCmd()
+		}
+	}
+
+	var cmd tea.Cmd
+	switch m.focused {
+	case profileFieldDisplayName:
+		m.displayName, cmd = m.displayName.Update(msg)
+	case profileFieldBio:
+		m.bio, cmd = m.bio.Update(msg)
+	case profileFieldAvatar:
+		m.avatarURL, cmd = m.avatarURL.Update(msg)
+	}
+	return m, cmd
+}
+
+// focus moves keyboard focus to the given field
+func (m ProfileEditModel) focus(field profileEditField) ProfileEditModel {
+	m.displayName.Blur()
+	m.bio.Blur()
+	m.avatarURL.Blur()
+
+	switch field {
+	case profileFieldDisplayName:
+		m.displayName.Focus()
+	case profileFieldBio:
+		m.bio.Focus()
+	case profileFieldAvatar:
+		m.avatarURL.Focus()
+	}
+	m.focused = field
+	return m
+}
+
+// saveCmd stores the profile, federates the Update and optionally syncs Mastodon
+func (m ProfileEditModel) saveCmd() tea.Cmd {
+	update := services.ProfileUpdate{
+		DisplayName: strings.TrimSpace(m.displayName.Value()),
+		Bio:         strings.TrimSpace(m.bio.Value()),
+		AvatarURL:   strings.TrimSpace(m.avatarURL.Value()),
+	}
+	accountSvc := m.accountService
+	mastodonSvc := m.mastodonService
+	userID := m.userID
+	sync := m.syncMastodon
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		user, err := accountSvc.UpdateProfile(ctx, userID, update)
+		if err != nil {
+			return profileSavedMsg{err: err}
+		}
+
+		// Federation delivery can be slow; don't keep the session waiting
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			defer cancel()
+			if err := accountSvc.DeliverProfileUpdate(ctx, userID); err != nil {
+				fmt.Printf("Failed to deliver profile update: %v\n", err)
+			}
+		}()
+
+		var syncErr error
+		if sync && mastodonSvc != nil {
+			_, syncErr = mastodonSvc.UpdateCredentials(ctx, userID, update.DisplayName, update.Bio)
+		}
+
+		return profileSavedMsg{user: user, syncErr: syncErr}
+	}
+}
+
+// View renders the edit profile screen
+func (m ProfileEditModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Edit Profile") + "\n\n")
+
+	label := func(field profileEditField, text string) string {
+		if m.focused == field {
+			return promptStyle.Render("► " + text)
+		}
+		return "  " + text
+	}
+
+	b.WriteString(label(profileFieldDisplayName, "Display name") + "\n")
+	b.WriteString("  " + m.displayName.View() + "\n\n")
+
+	b.WriteString(label(profileFieldBio, "Bio") + "\n")
+	b.WriteString(m.bio.View() + "\n\n")
+
+	b.WriteString(label(profileFieldAvatar, "Avatar URL") + "\n")
+	b.WriteString("  " + m.avatarURL.View() + "\n\n")
+
+	sync := "[ ]"
+	if m.syncMastodon {
+		sync = "[x]"
+	}
+	b.WriteString(fmt.Sprintf("  %s Also update my Mastodon account (name and bio)\n\n", sync))
+
+	b.WriteString(keyStyle.Render("[Tab]") + " Next field  " +
+		keyStyle.Render("[Ctrl+T]") + " Toggle sync  " +
+		keyStyle.Render("[Ctrl+S]") + " Save  " +
+		keyStyle.Render("[Esc]") + " Back\n")
+
+	if m.statusMessage != "" {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") || strings.Contains(m.statusMessage, "failed") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
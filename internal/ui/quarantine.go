@@ -0,0 +1,221 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// quarantineLimit is how many quarantined activities the review screen shows
+const quarantineLimit = 100
+
+// QuarantineModel lets admins review inbound posts held back as likely
+// spam, releasing them to their recipients or purging them
+type QuarantineModel struct {
+	quarantine    *services.QuarantineService
+	userID        int
+	activities    []models.QuarantinedActivity
+	selectedIndex int
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// quarantineLoadedMsg is sent when the quarantine has been fetched
+type quarantineLoadedMsg struct {
+	activities []models.QuarantinedActivity
+	err        error
+}
+
+// quarantineReviewedMsg is sent when an activity was released or purged
+type quarantineReviewedMsg struct {
+	id      int64
+	message string
+	err     error
+}
+
+// NewQuarantineModel creates a new quarantine review model
+func NewQuarantineModel(quarantine *services.QuarantineService, userID int) QuarantineModel {
+	return QuarantineModel{
+		quarantine:    quarantine,
+		userID:        userID,
+		loading:       true,
+		statusMessage: "Loading quarantine...",
+	}
+}
+
+// Init fetches the quarantine
+func (m QuarantineModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// Update handles messages for the quarantine review screen
+func (m QuarantineModel) Update(msg tea.Msg) (QuarantineModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case quarantineLoadedMsg:
+		m.loading = false
+		if errors.Is(msg.err, services.ErrNotAdmin) {
+			m.statusMessage = "Error: only admins can review the quarantine"
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.activities = msg.activities
+		if m.selectedIndex >= len(m.activities) {
+			m.selectedIndex = 0
+		}
+		m.statusMessage = ""
+		return m, nil
+
+	case quarantineReviewedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		for i := range m.activities {
+			if m.activities[i].ID == msg.id {
+				m.activities = append(m.activities[:i], m.activities[i+1:]...)
+				break
+			}
+		}
+		if m.selectedIndex >= len(m.activities) && m.selectedIndex > 0 {
+			m.selectedIndex--
+		}
+		m.statusMessage = msg.message
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.selectedIndex > 0 {
+				m.selectedIndex--
+			}
+		case "down", "j":
+			if m.selectedIndex < len(m.activities)-1 {
+				m.selectedIndex++
+			}
+		case "r", "R":
+			if m.selectedIndex < len(m.activities) {
+				return m, m.releaseCmd(m.activities[m.selectedIndex].ID)
+			}
+		case "p", "P":
+			if m.selectedIndex < len(m.activities) {
+				return m, m.purgeCmd(m.activities[m.selectedIndex].ID)
+			}
+		case "ctrl+r":
+			m.loading = true
+			return m, m.fetchCmd()
+		}
+	}
+
+	return m, nil
+}
+
+// fetchCmd loads the quarantined activities
+func (m QuarantineModel) fetchCmd() tea.Cmd {
+	quarantine, userID := m.quarantine, m.userID
+	return func() tea.Msg {
+		activities, err := quarantine.List(context.Background(), userID, quarantineLimit)
+		return quarantineLoadedMsg{activities: activities, err: err}
+	}
+}
+
+// releaseCmd delivers a quarantined activity to its recipients
+func (m QuarantineModel) releaseCmd(id int64) tea.Cmd {
+	quarantine, userID := m.quarantine, m.userID
+	return func() tea.Msg {
+		err := quarantine.Release(context.Background(), userID, id)
+		return quarantineReviewedMsg{id: id, message: "Released", err: err}
+	}
+}
+
+// purgeCmd deletes a quarantined activity
+func (m QuarantineModel) purgeCmd(id int64) tea.Cmd {
+	quarantine, userID := m.quarantine, m.userID
+	return func() tea.Msg {
+		err := quarantine.Purge(context.Background(), userID, id)
+		return quarantineReviewedMsg{id: id, message: "Purged", err: err}
+	}
+}
+
+// View renders the quarantine review screen
+func (m QuarantineModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Quarantine") + "\n")
+	b.WriteString(subtleStyle.Render("Inbound posts held back as likely spam") + "\n\n")
+
+	if m.loading {
+		b.WriteString(subtleStyle.Render(m.statusMessage) + "\n")
+		return b.String()
+	}
+
+	if len(m.activities) == 0 && !strings.HasPrefix(m.statusMessage, "Error") {
+		b.WriteString("Nothing in quarantine.\n\n")
+	}
+
+	visible := max(m.height-16, 5)
+	start := 0
+	if m.selectedIndex >= visible {
+		start = m.selectedIndex - visible + 1
+	}
+	end := min(start+visible, len(m.activities))
+
+	for i := start; i < end; i++ {
+		activity := m.activities[i]
+
+		selector := "  "
+		if i == m.selectedIndex {
+			selector = promptStyle.Render("► ")
+		}
+		age := subtleStyle.Render(formatAge(time.Since(activity.CreatedAt)))
+		b.WriteString(fmt.Sprintf("%s%s %s\n", selector, truncate(activity.ActorID, 50), age))
+	}
+
+	if m.selectedIndex < len(m.activities) {
+		activity := m.activities[m.selectedIndex]
+		to := "shared inbox"
+		if activity.Username != "" {
+			to = "@" + activity.Username
+		}
+		b.WriteString("\n" + subtleStyle.Render("To "+to+", held for: "+activity.Reason) + "\n")
+		b.WriteString(truncate(activity.Content, 140) + "\n")
+	}
+
+	b.WriteString("\n" + keyStyle.Render("[R]") + " Release  " +
+		keyStyle.Render("[P]") + " Purge  " +
+		keyStyle.Render("[Ctrl+R]") + " Refresh  " +
+		keyStyle.Render("[Esc]") + " Back\n")
+
+	if m.statusMessage != "" {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
+
+// openQuarantine switches to the quarantine review screen
+func (m Model) openQuarantine() (Model, tea.Cmd) {
+	m.quarantine = NewQuarantineModel(services.NewQuarantineService(m.ctx.DB, m.ctx.Config), m.user.ID)
+	m.quarantine.width = m.width
+	m.quarantine.height = m.height
+	m = m.pushScreen(screenQuarantine)
+	return m, m.quarantine.Init()
+}
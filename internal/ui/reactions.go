@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// reactionMenuTitle identifies the emoji picker in menu messages
+const reactionMenuTitle = "React"
+
+// reactionEmoji are the emoji offered by the picker
+var reactionEmoji = []string{"👍", "❤️", "😆", "😮", "😢", "🎉", "🔥", "🙏"}
+
+// reactionsLoadedMsg carries the reactions stored on this server for feed posts
+type reactionsLoadedMsg struct {
+	reactions map[string][]services.MastodonReaction
+	err       error
+}
+
+// reactedMsg reports the outcome of adding or removing a reaction
+type reactedMsg struct {
+	objectID string
+	emoji    string
+	removed  bool
+	err      error
+}
+
+// newReactionMenu lists the emoji a post can be reacted with, marking those
+// the user already used; choosing one of those removes it
+func newReactionMenu(current []services.MastodonReaction) MenuModel {
+	mine := map[string]bool{}
+	for _, reaction := range current {
+		if reaction.Me {
+			mine[reaction.Name] = true
+		}
+	}
+
+	items := make([]MenuItem, 0, len(reactionEmoji))
+	for i, emoji := range reactionEmoji {
+		label := emoji
+		if mine[emoji] {
+			label += " (remove)"
+		}
+		items = append(items, MenuItem{ID: emoji, Label: label, Key: strconv.Itoa(i + 1)})
+	}
+	return NewMenuModel(reactionMenuTitle, items)
+}
+
+// statusReactions returns the reactions to show under a post: those its
+// server reports, merged with those stored here
+func (m Model) statusReactions(status services.MastodonStatus) []services.MastodonReaction {
+	return mergeReactions(status.EmojiReactions(), m.feed.reactions[status.URI])
+}
+
+// mergeReactions combines two lists of reaction counts. The same reaction
+// may be known to both, so the larger count of each emoji is kept.
+func mergeReactions(a, b []services.MastodonReaction) []services.MastodonReaction {
+	if len(b) == 0 {
+		return a
+	}
+	index := map[string]int{}
+	var merged []services.MastodonReaction
+	for _, reaction := range append(append([]services.MastodonReaction{}, a...), b...) {
+		i, ok := index[reaction.Name]
+		if !ok {
+			index[reaction.Name] = len(merged)
+			merged = append(merged, reaction)
+			continue
+		}
+		merged[i].Count = max(merged[i].Count, reaction.Count)
+		merged[i].Me = merged[i].Me || reaction.Me
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Count > merged[j].Count })
+	return merged
+}
+
+// renderReactions renders reaction counts grouped by emoji, marking the
+// user's own with an asterisk
+func renderReactions(reactions []services.MastodonReaction) string {
+	parts := make([]string, 0, len(reactions))
+	for _, reaction := range reactions {
+		if reaction.Count == 0 {
+			continue
+		}
+		part := fmt.Sprintf("%s %d", reaction.Name, reaction.Count)
+		if reaction.Me {
+			part += "*"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// applyReaction updates the stored reactions after the user added or removed one
+func (f *FeedModel) applyReaction(objectID, emoji string, removed bool) {
+	if f.reactions == nil {
+		f.reactions = map[string][]services.MastodonReaction{}
+	}
+	f.reactions[objectID] = toggleReaction(f.reactions[objectID], emoji, removed)
+	f.reactionsVersion++
+}
+
+// toggleReaction counts the user's reaction with emoji in or out of reactions
+func toggleReaction(reactions []services.MastodonReaction, emoji string, removed bool) []services.MastodonReaction {
+	for i := range reactions {
+		if reactions[i].Name != emoji {
+			continue
+		}
+		if removed && reactions[i].Me {
+			reactions[i].Count--
+			reactions[i].Me = false
+		} else if !removed && !reactions[i].Me {
+			reactions[i].Count++
+			reactions[i].Me = true
+		}
+		return reactions
+	}
+	if !removed {
+		reactions = append(reactions, services.MastodonReaction{Name: emoji, Count: 1, Me: true})
+	}
+	return reactions
+}
+
+// loadReactionsCmd loads the reactions stored on this server for statuses
+func loadReactionsCmd(ctx *AppContext, userID int, statuses []services.MastodonStatus) tea.Cmd {
+	var objectIDs []string
+	for _, status := range statuses {
+		if status.Reblog != nil {
+			status = *status.Reblog
+		}
+		if status.URI != "" {
+			objectIDs = append(objectIDs, status.URI)
+		}
+	}
+	if len(objectIDs) == 0 || ctx == nil || ctx.DB == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		interactions := services.NewInteractionService(ctx.DB, ctx.Config)
+		reactions, err := interactions.Reactions(context.Background(), userID, objectIDs)
+		return reactionsLoadedMsg{reactions: reactions, err: err}
+	}
+}
+
+// reactCmd reacts to a status with emoji natively over ActivityPub, or
+// removes the reaction if the user already made it
+func reactCmd(ctx *AppContext, userID int, status services.MastodonStatus, emoji string, remove bool) tea.Cmd {
+	return func() tea.Msg {
+		if status.URI == "" {
+			return reactedMsg{err: fmt.Errorf("post has no ActivityPub ID")}
+		}
+		interactions := services.NewInteractionService(ctx.DB, ctx.Config)
+		var err error
+		if remove {
+			err = interactions.Unreact(context.Background(), userID, status.URI, emoji)
+		} else {
+			err = interactions.React(context.Background(), userID, status.URI, emoji)
+		}
+		return reactedMsg{objectID: status.URI, emoji: emoji, removed: remove, err: err}
+	}
+}
@@ -0,0 +1,224 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// readerMaxWidth keeps lines short enough to read comfortably on wide
+// terminals
+const readerMaxWidth = 72
+
+// readerTopReplies is how many replies the reader shows
+const readerTopReplies = 5
+
+// ReaderModel shows a single post without distractions: its whole text,
+// every alt text, its preview card and its top replies, spaced out and
+// wrapped to the screen, for phone SSH clients with narrow viewports
+type ReaderModel struct {
+	userID       int
+	timelines    TimelineFetcher
+	status       services.MastodonStatus
+	replies      []services.MastodonStatus
+	repliesErr   error
+	loading      bool
+	scrollOffset int
+	width        int
+	height       int
+}
+
+// readerRepliesMsg carries the replies to a post shown in the reader
+type readerRepliesMsg struct {
+	statusID string
+	replies  []services.MastodonStatus
+	err      error
+}
+
+// NewReaderModel creates a reader for status
+func NewReaderModel(userID int, timelines TimelineFetcher, status services.MastodonStatus) ReaderModel {
+	return ReaderModel{
+		userID:    userID,
+		timelines: timelines,
+		status:    status,
+		loading:   status.RepliesCount > 0,
+	}
+}
+
+// Init fetches the replies to the post, when it has any
+func (m ReaderModel) Init() tea.Cmd {
+	if !m.loading || m.timelines == nil {
+		return nil
+	}
+	timelines, userID, statusID := m.timelines, m.userID, m.status.ID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		thread, err := timelines.GetStatusContext(ctx, userID, statusID)
+		if err != nil {
+			return readerRepliesMsg{statusID: statusID, err: err}
+		}
+		return readerRepliesMsg{statusID: statusID, replies: topReplies(statusID, thread.Descendants)}
+	}
+}
+
+// topReplies returns the direct replies to a post that were liked and
+// boosted the most, oldest first among equals
+func topReplies(statusID string, descendants []services.MastodonStatus) []services.MastodonStatus {
+	var replies []services.MastodonStatus
+	for _, reply := range descendants {
+		if reply.InReplyToID != nil && *reply.InReplyToID == statusID {
+			replies = append(replies, reply)
+		}
+	}
+	slices.SortStableFunc(replies, func(a, b services.MastodonStatus) int {
+		return (b.FavouritesCount + b.ReblogsCount) - (a.FavouritesCount + a.ReblogsCount)
+	})
+	return replies[:min(len(replies), readerTopReplies)]
+}
+
+// Update handles messages for the reader
+func (m ReaderModel) Update(msg tea.Msg) (ReaderModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case readerRepliesMsg:
+		if msg.statusID != m.status.ID {
+			return m, nil
+		}
+		m.loading = false
+		m.replies, m.repliesErr = msg.replies, msg.err
+		return m, nil
+
+	case tea.KeyMsg:
+		last := max(len(m.lines())-m.page(), 0)
+		switch msg.String() {
+		case "up", "k":
+			m.scrollOffset--
+		case "down", "j":
+			m.scrollOffset++
+		case " ", "pgdown", "f":
+			m.scrollOffset += m.page() - 1
+		case "pgup", "b":
+			m.scrollOffset -= m.page() - 1
+		case "home", "g":
+			m.scrollOffset = 0
+		case "end", "G":
+			m.scrollOffset = last
+		}
+		m.scrollOffset = max(min(m.scrollOffset, last), 0)
+	}
+	return m, nil
+}
+
+// page is how many lines of the post fit on screen
+func (m ReaderModel) page() int {
+	// A blank line and the controls below
+	return max(m.height-2, 3)
+}
+
+// View renders the part of the post that fits the screen
+func (m ReaderModel) View() string {
+	lines := m.lines()
+	start := min(m.scrollOffset, max(len(lines)-1, 0))
+	end := min(start+m.page(), len(lines))
+
+	var b strings.Builder
+	b.WriteString(strings.Join(lines[start:end], "\n"))
+	for i := end - start; i < m.page(); i++ {
+		b.WriteString("\n")
+	}
+	controls := subtleStyle.Render("↑/↓ Space") + " Scroll  " + keyStyle.Render("[Esc]") + " Back"
+	if end < len(lines) {
+		controls += "  " + subtleStyle.Render(fmt.Sprintf("%d%%", 100*end/len(lines)))
+	}
+	b.WriteString("\n\n" + controls)
+	return b.String()
+}
+
+// margin returns the blank columns left of the text: none on narrow
+// screens, and enough to center the text on wide ones
+func (m ReaderModel) margin() int {
+	if m.width < 40 {
+		return 0
+	}
+	return max((m.width-readerMaxWidth)/2, 1)
+}
+
+// lines renders the whole post, one screen line each
+func (m ReaderModel) lines() []string {
+	status := m.status
+	margin := strings.Repeat(" ", m.margin())
+	width := max(min(m.width-2*m.margin(), readerMaxWidth), 20)
+	// Styled a line at a time, so that every line shows in its style alone
+	wrap := func(style lipgloss.Style, text string) []string {
+		lines := strings.Split(ansi.Wrap(text, width, ""), "\n")
+		for i, line := range lines {
+			lines[i] = style.Render(line)
+		}
+		return lines
+	}
+
+	var lines []string
+	add := func(more ...string) {
+		for _, line := range more {
+			lines = append(lines, margin+line)
+		}
+	}
+
+	name := status.Account.DisplayName
+	if name == "" {
+		name = status.Account.Username
+	}
+	add("", authorStyle.Render(name))
+	add(handleStyle.Render("@" + status.Account.Acct))
+	add(subtleStyle.Render(formatTimeAgo(status.CreatedAt)), "")
+	if status.SpoilerText != "" {
+		add(wrap(errorStyle, "CW: "+status.SpoilerText)...)
+		add("")
+	}
+	add(renderHTML(status.Content, width)...)
+
+	for i, media := range status.MediaAttachments {
+		add("", "", titleStyle.Render(fmt.Sprintf("%s %d of %d", mediaKind(media), i+1, len(status.MediaAttachments))))
+		if media.Description == "" {
+			add(subtleStyle.Render("No description"))
+		} else {
+			add(wrap(lipgloss.NewStyle(), media.Description)...)
+		}
+	}
+
+	if card := status.Card; card != nil && card.URL != "" {
+		add("", "")
+		if card.Title != "" {
+			add(wrap(titleStyle, card.Title)...)
+		}
+		if card.Description != "" {
+			add(wrap(lipgloss.NewStyle(), card.Description)...)
+		}
+		add(wrap(subtleStyle, card.URL)...)
+	}
+
+	add("", "", subtleStyle.Render(fmt.Sprintf("%d replies · %d boosts · %d likes",
+		status.RepliesCount, status.ReblogsCount, status.FavouritesCount)))
+
+	switch {
+	case m.loading:
+		add("", subtleStyle.Render("Loading replies..."))
+	case m.repliesErr != nil:
+		add("", errorStyle.Render(fmt.Sprintf("Error: %v", m.repliesErr)))
+	case len(m.replies) > 0:
+		add("", "", titleStyle.Render("Top replies"))
+		for _, reply := range m.replies {
+			add("", handleStyle.Render("@"+reply.Account.Acct))
+			add(renderHTMLLines(reply.Content, width, 6)...)
+		}
+	}
+	add("")
+	return lines
+}
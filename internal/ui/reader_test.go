@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+func TestTopReplies(t *testing.T) {
+	root, other := "1", "9"
+	reply := func(id string, parent *string, likes int) services.MastodonStatus {
+		return services.MastodonStatus{ID: id, InReplyToID: parent, FavouritesCount: likes}
+	}
+	descendants := []services.MastodonStatus{
+		reply("2", &root, 1), reply("3", &other, 50), reply("4", &root, 7), reply("5", &root, 1),
+		reply("6", &root, 0), reply("7", &root, 3), reply("8", &root, 2),
+	}
+	var got []string
+	for _, status := range topReplies(root, descendants) {
+		got = append(got, status.ID)
+	}
+	if want := []string{"4", "7", "8", "2", "5"}; !slices.Equal(got, want) {
+		t.Errorf("top replies = %v, want %v", got, want)
+	}
+}
+
+func TestReaderScroll(t *testing.T) {
+	m := newTestModel(t, 40, 12)
+	m.screen = screenFeed
+	m = send(m, timelineMsg{statuses: fixtureStatuses(), timelineType: services.TimelineHome})
+	m = send(m, tea.KeyMsg{Type: tea.KeyDown})
+	m = send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("z")})
+	if m.screen != screenReader {
+		t.Fatalf("expected z to open the reader, got screen %s", screenName(m.screen))
+	}
+
+	last := len(m.reader.lines()) - m.reader.page()
+	m = send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")})
+	if m.reader.scrollOffset != last {
+		t.Errorf("G scrolled to line %d, want %d", m.reader.scrollOffset, last)
+	}
+	m = send(m, tea.KeyMsg{Type: tea.KeySpace})
+	if m.reader.scrollOffset != last {
+		t.Errorf("scrolled past the end, to line %d", m.reader.scrollOffset)
+	}
+
+	m = send(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.screen != screenFeed || m.feed.selectedIndex != 1 {
+		t.Errorf("expected Esc to return to the feed as it was, got screen %s", screenName(m.screen))
+	}
+}
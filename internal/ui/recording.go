@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fulgidus/terminalpub/internal/asciicast"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// recordingMark is drawn in the top right corner while the session is recorded
+const recordingMark = "● REC"
+
+// recordingSavedMsg is returned when a stopped recording has been stored
+type recordingSavedMsg struct {
+	url       string
+	duration  time.Duration
+	truncated bool
+	err       error
+}
+
+// sessionRecorder returns the recorder the SSH server put in front of the
+// session, or nil when recordings are off
+func sessionRecorder(ctx *AppContext, s ssh.Session) *asciicast.Recorder {
+	if ctx.RecordingService == nil || !ctx.RecordingService.Enabled() || s == nil {
+		return nil
+	}
+	return asciicast.FromContext(s.Context())
+}
+
+// recording reports whether the session is being recorded
+func (m Model) recording() bool {
+	return m.recorder != nil && m.recorder.Recording()
+}
+
+// toggleRecording starts recording the session, or stops and saves the
+// recording under way. Only the user can start one, and the mark in the
+// corner shows for as long as it runs.
+func (m Model) toggleRecording() (Model, tea.Cmd) {
+	if m.recorder == nil {
+		m.message = "Error: session recording unavailable"
+		return m, nil
+	}
+
+	if recording := m.recorder.Stop(); recording != nil {
+		m.message = "Saving recording..."
+		return m, saveRecordingCmd(m.ctx.RecordingService, m.user.ID, recording)
+	}
+
+	title := fmt.Sprintf("@%s on %s", m.user.Username, m.ctx.Config.Server.Domain)
+	if err := m.recorder.Start(title); err != nil {
+		m.message = fmt.Sprintf("Error: %v", err)
+		return m, nil
+	}
+	m.message = "Recording: everything shown from now on, DMs included, is saved when you stop with :record. Leaving the session discards it."
+	// Redraw everything so the recording opens on a complete screen
+	return m, tea.ClearScreen
+}
+
+// discardRecording drops the recording under way, if any
+func (m Model) discardRecording() Model {
+	if m.recorder != nil {
+		m.recorder.Stop()
+	}
+	return m
+}
+
+// saveRecordingCmd stores a recording and creates a one-time link to it
+func saveRecordingCmd(recordingSvc *services.RecordingService, userID int, recording *asciicast.Recording) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		id, err := recordingSvc.Save(ctx, userID, recording)
+		if err != nil {
+			return recordingSavedMsg{err: err}
+		}
+		url, err := recordingSvc.CreateDownloadLink(ctx, userID, id)
+		return recordingSavedMsg{url: url, duration: recording.Duration, truncated: recording.Truncated, err: err}
+	}
+}
+
+// withRecordingMark draws recordingMark over the end of the first line
+func withRecordingMark(view string, width int) string {
+	first, rest, multiline := strings.Cut(view, "\n")
+	markWidth := ansi.StringWidth(recordingMark)
+	x := max(width-markWidth-1, 0)
+	left := ansi.Truncate(first, x, "")
+	if pad := x - ansi.StringWidth(left); pad > 0 {
+		left += strings.Repeat(" ", pad)
+	}
+	first = left + ansi.ResetStyle + errorStyle.Render(recordingMark)
+	if !multiline {
+		return first
+	}
+	return first + "\n" + rest
+}
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/asciicast"
+)
+
+func TestRecordingToggle(t *testing.T) {
+	m := newTestModel(t, 80, 24)
+	if _, cmd := m.toggleRecording(); cmd != nil || m.recording() {
+		t.Fatal("expected recording to be unavailable without a recorder")
+	}
+
+	m.recorder = asciicast.NewRecorder("xterm-256color", 80, 24, 0)
+	m, cmd := m.toggleRecording()
+	if cmd == nil || !m.recording() {
+		t.Fatal("expected the recording to start with a redraw")
+	}
+	view := m.View()
+	if first, _, _ := strings.Cut(view, "\n"); !strings.HasSuffix(first, recordingMark) {
+		t.Errorf("expected the mark on the first line, got %q", first)
+	}
+	m.recorder.Write([]byte(view))
+
+	m, cmd = m.toggleRecording()
+	if cmd == nil || m.recording() {
+		t.Fatal("expected the recording to stop and be saved")
+	}
+	if strings.Contains(m.View(), recordingMark) {
+		t.Error("expected the mark gone once stopped")
+	}
+
+	m, _ = m.toggleRecording()
+	m = m.discardRecording()
+	if m.recording() {
+		t.Error("expected logging out to discard the recording")
+	}
+}
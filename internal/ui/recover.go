@@ -0,0 +1,261 @@
+package ui
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"runtime/debug"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/reporting"
+)
+
+// cmdType is the type of the commands a batch or sequence message holds
+var cmdType = reflect.TypeOf((*tea.Cmd)(nil)).Elem()
+
+// panicMsg reports a command that panicked
+type panicMsg struct {
+	id    string // Reference the panic was reported under
+	value any
+	stack []byte
+}
+
+// panicReport is what is logged about a panic, as one JSON line
+type panicReport struct {
+	ID       string   `json:"id"` // Shown to the user to quote in bug reports
+	Source   string   `json:"source"`
+	Msg      string   `json:"msg,omitempty"` // Type of the message being handled
+	UserID   int      `json:"user_id,omitempty"`
+	Username string   `json:"username,omitempty"`
+	IP       string   `json:"ip,omitempty"`
+	Screen   string   `json:"screen"`
+	History  []string `json:"history,omitempty"` // Screens Back returns to, oldest first
+	Width    int      `json:"width"`
+	Height   int      `json:"height"`
+	Panic    string   `json:"panic"`
+	Stack    string   `json:"stack"`
+}
+
+// recoverModel keeps a panic in one session's Update, View or commands
+// from ending it: the panic is logged and the user sees an error screen
+// that leads back to the main menu. It is a pointer so View, which cannot
+// return a model, can record a panic too.
+type recoverModel struct {
+	model Model
+	crash *panicReport // Shown until the user leaves the error screen
+}
+
+// Recover wraps a session's model so that panics end up on an error screen
+// instead of closing the connection
+func Recover(m Model) tea.Model {
+	return &recoverModel{model: m}
+}
+
+// Init starts the wrapped model
+func (r *recoverModel) Init() (cmd tea.Cmd) {
+	defer func() {
+		if value := recover(); value != nil {
+			r.crashed("init", nil, value, debug.Stack(), "")
+			cmd = nil
+		}
+	}()
+	return guardCmd(r.model.Init(), r.model)
+}
+
+// Update passes messages to the wrapped model, or to the error screen
+func (r *recoverModel) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
+	if msg, ok := msg.(panicMsg); ok {
+		r.crashed("command", nil, msg.value, msg.stack, msg.id)
+		return r, nil
+	}
+	if r.crash != nil {
+		return r.updateCrashed(msg)
+	}
+
+	defer func() {
+		if value := recover(); value != nil {
+			// The model is left as it was before the message
+			r.crashed("update", msg, value, debug.Stack(), "")
+			model, cmd = r, nil
+		}
+	}()
+	next, cmd := r.model.Update(msg)
+	r.model = next.(Model)
+	return r, guardCmd(cmd, r.model)
+}
+
+// updateCrashed handles keys on the error screen: Enter returns to the main
+// menu. Other messages still reach the model, so timers and resizes carry on.
+func (r *recoverModel) updateCrashed(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter", "esc", "m":
+			r.crash = nil
+			r.model = r.model.resetToMenu()
+		case "q", "ctrl+c":
+			return r, tea.Quit
+		}
+		return r, nil
+	case tea.MouseMsg:
+		return r, nil
+	}
+
+	crash := r.crash
+	r.crash = nil
+	_, cmd := r.Update(msg)
+	if r.crash == nil {
+		r.crash = crash
+	}
+	return r, cmd
+}
+
+// View renders the wrapped model, or the error screen
+func (r *recoverModel) View() (view string) {
+	if r.crash != nil {
+		return r.renderCrash()
+	}
+	defer func() {
+		if value := recover(); value != nil {
+			r.crashed("view", nil, value, debug.Stack(), "")
+			view = r.renderCrash()
+		}
+	}()
+	return r.model.View()
+}
+
+// crashed logs a panic and switches to the error screen. Panics in Init,
+// Update and View are also sent to error reporting, under a new id; those
+// in commands were sent where they happened.
+func (r *recoverModel) crashed(source string, msg tea.Msg, value any, stack []byte, id string) {
+	m := r.model
+	if id == "" {
+		id = newPanicID()
+		reporting.CapturePanic(value, m.reportTags(source, id))
+	}
+	report := &panicReport{
+		ID:     id,
+		Source: source,
+		IP:     sessionIP(m.sshSession),
+		Screen: screenName(m.screen),
+		Width:  m.width,
+		Height: m.height,
+		Panic:  fmt.Sprint(value),
+		Stack:  string(stack),
+	}
+	if msg != nil {
+		report.Msg = fmt.Sprintf("%T", msg)
+	}
+	if m.user != nil {
+		report.UserID, report.Username = m.user.ID, m.user.Username
+	}
+	for _, entry := range m.screens {
+		report.History = append(report.History, screenName(entry.screen))
+	}
+
+	line, err := json.Marshal(report)
+	if err != nil {
+		log.Printf("TUI panic %s: %v\n%s", report.ID, value, stack)
+	} else {
+		log.Printf("TUI panic: %s", line)
+	}
+	r.crash = report
+}
+
+// renderCrash renders the error screen
+func (r *recoverModel) renderCrash() string {
+	m := r.model
+	back := "the welcome screen"
+	if m.authenticated {
+		back = "the main menu"
+	}
+
+	var b strings.Builder
+	b.WriteString(errorStyle.Render("Something went wrong") + "\n\n")
+	b.WriteString("This screen ran into an error and was closed. Your session is fine.\n")
+	b.WriteString(subtleStyle.Render("If you report this, quote error "+r.crash.ID+".") + "\n\n")
+	b.WriteString(subtleStyle.Render("[Enter] Back to " + back + "  [Q] Quit"))
+
+	view := m.centerContent(b.String())
+	if m.accessible {
+		view = b.String()
+	}
+	if m.ascii {
+		view = asciiOnly(view)
+	}
+	return view
+}
+
+// resetToMenu leaves whatever screen was open for the main menu, or the
+// welcome screen when nobody is signed in
+func (m Model) resetToMenu() Model {
+	m.screens, m.ahead = nil, nil
+	m.palette, m.menu = nil, nil
+	m.message = ""
+	if m.authenticated && m.user != nil {
+		m.screen = screenAuthenticated
+	} else {
+		m.screen = screenWelcome
+	}
+	return m
+}
+
+// guardCmd makes a command of m report a panic as a panicMsg, including
+// the commands of a batch or sequence it returns
+func guardCmd(cmd tea.Cmd, m Model) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() (msg tea.Msg) {
+		defer func() {
+			if value := recover(); value != nil {
+				id := newPanicID()
+				reporting.CapturePanic(value, m.reportTags("command", id))
+				msg = panicMsg{id: id, value: value, stack: debug.Stack()}
+			}
+		}()
+		msg = cmd()
+
+		// tea.BatchMsg and Bubble Tea's unexported sequence message are both
+		// slices of commands, run after this one returns
+		if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == cmdType {
+			for i := range v.Len() {
+				if inner, _ := v.Index(i).Interface().(tea.Cmd); inner != nil {
+					v.Index(i).Set(reflect.ValueOf(guardCmd(inner, m)))
+				}
+			}
+		}
+		return msg
+	}
+}
+
+// reportTags describes where a panic happened for error reporting
+func (m Model) reportTags(source, id string) reporting.Tags {
+	tags := reporting.Tags{"source": source, "panic_id": id, "screen": screenName(m.screen)}
+	if m.user != nil {
+		tags["user"] = reporting.UserHash(m.user.ID)
+		if m.user.PrimaryMastodonInstance != "" {
+			tags["instance"] = strings.TrimPrefix(m.user.PrimaryMastodonInstance, "https://")
+		}
+	}
+	return tags
+}
+
+// newPanicID returns a short random reference for a panic
+func newPanicID() string {
+	raw := make([]byte, 4)
+	_, _ = rand.Read(raw)
+	return hex.EncodeToString(raw)
+}
+
+// screenName names a screen for logs
+func screenName(screen screenType) string {
+	if name := screenNames[screen]; name != "" {
+		return name
+	}
+	return fmt.Sprintf("screen %d", screen)
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRecoverFromPanics(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	// The main menu of a session that lost its user panics on any key
+	m := newTestModel(t, 80, 24)
+	m.screen = screenAuthenticated
+	m.user = nil
+	r := Recover(m).(*recoverModel)
+
+	r.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	if r.crash == nil || r.crash.Source != "update" || r.crash.Msg != "tea.KeyMsg" {
+		t.Fatalf("expected the panic in Update recovered, got %+v", r.crash)
+	}
+	if !strings.Contains(r.View(), "Something went wrong") {
+		t.Errorf("expected the error screen, got %q", r.View())
+	}
+
+	r.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	if r.crash == nil || r.model.width != 100 {
+		t.Error("expected resizes to reach the model behind the error screen")
+	}
+
+	r.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if r.crash != nil || r.model.screen != screenWelcome {
+		t.Fatalf("expected Enter to leave the error screen, got screen %d", r.model.screen)
+	}
+}
+
+func TestGuardCmd(t *testing.T) {
+	cmd := guardCmd(tea.Batch(
+		func() tea.Msg { return nil },
+		func() tea.Msg { panic("boom") },
+	), newTestModel(t, 80, 24))
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected the batch, got %#v", batch)
+	}
+	msg, ok := batch[1]().(panicMsg)
+	if !ok || msg.value != "boom" || len(msg.stack) == 0 {
+		t.Fatalf("expected the panic reported, got %#v", msg)
+	}
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	r := Recover(newTestModel(t, 80, 24)).(*recoverModel)
+	r.Update(msg)
+	if r.crash == nil || r.crash.Source != "command" || r.crash.Panic != "boom" || r.crash.ID != msg.id {
+		t.Errorf("expected the error screen, got %+v", r.crash)
+	}
+}
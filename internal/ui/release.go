@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"context"
+	"log"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/fulgidus/terminalpub/internal/version"
+)
+
+// releaseMsg carries a release newer than the running build, if any
+type releaseMsg struct {
+	release *services.Release
+}
+
+// checkReleaseCmd looks for a newer release to tell an admin about
+func checkReleaseCmd(appCtx *AppContext, userID int) tea.Cmd {
+	if appCtx == nil || appCtx.ReleaseService == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		release, err := appCtx.ReleaseService.NewerRelease(ctx, userID)
+		if err != nil {
+			// Not worth bothering the admin with
+			log.Printf("Update check failed: %v", err)
+		}
+		return releaseMsg{release: release}
+	}
+}
+
+// renderReleaseNotice renders the line under the main menu telling an
+// admin a newer release is out, or nothing
+func (m Model) renderReleaseNotice(width int) string {
+	if m.release == nil {
+		return ""
+	}
+	notice := "terminalpub " + m.release.Tag + " is available (running " + version.Version + ")"
+	if m.release.URL != "" {
+		notice += ": " + m.release.URL
+	}
+	return centerText(subtleStyle.Render(notice), width) + "\n"
+}
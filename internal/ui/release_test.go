@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fulgidus/terminalpub/internal/services"
+	"github.com/fulgidus/terminalpub/internal/version"
+)
+
+func TestReleaseNotice(t *testing.T) {
+	defer func(v string) { version.Version = v }(version.Version)
+	version.Version = "v1.2.0"
+
+	m := newTestModel(t, 120, 50)
+	m.screen = screenAuthenticated
+	if strings.Contains(m.View(), "is available") {
+		t.Fatal("expected no notice without a newer release")
+	}
+
+	m = send(m, releaseMsg{release: &services.Release{Tag: "v1.3.0", URL: "https://github.com/fulgidus/terminalpub/releases/tag/v1.3.0"}})
+	if !strings.Contains(m.View(), "terminalpub v1.3.0 is available (running v1.2.0)") {
+		t.Errorf("expected the notice under the main menu, got\n%s", m.View())
+	}
+}
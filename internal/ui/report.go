@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// reportMenuTitle identifies the report category menu in menu messages
+const reportMenuTitle = "Report"
+
+// newReportMenu lists the report categories
+func newReportMenu() MenuModel {
+	return NewMenuModel(reportMenuTitle, []MenuItem{
+		{ID: string(services.ReportSpam), Label: "It's spam", Key: "s"},
+		{ID: string(services.ReportViolation), Label: "It breaks server rules", Key: "r"},
+		{ID: string(services.ReportLegal), Label: "It's illegal", Key: "l"},
+		{ID: string(services.ReportOther), Label: "Something else", Key: "o"},
+	})
+}
+
+// ReportModel represents the report form for a post and its author
+type ReportModel struct {
+	userID          int
+	mastodonService *services.MastodonService
+	status          services.MastodonStatus
+	category        services.ReportCategory
+	comment         textarea.Model
+	includePost     bool // Report the post itself, not just the account
+	remote          bool // Author lives on another instance
+	forward         bool // Forward an anonymized copy to the author's instance
+	submitting      bool
+	done            bool
+	statusMessage   string
+	width           int
+	height          int
+}
+
+// reportSubmittedMsg is sent when the report has been filed
+type reportSubmittedMsg struct {
+	err error
+}
+
+// NewReportModel creates a report form for status with the chosen category
+func NewReportModel(userID int, mastodonService *services.MastodonService, status services.MastodonStatus, category services.ReportCategory) ReportModel {
+	ta := textarea.New()
+	ta.Placeholder = "Additional comments for the moderators (optional)"
+	ta.CharLimit = 1000
+	ta.ShowLineNumbers = false
+	ta.SetWidth(60)
+	ta.SetHeight(4)
+	ta.Focus()
+
+	// Remote accounts have a domain in their acct
+	remote := strings.Contains(status.Account.Acct, "@")
+
+	return ReportModel{
+		userID:          userID,
+		mastodonService: mastodonService,
+		status:          status,
+		category:        category,
+		comment:         ta,
+		includePost:     true,
+		remote:          remote,
+		forward:         remote,
+	}
+}
+
+// Init initializes the report model
+func (m ReportModel) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+// Update handles messages for the report form
+func (m ReportModel) Update(msg tea.Msg) (ReportModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case reportSubmittedMsg:
+		m.submitting = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.done = true
+		m.statusMessage = "Report sent. Thank you, the moderators will review it."
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.submitting || m.done {
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "ctrl+a":
+			m.includePost = !m.includePost
+			return m, nil
+		case "ctrl+f":
+			if m.remote {
+				m.forward = !m.forward
+			}
+			return m, nil
+		case "ctrl+s":
+			m.submitting = true
+			m.statusMessage = "Sending report..."
+			return m, m.submitCmd()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.comment, cmd = m.comment.Update(msg)
+	return m, cmd
+}
+
+// submitCmd files the report with the user's instance
+func (m ReportModel) submitCmd() tea.Cmd {
+	svc := m.mastodonService
+	userID := m.userID
+	accountID := m.status.Account.ID
+	statusID := m.status.ID
+	category := m.category
+	comment := strings.TrimSpace(m.comment.Value())
+	forward := m.forward && m.remote
+	includePost := m.includePost
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		var err error
+		if includePost {
+			_, err = svc.ReportStatus(ctx, userID, accountID, statusID, category, comment, forward)
+		} else {
+			_, err = svc.ReportAccount(ctx, userID, accountID, category, comment, forward)
+		}
+		return reportSubmittedMsg{err: err}
+	}
+}
+
+// View renders the report form
+func (m ReportModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Report @"+m.status.Account.Acct) + "\n\n")
+	b.WriteString(subtleStyle.Render("Reason: "+string(m.category)) + "\n\n")
+
+	if m.done {
+		b.WriteString(successStyle.Render(m.statusMessage) + "\n\n")
+		b.WriteString(keyStyle.Render("[Esc]") + " Back\n")
+		return b.String()
+	}
+
+	b.WriteString(subtleStyle.Render(truncate(stripHTML(m.status.Content), 70)) + "\n\n")
+
+	b.WriteString(m.comment.View() + "\n\n")
+
+	check := func(on bool) string {
+		if on {
+			return "[x]"
+		}
+		return "[ ]"
+	}
+	b.WriteString(fmt.Sprintf("%s Include this post\n", check(m.includePost)))
+	if m.remote {
+		_, domain, _ := strings.Cut(m.status.Account.Acct, "@")
+		b.WriteString(fmt.Sprintf("%s Also send an anonymized copy to %s\n", check(m.forward), domain))
+	}
+	b.WriteString("\n")
+
+	help := keyStyle.Render("[Ctrl+A]") + " Toggle post  "
+	if m.remote {
+		help += keyStyle.Render("[Ctrl+F]") + " Toggle forward  "
+	}
+	help += keyStyle.Render("[Ctrl+S]") + " Send  " + keyStyle.Render("[Esc]") + " Cancel"
+	b.WriteString(help + "\n")
+
+	if m.statusMessage != "" {
+		msgStyle := subtleStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
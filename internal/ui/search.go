@@ -0,0 +1,225 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// searchPageSize is how many results a search page shows
+const searchPageSize = 10
+
+// SearchModel represents the "search my instance" screen over local and
+// cached remote posts
+type SearchModel struct {
+	userID        int
+	searchService *services.SearchService
+	input         textinput.Model
+	query         string // Query of the results shown
+	results       *services.SearchResults
+	offset        int
+	selectedIndex int
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// searchResultsMsg is sent when a search page is loaded
+type searchResultsMsg struct {
+	query   string
+	offset  int
+	results *services.SearchResults
+	err     error
+}
+
+// NewSearchModel creates a new search screen model
+func NewSearchModel(userID int, searchService *services.SearchService) SearchModel {
+	input := textinput.New()
+	input.Placeholder = `words, "a phrase", #hashtag, -exclude`
+	input.CharLimit = 200
+	input.Width = 50
+	input.Focus()
+
+	return SearchModel{
+		userID:        userID,
+		searchService: searchService,
+		input:         input,
+	}
+}
+
+// Init initializes the search model
+func (m SearchModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Focused reports whether the query field has focus
+func (m SearchModel) Focused() bool {
+	return m.input.Focused()
+}
+
+// Update handles messages for the search screen
+func (m SearchModel) Update(msg tea.Msg) (SearchModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case searchResultsMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.query, m.offset, m.results = msg.query, msg.offset, msg.results
+		m.selectedIndex = 0
+		m.statusMessage = ""
+		if len(msg.results.Statuses) == 0 {
+			m.statusMessage = "No matching posts"
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+
+		if m.input.Focused() {
+			switch msg.String() {
+			case "enter":
+				query := strings.TrimSpace(m.input.Value())
+				if query == "" {
+					return m, nil
+				}
+				m.input.Blur()
+				return m, m.searchCmd(query, 0)
+			case "esc":
+				if m.results != nil {
+					m.input.Blur()
+				}
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+
+		switch key := msg.String(); key {
+		case "/":
+			m.input.Focus()
+			return m, textinput.Blink
+		case "up", "k":
+			if m.selectedIndex > 0 {
+				m.selectedIndex--
+			}
+		case "down", "j":
+			if m.results != nil && m.selectedIndex < len(m.results.Statuses)-1 {
+				m.selectedIndex++
+			}
+		case "n", "N":
+			if m.results != nil && len(m.results.Statuses) == searchPageSize {
+				return m, m.searchCmd(m.query, m.offset+searchPageSize)
+			}
+		case "p", "P":
+			if m.offset > 0 {
+				return m, m.searchCmd(m.query, max(m.offset-searchPageSize, 0))
+			}
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			// Narrow the search to a hashtag facet
+			index, _ := strconv.Atoi(key)
+			if m.results != nil && index <= len(m.results.Hashtags) {
+				query := m.query + " #" + m.results.Hashtags[index-1].Name
+				m.input.SetValue(query)
+				return m, m.searchCmd(query, 0)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// searchCmd loads a page of results
+func (m *SearchModel) searchCmd(query string, offset int) tea.Cmd {
+	m.loading = true
+	m.statusMessage = "Searching..."
+	svc, userID := m.searchService, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		results, err := svc.Search(ctx, userID, query, searchPageSize, offset)
+		return searchResultsMsg{query: query, offset: offset, results: results, err: err}
+	}
+}
+
+// View renders the search screen
+func (m SearchModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Search this instance") + "\n\n")
+	b.WriteString(m.input.View() + "\n\n")
+
+	if m.results != nil && !m.loading {
+		if len(m.results.Hashtags) > 0 {
+			var facets []string
+			for i, tag := range m.results.Hashtags {
+				if i == 9 {
+					break
+				}
+				facets = append(facets, fmt.Sprintf("%s #%s (%d)", keyStyle.Render(strconv.Itoa(i+1)), tag.Name, tag.Count))
+			}
+			b.WriteString(subtleStyle.Render("Tags: ") + strings.Join(facets, "  ") + "\n\n")
+		}
+
+		// Each result takes two lines plus a blank one
+		visible := max((m.height-12)/3, 1)
+		start := 0
+		if m.selectedIndex >= visible {
+			start = m.selectedIndex - visible + 1
+		}
+		end := min(start+visible, len(m.results.Statuses))
+
+		for i := start; i < end; i++ {
+			status := m.results.Statuses[i]
+
+			selector := "  "
+			if i == m.selectedIndex {
+				selector = promptStyle.Render("► ")
+			}
+			name := status.Account.DisplayName
+			if name == "" {
+				name = status.Account.Username
+			}
+			b.WriteString(selector + titleStyle.Render(name) + " " +
+				subtleStyle.Render("@"+status.Account.Acct+" · "+formatTimeAgo(status.CreatedAt)) + "\n")
+			b.WriteString("    " + truncate(stripHTML(status.Content), 70) + "\n\n")
+		}
+
+		if len(m.results.Statuses) > 0 {
+			page := m.offset/searchPageSize + 1
+			b.WriteString(subtleStyle.Render(fmt.Sprintf("Page %d", page)) + "\n")
+		}
+	}
+
+	if m.input.Focused() {
+		b.WriteString(keyStyle.Render("[Enter]") + " Search  " +
+			keyStyle.Render("[Esc]") + " Back\n")
+	} else {
+		b.WriteString(keyStyle.Render("[/]") + " New search  " +
+			keyStyle.Render("[1-9]") + " Narrow to tag  " +
+			keyStyle.Render("[N]") + " Next  " +
+			keyStyle.Render("[P]") + " Previous  " +
+			keyStyle.Render("[Esc]") + " Back\n")
+	}
+
+	if m.statusMessage != "" {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
@@ -3,8 +3,6 @@ package ui
 import (
 	"context"
 	"fmt"
-	"html"
-	"regexp"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,11 +14,16 @@ import (
 type ThreadModel struct {
 	ctx             context.Context
 	userID          int
-	mastodonService *services.MastodonService
+	timelines       TimelineFetcher
 	rootStatus      services.MastodonStatus
 	ancestors       []services.MastodonStatus
 	descendants     []services.MastodonStatus
 	flattenedThread []threadItem
+	collapsed       map[string]bool // Branches hidden by the user
+	loadedBranches  map[string]bool // Deep branches fetched on demand
+	loadingBranch   string          // Status whose branch is being fetched
+	focusID         string          // Post the view is re-rooted on, if any
+	nav             Navigator       // Movement keys and the post filter
 	selectedIndex   int
 	scrollOffset    int
 	loading         bool
@@ -30,11 +33,18 @@ type ThreadModel struct {
 	err             error
 }
 
+// threadLazyDepth is how many levels of replies below the root are shown before
+// deeper branches are fetched on demand
+const threadLazyDepth = 4
+
 // threadItem represents a flattened thread item with depth information
 type threadItem struct {
-	status services.MastodonStatus
-	depth  int
-	isRoot bool
+	status    services.MastodonStatus
+	depth     int
+	isRoot    bool
+	hidden    int  // Replies below this post that are not shown
+	collapsed bool // Branch collapsed by the user
+	unloaded  bool // Branch must be fetched before it can be shown
 }
 
 // threadLoadedMsg is sent when the thread context is fetched
@@ -45,18 +55,39 @@ type threadLoadedMsg struct {
 	err         error
 }
 
+// threadBranchLoadedMsg is sent when a deep branch has been fetched
+type threadBranchLoadedMsg struct {
+	statusID    string
+	descendants []services.MastodonStatus
+	err         error
+}
+
 // NewThreadModel creates a new thread view model
-func NewThreadModel(ctx context.Context, userID int, mastodonService *services.MastodonService, rootStatus services.MastodonStatus) ThreadModel {
+func NewThreadModel(ctx context.Context, userID int, timelines TimelineFetcher, rootStatus services.MastodonStatus) ThreadModel {
 	return ThreadModel{
-		ctx:             ctx,
-		userID:          userID,
-		mastodonService: mastodonService,
-		rootStatus:      rootStatus,
-		loading:         true,
-		statusMessage:   "Loading thread...",
+		ctx:            ctx,
+		userID:         userID,
+		timelines:      timelines,
+		rootStatus:     rootStatus,
+		collapsed:      make(map[string]bool),
+		loadedBranches: make(map[string]bool),
+		loading:        true,
+		statusMessage:  "Loading thread...",
+		nav:            NewNavigator(),
 	}
 }
 
+// matches reports whether the thread item at i passes the filter
+func (m ThreadModel) matches(i int) bool {
+	return statusMatches(m.flattenedThread[i].status, m.nav.Query())
+}
+
+// page is how many thread items fit on screen
+func (m ThreadModel) page() int {
+	// Title and controls above, status line below
+	return max(m.height-4, 5)
+}
+
 // Init initializes the thread model and fetches the thread context
 func (m ThreadModel) Init() tea.Cmd {
 	return m.fetchThreadCmd()
@@ -93,11 +124,117 @@ func (m ThreadModel) Update(msg tea.Msg) (ThreadModel, tea.Cmd) {
 		}
 
 		return m, nil
+
+	case threadBranchLoadedMsg:
+		m.loadingBranch = ""
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+
+		known := make(map[string]bool, len(m.descendants))
+		for _, status := range m.descendants {
+			known[status.ID] = true
+		}
+		for _, status := range msg.descendants {
+			if !known[status.ID] {
+				m.descendants = append(m.descendants, status)
+			}
+		}
+		m.loadedBranches[msg.statusID] = true
+		m.statusMessage = ""
+		return m.rebuild(msg.statusID), nil
+
+	case tea.KeyMsg:
+		if m.loading || m.selectedIndex >= len(m.flattenedThread) {
+			return m, nil
+		}
+		item := m.flattenedThread[m.selectedIndex]
+
+		switch msg.String() {
+		case "enter", "right", "l":
+			// Fetch a branch that was cut off at the depth threshold
+			if item.unloaded && m.loadingBranch == "" {
+				m.loadingBranch = item.status.ID
+				m.statusMessage = "Loading replies..."
+				return m, m.fetchBranchCmd(item.status.ID)
+			}
+			if item.collapsed {
+				delete(m.collapsed, item.status.ID)
+				return m.rebuild(item.status.ID), nil
+			}
+		case "c", " ":
+			// Collapse or expand the selected branch
+			if item.collapsed {
+				delete(m.collapsed, item.status.ID)
+			} else if item.hidden > 0 || m.hasReplies(item.status.ID) {
+				m.collapsed[item.status.ID] = true
+			}
+			return m.rebuild(item.status.ID), nil
+		case "f":
+			// Re-root the view on the selected post
+			if item.status.ID != m.focusID {
+				m.focusID = item.status.ID
+				m.scrollOffset = 0
+				return m.rebuild(item.status.ID), nil
+			}
+		}
 	}
 
 	return m, nil
 }
 
+// Unfocus leaves focus mode and shows the whole thread again; it reports
+// whether the view was focused
+func (m ThreadModel) Unfocus() (ThreadModel, bool) {
+	if m.focusID == "" {
+		return m, false
+	}
+	selectedID := m.focusID
+	m.focusID = ""
+	return m.rebuild(selectedID), true
+}
+
+// rebuild flattens the thread again, keeping selectedID selected if visible
+func (m ThreadModel) rebuild(selectedID string) ThreadModel {
+	m.flattenedThread = m.buildFlattenedThread()
+	if m.selectedIndex >= len(m.flattenedThread) {
+		m.selectedIndex = max(len(m.flattenedThread)-1, 0)
+	}
+	for i, item := range m.flattenedThread {
+		if item.status.ID == selectedID {
+			m.selectedIndex = i
+			break
+		}
+	}
+	return m
+}
+
+// hasReplies reports whether any loaded post replies to statusID
+func (m ThreadModel) hasReplies(statusID string) bool {
+	for _, status := range m.descendants {
+		if status.InReplyToID != nil && *status.InReplyToID == statusID {
+			return true
+		}
+	}
+	return false
+}
+
+// findStatus returns a loaded post of the thread by ID
+func (m ThreadModel) findStatus(statusID string) (services.MastodonStatus, bool) {
+	if m.rootStatus.ID == statusID {
+		return m.rootStatus, true
+	}
+	for _, list := range [][]services.MastodonStatus{m.ancestors, m.descendants} {
+		for _, status := range list {
+			if status.ID == statusID {
+				return status, true
+			}
+		}
+	}
+	return services.MastodonStatus{}, false
+}
+
 // View renders the thread view
 func (m ThreadModel) View() string {
 	if m.loading {
@@ -112,34 +249,24 @@ func (m ThreadModel) View() string {
 
 	// Title
 	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("99"))
-	b.WriteString(titleStyle.Render("Conversation Thread") + "\n\n")
-
-	// Calculate available height for content
-	headerLines := 3 // title + controls
-	footerLines := 1
-	availableHeight := m.height - headerLines - footerLines
-	if availableHeight < 5 {
-		availableHeight = 5
+	if m.focusID != "" {
+		b.WriteString(titleStyle.Render("Conversation Thread (focused)") + "\n\n")
+	} else {
+		b.WriteString(titleStyle.Render("Conversation Thread") + "\n\n")
 	}
 
 	// Ensure scroll offset keeps selected item visible
-	if m.selectedIndex < m.scrollOffset {
-		m.scrollOffset = m.selectedIndex
-	}
-	if m.selectedIndex >= m.scrollOffset+availableHeight {
-		m.scrollOffset = m.selectedIndex - availableHeight + 1
-	}
+	m.scrollOffset = keepVisible(m.scrollOffset, m.selectedIndex, m.page(), m.matches)
 
 	// Render visible thread items
-	endIndex := m.scrollOffset + availableHeight
-	if endIndex > len(m.flattenedThread) {
-		endIndex = len(m.flattenedThread)
+	visible := visibleIndexes(m.scrollOffset, m.page(), len(m.flattenedThread), m.matches)
+	if len(visible) == 0 {
+		b.WriteString(subtleStyle.Render("  No posts match the filter"))
 	}
-
-	for i := m.scrollOffset; i < endIndex; i++ {
+	for n, i := range visible {
 		item := m.flattenedThread[i]
 		b.WriteString(m.renderThreadItem(item, i == m.selectedIndex))
-		if i < endIndex-1 {
+		if n < len(visible)-1 {
 			b.WriteString("\n")
 		}
 	}
@@ -148,12 +275,29 @@ func (m ThreadModel) View() string {
 	b.WriteString("\n")
 	keyColor := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("208"))
 	subtleColor := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-	controls := fmt.Sprintf("  %s Navigate  %s Reply  %s Back  %s View in Browser",
-		subtleColor.Render("↑/↓"),
+	back := "Back"
+	if m.focusID != "" {
+		back = "Whole thread"
+	}
+	controls := fmt.Sprintf("  %s Navigate  %s Filter  %s Back/Forward  %s Reply  %s Collapse  %s Focus  %s Load  %s %s  %s Links  %s Reader",
+		subtleColor.Render("↑/↓ gg G"),
+		subtleColor.Render("/"),
+		subtleColor.Render("[ ]"),
 		keyColor.Render("[R]"),
+		keyColor.Render("[C]"),
+		keyColor.Render("[F]"),
+		keyColor.Render("[Enter]"),
 		keyColor.Render("[ESC]"),
-		keyColor.Render("[O]"))
+		back,
+		keyColor.Render("[O]"),
+		keyColor.Render("[Z]"))
 	b.WriteString(controls)
+	if m.statusMessage != "" {
+		b.WriteString("\n  " + subtleColor.Render(m.statusMessage))
+	}
+	if filter := m.nav.View(); filter != "" {
+		b.WriteString("\n  " + filter)
+	}
 
 	return b.String()
 }
@@ -195,13 +339,10 @@ func (m ThreadModel) renderThreadItem(item threadItem, selected bool) string {
 
 	b.WriteString(selector + indent + author + rootMarker + "\n")
 
-	// Content (strip HTML and trim)
-	content := stripHTMLFromContent(item.status.Content)
-	content = strings.TrimSpace(content)
-	if len(content) > 200 {
-		content = content[:197] + "..."
+	// Content, up to 4 lines indented like the post
+	for _, line := range renderHTMLLines(item.status.Content, m.width-lipgloss.Width(indent)-2, 4) {
+		b.WriteString(selector + indent + line + "\n")
 	}
-	b.WriteString(selector + indent + content + "\n")
 
 	// Stats and interactions
 	stats := fmt.Sprintf("Likes: %d  Boosts: %d  Replies: %d",
@@ -214,6 +355,13 @@ func (m ThreadModel) renderThreadItem(item threadItem, selected bool) string {
 		stats += " " + greenColor.Render("[*]")
 	}
 
+	switch {
+	case item.unloaded:
+		stats += "  " + greenColor.Render("[Enter] load replies")
+	case item.collapsed:
+		stats += "  " + greenColor.Render(fmt.Sprintf("[+%d hidden]", item.hidden))
+	}
+
 	b.WriteString(selector + indent + grayColor.Render(stats))
 
 	return b.String()
@@ -223,6 +371,13 @@ func (m ThreadModel) renderThreadItem(item threadItem, selected bool) string {
 func (m ThreadModel) buildFlattenedThread() []threadItem {
 	var items []threadItem
 
+	// In focus mode only the focused post and its replies are shown
+	if focus, ok := m.findStatus(m.focusID); ok && m.focusID != "" {
+		items = append(items, threadItem{status: focus, isRoot: true})
+		tree := m.buildDescendantsTree(focus.ID, m.descendants)
+		return append(items, m.flattenDescendantsTree(tree, 1)...)
+	}
+
 	// Add ancestors (in chronological order)
 	for i, status := range m.ancestors {
 		items = append(items, threadItem{
@@ -290,36 +445,76 @@ func (m ThreadModel) buildDescendantsTree(parentID string, descendants []service
 func (m ThreadModel) flattenDescendantsTree(roots []*descendantNode, startDepth int) []threadItem {
 	var items []threadItem
 
-	var flatten func(node *descendantNode, depth int)
-	flatten = func(node *descendantNode, depth int) {
+	// fetched is true below a branch that was already loaded on demand
+	var flatten func(node *descendantNode, depth int, fetched bool)
+	flatten = func(node *descendantNode, depth int, fetched bool) {
 		// Cap depth at a reasonable level for readability
 		displayDepth := depth
 		if displayDepth > startDepth+5 {
 			displayDepth = startDepth + 5
 		}
 
-		items = append(items, threadItem{
+		item := threadItem{
 			status: node.status,
 			depth:  displayDepth,
 			isRoot: false,
-		})
+		}
 
+		// Branches past the threshold, or whose replies the server left
+		// out, are fetched when the user asks for them
+		fetched = fetched || m.loadedBranches[node.status.ID]
+		hasReplies := len(node.children) > 0 || node.status.RepliesCount > 0
+		deep := depth-startDepth+1 >= threadLazyDepth && hasReplies
+		missing := len(node.children) == 0 && node.status.RepliesCount > 0
+		switch {
+		case m.collapsed[node.status.ID]:
+			item.collapsed = true
+			item.hidden = countDescendants(node)
+		case (deep || missing) && !fetched:
+			item.unloaded = true
+			item.hidden = max(countDescendants(node), node.status.RepliesCount)
+		}
+		items = append(items, item)
+
+		if item.collapsed || item.unloaded {
+			return
+		}
 		for _, child := range node.children {
-			flatten(child, depth+1)
+			flatten(child, depth+1, fetched)
 		}
 	}
 
 	for _, root := range roots {
-		flatten(root, startDepth)
+		flatten(root, startDepth, false)
 	}
 
 	return items
 }
 
+// countDescendants returns the number of replies below node
+func countDescendants(node *descendantNode) int {
+	count := len(node.children)
+	for _, child := range node.children {
+		count += countDescendants(child)
+	}
+	return count
+}
+
+// fetchBranchCmd fetches the replies below a post deep in the thread
+func (m ThreadModel) fetchBranchCmd(statusID string) tea.Cmd {
+	return func() tea.Msg {
+		context, err := m.timelines.GetStatusContext(m.ctx, m.userID, statusID)
+		if err != nil {
+			return threadBranchLoadedMsg{statusID: statusID, err: err}
+		}
+		return threadBranchLoadedMsg{statusID: statusID, descendants: context.Descendants}
+	}
+}
+
 // fetchThreadCmd fetches the thread context
 func (m ThreadModel) fetchThreadCmd() tea.Cmd {
 	return func() tea.Msg {
-		context, err := m.mastodonService.GetStatusContext(m.ctx, m.userID, m.rootStatus.ID)
+		context, err := m.timelines.GetStatusContext(m.ctx, m.userID, m.rootStatus.ID)
 		if err != nil {
 			return threadLoadedMsg{err: err}
 		}
@@ -339,18 +534,3 @@ func (m ThreadModel) GetSelectedStatus() *services.MastodonStatus {
 	}
 	return nil
 }
-
-// stripHTMLFromContent removes HTML tags from content (specific to thread view)
-func stripHTMLFromContent(content string) string {
-	// Remove HTML tags
-	re := regexp.MustCompile(`<[^>]*>`)
-	stripped := re.ReplaceAllString(content, "")
-
-	// Decode HTML entities
-	stripped = html.UnescapeString(stripped)
-
-	// Replace multiple spaces/newlines with single space
-	stripped = strings.Join(strings.Fields(stripped), " ")
-
-	return stripped
-}
@@ -2,15 +2,20 @@ package ui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/ssh"
+	"github.com/fulgidus/terminalpub/internal/asciicast"
 	"github.com/fulgidus/terminalpub/internal/auth"
 	"github.com/fulgidus/terminalpub/internal/config"
+	"github.com/fulgidus/terminalpub/internal/events"
 	"github.com/fulgidus/terminalpub/internal/models"
 	"github.com/fulgidus/terminalpub/internal/services"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -31,11 +36,30 @@ var (
 // AppContext holds shared services for the TUI
 type AppContext struct {
 	DB                *pgxpool.Pool
-	Redis             *redis.Client
+	Redis             redis.UniversalClient
 	Config            *config.Config
 	DeviceFlowService *auth.DeviceFlowService
 	SSHKeyService     *auth.SSHKeyService
 	SessionManager    *auth.SessionManager
+	APITokenService   *auth.APITokenService
+	TokenService      *auth.TokenService
+	ExportService     *services.ExportService
+	AccountService    *services.AccountService
+	PresenceService   *services.PresenceService
+	ChatService       *services.ChatService
+	GuestbookService  *services.GuestbookService
+	WriteService      *services.WriteService
+	RecordingService  *services.RecordingService
+	ReleaseService    *services.ReleaseService
+	Events            *events.Bus // Domain events raised on this node, for live updates
+
+	// Mastodon as the timeline, post and notification screens see it; nil
+	// fields use the Mastodon API of the user's linked account
+	Timelines     TimelineFetcher
+	Actions       StatusActions
+	Notifications Notifier
+
+	Demo bool // Sessions are signed in to canned data; see NewDemoContext
 }
 
 // screenType represents different screens in the TUI
@@ -46,6 +70,9 @@ const (
 	screenLogin
 	screenLoginInstance
 	screenLoginWaiting
+	screenLoginToken
+	screenLoginInvite
+	screenChooseUsername
 	screenAuthenticated
 	screenAnonymous
 	screenFeed
@@ -53,6 +80,31 @@ const (
 	screenThread
 	screenProfile
 	screenNotifications
+	screenAPITokens
+	screenImportFollows
+	screenDeleteAccount
+	screenEditProfile
+	screenReport
+	screenDiscover
+	screenInbox
+	screenActionLog
+	screenFollowRequests
+	screenSearch
+	screenHashtag
+	screenDirect
+	screenPostDetail
+	screenAnnouncements
+	screenWho
+	screenChat
+	screenGames
+	screenFeedFilters
+	screenDirectory
+	screenWebhooks
+	screenIntegrations
+	screenDigest
+	screenAudit
+	screenQuarantine
+	screenReader
 )
 
 // Model represents the TUI state
@@ -62,6 +114,10 @@ type Model struct {
 	screen         screenType
 	message        string
 	input          string
+	tokenInput     string     // Personal access token being entered
+	tokenFocused   bool       // Whether the token field has focus on the token login screen
+	inviteCode     string     // Invite code entered before logging in, used on signup
+	inviteNext     screenType // Login screen to show after the invite prompt
 	deviceAuth     *auth.DeviceAuthResponse
 	user           *models.User
 	sessionID      string
@@ -73,14 +129,69 @@ type Model struct {
 	thread         ThreadModel
 	profile        ProfileModel
 	notifications  NotificationsModel
+	apiTokens      APITokensModel
+	followImport   FollowImportModel
+	profileEdit    ProfileEditModel
+	report         ReportModel
+	discover       DiscoverModel
+	inbox          InboxModel
+	actions        ActionLogModel
+	followRequests FollowRequestsModel
+	search         SearchModel
+	hashtag        HashtagModel
+	direct         DirectMessagesModel
+	detail         PostDetailModel
+	announcements  AnnouncementsModel
+	who            WhoModel
+	chat           ChatModel
+	guestbook      GuestbookModel
+	games          GamesModel
+	feedFilters    FeedFiltersModel
+	directory      DirectoryModel
+	webhooks       WebhooksModel
+	integrations   IntegrationsModel
+	digest         DigestModel
+	audit          AuditModel
+	quarantine     QuarantineModel
+	reader         ReaderModel
+	menu           *MenuModel              // Open popup menu, if any
+	menuTarget     services.MastodonStatus // Post the open menu acts on
+	interactions   interactionSet          // Likes and boosts on their way to the server
+	palette        *PaletteModel           // Open command line, if any
+	motd           []models.MOTD           // Messages of the day not yet dismissed
+	pins           []models.TimelinePin    // Timelines pinned to the feed's numbered slots
+	screens        []screenEntry           // Screens Back returns to, most recent last
+	ahead          []screenEntry           // Screens Forward returns to, nearest last
+	lastKey        time.Time               // When the user last pressed a key
+	locked         bool                    // Screen blanked after the idle timeout
+	unlocking      bool                    // SSH key being checked to unlock
+	writes         writeInbox              // Messages other users wrote to the terminal
+	live           *liveFeed               // Events concerning the user, while signed in
+	recorder       *asciicast.Recorder     // Captures the session once the user starts recording
+	debug          *debugStats             // Live stats overlay, while open
+	release        *services.Release       // Newer release an admin is told about
 	mastodonSvc    *services.MastodonService
+	actionLog      *services.ActionLogService
 	width          int
 	height         int
-	returnToScreen screenType // Screen to return to after composing
+	lastMentionID  string // Newest mention seen by the activity poller
+	unreadMentions int    // Mentions received since notifications were last viewed
+	unreadDirect   int    // Direct messages received since notifications were last viewed
+	unreadNotices  int    // Unread announcements from the user's Mastodon instance
+	accessible     bool   // Plain text output for screen readers and braille terminals
+	ascii          bool   // ASCII-only output for terminals without UTF-8
+	clipboard      bool   // Copies go to the terminal's clipboard with OSC 52
+	copyBlock      string // Text shown plainly for the user to select, until a key is pressed
+	compact        bool   // Terminal too small for the full layout
+	connected      int    // Sessions connected to the instance, for the welcome screen
 }
 
 // NewModel creates a new TUI model
 func NewModel(ctx *AppContext, s ssh.Session) Model {
+	if ctx.Demo {
+		ctx = demoSession(ctx)
+	}
+
 	// Extract SSH public key in authorized_keys format
 	publicKey := ""
 	if s.PublicKey() != nil {
@@ -88,27 +199,40 @@ func NewModel(ctx *AppContext, s ssh.Session) Model {
 	} else {
 	}
 
+	mastodonSvc := services.NewMastodonService(ctx.DB, ctx.Config)
+
 	return Model{
-		ctx:            ctx,
-		sshSession:     s,
-		screen:         screenWelcome,
-		publicKey:      publicKey,
-		feed:           NewFeedModel(),
-		compose:        NewComposeModel(),
-		mastodonSvc:    services.NewMastodonService(ctx.DB),
-		width:          80, // Default width
-		height:         24, // Default height
-		returnToScreen: screenAuthenticated,
+		ctx:         ctx,
+		sshSession:  s,
+		screen:      screenWelcome,
+		publicKey:   publicKey,
+		feed:        NewFeedModel(),
+		compose:     NewComposeModel(),
+		guestbook:   NewGuestbookModel(ctx.GuestbookService, sessionIP(s), publicKey),
+		feedFilters: NewFeedFiltersModel(nil, 0), // Loaded once signed in
+		mastodonSvc: mastodonSvc,
+		actionLog:   ctx.actionLog(mastodonSvc),
+		width:       80, // Default width
+		height:      24, // Default height
+		lastKey:     time.Now(),
+		accessible:  startAccessible(ctx, s),
+		ascii:       startASCII(ctx, s),
+		clipboard:   startClipboard(ctx, s),
+		recorder:    sessionRecorder(ctx, s),
 	}
 }
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
+	if m.ctx.Demo {
+		user := demoUser
+		return tea.Batch(idleTickCmd(), func() tea.Msg { return authenticatedMsg{user: &user} })
+	}
 	// Check if user is already authenticated via SSH key
 	if m.publicKey != "" && m.ctx.SSHKeyService != nil {
-		return checkSSHKeyCmd(m.ctx, m.publicKey)
+		return tea.Batch(loadMOTDCmd(m.ctx, 0), loadPresenceCountCmd(m.ctx), m.guestbook.Init(), idleTickCmd(), checkSSHKeyCmd(m.ctx, m.publicKey))
 	}
-	return nil
+	return tea.Batch(loadMOTDCmd(m.ctx, 0), loadPresenceCountCmd(m.ctx), m.guestbook.Init(), idleTickCmd())
 }
 
 // checkSSHKeyCmd checks if SSH key is associated with a user
@@ -116,7 +240,10 @@ func checkSSHKeyCmd(ctx *AppContext, publicKey string) tea.Cmd {
 	return func() tea.Msg {
 		user, err := ctx.SSHKeyService.GetUserBySSHKey(context.Background(), publicKey)
 		if err == nil {
-			return authenticatedMsg{user: user}
+			return authenticatedMsg{user: user, method: "SSH key"}
+		}
+		if errors.Is(err, auth.ErrAccountSuspended) {
+			return tokenLoginMsg{err: err}
 		}
 		return nil
 	}
@@ -124,7 +251,8 @@ func checkSSHKeyCmd(ctx *AppContext, publicKey string) tea.Cmd {
 
 // Messages
 type authenticatedMsg struct {
-	user *models.User
+	user   *models.User
+	method string // How the user logged in, for the audit log; empty if they already had
 }
 
 type deviceCodeMsg struct {
@@ -149,15 +277,182 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.feed.viewportHeight = msg.Height - 10 // Reserve space for header/footer
+		m.compact = isCompact(msg.Width, msg.Height)
+		if m.recorder != nil {
+			m.recorder.Resize(msg.Width, msg.Height)
+		}
 		return m, nil
 
 	case authenticatedMsg:
 		// User is already authenticated
 		m.user = msg.user
 		m.authenticated = true
+		playing := m.screen == screenGames
 		m.screen = screenAuthenticated
+		if m.user == nil {
+			return m, nil
+		}
+		var filterService *services.FeedFilterService
+		if m.ctx != nil && m.ctx.DB != nil {
+			filterService = services.NewFeedFilterService(m.ctx.DB)
+		}
+		m.feedFilters = NewFeedFiltersModel(filterService, m.user.ID)
+		identifyCmd := tea.Batch(m.identifyPresenceCmd(m.user.ID), m.subscribeWritesCmd(), m.feedFilters.Init(),
+			loadPinsCmd(m.ctx, m.user.ID), m.recordLoginCmd(msg.method), checkReleaseCmd(m.ctx, m.user.ID))
+		if !m.user.UsernameConfirmed && m.ctx != nil && m.ctx.DB != nil {
+			// New accounts pick their local username before anything else
+			m.screen = screenChooseUsername
+			m.input = suggestUsername(m.user.PrimaryMastodonAcct)
+			m.message = ""
+			return m, identifyCmd
+		}
+		if playing {
+			// Someone playing while the login went through finishes their game
+			m.screen = screenGames
+			m.games.notice = "You're signed in! Esc leads to the main menu when you're done."
+		}
+		// Start watching for new mentions and DMs
+		var announcementsCmd tea.Cmd
+		if m.ctx.DB != nil {
+			announcementsCmd = checkAnnouncementsCmd(m.mastodonSvc, m.user.ID)
+		}
+		m, liveCmd := m.startLive()
+		return m, tea.Batch(checkNewActivityCmd(m.ctx.notifier(), m.user.ID, ""), loadMOTDCmd(m.ctx, m.user.ID),
+			announcementsCmd, identifyCmd, liveCmd)
+
+	case paletteUserMsg:
+		return m.handlePaletteUser(msg)
+
+	case unreadAnnouncementsMsg:
+		m.unreadNotices = msg.count
+		return m, nil
+
+	case presenceCountMsg:
+		m.connected = msg.count
+		return m, nil
+
+	case writesSubscribedMsg:
+		return m.handleWritesSubscribed(msg)
+
+	case terminalMessageMsg:
+		return m.handleTerminalMessage(msg)
+
+	case liveEventMsg:
+		return m.handleLiveEvent(msg)
+
+	case writeSentMsg:
+		return m.handleWriteSent(msg)
+
+	case acceptWritesMsg:
+		return m.handleAcceptWrites(msg)
+
+	case pinsLoadedMsg, timelinePinnedMsg, timelineUnpinnedMsg:
+		return m.handlePinMsg(msg)
+
+	case releaseMsg:
+		m.release = msg.release
+		return m, nil
+
+	case feedFiltersLoadedMsg, feedFilterSavedMsg, feedFilterDeletedMsg:
+		// The feed cycles through the filters whichever screen is shown
+		var cmd tea.Cmd
+		m.feedFilters, cmd = m.feedFilters.Update(msg)
+		model, moveCmd := m.applyFeedFilter(m.refreshFeedFilter())
+		return model, tea.Batch(cmd, moveCmd)
+
+	case guestbookLoadedMsg, guestbookSignedMsg:
+		// The welcome screen shows the newest entries too
+		var cmd tea.Cmd
+		m.guestbook, cmd = m.guestbook.Update(msg)
+		return m, cmd
+
+	case chatJoinedMsg:
+		if m.screen != screenChat {
+			// The chat was left while joining
+			if msg.cancel != nil {
+				msg.cancel()
+			}
+			return m, nil
+		}
+
+	case motdLoadedMsg:
+		// Messages are decoration; the welcome screen works without them
+		if msg.err == nil {
+			m.motd = msg.messages
+		}
+		return m, nil
+
+	case motdDismissedMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error: %v", msg.err)
+		}
 		return m, nil
 
+	case activityTickMsg:
+		if !m.authenticated || m.user == nil {
+			return m, nil
+		}
+		return m, checkNewActivityCmd(m.ctx.notifier(), m.user.ID, m.lastMentionID)
+
+	case newActivityMsg:
+		if !m.authenticated || m.user == nil {
+			return m, nil
+		}
+		return m.handleNewActivity(msg)
+
+	case accountDeletedMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.authenticated = false
+		m.user = nil
+		m.input = ""
+		m.screen = screenWelcome
+		m.message = "Your account has been deleted. Goodbye!"
+		m = m.stopWrites().stopLive().discardRecording()
+		m.lastMentionID = ""
+		m.release = nil
+		return m.clearUnreadActivity()
+
+	case recordingSavedMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.message = fmt.Sprintf("Recording saved (%s). Download the cast (link valid 15 min, single use):\n%s", msg.duration.Round(time.Second), msg.url)
+		if msg.truncated {
+			m.message += "\nIt reached the size limit, so it ends early."
+		}
+		return m, nil
+
+	case exportLinkMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.message = "Download your data (link valid 15 min, single use):\n" + msg.url
+		return m, nil
+
+	case usernameChosenMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.input = ""
+		m.message = fmt.Sprintf("Welcome, @%s!", msg.user.Username)
+		return m.Update(authenticatedMsg{user: msg.user})
+
+	case tokenLoginMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.input = ""
+		m.tokenInput = ""
+		m.message = ""
+		return m.Update(authenticatedMsg{user: msg.user, method: "access token"})
+
 	case deviceCodeMsg:
 		if msg.err != nil {
 			m.message = fmt.Sprintf("Error: %v\n\nPress [Esc] to go back", msg.err)
@@ -182,8 +477,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tickCmd()
 
 	case tickMsg:
-		// Poll for authorization
-		if m.screen == screenLoginWaiting && m.deviceAuth != nil {
+		// Poll for authorization, also while a game passes the time
+		if (m.screen == screenLoginWaiting || m.screen == screenGames) && m.deviceAuth != nil && !m.authenticated {
 			return m, pollAuthorizationCmd(m.ctx, m.deviceAuth.DeviceCode)
 		}
 		return m, nil
@@ -198,8 +493,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.feed.statusMessage = fmt.Sprintf("Error: %v", msg.err)
 		} else {
 			if msg.isLoadMore {
-				// Append new posts to existing ones
-				m.feed.statuses = append(m.feed.statuses, msg.statuses...)
+				// Append new posts to existing ones, within the memory cap
+				m.feed.appendStatuses(msg.statuses, m.feedMaxPosts())
 				m.feed.statusMessage = fmt.Sprintf("Loaded %d more posts", len(msg.statuses))
 
 				// Check if we got fewer posts than requested (no more available)
@@ -207,40 +502,100 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.feed.hasMore = false
 					m.feed.statusMessage = "All posts loaded"
 				}
+				return m, loadReactionsCmd(m.ctx, m.user.ID, msg.statuses)
 			} else {
 				// Replace with new timeline
 				m.feed.statuses = msg.statuses
 				m.feed.timelineType = msg.timelineType
 				m.feed.selectedIndex = 0
 				m.feed.scrollOffset = 0
+				m.feed.dropped = 0
 				m.feed.err = nil
 				m.feed.hasMore = len(msg.statuses) >= 20
 				m.feed.statusMessage = "Timeline loaded"
+				m.feed.offline = msg.offline
+				m.feed.cachedAt = msg.cachedAt
+				if msg.offline {
+					// Paging needs the server; switching timelines retries it
+					m.feed.hasMore = false
+					m.feed.statusMessage = "Mastodon is unreachable, showing cached posts"
+				}
+				if msg.readMarkerID != "" {
+					// Start at the first post not newer than the read marker
+					for i, status := range m.feed.statuses {
+						if !services.StatusIDNewer(status.ID, msg.readMarkerID) {
+							m.feed.selectedIndex = i
+							m.feed.scrollOffset = i
+							break
+						}
+					}
+					m.feed.lastReadID = msg.readMarkerID
+					m.feed.savedReadID = msg.readMarkerID
+					if m.feed.selectedIndex > 0 {
+						m.feed.statusMessage = fmt.Sprintf("Resumed where you left off (%d newer posts above)", m.feed.selectedIndex)
+					}
+				}
+				m.feed.reactions = nil
+				m.feed.reactionsVersion++
+				return m, loadReactionsCmd(m.ctx, m.user.ID, msg.statuses)
 			}
 		}
 		return m, nil
 
-	case likeMsg:
-		// Status liked/favourited
+	case reactionsLoadedMsg:
+		// Reactions are an extra; the feed works without them
+		if msg.err != nil {
+			fmt.Printf("Failed to load reactions: %v\n", msg.err)
+			return m, nil
+		}
+		if m.feed.reactions == nil {
+			m.feed.reactions = map[string][]services.MastodonReaction{}
+		}
+		for objectID, reactions := range msg.reactions {
+			m.feed.reactions[objectID] = reactions
+		}
+		m.feed.reactionsVersion++
+		return m, nil
+
+	case reactedMsg:
 		if msg.err != nil {
 			m.feed.statusMessage = fmt.Sprintf("Error: %v", msg.err)
-		} else {
-			m.feed.statusMessage = "Post liked!"
+			return m, nil
+		}
+		m.feed.applyReaction(msg.objectID, msg.emoji, msg.removed)
+		m.feed.statusMessage = "Reacted with " + msg.emoji
+		if msg.removed {
+			m.feed.statusMessage = "Removed " + msg.emoji + " reaction"
 		}
 		return m, nil
 
-	case boostMsg:
-		// Status boosted/reblogged
+	case pinnedMsg:
 		if msg.err != nil {
 			m.feed.statusMessage = fmt.Sprintf("Error: %v", msg.err)
-		} else {
-			m.feed.statusMessage = "Post boosted!"
+			return m, nil
+		}
+		m.feed.applyPinned(msg.statusID, msg.pinned)
+		m.feed.statusMessage = "Post pinned to your profile"
+		if !msg.pinned {
+			m.feed.statusMessage = "Post unpinned"
 		}
 		return m, nil
 
+	case likeMsg:
+		return m.interactionDone(interactionKey{statusID: msg.status.ID}, msg.status, msg.liked, msg.err)
+
+	case boostMsg:
+		return m.interactionDone(interactionKey{boost: true, statusID: msg.status.ID}, msg.status, msg.boosted, msg.err)
+
 	case postStatusMsg:
 		// Handle post status request from compose screen
-		return m, executePostStatusCmd(m.ctx, m.mastodonSvc, m.user.ID, msg.content, string(msg.visibility), msg.replyToID, msg.contentWarning)
+		if len(msg.targets) > 0 {
+			return m, crossPostCmd(m.ctx, m.user.ID, msg.targets, msg.content, string(msg.visibility), msg.contentWarning)
+		}
+		if msg.quoteID != "" {
+			return m, executeQuoteStatusCmd(m.mastodonSvc, m.user.ID, msg.content, string(msg.visibility), msg.quoteID, msg.quoteParam)
+		}
+		return m, executePostStatusCmd(m.ctx, m.ctx.statusActions(), m.user.ID, msg.content, string(msg.visibility), msg.replyToID, msg.contentWarning)
 
 	case postStatusResultMsg:
 		// Post completed (success or error) - update compose model
@@ -250,30 +605,215 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.compose.err = msg.err
 		} else {
 			// Success - return to previous screen
-			m.screen = m.returnToScreen
+			m = m.popScreen()
 			m.message = "Post created successfully!"
 			// Refresh feed if we're returning to feed
-			if m.returnToScreen == screenFeed {
+			if m.screen == screenFeed {
 				m.feed.loading = true
 				return m, fetchTimelineCmd(m.ctx, m.user.ID, m.feed.timelineType, 20)
 			}
 		}
 		return m, nil
 
+	case crossPostResultMsg:
+		return m.handleCrossPostResult(msg)
+
 	case composeCancelMsg:
 		// User cancelled compose - return to previous screen
-		m.screen = m.returnToScreen
+		return m.popScreen(), nil
+
+	case menuClosedMsg:
+		m.menu = nil
 		return m, nil
 
+	case menuSelectedMsg:
+		m.menu = nil
+		return m.handleMenuSelection(msg)
+
+	case postActionMsg:
+		if msg.err != nil {
+			m.feed.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+		} else {
+			m.feed.statusMessage = msg.message
+		}
+		return m, nil
+
+	case quoteSupportMsg:
+		// Open compose once we know whether the quote can be native
+		m.compose = NewQuoteModel(msg.status.ID, msg.status.Account.Acct, stripHTML(msg.status.Content), msg.status.URL, msg.quoteParam)
+		m.compose.width = m.width
+		m.compose.height = m.height
+		m = m.pushScreen(screenCompose)
+		return m, m.compose.Init()
+
+	case profileSavedMsg:
+		if msg.user != nil && m.user != nil {
+			m.user.DisplayName = msg.user.DisplayName
+			m.user.Bio = msg.user.Bio
+			m.user.AvatarURL = msg.user.AvatarURL
+		}
+
+	case idleTickMsg:
+		m, cmd := m.checkIdle()
+		if m.screen == screenWelcome {
+			// Keep the number of people connected current
+			cmd = tea.Batch(cmd, loadPresenceCountCmd(m.ctx))
+		}
+		return m, cmd
+
+	case unlockMsg:
+		return m.handleUnlock(msg)
+
+	case debugTickMsg:
+		return m.handleDebugTick(msg)
+
 	case tea.KeyMsg:
+		m.lastKey = time.Now()
+		if m.locked {
+			return m.handleLockedKey(msg)
+		}
+		if msg.String() == debugKey {
+			return m.toggleDebug()
+		}
+		if len(m.writes.pending) > 0 {
+			return m.handleWriteKey(msg)
+		}
 		return m.handleKeyPress(msg)
 	}
 
+	// Route remaining messages to the active screen's model
+	var cmd tea.Cmd
+	switch m.screen {
+	case screenThread:
+		m.thread, cmd = m.thread.Update(msg)
+	case screenProfile:
+		m.profile, cmd = m.profile.Update(msg)
+	case screenNotifications:
+		m.notifications, cmd = m.notifications.Update(msg)
+	case screenAPITokens:
+		m.apiTokens, cmd = m.apiTokens.Update(msg)
+	case screenImportFollows:
+		m.followImport, cmd = m.followImport.Update(msg)
+	case screenEditProfile:
+		m.profileEdit, cmd = m.profileEdit.Update(msg)
+	case screenReport:
+		m.report, cmd = m.report.Update(msg)
+	case screenCompose:
+		m.compose, cmd = m.compose.Update(msg)
+	case screenDiscover:
+		m.discover, cmd = m.discover.Update(msg)
+	case screenInbox:
+		m.inbox, cmd = m.inbox.Update(msg)
+	case screenActionLog:
+		m.actions, cmd = m.actions.Update(msg)
+	case screenFollowRequests:
+		m.followRequests, cmd = m.followRequests.Update(msg)
+	case screenSearch:
+		m.search, cmd = m.search.Update(msg)
+	case screenHashtag:
+		m.hashtag, cmd = m.hashtag.Update(msg)
+	case screenDirect:
+		m.direct, cmd = m.direct.Update(msg)
+	case screenPostDetail:
+		m.detail, cmd = m.detail.Update(msg)
+	case screenAnnouncements:
+		m.announcements, cmd = m.announcements.Update(msg)
+	case screenWho:
+		m.who, cmd = m.who.Update(msg)
+	case screenChat:
+		m.chat, cmd = m.chat.Update(msg)
+	case screenGames:
+		m.games, cmd = m.games.Update(msg)
+	case screenFeedFilters:
+		m.feedFilters, cmd = m.feedFilters.Update(msg)
+	case screenDirectory:
+		m.directory, cmd = m.directory.Update(msg)
+	case screenWebhooks:
+		m.webhooks, cmd = m.webhooks.Update(msg)
+	case screenIntegrations:
+		m.integrations, cmd = m.integrations.Update(msg)
+	case screenDigest:
+		m.digest, cmd = m.digest.Update(msg)
+	case screenAudit:
+		m.audit, cmd = m.audit.Update(msg)
+	case screenQuarantine:
+		m.quarantine, cmd = m.quarantine.Update(msg)
+	case screenReader:
+		m.reader, cmd = m.reader.Update(msg)
+	}
+
+	return m, cmd
+}
+
+// handleMenuSelection performs the action chosen from a popup menu
+func (m Model) handleMenuSelection(msg menuSelectedMsg) (tea.Model, tea.Cmd) {
+	status := m.menuTarget
+	if m.ctx.Demo && demoBlockedActions[msg.id] {
+		m.feed.statusMessage = demoUnavailable
+		return m, nil
+	}
+
+	switch msg.menu {
+	case postActionsMenuTitle:
+		return m.handlePostAction(msg.id, status)
+	case linksMenuTitle:
+		return m.handleLinkChoice(msg.id, status)
+	case copyMenuTitle:
+		return m.handleCopyChoice(msg.id, status)
+	case reportMenuTitle:
+		m.report = NewReportModel(m.user.ID, m.mastodonSvc, status, services.ReportCategory(msg.id))
+		m.report.width = m.width
+		m.report.height = m.height
+		m = m.pushScreen(screenReport)
+		return m, m.report.Init()
+	case boostMenuTitle:
+		switch msg.id {
+		case "quote":
+			m.feed.statusMessage = "Preparing quote..."
+			return m, checkQuoteSupportCmd(m.mastodonSvc, m.user.ID, status)
+		case "undo":
+			return m.interact(interactionKey{boost: true, statusID: status.ID}, status, false, "")
+		}
+		return m.interact(interactionKey{boost: true, statusID: status.ID}, status, true, msg.id)
+	case reactionMenuTitle:
+		remove := false
+		for _, reaction := range m.statusReactions(status) {
+			if reaction.Name == msg.id && reaction.Me {
+				remove = true
+			}
+		}
+		return m, reactCmd(m.ctx, m.user.ID, status, msg.id, remove)
+	}
+
 	return m, nil
 }
 
 // handleKeyPress handles keyboard input
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Any key puts text shown to copy away
+	if m.copyBlock != "" {
+		m.copyBlock = ""
+		return m, nil
+	}
+	// An open popup menu takes all keys
+	if m.menu != nil {
+		menu, cmd := m.menu.Update(msg)
+		m.menu = &menu
+		return m, cmd
+	}
+
+	// So does the command line
+	if m.palette != nil {
+		return m.handlePaletteKey(msg)
+	}
+	// [ and ] browse back and forward through the screens visited
+	if (msg.String() == "[" || msg.String() == "]") && m.authenticated && m.user != nil && m.historyKeys() {
+		return m.handleHistoryKey(msg.String())
+	}
+	if msg.String() == ":" && m.authenticated && m.user != nil && paletteScreens[m.screen] && !m.filtering() {
+		return m.openPalette()
+	}
+
 	switch m.screen {
 	case screenWelcome:
 		switch msg.String() {
@@ -285,12 +825,26 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.message = "Login unavailable: Database not connected"
 				return m, nil
 			}
-			m.screen = screenLoginInstance
+			m.screen = m.loginScreen(screenLoginInstance)
 			m.input = ""
 			m.message = ""
+		case "t", "T":
+			// Login by pasting a personal access token
+			if m.ctx == nil || m.ctx.TokenService == nil {
+				m.message = "Login unavailable: Database not connected"
+				return m, nil
+			}
+			m.screen = m.loginScreen(screenLoginToken)
+			m.input = ""
+			m.tokenInput = ""
+			m.tokenFocused = false
+			m.message = ""
 		case "a", "A":
 			m.screen = screenAnonymous
-			m.message = "Anonymous mode activated!"
+			m.message = ""
+			return m, m.guestbook.Init()
+		case "y", "Y":
+			return m.toggleAccessible(), nil
 		}
 
 	case screenLoginInstance:
@@ -304,7 +858,7 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				}
 				instance := strings.TrimSpace(m.input)
 				m.message = "Connecting to Mastodon..."
-				return m, initiateDeviceFlowCmd(m.ctx, instance, m.sshSession.User())
+				return m, initiateDeviceFlowCmd(m.ctx, instance, m.sshSession.User(), m.inviteCode)
 			}
 		case "esc", "ctrl+c":
 			m.screen = screenWelcome
@@ -321,110 +875,374 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case screenLoginToken:
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			m.screen = screenWelcome
+			m.input = ""
+			m.tokenInput = ""
+			m.message = ""
+		case "tab", "shift+tab", "up", "down":
+			m.tokenFocused = !m.tokenFocused
+		case "enter":
+			if !m.tokenFocused {
+				if strings.TrimSpace(m.input) != "" {
+					m.tokenFocused = true
+				}
+				return m, nil
+			}
+			if strings.TrimSpace(m.input) == "" || strings.TrimSpace(m.tokenInput) == "" {
+				m.message = "Error: instance and token are required"
+				return m, nil
+			}
+			m.message = "Verifying token..."
+			return m, loginWithTokenCmd(m.ctx, strings.TrimSpace(m.input), strings.TrimSpace(m.tokenInput), m.inviteCode, m.publicKey)
+		case "backspace":
+			if m.tokenFocused {
+				m.tokenInput = dropLastRune(m.tokenInput)
+			} else {
+				m.input = dropLastRune(m.input)
+			}
+		default:
+			// Accept typed and pasted characters
+			if msg.Type == tea.KeyRunes {
+				if m.tokenFocused {
+					m.tokenInput += string(msg.Runes)
+				} else {
+					m.input += string(msg.Runes)
+				}
+			}
+		}
+
+	case screenChooseUsername:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "enter":
+			username := strings.TrimSpace(m.input)
+			if err := models.ValidateUsername(username); err != nil {
+				m.message = fmt.Sprintf("Error: %v", err)
+				return m, nil
+			}
+			m.message = "Checking availability..."
+			return m, chooseUsernameCmd(m.ctx, m.user.ID, username)
+		case "backspace":
+			if len(m.input) > 0 {
+				m.input = m.input[:len(m.input)-1]
+			}
+		default:
+			if msg.Type == tea.KeyRunes && len(m.input) < models.MaxUsernameLength {
+				m.input += strings.ToLower(string(msg.Runes))
+			}
+		}
+
+	case screenLoginInvite:
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			m.screen = screenWelcome
+			m.inviteCode = ""
+			m.message = ""
+		case "enter":
+			// A blank code is fine for existing accounts; signup enforces it
+			m.inviteCode = services.NormalizeInviteCode(m.inviteCode)
+			m.screen = m.inviteNext
+			m.message = ""
+		case "backspace":
+			if len(m.inviteCode) > 0 {
+				m.inviteCode = m.inviteCode[:len(m.inviteCode)-1]
+			}
+		default:
+			if msg.Type == tea.KeyRunes && len(m.inviteCode) < 32 {
+				m.inviteCode += string(msg.Runes)
+			}
+		}
+
+	case screenDeleteAccount:
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			m.screen = screenAuthenticated
+			m.input = ""
+			m.message = ""
+		case "enter":
+			if m.input != m.user.Username {
+				m.message = "Error: username does not match"
+				return m, nil
+			}
+			m.message = "Deleting account..."
+			return m, deleteAccountCmd(m.ctx.AccountService, m.user.ID)
+		case "backspace":
+			if len(m.input) > 0 {
+				m.input = m.input[:len(m.input)-1]
+			}
+		default:
+			if msg.Type == tea.KeyRunes {
+				m.input += string(msg.Runes)
+			}
+		}
+
 	case screenLoginWaiting:
 		switch msg.String() {
 		case "esc", "ctrl+c", "q":
 			m.screen = screenWelcome
 			m.deviceAuth = nil
+		case "g", "G":
+			// Play while waiting; authorization is still polled
+			m.games = NewGamesModel()
+			m.screen = screenGames
 		}
 
 	case screenAuthenticated:
+		if m.demoBlocksMenuKey(msg.String()) {
+			m.message = demoUnavailable
+			return m, nil
+		}
 		switch msg.String() {
 		case "q", "ctrl+c":
 			return m, tea.Quit
+		case "y", "Y":
+			return m.toggleAccessible(), nil
 		case "x", "X":
 			// Logout - reset to welcome screen
 			m.authenticated = false
 			m.user = nil
 			m.screen = screenWelcome
+			m.screens = nil
 			m.message = "Logged out successfully"
-			return m, nil
+			m = m.stopWrites().stopLive().discardRecording()
+			m.lastMentionID = ""
+			m.release = nil
+			m, cmd := m.clearUnreadActivity()
+			return m, tea.Batch(cmd, loadMOTDCmd(m.ctx, 0), m.identifyPresenceCmd(0), loadPresenceCountCmd(m.ctx))
+		case "o", "O":
+			return m.dismissMOTD()
 		case "f", "F":
 			// Open feed screen
-			m.screen = screenFeed
+			m = m.pushScreen(screenFeed)
 			m.feed.loading = true
 			m.feed.err = nil
 			m.feed.timelineType = services.TimelineHome
-			return m, fetchTimelineCmd(m.ctx, m.user.ID, services.TimelineHome, 20)
+			return m, fetchHomeAtMarkerCmd(m.ctx, m.user.ID, 20)
 		case "p", "P":
 			// Open compose screen for new post
 			m.compose = NewComposeModel()
 			m.compose.width = m.width
 			m.compose.height = m.height
-			m.returnToScreen = screenAuthenticated
-			m.screen = screenCompose
-			return m, m.compose.Init()
+			m = m.pushScreen(screenCompose)
+			return m, tea.Batch(m.compose.Init(), loadCrossPostTargetsCmd(m.ctx, m.user.ID))
 		case "n", "N":
 			// Open notifications screen
 			bgCtx := context.Background()
-			m.notifications = NewNotificationsModel(bgCtx, m.user.ID, m.mastodonSvc)
+			var markers *services.MarkerService
+			if m.ctx.DB != nil {
+				markers = services.NewMarkerService(m.ctx.DB, m.mastodonSvc)
+			}
+			m.notifications = NewNotificationsModel(bgCtx, m.user.ID, m.ctx.notifier(), markers)
 			m.notifications.width = m.width
 			m.notifications.height = m.height
-			m.returnToScreen = screenAuthenticated
-			m.screen = screenNotifications
-			return m, m.notifications.Init()
+			m = m.pushScreen(screenNotifications)
+			var clearCmd tea.Cmd
+			m, clearCmd = m.clearUnreadActivity()
+			return m, tea.Batch(m.notifications.Init(), clearCmd)
+		case "t", "T":
+			// Open API token management
+			if m.ctx.APITokenService == nil {
+				m.message = "Error: API tokens unavailable"
+				return m, nil
+			}
+			m.apiTokens = NewAPITokensModel(auth.WithClientIP(context.Background(), sessionIP(m.sshSession)), m.user.ID, m.ctx.APITokenService, m.ctx.Config.Server.Domain)
+			m.apiTokens.width = m.width
+			m.apiTokens.height = m.height
+			m = m.pushScreen(screenAPITokens)
+			return m, m.apiTokens.Init()
+		case "e", "E":
+			// Create a one-time download link for the user's data archive
+			if m.ctx.ExportService == nil {
+				m.message = "Error: export unavailable"
+				return m, nil
+			}
+			m.message = "Preparing export..."
+			return m, createExportLinkCmd(m.ctx.ExportService, m.user.ID)
+		case "d", "D":
+			// Open account deletion confirmation
+			if m.ctx.AccountService == nil {
+				m.message = "Error: account deletion unavailable"
+				return m, nil
+			}
+			m = m.pushScreen(screenDeleteAccount)
+			m.input = ""
+			m.message = ""
+		case "u", "U":
+			// Open profile editor
+			if m.ctx.AccountService == nil {
+				m.message = "Error: profile editing unavailable"
+				return m, nil
+			}
+			m.profileEdit = NewProfileEditModel(m.user, m.ctx.AccountService, m.mastodonSvc)
+			m.profileEdit.width = m.width
+			m.profileEdit.height = m.height
+			m = m.pushScreen(screenEditProfile)
+			return m, m.profileEdit.Init()
+		case "a", "A":
+			// Open the log of the user's own actions
+			if m.actionLog == nil {
+				m.message = "Error: activity log unavailable"
+				return m, nil
+			}
+			m.actions = NewActionLogModel(m.user.ID, m.actionLog)
+			m.actions.width = m.width
+			m.actions.height = m.height
+			m = m.pushScreen(screenActionLog)
+			return m, m.actions.Init()
+		case "r", "R":
+			// Open follow requests awaiting approval
+			m.followRequests = NewFollowRequestsModel(m.user.ID, services.NewFollowerService(m.ctx.DB, m.ctx.Config))
+			m.followRequests.width = m.width
+			m.followRequests.height = m.height
+			m = m.pushScreen(screenFollowRequests)
+			return m, m.followRequests.Init()
+		case "m", "M":
+			// Open the unified inbox of mentions and DMs
+			m.inbox = NewInboxModel(m.user.ID, m.mastodonSvc)
+			m.inbox.width = m.width
+			m.inbox.height = m.height
+			m = m.pushScreen(screenInbox)
+			return m, m.inbox.Init()
+		case "s", "S":
+			// Open suggested follows
+			m.discover = NewDiscoverModel(m.user.ID, m.mastodonSvc, m.actionLog)
+			m.discover.width = m.width
+			m.discover.height = m.height
+			m = m.pushScreen(screenDiscover)
+			return m, m.discover.Init()
+		case "/":
+			// Search posts on this instance
+			m.search = NewSearchModel(m.user.ID, services.NewSearchService(m.ctx.DB, m.ctx.Config))
+			m.search.width = m.width
+			m.search.height = m.height
+			m = m.pushScreen(screenSearch)
+			return m, m.search.Init()
+		case "h", "H":
+			// Open a local hashtag timeline
+			m.hashtag = NewHashtagModel(services.NewTimelineService(m.ctx.DB, m.ctx.Config))
+			m.hashtag.width = m.width
+			m.hashtag.height = m.height
+			m = m.pushScreen(screenHashtag)
+			return m, m.hashtag.Init()
+		case "b", "B":
+			// Open the announcements of the user's Mastodon instance
+			m.announcements = NewAnnouncementsModel(m.user.ID, m.mastodonSvc)
+			m.announcements.width = m.width
+			m.announcements.height = m.height
+			m = m.pushScreen(screenAnnouncements)
+			return m, m.announcements.Init()
+		case "w", "W":
+			// See who else is connected to the instance
+			var presence *services.PresenceService
+			if m.ctx != nil {
+				presence = m.ctx.PresenceService
+			}
+			m.who = NewWhoModel(presence, m.user.ID)
+			m.who.width = m.width
+			m.who.height = m.height
+			m = m.pushScreen(screenWho)
+			return m, m.who.Init()
+		case "l", "L":
+			// Talk with everyone connected in the lobby
+			return m.openChat("lobby")
+		case "g", "G":
+			m.games = NewGamesModel()
+			m = m.pushScreen(screenGames)
+		case "j", "J":
+			// Browse the people of this instance who chose to be listed
+			return m.openDirectory()
+		case "k", "K":
+			// Type the domain of an instance to browse
+			return m.reopenPalette("instance ", "")
+		case "z", "Z":
+			// Manage the webhooks sent on account events
+			return m.openWebhooks()
+		case "v", "V":
+			// Manage the filters the feed switches between
+			m = m.pushScreen(screenFeedFilters)
+			return m, m.feedFilters.fetchCmd()
+		case "c", "C":
+			// Open native direct message conversations
+			m.direct = NewDirectMessagesModel(m.user.ID, services.NewDirectMessageService(m.ctx.DB, m.ctx.Config))
+			m.direct.width = m.width
+			m.direct.height = m.height
+			m = m.pushScreen(screenDirect)
+			return m, m.direct.Init()
+		case "i", "I":
+			// Open follow list import
+			m.followImport = NewFollowImportModel(m.user.ID, m.mastodonSvc)
+			m.followImport.width = m.width
+			m.followImport.height = m.height
+			m = m.pushScreen(screenImportFollows)
+			return m, m.followImport.Init()
 		}
 
 	case screenAnonymous:
+		if m.guestbook.Writing() {
+			var cmd tea.Cmd
+			m.guestbook, cmd = m.guestbook.Update(msg)
+			return m, cmd
+		}
 		switch msg.String() {
 		case "q", "ctrl+c":
 			return m, tea.Quit
 		case "b", "B", "esc":
 			m.screen = screenWelcome
 			m.message = ""
+		default:
+			var cmd tea.Cmd
+			m.guestbook, cmd = m.guestbook.Update(msg)
+			return m, cmd
 		}
 
 	case screenFeed:
+		// The filter being typed takes all keys
+		if m.feed.nav.Filtering() {
+			var cmd tea.Cmd
+			m.feed.nav, cmd = m.feed.nav.Update(msg)
+			model, moveCmd := m.moveFeed(nearestIndex(m.feed.selectedIndex, len(m.feed.statuses), m.feed.matches))
+			return model, tea.Batch(cmd, moveCmd)
+		}
+		// So does the slot after m or ', before digits are taken as a count
+		if m.feed.pinKey != "" {
+			return m.handlePinKey(msg.String())
+		}
+		if index, ok := m.feed.nav.Key(msg.String(), m.feed.selectedIndex, len(m.feed.statuses), m.feedPage(), m.feed.matches); ok {
+			return m.moveFeed(index)
+		}
+		if m.remoteReadOnly(msg.String()) {
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "q", "ctrl+c":
-			return m, tea.Quit
+			return m, tea.Sequence(m.saveReadMarkerCmd(), tea.Quit)
 		case "b", "B", "esc":
-			m.screen = screenAuthenticated
-			return m, nil
-		case "up", "k":
-			// Navigate up
-			if m.feed.selectedIndex > 0 {
-				m.feed.selectedIndex--
-				// Adjust scroll offset if needed
-				if m.feed.selectedIndex < m.feed.scrollOffset {
-					m.feed.scrollOffset = m.feed.selectedIndex
-				}
-			}
-		case "down", "j":
-			// Navigate down
-			if m.feed.selectedIndex < len(m.feed.statuses)-1 {
-				m.feed.selectedIndex++
-				// Adjust scroll offset if needed (viewport shows 5 posts)
-				if m.feed.selectedIndex >= m.feed.scrollOffset+5 {
-					m.feed.scrollOffset = m.feed.selectedIndex - 4
-				}
-
-				// Infinite scrolling: auto-load more when near the end
-				postsRemaining := len(m.feed.statuses) - m.feed.selectedIndex
-				if postsRemaining <= 5 && m.feed.hasMore && !m.feed.loadingMore && !m.feed.loading {
-					// Trigger auto-load
-					lastPost := m.feed.statuses[len(m.feed.statuses)-1]
-					maxID := lastPost.ID
-					m.feed.loadingMore = true
-					m.feed.statusMessage = "Loading more..."
-					return m, loadMorePostsCmd(m.ctx, m.user.ID, m.feed.timelineType, 20, maxID)
-				}
+			// Esc clears the filter before leaving
+			if nav, ok := m.feed.nav.ClearFilter(); ok && msg.String() == "esc" {
+				m.feed.nav = nav
+				return m, nil
 			}
+			m = m.popScreen()
+			return m, m.saveReadMarkerCmd()
 		case "h", "H":
-			// Switch to Home timeline
-			m.feed.loading = true
-			m.feed.timelineType = services.TimelineHome
-			return m, fetchTimelineCmd(m.ctx, m.user.ID, services.TimelineHome, 20)
+			return m.switchTimeline(services.TimelineHome)
 		case "l", "L":
-			// Switch to Local timeline
-			m.feed.loading = true
-			m.feed.timelineType = services.TimelineLocal
-			return m, fetchTimelineCmd(m.ctx, m.user.ID, services.TimelineLocal, 20)
+			return m.switchTimeline(services.TimelineLocal)
 		case "f", "F":
-			// Switch to Federated timeline
-			m.feed.loading = true
-			m.feed.timelineType = services.TimelineFederated
-			return m, fetchTimelineCmd(m.ctx, m.user.ID, services.TimelineFederated, 20)
+			return m.switchTimeline(services.TimelineFederated)
+		case "m", "'":
+			// m<slot> pins this timeline, '<slot> switches to a pinned one
+			m.feed.pinKey = msg.String()
+			m.feed.statusMessage = "Go to pin 1-9..."
+			if msg.String() == "m" {
+				m.feed.statusMessage = "Pin this timeline to 1-9..."
+			}
+			return m, nil
 		case "ctrl+r":
 			// Refresh feed
 			m.feed.loading = true
@@ -432,81 +1250,71 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, fetchTimelineCmd(m.ctx, m.user.ID, m.feed.timelineType, 20)
 
 		case "x", "X":
-			// Like the selected post (x for love)
-			if m.feed.selectedIndex < len(m.feed.statuses) {
-				status := m.feed.statuses[m.feed.selectedIndex]
-				// If it's a reblog, like the original post
-				if status.Reblog != nil {
-					return m, likeStatusCmd(m.ctx, m.user.ID, status.Reblog.ID)
-				}
-				return m, likeStatusCmd(m.ctx, m.user.ID, status.ID)
+			// Like the selected post (x for love), or take the like back
+			// If it's a reblog, like the original post
+			if status, ok := m.selectedFeedStatus(); ok {
+				return m.interact(interactionKey{statusID: status.ID}, status, !status.Favourited, "")
+			}
+		case "enter":
+			// Show everything that can be done with the selected post
+			if status, ok := m.selectedFeedStatus(); ok {
+				return m.openMenu(newPostActionsMenu(status, m.ownsStatus(status)), status), nil
+			}
+		case "e", "E":
+			// Pick an emoji to react to the selected post with
+			if status, ok := m.selectedFeedStatus(); ok {
+				return m.openMenu(newReactionMenu(m.statusReactions(status)), status), nil
 			}
 		case "s", "S":
-			// Boost the selected post (s for share)
-			if m.feed.selectedIndex < len(m.feed.statuses) {
-				status := m.feed.statuses[m.feed.selectedIndex]
-				// If it's a reblog, boost the original post
-				if status.Reblog != nil {
-					return m, boostStatusCmd(m.ctx, m.user.ID, status.Reblog.ID)
+			// Choose how to boost or quote the selected post (s for share),
+			// or boost it publicly at once when not asked to confirm
+			if status, ok := m.selectedFeedStatus(); ok {
+				if !m.ctx.Config.TUI.ConfirmBoosts {
+					return m.interact(interactionKey{boost: true, statusID: status.ID}, status, !status.Reblogged, "public")
 				}
-				return m, boostStatusCmd(m.ctx, m.user.ID, status.ID)
+				return m.openMenu(newBoostMenu(status.Reblogged), status), nil
 			}
 		case "r", "R":
-			// Reply to selected post
-			if m.feed.selectedIndex < len(m.feed.statuses) {
-				status := m.feed.statuses[m.feed.selectedIndex]
-				// If it's a reblog, reply to the original post
-				originalStatus := &status
-				if status.Reblog != nil {
-					originalStatus = status.Reblog
-				}
-				// Create reply compose model
-				author := originalStatus.Account.Acct
-				// Strip HTML from content for context display
-				content := stripHTML(originalStatus.Content)
-				m.compose = NewReplyModel(originalStatus.ID, author, content)
-				m.compose.width = m.width
-				m.compose.height = m.height
-				m.returnToScreen = screenFeed
-				m.screen = screenCompose
-				return m, m.compose.Init()
+			// Reply to selected post (the original if it's a reblog)
+			if status, ok := m.selectedFeedStatus(); ok {
+				return m.openReply(status)
 			}
 		case "t", "T":
 			// View thread for selected post
-			if m.feed.selectedIndex < len(m.feed.statuses) {
-				status := m.feed.statuses[m.feed.selectedIndex]
-				// If it's a reblog, view the thread of the original post
-				originalStatus := &status
-				if status.Reblog != nil {
-					originalStatus = status.Reblog
-				}
-				// Create thread model with background context
-				bgCtx := context.Background()
-				m.thread = NewThreadModel(bgCtx, m.user.ID, m.mastodonSvc, *originalStatus)
-				m.thread.width = m.width
-				m.thread.height = m.height
-				m.returnToScreen = screenFeed
-				m.screen = screenThread
-				return m, m.thread.Init()
+			if status, ok := m.selectedFeedStatus(); ok {
+				return m.openThread(status)
 			}
 		case "p", "P":
 			// View profile for selected post author
-			if m.feed.selectedIndex < len(m.feed.statuses) {
-				status := m.feed.statuses[m.feed.selectedIndex]
-				// If it's a reblog, view the profile of the original author
-				accountID := status.Account.ID
-				if status.Reblog != nil {
-					accountID = status.Reblog.Account.ID
+			if status, ok := m.selectedFeedStatus(); ok {
+				return m.openProfile(status.Account.ID)
+			}
+		case "i", "I":
+			// Show everything known about the selected post
+			if status, ok := m.selectedFeedStatus(); ok {
+				if _, remote := m.feed.timelineType.Instance(); remote {
+					return m.openRemoteDetail(status), nil
 				}
-				// Create profile model with background context
-				bgCtx := context.Background()
-				m.profile = NewProfileModel(bgCtx, m.user.ID, m.mastodonSvc, accountID)
-				m.profile.width = m.width
-				m.profile.height = m.height
-				m.returnToScreen = screenFeed
-				m.screen = screenProfile
-				return m, m.profile.Init()
+				return m.openDetail(status)
+			}
+		case "o", "O":
+			// List the links of the selected post to open or copy
+			if status, ok := m.selectedFeedStatus(); ok {
+				return m.openLinks(status)
+			}
+		case "c", "C":
+			// Copy the selected post's text, URL or author
+			if status, ok := m.selectedFeedStatus(); ok {
+				return m.openMenu(newCopyMenu(), status), nil
+			}
+		case "z", "Z":
+			// Read the selected post on its own
+			if status, ok := m.selectedFeedStatus(); ok {
+				return m.openReader(status)
 			}
+		case "v", "V":
+			// Switch to the next saved filter, then back to none
+			return m.cycleFeedFilter()
 		}
 
 	case screenCompose:
@@ -516,38 +1324,46 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case screenThread:
+		// The filter being typed takes all keys
+		if m.thread.nav.Filtering() {
+			var cmd tea.Cmd
+			m.thread.nav, cmd = m.thread.nav.Update(msg)
+			m.thread.selectedIndex = nearestIndex(m.thread.selectedIndex, len(m.thread.flattenedThread), m.thread.matches)
+			return m, cmd
+		}
+		if index, ok := m.thread.nav.Key(msg.String(), m.thread.selectedIndex, len(m.thread.flattenedThread), m.thread.page(), m.thread.matches); ok {
+			m.thread.selectedIndex = index
+			return m, nil
+		}
+
 		// Handle thread screen keys
 		switch msg.String() {
 		case "esc":
-			// Return to feed
-			m.screen = m.returnToScreen
-			return m, nil
-		case "up", "k":
-			// Navigate up in thread
-			if m.thread.selectedIndex > 0 {
-				m.thread.selectedIndex--
+			// Clear the filter and leave focus mode first, then return to
+			// the previous screen
+			if nav, ok := m.thread.nav.ClearFilter(); ok {
+				m.thread.nav = nav
+				return m, nil
 			}
-		case "down", "j":
-			// Navigate down in thread
-			if m.thread.selectedIndex < len(m.thread.flattenedThread)-1 {
-				m.thread.selectedIndex++
+			if thread, ok := m.thread.Unfocus(); ok {
+				m.thread = thread
+				return m, nil
 			}
+			return m.popScreen(), nil
 		case "r", "R":
 			// Reply to selected post in thread
 			if selectedStatus := m.thread.GetSelectedStatus(); selectedStatus != nil {
-				author := selectedStatus.Account.Acct
-				content := stripHTML(selectedStatus.Content)
-				m.compose = NewReplyModel(selectedStatus.ID, author, content)
-				m.compose.width = m.width
-				m.compose.height = m.height
-				m.returnToScreen = screenThread
-				m.screen = screenCompose
-				return m, m.compose.Init()
+				return m.openReply(*selectedStatus)
 			}
 		case "o", "O":
-			// Open in browser (placeholder for now)
-			if selectedStatus := m.thread.GetSelectedStatus(); selectedStatus != nil && selectedStatus.URL != "" {
-				m.thread.statusMessage = fmt.Sprintf("URL: %s", selectedStatus.URL)
+			// List the links of the selected post to open or copy
+			if selectedStatus := m.thread.GetSelectedStatus(); selectedStatus != nil {
+				return m.openLinks(*selectedStatus)
+			}
+		case "z", "Z":
+			// Read the selected post on its own
+			if selectedStatus := m.thread.GetSelectedStatus(); selectedStatus != nil {
+				return m.openReader(*selectedStatus)
 			}
 		}
 		// Delegate other updates to thread model
@@ -560,8 +1376,7 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "esc", "b", "B":
 			// Return to previous screen
-			m.screen = m.returnToScreen
-			return m, nil
+			return m.popScreen(), nil
 		case "up", "k":
 			// Navigate up in posts list
 			if m.profile.selectedIndex > 0 {
@@ -580,102 +1395,393 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case "r", "R":
 			// Reply to selected post in profile
 			if selectedStatus := m.profile.GetSelectedStatus(); selectedStatus != nil {
-				author := selectedStatus.Account.Acct
-				content := stripHTML(selectedStatus.Content)
-				m.compose = NewReplyModel(selectedStatus.ID, author, content)
-				m.compose.width = m.width
-				m.compose.height = m.height
-				m.returnToScreen = screenProfile
-				m.screen = screenCompose
-				return m, m.compose.Init()
+				return m.openReply(*selectedStatus)
 			}
 		case "t", "T":
 			// View thread for selected post in profile
 			if selectedStatus := m.profile.GetSelectedStatus(); selectedStatus != nil {
-				bgCtx := context.Background()
-				m.thread = NewThreadModel(bgCtx, m.user.ID, m.mastodonSvc, *selectedStatus)
-				m.thread.width = m.width
-				m.thread.height = m.height
-				m.returnToScreen = screenProfile
-				m.screen = screenThread
-				return m, m.thread.Init()
+				return m.openThread(*selectedStatus)
+			}
+		}
+		// Delegate other updates to profile model
+		var cmd tea.Cmd
+		m.profile, cmd = m.profile.Update(msg)
+		return m, cmd
+
+	case screenNotifications:
+		// Handle notifications screen keys
+		switch msg.String() {
+		case "esc", "b", "B":
+			// Return to previous screen
+			return m.popScreen(), nil
+		case "up", "k":
+			// Navigate up in notifications list
+			if m.notifications.selectedIndex > 0 {
+				m.notifications.selectedIndex--
+			}
+		case "down", "j":
+			// Navigate down in notifications list
+			if m.notifications.selectedIndex < len(m.notifications.notifications)-1 {
+				m.notifications.selectedIndex++
+				// Auto-load more when near end
+				notifsRemaining := len(m.notifications.notifications) - m.notifications.selectedIndex
+				if notifsRemaining <= 3 && m.notifications.hasMore && !m.notifications.loadingMore && !m.notifications.loading {
+					m.notifications.loadingMore = true
+					return m, m.notifications.fetchNotificationsCmd(true)
+				}
+			}
+		case "enter":
+			// View the notification (go to status or profile)
+			if selectedNotif := m.notifications.GetSelectedNotification(); selectedNotif != nil {
+				// If notification has a status, view it in thread
+				if selectedNotif.Status != nil {
+					return m.openThread(*selectedNotif.Status)
+				} else if selectedNotif.Type == services.NotificationFollow {
+					// For follows, view the profile
+					return m.openProfile(selectedNotif.Account.ID)
+				}
+			}
+		case "d", "D":
+			// Dismiss selected notification
+			if selectedNotif := m.notifications.GetSelectedNotification(); selectedNotif != nil {
+				return m, m.dismissNotificationCmd(selectedNotif.ID)
+			}
+		case "c", "C":
+			// Clear all notifications
+			return m, m.clearAllNotificationsCmd()
+		case "ctrl+r":
+			// Refresh notifications
+			m.notifications.loading = true
+			return m, m.notifications.fetchNotificationsCmd(false)
+		}
+		// Delegate other updates to notifications model
+		var cmd tea.Cmd
+		m.notifications, cmd = m.notifications.Update(msg)
+		return m, cmd
+
+	case screenAPITokens:
+		// Esc leaves the screen unless the name prompt is open
+		if msg.String() == "esc" && !m.apiTokens.creating {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.apiTokens, cmd = m.apiTokens.Update(msg)
+		return m, cmd
+
+	case screenImportFollows:
+		if msg.String() == "esc" {
+			// Leaving cancels an import that is still running
+			m.followImport.Cancel()
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.followImport, cmd = m.followImport.Update(msg)
+		return m, cmd
+
+	case screenReport:
+		if msg.String() == "esc" {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.report, cmd = m.report.Update(msg)
+		return m, cmd
+
+	case screenEditProfile:
+		if msg.String() == "esc" {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.profileEdit, cmd = m.profileEdit.Update(msg)
+		return m, cmd
+
+	case screenActionLog:
+		if msg.String() == "esc" {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.actions, cmd = m.actions.Update(msg)
+		return m, cmd
+
+	case screenFollowRequests:
+		if msg.String() == "esc" {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.followRequests, cmd = m.followRequests.Update(msg)
+		return m, cmd
+
+	case screenInbox:
+		switch msg.String() {
+		case "esc", "b", "B":
+			return m.popScreen(), nil
+		case "r", "R":
+			// Quick reply, addressed to everyone in the conversation
+			if item := m.inbox.SelectedItem(); item != nil {
+				status := item.Status
+				var readCmd, replyCmd tea.Cmd
+				m.inbox, readCmd = m.inbox.MarkSelectedRead()
+				m, replyCmd = m.openReply(status)
+				return m, tea.Batch(readCmd, replyCmd)
+			}
+			return m, nil
+		case "enter", "t", "T":
+			if item := m.inbox.SelectedItem(); item != nil {
+				status := item.Status
+				var readCmd, threadCmd tea.Cmd
+				m.inbox, readCmd = m.inbox.MarkSelectedRead()
+				m, threadCmd = m.openThread(status)
+				return m, tea.Batch(readCmd, threadCmd)
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.inbox, cmd = m.inbox.Update(msg)
+		return m, cmd
+
+	case screenDiscover:
+		switch msg.String() {
+		case "esc", "b", "B":
+			return m.popScreen(), nil
+		case "p", "P", "enter":
+			if account := m.discover.SelectedAccount(); account != nil {
+				return m.openProfile(account.ID)
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.discover, cmd = m.discover.Update(msg)
+		return m, cmd
+
+	case screenSearch:
+		// Esc first leaves the query field when there are results to browse
+		if msg.String() == "esc" && (!m.search.Focused() || m.search.results == nil) {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.search, cmd = m.search.Update(msg)
+		return m, cmd
+
+	case screenHashtag:
+		if msg.String() == "esc" && (!m.hashtag.Focused() || m.hashtag.tag == "") {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.hashtag, cmd = m.hashtag.Update(msg)
+		return m, cmd
+
+	case screenDirect:
+		if msg.String() == "esc" && m.direct.Browsing() {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.direct, cmd = m.direct.Update(msg)
+		return m, cmd
+
+	case screenPostDetail:
+		if msg.String() == "esc" {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.detail, cmd = m.detail.Update(msg)
+		return m, cmd
+
+	case screenAnnouncements:
+		if msg.String() == "esc" {
+			m.unreadNotices = m.announcements.Unread()
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.announcements, cmd = m.announcements.Update(msg)
+		return m, cmd
+
+	case screenWho:
+		if msg.String() == "esc" {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.who, cmd = m.who.Update(msg)
+		return m, cmd
+
+	case screenDirectory:
+		switch msg.String() {
+		case "esc":
+			return m.popScreen(), nil
+		case "enter":
+			if entry := m.directory.Selected(); entry != nil && m.ctx != nil && m.ctx.Config != nil {
+				acct := entry.Username + "@" + m.ctx.Config.Server.Domain
+				return m, lookupUserCmd(m.mastodonSvc, m.user.ID, acct)
 			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.directory, cmd = m.directory.Update(msg)
+		return m, cmd
+
+	case screenWebhooks:
+		// Esc leaves the screen unless the form is open
+		if msg.String() == "esc" && !m.webhooks.Editing() {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.webhooks, cmd = m.webhooks.Update(msg)
+		return m, cmd
+
+	case screenIntegrations:
+		// Esc leaves the screen unless the sign-in form is open
+		if msg.String() == "esc" && !m.integrations.Editing() {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.integrations, cmd = m.integrations.Update(msg)
+		return m, cmd
+
+	case screenDigest:
+		// Esc leaves the screen unless the form is open
+		if msg.String() == "esc" && !m.digest.Editing() {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.digest, cmd = m.digest.Update(msg)
+		return m, cmd
+
+	case screenAudit:
+		if msg.String() == "esc" {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.audit, cmd = m.audit.Update(msg)
+		return m, cmd
+
+	case screenQuarantine:
+		if msg.String() == "esc" {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.quarantine, cmd = m.quarantine.Update(msg)
+		return m, cmd
+
+	case screenReader:
+		if msg.String() == "esc" {
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.reader.width, m.reader.height = m.width, m.height
+		m.reader, cmd = m.reader.Update(msg)
+		return m, cmd
+
+	case screenFeedFilters:
+		// Esc leaves the screen unless the form is open
+		if msg.String() == "esc" && !m.feedFilters.Editing() {
+			return m.popScreen(), nil
 		}
-		// Delegate other updates to profile model
 		var cmd tea.Cmd
-		m.profile, cmd = m.profile.Update(msg)
+		m.feedFilters, cmd = m.feedFilters.Update(msg)
 		return m, cmd
 
-	case screenNotifications:
-		// Handle notifications screen keys
+	case screenChat:
+		if msg.String() == "esc" {
+			m.chat = m.chat.Leave()
+			return m.popScreen(), nil
+		}
+		var cmd tea.Cmd
+		m.chat, cmd = m.chat.Update(msg)
+		return m, cmd
+
+	case screenGames:
 		switch msg.String() {
-		case "esc", "b", "B":
-			// Return to previous screen
-			m.screen = m.returnToScreen
-			return m, nil
-		case "up", "k":
-			// Navigate up in notifications list
-			if m.notifications.selectedIndex > 0 {
-				m.notifications.selectedIndex--
-			}
-		case "down", "j":
-			// Navigate down in notifications list
-			if m.notifications.selectedIndex < len(m.notifications.notifications)-1 {
-				m.notifications.selectedIndex++
-				// Auto-load more when near end
-				notifsRemaining := len(m.notifications.notifications) - m.notifications.selectedIndex
-				if notifsRemaining <= 3 && m.notifications.hasMore && !m.notifications.loadingMore && !m.notifications.loading {
-					m.notifications.loadingMore = true
-					return m, m.notifications.fetchNotificationsCmd(true)
-				}
-			}
-		case "enter":
-			// View the notification (go to status or profile)
-			if selectedNotif := m.notifications.GetSelectedNotification(); selectedNotif != nil {
-				// If notification has a status, view it in thread
-				if selectedNotif.Status != nil {
-					bgCtx := context.Background()
-					m.thread = NewThreadModel(bgCtx, m.user.ID, m.mastodonSvc, *selectedNotif.Status)
-					m.thread.width = m.width
-					m.thread.height = m.height
-					m.returnToScreen = screenNotifications
-					m.screen = screenThread
-					return m, m.thread.Init()
-				} else if selectedNotif.Type == services.NotificationFollow {
-					// For follows, view the profile
-					bgCtx := context.Background()
-					m.profile = NewProfileModel(bgCtx, m.user.ID, m.mastodonSvc, selectedNotif.Account.ID)
-					m.profile.width = m.width
-					m.profile.height = m.height
-					m.returnToScreen = screenNotifications
-					m.screen = screenProfile
-					return m, m.profile.Init()
-				}
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			if m.games.Playing() {
+				m.games = m.games.Stop()
+				return m, nil
 			}
-		case "d", "D":
-			// Dismiss selected notification
-			if selectedNotif := m.notifications.GetSelectedNotification(); selectedNotif != nil {
-				return m, m.dismissNotificationCmd(selectedNotif.ID)
+			m.games.notice = ""
+			switch {
+			case m.authenticated:
+				return m.popScreen(), nil
+			case m.deviceAuth != nil:
+				m.screen = screenLoginWaiting
+			default:
+				m.screen = screenWelcome
 			}
-		case "c", "C":
-			// Clear all notifications
-			return m, m.clearAllNotificationsCmd()
-		case "ctrl+r":
-			// Refresh notifications
-			m.notifications.loading = true
-			return m, m.notifications.fetchNotificationsCmd(false)
+			return m, nil
 		}
-		// Delegate other updates to notifications model
 		var cmd tea.Cmd
-		m.notifications, cmd = m.notifications.Update(msg)
+		m.games, cmd = m.games.Update(msg)
 		return m, cmd
 	}
 
 	return m, nil
 }
 
+// openChat joins a chat room
+func (m Model) openChat(room string) (tea.Model, tea.Cmd) {
+	if m.ctx == nil || m.ctx.ChatService == nil {
+		m.message = "Error: chat is not available"
+		return m, nil
+	}
+	if m.screen != screenChat {
+		var session context.Context = context.Background()
+		if m.sshSession != nil {
+			session = m.sshSession.Context()
+		}
+		m.chat = NewChatModel(session, m.ctx.ChatService, m.user)
+		m.chat.width = m.width
+		m.chat.height = m.height
+		m = m.pushScreen(screenChat)
+	}
+	var cmd tea.Cmd
+	m.chat, cmd = m.chat.Open(room)
+	return m, cmd
+}
+
+// loginScreen returns the invite prompt when registration requires an invite,
+// remembering which login screen to continue to
+func (m *Model) loginScreen(next screenType) screenType {
+	m.inviteCode = ""
+	if m.ctx == nil || m.ctx.Config == nil || !m.ctx.Config.Features.Registration.RequireInvite {
+		return next
+	}
+	m.inviteNext = next
+	return screenLoginInvite
+}
+
+// usernameChosenMsg is returned after the onboarding username is saved
+type usernameChosenMsg struct {
+	user *models.User
+	err  error
+}
+
+// chooseUsernameCmd saves the local username picked during onboarding
+func chooseUsernameCmd(ctx *AppContext, userID int, username string) tea.Cmd {
+	return func() tea.Msg {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		user, err := services.NewUserService(ctx.DB).ChooseUsername(bgCtx, userID, username, ctx.Config.Server.BaseURL)
+		return usernameChosenMsg{user: user, err: err}
+	}
+}
+
+// suggestUsername proposes a local username from the Mastodon acct
+func suggestUsername(acct string) string {
+	acct, _, _ = strings.Cut(strings.ToLower(acct), "@")
+	var b strings.Builder
+	for _, r := range acct {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		}
+	}
+	suggestion := b.String()
+	if len(suggestion) > models.MaxUsernameLength {
+		suggestion = suggestion[:models.MaxUsernameLength]
+	}
+	return suggestion
+}
+
 // initiateDeviceFlowCmd starts the OAuth Device Flow
-func initiateDeviceFlowCmd(ctx *AppContext, instance, sessionID string) tea.Cmd {
+func initiateDeviceFlowCmd(ctx *AppContext, instance, sessionID, inviteCode string) tea.Cmd {
 	return func() tea.Msg {
 		// Add timeout to prevent hanging
 		bgCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -685,6 +1791,7 @@ func initiateDeviceFlowCmd(ctx *AppContext, instance, sessionID string) tea.Cmd
 			bgCtx,
 			instance,
 			sessionID,
+			inviteCode,
 		)
 		if err != nil {
 			// Wrap error with more context
@@ -734,11 +1841,11 @@ func loadUserCmd(ctx *AppContext, userID int, publicKey, deviceCode string) tea.
 		err := ctx.DB.QueryRow(
 			context.Background(),
 			`SELECT id, username, email, primary_mastodon_instance,
-			        primary_mastodon_acct, created_at
+			        primary_mastodon_acct, created_at, username_confirmed
 			 FROM users WHERE id = $1`,
 			userID,
 		).Scan(&user.ID, &user.Username, &user.Email, &user.PrimaryMastodonInstance,
-			&user.PrimaryMastodonAcct, &user.CreatedAt)
+			&user.PrimaryMastodonAcct, &user.CreatedAt, &user.UsernameConfirmed)
 
 		if err != nil {
 			fmt.Printf("Failed to load user: %v\n", err)
@@ -760,14 +1867,105 @@ func loadUserCmd(ctx *AppContext, userID int, publicKey, deviceCode string) tea.
 		} else {
 		}
 
-		return authenticatedMsg{user: &user}
+		return authenticatedMsg{user: &user, method: "device flow"}
+	}
+}
+
+// accountDeletedMsg is returned when account deletion completes
+type accountDeletedMsg struct {
+	err error
+}
+
+// deleteAccountCmd deletes the account and notifies followers in the background
+func deleteAccountCmd(accountSvc *services.AccountService, userID int) tea.Cmd {
+	return func() tea.Msg {
+		if err := accountSvc.DeleteAccount(context.Background(), userID); err != nil {
+			return accountDeletedMsg{err: err}
+		}
+
+		// Federation delivery can be slow; don't keep the session waiting
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			defer cancel()
+			if err := accountSvc.DeliverAccountDeletion(ctx, userID); err != nil {
+				fmt.Printf("Failed to deliver account deletion: %v\n", err)
+			}
+		}()
+
+		return accountDeletedMsg{}
+	}
+}
+
+// exportLinkMsg is returned when a data export download link has been created
+type exportLinkMsg struct {
+	url string
+	err error
+}
+
+// createExportLinkCmd creates a one-time download link for the user's data archive
+func createExportLinkCmd(exportSvc *services.ExportService, userID int) tea.Cmd {
+	return func() tea.Msg {
+		url, err := exportSvc.CreateDownloadLink(context.Background(), userID)
+		return exportLinkMsg{url: url, err: err}
+	}
+}
+
+// tokenLoginMsg is returned when a personal access token login completes
+type tokenLoginMsg struct {
+	user *models.User
+	err  error
+}
+
+// loginWithTokenCmd verifies a personal access token, then creates or loads the
+// user and stores the token exactly like the device flow does
+func loginWithTokenCmd(ctx *AppContext, instance, accessToken, inviteCode, publicKey string) tea.Cmd {
+	return func() tea.Msg {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		token, err := ctx.TokenService.VerifyPersonalToken(bgCtx, instance, accessToken)
+		if err != nil {
+			return tokenLoginMsg{err: fmt.Errorf("invalid token for %s", instance)}
+		}
+
+		userService := services.NewUserService(ctx.DB)
+		user, err := userService.GetOrRegisterMastodonUser(bgCtx, token, inviteCode, ctx.Config)
+		if err != nil {
+			return tokenLoginMsg{err: fmt.Errorf("failed to create user account: %w", err)}
+		}
+
+		if err := ctx.TokenService.StoreToken(bgCtx, user.ID, token, true); err != nil {
+			return tokenLoginMsg{err: err}
+		}
+
+		if err := userService.UpdatePrimaryMastodonAccount(bgCtx, user.ID, token.InstanceURL, token.MastodonID, token.Username); err != nil {
+			log.Printf("Failed to update primary mastodon account: %v", err)
+		}
+		user.PrimaryMastodonInstance = token.InstanceURL
+		user.PrimaryMastodonID = token.MastodonID
+		user.PrimaryMastodonAcct = token.Username
+
+		// Associate SSH key with user
+		if publicKey != "" {
+			if _, err := ctx.SSHKeyService.AddSSHKeyToUser(bgCtx, user.ID, publicKey); err != nil {
+				log.Printf("Failed to save SSH key: %v", err)
+			}
+		}
+
+		return tokenLoginMsg{user: user}
 	}
 }
 
 // executePostStatusCmd posts a status to Mastodon
-func executePostStatusCmd(ctx *AppContext, mastodonSvc *services.MastodonService, userID int, content, visibility, replyToID, contentWarning string) tea.Cmd {
+// dropLastRune removes the last character typed into a text field
+func dropLastRune(text string) string {
+	_, size := utf8.DecodeLastRuneInString(text)
+	return text[:len(text)-size]
+}
+
+func executePostStatusCmd(ctx *AppContext, actions StatusActions, userID int, content, visibility, replyToID, contentWarning string) tea.Cmd {
 	return func() tea.Msg {
-		statusID, err := mastodonSvc.PostStatus(
+		statusID, err := actions.PostStatus(
 			context.Background(),
 			userID,
 			content,
@@ -782,8 +1980,57 @@ func executePostStatusCmd(ctx *AppContext, mastodonSvc *services.MastodonService
 	}
 }
 
-// View renders the TUI
+// executeQuoteStatusCmd posts a native quote of another status
+func executeQuoteStatusCmd(mastodonSvc *services.MastodonService, userID int, content, visibility, quoteID, quoteParam string) tea.Cmd {
+	return func() tea.Msg {
+		statusID, err := mastodonSvc.QuoteStatus(context.Background(), userID, content, visibility, quoteID, quoteParam)
+		return postStatusResultMsg{
+			statusID: statusID,
+			err:      err,
+		}
+	}
+}
+
+// View renders the TUI, as plain text in accessibility mode and with ASCII
+// only on terminals without UTF-8
 func (m Model) View() string {
+	var view string
+	if m.locked {
+		// Nothing of the session shows while it is locked
+		view = m.renderLock()
+	} else if m.copyBlock != "" {
+		view = m.renderCopyBlock()
+	} else {
+		view = m.view()
+		if m.palette != nil {
+			view = withPalette(view, m.palette.View(m.width), m.height)
+		}
+		if len(m.writes.pending) > 0 {
+			if m.accessible {
+				// A screen reader reads the message before the screen
+				view = m.renderWrite() + "\n" + view
+			} else {
+				view = overlay(view, m.renderWrite(), m.width, m.height)
+			}
+		}
+	}
+	if m.debug != nil && !m.locked {
+		view = m.withDebug(view)
+	}
+	if m.recording() {
+		view = withRecordingMark(view, m.width)
+	}
+	if m.accessible {
+		view = accessibleView(m.screen, view)
+	}
+	if m.ascii {
+		view = asciiOnly(view)
+	}
+	return view
+}
+
+// view renders the active screen
+func (m Model) view() string {
 	var content string
 	switch m.screen {
 	case screenWelcome:
@@ -792,20 +2039,102 @@ func (m Model) View() string {
 		content = m.renderLoginInstance()
 	case screenLoginWaiting:
 		content = m.renderLoginWaiting()
+	case screenLoginToken:
+		content = m.renderLoginToken()
+	case screenLoginInvite:
+		content = m.renderLoginInvite()
+	case screenChooseUsername:
+		content = m.renderChooseUsername()
+	case screenDeleteAccount:
+		content = m.renderDeleteAccount()
 	case screenAuthenticated:
 		content = m.renderAuthenticated()
 	case screenAnonymous:
 		content = m.renderAnonymous()
 	case screenFeed:
+		if m.menu != nil && m.compact {
+			return m.menu.StackedView(m.width)
+		}
+		if m.menu != nil && m.accessible {
+			// A screen reader reads the menu first rather than over the feed
+			return m.menu.View() + "\n" + m.renderFeed()
+		}
+		if m.menu != nil {
+			return overlay(m.renderFeed(), m.menu.View(), m.width, m.height)
+		}
 		return m.renderFeed() // Feed uses full screen
 	case screenCompose:
 		return m.centerContent(m.compose.View())
 	case screenThread:
+		if m.menu != nil {
+			return overlay(m.thread.View(), m.menu.View(), m.width, m.height)
+		}
 		return m.thread.View()
 	case screenProfile:
 		return m.profile.View()
+	case screenPostDetail:
+		return m.detail.View()
 	case screenNotifications:
 		return m.notifications.View()
+	case screenAPITokens:
+		content = m.apiTokens.View()
+	case screenImportFollows:
+		content = m.followImport.View()
+	case screenEditProfile:
+		content = m.profileEdit.View()
+	case screenReport:
+		content = m.report.View()
+	case screenDiscover:
+		content = m.discover.View()
+	case screenInbox:
+		content = m.inbox.View()
+	case screenActionLog:
+		content = m.actions.View()
+	case screenFollowRequests:
+		content = m.followRequests.View()
+	case screenSearch:
+		content = m.search.View()
+	case screenHashtag:
+		content = m.hashtag.View()
+	case screenDirect:
+		content = m.direct.View()
+	case screenAnnouncements:
+		content = m.announcements.View()
+	case screenWho:
+		content = m.who.View()
+	case screenChat:
+		content = m.chat.View()
+	case screenGames:
+		content = m.games.View()
+	case screenDirectory:
+		content = m.directory.View()
+	case screenWebhooks:
+		content = m.webhooks.View()
+	case screenIntegrations:
+		integrations := m.integrations
+		integrations.width, integrations.height = m.width, m.height
+		content = integrations.View()
+	case screenDigest:
+		digest := m.digest
+		digest.width, digest.height = m.width, m.height
+		content = digest.View()
+	case screenAudit:
+		audit := m.audit
+		audit.width, audit.height = m.width, m.height
+		content = audit.View()
+	case screenQuarantine:
+		quarantine := m.quarantine
+		quarantine.width, quarantine.height = m.width, m.height
+		content = quarantine.View()
+	case screenReader:
+		// Not centered: the reader lays itself out to the screen
+		reader := m.reader
+		reader.width, reader.height = m.width, m.height
+		return reader.View()
+	case screenFeedFilters:
+		feedFilters := m.feedFilters
+		feedFilters.width, feedFilters.height = m.width, m.height
+		content = feedFilters.View()
 	default:
 		// Fallback to welcome screen if unknown state
 		m.screen = screenWelcome
@@ -874,21 +2203,38 @@ func (m Model) renderWelcome() string {
 
 	var b strings.Builder
 
-	width := 60 // Fixed content width
+	width := m.contentWidth()
 
 	// Title
-	title := titleStyle.Render("terminalpub")
 	subtitle := subtleStyle.Render("ActivityPub for terminals")
-	b.WriteString(centerText(title, width) + "\n")
+	b.WriteString(m.renderBanner(width) + "\n")
 	b.WriteString(centerText(subtitle, width) + "\n\n")
 
+	if motd := m.renderMOTD(width); motd != "" {
+		b.WriteString(motd + "\n\n")
+	}
+
 	// Status
 	statusLine := fmt.Sprintf("Connected as: %s", subtleStyle.Render(status))
-	b.WriteString(centerText(statusLine, width) + "\n\n")
+	b.WriteString(centerText(statusLine, width) + "\n")
+	if connected := connectedLine(m.connected); connected != "" {
+		b.WriteString(centerText(subtleStyle.Render(connected), width) + "\n")
+	}
+	b.WriteString("\n")
+
+	if guestbook := m.renderGuestbookPreview(width); guestbook != "" {
+		b.WriteString(guestbook + "\n")
+	}
 
 	// Options
 	b.WriteString(centerText(keyStyle.Render("[L]")+" Login with Mastodon", width) + "\n")
-	b.WriteString(centerText(keyStyle.Render("[A]")+" Continue anonymously", width) + "\n")
+	b.WriteString(centerText(keyStyle.Render("[T]")+" Login with access token", width) + "\n")
+	anonymous := " Continue anonymously"
+	if m.ctx != nil && m.ctx.GuestbookService != nil && m.ctx.GuestbookService.Enabled() {
+		anonymous = " Continue anonymously and sign the guestbook"
+	}
+	b.WriteString(centerText(keyStyle.Render("[A]")+anonymous, width) + "\n")
+	b.WriteString(centerText(keyStyle.Render("[Y]")+m.accessibilityLabel(), width) + "\n")
 	b.WriteString(centerText(keyStyle.Render("[Q]")+" Quit", width) + "\n")
 
 	if m.message != "" {
@@ -907,7 +2253,7 @@ func (m Model) renderWelcome() string {
 
 func (m Model) renderLoginInstance() string {
 	var b strings.Builder
-	width := 60
+	width := m.contentWidth()
 
 	// Title
 	b.WriteString(centerText(titleStyle.Render("Login with Mastodon"), width) + "\n\n")
@@ -930,6 +2276,133 @@ func (m Model) renderLoginInstance() string {
 	return b.String()
 }
 
+func (m Model) renderLoginToken() string {
+	var b strings.Builder
+	width := m.contentWidth()
+
+	// Title
+	b.WriteString(centerText(titleStyle.Render("Login with Access Token"), width) + "\n\n")
+
+	b.WriteString(centerText(subtleStyle.Render("Create one under Preferences > Development on your instance"), width) + "\n\n")
+
+	// Instance field
+	instanceCursor, tokenCursor := "█", ""
+	if m.tokenFocused {
+		instanceCursor, tokenCursor = "", "█"
+	}
+	b.WriteString(centerText("Mastodon instance:", width) + "\n")
+	b.WriteString(centerText(promptStyle.Render("> "+m.input+instanceCursor), width) + "\n\n")
+
+	// Token field (masked)
+	masked := strings.Repeat("*", min(utf8.RuneCountInString(m.tokenInput), 32))
+	b.WriteString(centerText("Access token:", width) + "\n")
+	b.WriteString(centerText(promptStyle.Render("> "+masked+tokenCursor), width) + "\n\n")
+
+	// Instructions
+	b.WriteString(centerText(keyStyle.Render("[Tab]")+" Switch field  "+keyStyle.Render("[Enter]")+" Continue  "+keyStyle.Render("[Esc]")+" Back", width) + "\n")
+
+	if m.message != "" {
+		b.WriteString("\n")
+		msgStyle := subtleStyle
+		if strings.Contains(m.message, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString(centerText(msgStyle.Render(m.message), width) + "\n")
+	}
+
+	return b.String()
+}
+
+func (m Model) renderLoginInvite() string {
+	var b strings.Builder
+	width := m.contentWidth()
+
+	b.WriteString(centerText(titleStyle.Render("Invite Code"), width) + "\n\n")
+
+	b.WriteString(centerText("New accounts need an invite code.", width) + "\n")
+	b.WriteString(centerText(subtleStyle.Render("Already registered? Leave it blank."), width) + "\n\n")
+
+	b.WriteString(centerText(promptStyle.Render("> "+m.inviteCode+"█"), width) + "\n\n")
+
+	b.WriteString(centerText(keyStyle.Render("[Enter]")+" Continue  "+keyStyle.Render("[Esc]")+" Back", width) + "\n")
+
+	return b.String()
+}
+
+func (m Model) renderChooseUsername() string {
+	var b strings.Builder
+	width := m.contentWidth()
+
+	b.WriteString(centerText(titleStyle.Render("Choose a Username"), width) + "\n\n")
+
+	b.WriteString(centerText("Pick your name on this server.", width) + "\n")
+	if m.user != nil && m.user.PrimaryMastodonAcct != "" {
+		linked := m.user.PrimaryMastodonAcct + " on " + strings.TrimPrefix(m.user.PrimaryMastodonInstance, "https://")
+		b.WriteString(centerText(subtleStyle.Render("Linked to "+linked), width) + "\n")
+	}
+	b.WriteString("\n")
+
+	domain := ""
+	if m.ctx != nil && m.ctx.Config != nil {
+		domain = m.ctx.Config.Server.Domain
+	}
+	b.WriteString(centerText(promptStyle.Render("> "+m.input+"█"), width) + "\n")
+	b.WriteString(centerText(subtleStyle.Render("@"+m.input+"@"+domain), width) + "\n\n")
+
+	b.WriteString(centerText(subtleStyle.Render("a-z, 0-9 and _ only; this cannot be changed later"), width) + "\n\n")
+	b.WriteString(centerText(keyStyle.Render("[Enter]")+" Confirm  "+keyStyle.Render("[Ctrl+C]")+" Quit", width) + "\n")
+
+	if m.message != "" {
+		b.WriteString("\n")
+		msgStyle := subtleStyle
+		if strings.Contains(m.message, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString(centerText(msgStyle.Render(m.message), width) + "\n")
+	}
+
+	return b.String()
+}
+
+func (m Model) renderDeleteAccount() string {
+	var b strings.Builder
+	width := m.contentWidth()
+
+	b.WriteString(centerText(errorStyle.Bold(true).Render("Delete Account"), width) + "\n\n")
+
+	b.WriteString(centerText("This will permanently:", width) + "\n")
+	b.WriteString(centerText(subtleStyle.Render("- revoke your Mastodon tokens and API tokens"), width) + "\n")
+	b.WriteString(centerText(subtleStyle.Render("- remove your SSH keys and sessions"), width) + "\n")
+	b.WriteString(centerText(subtleStyle.Render("- notify your followers that this account is gone"), width) + "\n")
+	retention := 30
+	if m.ctx != nil && m.ctx.Config != nil {
+		retention = m.ctx.Config.Features.AccountDeletion.RetentionDays
+	}
+	b.WriteString(centerText(subtleStyle.Render(fmt.Sprintf("- erase your posts and follows after %d days", retention)), width) + "\n\n")
+
+	b.WriteString(centerText(subtleStyle.Render("Consider exporting your data first ([E] on the menu)."), width) + "\n\n")
+
+	username := ""
+	if m.user != nil {
+		username = m.user.Username
+	}
+	b.WriteString(centerText("Type your username to confirm: "+username, width) + "\n")
+	b.WriteString(centerText(promptStyle.Render("> "+m.input+"█"), width) + "\n\n")
+
+	b.WriteString(centerText(keyStyle.Render("[Enter]")+" Delete  "+keyStyle.Render("[Esc]")+" Cancel", width) + "\n")
+
+	if m.message != "" {
+		b.WriteString("\n")
+		msgStyle := subtleStyle
+		if strings.Contains(m.message, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString(centerText(msgStyle.Render(m.message), width) + "\n")
+	}
+
+	return b.String()
+}
+
 func (m Model) renderLoginWaiting() string {
 	if m.deviceAuth == nil {
 		return "Loading..."
@@ -941,7 +2414,7 @@ func (m Model) renderLoginWaiting() string {
 	seconds := int(timeRemaining.Seconds()) % 60
 
 	var b strings.Builder
-	width := 60
+	width := m.contentWidth()
 
 	// Title
 	b.WriteString(centerText(titleStyle.Render("Waiting for Authorization"), width) + "\n\n")
@@ -960,7 +2433,7 @@ func (m Model) renderLoginWaiting() string {
 	expiryText := fmt.Sprintf("Code expires in: %02d:%02d", minutes, seconds)
 	b.WriteString(centerText(subtleStyle.Render(expiryText), width) + "\n\n")
 
-	b.WriteString(centerText(keyStyle.Render("[Esc]")+" Cancel", width) + "\n")
+	b.WriteString(centerText(keyStyle.Render("[G]")+" Play a game while you wait  "+keyStyle.Render("[Esc]")+" Cancel", width) + "\n")
 
 	return b.String()
 }
@@ -971,22 +2444,33 @@ func (m Model) renderAuthenticated() string {
 		username = m.user.Username
 	}
 
+	if m.compact {
+		return m.renderCompactMenu(username, m.menuEntries())
+	}
+
 	var b strings.Builder
-	width := 60
+	width := m.contentWidth()
 
 	// Welcome message
 	welcomeMsg := fmt.Sprintf("Welcome, %s", titleStyle.Render("@"+username))
 	b.WriteString(centerText(welcomeMsg, width) + "\n\n")
 
-	b.WriteString(centerText(subtleStyle.Render("Your SSH key has been associated with your account."), width) + "\n")
-	b.WriteString(centerText(subtleStyle.Render("Next time you connect, you'll be automatically logged in!"), width) + "\n\n")
+	if m.ctx != nil && m.ctx.Demo {
+		b.WriteString(centerText(subtleStyle.Render("This is a demo: the people and posts are made up,"), width) + "\n")
+		b.WriteString(centerText(subtleStyle.Render("and whatever you do is forgotten when you disconnect."), width) + "\n\n")
+	} else {
+		b.WriteString(centerText(subtleStyle.Render("Your SSH key has been associated with your account."), width) + "\n")
+		b.WriteString(centerText(subtleStyle.Render("Next time you connect, you'll be automatically logged in!"), width) + "\n\n")
+	}
+
+	if motd := m.renderMOTD(width); motd != "" {
+		b.WriteString(motd + "\n\n")
+	}
 
 	// Menu options
-	b.WriteString(centerText(keyStyle.Render("[P]")+" Compose new post", width) + "\n")
-	b.WriteString(centerText(keyStyle.Render("[F]")+" View your Mastodon feed", width) + "\n")
-	b.WriteString(centerText(keyStyle.Render("[N]")+" View notifications", width) + "\n")
-	b.WriteString(centerText(keyStyle.Render("[X]")+" Logout", width) + "\n")
-	b.WriteString(centerText(keyStyle.Render("[Q]")+" Quit", width) + "\n")
+	for _, entry := range m.menuEntries() {
+		b.WriteString(centerText(keyStyle.Render("["+entry.key+"]")+" "+entry.label, width) + "\n")
+	}
 
 	if m.message != "" {
 		b.WriteString("\n")
@@ -994,33 +2478,98 @@ func (m Model) renderAuthenticated() string {
 		if strings.Contains(m.message, "Error") {
 			msgStyle = errorStyle
 		}
-		b.WriteString(centerText(msgStyle.Render(m.message), width) + "\n")
+		for _, line := range strings.Split(m.message, "\n") {
+			// Long lines (e.g. URLs) are printed whole rather than truncated
+			if len(line) > width {
+				b.WriteString(msgStyle.Render(line) + "\n")
+				continue
+			}
+			b.WriteString(centerText(msgStyle.Render(line), width) + "\n")
+		}
 	}
 
 	// Bottom line
 	b.WriteString(strings.Repeat("─", m.width) + "\n")
+	b.WriteString(m.renderReleaseNotice(width))
 
 	return b.String()
 }
 
-func (m Model) renderAnonymous() string {
-	var b strings.Builder
+// mainMenuEntries lists the main menu, with unread activity in the
+// notifications and announcements entries
+func (m Model) mainMenuEntries() []mainMenuEntry {
+	notifications := "View notifications"
+	short := "Notifs"
+	if unread := m.unreadMentions + m.unreadDirect; unread > 0 {
+		count := " " + successStyle.Render(fmt.Sprintf("(%d new)", unread))
+		notifications += count
+		short += count
+	}
 
-	b.WriteString(strings.Repeat("─", m.width) + "\n\n")
-	b.WriteString("  Anonymous Mode\n\n")
-	b.WriteString("  You're browsing as: anonymous\n\n")
-	b.WriteString("  Available features:\n")
-	b.WriteString("  • View public feed\n")
-	b.WriteString("  • Browse hashtags\n")
-	b.WriteString("  [Coming soon...]\n\n")
-	b.WriteString("  [B] Back to menu  [Q] Quit\n\n")
+	announcements := "Instance announcements"
+	shortAnnouncements := "News"
+	if m.unreadNotices > 0 {
+		count := " " + successStyle.Render(fmt.Sprintf("(%d new)", m.unreadNotices))
+		announcements += count
+		shortAnnouncements += count
+	}
 
-	if m.message != "" {
-		b.WriteString("  " + m.message + "\n\n")
+	return []mainMenuEntry{
+		{key: "P", label: "Compose new post", short: "Post"},
+		{key: "F", label: "View your Mastodon feed", short: "Feed"},
+		{key: "N", label: notifications, short: short},
+		{key: "M", label: "Inbox: mentions and DMs", short: "Inbox"},
+		{key: "S", label: "Discover people to follow", short: "Discover"},
+		{key: "/", label: "Search this instance", short: "Search"},
+		{key: "H", label: "Local hashtag timeline", short: "Hashtags"},
+		{key: "C", label: "Direct messages", short: "DMs"},
+		{key: "B", label: announcements, short: shortAnnouncements},
+		{key: "W", label: "Who's online", short: "Who"},
+		{key: "J", label: "Directory of people here", short: "Directory"},
+		{key: "L", label: "Chat in the lobby", short: "Chat"},
+		{key: "G", label: "Play a game", short: "Games"},
+		{key: "V", label: "Feed filters", short: "Filters"},
+		{key: "Z", label: "Webhooks", short: "Webhooks"},
+		{key: "K", label: "Browse another instance", short: "Instances"},
+		{key: "A", label: "Activity: undo recent actions", short: "Activity"},
+		{key: "R", label: "Follow requests", short: "Requests"},
+		{key: "U", label: "Edit profile", short: "Profile"},
+		{key: "I", label: "Import follows from CSV", short: "Import"},
+		{key: "E", label: "Export my data", short: "Export"},
+		{key: "T", label: "Manage API tokens", short: "Tokens"},
+		{key: "D", label: "Delete account", short: "Delete"},
+		{key: "Y", label: strings.TrimSpace(m.accessibilityLabel()), short: "Accessibility"},
+		{key: "X", label: "Logout", short: "Logout"},
+		{key: "Q", label: "Quit", short: "Quit"},
 	}
+}
 
-	b.WriteString(strings.Repeat("─", m.width) + "\n")
+// renderAnonymous renders the guestbook anonymous visitors read and sign
+func (m Model) renderAnonymous() string {
+	guestbook := m.guestbook
+	guestbook.width, guestbook.height = m.width, m.height
+
+	var b strings.Builder
+	b.WriteString(subtleStyle.Render("You're browsing as: anonymous") + "\n\n")
+	b.WriteString(guestbook.View())
+	return b.String()
+}
+
+// renderGuestbookPreview renders the newest guestbook entries for the
+// welcome screen, or "" when there are none
+func (m Model) renderGuestbookPreview(width int) string {
+	entries := m.guestbook.Recent(guestbookPreview)
+	if len(entries) == 0 {
+		return ""
+	}
 
+	var b strings.Builder
+	b.WriteString(centerText(subtleStyle.Render("From the guestbook"), width) + "\n")
+	for _, entry := range entries {
+		line := lipgloss.NewStyle().Bold(true).Render(entry.Name) + ": " + entry.Message
+		// Visitors write any script, so center by cells rather than bytes
+		b.WriteString(lipgloss.PlaceHorizontal(width, lipgloss.Center, fitLine(line, width)) + "\n")
+	}
 	return b.String()
 }
 
@@ -1036,11 +2585,14 @@ func (m Model) toggleFollowCmd() tea.Cmd {
 
 		if m.profile.relationship.Following {
 			// Unfollow
-			err = m.mastodonSvc.UnfollowAccount(m.profile.ctx, m.user.ID, m.profile.accountID)
+			err = m.ctx.statusActions().UnfollowAccount(m.profile.ctx, m.user.ID, m.profile.accountID)
 			following = false
 		} else {
 			// Follow
-			err = m.mastodonSvc.FollowAccount(m.profile.ctx, m.user.ID, m.profile.accountID)
+			err = m.ctx.statusActions().FollowAccount(m.profile.ctx, m.user.ID, m.profile.accountID)
+			if err == nil {
+				recordAction(m.actionLog, m.user.ID, models.ActionFollow, m.profile.accountID, "@"+m.profile.account.Acct)
+			}
 			following = true
 		}
 
@@ -1054,7 +2606,7 @@ func (m Model) toggleFollowCmd() tea.Cmd {
 // dismissNotificationCmd dismisses a single notification
 func (m Model) dismissNotificationCmd(notificationID string) tea.Cmd {
 	return func() tea.Msg {
-		err := m.mastodonSvc.DismissNotification(m.notifications.ctx, m.user.ID, notificationID)
+		err := m.ctx.notifier().DismissNotification(m.notifications.ctx, m.user.ID, notificationID)
 		return dismissNotificationMsg{
 			notificationID: notificationID,
 			err:            err,
@@ -1065,7 +2617,7 @@ func (m Model) dismissNotificationCmd(notificationID string) tea.Cmd {
 // clearAllNotificationsCmd clears all notifications
 func (m Model) clearAllNotificationsCmd() tea.Cmd {
 	return func() tea.Msg {
-		err := m.mastodonSvc.ClearAllNotifications(m.notifications.ctx, m.user.ID)
+		err := m.ctx.notifier().ClearAllNotifications(m.notifications.ctx, m.user.ID)
 		if err != nil {
 			return notificationsLoadedMsg{err: err}
 		}
@@ -0,0 +1,25 @@
+package ui
+
+import "testing"
+
+func TestDropLastRune(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"empty", "", ""},
+		{"ascii", "abc", "ab"},
+		{"accented", "café", "caf"},
+		{"emoji", "token🔑", "token"},
+		{"single", "é", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dropLastRune(tt.text); got != tt.want {
+				t.Errorf("dropLastRune(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
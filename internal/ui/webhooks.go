@@ -0,0 +1,458 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// webhookEventLabels describes the events a webhook can subscribe to
+var webhookEventLabels = map[string]string{
+	models.WebhookFollow:         "New follower",
+	models.WebhookMention:        "Mention received",
+	models.WebhookPostFederated:  "Post delivered to followers",
+	models.WebhookDeliveryFailed: "Delivery to a server failed",
+}
+
+// WebhooksModel represents the screen managing a user's webhooks
+type WebhooksModel struct {
+	webhookService *services.WebhookService
+	userID         int
+	webhooks       []models.Webhook
+	admin          bool // May register instance-wide webhooks
+	selectedIndex  int
+	editing        bool // Form shown
+	editID         int  // Webhook being edited, 0 for a new one
+	editEnabled    bool
+	url            textinput.Model
+	events         []string
+	instance       bool
+	focus          int    // Form field with the focus: the URL, an event or the instance switch
+	deleting       bool   // Waiting for the deletion to be confirmed
+	secret         string // Shown once, after creating a webhook or rotating its secret
+	loading        bool
+	statusMessage  string
+	width          int
+	height         int
+}
+
+// webhooksLoadedMsg is sent when the user's webhooks have been fetched
+type webhooksLoadedMsg struct {
+	webhooks []models.Webhook
+	admin    bool
+	err      error
+}
+
+// webhookSavedMsg reports the outcome of saving a webhook
+type webhookSavedMsg struct {
+	webhook *models.Webhook
+	created bool
+	err     error
+}
+
+// webhookDeletedMsg reports the outcome of deleting a webhook
+type webhookDeletedMsg struct {
+	err error
+}
+
+// webhookSecretMsg reports the outcome of rotating a webhook's secret
+type webhookSecretMsg struct {
+	secret string
+	err    error
+}
+
+// webhookPingedMsg reports the outcome of queueing a test payload
+type webhookPingedMsg struct {
+	err error
+}
+
+// NewWebhooksModel creates the webhook screen of a user
+func NewWebhooksModel(webhookService *services.WebhookService, userID int) WebhooksModel {
+	url := textinput.New()
+	url.Prompt = "URL: "
+	url.Placeholder = "https://example.com/hooks/terminalpub"
+	url.CharLimit = 2048
+
+	return WebhooksModel{
+		webhookService: webhookService,
+		userID:         userID,
+		url:            url,
+		loading:        true,
+	}
+}
+
+// Init fetches the user's webhooks
+func (m WebhooksModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// Editing reports whether the form takes the keys
+func (m WebhooksModel) Editing() bool {
+	return m.editing
+}
+
+// Update handles messages for the webhook screen
+func (m WebhooksModel) Update(msg tea.Msg) (WebhooksModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case webhooksLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.webhooks = msg.webhooks
+		m.admin = msg.admin
+		m.selectedIndex = min(m.selectedIndex, max(len(m.webhooks)-1, 0))
+		return m, nil
+
+	case webhookSavedMsg:
+		if msg.err != nil {
+			// The form comes back so the webhook can be corrected
+			m.editing = true
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, m.focusField(m.focus)
+		}
+		m.statusMessage = "Webhook saved"
+		if msg.created {
+			m.secret = msg.webhook.Secret
+		}
+		return m, m.fetchCmd()
+
+	case webhookDeletedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.statusMessage = "Webhook deleted"
+		return m, m.fetchCmd()
+
+	case webhookSecretMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.statusMessage = "Secret rotated: update the receiving end"
+		m.secret = msg.secret
+		return m, nil
+
+	case webhookPingedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.statusMessage = "Test payload queued: check the status in a few seconds (Ctrl+R)"
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.editing {
+			return m.updateForm(msg)
+		}
+		if m.deleting {
+			m.deleting = false
+			if (msg.String() == "y" || msg.String() == "Y") && m.selectedIndex < len(m.webhooks) {
+				m.statusMessage = "Deleting..."
+				return m, m.deleteCmd(m.webhooks[m.selectedIndex].ID)
+			}
+			m.statusMessage = ""
+			return m, nil
+		}
+		// The secret is shown until the next key
+		m.secret = ""
+
+		switch msg.String() {
+		case "up", "k":
+			m.selectedIndex = max(m.selectedIndex-1, 0)
+		case "down", "j":
+			m.selectedIndex = min(m.selectedIndex+1, max(len(m.webhooks)-1, 0))
+		case "n", "N":
+			return m.openForm(models.Webhook{Enabled: true, Events: []string{models.WebhookFollow, models.WebhookMention}})
+		case "ctrl+r":
+			m.loading = true
+			m.statusMessage = ""
+			return m, m.fetchCmd()
+		}
+
+		if m.selectedIndex >= len(m.webhooks) {
+			return m, nil
+		}
+		webhook := m.webhooks[m.selectedIndex]
+		switch msg.String() {
+		case "enter", "e", "E":
+			return m.openForm(webhook)
+		case "d", "D":
+			m.deleting = true
+			m.statusMessage = fmt.Sprintf("Delete the webhook to %s? y/n", webhook.URL)
+		case " ":
+			m.statusMessage = "Saving..."
+			return m, m.saveCmd(webhook.ID, webhook.URL, webhook.Events, webhook.Instance, !webhook.Enabled)
+		case "t", "T":
+			m.statusMessage = "Sending a test payload..."
+			return m, m.pingCmd(webhook.ID)
+		case "r", "R":
+			m.statusMessage = "Rotating the secret..."
+			return m, m.rotateCmd(webhook.ID)
+		}
+	}
+	return m, nil
+}
+
+// formFields returns how many fields the form has: the URL, one switch per
+// event and, for admins, the instance-wide switch
+func (m WebhooksModel) formFields() int {
+	fields := 1 + len(models.WebhookEvents)
+	if m.admin {
+		fields++
+	}
+	return fields
+}
+
+// openForm shows the form filled in with webhook, which is new when its ID
+// is zero
+func (m WebhooksModel) openForm(webhook models.Webhook) (WebhooksModel, tea.Cmd) {
+	m.editing = true
+	m.editID = webhook.ID
+	m.editEnabled = webhook.Enabled
+	m.url.SetValue(webhook.URL)
+	m.events = slices.Clone(webhook.Events)
+	m.instance = webhook.Instance
+	m.statusMessage = ""
+	return m, m.focusField(0)
+}
+
+// focusField moves the focus of the form to field, the URL being 0
+func (m *WebhooksModel) focusField(field int) tea.Cmd {
+	m.focus = field
+	if field == 0 {
+		return m.url.Focus()
+	}
+	m.url.Blur()
+	return nil
+}
+
+// updateForm handles keys while a webhook is edited: Tab moves between the
+// fields, Space switches an event on or off, Enter saves and Esc cancels
+func (m WebhooksModel) updateForm(msg tea.KeyMsg) (WebhooksModel, tea.Cmd) {
+	fields := m.formFields()
+	switch msg.String() {
+	case "esc":
+		m.editing = false
+		m.url.Blur()
+		m.statusMessage = ""
+		return m, nil
+	case "tab", "down":
+		return m, m.focusField((m.focus + 1) % fields)
+	case "shift+tab", "up":
+		return m, m.focusField((m.focus + fields - 1) % fields)
+	case "enter":
+		m.editing = false
+		m.url.Blur()
+		m.statusMessage = "Saving..."
+		return m, m.saveCmd(m.editID, m.url.Value(), m.events, m.instance, m.editEnabled)
+	}
+
+	if m.focus == 0 {
+		var cmd tea.Cmd
+		m.url, cmd = m.url.Update(msg)
+		return m, cmd
+	}
+	if msg.String() != " " && msg.String() != "x" {
+		return m, nil
+	}
+	if m.focus > len(models.WebhookEvents) {
+		m.instance = !m.instance
+		return m, nil
+	}
+	event := models.WebhookEvents[m.focus-1]
+	if i := slices.Index(m.events, event); i >= 0 {
+		m.events = slices.Delete(m.events, i, i+1)
+	} else {
+		m.events = append(m.events, event)
+	}
+	return m, nil
+}
+
+// fetchCmd loads the user's webhooks and whether they may watch the instance
+func (m WebhooksModel) fetchCmd() tea.Cmd {
+	if m.webhookService == nil {
+		return nil
+	}
+	webhookService, userID := m.webhookService, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		webhooks, err := webhookService.List(ctx, userID)
+		if err != nil {
+			return webhooksLoadedMsg{err: err}
+		}
+		admin, err := webhookService.CanWatchInstance(ctx, userID)
+		return webhooksLoadedMsg{webhooks: webhooks, admin: admin, err: err}
+	}
+}
+
+// saveCmd stores a new or edited webhook
+func (m WebhooksModel) saveCmd(id int, url string, events []string, instance, enabled bool) tea.Cmd {
+	webhookService, userID := m.webhookService, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		webhook, err := webhookService.Save(ctx, userID, id, url, events, instance, enabled)
+		return webhookSavedMsg{webhook: webhook, created: id == 0, err: err}
+	}
+}
+
+// deleteCmd removes a webhook
+func (m WebhooksModel) deleteCmd(id int) tea.Cmd {
+	webhookService, userID := m.webhookService, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return webhookDeletedMsg{err: webhookService.Delete(ctx, userID, id)}
+	}
+}
+
+// rotateCmd gives a webhook a new secret
+func (m WebhooksModel) rotateCmd(id int) tea.Cmd {
+	webhookService, userID := m.webhookService, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		secret, err := webhookService.RotateSecret(ctx, userID, id)
+		return webhookSecretMsg{secret: secret, err: err}
+	}
+}
+
+// pingCmd queues a test payload for a webhook
+func (m WebhooksModel) pingCmd(id int) tea.Cmd {
+	webhookService, userID := m.webhookService, m.userID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return webhookPingedMsg{err: webhookService.Ping(ctx, userID, id)}
+	}
+}
+
+// View renders the webhook screen
+func (m WebhooksModel) View() string {
+	var b strings.Builder
+	width := max(min(m.width, 100)-4, 40)
+
+	b.WriteString(titleStyle.Render("Webhooks") + "\n")
+	b.WriteString(subtleStyle.Render("Send signed JSON to your own services when something happens to your account.") + "\n\n")
+
+	if m.editing {
+		return b.String() + m.renderForm(width)
+	}
+
+	switch {
+	case m.loading:
+		b.WriteString(subtleStyle.Render("Loading...") + "\n")
+	case len(m.webhooks) == 0:
+		b.WriteString("No webhooks yet\n")
+	}
+	for i, webhook := range m.webhooks {
+		selector := "  "
+		if i == m.selectedIndex {
+			selector = promptStyle.Render("► ")
+		}
+		state := successStyle.Render("on ")
+		if !webhook.Enabled {
+			state = subtleStyle.Render("off")
+		}
+		scope := ""
+		if webhook.Instance {
+			scope = " [instance]"
+		}
+		b.WriteString(fmt.Sprintf("%s%s %s%s\n", selector, state, truncate(webhook.URL, max(width-20, 20)), scope))
+
+		status := "never delivered"
+		if webhook.LastDeliveryAt != nil {
+			status = fmt.Sprintf("%s %s", webhook.LastStatus, formatAge(time.Since(*webhook.LastDeliveryAt)))
+		}
+		b.WriteString("      " + subtleStyle.Render(truncate(strings.Join(webhook.Events, ", ")+" · "+status, max(width-6, 20))) + "\n")
+	}
+	b.WriteString("\n")
+
+	if m.secret != "" {
+		b.WriteString("Signing secret, shown only now:\n")
+		b.WriteString(promptStyle.Render(m.secret) + "\n\n")
+	}
+
+	b.WriteString(keyStyle.Render("[N]") + " New  " +
+		keyStyle.Render("[Enter]") + " Edit  " +
+		keyStyle.Render("[Space]") + " On/Off  " +
+		keyStyle.Render("[T]") + " Test  " +
+		keyStyle.Render("[R]") + " New secret  " +
+		keyStyle.Render("[D]") + " Delete  " +
+		keyStyle.Render("[Esc]") + " Back\n")
+
+	if m.statusMessage != "" {
+		msgStyle := subtleStyle
+		if strings.HasPrefix(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+	return b.String()
+}
+
+// renderForm renders the form editing a webhook
+func (m WebhooksModel) renderForm(width int) string {
+	var b strings.Builder
+
+	url := m.url
+	url.Width = width - len(url.Prompt) - 1
+	b.WriteString(url.View() + "\n\n")
+
+	b.WriteString("Events:\n")
+	for i, event := range models.WebhookEvents {
+		b.WriteString(m.renderSwitch(i+1, slices.Contains(m.events, event), fmt.Sprintf("%-16s %s", event, webhookEventLabels[event])))
+	}
+	if m.admin {
+		b.WriteString("\n" + m.renderSwitch(len(models.WebhookEvents)+1, m.instance, "Events of every user on the instance"))
+	}
+	b.WriteString("\n")
+
+	b.WriteString(subtleStyle.Render("Payloads carry an HMAC-SHA256 signature made with the webhook's secret") + "\n\n")
+
+	b.WriteString(keyStyle.Render("[Enter]") + " Save  " +
+		keyStyle.Render("[Tab]") + " Field  " +
+		keyStyle.Render("[Space]") + " On/Off  " +
+		keyStyle.Render("[Esc]") + " Cancel\n")
+
+	if m.statusMessage != "" {
+		msgStyle := subtleStyle
+		if strings.HasPrefix(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+	return b.String()
+}
+
+// renderSwitch renders a checkbox of the form, highlighted when focused
+func (m WebhooksModel) renderSwitch(field int, on bool, label string) string {
+	box := "[ ] "
+	if on {
+		box = "[x] "
+	}
+	if m.focus == field {
+		return promptStyle.Render("► "+box+label) + "\n"
+	}
+	return "  " + box + label + "\n"
+}
+
+// openWebhooks shows the screen managing the user's webhooks
+func (m Model) openWebhooks() (Model, tea.Cmd) {
+	m.webhooks = NewWebhooksModel(services.NewWebhookService(m.ctx.DB, m.ctx.Config), m.user.ID)
+	m.webhooks.width = m.width
+	m.webhooks.height = m.height
+	m = m.pushScreen(screenWebhooks)
+	return m, m.webhooks.Init()
+}
@@ -0,0 +1,234 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// WhoModel represents the screen listing who is connected to the instance
+type WhoModel struct {
+	presence      *services.PresenceService
+	userID        int
+	count         int      // Sessions connected, listed or not
+	usernames     []string // Connected users who chose to be listed
+	visible       bool     // Whether the user is listed
+	loading       bool
+	statusMessage string
+	width         int
+	height        int
+}
+
+// whoLoadedMsg is sent when the connected users have been fetched
+type whoLoadedMsg struct {
+	count     int
+	usernames []string
+	visible   bool
+	err       error
+}
+
+// presenceVisibleMsg reports the outcome of changing whether the user is listed
+type presenceVisibleMsg struct {
+	visible bool
+	err     error
+}
+
+// presenceCountMsg carries the number of connected sessions for the welcome
+// screen
+type presenceCountMsg struct {
+	count int
+}
+
+// NewWhoModel creates a new who's online model
+func NewWhoModel(presence *services.PresenceService, userID int) WhoModel {
+	return WhoModel{
+		presence:      presence,
+		userID:        userID,
+		loading:       true,
+		statusMessage: "Loading...",
+	}
+}
+
+// Init fetches the connected users
+func (m WhoModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// Update handles messages for the who's online screen
+func (m WhoModel) Update(msg tea.Msg) (WhoModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case whoLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.count = msg.count
+		m.usernames = msg.usernames
+		m.visible = msg.visible
+		m.statusMessage = ""
+		return m, nil
+
+	case presenceVisibleMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.visible = msg.visible
+		m.statusMessage = "You are now hidden from this list"
+		if msg.visible {
+			m.statusMessage = "You are now listed"
+		}
+		return m, m.fetchCmd()
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+		switch msg.String() {
+		case "v", "V":
+			return m, m.setVisibleCmd(!m.visible)
+		case "ctrl+r":
+			m.loading = true
+			return m, m.fetchCmd()
+		}
+	}
+
+	return m, nil
+}
+
+// fetchCmd loads the session count, the listed users and the user's setting
+func (m WhoModel) fetchCmd() tea.Cmd {
+	presence, userID := m.presence, m.userID
+	return func() tea.Msg {
+		if presence == nil {
+			return whoLoadedMsg{err: fmt.Errorf("presence is not available")}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		count, err := presence.Count(ctx)
+		if err != nil {
+			return whoLoadedMsg{err: err}
+		}
+		usernames, err := presence.Online(ctx)
+		if err != nil {
+			return whoLoadedMsg{err: err}
+		}
+		visible, err := presence.Visible(ctx, userID)
+		return whoLoadedMsg{count: count, usernames: usernames, visible: visible, err: err}
+	}
+}
+
+// setVisibleCmd chooses whether the user is listed
+func (m WhoModel) setVisibleCmd(visible bool) tea.Cmd {
+	presence, userID := m.presence, m.userID
+	return func() tea.Msg {
+		err := presence.SetVisible(context.Background(), userID, visible)
+		return presenceVisibleMsg{visible: visible, err: err}
+	}
+}
+
+// loadPresenceCountCmd counts connected sessions for the welcome screen;
+// failures leave the count out
+func loadPresenceCountCmd(ctx *AppContext) tea.Cmd {
+	if ctx == nil || ctx.PresenceService == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		countCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		count, err := ctx.PresenceService.Count(countCtx)
+		if err != nil {
+			return nil
+		}
+		return presenceCountMsg{count: count}
+	}
+}
+
+// identifyPresenceCmd links the SSH session to the user signed in on it, or
+// unlinks it when userID is 0; presence is best effort, so errors are dropped
+func (m Model) identifyPresenceCmd(userID int) tea.Cmd {
+	if m.ctx == nil || m.ctx.PresenceService == nil || m.sshSession == nil {
+		return nil
+	}
+	presence, sessionID := m.ctx.PresenceService, m.sshSession.Context().SessionID()
+	return func() tea.Msg {
+		_ = presence.Identify(context.Background(), sessionID, userID)
+		return nil
+	}
+}
+
+// connectedLine describes how many people are connected, or "" before the
+// count is known
+func connectedLine(count int) string {
+	switch {
+	case count <= 0:
+		return ""
+	case count == 1:
+		return "1 person connected to this instance"
+	}
+	return fmt.Sprintf("%d people connected to this instance", count)
+}
+
+// View renders the who's online screen
+func (m WhoModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Who's online") + "\n")
+	if m.loading {
+		b.WriteString("\n" + subtleStyle.Render(m.statusMessage) + "\n")
+		return b.String()
+	}
+	b.WriteString(subtleStyle.Render(connectedLine(m.count)) + "\n\n")
+
+	if len(m.usernames) == 0 {
+		b.WriteString("Nobody connected chose to be listed.\n")
+	}
+	// Names are laid out in as many columns as fit
+	columnWidth := 0
+	for _, username := range m.usernames {
+		columnWidth = max(columnWidth, len(username)+3)
+	}
+	columns := max(min(m.width, 100)/max(columnWidth, 1), 1)
+	rows := max(m.height-12, 3)
+	for i, username := range m.usernames {
+		if i == rows*columns {
+			b.WriteString(subtleStyle.Render(fmt.Sprintf("...and %d more", len(m.usernames)-i)) + "\n")
+			break
+		}
+		b.WriteString(fmt.Sprintf("%-*s", columnWidth, "@"+username))
+		if (i+1)%columns == 0 || i == len(m.usernames)-1 {
+			b.WriteString("\n")
+		}
+	}
+	if hidden := m.count - len(m.usernames); hidden > 0 && len(m.usernames) > 0 {
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("and %d other connections", hidden)) + "\n")
+	}
+
+	listed := "You are not listed"
+	toggle := "List me"
+	if m.visible {
+		listed = "You are listed"
+		toggle = "Hide me"
+	}
+	b.WriteString("\n" + subtleStyle.Render(listed) + "\n")
+	b.WriteString(subtleStyle.Render(":write <user> <message> writes to someone's terminal; :mesg y lets others write to you") + "\n")
+	b.WriteString(keyStyle.Render("[V]") + " " + toggle + "  " +
+		keyStyle.Render("[Ctrl+R]") + " Refresh  " +
+		keyStyle.Render("[Esc]") + " Back\n")
+
+	if m.statusMessage != "" {
+		msgStyle := successStyle
+		if strings.Contains(m.statusMessage, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString("\n" + msgStyle.Render(m.statusMessage) + "\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,235 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fulgidus/terminalpub/internal/models"
+	"github.com/fulgidus/terminalpub/internal/services"
+)
+
+// maxPendingWrites bounds the messages waiting to be read, dropping the
+// oldest when someone writes faster than they are read
+const maxPendingWrites = 10
+
+// writeStyle frames messages other users write to the terminal
+var writeStyle = lipgloss.NewStyle().
+	Border(lipgloss.DoubleBorder()).
+	BorderForeground(lipgloss.Color("205")).
+	Padding(0, 1)
+
+// writeInbox holds the messages other users write to the session's
+// terminal, shown one at a time over whatever screen is open
+type writeInbox struct {
+	messages <-chan models.TerminalMessage
+	cancel   context.CancelFunc // Stops listening, on logout
+	pending  []models.TerminalMessage
+}
+
+// writesSubscribedMsg is sent when the session starts listening for
+// messages written to the user
+type writesSubscribedMsg struct {
+	messages <-chan models.TerminalMessage
+	cancel   context.CancelFunc
+}
+
+// terminalMessageMsg carries a message written to the user
+type terminalMessageMsg struct {
+	message  models.TerminalMessage
+	messages <-chan models.TerminalMessage
+}
+
+// writeSentMsg reports the outcome of :write
+type writeSentMsg struct {
+	to   string
+	body string
+	err  error
+}
+
+// acceptWritesMsg reports the user's setting after :mesg
+type acceptWritesMsg struct {
+	accept bool
+	err    error
+}
+
+// subscribeWritesCmd listens for messages written to the signed-in user for
+// as long as the SSH session lasts; without Redis the session just gets none
+func (m Model) subscribeWritesCmd() tea.Cmd {
+	if m.ctx == nil || m.ctx.WriteService == nil || m.user == nil {
+		return nil
+	}
+	var session context.Context = context.Background()
+	if m.sshSession != nil {
+		session = m.sshSession.Context()
+	}
+	writes, userID := m.ctx.WriteService, m.user.ID
+	return func() tea.Msg {
+		ctx, cancel := context.WithCancel(session)
+		messages, err := writes.Subscribe(ctx, userID)
+		if err != nil {
+			cancel()
+			return nil
+		}
+		return writesSubscribedMsg{messages: messages, cancel: cancel}
+	}
+}
+
+// waitForTerminalMessageCmd waits for the next message written to the user
+func waitForTerminalMessageCmd(messages <-chan models.TerminalMessage) tea.Cmd {
+	if messages == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		message, ok := <-messages
+		if !ok {
+			return nil
+		}
+		return terminalMessageMsg{message: message, messages: messages}
+	}
+}
+
+// handleWritesSubscribed starts showing messages written to the user, unless
+// they logged out while the subscription was set up
+func (m Model) handleWritesSubscribed(msg writesSubscribedMsg) (Model, tea.Cmd) {
+	if !m.authenticated || m.user == nil {
+		msg.cancel()
+		return m, nil
+	}
+	m = m.stopWrites()
+	m.writes.messages = msg.messages
+	m.writes.cancel = msg.cancel
+	return m, waitForTerminalMessageCmd(m.writes.messages)
+}
+
+// handleTerminalMessage queues a message written to the user
+func (m Model) handleTerminalMessage(msg terminalMessageMsg) (Model, tea.Cmd) {
+	if msg.messages != m.writes.messages {
+		// From before logging out
+		return m, nil
+	}
+	m = m.showWrite(msg.message)
+	return m, waitForTerminalMessageCmd(m.writes.messages)
+}
+
+// showWrite queues a message to show over the screen
+func (m Model) showWrite(message models.TerminalMessage) Model {
+	pending := append(m.writes.pending, message)
+	m.writes.pending = pending[max(len(pending)-maxPendingWrites, 0):]
+	return m
+}
+
+// stopWrites stops listening for messages and drops those not yet read
+func (m Model) stopWrites() Model {
+	if m.writes.cancel != nil {
+		m.writes.cancel()
+	}
+	m.writes = writeInbox{}
+	return m
+}
+
+// handleWriteKey dismisses the message shown; R answers it from the command
+// line instead
+func (m Model) handleWriteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	message := m.writes.pending[0]
+	m.writes.pending = m.writes.pending[1:]
+	if msg.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+	if (msg.String() == "r" || msg.String() == "R") && message.From != "" && m.authenticated {
+		palette := NewPaletteModel()
+		palette.input.SetValue("write " + message.From + " ")
+		palette.input.CursorEnd()
+		m.palette = &palette
+	}
+	return m, nil
+}
+
+// writeCmd writes a message to another connected user
+func (m Model) writeCmd(to, body string) tea.Cmd {
+	if m.ctx == nil || m.ctx.WriteService == nil {
+		return func() tea.Msg {
+			return writeSentMsg{to: to, body: body, err: fmt.Errorf("messages are not available")}
+		}
+	}
+	writes, userID := m.ctx.WriteService, m.user.ID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := writes.Send(ctx, userID, to, body)
+		return writeSentMsg{to: to, body: body, err: err}
+	}
+}
+
+// handleWriteSent confirms a message was delivered, or reopens the command
+// line with the error so it can be sent again
+func (m Model) handleWriteSent(msg writeSentMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		reason := msg.err.Error()
+		if errors.Is(msg.err, services.ErrWriteRefused) {
+			reason += " (they can turn messages on with :mesg y)"
+		}
+		return m.reopenPalette("write "+msg.to+" "+msg.body, reason)
+	}
+	m = m.showWrite(models.TerminalMessage{Body: "Delivered to @" + strings.TrimPrefix(msg.to, "@"), SentAt: time.Now()})
+	return m, nil
+}
+
+// acceptWritesCmd sets whether the user accepts messages, or only reports
+// the setting when accept is nil
+func (m Model) acceptWritesCmd(accept *bool) tea.Cmd {
+	if m.ctx == nil || m.ctx.WriteService == nil {
+		return nil
+	}
+	writes, userID := m.ctx.WriteService, m.user.ID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if accept != nil {
+			err := writes.SetAccepts(ctx, userID, *accept)
+			return acceptWritesMsg{accept: *accept, err: err}
+		}
+		accepts, err := writes.Accepts(ctx, userID)
+		return acceptWritesMsg{accept: accepts, err: err}
+	}
+}
+
+// handleAcceptWrites tells the user whether others can write to them
+func (m Model) handleAcceptWrites(msg acceptWritesMsg) (Model, tea.Cmd) {
+	body := "Messages are off: nobody can write to you"
+	switch {
+	case msg.err != nil:
+		body = "Error: " + msg.err.Error()
+	case msg.accept:
+		body = "Messages are on: connected users can write to you"
+	}
+	return m.showWrite(models.TerminalMessage{Body: body, SentAt: time.Now()}), nil
+}
+
+// renderWrite renders the oldest unread message for drawing over the screen
+func (m Model) renderWrite() string {
+	message := m.writes.pending[0]
+	width := max(min(m.width-8, 60), 20)
+
+	var b strings.Builder
+	if message.From != "" {
+		b.WriteString(titleStyle.Render("Message from @"+message.From) + " " +
+			subtleStyle.Render(message.SentAt.Local().Format("15:04")) + "\n\n")
+	}
+	b.WriteString(lipgloss.NewStyle().Width(width).Render(message.Body) + "\n\n")
+
+	hint := "Any key to dismiss"
+	if message.From != "" {
+		hint = "R Reply  Any other key to dismiss"
+	}
+	if more := len(m.writes.pending) - 1; more > 0 {
+		hint += fmt.Sprintf("  (%d more)", more)
+	}
+	b.WriteString(subtleStyle.Render(hint))
+
+	return writeStyle.Render(b.String())
+}
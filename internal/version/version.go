@@ -0,0 +1,104 @@
+// Package version identifies the running build. Release builds set the
+// variables with the linker:
+//
+//	go build -ldflags "-X github.com/fulgidus/terminalpub/internal/version.Version=v1.2.0 \
+//	  -X github.com/fulgidus/terminalpub/internal/version.Commit=$(git rev-parse --short HEAD)"
+//
+// Other builds fall back to the commit Go records from version control.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// Set at build time with -ldflags "-X ..."
+var (
+	// Version is the release tag the binary was built from, or "dev"
+	Version = "dev"
+
+	// Commit is the commit the binary was built from
+	Commit = ""
+
+	// Date is when the binary was built
+	Date = ""
+)
+
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if Commit == "" {
+				Commit = setting.Value[:min(len(setting.Value), 7)]
+			}
+		case "vcs.time":
+			if Date == "" {
+				Date = setting.Value
+			}
+		}
+	}
+}
+
+// String describes the build in one line, as --version prints it
+func String() string {
+	s := "terminalpub " + Version
+	if Commit != "" {
+		s += " (" + Commit
+		if Date != "" {
+			s += ", " + Date
+		}
+		s += ")"
+	}
+	return fmt.Sprintf("%s %s/%s", s, runtime.GOOS, runtime.GOARCH)
+}
+
+// IsRelease reports whether the running build is a tagged release, as
+// opposed to a development build that cannot be compared with releases
+func IsRelease() bool {
+	_, ok := parse(Version)
+	return ok
+}
+
+// Newer reports whether release tag is a later version than the running
+// build. Development builds are never behind.
+func Newer(tag string) bool {
+	current, ok := parse(Version)
+	if !ok {
+		return false
+	}
+	candidate, ok := parse(tag)
+	if !ok {
+		return false
+	}
+	for i := range candidate {
+		if candidate[i] != current[i] {
+			return candidate[i] > current[i]
+		}
+	}
+	return false
+}
+
+// parse reads a version such as v1.2.3 or 1.2; pre-releases such as
+// v1.3.0-rc.1 are not releases
+func parse(v string) ([3]int, bool) {
+	var parts [3]int
+	fields := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(fields) > len(parts) {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
@@ -0,0 +1,27 @@
+package version
+
+import "testing"
+
+func TestNewer(t *testing.T) {
+	defer func(v string) { Version = v }(Version)
+
+	tests := []struct {
+		running, tag string
+		newer        bool
+	}{
+		{"v1.2.0", "v1.2.1", true},
+		{"v1.2.0", "v1.10.0", true},
+		{"v1.2.0", "v2.0", true},
+		{"1.2.0", "v1.2.0", false},
+		{"v1.2.0", "v1.1.9", false},
+		{"v1.2.0", "v1.3.0-rc.1", false},
+		{"v1.2.0", "nightly", false},
+		{"dev", "v9.9.9", false},
+	}
+	for _, tt := range tests {
+		Version = tt.running
+		if got := Newer(tt.tag); got != tt.newer {
+			t.Errorf("Newer(%q) running %s = %v, want %v", tt.tag, tt.running, got, tt.newer)
+		}
+	}
+}
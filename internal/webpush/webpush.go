@@ -0,0 +1,237 @@
+// Package webpush sends encrypted Web Push messages (RFC 8030, RFC 8291)
+// authenticated with VAPID (RFC 8292)
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fulgidus/terminalpub/internal/outbound"
+)
+
+// recordSize is the aes128gcm record size; a push message is a single record
+const recordSize = 4096
+
+// tokenLifetime is how long a VAPID token is valid; RFC 8292 caps it at 24 hours
+const tokenLifetime = 12 * time.Hour
+
+// ErrSubscriptionGone is returned when the push service reports that a
+// subscription has expired or been unsubscribed
+var ErrSubscriptionGone = errors.New("push subscription is gone")
+
+// client is shared by all push deliveries
+var client = outbound.NewClient(15 * time.Second)
+
+// Subscription is the endpoint and keys a browser or app hands out when it subscribes
+type Subscription struct {
+	Endpoint string
+	P256dh   string // base64url client public key
+	Auth     string // base64url client auth secret
+}
+
+// GenerateVAPIDKeys generates a P-256 key pair, returning the private key as
+// PKCS#8 PEM and the public key as base64url, the form clients expect
+func GenerateVAPIDKeys() (string, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate VAPID key: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode VAPID key: %w", err)
+	}
+	public, err := key.PublicKey.ECDH()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode VAPID key: %w", err)
+	}
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	return string(privatePEM), base64.RawURLEncoding.EncodeToString(public.Bytes()), nil
+}
+
+// Send encrypts payload for a subscription and POSTs it to the push service.
+// subject identifies the sender to the push service, as a mailto: or https: URL.
+func Send(ctx context.Context, sub Subscription, payload []byte, privateKeyPEM, subject string, ttl time.Duration) error {
+	key, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return err
+	}
+	body, err := encrypt(sub, payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := vapidAuthorization(sub.Endpoint, key, subject)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(ttl.Seconds())))
+	req.Header.Set("Urgency", "normal")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return ErrSubscriptionGone
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("push service returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// encrypt seals payload for the subscription's keys in a single aes128gcm record
+func encrypt(sub Subscription, payload []byte) ([]byte, error) {
+	clientPublic, err := decodeKey(sub.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeKey(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+	clientKey, err := ecdh.P256().NewPublicKey(clientPublic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	shared, err := serverKey.ECDH(clientKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	serverPublic := serverKey.PublicKey().Bytes()
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	ikm, err := hkdf.Key(sha256.New, shared, authSecret, "WebPush: info\x00"+string(clientPublic)+string(serverPublic), 32)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// 0x02 marks the last (and only) record
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	if len(plaintext)+gcm.Overhead() > recordSize {
+		return nil, fmt.Errorf("push payload is too large")
+	}
+
+	header := make([]byte, 0, 16+4+1+len(serverPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(serverPublic)))
+	header = append(header, serverPublic...)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// vapidAuthorization builds the Authorization header identifying the server
+// to the push service behind endpoint
+func vapidAuthorization(endpoint string, key *ecdsa.PrivateKey, subject string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid push endpoint: %w", err)
+	}
+	claims, err := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(tokenLifetime).Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encode := base64.RawURLEncoding.EncodeToString
+	unsigned := encode([]byte(`{"typ":"JWT","alg":"ES256"}`)) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	public, err := key.PublicKey.ECDH()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("vapid t=%s.%s, k=%s", unsigned, encode(signature), encode(public.Bytes())), nil
+}
+
+// parsePrivateKey parses a PEM-encoded P-256 private key
+func parsePrivateKey(pemData string) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse VAPID key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok || key.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("not a P-256 private key")
+	}
+	return key, nil
+}
+
+// decodeKey decodes a client key, which clients send as base64url or
+// standard base64, padded or not
+func decodeKey(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	s = strings.NewReplacer("+", "-", "/", "_").Replace(s)
+	return base64.RawURLEncoding.DecodeString(s)
+}
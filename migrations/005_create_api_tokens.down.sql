@@ -0,0 +1,2 @@
+-- Drop personal API tokens
+DROP TABLE IF EXISTS api_tokens;
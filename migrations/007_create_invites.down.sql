@@ -0,0 +1,5 @@
+-- Drop invite codes
+ALTER TABLE device_codes DROP COLUMN IF EXISTS invite_code;
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
+ALTER TABLE users DROP COLUMN IF EXISTS invite_id;
+DROP TABLE IF EXISTS invites;
@@ -0,0 +1,19 @@
+-- Invite codes for registration when features.registration.require_invite is set
+CREATE TABLE IF NOT EXISTS invites (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    max_uses INTEGER NOT NULL DEFAULT 1,
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_invites_created_by ON invites(created_by);
+
+-- Track which invite created which account, and who may create invites freely
+ALTER TABLE users ADD COLUMN IF NOT EXISTS invite_id INTEGER REFERENCES invites(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Invite code entered in the TUI before starting the device flow
+ALTER TABLE device_codes ADD COLUMN IF NOT EXISTS invite_code VARCHAR(32);
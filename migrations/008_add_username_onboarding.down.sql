@@ -0,0 +1,3 @@
+-- Drop username onboarding
+DROP INDEX IF EXISTS idx_users_mastodon_identity;
+ALTER TABLE users DROP COLUMN IF EXISTS username_confirmed;
@@ -0,0 +1,6 @@
+-- New accounts pick a local username on first login; existing usernames are kept
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username_confirmed BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE users SET username_confirmed = TRUE;
+
+-- Users are found by their Mastodon identity, independent of the local username
+CREATE INDEX IF NOT EXISTS idx_users_mastodon_identity ON users(primary_mastodon_instance, primary_mastodon_id);
@@ -0,0 +1,2 @@
+-- Drop display name
+ALTER TABLE users DROP COLUMN IF EXISTS display_name;
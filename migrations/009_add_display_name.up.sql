@@ -0,0 +1,2 @@
+-- Display name shown on the local actor, editable from the TUI
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name VARCHAR(100);
@@ -0,0 +1,2 @@
+-- Drop offline timeline cache
+DROP TABLE IF EXISTS cached_statuses;
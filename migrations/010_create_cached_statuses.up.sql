@@ -0,0 +1,12 @@
+-- Timeline posts kept per user so the feed can be read while Mastodon is unreachable
+CREATE TABLE IF NOT EXISTS cached_statuses (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    timeline VARCHAR(20) NOT NULL,
+    status_id VARCHAR(64) NOT NULL,
+    status_json JSONB NOT NULL,
+    status_created_at TIMESTAMP NOT NULL,
+    cached_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, timeline, status_id)
+);
+
+CREATE INDEX idx_cached_statuses_timeline ON cached_statuses(user_id, timeline, status_created_at DESC);
@@ -0,0 +1,2 @@
+-- Drop read markers
+DROP TABLE IF EXISTS read_markers;
@@ -0,0 +1,8 @@
+-- Last read post per timeline, kept in sync with Mastodon's markers API
+CREATE TABLE IF NOT EXISTS read_markers (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    timeline VARCHAR(20) NOT NULL,
+    last_read_id VARCHAR(64) NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, timeline)
+);
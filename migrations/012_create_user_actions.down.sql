@@ -0,0 +1,2 @@
+-- Drop action log
+DROP TABLE IF EXISTS user_actions;
@@ -0,0 +1,12 @@
+-- Likes, boosts, follows etc. performed through terminalpub, so they can be undone later
+CREATE TABLE IF NOT EXISTS user_actions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    action VARCHAR(20) NOT NULL,
+    target_id VARCHAR(64) NOT NULL,
+    summary TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    undone_at TIMESTAMP
+);
+
+CREATE INDEX idx_user_actions_user_created ON user_actions(user_id, created_at DESC);
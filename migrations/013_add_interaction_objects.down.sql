@@ -0,0 +1,5 @@
+-- Drop remote object references from likes and boosts
+DROP INDEX IF EXISTS idx_boosts_object_id;
+DROP INDEX IF EXISTS idx_likes_object_id;
+ALTER TABLE boosts DROP COLUMN IF EXISTS object_id;
+ALTER TABLE likes DROP COLUMN IF EXISTS object_id;
@@ -0,0 +1,6 @@
+-- Remote objects liked or boosted by local users, so the activity can be undone
+ALTER TABLE likes ADD COLUMN IF NOT EXISTS object_id VARCHAR(512);
+ALTER TABLE boosts ADD COLUMN IF NOT EXISTS object_id VARCHAR(512);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_likes_object_id ON likes(user_id, actor_id, object_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_boosts_object_id ON boosts(user_id, actor_id, object_id);
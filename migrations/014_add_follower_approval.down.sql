@@ -0,0 +1,4 @@
+-- Drop follower approval
+DROP INDEX IF EXISTS idx_followers_pending;
+ALTER TABLE followers DROP COLUMN IF EXISTS follow_activity_id;
+ALTER TABLE users DROP COLUMN IF EXISTS manually_approves_followers;
@@ -0,0 +1,7 @@
+-- Let users approve followers by hand
+ALTER TABLE users ADD COLUMN IF NOT EXISTS manually_approves_followers BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Follow activity to answer with Accept or Reject
+ALTER TABLE followers ADD COLUMN IF NOT EXISTS follow_activity_id VARCHAR(512);
+
+CREATE INDEX IF NOT EXISTS idx_followers_pending ON followers(user_id) WHERE accepted = FALSE;
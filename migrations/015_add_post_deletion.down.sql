@@ -0,0 +1,3 @@
+-- Drop post deletion tracking
+DROP INDEX IF EXISTS idx_cached_statuses_uri;
+ALTER TABLE posts DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,5 @@
+-- Deleted local posts are kept as tombstones
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Cached remote posts are removed by ActivityPub ID when their server deletes them
+CREATE INDEX IF NOT EXISTS idx_cached_statuses_uri ON cached_statuses((status_json->>'uri'));
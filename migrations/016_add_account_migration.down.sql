@@ -0,0 +1,3 @@
+-- Drop account migration fields
+ALTER TABLE users DROP COLUMN IF EXISTS moved_to;
+ALTER TABLE users DROP COLUMN IF EXISTS also_known_as;
@@ -0,0 +1,3 @@
+-- Account migration: aliases the user has moved from, and the account they moved to
+ALTER TABLE users ADD COLUMN IF NOT EXISTS also_known_as TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS moved_to VARCHAR(512);
@@ -0,0 +1,2 @@
+-- Drop remote actor cache
+DROP TABLE IF EXISTS remote_actors;
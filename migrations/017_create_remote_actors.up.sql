@@ -0,0 +1,14 @@
+-- Remote actor documents cached to avoid refetching them on every use
+CREATE TABLE IF NOT EXISTS remote_actors (
+    actor_id VARCHAR(512) PRIMARY KEY, -- ActivityPub actor URI
+    acct VARCHAR(255), -- user@domain, from WebFinger or the actor document
+    inbox VARCHAR(512),
+    shared_inbox VARCHAR(512),
+    public_key_id VARCHAR(512),
+    public_key_pem TEXT,
+    actor_json JSONB NOT NULL,
+    fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_remote_actors_acct ON remote_actors(acct);
+CREATE INDEX idx_remote_actors_public_key_id ON remote_actors(public_key_id);
@@ -0,0 +1,2 @@
+-- Drop remote object cache
+DROP TABLE IF EXISTS remote_objects;
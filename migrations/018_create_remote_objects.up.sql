@@ -0,0 +1,12 @@
+-- Remote objects fetched on demand, e.g. boosted notes that were never delivered here
+CREATE TABLE IF NOT EXISTS remote_objects (
+    object_id VARCHAR(512) PRIMARY KEY, -- ActivityPub object URI
+    object_type VARCHAR(50) NOT NULL,
+    attributed_to VARCHAR(512) NOT NULL, -- Author actor URI
+    in_reply_to VARCHAR(512),
+    object_json JSONB NOT NULL,
+    fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_remote_objects_attributed_to ON remote_objects(attributed_to);
+CREATE INDEX idx_remote_objects_in_reply_to ON remote_objects(in_reply_to);
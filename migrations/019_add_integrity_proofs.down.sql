@@ -0,0 +1,4 @@
+-- Drop integrity proof keys
+ALTER TABLE activities DROP COLUMN IF EXISTS proof_verified;
+ALTER TABLE users DROP COLUMN IF EXISTS proof_public_key;
+ALTER TABLE users DROP COLUMN IF EXISTS proof_private_key;
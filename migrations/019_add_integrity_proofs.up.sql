@@ -0,0 +1,6 @@
+-- Ed25519 keys for FEP-8b32 object integrity proofs
+ALTER TABLE users ADD COLUMN IF NOT EXISTS proof_private_key TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS proof_public_key VARCHAR(128);
+
+-- Whether an inbound activity carried a valid integrity proof from its actor
+ALTER TABLE activities ADD COLUMN IF NOT EXISTS proof_verified BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+-- Drop relay subscriptions and the federated timeline
+DROP TABLE IF EXISTS federated_timeline;
+DROP TRIGGER IF EXISTS update_relays_updated_at ON relays;
+DROP TABLE IF EXISTS relays;
+DROP TABLE IF EXISTS instance_actor;
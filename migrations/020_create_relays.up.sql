@@ -0,0 +1,31 @@
+-- Signing keys of the server's own actor, which follows relays on behalf of the instance
+CREATE TABLE IF NOT EXISTS instance_actor (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id), -- Single row
+    private_key TEXT NOT NULL,
+    public_key TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Relay subscriptions
+CREATE TABLE IF NOT EXISTS relays (
+    actor_id VARCHAR(512) PRIMARY KEY, -- Relay actor URI
+    inbox VARCHAR(512) NOT NULL,
+    follow_activity_id VARCHAR(512) NOT NULL,
+    state VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, accepted, rejected
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_relays_updated_at
+    BEFORE UPDATE ON relays
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Posts received through relays, shown as the federated timeline to users
+-- without a Mastodon account; contents live in remote_objects
+CREATE TABLE IF NOT EXISTS federated_timeline (
+    id SERIAL PRIMARY KEY,
+    object_id VARCHAR(512) NOT NULL UNIQUE,
+    relay_actor_id VARCHAR(512) NOT NULL,
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+-- Drop timeline indexes
+DROP INDEX IF EXISTS idx_federated_timeline_received_at;
+DROP INDEX IF EXISTS idx_posts_public_timeline;
@@ -0,0 +1,4 @@
+-- Indexes for the server-side local and federated timelines
+CREATE INDEX IF NOT EXISTS idx_posts_public_timeline ON posts(published_at DESC)
+    WHERE deleted_at IS NULL AND visibility = 'public';
+CREATE INDEX IF NOT EXISTS idx_federated_timeline_received_at ON federated_timeline(received_at DESC);
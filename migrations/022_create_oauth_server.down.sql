@@ -0,0 +1,4 @@
+-- Drop client application OAuth tables
+DROP TABLE IF EXISTS oauth_authorization_codes;
+DROP TABLE IF EXISTS oauth_login_codes;
+DROP TABLE IF EXISTS oauth_clients;
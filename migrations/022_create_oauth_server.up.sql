@@ -0,0 +1,30 @@
+-- Client applications registered through the Mastodon-compatible API
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id SERIAL PRIMARY KEY,
+    client_id VARCHAR(64) NOT NULL UNIQUE,
+    client_secret VARCHAR(64) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    website VARCHAR(512),
+    redirect_uris TEXT NOT NULL, -- Newline-separated
+    scopes VARCHAR(255) NOT NULL DEFAULT 'read',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One-time codes a user gets over SSH to log a client application in
+CREATE TABLE IF NOT EXISTS oauth_login_codes (
+    code_hash VARCHAR(64) PRIMARY KEY, -- SHA256 hex of the code
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Authorization codes issued to client applications, exchanged for access tokens
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+    code_hash VARCHAR(64) PRIMARY KEY, -- SHA256 hex of the code
+    client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+-- Drop Web Push tables
+DROP TABLE IF EXISTS push_notifications;
+DROP TRIGGER IF EXISTS update_push_subscriptions_updated_at ON push_subscriptions;
+DROP TABLE IF EXISTS push_subscriptions;
+DROP TABLE IF EXISTS vapid_keys;
@@ -0,0 +1,42 @@
+-- VAPID key pair the server signs Web Push requests with
+CREATE TABLE IF NOT EXISTS vapid_keys (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id), -- Single row
+    private_key TEXT NOT NULL, -- PKCS#8 PEM P-256 key
+    public_key TEXT NOT NULL,  -- base64url uncompressed point, shared with clients
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Web Push subscriptions; Mastodon clients hold one per access token
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_id INTEGER NOT NULL UNIQUE REFERENCES api_tokens(id) ON DELETE CASCADE,
+    endpoint TEXT NOT NULL,
+    p256dh TEXT NOT NULL, -- base64url client public key
+    auth TEXT NOT NULL,   -- base64url client auth secret
+    alert_mention BOOLEAN NOT NULL DEFAULT FALSE,
+    alert_follow BOOLEAN NOT NULL DEFAULT FALSE,
+    policy VARCHAR(20) NOT NULL DEFAULT 'all', -- all, followed, follower, none
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_push_subscriptions_user_id ON push_subscriptions(user_id);
+
+CREATE TRIGGER update_push_subscriptions_updated_at
+    BEFORE UPDATE ON push_subscriptions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Notifications waiting for the worker to push them
+CREATE TABLE IF NOT EXISTS push_notifications (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES push_subscriptions(id) ON DELETE CASCADE,
+    notification_type VARCHAR(20) NOT NULL, -- mention, follow
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_push_notifications_created_at ON push_notifications(created_at);
@@ -0,0 +1,5 @@
+-- Drop full-text search columns
+DROP INDEX IF EXISTS idx_remote_objects_search;
+ALTER TABLE remote_objects DROP COLUMN IF EXISTS search_vector;
+DROP INDEX IF EXISTS idx_posts_search;
+ALTER TABLE posts DROP COLUMN IF EXISTS search_vector;
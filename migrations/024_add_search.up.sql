@@ -0,0 +1,13 @@
+-- Full-text search over local posts and cached remote posts. The 'simple'
+-- configuration does not stem, so it treats every language alike.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('simple', regexp_replace(content, '<[^>]*>', ' ', 'g'))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_posts_search ON posts USING GIN (search_vector);
+
+ALTER TABLE remote_objects ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('simple',
+        COALESCE(object_json->>'summary', '') || ' ' ||
+        regexp_replace(COALESCE(object_json->>'content', ''), '<[^>]*>', ' ', 'g'))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_remote_objects_search ON remote_objects USING GIN (search_vector);
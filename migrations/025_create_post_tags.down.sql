@@ -0,0 +1,2 @@
+-- Drop post hashtags
+DROP TABLE IF EXISTS post_tags;
@@ -0,0 +1,15 @@
+-- Hashtags used in native posts
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    tag VARCHAR(100) NOT NULL, -- Lowercased, without the leading #
+    PRIMARY KEY (post_id, tag)
+);
+
+CREATE INDEX idx_post_tags_tag ON post_tags(tag, post_id);
+
+-- Tag posts written before hashtags were extracted
+INSERT INTO post_tags (post_id, tag)
+SELECT DISTINCT p.id, lower(m[1])
+FROM posts p, regexp_matches(p.content, '(?:^|[^[:alnum:]_&/])#([[:alnum:]_]+)', 'g') AS m
+WHERE length(m[1]) <= 100
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+-- Drop post mentions
+DROP TABLE IF EXISTS post_mentions;
@@ -0,0 +1,10 @@
+-- Actors mentioned in native posts; they are part of the post's audience
+-- whatever its visibility, and the only audience of direct posts
+CREATE TABLE IF NOT EXISTS post_mentions (
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    actor_id VARCHAR(512) NOT NULL, -- ActivityPub actor URI
+    acct VARCHAR(255) NOT NULL, -- e.g., user@domain.com
+    PRIMARY KEY (post_id, actor_id)
+);
+
+CREATE INDEX idx_post_mentions_actor_id ON post_mentions(actor_id);
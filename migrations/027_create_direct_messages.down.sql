@@ -0,0 +1,2 @@
+-- Drop direct messages
+DROP TABLE IF EXISTS direct_messages;
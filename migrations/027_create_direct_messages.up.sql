@@ -0,0 +1,16 @@
+-- Direct posts sent and received by local users, one row per conversation
+-- partner so a post to several recipients shows in each conversation
+CREATE TABLE IF NOT EXISTS direct_messages (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    counterpart_actor_id VARCHAR(512) NOT NULL, -- The other side of the conversation
+    object_id VARCHAR(512) NOT NULL, -- ActivityPub ID of the note
+    post_id INTEGER REFERENCES posts(id) ON DELETE CASCADE, -- Set for messages the user sent
+    direction VARCHAR(10) NOT NULL, -- inbound or outbound
+    content TEXT NOT NULL, -- HTML
+    published_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP, -- NULL for unread inbound messages
+    UNIQUE (user_id, counterpart_actor_id, object_id)
+);
+
+CREATE INDEX idx_direct_messages_conversation ON direct_messages(user_id, counterpart_actor_id, published_at DESC);
@@ -0,0 +1,2 @@
+-- Drop emoji reactions
+DROP TABLE IF EXISTS reactions;
@@ -0,0 +1,16 @@
+-- Emoji reactions (Pleroma/Misskey EmojiReact, or Like with content) sent by
+-- local users and received on local posts
+CREATE TABLE IF NOT EXISTS reactions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE, -- Local user who reacted, if any
+    post_id INTEGER REFERENCES posts(id) ON DELETE CASCADE, -- Local post reacted to, if any
+    actor_id VARCHAR(512) NOT NULL, -- Who reacted
+    object_id VARCHAR(512) NOT NULL, -- ActivityPub ID of the post reacted to
+    emoji VARCHAR(255) NOT NULL, -- Unicode emoji, or :shortcode: for custom emoji
+    ap_id VARCHAR(512) UNIQUE, -- ActivityPub EmojiReact or Like activity URI
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (actor_id, object_id, emoji)
+);
+
+CREATE INDEX idx_reactions_object_id ON reactions(object_id);
+CREATE INDEX idx_reactions_user_id ON reactions(user_id);
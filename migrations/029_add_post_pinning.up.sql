@@ -0,0 +1,4 @@
+-- Pinned posts are featured at the top of their author's profile
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS pinned_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_posts_pinned ON posts(user_id, pinned_at DESC) WHERE pinned_at IS NOT NULL;
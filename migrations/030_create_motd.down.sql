@@ -0,0 +1,3 @@
+-- Drop messages of the day
+DROP TABLE IF EXISTS motd_dismissals;
+DROP TABLE IF EXISTS motd_messages;
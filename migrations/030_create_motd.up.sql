@@ -0,0 +1,16 @@
+-- Messages of the day posted by admins and shown on the welcome screen
+CREATE TABLE IF NOT EXISTS motd_messages (
+    id SERIAL PRIMARY KEY,
+    message TEXT NOT NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Messages each user has dismissed; the one from the config file is keyed by a hash of its text
+CREATE TABLE IF NOT EXISTS motd_dismissals (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    motd_key VARCHAR(64) NOT NULL,
+    dismissed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, motd_key)
+);
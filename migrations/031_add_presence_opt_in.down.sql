@@ -0,0 +1,2 @@
+-- Drop presence opt-in
+ALTER TABLE users DROP COLUMN IF EXISTS show_presence;
@@ -0,0 +1,2 @@
+-- Users choose whether the "who's online" list shows them
+ALTER TABLE users ADD COLUMN IF NOT EXISTS show_presence BOOLEAN NOT NULL DEFAULT FALSE;
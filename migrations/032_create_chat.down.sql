@@ -0,0 +1,4 @@
+-- Drop chat rooms
+DROP TABLE IF EXISTS chat_bans;
+DROP TABLE IF EXISTS chat_messages;
+DROP TABLE IF EXISTS chat_rooms;
@@ -0,0 +1,2 @@
+-- Drop guestbook entries
+DROP TABLE IF EXISTS guestbook_entries;
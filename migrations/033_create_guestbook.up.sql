@@ -0,0 +1,9 @@
+-- Messages anonymous visitors leave on the instance's guestbook
+CREATE TABLE IF NOT EXISTS guestbook_entries (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(32) NOT NULL DEFAULT 'anonymous',
+    message TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_guestbook_entries_created ON guestbook_entries(created_at DESC);
@@ -0,0 +1,2 @@
+-- Drop terminal message consent
+ALTER TABLE users DROP COLUMN IF EXISTS accept_writes;
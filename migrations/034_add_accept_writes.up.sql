@@ -0,0 +1,2 @@
+-- Users choose whether other connected users may write to their terminal
+ALTER TABLE users ADD COLUMN IF NOT EXISTS accept_writes BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+-- Drop feed filters
+DROP TABLE IF EXISTS feed_filters;
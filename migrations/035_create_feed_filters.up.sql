@@ -0,0 +1,11 @@
+-- Named filters users apply to their timelines in the TUI
+CREATE TABLE IF NOT EXISTS feed_filters (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(32) NOT NULL,
+    expression TEXT NOT NULL,
+    action VARCHAR(8) NOT NULL DEFAULT 'hide', -- 'hide' matching posts or 'show' only them
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
@@ -0,0 +1,4 @@
+-- Drop timeline pins
+DELETE FROM cached_statuses WHERE LENGTH(timeline) > 20;
+ALTER TABLE cached_statuses ALTER COLUMN timeline TYPE VARCHAR(20);
+DROP TABLE IF EXISTS timeline_pins;
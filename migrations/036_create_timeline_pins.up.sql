@@ -0,0 +1,11 @@
+-- Timelines users pin to numbered slots of the feed
+CREATE TABLE IF NOT EXISTS timeline_pins (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    slot SMALLINT NOT NULL CHECK (slot BETWEEN 1 AND 9),
+    timeline VARCHAR(255) NOT NULL, -- e.g. 'home', 'tag:rust', 'list:42', 'instance:example.social'
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, slot)
+);
+
+-- Pinned timelines are cached like the others, and their names are longer
+ALTER TABLE cached_statuses ALTER COLUMN timeline TYPE VARCHAR(255);
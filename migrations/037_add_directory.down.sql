@@ -0,0 +1,4 @@
+-- Drop profile directory
+DROP INDEX IF EXISTS idx_users_directory;
+ALTER TABLE users DROP COLUMN IF EXISTS last_active_at;
+ALTER TABLE users DROP COLUMN IF EXISTS discoverable;
@@ -0,0 +1,6 @@
+-- Users choose whether the profile directory lists them; last_active_at
+-- orders it by when they last signed in over SSH
+ALTER TABLE users ADD COLUMN IF NOT EXISTS discoverable BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_active_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_directory ON users(last_active_at DESC NULLS LAST) WHERE discoverable AND deleted_at IS NULL;
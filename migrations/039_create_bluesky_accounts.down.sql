@@ -0,0 +1,2 @@
+-- Drop Bluesky accounts
+DROP TABLE IF EXISTS bluesky_accounts;
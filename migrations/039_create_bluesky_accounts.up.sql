@@ -0,0 +1,12 @@
+-- Bluesky accounts users cross-post to, signed in with an app password
+-- encrypted with the instance's secret key
+CREATE TABLE IF NOT EXISTS bluesky_accounts (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    service VARCHAR(255) NOT NULL,
+    handle VARCHAR(255) NOT NULL,
+    did VARCHAR(255) NOT NULL,
+    app_password TEXT NOT NULL,
+    crosspost BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- Drop Nostr accounts and deliveries
+DROP TABLE IF EXISTS nostr_deliveries;
+DROP TABLE IF EXISTS nostr_accounts;
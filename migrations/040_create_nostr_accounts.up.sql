@@ -0,0 +1,23 @@
+-- Nostr keys users publish notes with, encrypted with the instance's secret
+-- key, and how relays took the notes
+CREATE TABLE IF NOT EXISTS nostr_accounts (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    public_key VARCHAR(64) NOT NULL,
+    secret_key TEXT NOT NULL,
+    relays TEXT[] NOT NULL DEFAULT '{}',
+    crosspost BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS nostr_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_id VARCHAR(64) NOT NULL,
+    relay VARCHAR(512) NOT NULL,
+    accepted BOOLEAN NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_nostr_deliveries_user ON nostr_deliveries(user_id, created_at DESC);
@@ -0,0 +1,2 @@
+-- Drop Matrix bridges
+DROP TABLE IF EXISTS matrix_bridges;
@@ -0,0 +1,19 @@
+-- Matrix rooms users' mentions and direct messages are forwarded to while
+-- they are away, with an access token encrypted with the instance's secret
+-- key and the last notification handled
+CREATE TABLE IF NOT EXISTS matrix_bridges (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    homeserver VARCHAR(255) NOT NULL,
+    matrix_user_id VARCHAR(255) NOT NULL,
+    access_token TEXT NOT NULL,
+    room_id VARCHAR(255) NOT NULL,
+    forward_mentions BOOLEAN NOT NULL DEFAULT TRUE,
+    forward_direct BOOLEAN NOT NULL DEFAULT TRUE,
+    last_notification_id VARCHAR(64) NOT NULL DEFAULT '',
+    last_online_at TIMESTAMP,
+    last_checked_at TIMESTAMP,
+    last_forwarded_at TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+-- Drop email digests
+DROP TABLE IF EXISTS email_digests;
@@ -0,0 +1,13 @@
+-- Email digests users opted into: the address, how often, the token of their
+-- confirmation and unsubscribe links and when the last digest went out
+CREATE TABLE IF NOT EXISTS email_digests (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    frequency VARCHAR(10) NOT NULL DEFAULT 'weekly',
+    token VARCHAR(64) NOT NULL UNIQUE,
+    confirmed BOOLEAN NOT NULL DEFAULT FALSE,
+    last_sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT email_digests_frequency CHECK (frequency IN ('daily', 'weekly'))
+);
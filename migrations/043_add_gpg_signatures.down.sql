@@ -0,0 +1,4 @@
+-- Drop GPG keys and post signatures
+ALTER TABLE posts DROP COLUMN IF EXISTS gpg_signature;
+ALTER TABLE users DROP COLUMN IF EXISTS gpg_fingerprint;
+ALTER TABLE users DROP COLUMN IF EXISTS gpg_public_key;
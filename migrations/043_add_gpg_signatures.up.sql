@@ -0,0 +1,6 @@
+-- OpenPGP public keys users sign their posts with
+ALTER TABLE users ADD COLUMN IF NOT EXISTS gpg_public_key TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS gpg_fingerprint VARCHAR(40);
+
+-- Armored detached signature of a signed post's content
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS gpg_signature TEXT;
@@ -0,0 +1,4 @@
+-- Drop moderation state
+DROP TABLE IF EXISTS rejected_actors;
+ALTER TABLE users DROP COLUMN IF EXISTS silenced_at;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
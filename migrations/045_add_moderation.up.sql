@@ -0,0 +1,10 @@
+-- Moderation: suspended and silenced local users, rejected remote actors
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS silenced_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS rejected_actors (
+    actor_id TEXT PRIMARY KEY,
+    reason TEXT NOT NULL DEFAULT '',
+    rejected_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
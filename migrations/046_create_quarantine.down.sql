@@ -0,0 +1,2 @@
+-- Drop quarantine
+DROP TABLE IF EXISTS quarantined_activities;
@@ -0,0 +1,14 @@
+-- Inbound activities held back as likely spam until an admin reviews them
+CREATE TABLE IF NOT EXISTS quarantined_activities (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE, -- NULL for the shared inbox
+    activity_type VARCHAR(50) NOT NULL,
+    actor_id VARCHAR(512) NOT NULL,
+    object_id VARCHAR(512),
+    activity_json JSONB NOT NULL,
+    proof_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_quarantined_activities_created ON quarantined_activities(created_at DESC);
@@ -0,0 +1,4 @@
+-- Drop collection pagination indexes
+DROP INDEX IF EXISTS idx_following_accepted;
+DROP INDEX IF EXISTS idx_followers_accepted;
+DROP INDEX IF EXISTS idx_posts_outbox;
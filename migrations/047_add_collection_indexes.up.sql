@@ -0,0 +1,5 @@
+-- Indexes for keyset pagination of the outbox, followers and following collections
+CREATE INDEX IF NOT EXISTS idx_posts_outbox ON posts(user_id, id DESC)
+    WHERE deleted_at IS NULL AND visibility IN ('public', 'unlisted');
+CREATE INDEX IF NOT EXISTS idx_followers_accepted ON followers(user_id, id DESC) WHERE accepted = TRUE;
+CREATE INDEX IF NOT EXISTS idx_following_accepted ON following(user_id, id DESC) WHERE accepted = TRUE;
@@ -0,0 +1,9 @@
+-- Drop tuned authentication indexes, restoring the originals
+CREATE INDEX IF NOT EXISTS idx_api_tokens_token_hash ON api_tokens(token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_ssh_keys_public_key ON user_ssh_keys(public_key);
+
+CREATE INDEX IF NOT EXISTS idx_user_ssh_keys_fingerprint ON user_ssh_keys(fingerprint);
+DROP INDEX IF EXISTS idx_user_ssh_keys_fingerprint_user;
+
+CREATE INDEX IF NOT EXISTS idx_mastodon_tokens_primary ON mastodon_tokens(user_id, is_primary) WHERE is_primary = TRUE;
+DROP INDEX IF EXISTS idx_mastodon_tokens_primary_token;
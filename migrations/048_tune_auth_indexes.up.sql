@@ -0,0 +1,13 @@
+-- Indexes for the lookups made on every connection and API request
+-- A covering index answers the primary Mastodon token lookup without reading the table
+CREATE INDEX IF NOT EXISTS idx_mastodon_tokens_primary_token ON mastodon_tokens(user_id)
+    INCLUDE (access_token, instance_url) WHERE is_primary = TRUE;
+DROP INDEX IF EXISTS idx_mastodon_tokens_primary;
+
+-- SSH key lookups join users through user_id
+CREATE INDEX IF NOT EXISTS idx_user_ssh_keys_fingerprint_user ON user_ssh_keys(fingerprint, user_id);
+DROP INDEX IF EXISTS idx_user_ssh_keys_fingerprint;
+
+-- These duplicate the indexes behind UNIQUE constraints and only slow writes
+DROP INDEX IF EXISTS idx_user_ssh_keys_public_key;
+DROP INDEX IF EXISTS idx_api_tokens_token_hash;
@@ -0,0 +1,2 @@
+-- Drop session recordings
+DROP TABLE IF EXISTS session_recordings;
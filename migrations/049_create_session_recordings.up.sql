@@ -0,0 +1,14 @@
+-- Asciinema casts users recorded of their own sessions, kept for download
+-- until they expire
+CREATE TABLE IF NOT EXISTS session_recordings (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL DEFAULT '',
+    cast_data BYTEA NOT NULL,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    truncated BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_recordings_user ON session_recordings(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_session_recordings_created ON session_recordings(created_at);
@@ -0,0 +1,2 @@
+-- Drop blocked domains
+DROP TABLE IF EXISTS blocked_domains;
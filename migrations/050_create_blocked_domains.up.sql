@@ -0,0 +1,6 @@
+-- Remote servers whose actors are refused, set with terminalpub admin domain block
+CREATE TABLE IF NOT EXISTS blocked_domains (
+    domain VARCHAR(255) PRIMARY KEY,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+-- Drop leases
+DROP TABLE IF EXISTS leases;
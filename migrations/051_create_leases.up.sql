@@ -0,0 +1,8 @@
+-- Leases let one of several nodes sharing the database do a job at a time
+CREATE TABLE IF NOT EXISTS leases (
+    name VARCHAR(600) PRIMARY KEY,
+    holder VARCHAR(255) NOT NULL, -- Node ID of the holder
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_leases_expires_at ON leases(expires_at);
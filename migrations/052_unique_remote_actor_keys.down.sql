@@ -0,0 +1,3 @@
+-- Allow a key ID to be cached for several actors again
+DROP INDEX IF EXISTS idx_remote_actors_public_key_id;
+CREATE INDEX IF NOT EXISTS idx_remote_actors_public_key_id ON remote_actors(public_key_id);
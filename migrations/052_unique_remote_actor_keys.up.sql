@@ -0,0 +1,11 @@
+-- A key ID names the key of a single actor; keys claimed by several cached
+-- actors are dropped and refetched from their owners on next use
+UPDATE remote_actors SET public_key_id = NULL, public_key_pem = NULL
+WHERE public_key_id IN (
+    SELECT public_key_id FROM remote_actors
+    WHERE public_key_id IS NOT NULL
+    GROUP BY public_key_id HAVING COUNT(*) > 1
+);
+
+DROP INDEX IF EXISTS idx_remote_actors_public_key_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_remote_actors_public_key_id ON remote_actors(public_key_id);
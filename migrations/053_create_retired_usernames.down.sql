@@ -0,0 +1,2 @@
+-- Drop retired usernames
+DROP TABLE IF EXISTS retired_usernames;
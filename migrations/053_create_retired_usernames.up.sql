@@ -0,0 +1,6 @@
+-- Usernames of purged accounts, kept so that nobody else can register them
+-- and take over the actor URLs remote servers still know
+CREATE TABLE IF NOT EXISTS retired_usernames (
+    username VARCHAR(50) PRIMARY KEY,
+    retired_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- Drop the app of access tokens
+DROP INDEX IF EXISTS idx_api_tokens_client_id;
+ALTER TABLE api_tokens DROP COLUMN IF EXISTS client_id;
@@ -0,0 +1,4 @@
+-- The app an OAuth access token was issued to, which alone may revoke it
+ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS client_id VARCHAR(64) REFERENCES oauth_clients(client_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_client_id ON api_tokens(client_id);